@@ -0,0 +1,273 @@
+// Package bhyve Driver collecting bhyve virtual machines on FreeBSD hosts.
+//
+// Every device under /dev/vmm is a virtual machine, named after it. They are
+// read through bhyvectl, whose statistics cover vCPU run time and resident
+// memory. bhyve exposes no block or network counters. Importing the package
+// registers the driver under the name "bhyve" using DefaultDevDir and
+// DefaultBhyvectl; driver.NewDriver takes the dev_dir and bhyvectl keys. It
+// builds on every platform but only detects on FreeBSD with the vmm module
+// loaded.
+package bhyve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/virtmonitor/driver"
+)
+
+const (
+	// Hypervisor Hypervisor name reported by the bhyve driver
+	Hypervisor driver.DomainHypervisor = "bhyve"
+	// DefaultDevDir Default directory holding one vmm device per VM
+	DefaultDevDir = "/dev/vmm"
+	// DefaultBhyvectl Default bhyvectl command, looked up in PATH
+	DefaultBhyvectl = "bhyvectl"
+)
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New(DefaultDevDir, DefaultBhyvectl)); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		Keys: []string{"dev_dir", "bhyvectl"},
+		New: func(cfg driver.Config) (driver.Driver, error) {
+			return New(cfg.String("dev_dir", DefaultDevDir), cfg.String("bhyvectl", DefaultBhyvectl)), nil
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// Bhyve bhyve driver
+type Bhyve struct {
+	dir      string
+	bhyvectl string
+}
+
+// New Create a bhyve driver for the VMs under the vmm device directory dir,
+// read with the bhyvectl command
+func New(dir, bhyvectl string) *Bhyve {
+	return &Bhyve{dir: dir, bhyvectl: bhyvectl}
+}
+
+// Name Hypervisor name
+func (b *Bhyve) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Capabilities Supported metrics, per vCPU run time and resident memory
+func (b *Bhyve) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:   true,
+		SupportsMemory: true,
+	}
+}
+
+// Detect Test if the vmm device directory and bhyvectl are present
+func (b *Bhyve) Detect() bool {
+	return b.Diagnose().Detected
+}
+
+// Diagnose Tell a host without the vmm module from one without bhyvectl,
+// counting the VMs when both are present
+func (b *Bhyve) Diagnose() driver.DetectResult {
+	if _, err := os.Stat(b.dir); err != nil {
+		return driver.DetectResult{Reason: "no vmm devices at " + b.dir + ", is vmm.ko loaded?", Err: fsError(err)}
+	}
+	if _, err := exec.LookPath(b.bhyvectl); err != nil {
+		return driver.DetectResult{Reason: b.bhyvectl + " not found", Err: fmt.Errorf("bhyve: %w: %w", driver.ErrHypervisorUnavailable, err)}
+	}
+
+	names, err := b.vms()
+	switch {
+	case err != nil:
+		return driver.DetectResult{Detected: true, Reason: "listing VMs failed: " + err.Error()}
+	case len(names) == 0:
+		return driver.DetectResult{Detected: true, Reason: "no VMs under " + b.dir}
+	}
+	return driver.DetectResult{Detected: true, Reason: fmt.Sprintf("%d VMs", len(names))}
+}
+
+// Collect Collect VMs
+func (b *Bhyve) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return b.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect every VM, killing the running bhyvectl commands
+// when ctx is done
+func (b *Bhyve) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	names, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) {
+		names, err := b.vms()
+		if err != nil {
+			return nil, fsError(err)
+		}
+		return names, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.CollectDomains(ctx, opts, names, b.collector(opts, nil))
+}
+
+// CollectInto Collect every VM as CollectContext does into dst, reusing its
+// domains, see driver.CollectInto
+func (b *Bhyve) CollectInto(ctx context.Context, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	names, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) {
+		names, err := b.vms()
+		if err != nil {
+			return nil, fsError(err)
+		}
+		return names, nil
+	})
+	if err != nil {
+		clear(dst)
+		return err
+	}
+
+	return driver.CollectDomainsInto(ctx, opts, names, dst, func(ctx context.Context, name string, r *driver.Recycler) (*driver.Domain, error) {
+		return b.collector(opts, r)(ctx, name)
+	})
+}
+
+// CollectStream Collect every VM as CollectContext does, emitting each as
+// soon as it's collected
+func (b *Bhyve) CollectStream(ctx context.Context, opts driver.CollectOptions, emit func(*driver.Domain) error) error {
+	names, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) {
+		names, err := b.vms()
+		if err != nil {
+			return nil, fsError(err)
+		}
+		return names, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return driver.StreamDomains(ctx, opts, names, b.collector(opts, nil), emit)
+}
+
+// collector Collect function of the VMs listed by CollectContext,
+// CollectInto and CollectStream, filling the domains of r
+func (b *Bhyve) collector(opts driver.CollectOptions, r *driver.Recycler) func(context.Context, string) (*driver.Domain, error) {
+	return func(ctx context.Context, name string) (*driver.Domain, error) {
+		d, err := b.collectVM(ctx, name, opts, r)
+		if err != nil {
+			return nil, &driver.DomainError{ID: vmID(name), Name: name, Err: err}
+		}
+		return d, nil
+	}
+}
+
+// CollectDomain Collect a single VM by ID
+func (b *Bhyve) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	opts = opts.Unfiltered()
+	names, err := b.vms()
+	if err != nil {
+		return nil, fsError(err)
+	}
+
+	for _, name := range names {
+		if vmID(name) == id {
+			return b.collect(name, opts)
+		}
+	}
+	return nil, fmt.Errorf("bhyve: domain %d: %w", id, driver.ErrDomainNotFound)
+}
+
+// CollectDomainByUUID VMs are collected without their UUID, any valid UUID
+// is a miss
+func (b *Bhyve) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	if !driver.ValidUUID(uuid) {
+		return nil, fmt.Errorf("bhyve: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+	return nil, fmt.Errorf("bhyve: domain %s: %w", uuid, driver.ErrDomainNotFound)
+}
+
+// CollectDomainByName Collect a single VM by name
+func (b *Bhyve) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	if filepath.Base(name) != name {
+		return nil, fmt.Errorf("bhyve: domain %q: %w", name, driver.ErrDomainNotFound)
+	}
+	if _, err := os.Stat(filepath.Join(b.dir, name)); err != nil {
+		return nil, fmt.Errorf("bhyve: domain %q: %w", name, driver.ErrDomainNotFound)
+	}
+	opts = opts.Unfiltered()
+	return b.collect(name, opts)
+}
+
+// collect Collect a single VM, a VM gone since it was found isn't found
+func (b *Bhyve) collect(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := b.collectVM(context.Background(), name, opts, nil)
+	if err == nil && d == nil {
+		err = fmt.Errorf("bhyve: domain %q: %w", name, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// CollectSnapshots bhyve has no snapshot listing
+func (b *Bhyve) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	return nil, fmt.Errorf("bhyve: snapshots: %w", driver.ErrNotSupported)
+}
+
+// Host Metrics of the local host, which runs the VMs
+func (b *Bhyve) Host() (*driver.HostInfo, error) {
+	h, err := driver.LocalHostInfo()
+	if err != nil {
+		return nil, err
+	}
+	hostMemory(h)
+	return h, nil
+}
+
+// Ping Test if the vmm device directory is still readable, there is no
+// connection to check
+func (b *Bhyve) Ping(ctx context.Context) error {
+	if _, err := os.ReadDir(b.dir); err != nil {
+		return fsError(err)
+	}
+	return nil
+}
+
+// Watch bhyve has no event source
+func (b *Bhyve) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	return nil, fmt.Errorf("bhyve: watch: %w", driver.ErrNotSupported)
+}
+
+// Close Nothing to release, bhyvectl runs per query
+func (b *Bhyve) Close() error {
+	return nil
+}
+
+// vms Names of the VMs with a vmm device
+func (b *Bhyve) vms() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+// vmID Stable domain ID hashed from the VM name, bhyve VMs are only known
+// by name
+func vmID(name string) driver.DomainID {
+	return driver.HashDomainID(name)
+}
+
+// fsError Wrap a vmm device directory failure with the matching sentinel
+func fsError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("bhyve: %w: %w", driver.ErrPermissionDenied, err)
+	}
+	return fmt.Errorf("bhyve: %w: %w", driver.ErrHypervisorUnavailable, err)
+}
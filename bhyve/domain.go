@@ -0,0 +1,157 @@
+package bhyve
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// vmm statistics read from bhyvectl --get-stats
+const (
+	statRuntime  = "vcpu total runtime"
+	statResident = "Resident memory"
+)
+
+// errVMGone The VM was destroyed while being collected
+var errVMGone = errors.New("bhyve: VM gone")
+
+// collectVM Collect a VM into a domain of r, nil if opts.Filter excludes it
+// or it was destroyed meanwhile
+func (b *Bhyve) collectVM(ctx context.Context, name string, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	id := vmID(name)
+	if !opts.Keep(name, "", id) {
+		return nil, nil
+	}
+	d := r.Domain(id)
+	d.Name, d.ID, d.Hypervisor, d.Time = name, id, Hypervisor, driver.TimestampNow()
+	d.Flags = driver.DomainShutdown
+
+	d, err := b.collectStats(ctx, d, opts)
+	if errors.Is(err, errVMGone) {
+		driver.GetLogger().Debug("skipping destroyed VM", "driver", Hypervisor, "domain", name)
+		return nil, nil
+	}
+	return d, err
+}
+
+func (b *Bhyve) collectStats(ctx context.Context, d *driver.Domain, opts driver.CollectOptions) (*driver.Domain, error) {
+	// A VM without active vCPUs exists but doesn't run
+	out, err := b.run(ctx, d.Name, "--get-active-cpus")
+	if err != nil {
+		return nil, err
+	}
+	active, err := activeCPUs(out)
+	if err != nil {
+		return nil, err
+	}
+	d.VCPUs = active.Count()
+	d.VCPUsCurrent = d.VCPUs
+	if d.VCPUs == 0 {
+		return d, nil
+	}
+	d.Flags = driver.DomainOnline
+
+	if opts.Skips(d.Flags) || !opts.CPUs && !opts.Memory {
+		return d, nil
+	}
+
+	// Run time is counted in TSC ticks
+	freq, ferr := tscFrequency()
+	for _, id := range active.CPUs() {
+		out, err := b.run(ctx, d.Name, "--cpu="+strconv.Itoa(id), "--get-stats")
+		if err != nil {
+			return nil, err
+		}
+		stats := parseStats(out)
+
+		// Memory statistics are only kept by vCPU 0
+		if opts.Memory && id == 0 {
+			if rss, ok := stats[statResident]; ok {
+				d.Memory.RSS, d.Memory.RSSSet = rss, true
+			}
+		}
+		if opts.CPUs {
+			cpu := driver.CPU{ID: uint64(id), Flags: driver.CPUOnline}
+			if ticks, ok := stats[statRuntime]; ok && ferr == nil && freq > 0 {
+				cpu.Time = float64(ticks) / float64(freq) * float64(time.Second)
+			}
+			d.Cpus = append(d.Cpus, cpu)
+		}
+	}
+	if opts.CPUs && ferr != nil {
+		driver.GetLogger().Debug("TSC frequency unknown, vCPU time left zero", "driver", Hypervisor, "error", ferr)
+	}
+
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d, nil
+}
+
+// run Run bhyvectl against a VM
+func (b *Bhyve) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, b.bhyvectl, append([]string{"--vm=" + name}, args...)...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	switch {
+	case err == nil:
+		return out, nil
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case errors.Is(err, exec.ErrNotFound):
+		return nil, fmt.Errorf("bhyve: %w: %w", driver.ErrHypervisorUnavailable, err)
+	}
+
+	msg := strings.TrimSpace(stderr.String())
+	switch {
+	case strings.Contains(msg, "No such file or directory"):
+		return nil, errVMGone
+	case strings.Contains(msg, "Permission denied"), strings.Contains(msg, "Operation not permitted"):
+		return nil, fmt.Errorf("bhyve: %s: %w: %s", name, driver.ErrPermissionDenied, msg)
+	}
+	return nil, fmt.Errorf("bhyve: %s: bhyvectl %s: %w: %s", name, strings.Join(args, " "), err, msg)
+}
+
+// activeCPUs Parse the "active cpus: 0, 1" line of --get-active-cpus
+func activeCPUs(out []byte) (driver.CPUSet, error) {
+	_, list, ok := strings.Cut(string(out), ":")
+	if !ok {
+		return nil, fmt.Errorf("bhyve: malformed active cpus %q", out)
+	}
+	list = strings.TrimSpace(list)
+	if list == "<none>" {
+		return nil, nil
+	}
+	return driver.ParseCPUSet(strings.ReplaceAll(list, " ", ""))
+}
+
+// parseStats Parse --get-stats, one "<description>\t<value>" line per
+// statistic after a "vcpuN stats:" header
+func parseStats(out []byte) map[string]uint64 {
+	stats := make(map[string]uint64)
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		i := strings.LastIndexAny(line, " \t")
+		if i < 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(line[i+1:], 10, 64)
+		if err != nil {
+			continue
+		}
+		stats[strings.TrimSpace(line[:i])] = v
+	}
+	return stats
+}
@@ -0,0 +1,22 @@
+//go:build freebsd
+
+package bhyve
+
+import (
+	"golang.org/x/sys/unix"
+
+	"github.com/virtmonitor/driver"
+)
+
+// tscFrequency TSC ticks per second, the unit of vCPU run time
+func tscFrequency() (uint64, error) {
+	return unix.SysctlUint64("machdep.tsc_freq")
+}
+
+// hostMemory Fill in the physical memory of the host, /proc/meminfo
+// doesn't exist on FreeBSD
+func hostMemory(h *driver.HostInfo) {
+	if total, err := unix.SysctlUint64("hw.physmem"); err == nil {
+		h.MemoryTotal = total
+	}
+}
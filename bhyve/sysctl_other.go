@@ -0,0 +1,17 @@
+//go:build !freebsd
+
+package bhyve
+
+import (
+	"fmt"
+
+	"github.com/virtmonitor/driver"
+)
+
+// tscFrequency Only known on FreeBSD
+func tscFrequency() (uint64, error) {
+	return 0, fmt.Errorf("bhyve: TSC frequency: %w", driver.ErrNotSupported)
+}
+
+// hostMemory Left to LocalHostInfo
+func hostMemory(h *driver.HostInfo) {}
@@ -0,0 +1,41 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+)
+
+// binaryVersion Leading byte of the MarshalBinary encoding, changed when
+// it stops decoding with older versions
+const binaryVersion = 1
+
+// domain Domain without its methods, so gob doesn't recurse into
+// MarshalBinary
+type domain Domain
+
+// MarshalBinary Encode the domain with gob. Driver private state isn't
+// encoded. Every call carries the gob type information, a single
+// gob.Encoder sends it once for a stream of domains.
+func (d Domain) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(binaryVersion)
+	if err := gob.NewEncoder(&buf).Encode(domain(d)); err != nil {
+		return nil, fmt.Errorf("driver: encoding domain %q: %w", d.Name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary Decode a domain encoded by MarshalBinary, replacing d.
+// Driver private state is left unset.
+func (d *Domain) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != binaryVersion {
+		return fmt.Errorf("driver: decoding domain: unsupported encoding")
+	}
+	var v domain
+	if err := gob.NewDecoder(bytes.NewReader(data[1:])).Decode(&v); err != nil {
+		return fmt.Errorf("driver: decoding domain: %w", err)
+	}
+	*d = Domain(v)
+	return nil
+}
@@ -0,0 +1,45 @@
+package driver_test
+
+import (
+	"testing"
+
+	"github.com/virtmonitor/driver"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for name, d := range map[string]*driver.Domain{"full": fullDomain(), "zero": {}} {
+		t.Run(name, func(t *testing.T) {
+			data, err := d.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got driver.Domain
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(d) {
+				t.Errorf("decoded domain isn't Equal: got %+v, want %+v", got, d)
+			}
+			if !got.EqualCounters(d) {
+				t.Errorf("decoded domain counters differ: got %+v, want %+v", got, d)
+			}
+		})
+	}
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	data, err := fullDomain().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := append([]byte{data[0] + 1}, data[1:]...)
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"version":   version,
+		"truncated": data[:len(data)/2],
+	} {
+		if err := new(driver.Domain).UnmarshalBinary(data); err == nil {
+			t.Errorf("%s: decoded", name)
+		}
+	}
+}
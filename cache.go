@@ -0,0 +1,158 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Invalidator Optional interface of drivers caching collections, such as
+// the one returned by WithCache
+type Invalidator interface {
+	// Invalidate drops every cached collection, the next one queries the
+	// underlying driver.
+	Invalidate()
+}
+
+// cacheEntry Collection shared by every caller until it expires. done is
+// closed once domains and err are set.
+type cacheEntry struct {
+	done    chan struct{}
+	domains map[DomainID]*Domain
+	err     error
+	expires time.Time
+}
+
+// cacheDriver Driver memoizing collections for a TTL
+type cacheDriver struct {
+	Driver
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// WithCache Driver serving the last collection of d to every caller until
+// ttl expires. Concurrent misses run a single collection whose result they
+// all share. Collections with different options are cached separately,
+// Concurrency is ignored and Include, Exclude and Filter are applied to the
+// cached result rather than inside the driver. Collections failing as a
+// whole aren't cached, those where only some domains failed are, along with
+// their DomainErrors. Callers get their own copy of the domains. Lookups of
+// a single domain, Host and Watch go straight to d. The result implements
+// Invalidator.
+func WithCache(d Driver, ttl time.Duration) Driver {
+	return &cacheDriver{Driver: d, ttl: ttl, entries: make(map[string]*cacheEntry)}
+}
+
+// Collect Collect domains, served from the cache while it is fresh
+func (c *cacheDriver) Collect(opts CollectOptions) (map[DomainID]*Domain, error) {
+	return c.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect domains, served from the cache while it is fresh.
+// Waiting for a collection started by another caller stops when ctx is done.
+func (c *cacheDriver) CollectContext(ctx context.Context, opts CollectOptions) (map[DomainID]*Domain, error) {
+	if err := opts.CheckPatterns(); err != nil {
+		return nil, err
+	}
+	keep := opts
+	opts = opts.Unfiltered()
+	key := cacheKey(opts)
+
+	for {
+		e, leader := c.entry(key)
+		if leader {
+			c.fill(ctx, key, e, opts)
+		}
+
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// The leader was cancelled, not this caller, collect again
+		if ctx.Err() == nil && (errors.Is(e.err, context.Canceled) || errors.Is(e.err, context.DeadlineExceeded)) {
+			continue
+		}
+		if !cacheable(e.err) {
+			return nil, e.err
+		}
+
+		domains := make(map[DomainID]*Domain, len(e.domains))
+		for id, d := range e.domains {
+			if keep.Keep(d.Name, d.UUID, d.ID) {
+				domains[id] = d.Clone()
+			}
+		}
+		return domains, e.err
+	}
+}
+
+// cacheable Test if a collection failing with err is cached: it succeeded
+// or only some domains failed
+func cacheable(err error) bool {
+	return err == nil || onlyDomainErrors(err)
+}
+
+// cacheKey Key of the collections made with opts, options only affecting
+// how domains are collected are left out
+func cacheKey(opts CollectOptions) string {
+	opts = opts.Unfiltered()
+	opts.Concurrency = 0
+	return fmt.Sprintf("%+v", opts)
+}
+
+// entry Fresh or pending entry for key, creating a pending one if there is
+// none. leader reports whether the caller must fill it.
+func (c *cacheDriver) entry(key string) (e *cacheEntry, leader bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		select {
+		case <-e.done:
+			if cacheable(e.err) && time.Now().Before(e.expires) {
+				return e, false
+			}
+		default:
+			return e, false
+		}
+	}
+
+	e = &cacheEntry{done: make(chan struct{})}
+	c.entries[key] = e
+	return e, true
+}
+
+// fill Run the collection of a pending entry
+func (c *cacheDriver) fill(ctx context.Context, key string, e *cacheEntry, opts CollectOptions) {
+	e.domains, e.err = c.Driver.CollectContext(ctx, opts)
+	e.expires = time.Now().Add(c.ttl)
+
+	if !cacheable(e.err) {
+		c.mu.Lock()
+		if c.entries[key] == e {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+	close(e.done)
+}
+
+// Invalidate Drop every cached collection. Collections still running are
+// shared with their waiters but not cached.
+func (c *cacheDriver) Invalidate() {
+	c.mu.Lock()
+	c.entries = make(map[string]*cacheEntry)
+	c.mu.Unlock()
+}
+
+// Close Drop the cache and close the underlying driver
+func (c *cacheDriver) Close() error {
+	c.Invalidate()
+	return c.Driver.Close()
+}
@@ -0,0 +1,57 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/mock"
+)
+
+// partialDriver Driver collecting one domain and failing another
+type partialDriver struct {
+	*mock.Mock
+	calls atomic.Int32
+}
+
+func (p *partialDriver) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	p.calls.Add(1)
+	return map[driver.DomainID]*driver.Domain{1: {ID: 1, Name: "ok"}},
+		&driver.DomainError{ID: 2, Name: "broken", Err: driver.ErrHypervisorUnavailable}
+}
+
+func TestCachePartialFailure(t *testing.T) {
+	p := &partialDriver{Mock: mock.New()}
+	c := driver.WithCache(p, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		domains, err := c.CollectContext(context.Background(), driver.CollectOptions{})
+		if len(domains) != 1 || domains[1] == nil {
+			t.Fatalf("collection %d: got %d domains, want the one collected", i, len(domains))
+		}
+		if errs := driver.DomainErrors(err); errs[2] == nil {
+			t.Fatalf("collection %d: error %v lacks the failed domain", i, err)
+		}
+	}
+	if n := p.calls.Load(); n != 1 {
+		t.Errorf("underlying driver called %d times, want 1", n)
+	}
+}
+
+func TestCacheFailureNotCached(t *testing.T) {
+	m := mock.New().WithCollectError(driver.ErrHypervisorUnavailable)
+	c := driver.WithCache(m, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		domains, err := c.CollectContext(context.Background(), driver.CollectOptions{})
+		if domains != nil || !errors.Is(err, driver.ErrHypervisorUnavailable) {
+			t.Fatalf("collection %d: got %v, %v", i, domains, err)
+		}
+	}
+	if n := m.Calls(); n != 2 {
+		t.Errorf("underlying driver called %d times, want 2", n)
+	}
+}
@@ -0,0 +1,48 @@
+package driver
+
+// Capabilities Metric categories and features a driver supports. Requesting
+// an unsupported category isn't an error, its fields are just left zero.
+type Capabilities struct {
+	SupportsCPUs          bool `json:"supports_cpus"`
+	SupportsBlocks        bool `json:"supports_blocks"`
+	SupportsInterfaces    bool `json:"supports_interfaces"`
+	SupportsMemory        bool `json:"supports_memory"`
+	SupportsFilesystems   bool `json:"supports_filesystems"`
+	SupportsGraphics      bool `json:"supports_graphics"`
+	SupportsMetadata      bool `json:"supports_metadata"`
+	SupportsHostDevices   bool `json:"supports_host_devices"`
+	SupportsCPUTuning     bool `json:"supports_cpu_tuning"`
+	SupportsNUMA          bool `json:"supports_numa"`
+	SupportsGuestIP       bool `json:"supports_guest_ip"`
+	SupportsBlockCapacity bool `json:"supports_block_capacity"`
+	SupportsBackingChain  bool `json:"supports_backing_chain"`
+	SupportsPinning       bool `json:"supports_pinning"`
+	SupportsLimits        bool `json:"supports_limits"`
+	SupportsSnapshots     bool `json:"supports_snapshots"`
+	SupportsEvents        bool `json:"supports_events"`
+	SupportsVersions      bool `json:"supports_versions"`
+	SupportsDirtyRate     bool `json:"supports_dirty_rate"`
+}
+
+// Options Collect options enabling every supported metric category but
+// DirtyRate, which slows collections down
+func (c Capabilities) Options() CollectOptions {
+	return CollectOptions{
+		CPUs:          c.SupportsCPUs,
+		Pinning:       c.SupportsCPUs && c.SupportsPinning,
+		Blocks:        c.SupportsBlocks,
+		BlockCapacity: c.SupportsBlocks && c.SupportsBlockCapacity,
+		BackingChain:  c.SupportsBlocks && c.SupportsBackingChain,
+		Interfaces:    c.SupportsInterfaces,
+		Addresses:     c.SupportsInterfaces && c.SupportsGuestIP,
+		Limits:        (c.SupportsBlocks || c.SupportsInterfaces) && c.SupportsLimits,
+		Memory:        c.SupportsMemory,
+		Filesystems:   c.SupportsFilesystems,
+		Graphics:      c.SupportsGraphics,
+		Metadata:      c.SupportsMetadata,
+		HostDevices:   c.SupportsHostDevices,
+		CPUTuning:     c.SupportsCPUTuning,
+		NUMA:          c.SupportsNUMA,
+		Versions:      c.SupportsVersions,
+	}
+}
@@ -0,0 +1,69 @@
+package driver
+
+import "net"
+
+// Clone Deep copy of the domain, sharing no slices with the original.
+// The driver-private data is copied shallowly, it belongs to the driver.
+func (d *Domain) Clone() *Domain {
+	if d == nil {
+		return nil
+	}
+
+	c := *d
+	c.Cpus = append([]CPU(nil), d.Cpus...)
+	for i := range c.Cpus {
+		c.Cpus[i].Affinity = append(CPUSet(nil), c.Cpus[i].Affinity...)
+	}
+	c.IOThreads = append([]IOThread(nil), d.IOThreads...)
+	for i := range c.IOThreads {
+		c.IOThreads[i].Affinity = append(CPUSet(nil), c.IOThreads[i].Affinity...)
+	}
+	c.Blocks = append([]BlockDevice(nil), d.Blocks...)
+	for i := range c.Blocks {
+		c.Blocks[i].BackingChain = append([]string(nil), c.Blocks[i].BackingChain...)
+	}
+	c.Filesystems = append([]Filesystem(nil), d.Filesystems...)
+	c.Graphics = append([]GraphicsDevice(nil), d.Graphics...)
+	for i := range c.Graphics {
+		c.Graphics[i].Listen = append(net.IP(nil), c.Graphics[i].Listen...)
+	}
+	c.Consoles = append([]string(nil), d.Consoles...)
+	c.HostDevices = append([]HostDevice(nil), d.HostDevices...)
+	c.NUMA.Nodes = append(CPUSet(nil), d.NUMA.Nodes...)
+	c.NUMA.Cells = append([]NUMACell(nil), d.NUMA.Cells...)
+	for i := range c.NUMA.Cells {
+		c.NUMA.Cells[i].CPUs = append(CPUSet(nil), c.NUMA.Cells[i].CPUs...)
+		c.NUMA.Cells[i].Nodes = append(CPUSet(nil), c.NUMA.Cells[i].Nodes...)
+	}
+	if d.Labels != nil {
+		c.Labels = make(map[string]string, len(d.Labels))
+		for k, v := range d.Labels {
+			c.Labels[k] = v
+		}
+	}
+
+	if d.Interfaces != nil {
+		c.Interfaces = make([]NetworkInterface, len(d.Interfaces))
+		for i, iface := range d.Interfaces {
+			c.Interfaces[i] = iface.clone()
+		}
+	}
+	return &c
+}
+
+func (n NetworkInterface) clone() NetworkInterface {
+	n.Mac = append(net.HardwareAddr(nil), n.Mac...)
+	n.Bridges = append([]string(nil), n.Bridges...)
+
+	if n.Addresses != nil {
+		addrs := make([]net.IPNet, len(n.Addresses))
+		for i, a := range n.Addresses {
+			addrs[i] = net.IPNet{
+				IP:   append(net.IP(nil), a.IP...),
+				Mask: append(net.IPMask(nil), a.Mask...),
+			}
+		}
+		n.Addresses = addrs
+	}
+	return n
+}
@@ -0,0 +1,61 @@
+package driver_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/virtmonitor/driver"
+)
+
+// mutate Change every exported value reachable from v through structs,
+// slices, maps and pointers
+func mutate(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			mutate(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				mutate(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			mutate(v.Index(i))
+		}
+	case reflect.Map:
+		v.SetMapIndex(reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem())
+	case reflect.Bool:
+		v.SetBool(!v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(v.Uint() + 1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(v.Float() + 1)
+	case reflect.String:
+		v.SetString(v.String() + "-mutated")
+	}
+}
+
+func TestClone(t *testing.T) {
+	d := fullDomain()
+	c := d.Clone()
+	if !reflect.DeepEqual(c, d) {
+		t.Fatalf("Clone() = %+v, want %+v", c, d)
+	}
+
+	mutate(reflect.ValueOf(c))
+	if reflect.DeepEqual(c, d) {
+		t.Fatal("mutating the clone left it unchanged")
+	}
+	if want := fullDomain(); !reflect.DeepEqual(d, want) {
+		t.Errorf("mutating the clone changed the original: got %+v, want %+v", d, want)
+	}
+
+	if (*driver.Domain)(nil).Clone() != nil {
+		t.Error("Clone of a nil domain isn't nil")
+	}
+}
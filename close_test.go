@@ -0,0 +1,42 @@
+package driver_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/mock"
+)
+
+func TestCloseError(t *testing.T) {
+	errClose := errors.New("socket close failed")
+	wrappers := []struct {
+		name string
+		wrap func(driver.Driver) driver.Driver
+	}{
+		{"mock", func(d driver.Driver) driver.Driver { return d }},
+		{"WithCache", func(d driver.Driver) driver.Driver { return driver.WithCache(d, time.Minute) }},
+		{"WithSlowInterval", driver.WithSlowInterval},
+		{"WithHealth", driver.WithHealth},
+		{"Serialize", driver.Serialize},
+		{"WithReconnect", func(d driver.Driver) driver.Driver {
+			return driver.WithReconnect(func() (driver.Driver, error) { return d, nil }, driver.BackoffPolicy{})
+		}},
+	}
+	for _, w := range wrappers {
+		t.Run(w.name, func(t *testing.T) {
+			m := mock.New().WithCloseError(errClose)
+			if err := w.wrap(m).Close(); !errors.Is(err, errClose) {
+				t.Errorf("Close() = %v, want %v", err, errClose)
+			}
+			if !m.Closed() {
+				t.Error("underlying driver not closed")
+			}
+		})
+	}
+
+	if err := mock.New().Close(); err != nil {
+		t.Errorf("Close() = %v without a close error", err)
+	}
+}
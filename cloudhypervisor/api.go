@@ -0,0 +1,167 @@
+package cloudhypervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/virtmonitor/driver"
+)
+
+// apiPrefix Path prefix of the API endpoints
+const apiPrefix = "/api/v1/"
+
+// vmmPing Response of vmm.ping
+type vmmPing struct {
+	BuildVersion string `json:"build_version"`
+	Version      string `json:"version"`
+	PID          int    `json:"pid"`
+}
+
+type cpusConfig struct {
+	BootVCPUs int `json:"boot_vcpus"`
+	MaxVCPUs  int `json:"max_vcpus"`
+}
+
+type memoryConfig struct {
+	Size         uint64 `json:"size"`
+	HotplugSize  uint64 `json:"hotplug_size"`
+	Hugepages    bool   `json:"hugepages"`
+	HugepageSize uint64 `json:"hugepage_size"`
+}
+
+type diskConfig struct {
+	ID       string `json:"id"`
+	Path     string `json:"path"`
+	Readonly bool   `json:"readonly"`
+}
+
+type netConfig struct {
+	ID  string `json:"id"`
+	Tap string `json:"tap"`
+	MAC string `json:"mac"`
+}
+
+type balloonConfig struct {
+	Size uint64 `json:"size"`
+}
+
+type platformConfig struct {
+	UUID string `json:"uuid"`
+}
+
+// vmConfig Configuration of the VM, sizes in bytes. Device IDs are assigned
+// by the VMM when not given (e.g. _disk0, _net1).
+type vmConfig struct {
+	CPUs     cpusConfig      `json:"cpus"`
+	Memory   memoryConfig    `json:"memory"`
+	Disks    []diskConfig    `json:"disks"`
+	Net      []netConfig     `json:"net"`
+	Balloon  *balloonConfig  `json:"balloon"`
+	Platform *platformConfig `json:"platform"`
+}
+
+// vmInfo Response of vm.info
+type vmInfo struct {
+	Config           vmConfig `json:"config"`
+	State            string   `json:"state"`
+	MemoryActualSize uint64   `json:"memory_actual_size"`
+}
+
+// counters Response of vm.counters, counters by name of every device by ID
+type counters map[string]map[string]uint64
+
+// apiError The API answered with an error status
+type apiError struct {
+	Path   string
+	Status int
+	Fault  string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("cloudhypervisor: GET %s: %d %s", e.Path, e.Status, e.Fault)
+}
+
+// Unwrap Map the status onto a driver sentinel
+func (e *apiError) Unwrap() error {
+	if e.Status == http.StatusNotFound || e.Status == http.StatusNotImplemented {
+		return driver.ErrNotSupported
+	}
+	return nil
+}
+
+// notCreated Test if err means the VMM has no VM, answered as an internal
+// error by vm.info and vm.counters
+func notCreated(err error) bool {
+	var aerr *apiError
+	return errors.As(err, &aerr) && strings.Contains(strings.ToLower(aerr.Fault), "not created")
+}
+
+// client HTTP client of the API socket of one VMM
+type client struct {
+	http *http.Client
+}
+
+func newClient(path string) *client {
+	return &client{http: &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+		MaxIdleConns: 1,
+	}}}
+}
+
+// get Decode the response to GET of the endpoint into out
+func (c *client) get(ctx context.Context, endpoint string, out interface{}) error {
+	path := apiPrefix + endpoint
+	// The host is ignored, requests go over the socket
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost"+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		// Errors are plain text, or a JSON string from recent versions
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		fault := strings.TrimSpace(string(b))
+		var s string
+		if json.Unmarshal(b, &s) == nil {
+			fault = s
+		}
+		return &apiError{Path: path, Status: resp.StatusCode, Fault: fault}
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("cloudhypervisor: GET %s: %w", path, err)
+	}
+	return nil
+}
+
+// close Drop the idle connection
+func (c *client) close() {
+	c.http.CloseIdleConnections()
+}
+
+// connError Wrap a failure to connect to or talk over an API socket
+func connError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("cloudhypervisor: %w: %w", driver.ErrPermissionDenied, err)
+	}
+	return fmt.Errorf("cloudhypervisor: %w: %w", driver.ErrHypervisorUnavailable, err)
+}
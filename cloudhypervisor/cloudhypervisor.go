@@ -0,0 +1,357 @@
+// Package cloudhypervisor Driver collecting Cloud Hypervisor VMs through their
+// REST API sockets.
+//
+// Every socket matching the configured pattern in the configured directory is
+// the API socket (--api-socket) of one VMM running a single VM. The VM is
+// described by vm.info and its device counters come from vm.counters, keyed
+// by the device IDs vm.info reports. Cloud Hypervisor has no per vCPU
+// statistics, CPUs are limited to their boot and maximum counts, and no
+// guest memory statistics, memory is limited to the balloon. Block devices
+// report operation and byte counts, interfaces bytes and frames. The API
+// socket path is kept as the private data of each domain (see
+// driver.Private), to call the endpoints the driver doesn't. Importing
+// the package registers the driver under the name "cloudhypervisor" using
+// DefaultDirectory and DefaultPattern; driver.NewDriver takes the directory
+// and pattern keys.
+package cloudhypervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/internal/hvutil"
+)
+
+const (
+	// Hypervisor Hypervisor name reported by the Cloud Hypervisor driver
+	Hypervisor driver.DomainHypervisor = "cloudhypervisor"
+	// DefaultDirectory Default directory scanned for API sockets
+	DefaultDirectory = "/run/cloud-hypervisor"
+	// DefaultPattern Default glob matching API sockets in the directory, one
+	// directory per VM
+	DefaultPattern = "*/api.sock"
+)
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New(DefaultDirectory, DefaultPattern)); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		Keys: []string{"directory", "pattern"},
+		New:  fromConfig,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// fromConfig Create a Cloud Hypervisor driver from the directory and pattern keys
+// of a driver.Config, a malformed pattern is an error
+func fromConfig(cfg driver.Config) (driver.Driver, error) {
+	pattern := cfg.String("pattern", DefaultPattern)
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("%w: pattern %q: %w", driver.ErrInvalidConfig, pattern, err)
+	}
+	return New(cfg.String("directory", DefaultDirectory), pattern), nil
+}
+
+// CloudHypervisor Cloud Hypervisor driver
+type CloudHypervisor struct {
+	dir     string
+	pattern string
+
+	mu      sync.Mutex
+	clients map[string]*client
+}
+
+// New Create a Cloud Hypervisor driver scanning dir for API sockets matching
+// the glob pattern
+func New(dir, pattern string) *CloudHypervisor {
+	return &CloudHypervisor{
+		dir:     dir,
+		pattern: pattern,
+		clients: make(map[string]*client),
+	}
+}
+
+// Name Hypervisor name
+func (c *CloudHypervisor) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Capabilities Supported metrics, see the package documentation for what
+// each covers
+func (c *CloudHypervisor) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:          true,
+		SupportsBlocks:        true,
+		SupportsInterfaces:    true,
+		SupportsMemory:        true,
+		SupportsBlockCapacity: true,
+	}
+}
+
+// Detect Test if any API sockets are present
+func (c *CloudHypervisor) Detect() bool {
+	return c.Diagnose().Detected
+}
+
+// Diagnose Report the API sockets found. Sockets aren't connected to, a
+// stale one left by an exited VMM still counts.
+func (c *CloudHypervisor) Diagnose() driver.DetectResult {
+	where := filepath.Join(c.dir, c.pattern)
+	sockets, err := c.sockets()
+	switch {
+	case err != nil:
+		return driver.DetectResult{Reason: "listing " + where + " failed: " + err.Error(), Err: err}
+	case len(sockets) == 0:
+		return driver.DetectResult{
+			Reason: "no socket found matching " + where,
+			Err:    fmt.Errorf("cloudhypervisor: no API sockets: %w", driver.ErrHypervisorUnavailable),
+		}
+	}
+	return driver.DetectResult{Detected: true, Reason: fmt.Sprintf("%d API sockets", len(sockets))}
+}
+
+// Collect Collect VMs
+func (c *CloudHypervisor) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return c.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect VMs from every socket concurrently
+func (c *CloudHypervisor) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	sockets, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) { return c.sockets() })
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.CollectDomains(ctx, opts, sockets, c.collector(opts, nil))
+}
+
+// CollectInto Collect VMs as CollectContext does into dst, reusing its
+// domains, see driver.CollectInto
+func (c *CloudHypervisor) CollectInto(ctx context.Context, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	sockets, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) { return c.sockets() })
+	if err != nil {
+		clear(dst)
+		return err
+	}
+
+	return driver.CollectDomainsInto(ctx, opts, sockets, dst, func(ctx context.Context, path string, r *driver.Recycler) (*driver.Domain, error) {
+		return c.collector(opts, r)(ctx, path)
+	})
+}
+
+// CollectStream Collect VMs as CollectContext does, emitting each as soon as
+// it's collected
+func (c *CloudHypervisor) CollectStream(ctx context.Context, opts driver.CollectOptions, emit func(*driver.Domain) error) error {
+	sockets, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) { return c.sockets() })
+	if err != nil {
+		return err
+	}
+
+	return driver.StreamDomains(ctx, opts, sockets, c.collector(opts, nil), emit)
+}
+
+// collector Collect function of the sockets listed by CollectContext,
+// CollectInto and CollectStream, filling the domains of r
+func (c *CloudHypervisor) collector(opts driver.CollectOptions, r *driver.Recycler) func(context.Context, string) (*driver.Domain, error) {
+	return func(ctx context.Context, path string) (*driver.Domain, error) {
+		d, err := c.collectSocket(ctx, path, opts, r)
+		if err != nil {
+			return nil, &driver.DomainError{ID: socketID(path), Name: socketName(path), Err: err}
+		}
+		return d, nil
+	}
+}
+
+// CollectDomain Collect a single VM by ID
+func (c *CloudHypervisor) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := c.find(context.Background(), opts, func(d *driver.Domain) bool { return d.ID == id })
+	if err == nil && d == nil {
+		err = fmt.Errorf("cloudhypervisor: domain %d: %w", id, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// CollectDomainByUUID Collect a single VM by the platform UUID it was
+// started with
+func (c *CloudHypervisor) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	u, err := driver.NormalizeUUID(uuid)
+	if err != nil {
+		return nil, fmt.Errorf("cloudhypervisor: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+	d, err := c.find(context.Background(), opts, func(d *driver.Domain) bool { return d.UUID == u })
+	if err == nil && d == nil {
+		err = fmt.Errorf("cloudhypervisor: domain %s: %w", uuid, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// CollectDomainByName Collect a single VM by name, scanning every socket
+func (c *CloudHypervisor) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := c.find(context.Background(), opts, func(d *driver.Domain) bool { return d.Name == name })
+	if err == nil && d == nil {
+		err = fmt.Errorf("cloudhypervisor: domain %q: %w", name, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// find Collect the first VM accepted by match, or nil if none is. Every
+// socket is queried for its identity before the matching one is collected
+// in full.
+func (c *CloudHypervisor) find(ctx context.Context, opts driver.CollectOptions, match func(*driver.Domain) bool) (*driver.Domain, error) {
+	opts = opts.Unfiltered()
+	sockets, err := c.sockets()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range sockets {
+		d, err := c.collectSocket(ctx, path, driver.CollectOptions{}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if d != nil && match(d) {
+			return c.collectSocket(ctx, path, opts, nil)
+		}
+	}
+	return nil, nil
+}
+
+// CollectSnapshots Cloud Hypervisor snapshots are directories written on
+// request, not tracked by the VMM
+func (c *CloudHypervisor) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	return nil, fmt.Errorf("cloudhypervisor: snapshots: %w", driver.ErrNotSupported)
+}
+
+// Host Metrics of the local host, where the API sockets live
+func (c *CloudHypervisor) Host() (*driver.HostInfo, error) {
+	return driver.LocalHostInfo()
+}
+
+// Ping Ping every VMM, joining the failures. Stale sockets are skipped as in
+// collections.
+func (c *CloudHypervisor) Ping(ctx context.Context) error {
+	sockets, err := c.sockets()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, path := range sockets {
+		var ping vmmPing
+		if err := c.client(path).get(ctx, "vmm.ping", &ping); err != nil {
+			if hvutil.Stale(err) {
+				c.drop(path)
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, apiOrConnError(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Watch The event monitor (--event-monitor) is a file or descriptor given
+// to the VMM, not part of the API
+func (c *CloudHypervisor) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	return nil, fmt.Errorf("cloudhypervisor: watch: %w", driver.ErrNotSupported)
+}
+
+// Close Close the API connections
+func (c *CloudHypervisor) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path, cl := range c.clients {
+		cl.close()
+		delete(c.clients, path)
+	}
+	return nil
+}
+
+// sockets List the API sockets currently present
+func (c *CloudHypervisor) sockets() ([]string, error) {
+	return filepath.Glob(filepath.Join(c.dir, c.pattern))
+}
+
+// client API client of the VMM behind path, created on first use
+func (c *CloudHypervisor) client(path string) *client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cl, ok := c.clients[path]
+	if !ok {
+		cl = newClient(path)
+		c.clients[path] = cl
+	}
+	return cl
+}
+
+// drop Forget the VMM behind path
+func (c *CloudHypervisor) drop(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cl, ok := c.clients[path]; ok {
+		cl.close()
+		delete(c.clients, path)
+	}
+}
+
+// collectSocket Collect the VM behind a socket, a nil domain means the
+// socket is stale (its VMM has exited), the VMM has no VM created yet or the
+// domain is filtered out. The domain is one of r.
+func (c *CloudHypervisor) collectSocket(ctx context.Context, path string, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	d, err := collectDomain(ctx, c.client(path), path, opts, r)
+	switch {
+	case err == nil:
+		return d, nil
+	case hvutil.Stale(err):
+		driver.GetLogger().Debug("skipping stale API socket", "driver", Hypervisor, "socket", path, "error", err)
+		c.drop(path)
+		return nil, nil
+	case notCreated(err):
+		driver.GetLogger().Debug("skipping VMM without a VM", "driver", Hypervisor, "socket", path)
+		return nil, nil
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	}
+	return nil, apiOrConnError(err)
+}
+
+// apiOrConnError Return API errors as is, wrapping transport failures
+func apiOrConnError(err error) error {
+	var aerr *apiError
+	if errors.As(err, &aerr) {
+		return err
+	}
+	return connError(err)
+}
+
+// socketID Domain ID from a numeric socket name (e.g. 101/api.sock),
+// otherwise a stable hash of the socket name, which names the domain.
+// Derived from the path alone, sockets failing to answer are reported under
+// the ID they collect with.
+func socketID(path string) driver.DomainID {
+	if id, err := driver.ParseDomainID(socketName(path)); err == nil {
+		return id
+	}
+	return driver.HashDomainID(socketName(path))
+}
+
+// socketName Socket file name without its extension, or the name of its
+// directory for sockets named api.sock
+func socketName(path string) string {
+	base := filepath.Base(path)
+	if base == "api.sock" {
+		return filepath.Base(filepath.Dir(path))
+	}
+	return base[:len(base)-len(filepath.Ext(base))]
+}
@@ -0,0 +1,148 @@
+package cloudhypervisor
+
+import (
+	"context"
+	"net"
+	"os"
+	"slices"
+
+	"github.com/virtmonitor/driver"
+)
+
+// collectDomain Collect the VM behind the API socket path into a domain of
+// r, nil if opts.Filter excludes it
+func collectDomain(ctx context.Context, c *client, path string, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	name := socketName(path)
+	id := socketID(path)
+	d := r.Domain(id)
+	d.Name, d.ID, d.Hypervisor, d.Time = name, id, Hypervisor, driver.TimestampNow()
+	d.SetPrivate(path)
+
+	var info vmInfo
+	if err := c.get(ctx, "vm.info", &info); err != nil {
+		return nil, err
+	}
+	d.Flags = domainFlag(info.State)
+	if p := info.Config.Platform; p != nil {
+		if uuid, err := driver.NormalizeUUID(p.UUID); err == nil {
+			d.UUID = uuid
+		}
+	}
+
+	if !opts.Keep(d.Name, d.UUID, d.ID) {
+		return nil, nil
+	}
+	if opts.Skips(d.Flags) {
+		return d, nil
+	}
+	d.VCPUs = info.Config.CPUs.BootVCPUs
+
+	// Counters exist once the VM is booted
+	var devices counters
+	if (opts.Blocks || opts.Interfaces) && (d.Flags == driver.DomainOnline || d.Flags == driver.DomainPaused) {
+		if err := c.get(ctx, "vm.counters", &devices); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.CPUs {
+		// Resizing updates the boot vCPUs
+		d.VCPUsCurrent, d.VCPUsMaximum = d.VCPUs, info.Config.CPUs.MaxVCPUs
+	}
+	if opts.Blocks {
+		d.Blocks = collectBlocks(d.Blocks, info.Config.Disks, devices, opts.BlockCapacity)
+	}
+	if opts.Interfaces {
+		d.Interfaces = collectInterfaces(d.Interfaces, info.Config.Net, devices)
+	}
+	if opts.Memory {
+		collectMemory(&info, d)
+	}
+
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d, nil
+}
+
+// collectBlocks Disks named after their device ID, Cloud Hypervisor has a
+// single virtio bus and doesn't know the guest device names. Appended to
+// blocks emptied.
+func collectBlocks(blocks []driver.BlockDevice, disks []diskConfig, devices counters, capacity bool) []driver.BlockDevice {
+	blocks = slices.Grow(blocks[:0], len(disks))
+	for _, disk := range disks {
+		c := devices[disk.ID]
+		block := driver.BlockDevice{
+			Name:     disk.ID,
+			ReadOnly: disk.Readonly,
+			IsDisk:   true,
+			Bus:      "virtio",
+			Source:   disk.Path,
+			Read:     driver.BlockIO{Operations: c["read_ops"], Bytes: c["read_bytes"], Absolute: true},
+			Write:    driver.BlockIO{Operations: c["write_ops"], Bytes: c["write_bytes"], Absolute: true},
+		}
+		// Raw images' size is the capacity, other formats are left alone
+		if capacity {
+			if fi, err := os.Stat(disk.Path); err == nil && fi.Mode().IsRegular() {
+				block.Capacity, block.Physical = uint64(fi.Size()), uint64(fi.Size())
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// collectInterfaces Interfaces named after their tap device on the host,
+// appended to ifaces emptied
+func collectInterfaces(ifaces []driver.NetworkInterface, nets []netConfig, devices counters) []driver.NetworkInterface {
+	ifaces = slices.Grow(ifaces[:0], len(nets))
+	for _, n := range nets {
+		iface := driver.NetworkInterface{Name: n.Tap, HostDevice: n.Tap}
+		if iface.Name == "" {
+			iface.Name = n.ID
+		}
+		iface.Bridges = driver.HostBridges(n.Tap)
+		if mac, err := net.ParseMAC(n.MAC); err == nil {
+			iface.Mac = mac
+		}
+		c := devices[n.ID]
+		iface.RX = driver.NetworkIO{Bytes: c["rx_bytes"], Packets: c["rx_frames"]}
+		iface.TX = driver.NetworkIO{Bytes: c["tx_bytes"], Packets: c["tx_frames"]}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces
+}
+
+// collectMemory Configured memory and what the balloon leaves to the guest.
+// Hotplugged memory is part of the actual size, the maximum includes what
+// can be hotplugged.
+func collectMemory(info *vmInfo, d *driver.Domain) {
+	memory := info.Config.Memory
+	d.MemoryBacking.BalloonMaximum = memory.Size + memory.HotplugSize
+	d.MemoryBacking.Hugepages = memory.Hugepages
+	d.MemoryBacking.HugepageSize = memory.HugepageSize
+
+	d.Memory.Actual, d.Memory.ActualSet = info.MemoryActualSize, true
+	if info.MemoryActualSize == 0 {
+		// Older versions only report the balloon size
+		d.Memory.Actual = memory.Size
+		if b := info.Config.Balloon; b != nil {
+			d.Memory.Actual -= min(b.Size, memory.Size)
+		}
+	}
+	d.MemoryBacking.BalloonCurrent = d.Memory.Actual
+}
+
+// domainFlag Map a Cloud Hypervisor VM state onto a DomainFlag, a VM created
+// but not booted is configured but not running
+func domainFlag(state string) driver.DomainFlag {
+	switch state {
+	case "Running":
+		return driver.DomainOnline
+	case "Paused", "BreakPoint":
+		return driver.DomainPaused
+	default:
+		return driver.DomainShutdown
+	}
+}
@@ -0,0 +1,90 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// DomainKey Key identifying a domain across drivers: its UUID in canonical
+// form when set, otherwise "<hypervisor>/<id>"
+func DomainKey(d *Domain) string {
+	if u, err := NormalizeUUID(d.UUID); err == nil {
+		return u
+	}
+	if d.UUID != "" {
+		return d.UUID
+	}
+	return string(d.Hypervisor) + "/" + strconv.FormatUint(uint64(d.ID), 10)
+}
+
+// CollectAll Equivalent to CollectAllContext(context.Background(), opts)
+func CollectAll(opts CollectOptions) (map[string]*Domain, error) {
+	return CollectAllContext(context.Background(), opts)
+}
+
+// CollectAllContext Collect concurrently from every registered driver whose
+// Detect succeeds and merge the results keyed by DomainKey.
+//
+// DomainIDs are only unique within a hypervisor, so domains are keyed by UUID,
+// falling back to namespacing the ID by hypervisor. A domain reported by
+// several drivers (same UUID) is kept from the highest priority driver.
+//
+// A failing driver doesn't abort the scan: the domains from the remaining
+// drivers are returned along with all driver errors joined together.
+// ErrNoDriver is returned if no driver is detected.
+func CollectAllContext(ctx context.Context, opts CollectOptions) (map[string]*Domain, error) {
+	ordered := byPriority()
+
+	var (
+		wg       sync.WaitGroup
+		detected = make([]bool, len(ordered))
+		results  = make([]map[DomainID]*Domain, len(ordered))
+		errs     = make([]error, len(ordered))
+	)
+
+	for i, d := range ordered {
+		wg.Add(1)
+		go func(i int, d Driver) {
+			defer wg.Done()
+
+			if !d.Detect() {
+				GetLogger().Debug("driver not detected", "driver", d.Name())
+				return
+			}
+			detected[i] = true
+
+			doms, err := d.CollectContext(ctx, opts)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", d.Name(), err)
+			}
+			results[i] = doms
+		}(i, d)
+	}
+	wg.Wait()
+
+	found := false
+	merged := make(map[string]*Domain)
+	for i := range ordered {
+		if !detected[i] {
+			continue
+		}
+		found = true
+
+		for _, dom := range results[i] {
+			key := DomainKey(dom)
+			if kept, dup := merged[key]; !dup {
+				merged[key] = dom
+			} else {
+				GetLogger().Debug("dropping domain reported by a lower priority driver", "domain", key, "driver", dom.Hypervisor, "kept", kept.Hypervisor)
+			}
+		}
+	}
+
+	if !found {
+		return nil, ErrNoDriver
+	}
+	return merged, errors.Join(errs...)
+}
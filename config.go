@@ -0,0 +1,129 @@
+package driver
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config Settings of a driver by key, such as the socket path, URI or
+// directory it reads, see NewDriver. Keys are lower case, words separated by
+// underscores; the keys a driver accepts are documented with its factory.
+type Config map[string]string
+
+// ConfigFromEnv Config made of the environment variables starting with
+// prefix, keyed by the rest of their name in lower case:
+// ConfigFromEnv("VIRTMONITOR_QMP_") maps VIRTMONITOR_QMP_DIRECTORY to the
+// directory key. Variables set but empty are kept.
+func ConfigFromEnv(prefix string) Config {
+	cfg := make(Config)
+	for _, env := range os.Environ() {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(key, prefix) || len(key) == len(prefix) {
+			continue
+		}
+		cfg[strings.ToLower(key[len(prefix):])] = value
+	}
+	return cfg
+}
+
+// String Value of key, def if unset or empty
+func (c Config) String(key, def string) string {
+	if v := c[key]; v != "" {
+		return v
+	}
+	return def
+}
+
+// Duration Value of key parsed with time.ParseDuration, def if unset or
+// empty. A malformed value is an error wrapping ErrInvalidConfig.
+func (c Config) Duration(key string, def time.Duration) (time.Duration, error) {
+	v := c[key]
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("driver: config %q: %w: %w", key, ErrInvalidConfig, err)
+	}
+	return d, nil
+}
+
+// Bool Value of key parsed with strconv.ParseBool, def if unset or empty. A
+// malformed value is an error wrapping ErrInvalidConfig.
+func (c Config) Bool(key string, def bool) (bool, error) {
+	v := c[key]
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("driver: config %q: %w: %w", key, ErrInvalidConfig, err)
+	}
+	return b, nil
+}
+
+// Factory Creates configured instances of a driver, see RegisterFactory
+type Factory struct {
+	// Keys Keys a config may hold, any other is an error
+	Keys []string
+	// Required Keys a config must hold with a value, they needn't be
+	// repeated in Keys
+	Required []string
+	// New Create a driver from a config NewDriver validated against Keys
+	// and Required, errors of malformed values should wrap ErrInvalidConfig
+	New func(cfg Config) (Driver, error)
+}
+
+// validate Check cfg only holds known keys and every required one
+func (f Factory) validate(cfg Config) error {
+	var unknown []string
+	for key := range cfg {
+		if !contains(f.Keys, key) && !contains(f.Required, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: unknown keys %q, accepted keys are %q", ErrInvalidConfig, unknown, f.keys())
+	}
+	for _, key := range f.Required {
+		if cfg[key] == "" {
+			return fmt.Errorf("%w: missing required key %q", ErrInvalidConfig, key)
+		}
+	}
+	return nil
+}
+
+// keys Every key a config may hold, sorted
+func (f Factory) keys() []string {
+	keys := append(append([]string(nil), f.Required...), f.Keys...)
+	sort.Strings(keys)
+	return keys
+}
+
+// NewDriver Create an instance of the driver registered under name
+// configured with cfg, which may be nil for the defaults. The driver package
+// must be imported for its factory to be registered. An unregistered name is
+// an error wrapping ErrUnknownDriver; keys the driver doesn't accept, missing
+// required keys and malformed values are errors wrapping ErrInvalidConfig.
+func NewDriver(name string, cfg Config) (Driver, error) {
+	f, ok := GetFactory(name)
+	if !ok {
+		return nil, fmt.Errorf("driver: NewDriver %q: %w", name, ErrUnknownDriver)
+	}
+	if err := f.validate(cfg); err != nil {
+		return nil, fmt.Errorf("driver: NewDriver %q: %w", name, err)
+	}
+	if cfg == nil {
+		cfg = Config{}
+	}
+	d, err := f.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("driver: NewDriver %q: %w", name, err)
+	}
+	return d, nil
+}
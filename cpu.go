@@ -0,0 +1,141 @@
+package driver
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// CPUUsagePercent Domain CPU usage over interval between two samples, as the
+// sum of the per vCPU percentages: 0 to 100 times the number of vCPUs
+func CPUUsagePercent(prev, cur *Domain, interval time.Duration) (total float64) {
+	for _, pct := range VCPUUsagePercent(prev, cur, interval) {
+		total += pct
+	}
+	return
+}
+
+// NormalizedCPUTime vCPU time of the domain in nanoseconds divided by its
+// vCPU count, comparable across domains of different sizes. The count is
+// VCPUs, or the number of Cpus when unknown; 0 without either.
+func (d *Domain) NormalizedCPUTime() float64 {
+	n := d.vcpuCount()
+	if n == 0 {
+		return 0
+	}
+	var total float64
+	for _, cpu := range d.Cpus {
+		total += cpu.Time
+	}
+	return total / float64(n)
+}
+
+// vcpuCount vCPUs configured, or collected when unknown
+func (d *Domain) vcpuCount() int {
+	if d.VCPUs > 0 {
+		return d.VCPUs
+	}
+	return len(d.Cpus)
+}
+
+// BusiestDomains The n domains of cur with the highest per vCPU usage over
+// interval since prev, busiest first, all of them when n <= 0. Usage is
+// CPUUsagePercent divided by the vCPU count, so a large idle domain doesn't
+// outrank a small busy one. Domains are matched by UUID, across restarts,
+// or by ID without one; those missing from either collection have no usage
+// to rank and are left out. Ties are ordered by ID.
+func BusiestDomains(prev, cur map[DomainID]*Domain, interval time.Duration, n int) []*Domain {
+	before := make(map[string]*Domain, len(prev))
+	for _, d := range prev {
+		before[busyKey(d)] = d
+	}
+
+	type ranked struct {
+		d     *Domain
+		usage float64
+	}
+	var domains []ranked
+	for _, d := range cur {
+		p, ok := before[busyKey(d)]
+		count := d.vcpuCount()
+		if !ok || count == 0 {
+			continue
+		}
+		domains = append(domains, ranked{d, CPUUsagePercent(p, d, interval) / float64(count)})
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if domains[i].usage != domains[j].usage {
+			return domains[i].usage > domains[j].usage
+		}
+		return domains[i].d.ID < domains[j].d.ID
+	})
+
+	if n <= 0 || n > len(domains) {
+		n = len(domains)
+	}
+	out := make([]*Domain, n)
+	for i := range out {
+		out[i] = domains[i].d
+	}
+	return out
+}
+
+// busyKey Key matching a domain across collections
+func busyKey(d *Domain) string {
+	if d.UUID != "" {
+		return d.UUID
+	}
+	return string(d.Hypervisor) + "/" + strconv.FormatUint(uint64(d.ID), 10)
+}
+
+// VCPUUsagePercent Usage of each vCPU by ID over interval between two
+// samples, clamped to 0-100. vCPUs missing from prev are left out.
+//
+// Idle time is preferred when both samples have it, giving 100 minus the
+// idle percentage, otherwise the run time delta is used. A counter that went
+// backwards (domain restarted) is measured from zero.
+func VCPUUsagePercent(prev, cur *Domain, interval time.Duration) map[uint64]float64 {
+	usage := make(map[uint64]float64, len(cur.Cpus))
+	if interval <= 0 {
+		return usage
+	}
+
+	before := make(map[uint64]CPU, len(prev.Cpus))
+	for _, cpu := range prev.Cpus {
+		before[cpu.ID] = cpu
+	}
+
+	for _, c := range cur.Cpus {
+		p, ok := before[c.ID]
+		if !ok {
+			continue
+		}
+
+		var pct float64
+		if c.IdleSet && p.IdleSet {
+			pct = 100 - timeDelta(c.Idle, p.Idle)/float64(interval)*100
+		} else {
+			pct = timeDelta(c.Time, p.Time) / float64(interval) * 100
+		}
+		usage[c.ID] = clampPercent(pct)
+	}
+	return usage
+}
+
+// timeDelta Difference of two cumulative times, from zero on a reset
+func timeDelta(cur, prev float64) float64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+func clampPercent(pct float64) float64 {
+	switch {
+	case pct < 0:
+		return 0
+	case pct > 100:
+		return 100
+	}
+	return pct
+}
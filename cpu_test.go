@@ -0,0 +1,80 @@
+package driver_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// cpuSample Domain with vCPUs of the given cumulative times, in nanoseconds
+func cpuSample(times ...float64) *driver.Domain {
+	d := &driver.Domain{ID: 7, Name: "db-1", VCPUs: len(times)}
+	for i, t := range times {
+		d.Cpus = append(d.Cpus, driver.CPU{ID: uint64(i), Time: t, Flags: driver.CPURunning})
+	}
+	return d
+}
+
+func TestCPUUsagePercent(t *testing.T) {
+	const interval = 10 * time.Second
+	tests := []struct {
+		name      string
+		prev, cur *driver.Domain
+		want      float64
+	}{{
+		// libvirt vcpu.N.time 10s apart: half busy, saturated, idle and a
+		// quarter busy
+		"run time",
+		cpuSample(8_412_553_000_000, 9_100_020_000_000, 310_000_000, 2_250_004_000_000),
+		cpuSample(8_417_553_000_000, 9_110_020_000_000, 310_000_000, 2_252_504_000_000),
+		175,
+	}, {
+		"idle time",
+		&driver.Domain{Cpus: []driver.CPU{
+			{ID: 0, Time: 4e12, Idle: 3.5e12, IdleSet: true},
+			{ID: 1, Time: 4e12, Idle: 3.9e12, IdleSet: true},
+		}},
+		&driver.Domain{Cpus: []driver.CPU{
+			{ID: 0, Time: 4.006e12, Idle: 3.508e12, IdleSet: true},
+			{ID: 1, Time: 4.001e12, Idle: 3.9099e12, IdleSet: true},
+		}},
+		// 80% and 99% idle
+		20 + 1,
+	}, {
+		// Sampling jitter reporting slightly more than the interval
+		"clamped",
+		cpuSample(1e12, 2e12),
+		cpuSample(1.0102e12, 2.005e12),
+		100 + 50,
+	}, {
+		// Restarted domain, its times counted from zero
+		"restart",
+		cpuSample(5e12, 5e12),
+		cpuSample(2e9, 1e9),
+		20 + 10,
+	}, {
+		// Hot plugged vCPU 2 has nothing to compare with
+		"hot plugged",
+		cpuSample(1e12, 1e12),
+		cpuSample(1.001e12, 1.003e12, 5e9),
+		10 + 30,
+	}, {
+		"idle domain",
+		cpuSample(1e12, 1e12),
+		cpuSample(1e12, 1e12),
+		0,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := driver.CPUUsagePercent(tt.prev, tt.cur, interval); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("CPUUsagePercent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := driver.CPUUsagePercent(cpuSample(1e12), cpuSample(2e12), 0); got != 0 {
+		t.Errorf("CPUUsagePercent() = %v over no interval, want 0", got)
+	}
+}
@@ -0,0 +1,114 @@
+package driver
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// CPUSet Set of physical CPU numbers
+type CPUSet []uint64
+
+// Set Add cpu to the set
+func (s *CPUSet) Set(cpu int) {
+	for len(*s) <= cpu/64 {
+		*s = append(*s, 0)
+	}
+	(*s)[cpu/64] |= 1 << (uint(cpu) % 64)
+}
+
+// Has Test if cpu is in the set
+func (s CPUSet) Has(cpu int) bool {
+	if cpu < 0 || cpu/64 >= len(s) {
+		return false
+	}
+	return s[cpu/64]&(1<<(uint(cpu)%64)) != 0
+}
+
+// Count Number of CPUs in the set
+func (s CPUSet) Count() (n int) {
+	for _, w := range s {
+		n += bits.OnesCount64(w)
+	}
+	return
+}
+
+// CPUs CPU numbers in the set, ascending
+func (s CPUSet) CPUs() []int {
+	cpus := make([]int, 0, s.Count())
+	for i, w := range s {
+		for w != 0 {
+			b := bits.TrailingZeros64(w)
+			cpus = append(cpus, i*64+b)
+			w &^= 1 << uint(b)
+		}
+	}
+	return cpus
+}
+
+// String Set in list format, e.g. "0-3,8"
+func (s CPUSet) String() string {
+	var b strings.Builder
+	cpus := s.CPUs()
+	for i := 0; i < len(cpus); {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(cpus[i]))
+		if j > i {
+			b.WriteByte('-')
+			b.WriteString(strconv.Itoa(cpus[j]))
+		}
+		i = j + 1
+	}
+	return b.String()
+}
+
+// maxCPU Highest CPU number ParseCPUSet accepts, well beyond the 8192 CPUs
+// Linux supports. It bounds the memory a malformed list can allocate.
+const maxCPU = 1<<16 - 1
+
+// ParseCPUSet Parse a set in list format, as found in cpuset files and
+// /proc/<pid>/status. CPU numbers above 65535 are rejected.
+func ParseCPUSet(list string) (CPUSet, error) {
+	var s CPUSet
+	for _, r := range strings.Split(strings.TrimSpace(list), ",") {
+		if r == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(r, "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("driver: invalid CPU list %q", list)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(hi); err != nil || last < first {
+				return nil, fmt.Errorf("driver: invalid CPU list %q", list)
+			}
+		}
+		if last > maxCPU {
+			return nil, fmt.Errorf("driver: invalid CPU list %q: CPU %d above %d", list, last, maxCPU)
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			s.Set(cpu)
+		}
+	}
+	return s, nil
+}
+
+// MarshalText Encode the set in list format
+func (s CPUSet) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText Decode a set in list format
+func (s *CPUSet) UnmarshalText(text []byte) (err error) {
+	*s, err = ParseCPUSet(string(text))
+	return
+}
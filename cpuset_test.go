@@ -0,0 +1,47 @@
+package driver
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestParseCPUSet(t *testing.T) {
+	for _, tt := range []struct {
+		list string
+		want string
+	}{
+		{"", ""},
+		{"0", "0"},
+		{"0-3,8", "0-3,8"},
+		{" 8,0-1,2 \n", "0-2,8"},
+		{"63-64", "63-64"},
+		{strconv.Itoa(maxCPU), strconv.Itoa(maxCPU)},
+	} {
+		s, err := ParseCPUSet(tt.list)
+		if err != nil {
+			t.Errorf("ParseCPUSet(%q): %v", tt.list, err)
+			continue
+		}
+		if got := s.String(); got != tt.want {
+			t.Errorf("ParseCPUSet(%q) = %q, want %q", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestParseCPUSetInvalid(t *testing.T) {
+	for _, list := range []string{
+		"a",
+		"-1",
+		"3-1",
+		"0-",
+		strconv.Itoa(maxCPU + 1),
+		"0-" + strconv.Itoa(maxCPU+1),
+		"0-" + strconv.Itoa(math.MaxInt),
+		strconv.Itoa(math.MaxInt),
+	} {
+		if s, err := ParseCPUSet(list); err == nil {
+			t.Errorf("ParseCPUSet(%q) = %v, want an error", list, s)
+		}
+	}
+}
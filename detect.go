@@ -0,0 +1,78 @@
+package driver
+
+import "sort"
+
+// DetectResult Outcome of a detection and the reason for it
+type DetectResult struct {
+	Detected bool `json:"detected"`
+	// Reason Human readable explanation, such as "no socket found at ..."
+	// or "connected, 3 domains"
+	Reason string `json:"reason"`
+	// Err Failure behind an unsuccessful detection, wrapping a sentinel such
+	// as ErrHypervisorUnavailable or ErrPermissionDenied when one applies
+	Err error `json:"-"`
+}
+
+// Diagnoser Optional interface of drivers explaining their detection.
+// Their Detect reports Diagnose().Detected.
+type Diagnoser interface {
+	Diagnose() DetectResult
+}
+
+// Diagnose Detect d, with the reason when d is a Diagnoser
+func Diagnose(d Driver) DetectResult {
+	if dg, ok := d.(Diagnoser); ok {
+		return dg.Diagnose()
+	}
+	if d.Detect() {
+		return DetectResult{Detected: true, Reason: "detected"}
+	}
+	return DetectResult{Reason: "not detected"}
+}
+
+// Prioritizer Optional interface ranking a driver in AutoDetect.
+// Higher priorities are tried first, drivers without it have priority 0.
+type Prioritizer interface {
+	Priority() int
+}
+
+// priority Priority of a driver for detection order
+func priority(d Driver) int {
+	if p, ok := d.(Prioritizer); ok {
+		return p.Priority()
+	}
+	return 0
+}
+
+// byPriority Registered drivers, highest priority first, ties by name
+func byPriority() []Driver {
+	driversMu.RLock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	ordered := make([]Driver, 0, len(drivers))
+	sort.Strings(names)
+	for _, name := range names {
+		ordered = append(ordered, drivers[name])
+	}
+	driversMu.RUnlock()
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return priority(ordered[i]) > priority(ordered[j])
+	})
+	return ordered
+}
+
+// AutoDetect First registered driver, in priority order, whose Detect succeeds.
+// Use Name() on the result to log which driver was chosen.
+func AutoDetect() (Driver, error) {
+	for _, d := range byPriority() {
+		r := Diagnose(d)
+		if r.Detected {
+			return d, nil
+		}
+		GetLogger().Debug("driver not detected", "driver", d.Name(), "reason", r.Reason)
+	}
+	return nil, ErrNoDriver
+}
@@ -0,0 +1,186 @@
+package driver
+
+import "fmt"
+
+// Diff Domain holding the changes from prev to cur: a copy of cur whose
+// cumulative counters (block and network IO, vCPU run, idle, steal and IO
+// wait time, IO thread and emulator time, swap and page faults) are replaced
+// by their deltas. Block IO in the result is not Absolute.
+//
+// vCPUs and IO threads are matched by ID and devices by name, those present
+// in only one of the samples are left out. Counter resets are measured from
+// zero as in Rate. An error wrapping ErrSampleOrder is returned if cur isn't
+// newer than prev.
+func Diff(prev, cur *Domain) (*Domain, error) {
+	if prev == nil || cur == nil {
+		return nil, fmt.Errorf("driver: Diff: %w", ErrDomainMismatch)
+	}
+	if prev.UUID != "" && cur.UUID != "" && prev.UUID != cur.UUID {
+		return nil, fmt.Errorf("driver: Diff %s and %s: %w", prev.UUID, cur.UUID, ErrDomainMismatch)
+	}
+	if cur.Time <= prev.Time {
+		return nil, fmt.Errorf("driver: Diff at %d and %d: %w", prev.Time, cur.Time, ErrSampleOrder)
+	}
+
+	d := cur.Clone()
+	d.Cpus = diffCPUs(prev.Cpus, d.Cpus)
+	d.IOThreads = diffIOThreads(prev.IOThreads, d.IOThreads)
+	if d.EmulatorTimeSet && prev.EmulatorTimeSet {
+		d.EmulatorTime = timeDelta(d.EmulatorTime, prev.EmulatorTime)
+	} else {
+		d.EmulatorTime, d.EmulatorTimeSet = 0, false
+	}
+	d.Blocks = diffBlocks(prev.Blocks, d.Blocks)
+	d.Interfaces = diffInterfaces(prev.Interfaces, d.Interfaces)
+	diffMemory(&prev.Memory, &d.Memory)
+	return d, nil
+}
+
+func diffCPUs(prev, cur []CPU) []CPU {
+	before := make(map[uint64]CPU, len(prev))
+	for _, cpu := range prev {
+		before[cpu.ID] = cpu
+	}
+
+	cpus := cur[:0]
+	for _, c := range cur {
+		p, ok := before[c.ID]
+		if !ok {
+			continue
+		}
+		c.Time = timeDelta(c.Time, p.Time)
+		if c.IdleSet && p.IdleSet {
+			c.Idle = timeDelta(c.Idle, p.Idle)
+		} else {
+			c.Idle, c.IdleSet = 0, false
+		}
+		if c.StealSet && p.StealSet {
+			c.Steal = timeDelta(c.Steal, p.Steal)
+		} else {
+			c.Steal, c.StealSet = 0, false
+		}
+		if c.IOWaitSet && p.IOWaitSet {
+			c.IOWait = timeDelta(c.IOWait, p.IOWait)
+		} else {
+			c.IOWait, c.IOWaitSet = 0, false
+		}
+		cpus = append(cpus, c)
+	}
+	return cpus
+}
+
+func diffIOThreads(prev, cur []IOThread) []IOThread {
+	before := make(map[uint64]IOThread, len(prev))
+	for _, t := range prev {
+		before[t.ID] = t
+	}
+
+	threads := cur[:0]
+	for _, t := range cur {
+		p, ok := before[t.ID]
+		if !ok {
+			continue
+		}
+		if t.TimeSet && p.TimeSet {
+			t.Time = timeDelta(t.Time, p.Time)
+		} else {
+			t.Time, t.TimeSet = 0, false
+		}
+		threads = append(threads, t)
+	}
+	return threads
+}
+
+func diffBlocks(prev, cur []BlockDevice) []BlockDevice {
+	before := make(map[string]BlockDevice, len(prev))
+	for _, b := range prev {
+		before[b.Name] = b
+	}
+
+	blocks := cur[:0]
+	for _, b := range cur {
+		p, ok := before[b.Name]
+		if !ok {
+			continue
+		}
+		b.Read = b.Read.delta(p.Read)
+		b.Write = b.Write.delta(p.Write)
+		b.Flush = b.Flush.delta(p.Flush)
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+// delta Change from prev, values that are already deltas are kept
+func (cur BlockIO) delta(prev BlockIO) BlockIO {
+	if !cur.Absolute {
+		return cur
+	}
+	cur.Operations, _ = counterDelta(cur.Operations, prev.Operations)
+	cur.Bytes, _ = counterDelta(cur.Bytes, prev.Bytes)
+	cur.Sectors, _ = counterDelta(cur.Sectors, prev.Sectors)
+	cur.Errors, _ = counterDelta(cur.Errors, prev.Errors)
+	if cur.TotalTimeSet && prev.TotalTimeSet {
+		cur.TotalTime, _ = counterDelta(cur.TotalTime, prev.TotalTime)
+	} else {
+		cur.TotalTime, cur.TotalTimeSet = 0, false
+	}
+	cur.Absolute = false
+	return cur
+}
+
+func diffInterfaces(prev, cur []NetworkInterface) []NetworkInterface {
+	before := make(map[string]NetworkInterface, len(prev))
+	for _, n := range prev {
+		before[n.Name] = n
+	}
+
+	ifaces := cur[:0]
+	for _, n := range cur {
+		p, ok := before[n.Name]
+		if !ok {
+			continue
+		}
+		n.RX = n.RX.delta(p.RX)
+		n.TX = n.TX.delta(p.TX)
+		ifaces = append(ifaces, n)
+	}
+	return ifaces
+}
+
+// delta Change from prev
+func (cur NetworkIO) delta(prev NetworkIO) NetworkIO {
+	cur.Bytes, _ = counterDelta(cur.Bytes, prev.Bytes)
+	cur.Packets, _ = counterDelta(cur.Packets, prev.Packets)
+	cur.Errors, _ = counterDelta(cur.Errors, prev.Errors)
+	cur.Drops, _ = counterDelta(cur.Drops, prev.Drops)
+	cur.FIFO, _ = counterDelta(cur.FIFO, prev.FIFO)
+	cur.Frame, _ = counterDelta(cur.Frame, prev.Frame)
+	cur.Collisions, _ = counterDelta(cur.Collisions, prev.Collisions)
+	cur.Carrier, _ = counterDelta(cur.Carrier, prev.Carrier)
+	return cur
+}
+
+// diffMemory Replace the cumulative memory counters with deltas, counters
+// missing from either sample become unset
+func diffMemory(prev, cur *Memory) {
+	counters := []struct {
+		cur     *uint64
+		curSet  *bool
+		prev    uint64
+		prevSet bool
+	}{
+		{&cur.SwapIn, &cur.SwapInSet, prev.SwapIn, prev.SwapInSet},
+		{&cur.SwapOut, &cur.SwapOutSet, prev.SwapOut, prev.SwapOutSet},
+		{&cur.MajorFaults, &cur.MajorFaultsSet, prev.MajorFaults, prev.MajorFaultsSet},
+		{&cur.MinorFaults, &cur.MinorFaultsSet, prev.MinorFaults, prev.MinorFaultsSet},
+	}
+
+	for _, c := range counters {
+		if !*c.curSet || !c.prevSet {
+			*c.cur, *c.curSet = 0, false
+			continue
+		}
+		*c.cur, _ = counterDelta(*c.cur, c.prev)
+	}
+}
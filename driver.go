@@ -1,13 +1,12 @@
 package driver
 
 import (
+	"context"
+	"fmt"
+	"hash/fnv"
 	"net"
 	"strconv"
-)
-
-var (
-	// Drivers Array of available drivers
-	Drivers = make(map[string]Driver)
+	"time"
 )
 
 //CPUFlag CPU flag type
@@ -38,14 +37,88 @@ const (
 	DomainDying
 	//DomainPaused Domain is waiting for CPU time
 	DomainPaused
+	//DomainMigrating Domain is being migrated to or from another host, in a
+	//direction the driver can't tell. See DomainMigratingIn and
+	//DomainMigratingOut, and DomainFlag.Migrating.
+	DomainMigrating
+	//DomainSaving Domain memory is being saved to disk
+	DomainSaving
+	//DomainMigratingIn Domain is being migrated from another host to this
+	//one, paused until the migration completes
+	DomainMigratingIn
+	//DomainMigratingOut Domain is being migrated from this host to another,
+	//running until the final switchover pauses it
+	DomainMigratingOut
+	//DomainRestoring Domain memory is being restored from disk, paused until
+	//the restore completes
+	DomainRestoring
+)
+
+// AgentState Guest agent connection state
+type AgentState int
+
+const (
+	//AgentUnknown The driver can't tell whether a guest agent answers
+	AgentUnknown AgentState = iota
+	//AgentConnected A guest agent is connected to its channel
+	AgentConnected
+	//AgentDisconnected No guest agent is connected, or the domain has no
+	//agent channel
+	AgentDisconnected
 )
 
 // Driver Driver struct
+//
+// Collect and CollectContext return errors wrapping ErrHypervisorUnavailable
+// when the hypervisor can't be reached, ErrPermissionDenied when access is
+// refused and ErrNotSupported when the hypervisor lacks a requested facility.
+// A domain failing to collect doesn't abort the collection: the remaining
+// domains are returned along with the per domain errors joined.
+//
+// Drivers are safe for concurrent use by multiple goroutines unless their
+// documentation says otherwise: concurrent collections may share one
+// hypervisor connection, which the driver guards. Every driver and wrapper
+// of this module is, and drivers registered from elsewhere should be too.
+// Those that aren't can be shared once wrapped with Serialize.
 type Driver interface {
 	Name() DomainHypervisor
 	Detect() bool
-	Collect(bool, bool, bool) (map[DomainID]*Domain, error)
-	Close()
+	// Capabilities reports what the driver can collect.
+	Capabilities() Capabilities
+	// Collect is equivalent to CollectContext(context.Background(), opts)
+	Collect(opts CollectOptions) (map[DomainID]*Domain, error)
+	// CollectContext collects domains, aborting with ctx.Err() if ctx is done.
+	// On cancellation no partially collected domains are returned.
+	CollectContext(ctx context.Context, opts CollectOptions) (map[DomainID]*Domain, error)
+	// CollectDomain collects a single domain without enumerating the others,
+	// returning an error wrapping ErrDomainNotFound if it doesn't exist.
+	CollectDomain(id DomainID, opts CollectOptions) (*Domain, error)
+	// CollectDomainByUUID collects a single domain by UUID, returning an error
+	// wrapping ErrInvalidUUID for malformed UUIDs or ErrDomainNotFound on a miss.
+	// Drivers without native UUID lookup scan all domains, which is O(n).
+	CollectDomainByUUID(uuid string, opts CollectOptions) (*Domain, error)
+	// CollectDomainByName collects a single domain by name, returning an error
+	// wrapping ErrDomainNotFound on a miss.
+	// Drivers without native name lookup scan all domains, which is O(n).
+	CollectDomainByName(name string, opts CollectOptions) (*Domain, error)
+	// CollectSnapshots lists the snapshots of a domain, returning an error
+	// wrapping ErrDomainNotFound if it doesn't exist. Drivers without
+	// snapshots return ErrNotSupported.
+	CollectSnapshots(id DomainID) ([]Snapshot, error)
+	// Host collects metrics of the physical host, separately from the domains.
+	Host() (*HostInfo, error)
+	// Ping checks the hypervisor answers a minimal round trip over the
+	// driver's connection, connecting first as any other call would, and
+	// returns an error wrapping ErrHypervisorUnavailable when it doesn't.
+	// Unlike Detect it doesn't probe for a hypervisor from scratch.
+	Ping(ctx context.Context) error
+	// Watch pushes domain lifecycle changes as they happen. The channel is
+	// closed when ctx is done or the connection to the hypervisor drops.
+	// Drivers without an event source return ErrNotSupported.
+	Watch(ctx context.Context) (<-chan DomainEvent, error)
+	// Close releases the hypervisor connection, reporting a failure to
+	// close it cleanly.
+	Close() error
 }
 
 // DomainID Domain #ID
@@ -54,89 +127,465 @@ type DomainID uint64
 // DomainHypervisor What underlying hypervisor does domain use
 type DomainHypervisor string
 
-// Timestamp Collection timestamp
+// Timestamp Collection timestamp in Unix nanoseconds, see TimestampNow
 type Timestamp int64
 
 // Domain Domain
 type Domain struct {
-	Name       string
-	ID         DomainID
-	Hypervisor DomainHypervisor
-	UUID       string
-	OSType     string
-	Time       Timestamp
-	Flags      DomainFlag
-
-	Cpus       []CPU
-	Blocks     []BlockDevice
-	Interfaces []NetworkInterface
+	Name       string           `json:"name"`
+	ID         DomainID         `json:"id"`
+	Hypervisor DomainHypervisor `json:"hypervisor"`
+	UUID       string           `json:"uuid"`
+	OSType     string           `json:"os_type"`
+	Time       Timestamp        `json:"time"`
+	Flags      DomainFlag       `json:"flags"`
+	// StartTime When the running domain was started, 0 when unknown or not
+	// running. Reported by libvirt (QEMU domains) and lxc. See Uptime.
+	StartTime Timestamp `json:"start_time"`
+
+	// Persistent Whether the domain is defined and survives being stopped,
+	// rather than transient, valid when PersistentSet. Reported by libvirt
+	// and lxc.
+	Persistent    bool `json:"persistent"`
+	PersistentSet bool `json:"persistent_set"`
+	// Autostart Whether the domain starts with the host, valid when
+	// AutostartSet. Reported by libvirt and lxc.
+	Autostart    bool `json:"autostart"`
+	AutostartSet bool `json:"autostart_set"`
+	// MachineType Machine type the domain emulates, such as pc-q35-8.2, and
+	// EmulatorVersion version of the emulator running it, such as 8.2.0,
+	// empty when unknown. Only populated with CollectOptions.Versions, by
+	// libvirt, qmp and procfs (machine type only); the emulator version only
+	// for running domains.
+	MachineType     string `json:"machine_type"`
+	EmulatorVersion string `json:"emulator_version"`
+
+	// VCPUs Number of vCPUs configured, Cpus may hold fewer during hotplug
+	VCPUs int `json:"vcpus"`
+	// VCPUsCurrent Number of vCPUs online, 0 when unknown
+	VCPUsCurrent int `json:"vcpus_current"`
+	// VCPUsMaximum Number of vCPUs the domain can be hotplugged up to, 0
+	// when unknown
+	VCPUsMaximum int `json:"vcpus_maximum"`
+	// NestedVirt Whether the vCPUs expose hardware virtualization (vmx or
+	// svm) to the guest, valid when NestedVirtSet. Only determined with
+	// CollectOptions.CPUs.
+	NestedVirt    bool `json:"nested_virt"`
+	NestedVirtSet bool `json:"nested_virt_set"`
+	// Topology Guest CPU topology as configured, only determined with
+	// CollectOptions.CPUs by libvirt
+	Topology CPUTopology `json:"topology"`
+	// CPUTuning Scheduler configuration, only populated with
+	// CollectOptions.CPUTuning
+	CPUTuning CPUTuning `json:"cpu_tuning"`
+	// NUMA Guest NUMA cells and the host NUMA nodes memory is bound to,
+	// only populated with CollectOptions.NUMA
+	NUMA NUMA `json:"numa"`
+	// IOThreads Threads the hypervisor runs block IO on, outside the vCPUs.
+	// Only populated with CollectOptions.CPUs, by libvirt for QEMU domains.
+	IOThreads []IOThread `json:"iothreads"`
+	// EmulatorTime Cumulative CPU time of the hypervisor threads other than
+	// the vCPUs and IO threads in nanoseconds, valid when EmulatorTimeSet.
+	// Only determined with CollectOptions.CPUs.
+	EmulatorTime    float64 `json:"emulator_time"`
+	EmulatorTimeSet bool    `json:"emulator_time_set"`
+
+	Cpus       []CPU              `json:"cpus"`
+	Blocks     []BlockDevice      `json:"blocks"`
+	Interfaces []NetworkInterface `json:"interfaces"`
+	Memory     Memory             `json:"memory"`
+	// MemoryBacking Balloon sizes and hugepage backing, only populated with
+	// CollectOptions.Memory
+	MemoryBacking MemoryBacking `json:"memory_backing"`
+
+	// Filesystems Guest file systems, only populated with
+	// CollectOptions.Filesystems and empty when the guest agent is unreachable
+	Filesystems []Filesystem `json:"filesystems"`
+	// GuestAgent State of the guest agent, telling an unreachable agent from
+	// guest data that doesn't exist. Determined with the options the agent
+	// backs, CollectOptions.CPUs, Addresses and Filesystems, from the agent
+	// channel state libvirt tracks; AgentUnknown otherwise.
+	GuestAgent AgentState `json:"guest_agent"`
+
+	// Title Short description of the domain, only populated with
+	// CollectOptions.Metadata
+	Title string `json:"title"`
+	// Description Free form description, only populated with
+	// CollectOptions.Metadata
+	Description string `json:"description"`
+	// Labels User defined key/value pairs such as owner or environment, only
+	// populated with CollectOptions.Metadata
+	Labels map[string]string `json:"labels"`
+
+	// Graphics Graphical consoles, only populated with CollectOptions.Graphics
+	Graphics []GraphicsDevice `json:"graphics"`
+	// Consoles Host paths of the serial consoles (e.g. /dev/pts/3), only
+	// populated with CollectOptions.Graphics
+	Consoles []string `json:"consoles"`
+	// HostDevices Physical devices passed through to the domain, only
+	// populated with CollectOptions.HostDevices
+	HostDevices []HostDevice `json:"host_devices"`
+
+	// CollectDuration Wall clock time the driver spent collecting the domain
+	CollectDuration time.Duration `json:"collect_duration"`
 
+	// prv Driver-private data, see Private
 	prv interface{}
 }
 
 // BlockIO Block IO
 type BlockIO struct {
-	Operations uint64
-	Bytes      uint64
-	Sectors    uint64
-	Absolute   bool
+	Operations uint64 `json:"operations"`
+	Bytes      uint64 `json:"bytes"`
+	Sectors    uint64 `json:"sectors"`
+	Absolute   bool   `json:"absolute"`
+
+	// TotalTime Time spent completing the operations in nanoseconds, valid
+	// when TotalTimeSet. See Latency and QueueDepth.
+	TotalTime    uint64 `json:"total_time"`
+	TotalTimeSet bool   `json:"total_time_set"`
+
+	// Errors Operations that failed, 0 where the hypervisor doesn't count
+	// them (reported by qmp)
+	Errors uint64 `json:"errors"`
 }
 
 // BlockDevice Block Device
+//
+// Capacity, Allocation and Physical are only populated with
+// CollectOptions.BlockCapacity and are zero when the store can't report them.
 type BlockDevice struct {
-	Name     string
-	ReadOnly bool
-	IsDisk   bool
-	IsCDrom  bool
-	Read     BlockIO
-	Write    BlockIO
-	Flush    BlockIO
+	Name     string  `json:"name"`
+	ReadOnly bool    `json:"read_only"`
+	IsDisk   bool    `json:"is_disk"`
+	IsCDrom  bool    `json:"is_cdrom"`
+	Read     BlockIO `json:"read"`
+	Write    BlockIO `json:"write"`
+	Flush    BlockIO `json:"flush"`
+	// Stalled The device is in an error state, such as a full or read-only
+	// backing store, and IO to it is failing or paused. Reported by libvirt
+	// and qmp, false elsewhere.
+	Stalled bool `json:"stalled"`
+
+	// Bus Bus the device is attached to in the guest (virtio, scsi, ide,
+	// sata, xen, ...), empty when unknown
+	Bus string `json:"bus"`
+	// Target Device name presented to the guest (e.g. vda), empty when unknown
+	Target string `json:"target"`
+	// Source Backing file, host device or network volume, empty when unknown
+	Source string `json:"source"`
+	// BackingChain Backing images below Source, such as the base of a qcow2
+	// overlay, nearest first. Only populated with CollectOptions.BackingChain.
+	BackingChain []string `json:"backing_chain"`
+
+	// Capacity Logical size seen by the guest in bytes
+	Capacity uint64 `json:"capacity"`
+	// Allocation Bytes allocated in the backing store (less than Capacity when thin provisioned)
+	Allocation uint64 `json:"allocation"`
+	// Physical Size of the backing store in bytes
+	Physical uint64 `json:"physical"`
+
+	// Limits Throttling configured on the device, only populated with
+	// CollectOptions.Limits
+	Limits BlockLimits `json:"limits"`
+	// Throttled IO to the device ran at one of its Limits over the last
+	// sampling interval. No driver reports it, see ThrottleTracker.
+	Throttled bool `json:"throttled"`
+}
+
+// BlockLimits IO throttling of a block device, 0 when unlimited. Total
+// limits apply to reads and writes combined.
+type BlockLimits struct {
+	ReadIOPS      uint64 `json:"read_iops"`
+	WriteIOPS     uint64 `json:"write_iops"`
+	TotalIOPS     uint64 `json:"total_iops"`
+	ReadBytesSec  uint64 `json:"read_bytes_sec"`
+	WriteBytesSec uint64 `json:"write_bytes_sec"`
+	TotalBytesSec uint64 `json:"total_bytes_sec"`
+
+	// Weight Proportional share of IO the domain gets on the device under
+	// contention, relative to other domains and in the hypervisor's range:
+	// 100 to 1000 for libvirt, 1 to 10000 for cgroup v2. 0 when not
+	// configured.
+	Weight uint64 `json:"weight"`
 }
 
 // CPU CPU
 type CPU struct {
-	ID      uint64
-	Flags   CPUFlag
-	Time    float64
-	Idle    float64
-	IdleSet bool
-	Load1   float64
-	Load5   float64
-	Load15  float64
+	ID    uint64  `json:"id"`
+	Flags CPUFlag `json:"flags"`
+	// Time Cumulative time the vCPU has run, in nanoseconds
+	Time float64 `json:"time"`
+	// Idle Cumulative time the vCPU has idled, in nanoseconds, valid when IdleSet
+	Idle    float64 `json:"idle"`
+	IdleSet bool    `json:"idle_set"`
+	// Load1, Load5 and Load15 Utilization of the vCPU averaged over 1, 5
+	// and 15 minutes, 0 unless computed by a LoadTracker
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+
+	// Steal Cumulative time the vCPU was runnable but waiting for a physical
+	// CPU, in nanoseconds, valid when StealSet. It grows when the host is
+	// overcommitted.
+	Steal    float64 `json:"steal"`
+	StealSet bool    `json:"steal_set"`
+	// IOWait Cumulative time the guest idled on the vCPU waiting for IO, in
+	// nanoseconds, valid when IOWaitSet. Only the guest agent reports it.
+	IOWait    float64 `json:"iowait"`
+	IOWaitSet bool    `json:"iowait_set"`
+
+	// PhysicalCPU Physical CPU the vCPU last ran on, valid when PhysicalCPUSet
+	PhysicalCPU    int  `json:"physical_cpu"`
+	PhysicalCPUSet bool `json:"physical_cpu_set"`
+	// Affinity Physical CPUs the vCPU may run on, only populated with
+	// CollectOptions.Pinning
+	Affinity CPUSet `json:"affinity"`
+	// NUMANode Host NUMA node the vCPU is pinned to, valid when NUMANodeSet,
+	// which needs its affinity to lie within a single node. Only determined
+	// with CollectOptions.NUMA.
+	NUMANode    int  `json:"numa_node"`
+	NUMANodeSet bool `json:"numa_node_set"`
+}
+
+// CPUTopology Sockets, cores and threads the vCPUs are presented as to the
+// guest, zero when not configured and the guest sees one socket per vCPU.
+// Dies and clusters are folded into CoresPerSocket.
+type CPUTopology struct {
+	Sockets        int `json:"sockets"`
+	CoresPerSocket int `json:"cores_per_socket"`
+	ThreadsPerCore int `json:"threads_per_core"`
+}
+
+// IOThread Hypervisor thread serving block IO
+type IOThread struct {
+	ID uint64 `json:"id"`
+	// Time Cumulative CPU time of the thread in nanoseconds, valid when
+	// TimeSet
+	Time    float64 `json:"time"`
+	TimeSet bool    `json:"time_set"`
+	// Affinity Physical CPUs the thread may run on, only populated with
+	// CollectOptions.Pinning
+	Affinity CPUSet `json:"affinity"`
+}
+
+// CPUTuning CPU scheduler configuration of a domain. Values are 0 when
+// unknown or not configured, quotas are -1 when unlimited. Periods and
+// quotas are in microseconds.
+type CPUTuning struct {
+	// Shares Relative CPU weight as cgroup v1 cpu.shares (default 1024) or
+	// libvirt cpu_shares
+	Shares uint64 `json:"shares"`
+	// Weight Relative CPU weight as cgroup v2 cpu.weight (default 100) or
+	// the Xen credit scheduler weight (default 256)
+	Weight uint64 `json:"weight"`
+	// Period Bandwidth control period of each vCPU
+	Period uint64 `json:"period"`
+	// Quota CPU time each vCPU may use per Period
+	Quota int64 `json:"quota"`
+	// GlobalPeriod Bandwidth control period of the domain as a whole, that
+	// of the container's cgroup for containers
+	GlobalPeriod uint64 `json:"global_period"`
+	// GlobalQuota CPU time the domain may use per GlobalPeriod
+	GlobalQuota int64 `json:"global_quota"`
+	// EmulatorPeriod Bandwidth control period of the emulator threads
+	EmulatorPeriod uint64 `json:"emulator_period"`
+	// EmulatorQuota CPU time the emulator threads may use per EmulatorPeriod
+	EmulatorQuota int64 `json:"emulator_quota"`
+	// IOThreadPeriod Bandwidth control period of each IO thread
+	IOThreadPeriod uint64 `json:"iothread_period"`
+	// IOThreadQuota CPU time each IO thread may use per IOThreadPeriod
+	IOThreadQuota int64 `json:"iothread_quota"`
+}
+
+// NUMA NUMA placement of a domain. Host NUMA nodes are numbered as in
+// /sys/devices/system/node, node sets are CPUSets of node numbers.
+type NUMA struct {
+	// Mode Memory placement policy on the host nodes: strict, preferred,
+	// interleave or restrictive. Empty when memory isn't bound.
+	Mode string `json:"mode"`
+	// Nodes Host nodes the domain memory is allocated from, empty when
+	// unbound
+	Nodes CPUSet `json:"nodes"`
+	// Cells Guest NUMA cells, empty when the guest sees a single node
+	Cells []NUMACell `json:"cells"`
+}
+
+// NUMACell Guest NUMA cell
+type NUMACell struct {
+	ID uint64 `json:"id"`
+	// CPUs vCPUs of the cell, by vCPU ID
+	CPUs CPUSet `json:"cpus"`
+	// Memory Memory of the cell in bytes
+	Memory uint64 `json:"memory"`
+	// Mode and Nodes Placement of the cell memory on the host nodes, empty
+	// when it follows that of the domain
+	Mode  string `json:"mode"`
+	Nodes CPUSet `json:"nodes"`
+}
+
+// Memory Domain memory statistics, only populated when requested.
+// Fields the hypervisor can't report are left zero with their *Set flag false.
+type Memory struct {
+	// Actual Current balloon size in bytes
+	Actual    uint64 `json:"actual"`
+	ActualSet bool   `json:"actual_set"`
+	// Available Total memory visible to the guest in bytes
+	Available    uint64 `json:"available"`
+	AvailableSet bool   `json:"available_set"`
+	// Unused Memory left completely unused by the guest in bytes
+	Unused    uint64 `json:"unused"`
+	UnusedSet bool   `json:"unused_set"`
+	// RSS Resident set size of the hypervisor process in bytes
+	RSS    uint64 `json:"rss"`
+	RSSSet bool   `json:"rss_set"`
+	// SwapIn Bytes swapped in by the guest
+	SwapIn    uint64 `json:"swap_in"`
+	SwapInSet bool   `json:"swap_in_set"`
+	// SwapOut Bytes swapped out by the guest
+	SwapOut    uint64 `json:"swap_out"`
+	SwapOutSet bool   `json:"swap_out_set"`
+	// MajorFaults Number of major page faults in the guest
+	MajorFaults    uint64 `json:"major_faults"`
+	MajorFaultsSet bool   `json:"major_faults_set"`
+	// MinorFaults Number of minor page faults in the guest
+	MinorFaults    uint64 `json:"minor_faults"`
+	MinorFaultsSet bool   `json:"minor_faults_set"`
+	// DirtyRate 4 KiB pages of guest memory written per second, measured
+	// over a second, valid when DirtyRateSet. Only measured with
+	// CollectOptions.DirtyRate.
+	DirtyRate    uint64 `json:"dirty_rate"`
+	DirtyRateSet bool   `json:"dirty_rate_set"`
+}
+
+// MemoryBacking Balloon and backing configuration of domain memory
+type MemoryBacking struct {
+	// BalloonCurrent Memory the balloon currently targets in bytes, 0 when
+	// unknown
+	BalloonCurrent uint64 `json:"balloon_current"`
+	// BalloonMaximum Memory the balloon can grow up to in bytes, 0 when
+	// unknown
+	BalloonMaximum uint64 `json:"balloon_maximum"`
+	// Hugepages Whether guest memory is backed by hugepages
+	Hugepages bool `json:"hugepages"`
+	// HugepageSize Size of the hugepages in bytes, 0 for the host default
+	HugepageSize uint64 `json:"hugepage_size"`
+}
+
+// Filesystem Guest file system usage as reported by the guest agent
+type Filesystem struct {
+	Mountpoint string `json:"mountpoint"`
+	// Name Device name in the guest (e.g. sda1)
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	TotalBytes uint64 `json:"total_bytes"`
+	UsedBytes  uint64 `json:"used_bytes"`
+}
+
+// GraphicsDevice Graphical console a client connects to
+type GraphicsDevice struct {
+	// Type Protocol, vnc or spice
+	Type string `json:"type"`
+	// Listen Address listened on, nil when unknown or on a UNIX socket
+	Listen  net.IP `json:"listen"`
+	Port    int    `json:"port"`
+	TLSPort int    `json:"tls_port"`
+	// Password Whether a password is set, its value is never collected
+	Password bool `json:"password"`
+}
+
+// HostDevice Physical host device assigned to a domain
+type HostDevice struct {
+	// Type Kind of device, pci, usb or mdev
+	Type string `json:"type"`
+	// Address Host address of the device: domain:bus:slot.function for PCI
+	// (e.g. 0000:06:02.0), bus:device for USB and the UUID of a mediated
+	// device
+	Address string `json:"address"`
+	// Name Vendor and product IDs (e.g. 8086:1521), or the model of a
+	// mediated device, empty when unknown
+	Name string `json:"name"`
+	// Description Human readable vendor and product names, empty when unknown
+	Description string `json:"description"`
 }
 
 // NetworkIO Network IO
 type NetworkIO struct {
-	Bytes   uint64
-	Packets uint64
-	Errors  uint64
-	Drops   uint64
+	Bytes   uint64 `json:"bytes"`
+	Packets uint64 `json:"packets"`
+	Errors  uint64 `json:"errors"`
+	Drops   uint64 `json:"drops"`
+	// FIFO, Frame, Collisions and Carrier Breakdown of the errors where the
+	// backend reports it, zero otherwise: FIFO buffer overruns, Frame
+	// received frames with a bad length or checksum, Collisions and Carrier
+	// transmit collisions and carrier losses. Frame is only counted on
+	// receive, Collisions and Carrier on transmit.
+	FIFO       uint64 `json:"fifo"`
+	Frame      uint64 `json:"frame"`
+	Collisions uint64 `json:"collisions"`
+	Carrier    uint64 `json:"carrier"`
 }
 
 // NetworkInterface Network Interface
 type NetworkInterface struct {
-	Name    string
-	Mac     net.HardwareAddr
-	Bridges []string
-	RX      NetworkIO
-	TX      NetworkIO
+	Name string           `json:"name"`
+	Mac  net.HardwareAddr `json:"mac"`
+	// Bridges Host bridges the interface is attached to, nearest first.
+	// Drivers resolve them from the host network devices where they can (see
+	// HostBridges), falling back to the configured bridge.
+	Bridges []string  `json:"bridges"`
+	RX      NetworkIO `json:"rx"`
+	TX      NetworkIO `json:"tx"`
+	// HostDevice Host side network device of the interface (tap, vnet,
+	// macvtap, vif or veth), empty when it has none or it's unknown. Its host
+	// counters mirror RX and TX.
+	HostDevice string `json:"host_device"`
+
+	// Addresses IPv4 and IPv6 addresses configured in the guest, only
+	// populated with CollectOptions.Addresses and empty when unavailable
+	Addresses []net.IPNet `json:"addresses"`
+	// LinkUp Link state of the virtual NIC, valid when LinkUpSet
+	LinkUp    bool `json:"link_up"`
+	LinkUpSet bool `json:"link_up_set"`
+
+	// InboundLimit Bandwidth limit of traffic to the guest in bytes per
+	// second, 0 when unlimited. Only populated with CollectOptions.Limits.
+	InboundLimit uint64 `json:"inbound_limit"`
+	// OutboundLimit Bandwidth limit of traffic from the guest in bytes per
+	// second, 0 when unlimited. Only populated with CollectOptions.Limits.
+	OutboundLimit uint64 `json:"outbound_limit"`
 }
 
-//StringToDomainID Convert string to DomainID
+//StringToDomainID Convert string to DomainID. Invalid IDs silently become 0,
+//indistinguishable from domain 0.
+//
+//Deprecated: use ParseDomainID, which reports invalid IDs.
 func StringToDomainID(id string) DomainID {
-	domid, err := strconv.ParseUint(id, 10, 64)
+	domid, err := ParseDomainID(id)
 	if err != nil {
 		return DomainID(0)
 	}
-	return DomainID(domid)
+	return domid
 }
 
-//AvailableDrivers List of registered driver names
-func AvailableDrivers() (drivers []string) {
-	for name := range Drivers {
-		drivers = append(drivers, name)
+//ParseDomainID Parse a decimal domain ID, returning an error wrapping
+//ErrInvalidDomainID if it isn't one. Use HashDomainID for identifiers that
+//aren't numeric.
+func ParseDomainID(id string) (DomainID, error) {
+	domid, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("driver: %w %q: %w", ErrInvalidDomainID, id, err)
 	}
-	return
+	return DomainID(domid), nil
+}
+
+//HashDomainID Stable DomainID hashed from a non-numeric identifier, such as
+//a container name or hash. Distinct identifiers collide with negligible
+//probability.
+func HashDomainID(id string) DomainID {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return DomainID(h.Sum64())
 }
 
 //IsDriver Test if supplied interface implements the Driver interface
@@ -0,0 +1,81 @@
+package encode
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/virtmonitor/driver"
+)
+
+// csvHeader Columns written by CSV, in order. Appending columns is the only
+// change allowed so existing consumers keep working.
+var csvHeader = []string{
+	"time", "id", "uuid", "name", "hypervisor", "state", "os_type",
+	"vcpus", "cpu_time",
+	"block_delta",
+	"block_read_operations", "block_read_bytes",
+	"block_write_operations", "block_write_bytes",
+	"block_flush_operations",
+	"rx_bytes", "rx_packets", "rx_errors", "rx_drops",
+	"tx_bytes", "tx_packets", "tx_errors", "tx_drops",
+	"memory_actual", "memory_available", "memory_unused", "memory_rss",
+	"memory_swap_in", "memory_swap_out", "memory_major_faults", "memory_minor_faults",
+}
+
+// CSV One row per domain, with its devices summed into flattened columns.
+// CPU time is in nanoseconds, block IO holds either absolute counters or
+// per interval deltas as reported by block_delta, memory columns the driver
+// didn't report are empty.
+type CSV struct {
+	// NoHeader Leave out the header row, e.g. when appending to a file
+	NoHeader bool
+}
+
+// Encode Implements driver.Encoder
+func (c CSV) Encode(w io.Writer, doms map[driver.DomainID]*driver.Domain) error {
+	cw := csv.NewWriter(w)
+	if !c.NoHeader {
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range driver.SortedDomains(doms, driver.SortByID) {
+		if err := cw.Write(csvRow(d)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvRow(d *driver.Domain) []string {
+	t := driver.TotalsOf(map[driver.DomainID]*driver.Domain{d.ID: d})
+	blocks, delta := t.Blocks, false
+	if t.BlockDeltas != (driver.BlockTotals{}) {
+		blocks, delta = t.BlockDeltas, true
+	}
+
+	u := func(v uint64) string { return strconv.FormatUint(v, 10) }
+	opt := func(v uint64, set bool) string {
+		if !set {
+			return ""
+		}
+		return u(v)
+	}
+
+	m := d.Memory
+	return []string{
+		strconv.FormatInt(int64(d.Time), 10), u(uint64(d.ID)), d.UUID, d.Name, string(d.Hypervisor), d.Flags.String(), d.OSType,
+		strconv.Itoa(d.VCPUs), strconv.FormatFloat(t.CPUTime, 'f', -1, 64),
+		strconv.FormatBool(delta),
+		u(blocks.Read.Operations), u(blocks.Read.Bytes),
+		u(blocks.Write.Operations), u(blocks.Write.Bytes),
+		u(blocks.Flush.Operations),
+		u(t.RX.Bytes), u(t.RX.Packets), u(t.RX.Errors), u(t.RX.Drops),
+		u(t.TX.Bytes), u(t.TX.Packets), u(t.TX.Errors), u(t.TX.Drops),
+		opt(m.Actual, m.ActualSet), opt(m.Available, m.AvailableSet), opt(m.Unused, m.UnusedSet), opt(m.RSS, m.RSSSet),
+		opt(m.SwapIn, m.SwapInSet), opt(m.SwapOut, m.SwapOutSet), opt(m.MajorFaults, m.MajorFaultsSet), opt(m.MinorFaults, m.MinorFaultsSet),
+	}
+}
@@ -0,0 +1,4 @@
+// Package encode Encoders writing collections as CSV or Graphite plaintext.
+//
+// Both implement driver.Encoder and write domains in increasing ID order.
+package encode
@@ -0,0 +1,153 @@
+package encode
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// DefaultGraphitePrefix Prefix of the metric paths when Graphite.Prefix is
+// empty
+const DefaultGraphitePrefix = "virtmonitor"
+
+// Graphite Graphite plaintext protocol, one "path value timestamp" line per
+// metric. Paths are <prefix>.<hypervisor>.<domain>.<metric>, with every
+// element sanitized so dots and spaces in names don't add levels. CPU and
+// block IO times are in seconds, timestamps are the domain's Time or the
+// encoding time.
+type Graphite struct {
+	Prefix string
+}
+
+// Encode Implements driver.Encoder
+func (g Graphite) Encode(w io.Writer, doms map[driver.DomainID]*driver.Domain) error {
+	prefix := g.Prefix
+	if prefix == "" {
+		prefix = DefaultGraphitePrefix
+	}
+
+	bw := bufio.NewWriter(w)
+	now := time.Now()
+	for _, d := range driver.SortedDomains(doms, driver.SortByID) {
+		encodeGraphite(bw, prefix, d, now)
+	}
+	return bw.Flush()
+}
+
+func encodeGraphite(w *bufio.Writer, prefix string, d *driver.Domain, now time.Time) {
+	ts := now.Unix()
+	if d.Time != 0 {
+		ts = d.Time.Time().Unix()
+	}
+	stamp := strconv.FormatInt(ts, 10)
+	base := prefix + "." + sanitize(string(d.Hypervisor)) + "." + sanitize(d.Name)
+
+	put := func(value string, path ...string) {
+		w.WriteString(base)
+		for _, p := range path {
+			w.WriteByte('.')
+			w.WriteString(sanitize(p))
+		}
+		w.WriteByte(' ')
+		w.WriteString(value)
+		w.WriteByte(' ')
+		w.WriteString(stamp)
+		w.WriteByte('\n')
+	}
+	u := func(v uint64, path ...string) { put(strconv.FormatUint(v, 10), path...) }
+	f := func(v float64, path ...string) { put(strconv.FormatFloat(v, 'f', -1, 64), path...) }
+
+	u(uint64(d.Flags), "state")
+	if d.GuestAgent != driver.AgentUnknown {
+		u(uint64(d.GuestAgent), "guest_agent")
+	}
+	u(uint64(d.VCPUs), "vcpus")
+
+	for _, cpu := range d.Cpus {
+		id := strconv.FormatUint(cpu.ID, 10)
+		f(cpu.Time/float64(time.Second), "cpu", id, "time")
+	}
+
+	for _, b := range d.Blocks {
+		for _, op := range []struct {
+			name string
+			io   driver.BlockIO
+		}{{"read", b.Read}, {"write", b.Write}, {"flush", b.Flush}} {
+			u(op.io.Operations, "block", b.Name, op.name, "operations")
+			u(op.io.Bytes, "block", b.Name, op.name, "bytes")
+			u(op.io.Errors, "block", b.Name, op.name, "errors")
+			if op.io.TotalTimeSet {
+				f(float64(op.io.TotalTime)/float64(time.Second), "block", b.Name, op.name, "time")
+			}
+		}
+	}
+
+	for _, iface := range d.Interfaces {
+		for _, dir := range []struct {
+			name string
+			io   driver.NetworkIO
+		}{{"rx", iface.RX}, {"tx", iface.TX}} {
+			u(dir.io.Bytes, "network", iface.Name, dir.name, "bytes")
+			u(dir.io.Packets, "network", iface.Name, dir.name, "packets")
+			u(dir.io.Errors, "network", iface.Name, dir.name, "errors")
+			u(dir.io.Drops, "network", iface.Name, dir.name, "drops")
+			u(dir.io.FIFO, "network", iface.Name, dir.name, "fifo_errors")
+		}
+		u(iface.RX.Frame, "network", iface.Name, "rx", "frame_errors")
+		u(iface.TX.Collisions, "network", iface.Name, "tx", "collisions")
+		u(iface.TX.Carrier, "network", iface.Name, "tx", "carrier_errors")
+	}
+
+	m := d.Memory
+	for _, mem := range []struct {
+		name string
+		v    uint64
+		set  bool
+	}{
+		{"actual", m.Actual, m.ActualSet},
+		{"available", m.Available, m.AvailableSet},
+		{"unused", m.Unused, m.UnusedSet},
+		{"rss", m.RSS, m.RSSSet},
+		{"swap_in", m.SwapIn, m.SwapInSet},
+		{"swap_out", m.SwapOut, m.SwapOutSet},
+		{"major_faults", m.MajorFaults, m.MajorFaultsSet},
+		{"minor_faults", m.MinorFaults, m.MinorFaultsSet},
+		{"dirty_rate", m.DirtyRate, m.DirtyRateSet},
+	} {
+		if mem.set {
+			u(mem.v, "memory", mem.name)
+		}
+	}
+
+	for _, fs := range d.Filesystems {
+		u(fs.TotalBytes, "filesystem", fs.Mountpoint, "total_bytes")
+		u(fs.UsedBytes, "filesystem", fs.Mountpoint, "used_bytes")
+	}
+}
+
+// sanitize Make s a single path element: runs of characters other than
+// letters, digits, '-' and '_' become one '_', and an empty element becomes
+// "unknown"
+func sanitize(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range s {
+		ok := r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		switch {
+		case ok:
+			b.WriteRune(r)
+			underscore = false
+		case !underscore:
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	if b.Len() == 0 {
+		return "unknown"
+	}
+	return b.String()
+}
@@ -0,0 +1,18 @@
+package driver
+
+import "io"
+
+// Encoder Serializes a collection to a backend format. Implementations for
+// CSV and Graphite live in the encode package, InfluxDB line protocol in
+// the influx package.
+type Encoder interface {
+	Encode(w io.Writer, doms map[DomainID]*Domain) error
+}
+
+// EncoderFunc Function implementing Encoder
+type EncoderFunc func(w io.Writer, doms map[DomainID]*Domain) error
+
+// Encode Call f
+func (f EncoderFunc) Encode(w io.Writer, doms map[DomainID]*Domain) error {
+	return f(w, doms)
+}
@@ -0,0 +1,162 @@
+package driver
+
+import (
+	"bytes"
+	"net"
+	"slices"
+)
+
+// Equal Test if two domains have the same identity and configuration:
+// name, IDs, type, vCPU, scheduler, NUMA and memory configuration, metadata
+// and device topology. Run state, timestamps, counters and other values
+// changing while the domain runs are ignored, as is the driver-private
+// data. Devices are matched by name (block devices, interfaces), ID (vCPUs,
+// IO threads, NUMA cells), mount point (file systems) or address (host
+// devices) regardless of their order, nil and empty slices are equal.
+func (d *Domain) Equal(other *Domain) bool {
+	return d.equal(other, false)
+}
+
+// EqualCounters Test if two domains are equal including their run state,
+// timestamps and counters, everything but the driver-private data
+func (d *Domain) EqualCounters(other *Domain) bool {
+	return d.equal(other, true)
+}
+
+func (d *Domain) equal(o *Domain, counters bool) bool {
+	if d == nil || o == nil {
+		return d == o
+	}
+
+	if d.Name != o.Name || d.ID != o.ID || d.Hypervisor != o.Hypervisor || d.UUID != o.UUID || d.OSType != o.OSType ||
+		d.MachineType != o.MachineType || d.EmulatorVersion != o.EmulatorVersion ||
+		d.Persistent != o.Persistent || d.PersistentSet != o.PersistentSet ||
+		d.Autostart != o.Autostart || d.AutostartSet != o.AutostartSet ||
+		d.VCPUs != o.VCPUs || d.VCPUsCurrent != o.VCPUsCurrent || d.VCPUsMaximum != o.VCPUsMaximum ||
+		d.NestedVirt != o.NestedVirt || d.NestedVirtSet != o.NestedVirtSet || d.Topology != o.Topology || d.CPUTuning != o.CPUTuning ||
+		d.MemoryBacking.BalloonMaximum != o.MemoryBacking.BalloonMaximum ||
+		d.MemoryBacking.Hugepages != o.MemoryBacking.Hugepages ||
+		d.MemoryBacking.HugepageSize != o.MemoryBacking.HugepageSize ||
+		d.Title != o.Title || d.Description != o.Description ||
+		d.NUMA.Mode != o.NUMA.Mode || !cpuSetEqual(d.NUMA.Nodes, o.NUMA.Nodes) {
+		return false
+	}
+	if counters && (d.Time != o.Time || d.Flags != o.Flags || d.GuestAgent != o.GuestAgent || d.StartTime != o.StartTime ||
+		d.CollectDuration != o.CollectDuration || d.Memory != o.Memory ||
+		d.EmulatorTime != o.EmulatorTime || d.EmulatorTimeSet != o.EmulatorTimeSet ||
+		d.MemoryBacking.BalloonCurrent != o.MemoryBacking.BalloonCurrent) {
+		return false
+	}
+
+	if len(d.Labels) != len(o.Labels) {
+		return false
+	}
+	for k, v := range d.Labels {
+		if w, ok := o.Labels[k]; !ok || v != w {
+			return false
+		}
+	}
+
+	self := func(s string) string { return s }
+	return matchBy(d.Cpus, o.Cpus, func(c CPU) uint64 { return c.ID }, func(a, b CPU) bool { return a.equal(b, counters) }) &&
+		matchBy(d.IOThreads, o.IOThreads, func(t IOThread) uint64 { return t.ID }, func(a, b IOThread) bool {
+			return cpuSetEqual(a.Affinity, b.Affinity) && (!counters || a.Time == b.Time && a.TimeSet == b.TimeSet)
+		}) &&
+		matchBy(d.Blocks, o.Blocks, func(b BlockDevice) string { return b.Name }, func(a, b BlockDevice) bool { return a.equal(b, counters) }) &&
+		matchBy(d.Interfaces, o.Interfaces, func(n NetworkInterface) string { return n.Name }, func(a, b NetworkInterface) bool { return a.equal(b, counters) }) &&
+		matchBy(d.Filesystems, o.Filesystems, func(f Filesystem) string { return f.Mountpoint }, func(a, b Filesystem) bool {
+			return a.Name == b.Name && a.Type == b.Type && a.TotalBytes == b.TotalBytes && (!counters || a.UsedBytes == b.UsedBytes)
+		}) &&
+		matchBy(d.Graphics, o.Graphics, func(g GraphicsDevice) string { return g.Type }, func(a, b GraphicsDevice) bool {
+			return a.Listen.Equal(b.Listen) && a.Port == b.Port && a.TLSPort == b.TLSPort && a.Password == b.Password
+		}) &&
+		matchBy(d.Consoles, o.Consoles, self, func(a, b string) bool { return true }) &&
+		matchBy(d.HostDevices, o.HostDevices, func(h HostDevice) string { return h.Address }, func(a, b HostDevice) bool { return a == b }) &&
+		matchBy(d.NUMA.Cells, o.NUMA.Cells, func(c NUMACell) uint64 { return c.ID }, func(a, b NUMACell) bool {
+			return cpuSetEqual(a.CPUs, b.CPUs) && a.Memory == b.Memory && a.Mode == b.Mode && cpuSetEqual(a.Nodes, b.Nodes)
+		})
+}
+
+func (c CPU) equal(o CPU, counters bool) bool {
+	if !cpuSetEqual(c.Affinity, o.Affinity) || c.NUMANode != o.NUMANode || c.NUMANodeSet != o.NUMANodeSet {
+		return false
+	}
+	if !counters {
+		return true
+	}
+	return c.Flags == o.Flags && c.Time == o.Time && c.Idle == o.Idle && c.IdleSet == o.IdleSet &&
+		c.Steal == o.Steal && c.StealSet == o.StealSet && c.IOWait == o.IOWait && c.IOWaitSet == o.IOWaitSet &&
+		c.Load1 == o.Load1 && c.Load5 == o.Load5 && c.Load15 == o.Load15 &&
+		c.PhysicalCPU == o.PhysicalCPU && c.PhysicalCPUSet == o.PhysicalCPUSet
+}
+
+func (b BlockDevice) equal(o BlockDevice, counters bool) bool {
+	if b.ReadOnly != o.ReadOnly || b.IsDisk != o.IsDisk || b.IsCDrom != o.IsCDrom || b.Stalled != o.Stalled ||
+		b.Bus != o.Bus || b.Target != o.Target || b.Source != o.Source ||
+		b.Capacity != o.Capacity || b.Limits != o.Limits || !slices.Equal(b.BackingChain, o.BackingChain) {
+		return false
+	}
+	if !counters {
+		return true
+	}
+	return b.Read == o.Read && b.Write == o.Write && b.Flush == o.Flush &&
+		b.Allocation == o.Allocation && b.Physical == o.Physical && b.Throttled == o.Throttled
+}
+
+func (n NetworkInterface) equal(o NetworkInterface, counters bool) bool {
+	if !bytes.Equal(n.Mac, o.Mac) || n.HostDevice != o.HostDevice || n.InboundLimit != o.InboundLimit || n.OutboundLimit != o.OutboundLimit ||
+		!matchBy(n.Bridges, o.Bridges, func(s string) string { return s }, func(a, b string) bool { return true }) {
+		return false
+	}
+	if !counters {
+		return true
+	}
+	return n.RX == o.RX && n.TX == o.TX && n.LinkUp == o.LinkUp && n.LinkUpSet == o.LinkUpSet &&
+		matchBy(n.Addresses, o.Addresses, func(a net.IPNet) string { return a.String() }, func(a, b net.IPNet) bool { return true })
+}
+
+// cpuSetEqual Test if two CPU sets hold the same CPUs, trailing empty words
+// aside
+func cpuSetEqual(a, b CPUSet) bool {
+	for len(a) > 0 && a[len(a)-1] == 0 {
+		a = a[:len(a)-1]
+	}
+	for len(b) > 0 && b[len(b)-1] == 0 {
+		b = b[:len(b)-1]
+	}
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchBy Test if a and b hold the same elements in any order, pairing
+// them by key and comparing the pairs with eq
+func matchBy[T any, K comparable](a, b []T, key func(T) K, eq func(T, T) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	if len(a) == 0 {
+		return true
+	}
+
+	byKey := make(map[K][]T, len(b))
+	for _, v := range b {
+		k := key(v)
+		byKey[k] = append(byKey[k], v)
+	}
+	for _, v := range a {
+		k := key(v)
+		candidates := byKey[k]
+		if len(candidates) == 0 || !eq(v, candidates[0]) {
+			return false
+		}
+		byKey[k] = candidates[1:]
+	}
+	return true
+}
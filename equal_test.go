@@ -0,0 +1,103 @@
+package driver_test
+
+import (
+	"testing"
+
+	"github.com/virtmonitor/driver"
+)
+
+func TestEqualNilAndEmpty(t *testing.T) {
+	nilSlices := &driver.Domain{Name: "vm", ID: 1}
+	empty := &driver.Domain{
+		Name: "vm", ID: 1,
+		Cpus: []driver.CPU{}, IOThreads: []driver.IOThread{}, Blocks: []driver.BlockDevice{},
+		Interfaces: []driver.NetworkInterface{}, Filesystems: []driver.Filesystem{},
+		Graphics: []driver.GraphicsDevice{}, Consoles: []string{}, HostDevices: []driver.HostDevice{},
+		Labels: map[string]string{},
+		NUMA:   driver.NUMA{Nodes: driver.CPUSet{}, Cells: []driver.NUMACell{}},
+	}
+	if !nilSlices.Equal(empty) || !empty.Equal(nilSlices) || !nilSlices.EqualCounters(empty) {
+		t.Error("nil and empty slices differ")
+	}
+
+	// Within devices too
+	a, b := fullDomain(), fullDomain()
+	a.Blocks[0].BackingChain, b.Blocks[0].BackingChain = nil, []string{}
+	a.Interfaces[0].Bridges, b.Interfaces[0].Bridges = nil, []string{}
+	a.Cpus[0].Affinity, b.Cpus[0].Affinity = nil, driver.CPUSet{}
+	if !a.EqualCounters(b) {
+		t.Error("nil and empty device slices differ")
+	}
+
+	var none *driver.Domain
+	if !none.Equal(nil) || none.Equal(nilSlices) || nilSlices.Equal(nil) {
+		t.Error("nil domains compare wrongly")
+	}
+}
+
+func TestEqualNested(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(d *driver.Domain)
+		// counter Only EqualCounters tells the change
+		counter bool
+	}{
+		{"Topology", func(d *driver.Domain) { d.Topology.ThreadsPerCore = 2 }, false},
+		{"CPUTuning", func(d *driver.Domain) { d.CPUTuning.EmulatorQuota++ }, false},
+		{"NUMA.Nodes", func(d *driver.Domain) { d.NUMA.Nodes = cpuSet("0-1") }, false},
+		{"NUMA.Cells.CPUs", func(d *driver.Domain) { d.NUMA.Cells[0].CPUs = cpuSet("0-1,5") }, false},
+		{"NUMA.Cells.Memory", func(d *driver.Domain) { d.NUMA.Cells[0].Memory /= 2 }, false},
+		{"MemoryBacking.HugepageSize", func(d *driver.Domain) { d.MemoryBacking.HugepageSize = 1 << 30 }, false},
+		{"IOThreads.Affinity", func(d *driver.Domain) { d.IOThreads[0].Affinity = cpuSet("0,2-3") }, false},
+		{"Cpus.Affinity", func(d *driver.Domain) { d.Cpus[0].Affinity = cpuSet("0-4,8") }, false},
+		{"Cpus.NUMANode", func(d *driver.Domain) { d.Cpus[0].NUMANode = 0 }, false},
+		{"Blocks.Limits", func(d *driver.Domain) { d.Blocks[0].Limits.WriteIOPS++ }, false},
+		{"Blocks.BackingChain", func(d *driver.Domain) { d.Blocks[0].BackingChain[0] = "/var/lib/libvirt/images/other.qcow2" }, false},
+		{"Interfaces.Mac", func(d *driver.Domain) { d.Interfaces[0].Mac[5]++ }, false},
+		{"Interfaces.Bridges", func(d *driver.Domain) { d.Interfaces[0].Bridges = []string{"br0"} }, false},
+		{"Graphics.Listen", func(d *driver.Domain) { d.Graphics[0].Listen[3]++ }, false},
+		{"HostDevices.Name", func(d *driver.Domain) { d.HostDevices[0].Name = "nic" }, false},
+		{"Filesystems.TotalBytes", func(d *driver.Domain) { d.Filesystems[0].TotalBytes++ }, false},
+		{"Labels", func(d *driver.Domain) { d.Labels["env"] = "staging" }, false},
+
+		{"Memory.RSS", func(d *driver.Domain) { d.Memory.RSS++ }, true},
+		{"MemoryBacking.BalloonCurrent", func(d *driver.Domain) { d.MemoryBacking.BalloonCurrent /= 2 }, true},
+		{"Cpus.Time", func(d *driver.Domain) { d.Cpus[0].Time++ }, true},
+		{"IOThreads.Time", func(d *driver.Domain) { d.IOThreads[0].Time++ }, true},
+		{"Blocks.Read", func(d *driver.Domain) { d.Blocks[0].Read.Bytes++ }, true},
+		{"Blocks.Flush", func(d *driver.Domain) { d.Blocks[0].Flush.TotalTimeSet = false }, true},
+		{"Interfaces.TX", func(d *driver.Domain) { d.Interfaces[0].TX.Carrier++ }, true},
+		{"Interfaces.Addresses", func(d *driver.Domain) { d.Interfaces[0].Addresses = d.Interfaces[0].Addresses[:1] }, true},
+		{"Filesystems.UsedBytes", func(d *driver.Domain) { d.Filesystems[0].UsedBytes++ }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := fullDomain()
+			tt.change(d)
+			if got := fullDomain().Equal(d); got != tt.counter {
+				t.Errorf("Equal() = %v, want %v", got, tt.counter)
+			}
+			if fullDomain().EqualCounters(d) {
+				t.Error("EqualCounters() = true")
+			}
+		})
+	}
+
+	// Device order doesn't matter
+	a, b := fullDomain(), fullDomain()
+	extra := a.Blocks[0]
+	extra.Name = "vdb"
+	a.Blocks = append(a.Blocks, extra)
+	b.Blocks = append([]driver.BlockDevice{extra}, b.Blocks...)
+	if !a.EqualCounters(b) {
+		t.Error("reordered block devices differ")
+	}
+}
+
+func cpuSet(list string) driver.CPUSet {
+	s, err := driver.ParseCPUSet(list)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
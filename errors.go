@@ -0,0 +1,96 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// Sentinel errors wrapped by drivers, test for them with errors.Is
+var (
+	// ErrNoDriver No registered driver detected its hypervisor
+	ErrNoDriver = errors.New("driver: no driver detected")
+	// ErrDomainNotFound The requested domain doesn't exist
+	ErrDomainNotFound = errors.New("driver: domain not found")
+	// ErrPermissionDenied Access to the hypervisor was refused, retrying won't help
+	ErrPermissionDenied = errors.New("driver: permission denied")
+	// ErrHypervisorUnavailable The hypervisor can't be reached, possibly temporarily
+	ErrHypervisorUnavailable = errors.New("driver: hypervisor unavailable")
+	// ErrInvalidUUID A UUID argument is malformed
+	ErrInvalidUUID = errors.New("driver: invalid UUID")
+	// ErrInvalidDomainID A domain ID argument isn't a number
+	ErrInvalidDomainID = errors.New("driver: invalid domain ID")
+	// ErrSampleOrder Two samples aren't in increasing time order
+	ErrSampleOrder = errors.New("driver: samples out of order")
+	// ErrDomainMismatch Two samples belong to different domains
+	ErrDomainMismatch = errors.New("driver: samples of different domains")
+	// ErrNotSupported The driver or hypervisor doesn't support the operation
+	ErrNotSupported = errors.New("driver: not supported")
+	// ErrUnknownDriver No driver is registered under the requested name
+	ErrUnknownDriver = errors.New("driver: unknown driver")
+	// ErrInvalidConfig A driver config holds unknown keys, lacks required ones
+	// or has a malformed value
+	ErrInvalidConfig = errors.New("driver: invalid config")
+	// ErrInvalidPattern A CollectOptions.Include or Exclude pattern is
+	// malformed
+	ErrInvalidPattern = errors.New("driver: invalid pattern")
+	// ErrDuplicateDomainID Two domains of a collection report the same ID,
+	// the DomainError wrapping it names the one left out, see AddDomain
+	ErrDuplicateDomainID = errors.New("driver: duplicate domain ID")
+)
+
+// Transient Test if err is worth retrying: it wraps ErrHypervisorUnavailable
+// and neither ErrPermissionDenied nor a context error, a done context
+// failing every retry as well
+func Transient(err error) bool {
+	return errors.Is(err, ErrHypervisorUnavailable) && !errors.Is(err, ErrPermissionDenied) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// DomainError Failure collecting a single domain of a collection. Drivers
+// collecting concurrently join them with the domains collected, see
+// DomainErrors.
+type DomainError struct {
+	ID   DomainID
+	Name string
+	Err  error
+}
+
+func (e *DomainError) Error() string {
+	if e.Name == "" {
+		return "domain " + strconv.FormatUint(uint64(e.ID), 10) + ": " + e.Err.Error()
+	}
+	return "domain " + strconv.Quote(e.Name) + ": " + e.Err.Error()
+}
+
+func (e *DomainError) Unwrap() error {
+	return e.Err
+}
+
+// DomainErrors Per domain failures found in err by domain ID, nil if there
+// are none. Errors of a collection failing as a whole aren't listed, test them
+// with errors.Is as usual.
+func DomainErrors(err error) map[DomainID]error {
+	var errs map[DomainID]error
+	walkDomainErrors(err, func(e *DomainError) {
+		if errs == nil {
+			errs = make(map[DomainID]error)
+		}
+		errs[e.ID] = e
+	})
+	return errs
+}
+
+func walkDomainErrors(err error, fn func(*DomainError)) {
+	switch e := err.(type) {
+	case nil:
+	case *DomainError:
+		fn(e)
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			walkDomainErrors(err, fn)
+		}
+	case interface{ Unwrap() error }:
+		walkDomainErrors(e.Unwrap(), fn)
+	}
+}
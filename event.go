@@ -0,0 +1,12 @@
+package driver
+
+// DomainEvent A domain lifecycle change pushed by Watch
+type DomainEvent struct {
+	ID         DomainID         `json:"id"`
+	UUID       string           `json:"uuid"`
+	Name       string           `json:"name"`
+	Hypervisor DomainHypervisor `json:"hypervisor"`
+	// Flags New state of the domain
+	Flags DomainFlag `json:"flags"`
+	Time  Timestamp  `json:"time"`
+}
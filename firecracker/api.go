@@ -0,0 +1,187 @@
+package firecracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/virtmonitor/driver"
+)
+
+// instanceInfo Response of GET /
+type instanceInfo struct {
+	ID         string `json:"id"`
+	State      string `json:"state"`
+	VMMVersion string `json:"vmm_version"`
+}
+
+// tokenBucket Rate limiter bucket, Size tokens refilled every RefillTime
+// milliseconds
+type tokenBucket struct {
+	Size       uint64 `json:"size"`
+	RefillTime uint64 `json:"refill_time"`
+}
+
+// perSecond Sustained rate of the bucket, 0 when unlimited
+func (b *tokenBucket) perSecond() uint64 {
+	if b == nil || b.RefillTime == 0 {
+		return 0
+	}
+	return b.Size * 1000 / b.RefillTime
+}
+
+type rateLimiter struct {
+	Bandwidth *tokenBucket `json:"bandwidth"`
+	Ops       *tokenBucket `json:"ops"`
+}
+
+type driveConfig struct {
+	DriveID      string       `json:"drive_id"`
+	PathOnHost   string       `json:"path_on_host"`
+	IsRootDevice bool         `json:"is_root_device"`
+	IsReadOnly   bool         `json:"is_read_only"`
+	RateLimiter  *rateLimiter `json:"rate_limiter"`
+}
+
+type netConfig struct {
+	IfaceID       string       `json:"iface_id"`
+	HostDevName   string       `json:"host_dev_name"`
+	GuestMAC      string       `json:"guest_mac"`
+	RxRateLimiter *rateLimiter `json:"rx_rate_limiter"`
+	TxRateLimiter *rateLimiter `json:"tx_rate_limiter"`
+}
+
+type machineConfig struct {
+	VCPUCount  int    `json:"vcpu_count"`
+	MemSizeMiB uint64 `json:"mem_size_mib"`
+	HugePages  string `json:"huge_pages"`
+}
+
+// vmConfig Response of GET /vm/config
+type vmConfig struct {
+	Balloon *struct {
+		AmountMiB uint64 `json:"amount_mib"`
+	} `json:"balloon"`
+	Drives            []driveConfig `json:"drives"`
+	MachineConfig     machineConfig `json:"machine-config"`
+	NetworkInterfaces []netConfig   `json:"network-interfaces"`
+}
+
+// balloonStats Response of GET /balloon/statistics, the guest reported
+// fields are absent until the guest has reported them
+type balloonStats struct {
+	ActualMiB       uint64  `json:"actual_mib"`
+	SwapIn          *uint64 `json:"swap_in"`
+	SwapOut         *uint64 `json:"swap_out"`
+	MajorFaults     *uint64 `json:"major_faults"`
+	MinorFaults     *uint64 `json:"minor_faults"`
+	FreeMemory      *uint64 `json:"free_memory"`
+	TotalMemory     *uint64 `json:"total_memory"`
+	AvailableMemory *uint64 `json:"available_memory"`
+}
+
+// apiError The API answered with an error status
+type apiError struct {
+	Method string
+	Path   string
+	Status int
+	Fault  string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("firecracker: %s %s: %d %s", e.Method, e.Path, e.Status, e.Fault)
+}
+
+// Unwrap Map the status onto a driver sentinel
+func (e *apiError) Unwrap() error {
+	if e.Status == http.StatusNotFound || e.Status == http.StatusNotImplemented {
+		return driver.ErrNotSupported
+	}
+	return nil
+}
+
+// client HTTP client of the API socket of one microVM
+type client struct {
+	http *http.Client
+}
+
+func newClient(path string) *client {
+	return &client{http: &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+		MaxIdleConns: 1,
+	}}}
+}
+
+// get Decode the response to GET path into out
+func (c *client) get(ctx context.Context, path string, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+// put PUT body to path, discarding the response
+func (c *client) put(ctx context.Context, path string, body interface{}) error {
+	return c.do(ctx, http.MethodPut, path, body, nil)
+}
+
+func (c *client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	// The host is ignored, requests go over the socket
+	req, err := http.NewRequestWithContext(ctx, method, "http://localhost"+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var fault struct {
+			FaultMessage string `json:"fault_message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&fault)
+		return &apiError{Method: method, Path: path, Status: resp.StatusCode, Fault: fault.FaultMessage}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("firecracker: %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// close Drop the idle connection
+func (c *client) close() {
+	c.http.CloseIdleConnections()
+}
+
+// connError Wrap a failure to connect to or talk over an API socket
+func connError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("firecracker: %w: %w", driver.ErrPermissionDenied, err)
+	}
+	return fmt.Errorf("firecracker: %w: %w", driver.ErrHypervisorUnavailable, err)
+}
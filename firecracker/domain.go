@@ -0,0 +1,203 @@
+package firecracker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"slices"
+
+	"github.com/virtmonitor/driver"
+)
+
+// anonymousID Instance ID of microVMs started without --id
+const anonymousID = "anonymous-instance"
+
+const mib = 1 << 20
+
+// collectDomain Collect the microVM behind the API socket path into a
+// domain of r, nil if opts.Filter excludes it
+func collectDomain(ctx context.Context, v *vm, path string, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	now := driver.TimestampNow()
+	var info instanceInfo
+	if err := v.client.get(ctx, "/", &info); err != nil {
+		return nil, err
+	}
+
+	// Sockets are often shared names under per VM jailer directories, the
+	// instance ID is the better name when one was given
+	name := info.ID
+	if name == "" || name == anonymousID {
+		name = socketName(path)
+	}
+	id := socketID(path)
+	if !opts.Keep(name, "", id) {
+		return nil, nil
+	}
+
+	d := r.Domain(id)
+	d.Name, d.ID, d.Hypervisor, d.Time = name, id, Hypervisor, now
+	d.Flags = domainFlag(info.State)
+	d.SetPrivate(path)
+	if opts.Skips(d.Flags) {
+		return d, nil
+	}
+
+	var config vmConfig
+	if err := v.client.get(ctx, "/vm/config", &config); err != nil {
+		return nil, err
+	}
+	d.VCPUs = config.MachineConfig.VCPUCount
+
+	if opts.Blocks || opts.Interfaces {
+		// Fails until metrics are configured, the totals are left as they are
+		if err := v.client.put(ctx, "/actions", map[string]string{"action_type": "FlushMetrics"}); err != nil {
+			var aerr *apiError
+			if !errors.As(err, &aerr) {
+				return nil, err
+			}
+			driver.GetLogger().Debug("flushing metrics failed", "driver", Hypervisor, "domain", d.Name, "error", err)
+		}
+		v.metrics.update()
+	}
+
+	if opts.CPUs {
+		// Firecracker has no vCPU hotplug
+		d.VCPUsCurrent, d.VCPUsMaximum = d.VCPUs, d.VCPUs
+	}
+	if opts.Blocks {
+		d.Blocks = collectBlocks(d.Blocks, config.Drives, v.metrics, opts.BlockCapacity, opts.Limits)
+	}
+	if opts.Interfaces {
+		d.Interfaces = collectInterfaces(d.Interfaces, config.NetworkInterfaces, v.metrics, opts.Limits)
+	}
+	if opts.Memory {
+		if err := collectMemory(ctx, v.client, &config, d); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d, nil
+}
+
+// collectBlocks Drives named after their drive ID, Firecracker has a single
+// virtio bus and doesn't know the guest device names. Appended to blocks
+// emptied.
+func collectBlocks(blocks []driver.BlockDevice, drives []driveConfig, m *metrics, capacity, limits bool) []driver.BlockDevice {
+	blocks = slices.Grow(blocks[:0], len(drives))
+	for _, drive := range drives {
+		totals := m.block(drive.DriveID)
+		block := driver.BlockDevice{
+			Name:     drive.DriveID,
+			ReadOnly: drive.IsReadOnly,
+			IsDisk:   true,
+			Bus:      "virtio",
+			Source:   drive.PathOnHost,
+			Read:     totals.read,
+			Write:    totals.write,
+			Flush:    totals.flush,
+		}
+		// Drives are raw images or block devices, their size is the capacity
+		if capacity {
+			if fi, err := os.Stat(drive.PathOnHost); err == nil && fi.Mode().IsRegular() {
+				block.Capacity, block.Physical = uint64(fi.Size()), uint64(fi.Size())
+			}
+		}
+		// A single rate limiter covers reads and writes
+		if limits && drive.RateLimiter != nil {
+			block.Limits.TotalBytesSec = drive.RateLimiter.Bandwidth.perSecond()
+			block.Limits.TotalIOPS = drive.RateLimiter.Ops.perSecond()
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// collectInterfaces Interfaces named after their tap device on the host,
+// appended to ifaces emptied
+func collectInterfaces(ifaces []driver.NetworkInterface, nets []netConfig, m *metrics, limits bool) []driver.NetworkInterface {
+	ifaces = slices.Grow(ifaces[:0], len(nets))
+	for _, n := range nets {
+		iface := driver.NetworkInterface{Name: n.HostDevName, HostDevice: n.HostDevName}
+		if iface.Name == "" {
+			iface.Name = n.IfaceID
+		}
+		iface.Bridges = driver.HostBridges(n.HostDevName)
+		if mac, err := net.ParseMAC(n.GuestMAC); err == nil {
+			iface.Mac = mac
+		}
+		iface.RX, iface.TX = m.iface(n.IfaceID)
+		// Received by the guest is transmitted by the host, rx limits
+		// traffic to the guest
+		if limits {
+			if n.RxRateLimiter != nil {
+				iface.InboundLimit = n.RxRateLimiter.Bandwidth.perSecond()
+			}
+			if n.TxRateLimiter != nil {
+				iface.OutboundLimit = n.TxRateLimiter.Bandwidth.perSecond()
+			}
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces
+}
+
+// collectMemory Configured memory, and the balloon statistics if the
+// microVM has a balloon with statistics enabled
+func collectMemory(ctx context.Context, c *client, config *vmConfig, d *driver.Domain) error {
+	size := config.MachineConfig.MemSizeMiB * mib
+	d.Memory.Actual, d.Memory.ActualSet = size, true
+	d.MemoryBacking.BalloonMaximum = size
+	if pages := config.MachineConfig.HugePages; pages != "" && pages != "None" {
+		d.MemoryBacking.Hugepages = true
+		if pages == "2M" {
+			d.MemoryBacking.HugepageSize = 2 * mib
+		}
+	}
+
+	if config.Balloon != nil {
+		var stats balloonStats
+		err := c.get(ctx, "/balloon/statistics", &stats)
+		var aerr *apiError
+		switch {
+		case err == nil:
+			d.Memory.Actual = size - min(stats.ActualMiB*mib, size)
+			setMemory(&d.Memory.Available, &d.Memory.AvailableSet, stats.TotalMemory)
+			setMemory(&d.Memory.Unused, &d.Memory.UnusedSet, stats.FreeMemory)
+			setMemory(&d.Memory.SwapIn, &d.Memory.SwapInSet, stats.SwapIn)
+			setMemory(&d.Memory.SwapOut, &d.Memory.SwapOutSet, stats.SwapOut)
+			setMemory(&d.Memory.MajorFaults, &d.Memory.MajorFaultsSet, stats.MajorFaults)
+			setMemory(&d.Memory.MinorFaults, &d.Memory.MinorFaultsSet, stats.MinorFaults)
+		case errors.As(err, &aerr):
+			// Statistics are disabled, the balloon target is all there is
+			d.Memory.Actual = size - min(config.Balloon.AmountMiB*mib, size)
+		default:
+			return err
+		}
+	}
+	d.MemoryBacking.BalloonCurrent = d.Memory.Actual
+	return nil
+}
+
+func setMemory(value *uint64, set *bool, v *uint64) {
+	if v != nil {
+		*value, *set = *v, true
+	}
+}
+
+// domainFlag Map a Firecracker instance state onto a DomainFlag, a microVM
+// not started yet is configured but not running
+func domainFlag(state string) driver.DomainFlag {
+	switch state {
+	case "Running":
+		return driver.DomainOnline
+	case "Paused":
+		return driver.DomainPaused
+	default:
+		return driver.DomainShutdown
+	}
+}
@@ -0,0 +1,357 @@
+// Package firecracker Driver collecting Firecracker microVMs through their
+// API sockets.
+//
+// Every socket matching the configured pattern in the configured directory is
+// the API socket of one microVM. Block and network counters come from the
+// metrics file Firecracker was configured with (PUT /metrics), expected next
+// to the socket with the .metrics extension (e.g. vm1.socket and
+// vm1.metrics). Metrics are flushed on every collection and summed by the
+// driver, as Firecracker only writes the change since the previous flush:
+// counters start from the first line the driver reads. Domains carry the
+// path of their API socket as private data, a string retrieved with
+// driver.Private[string]. Importing the package registers the driver under
+// the name "firecracker" using DefaultDirectory and DefaultPattern;
+// driver.NewDriver takes the directory and pattern keys.
+package firecracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/internal/hvutil"
+)
+
+const (
+	// Hypervisor Hypervisor name reported by the Firecracker driver
+	Hypervisor driver.DomainHypervisor = "firecracker"
+	// DefaultDirectory Default directory scanned for API sockets
+	DefaultDirectory = "/run/firecracker"
+	// DefaultPattern Default glob matching API sockets in the directory
+	DefaultPattern = "*.socket"
+	// MetricsExt Extension replacing the socket's to find its metrics file
+	MetricsExt = ".metrics"
+)
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New(DefaultDirectory, DefaultPattern)); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		Keys: []string{"directory", "pattern"},
+		New:  fromConfig,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// fromConfig Create a Firecracker driver from the directory and pattern keys
+// of a driver.Config, a malformed pattern is an error
+func fromConfig(cfg driver.Config) (driver.Driver, error) {
+	pattern := cfg.String("pattern", DefaultPattern)
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("%w: pattern %q: %w", driver.ErrInvalidConfig, pattern, err)
+	}
+	return New(cfg.String("directory", DefaultDirectory), pattern), nil
+}
+
+// Firecracker Firecracker driver
+type Firecracker struct {
+	dir     string
+	pattern string
+
+	mu  sync.Mutex
+	vms map[string]*vm
+}
+
+// vm API client and metrics of the microVM behind a socket
+type vm struct {
+	client  *client
+	metrics *metrics
+}
+
+// New Create a Firecracker driver scanning dir for API sockets matching the
+// glob pattern
+func New(dir, pattern string) *Firecracker {
+	return &Firecracker{
+		dir:     dir,
+		pattern: pattern,
+		vms:     make(map[string]*vm),
+	}
+}
+
+// Name Hypervisor name
+func (f *Firecracker) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Capabilities Supported metrics. Firecracker has no per vCPU statistics,
+// CPUs are limited to their count, and guest memory statistics need a
+// balloon device with statistics enabled.
+func (f *Firecracker) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:          true,
+		SupportsBlocks:        true,
+		SupportsInterfaces:    true,
+		SupportsMemory:        true,
+		SupportsBlockCapacity: true,
+		SupportsLimits:        true,
+	}
+}
+
+// Detect Test if any API sockets are present
+func (f *Firecracker) Detect() bool {
+	return f.Diagnose().Detected
+}
+
+// Diagnose Report the API sockets found. Sockets aren't connected to, a
+// stale one left by an exited microVM still counts.
+func (f *Firecracker) Diagnose() driver.DetectResult {
+	where := filepath.Join(f.dir, f.pattern)
+	sockets, err := f.sockets()
+	switch {
+	case err != nil:
+		return driver.DetectResult{Reason: "listing " + where + " failed: " + err.Error(), Err: err}
+	case len(sockets) == 0:
+		return driver.DetectResult{
+			Reason: "no socket found matching " + where,
+			Err:    fmt.Errorf("firecracker: no API sockets: %w", driver.ErrHypervisorUnavailable),
+		}
+	}
+	return driver.DetectResult{Detected: true, Reason: fmt.Sprintf("%d API sockets", len(sockets))}
+}
+
+// Collect Collect microVMs
+func (f *Firecracker) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return f.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect microVMs from every socket concurrently
+func (f *Firecracker) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	sockets, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) { return f.sockets() })
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.CollectDomains(ctx, opts, sockets, f.collector(opts, nil))
+}
+
+// CollectInto Collect microVMs as CollectContext does into dst, reusing its
+// domains, see driver.CollectInto
+func (f *Firecracker) CollectInto(ctx context.Context, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	sockets, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) { return f.sockets() })
+	if err != nil {
+		clear(dst)
+		return err
+	}
+
+	return driver.CollectDomainsInto(ctx, opts, sockets, dst, func(ctx context.Context, path string, r *driver.Recycler) (*driver.Domain, error) {
+		return f.collector(opts, r)(ctx, path)
+	})
+}
+
+// CollectStream Collect microVMs as CollectContext does, emitting each as
+// soon as it's collected
+func (f *Firecracker) CollectStream(ctx context.Context, opts driver.CollectOptions, emit func(*driver.Domain) error) error {
+	sockets, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) { return f.sockets() })
+	if err != nil {
+		return err
+	}
+
+	return driver.StreamDomains(ctx, opts, sockets, f.collector(opts, nil), emit)
+}
+
+// collector Collect function of the sockets listed by CollectContext,
+// CollectInto and CollectStream, filling the domains of r
+func (f *Firecracker) collector(opts driver.CollectOptions, r *driver.Recycler) func(context.Context, string) (*driver.Domain, error) {
+	return func(ctx context.Context, path string) (*driver.Domain, error) {
+		d, err := f.collectSocket(ctx, path, opts, r)
+		if err != nil {
+			return nil, &driver.DomainError{ID: socketID(path), Name: socketName(path), Err: err}
+		}
+		return d, nil
+	}
+}
+
+// CollectDomain Collect a single microVM by ID
+func (f *Firecracker) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := f.find(context.Background(), opts, func(d *driver.Domain) bool { return d.ID == id })
+	if err == nil && d == nil {
+		err = fmt.Errorf("firecracker: domain %d: %w", id, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// CollectDomainByUUID microVMs have no UUID, any valid UUID is a miss
+func (f *Firecracker) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	if !driver.ValidUUID(uuid) {
+		return nil, fmt.Errorf("firecracker: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+	return nil, fmt.Errorf("firecracker: domain %s: %w", uuid, driver.ErrDomainNotFound)
+}
+
+// CollectDomainByName Collect a single microVM by name, scanning every socket
+func (f *Firecracker) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := f.find(context.Background(), opts, func(d *driver.Domain) bool { return d.Name == name })
+	if err == nil && d == nil {
+		err = fmt.Errorf("firecracker: domain %q: %w", name, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// find Collect the first microVM accepted by match, or nil if none is. Every
+// socket is queried for its identity before the matching one is collected
+// in full.
+func (f *Firecracker) find(ctx context.Context, opts driver.CollectOptions, match func(*driver.Domain) bool) (*driver.Domain, error) {
+	opts = opts.Unfiltered()
+	sockets, err := f.sockets()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range sockets {
+		d, err := f.collectSocket(ctx, path, driver.CollectOptions{}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if d != nil && match(d) {
+			return f.collectSocket(ctx, path, opts, nil)
+		}
+	}
+	return nil, nil
+}
+
+// CollectSnapshots Firecracker snapshots are files written on request, not
+// tracked by the microVM
+func (f *Firecracker) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	return nil, fmt.Errorf("firecracker: snapshots: %w", driver.ErrNotSupported)
+}
+
+// Host Metrics of the local host, where the API sockets live
+func (f *Firecracker) Host() (*driver.HostInfo, error) {
+	return driver.LocalHostInfo()
+}
+
+// Ping Query the instance information of every microVM, joining the
+// failures. Stale sockets are skipped as in collections.
+func (f *Firecracker) Ping(ctx context.Context) error {
+	sockets, err := f.sockets()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, path := range sockets {
+		var info instanceInfo
+		if err := f.vm(path).client.get(ctx, "/", &info); err != nil {
+			if hvutil.Stale(err) {
+				f.drop(path)
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, apiOrConnError(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Watch Firecracker has no event stream
+func (f *Firecracker) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	return nil, fmt.Errorf("firecracker: watch: %w", driver.ErrNotSupported)
+}
+
+// Close Close the API connections and metrics files
+func (f *Firecracker) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for path, v := range f.vms {
+		v.client.close()
+		v.metrics.close()
+		delete(f.vms, path)
+	}
+	return nil
+}
+
+// sockets List the API sockets currently present
+func (f *Firecracker) sockets() ([]string, error) {
+	return filepath.Glob(filepath.Join(f.dir, f.pattern))
+}
+
+// vm State of the microVM behind path, created on first use
+func (f *Firecracker) vm(path string) *vm {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	v, ok := f.vms[path]
+	if !ok {
+		v = &vm{
+			client:  newClient(path),
+			metrics: newMetrics(path[:len(path)-len(filepath.Ext(path))] + MetricsExt),
+		}
+		f.vms[path] = v
+	}
+	return v
+}
+
+// drop Forget the microVM behind path, its counters start over if the
+// socket comes back
+func (f *Firecracker) drop(path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if v, ok := f.vms[path]; ok {
+		v.client.close()
+		v.metrics.close()
+		delete(f.vms, path)
+	}
+}
+
+// collectSocket Collect the microVM behind a socket into a domain of r, a
+// nil domain means the socket is stale (its microVM has exited) or the
+// domain is filtered out
+func (f *Firecracker) collectSocket(ctx context.Context, path string, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	d, err := collectDomain(ctx, f.vm(path), path, opts, r)
+	if err != nil {
+		if hvutil.Stale(err) {
+			driver.GetLogger().Debug("skipping stale API socket", "driver", Hypervisor, "socket", path, "error", err)
+			f.drop(path)
+			return nil, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, apiOrConnError(err)
+	}
+	return d, nil
+}
+
+// apiOrConnError Return API errors as is, wrapping transport failures
+func apiOrConnError(err error) error {
+	var aerr *apiError
+	if errors.As(err, &aerr) {
+		return err
+	}
+	return connError(err)
+}
+
+// socketID Domain ID from a numeric socket name (e.g. 101.socket), otherwise
+// a stable hash of the socket path. Jailed microVMs share socket names, and
+// sockets failing to answer have no instance ID to go by.
+func socketID(path string) driver.DomainID {
+	if id, err := driver.ParseDomainID(socketName(path)); err == nil {
+		return id
+	}
+	return driver.HashDomainID(path)
+}
+
+// socketName Socket file name without its extension
+func socketName(path string) string {
+	base := filepath.Base(path)
+	return base[:len(base)-len(filepath.Ext(base))]
+}
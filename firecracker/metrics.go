@@ -0,0 +1,230 @@
+package firecracker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// fifoWait How long a metrics FIFO is read once drained, in case the line
+// written by the flush is still in flight
+const fifoWait = 10 * time.Millisecond
+
+// latencyAgg Latency aggregate of a block device, in microseconds
+type latencyAgg struct {
+	SumUs uint64 `json:"sum_us"`
+}
+
+// blockMetrics Per drive section (block_<drive_id>) of a metrics line
+type blockMetrics struct {
+	ReadBytes  uint64      `json:"read_bytes"`
+	WriteBytes uint64      `json:"write_bytes"`
+	ReadCount  uint64      `json:"read_count"`
+	WriteCount uint64      `json:"write_count"`
+	FlushCount uint64      `json:"flush_count"`
+	ReadAgg    *latencyAgg `json:"read_agg"`
+	WriteAgg   *latencyAgg `json:"write_agg"`
+}
+
+// netMetrics Per interface section (net_<iface_id>) of a metrics line
+type netMetrics struct {
+	RxBytes   uint64 `json:"rx_bytes_count"`
+	RxPackets uint64 `json:"rx_packets_count"`
+	RxFails   uint64 `json:"rx_fails"`
+	TxBytes   uint64 `json:"tx_bytes_count"`
+	TxPackets uint64 `json:"tx_packets_count"`
+	TxFails   uint64 `json:"tx_fails"`
+}
+
+// blockTotals Cumulative counters of a drive
+type blockTotals struct {
+	read, write, flush driver.BlockIO
+}
+
+// netTotals Cumulative counters of an interface
+type netTotals struct {
+	rx, tx driver.NetworkIO
+}
+
+// metrics Counters of one microVM summed from its metrics file. Firecracker
+// writes the change since the previous flush, every line is read once and
+// added to the totals.
+type metrics struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	fifo    bool
+	partial []byte
+	blocks  map[string]*blockTotals
+	ifaces  map[string]*netTotals
+}
+
+func newMetrics(path string) *metrics {
+	return &metrics{
+		path:   path,
+		blocks: make(map[string]*blockTotals),
+		ifaces: make(map[string]*netTotals),
+	}
+}
+
+// update Add the lines written since the previous update. A missing metrics
+// file leaves the totals alone.
+func (m *metrics) update() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.f == nil {
+		// Non blocking so an unwritten FIFO doesn't block the open
+		f, err := os.OpenFile(m.path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				driver.GetLogger().Debug("metrics unavailable", "driver", Hypervisor, "path", m.path, "error", err)
+			}
+			return
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return
+		}
+		m.f, m.fifo = f, fi.Mode()&os.ModeNamedPipe != 0
+	}
+
+	data, err := m.read()
+	if err != nil {
+		driver.GetLogger().Debug("reading metrics failed", "driver", Hypervisor, "path", m.path, "error", err)
+		m.f.Close()
+		m.f, m.partial = nil, nil
+	}
+
+	data = append(m.partial, data...)
+	end := bytes.LastIndexByte(data, '\n')
+	m.partial = append([]byte(nil), data[end+1:]...)
+	for _, line := range bytes.Split(data[:end+1], []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			m.add(line)
+		}
+	}
+}
+
+// read Everything available in the metrics file. A regular file is read up
+// to its end, a FIFO until it has stayed empty for fifoWait.
+func (m *metrics) read() ([]byte, error) {
+	var buf bytes.Buffer
+	chunk := make([]byte, 32<<10)
+	for {
+		if m.fifo {
+			m.f.SetReadDeadline(time.Now().Add(fifoWait))
+		}
+		n, err := m.f.Read(chunk)
+		buf.Write(chunk[:n])
+		switch {
+		case err == nil:
+			continue
+		case err == io.EOF, errors.Is(err, os.ErrDeadlineExceeded):
+			// A FIFO without a writer reads as EOF as well
+			return buf.Bytes(), nil
+		default:
+			return buf.Bytes(), err
+		}
+	}
+}
+
+// add Add the counters of one metrics line
+func (m *metrics) add(line []byte) {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(line, &sections); err != nil {
+		driver.GetLogger().Debug("skipping malformed metrics line", "driver", Hypervisor, "path", m.path, "error", err)
+		return
+	}
+
+	for key, raw := range sections {
+		switch {
+		case strings.HasPrefix(key, "block_"):
+			var b blockMetrics
+			if json.Unmarshal(raw, &b) != nil {
+				continue
+			}
+			t := m.blocks[key[len("block_"):]]
+			if t == nil {
+				t = &blockTotals{}
+				m.blocks[key[len("block_"):]] = t
+			}
+			addBlockIO(&t.read, b.ReadCount, b.ReadBytes, b.ReadAgg)
+			addBlockIO(&t.write, b.WriteCount, b.WriteBytes, b.WriteAgg)
+			addBlockIO(&t.flush, b.FlushCount, 0, nil)
+		case strings.HasPrefix(key, "net_"):
+			var n netMetrics
+			if json.Unmarshal(raw, &n) != nil {
+				continue
+			}
+			t := m.ifaces[key[len("net_"):]]
+			if t == nil {
+				t = &netTotals{}
+				m.ifaces[key[len("net_"):]] = t
+			}
+			t.rx.Bytes += n.RxBytes
+			t.rx.Packets += n.RxPackets
+			t.rx.Errors += n.RxFails
+			t.tx.Bytes += n.TxBytes
+			t.tx.Packets += n.TxPackets
+			t.tx.Errors += n.TxFails
+		}
+	}
+}
+
+// addBlockIO Add the change of one flush to the totals, the latency sum
+// is converted to nanoseconds
+func addBlockIO(total *driver.BlockIO, ops, n uint64, agg *latencyAgg) {
+	total.Operations += ops
+	total.Bytes += n
+	total.Sectors = total.Bytes / 512
+	total.Absolute = true
+	if agg != nil {
+		total.TotalTime += agg.SumUs * 1000
+		total.TotalTimeSet = true
+	}
+}
+
+// block Totals of a drive, zero until its first metrics line
+func (m *metrics) block(id string) blockTotals {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t := m.blocks[id]; t != nil {
+		return *t
+	}
+	zero := driver.BlockIO{Absolute: true}
+	return blockTotals{read: zero, write: zero, flush: zero}
+}
+
+// iface Receive and transmit totals of an interface, zero until its first
+// metrics line
+func (m *metrics) iface(id string) (rx, tx driver.NetworkIO) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t := m.ifaces[id]; t != nil {
+		return t.rx, t.tx
+	}
+	return rx, tx
+}
+
+// close Close the metrics file
+func (m *metrics) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.f != nil {
+		m.f.Close()
+		m.f = nil
+	}
+}
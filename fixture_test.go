@@ -0,0 +1,169 @@
+package driver_test
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// fullDomain Domain with every exported field, down to its devices, set to a
+// non zero value
+func fullDomain() *driver.Domain {
+	mac, _ := net.ParseMAC("52:54:00:12:34:56")
+	_, v4, _ := net.ParseCIDR("192.168.122.10/24")
+	_, v6, _ := net.ParseCIDR("fe80::5054:ff:fe12:3456/64")
+	v4.IP = net.IPv4(192, 168, 122, 10).To4()
+	v6.IP = net.ParseIP("fe80::5054:ff:fe12:3456")
+	set := func(list string) driver.CPUSet {
+		s, err := driver.ParseCPUSet(list)
+		if err != nil {
+			panic(err)
+		}
+		return s
+	}
+	io := func(n uint64) driver.BlockIO {
+		return driver.BlockIO{Operations: n, Bytes: n * 4096, Sectors: n * 8, Absolute: true, TotalTime: n * 1000, TotalTimeSet: true, Errors: 1}
+	}
+	netIO := func(n uint64) driver.NetworkIO {
+		return driver.NetworkIO{Bytes: n * 1500, Packets: n, Errors: 1, Drops: 2, FIFO: 3, Frame: 4, Collisions: 5, Carrier: 6}
+	}
+
+	return &driver.Domain{
+		Name:            "web-1",
+		ID:              42,
+		Hypervisor:      "libvirt",
+		UUID:            "6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f",
+		OSType:          "hvm",
+		Time:            1700000000000000000,
+		Flags:           driver.DomainPaused,
+		StartTime:       1699990000000000000,
+		Persistent:      true,
+		PersistentSet:   true,
+		Autostart:       true,
+		AutostartSet:    true,
+		MachineType:     "pc-q35-8.2",
+		EmulatorVersion: "8.2.0",
+		VCPUs:           2,
+		VCPUsCurrent:    2,
+		VCPUsMaximum:    4,
+		NestedVirt:      true,
+		NestedVirtSet:   true,
+		Topology:        driver.CPUTopology{Sockets: 1, CoresPerSocket: 2, ThreadsPerCore: 1},
+		CPUTuning: driver.CPUTuning{
+			Shares: 1024, Weight: 100, Period: 100000, Quota: 50000,
+			GlobalPeriod: 100000, GlobalQuota: 200000,
+			EmulatorPeriod: 100000, EmulatorQuota: 10000,
+			IOThreadPeriod: 100000, IOThreadQuota: 20000,
+		},
+		NUMA: driver.NUMA{
+			Mode:  "strict",
+			Nodes: set("0"),
+			Cells: []driver.NUMACell{{ID: 1, CPUs: set("0-1"), Memory: 2 << 30, Mode: "preferred", Nodes: set("0")}},
+		},
+		IOThreads:       []driver.IOThread{{ID: 1, Time: 1.5e9, TimeSet: true, Affinity: set("2-3")}},
+		EmulatorTime:    2.5e9,
+		EmulatorTimeSet: true,
+		Cpus: []driver.CPU{{
+			ID: 1, Flags: driver.CPURunning, Time: 1e10,
+			Idle: 5e9, IdleSet: true,
+			Load1: 0.5, Load5: 0.25, Load15: 0.125,
+			Steal: 1e8, StealSet: true,
+			IOWait: 2e8, IOWaitSet: true,
+			PhysicalCPU: 3, PhysicalCPUSet: true,
+			Affinity: set("0-3,8"),
+			NUMANode: 1, NUMANodeSet: true,
+		}},
+		Blocks: []driver.BlockDevice{{
+			Name: "vda", ReadOnly: true, IsDisk: true, IsCDrom: true,
+			Read: io(10), Write: io(20), Flush: io(5),
+			Stalled:      true,
+			Bus:          "virtio",
+			Target:       "vda",
+			Source:       "/var/lib/libvirt/images/web-1.qcow2",
+			BackingChain: []string{"/var/lib/libvirt/images/base.qcow2"},
+			Capacity:     20 << 30,
+			Allocation:   5 << 30,
+			Physical:     6 << 30,
+			Limits: driver.BlockLimits{
+				ReadIOPS: 100, WriteIOPS: 200, TotalIOPS: 300,
+				ReadBytesSec: 1 << 20, WriteBytesSec: 2 << 20, TotalBytesSec: 3 << 20,
+				Weight: 500,
+			},
+			Throttled: true,
+		}},
+		Interfaces: []driver.NetworkInterface{{
+			Name:          "vnet0",
+			Mac:           mac,
+			Bridges:       []string{"virbr0"},
+			RX:            netIO(100),
+			TX:            netIO(200),
+			HostDevice:    "vnet0",
+			Addresses:     []net.IPNet{*v4, *v6},
+			LinkUp:        true,
+			LinkUpSet:     true,
+			InboundLimit:  1 << 20,
+			OutboundLimit: 2 << 20,
+		}},
+		Memory: driver.Memory{
+			Actual: 2 << 30, ActualSet: true,
+			Available: 1 << 30, AvailableSet: true,
+			Unused: 512 << 20, UnusedSet: true,
+			RSS: 1536 << 20, RSSSet: true,
+			SwapIn: 1, SwapInSet: true,
+			SwapOut: 2, SwapOutSet: true,
+			MajorFaults: 3, MajorFaultsSet: true,
+			MinorFaults: 4, MinorFaultsSet: true,
+			DirtyRate: 5, DirtyRateSet: true,
+		},
+		MemoryBacking:   driver.MemoryBacking{BalloonCurrent: 2 << 30, BalloonMaximum: 4 << 30, Hugepages: true, HugepageSize: 2 << 20},
+		Filesystems:     []driver.Filesystem{{Mountpoint: "/", Name: "vda1", Type: "ext4", TotalBytes: 20 << 30, UsedBytes: 4 << 30}},
+		GuestAgent:      driver.AgentConnected,
+		Title:           "Web server",
+		Description:     "Serves the public site",
+		Labels:          map[string]string{"env": "prod"},
+		Graphics:        []driver.GraphicsDevice{{Type: "vnc", Listen: net.IPv4(127, 0, 0, 1).To4(), Port: 5900, TLSPort: 5901, Password: true}},
+		Consoles:        []string{"/dev/pts/3"},
+		HostDevices:     []driver.HostDevice{{Type: "pci", Address: "0000:03:00.0", Name: "gpu", Description: "GPU"}},
+		CollectDuration: 3 * time.Millisecond,
+	}
+}
+
+// zeroFields Paths of the exported fields of v left zero, descending into
+// structs and the first element of slices
+func zeroFields(v reflect.Value, path string) []string {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return []string{path}
+		}
+		return zeroFields(v.Elem(), path)
+	case reflect.Struct:
+		var zero []string
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() {
+				zero = append(zero, zeroFields(v.Field(i), path+"."+f.Name)...)
+			}
+		}
+		return zero
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return []string{path}
+		}
+		if v.Type().Elem().Kind() == reflect.Struct {
+			return zeroFields(v.Index(0), path+"[0]")
+		}
+	}
+	if v.IsZero() {
+		return []string{path}
+	}
+	return nil
+}
+
+func TestFullDomainPopulated(t *testing.T) {
+	for _, path := range zeroFields(reflect.ValueOf(fullDomain()), "Domain") {
+		t.Errorf("%s is zero, fullDomain must set every field", path)
+	}
+}
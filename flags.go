@@ -0,0 +1,145 @@
+package driver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var cpuFlagNames = map[CPUFlag]string{
+	CPUOnline:  "online",
+	CPURunning: "running",
+	CPUHalted:  "halted",
+	CPUPaused:  "paused",
+}
+
+var agentStateNames = map[AgentState]string{
+	AgentUnknown:      "unknown",
+	AgentConnected:    "connected",
+	AgentDisconnected: "disconnected",
+}
+
+var domainFlagNames = map[DomainFlag]string{
+	DomainOnline:       "online",
+	DomainShutdown:     "shutdown",
+	DomainCrashed:      "crashed",
+	DomainDying:        "dying",
+	DomainPaused:       "paused",
+	DomainMigrating:    "migrating",
+	DomainSaving:       "saving",
+	DomainMigratingIn:  "migrating_in",
+	DomainMigratingOut: "migrating_out",
+	DomainRestoring:    "restoring",
+}
+
+// String Human readable CPU flag name
+func (f CPUFlag) String() string {
+	if name, ok := cpuFlagNames[f]; ok {
+		return name
+	}
+	return "CPUFlag(" + strconv.Itoa(int(f)) + ")"
+}
+
+// String Human readable domain flag name
+func (f DomainFlag) String() string {
+	if name, ok := domainFlagNames[f]; ok {
+		return name
+	}
+	return "DomainFlag(" + strconv.Itoa(int(f)) + ")"
+}
+
+// Migrating Test if f is one of the migration states, DomainMigrating,
+// DomainMigratingIn or DomainMigratingOut
+func (f DomainFlag) Migrating() bool {
+	return f == DomainMigrating || f == DomainMigratingIn || f == DomainMigratingOut
+}
+
+// String Human readable guest agent state
+func (s AgentState) String() string {
+	if name, ok := agentStateNames[s]; ok {
+		return name
+	}
+	return "AgentState(" + strconv.Itoa(int(s)) + ")"
+}
+
+// ParseCPUFlag Convert a name produced by CPUFlag.String back to a CPUFlag
+func ParseCPUFlag(s string) (CPUFlag, error) {
+	for f, name := range cpuFlagNames {
+		if name == s {
+			return f, nil
+		}
+	}
+	if n, ok := parseNumbered(s, "CPUFlag"); ok {
+		return CPUFlag(n), nil
+	}
+	return 0, fmt.Errorf("driver: unknown CPU flag %q", s)
+}
+
+// ParseDomainFlag Convert a name produced by DomainFlag.String back to a DomainFlag
+func ParseDomainFlag(s string) (DomainFlag, error) {
+	for f, name := range domainFlagNames {
+		if name == s {
+			return f, nil
+		}
+	}
+	if n, ok := parseNumbered(s, "DomainFlag"); ok {
+		return DomainFlag(n), nil
+	}
+	return 0, fmt.Errorf("driver: unknown domain flag %q", s)
+}
+
+// ParseAgentState Convert a name produced by AgentState.String back to an
+// AgentState
+func ParseAgentState(s string) (AgentState, error) {
+	for a, name := range agentStateNames {
+		if name == s {
+			return a, nil
+		}
+	}
+	if n, ok := parseNumbered(s, "AgentState"); ok {
+		return AgentState(n), nil
+	}
+	return 0, fmt.Errorf("driver: unknown agent state %q", s)
+}
+
+// parseNumbered Parse the "Type(N)" form String uses for unnamed values
+func parseNumbered(s, typ string) (int, bool) {
+	if !strings.HasPrefix(s, typ+"(") || !strings.HasSuffix(s, ")") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s[len(typ)+1 : len(s)-1])
+	return n, err == nil
+}
+
+// MarshalText Encode the flag by name
+func (f CPUFlag) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText Decode a flag name
+func (f *CPUFlag) UnmarshalText(text []byte) (err error) {
+	*f, err = ParseCPUFlag(string(text))
+	return
+}
+
+// MarshalText Encode the flag by name
+func (f DomainFlag) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText Decode a flag name
+func (f *DomainFlag) UnmarshalText(text []byte) (err error) {
+	*f, err = ParseDomainFlag(string(text))
+	return
+}
+
+// MarshalText Encode the state by name
+func (s AgentState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText Decode a state name
+func (s *AgentState) UnmarshalText(text []byte) (err error) {
+	*s, err = ParseAgentState(string(text))
+	return
+}
@@ -0,0 +1,25 @@
+package driver
+
+// GroupByHypervisor Domains of a collection by hypervisor, each group sorted
+// by ID. An empty collection gives an empty map.
+func GroupByHypervisor(domains map[DomainID]*Domain) map[DomainHypervisor][]*Domain {
+	return groupBy(domains, func(d *Domain) DomainHypervisor { return d.Hypervisor })
+}
+
+// GroupByState Domains of a collection by state, each group sorted by ID.
+// An empty collection gives an empty map.
+func GroupByState(domains map[DomainID]*Domain) map[DomainFlag][]*Domain {
+	return groupBy(domains, func(d *Domain) DomainFlag { return d.Flags })
+}
+
+func groupBy[K comparable](domains map[DomainID]*Domain, key func(*Domain) K) map[K][]*Domain {
+	groups := make(map[K][]*Domain)
+	for _, d := range domains {
+		k := key(d)
+		groups[k] = append(groups[k], d)
+	}
+	for _, group := range groups {
+		SortDomains(group, SortByID)
+	}
+	return groups
+}
@@ -0,0 +1,225 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/grpc/driverpb"
+)
+
+// Client Driver collecting from a remote Server. Domains keep the
+// hypervisor of the served driver, Name reports it too.
+type Client struct {
+	c    driverpb.DriverClient
+	conn *grpc.ClientConn
+	name driver.DomainHypervisor
+	caps driver.Capabilities
+}
+
+// Dial Connect to the Server at target, see grpc.NewClient for the format
+// of target and the options. Calls fail with ErrHypervisorUnavailable while
+// the server can't be reached, wrap the client with driver.WithReconnect to
+// retry them.
+func Dial(target string, opts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("grpc: dial %s: %w", target, err)
+	}
+
+	c, err := NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c.conn = conn
+	return c, nil
+}
+
+// NewClient Create a client on an existing connection, which Close leaves
+// open. The name and capabilities of the served driver are fetched once.
+func NewClient(conn grpc.ClientConnInterface) (*Client, error) {
+	c := &Client{c: driverpb.NewDriverClient(conn)}
+	info, err := c.c.Info(context.Background(), &driverpb.InfoRequest{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	c.name = driver.DomainHypervisor(info.GetName())
+	c.caps = fromCapabilities(info.GetCapabilities())
+	return c, nil
+}
+
+// Name Hypervisor of the served driver
+func (c *Client) Name() driver.DomainHypervisor {
+	return c.name
+}
+
+// Detect Test if the served driver detects its hypervisor
+func (c *Client) Detect() bool {
+	return c.Diagnose().Detected
+}
+
+// Diagnose Detection of the served driver, or why the server can't be
+// reached
+func (c *Client) Diagnose() driver.DetectResult {
+	info, err := c.c.Info(context.Background(), &driverpb.InfoRequest{})
+	if err != nil {
+		err = fromStatus(err)
+		return driver.DetectResult{Reason: "server unreachable: " + err.Error(), Err: err}
+	}
+	return driver.DetectResult{Detected: info.GetDetected(), Reason: info.GetReason()}
+}
+
+// Capabilities Capabilities of the served driver, as fetched on connection
+func (c *Client) Capabilities() driver.Capabilities {
+	return c.caps
+}
+
+// Collect Collect domains
+func (c *Client) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return c.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect domains, cancelling the call when ctx is done.
+// Include and Exclude are sent to the server, Filter runs on the client once
+// the domains are received. Per domain errors are returned as DomainErrors.
+func (c *Client) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	resp, err := c.c.Collect(ctx, &driverpb.CollectRequest{Options: toOptions(opts)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	domains := make(map[driver.DomainID]*driver.Domain, len(resp.GetDomains()))
+	var errs []error
+	for _, p := range resp.GetDomains() {
+		d := fromDomain(p)
+		if opts.Keep(d.Name, d.UUID, d.ID) {
+			if err := driver.AddDomain(domains, d); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, p := range resp.GetDomainErrors() {
+		errs = append(errs, &driver.DomainError{
+			ID:   driver.DomainID(p.GetId()),
+			Name: p.GetName(),
+			Err:  remoteError(p.GetMessage(), codes.Unknown),
+		})
+	}
+	for _, msg := range resp.GetErrors() {
+		errs = append(errs, remoteError(msg, codes.Unknown))
+	}
+	return domains, errors.Join(errs...)
+}
+
+// CollectDomain Collect a single domain by ID
+func (c *Client) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	return c.collectDomain(&driverpb.CollectDomainRequest{Key: &driverpb.CollectDomainRequest_Id{Id: uint64(id)}}, opts)
+}
+
+// CollectDomainByUUID Collect a single domain by UUID
+func (c *Client) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	if !driver.ValidUUID(uuid) {
+		return nil, fmt.Errorf("grpc: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+	return c.collectDomain(&driverpb.CollectDomainRequest{Key: &driverpb.CollectDomainRequest_Uuid{Uuid: uuid}}, opts)
+}
+
+// CollectDomainByName Collect a single domain by name
+func (c *Client) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	return c.collectDomain(&driverpb.CollectDomainRequest{Key: &driverpb.CollectDomainRequest_Name{Name: name}}, opts)
+}
+
+func (c *Client) collectDomain(req *driverpb.CollectDomainRequest, opts driver.CollectOptions) (*driver.Domain, error) {
+	req.Options = toOptions(opts)
+	p, err := c.c.CollectDomain(context.Background(), req)
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromDomain(p), nil
+}
+
+// CollectSnapshots Snapshots of a domain
+func (c *Client) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	resp, err := c.c.CollectSnapshots(context.Background(), &driverpb.CollectSnapshotsRequest{Id: uint64(id)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	snaps := make([]driver.Snapshot, 0, len(resp.GetSnapshots()))
+	for _, p := range resp.GetSnapshots() {
+		snaps = append(snaps, fromSnapshot(p))
+	}
+	return snaps, nil
+}
+
+// Host Metrics of the host the server runs on
+func (c *Client) Host() (*driver.HostInfo, error) {
+	p, err := c.c.Host(context.Background(), &driverpb.HostRequest{})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return fromHost(p), nil
+}
+
+// Ping Ping the served driver, failing with ErrHypervisorUnavailable when
+// either the server or its hypervisor doesn't answer
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.c.Ping(ctx, &driverpb.PingRequest{})
+	return fromStatus(err)
+}
+
+// Watch Stream the events of the served driver. The channel is closed when
+// ctx is done, the served driver stops watching or the connection drops.
+func (c *Client) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.c.Watch(ctx, &driverpb.WatchRequest{})
+	if err != nil {
+		cancel()
+		return nil, fromStatus(err)
+	}
+	// The server sends headers once subscribed, a stream ending without
+	// them failed and Recv returns why
+	if md, err := stream.Header(); err != nil || md == nil {
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		cancel()
+		return nil, fromStatus(err)
+	}
+
+	events := make(chan driver.DomainEvent)
+	go func() {
+		defer cancel()
+		defer close(events)
+		for {
+			p, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() == nil && err != io.EOF {
+					driver.GetLogger().Debug("watch stream ended", "driver", c.name, "error", err)
+				}
+				return
+			}
+			select {
+			case events <- fromEvent(p):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// Close Close the connection opened by Dial
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
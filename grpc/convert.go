@@ -0,0 +1,628 @@
+package grpc
+
+import (
+	"net"
+	"time"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/grpc/driverpb"
+)
+
+// optional Pointer to v when set, for proto3 optional fields
+func optional[T any](v T, set bool) *T {
+	if !set {
+		return nil
+	}
+	return &v
+}
+
+// value Value behind an optional field and whether it is present
+// presized Empty slice with room for n elements, nil when n is 0 as for
+// slices appended to from nil
+func presized[T any](n int) []T {
+	if n == 0 {
+		return nil
+	}
+	return make([]T, 0, n)
+}
+
+func value[T any](p *T) (T, bool) {
+	if p == nil {
+		var zero T
+		return zero, false
+	}
+	return *p, true
+}
+
+func toOptions(o driver.CollectOptions) *driverpb.CollectOptions {
+	p := &driverpb.CollectOptions{
+		Cpus:          o.CPUs,
+		Pinning:       o.Pinning,
+		Blocks:        o.Blocks,
+		BlockCapacity: o.BlockCapacity,
+		BackingChain:  o.BackingChain,
+		Interfaces:    o.Interfaces,
+		Addresses:     o.Addresses,
+		Limits:        o.Limits,
+		Memory:        o.Memory,
+		DirtyRate:     o.DirtyRate,
+		Filesystems:   o.Filesystems,
+		Graphics:      o.Graphics,
+		Metadata:      o.Metadata,
+		HostDevices:   o.HostDevices,
+		CpuTuning:     o.CPUTuning,
+		Numa:          o.NUMA,
+		Versions:      o.Versions,
+		TopologyOnly:  o.TopologyOnly,
+		Concurrency:   int32(o.Concurrency),
+		Retries:       int32(o.Retries),
+		RetryDelay:    int64(o.RetryDelay),
+
+		IncludeInactive:  o.IncludeInactive,
+		EnumerateTimeout: int64(o.EnumerateTimeout),
+		PerDomainTimeout: int64(o.PerDomainTimeout),
+		SlowInterval:     int64(o.SlowInterval),
+		Include:          o.Include,
+		Exclude:          o.Exclude,
+	}
+	for _, s := range o.SkipStates {
+		p.SkipStates = append(p.SkipStates, int32(s))
+	}
+	return p
+}
+
+func fromOptions(o *driverpb.CollectOptions) driver.CollectOptions {
+	opts := driver.CollectOptions{
+		CPUs:          o.GetCpus(),
+		Pinning:       o.GetPinning(),
+		Blocks:        o.GetBlocks(),
+		BlockCapacity: o.GetBlockCapacity(),
+		BackingChain:  o.GetBackingChain(),
+		Interfaces:    o.GetInterfaces(),
+		Addresses:     o.GetAddresses(),
+		Limits:        o.GetLimits(),
+		Memory:        o.GetMemory(),
+		DirtyRate:     o.GetDirtyRate(),
+		Filesystems:   o.GetFilesystems(),
+		Graphics:      o.GetGraphics(),
+		Metadata:      o.GetMetadata(),
+		HostDevices:   o.GetHostDevices(),
+		CPUTuning:     o.GetCpuTuning(),
+		NUMA:          o.GetNuma(),
+		Versions:      o.GetVersions(),
+		TopologyOnly:  o.GetTopologyOnly(),
+		Concurrency:   int(o.GetConcurrency()),
+		Retries:       int(o.GetRetries()),
+		RetryDelay:    time.Duration(o.GetRetryDelay()),
+
+		IncludeInactive:  o.GetIncludeInactive(),
+		EnumerateTimeout: time.Duration(o.GetEnumerateTimeout()),
+		PerDomainTimeout: time.Duration(o.GetPerDomainTimeout()),
+		SlowInterval:     time.Duration(o.GetSlowInterval()),
+		Include:          o.GetInclude(),
+		Exclude:          o.GetExclude(),
+	}
+	for _, s := range o.GetSkipStates() {
+		opts.SkipStates = append(opts.SkipStates, driver.DomainFlag(s))
+	}
+	return opts
+}
+
+func toCapabilities(c driver.Capabilities) *driverpb.Capabilities {
+	return &driverpb.Capabilities{
+		SupportsCpus:          c.SupportsCPUs,
+		SupportsBlocks:        c.SupportsBlocks,
+		SupportsInterfaces:    c.SupportsInterfaces,
+		SupportsMemory:        c.SupportsMemory,
+		SupportsFilesystems:   c.SupportsFilesystems,
+		SupportsGraphics:      c.SupportsGraphics,
+		SupportsMetadata:      c.SupportsMetadata,
+		SupportsGuestIp:       c.SupportsGuestIP,
+		SupportsBlockCapacity: c.SupportsBlockCapacity,
+		SupportsBackingChain:  c.SupportsBackingChain,
+		SupportsPinning:       c.SupportsPinning,
+		SupportsLimits:        c.SupportsLimits,
+		SupportsSnapshots:     c.SupportsSnapshots,
+		SupportsEvents:        c.SupportsEvents,
+		SupportsHostDevices:   c.SupportsHostDevices,
+		SupportsCpuTuning:     c.SupportsCPUTuning,
+		SupportsNuma:          c.SupportsNUMA,
+		SupportsVersions:      c.SupportsVersions,
+		SupportsDirtyRate:     c.SupportsDirtyRate,
+	}
+}
+
+func fromCapabilities(c *driverpb.Capabilities) driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:          c.GetSupportsCpus(),
+		SupportsBlocks:        c.GetSupportsBlocks(),
+		SupportsInterfaces:    c.GetSupportsInterfaces(),
+		SupportsMemory:        c.GetSupportsMemory(),
+		SupportsFilesystems:   c.GetSupportsFilesystems(),
+		SupportsGraphics:      c.GetSupportsGraphics(),
+		SupportsMetadata:      c.GetSupportsMetadata(),
+		SupportsGuestIP:       c.GetSupportsGuestIp(),
+		SupportsBlockCapacity: c.GetSupportsBlockCapacity(),
+		SupportsBackingChain:  c.GetSupportsBackingChain(),
+		SupportsPinning:       c.GetSupportsPinning(),
+		SupportsLimits:        c.GetSupportsLimits(),
+		SupportsSnapshots:     c.GetSupportsSnapshots(),
+		SupportsEvents:        c.GetSupportsEvents(),
+		SupportsHostDevices:   c.GetSupportsHostDevices(),
+		SupportsCPUTuning:     c.GetSupportsCpuTuning(),
+		SupportsNUMA:          c.GetSupportsNuma(),
+		SupportsVersions:      c.GetSupportsVersions(),
+		SupportsDirtyRate:     c.GetSupportsDirtyRate(),
+	}
+}
+
+func toDomain(d *driver.Domain) *driverpb.Domain {
+	p := &driverpb.Domain{
+		Name:         d.Name,
+		Id:           uint64(d.ID),
+		Hypervisor:   string(d.Hypervisor),
+		Uuid:         d.UUID,
+		OsType:       d.OSType,
+		Time:         int64(d.Time),
+		StartTime:    int64(d.StartTime),
+		Flags:        int32(d.Flags),
+		Persistent:   optional(d.Persistent, d.PersistentSet),
+		Autostart:    optional(d.Autostart, d.AutostartSet),
+		Vcpus:        int32(d.VCPUs),
+		VcpusCurrent: int32(d.VCPUsCurrent),
+		VcpusMaximum: int32(d.VCPUsMaximum),
+		NestedVirt:   optional(d.NestedVirt, d.NestedVirtSet),
+		Memory:       toMemory(d.Memory),
+		CpuTuning:    toCPUTuning(d.CPUTuning),
+		Topology:     toCPUTopology(d.Topology),
+		Numa:         toNUMA(d.NUMA),
+		EmulatorTime: optional(d.EmulatorTime, d.EmulatorTimeSet),
+		MemoryBacking: &driverpb.MemoryBacking{
+			BalloonCurrent: d.MemoryBacking.BalloonCurrent,
+			BalloonMaximum: d.MemoryBacking.BalloonMaximum,
+			Hugepages:      d.MemoryBacking.Hugepages,
+			HugepageSize:   d.MemoryBacking.HugepageSize,
+		},
+		MachineType:     d.MachineType,
+		EmulatorVersion: d.EmulatorVersion,
+		Title:           d.Title,
+		Description:     d.Description,
+		Labels:          d.Labels,
+		Consoles:        d.Consoles,
+		CollectDuration: int64(d.CollectDuration),
+		GuestAgent:      int32(d.GuestAgent),
+	}
+	p.Cpus = presized[*driverpb.CPU](len(d.Cpus))
+	for _, c := range d.Cpus {
+		p.Cpus = append(p.Cpus, &driverpb.CPU{
+			Id:          c.ID,
+			Flags:       int32(c.Flags),
+			Time:        c.Time,
+			Idle:        optional(c.Idle, c.IdleSet),
+			Load1:       c.Load1,
+			Load5:       c.Load5,
+			Load15:      c.Load15,
+			PhysicalCpu: optional(int32(c.PhysicalCPU), c.PhysicalCPUSet),
+			Affinity:    c.Affinity,
+			Steal:       optional(c.Steal, c.StealSet),
+			Iowait:      optional(c.IOWait, c.IOWaitSet),
+			NumaNode:    optional(int32(c.NUMANode), c.NUMANodeSet),
+		})
+	}
+	for _, t := range d.IOThreads {
+		p.Iothreads = append(p.Iothreads, &driverpb.IOThread{
+			Id:       t.ID,
+			Time:     optional(t.Time, t.TimeSet),
+			Affinity: t.Affinity,
+		})
+	}
+	p.Blocks = presized[*driverpb.BlockDevice](len(d.Blocks))
+	for _, b := range d.Blocks {
+		p.Blocks = append(p.Blocks, &driverpb.BlockDevice{
+			Name:         b.Name,
+			ReadOnly:     b.ReadOnly,
+			IsDisk:       b.IsDisk,
+			IsCdrom:      b.IsCDrom,
+			Read:         toBlockIO(b.Read),
+			Write:        toBlockIO(b.Write),
+			Flush:        toBlockIO(b.Flush),
+			Stalled:      b.Stalled,
+			Throttled:    b.Throttled,
+			Bus:          b.Bus,
+			Target:       b.Target,
+			Source:       b.Source,
+			BackingChain: b.BackingChain,
+			Capacity:     b.Capacity,
+			Allocation:   b.Allocation,
+			Physical:     b.Physical,
+			Limits: &driverpb.BlockLimits{
+				ReadIops:      b.Limits.ReadIOPS,
+				WriteIops:     b.Limits.WriteIOPS,
+				TotalIops:     b.Limits.TotalIOPS,
+				ReadBytesSec:  b.Limits.ReadBytesSec,
+				WriteBytesSec: b.Limits.WriteBytesSec,
+				TotalBytesSec: b.Limits.TotalBytesSec,
+				Weight:        b.Limits.Weight,
+			},
+		})
+	}
+	p.Interfaces = presized[*driverpb.NetworkInterface](len(d.Interfaces))
+	for _, n := range d.Interfaces {
+		i := &driverpb.NetworkInterface{
+			Name:          n.Name,
+			Mac:           n.Mac,
+			Bridges:       n.Bridges,
+			Rx:            toNetworkIO(n.RX),
+			Tx:            toNetworkIO(n.TX),
+			HostDevice:    n.HostDevice,
+			LinkUp:        optional(n.LinkUp, n.LinkUpSet),
+			InboundLimit:  n.InboundLimit,
+			OutboundLimit: n.OutboundLimit,
+		}
+		for _, a := range n.Addresses {
+			i.Addresses = append(i.Addresses, &driverpb.IPNet{Ip: a.IP, Mask: a.Mask})
+		}
+		p.Interfaces = append(p.Interfaces, i)
+	}
+	for _, f := range d.Filesystems {
+		p.Filesystems = append(p.Filesystems, &driverpb.Filesystem{
+			Mountpoint: f.Mountpoint,
+			Name:       f.Name,
+			Type:       f.Type,
+			TotalBytes: f.TotalBytes,
+			UsedBytes:  f.UsedBytes,
+		})
+	}
+	for _, g := range d.Graphics {
+		p.Graphics = append(p.Graphics, &driverpb.GraphicsDevice{
+			Type:     g.Type,
+			Listen:   g.Listen,
+			Port:     int32(g.Port),
+			TlsPort:  int32(g.TLSPort),
+			Password: g.Password,
+		})
+	}
+	for _, h := range d.HostDevices {
+		p.HostDevices = append(p.HostDevices, &driverpb.HostDevice{
+			Type:        h.Type,
+			Address:     h.Address,
+			Name:        h.Name,
+			Description: h.Description,
+		})
+	}
+	return p
+}
+
+func fromDomain(p *driverpb.Domain) *driver.Domain {
+	d := &driver.Domain{
+		Name:         p.GetName(),
+		ID:           driver.DomainID(p.GetId()),
+		Hypervisor:   driver.DomainHypervisor(p.GetHypervisor()),
+		UUID:         p.GetUuid(),
+		OSType:       p.GetOsType(),
+		Time:         driver.Timestamp(p.GetTime()),
+		StartTime:    driver.Timestamp(p.GetStartTime()),
+		Flags:        driver.DomainFlag(p.GetFlags()),
+		VCPUs:        int(p.GetVcpus()),
+		VCPUsCurrent: int(p.GetVcpusCurrent()),
+		VCPUsMaximum: int(p.GetVcpusMaximum()),
+		Memory:       fromMemory(p.GetMemory()),
+		CPUTuning:    fromCPUTuning(p.GetCpuTuning()),
+		Topology:     fromCPUTopology(p.GetTopology()),
+		NUMA:         fromNUMA(p.GetNuma()),
+		MemoryBacking: driver.MemoryBacking{
+			BalloonCurrent: p.GetMemoryBacking().GetBalloonCurrent(),
+			BalloonMaximum: p.GetMemoryBacking().GetBalloonMaximum(),
+			Hugepages:      p.GetMemoryBacking().GetHugepages(),
+			HugepageSize:   p.GetMemoryBacking().GetHugepageSize(),
+		},
+		MachineType:     p.GetMachineType(),
+		EmulatorVersion: p.GetEmulatorVersion(),
+		Title:           p.GetTitle(),
+		Description:     p.GetDescription(),
+		Labels:          p.GetLabels(),
+		Consoles:        p.GetConsoles(),
+		CollectDuration: time.Duration(p.GetCollectDuration()),
+		GuestAgent:      driver.AgentState(p.GetGuestAgent()),
+	}
+	d.Persistent, d.PersistentSet = value(p.Persistent)
+	d.Autostart, d.AutostartSet = value(p.Autostart)
+	d.NestedVirt, d.NestedVirtSet = value(p.NestedVirt)
+	d.EmulatorTime, d.EmulatorTimeSet = value(p.EmulatorTime)
+
+	d.Cpus = presized[driver.CPU](len(p.GetCpus()))
+	for _, c := range p.GetCpus() {
+		cpu := driver.CPU{
+			ID:       c.GetId(),
+			Flags:    driver.CPUFlag(c.GetFlags()),
+			Time:     c.GetTime(),
+			Load1:    c.GetLoad1(),
+			Load5:    c.GetLoad5(),
+			Load15:   c.GetLoad15(),
+			Affinity: c.GetAffinity(),
+		}
+		cpu.Idle, cpu.IdleSet = value(c.Idle)
+		cpu.Steal, cpu.StealSet = value(c.Steal)
+		cpu.IOWait, cpu.IOWaitSet = value(c.Iowait)
+		physical, set := value(c.PhysicalCpu)
+		cpu.PhysicalCPU, cpu.PhysicalCPUSet = int(physical), set
+		node, set := value(c.NumaNode)
+		cpu.NUMANode, cpu.NUMANodeSet = int(node), set
+		d.Cpus = append(d.Cpus, cpu)
+	}
+	for _, t := range p.GetIothreads() {
+		thread := driver.IOThread{ID: t.GetId(), Affinity: t.GetAffinity()}
+		thread.Time, thread.TimeSet = value(t.Time)
+		d.IOThreads = append(d.IOThreads, thread)
+	}
+	d.Blocks = presized[driver.BlockDevice](len(p.GetBlocks()))
+	for _, b := range p.GetBlocks() {
+		l := b.GetLimits()
+		d.Blocks = append(d.Blocks, driver.BlockDevice{
+			Name:         b.GetName(),
+			ReadOnly:     b.GetReadOnly(),
+			IsDisk:       b.GetIsDisk(),
+			IsCDrom:      b.GetIsCdrom(),
+			Read:         fromBlockIO(b.GetRead()),
+			Write:        fromBlockIO(b.GetWrite()),
+			Flush:        fromBlockIO(b.GetFlush()),
+			Stalled:      b.GetStalled(),
+			Throttled:    b.GetThrottled(),
+			Bus:          b.GetBus(),
+			Target:       b.GetTarget(),
+			Source:       b.GetSource(),
+			BackingChain: b.GetBackingChain(),
+			Capacity:     b.GetCapacity(),
+			Allocation:   b.GetAllocation(),
+			Physical:     b.GetPhysical(),
+			Limits: driver.BlockLimits{
+				ReadIOPS:      l.GetReadIops(),
+				WriteIOPS:     l.GetWriteIops(),
+				TotalIOPS:     l.GetTotalIops(),
+				ReadBytesSec:  l.GetReadBytesSec(),
+				WriteBytesSec: l.GetWriteBytesSec(),
+				TotalBytesSec: l.GetTotalBytesSec(),
+				Weight:        l.GetWeight(),
+			},
+		})
+	}
+	d.Interfaces = presized[driver.NetworkInterface](len(p.GetInterfaces()))
+	for _, i := range p.GetInterfaces() {
+		n := driver.NetworkInterface{
+			Name:          i.GetName(),
+			Mac:           i.GetMac(),
+			Bridges:       i.GetBridges(),
+			RX:            fromNetworkIO(i.GetRx()),
+			TX:            fromNetworkIO(i.GetTx()),
+			HostDevice:    i.GetHostDevice(),
+			InboundLimit:  i.GetInboundLimit(),
+			OutboundLimit: i.GetOutboundLimit(),
+		}
+		n.LinkUp, n.LinkUpSet = value(i.LinkUp)
+		for _, a := range i.GetAddresses() {
+			n.Addresses = append(n.Addresses, net.IPNet{IP: a.GetIp(), Mask: a.GetMask()})
+		}
+		d.Interfaces = append(d.Interfaces, n)
+	}
+	for _, f := range p.GetFilesystems() {
+		d.Filesystems = append(d.Filesystems, driver.Filesystem{
+			Mountpoint: f.GetMountpoint(),
+			Name:       f.GetName(),
+			Type:       f.GetType(),
+			TotalBytes: f.GetTotalBytes(),
+			UsedBytes:  f.GetUsedBytes(),
+		})
+	}
+	for _, g := range p.GetGraphics() {
+		d.Graphics = append(d.Graphics, driver.GraphicsDevice{
+			Type:     g.GetType(),
+			Listen:   g.GetListen(),
+			Port:     int(g.GetPort()),
+			TLSPort:  int(g.GetTlsPort()),
+			Password: g.GetPassword(),
+		})
+	}
+	for _, h := range p.GetHostDevices() {
+		d.HostDevices = append(d.HostDevices, driver.HostDevice{
+			Type:        h.GetType(),
+			Address:     h.GetAddress(),
+			Name:        h.GetName(),
+			Description: h.GetDescription(),
+		})
+	}
+	return d
+}
+
+func toBlockIO(b driver.BlockIO) *driverpb.BlockIO {
+	return &driverpb.BlockIO{
+		Operations: b.Operations,
+		Bytes:      b.Bytes,
+		Sectors:    b.Sectors,
+		Absolute:   b.Absolute,
+		TotalTime:  optional(b.TotalTime, b.TotalTimeSet),
+		Errors:     b.Errors,
+	}
+}
+
+func fromBlockIO(p *driverpb.BlockIO) driver.BlockIO {
+	b := driver.BlockIO{
+		Operations: p.GetOperations(),
+		Bytes:      p.GetBytes(),
+		Sectors:    p.GetSectors(),
+		Absolute:   p.GetAbsolute(),
+		Errors:     p.GetErrors(),
+	}
+	if p != nil {
+		b.TotalTime, b.TotalTimeSet = value(p.TotalTime)
+	}
+	return b
+}
+
+func toNetworkIO(n driver.NetworkIO) *driverpb.NetworkIO {
+	return &driverpb.NetworkIO{
+		Bytes: n.Bytes, Packets: n.Packets, Errors: n.Errors, Drops: n.Drops,
+		Fifo: n.FIFO, Frame: n.Frame, Collisions: n.Collisions, Carrier: n.Carrier,
+	}
+}
+
+func fromNetworkIO(p *driverpb.NetworkIO) driver.NetworkIO {
+	return driver.NetworkIO{
+		Bytes: p.GetBytes(), Packets: p.GetPackets(), Errors: p.GetErrors(), Drops: p.GetDrops(),
+		FIFO: p.GetFifo(), Frame: p.GetFrame(), Collisions: p.GetCollisions(), Carrier: p.GetCarrier(),
+	}
+}
+
+func toCPUTopology(t driver.CPUTopology) *driverpb.CPUTopology {
+	return &driverpb.CPUTopology{
+		Sockets:        int32(t.Sockets),
+		CoresPerSocket: int32(t.CoresPerSocket),
+		ThreadsPerCore: int32(t.ThreadsPerCore),
+	}
+}
+
+func fromCPUTopology(p *driverpb.CPUTopology) driver.CPUTopology {
+	return driver.CPUTopology{
+		Sockets:        int(p.GetSockets()),
+		CoresPerSocket: int(p.GetCoresPerSocket()),
+		ThreadsPerCore: int(p.GetThreadsPerCore()),
+	}
+}
+
+func toCPUTuning(t driver.CPUTuning) *driverpb.CPUTuning {
+	return &driverpb.CPUTuning{
+		Shares:         t.Shares,
+		Weight:         t.Weight,
+		Period:         t.Period,
+		Quota:          t.Quota,
+		GlobalPeriod:   t.GlobalPeriod,
+		GlobalQuota:    t.GlobalQuota,
+		EmulatorPeriod: t.EmulatorPeriod,
+		EmulatorQuota:  t.EmulatorQuota,
+		IothreadPeriod: t.IOThreadPeriod,
+		IothreadQuota:  t.IOThreadQuota,
+	}
+}
+
+func fromCPUTuning(p *driverpb.CPUTuning) driver.CPUTuning {
+	return driver.CPUTuning{
+		Shares:         p.GetShares(),
+		Weight:         p.GetWeight(),
+		Period:         p.GetPeriod(),
+		Quota:          p.GetQuota(),
+		GlobalPeriod:   p.GetGlobalPeriod(),
+		GlobalQuota:    p.GetGlobalQuota(),
+		EmulatorPeriod: p.GetEmulatorPeriod(),
+		EmulatorQuota:  p.GetEmulatorQuota(),
+		IOThreadPeriod: p.GetIothreadPeriod(),
+		IOThreadQuota:  p.GetIothreadQuota(),
+	}
+}
+
+func toNUMA(n driver.NUMA) *driverpb.NUMA {
+	p := &driverpb.NUMA{Mode: n.Mode, Nodes: n.Nodes}
+	for _, c := range n.Cells {
+		p.Cells = append(p.Cells, &driverpb.NUMACell{Id: c.ID, Cpus: c.CPUs, Memory: c.Memory, Mode: c.Mode, Nodes: c.Nodes})
+	}
+	return p
+}
+
+func fromNUMA(p *driverpb.NUMA) driver.NUMA {
+	n := driver.NUMA{Mode: p.GetMode(), Nodes: p.GetNodes()}
+	for _, c := range p.GetCells() {
+		n.Cells = append(n.Cells, driver.NUMACell{ID: c.GetId(), CPUs: c.GetCpus(), Memory: c.GetMemory(), Mode: c.GetMode(), Nodes: c.GetNodes()})
+	}
+	return n
+}
+
+func toMemory(m driver.Memory) *driverpb.Memory {
+	return &driverpb.Memory{
+		Actual:      optional(m.Actual, m.ActualSet),
+		Available:   optional(m.Available, m.AvailableSet),
+		Unused:      optional(m.Unused, m.UnusedSet),
+		Rss:         optional(m.RSS, m.RSSSet),
+		SwapIn:      optional(m.SwapIn, m.SwapInSet),
+		SwapOut:     optional(m.SwapOut, m.SwapOutSet),
+		MajorFaults: optional(m.MajorFaults, m.MajorFaultsSet),
+		MinorFaults: optional(m.MinorFaults, m.MinorFaultsSet),
+		DirtyRate:   optional(m.DirtyRate, m.DirtyRateSet),
+	}
+}
+
+func fromMemory(p *driverpb.Memory) driver.Memory {
+	var m driver.Memory
+	if p == nil {
+		return m
+	}
+	m.Actual, m.ActualSet = value(p.Actual)
+	m.Available, m.AvailableSet = value(p.Available)
+	m.Unused, m.UnusedSet = value(p.Unused)
+	m.RSS, m.RSSSet = value(p.Rss)
+	m.SwapIn, m.SwapInSet = value(p.SwapIn)
+	m.SwapOut, m.SwapOutSet = value(p.SwapOut)
+	m.MajorFaults, m.MajorFaultsSet = value(p.MajorFaults)
+	m.MinorFaults, m.MinorFaultsSet = value(p.MinorFaults)
+	m.DirtyRate, m.DirtyRateSet = value(p.DirtyRate)
+	return m
+}
+
+func toSnapshot(s driver.Snapshot) *driverpb.Snapshot {
+	p := &driverpb.Snapshot{Name: s.Name, State: s.State, Parent: s.Parent, IsCurrent: s.IsCurrent}
+	if !s.CreationTime.IsZero() {
+		p.CreationTime = s.CreationTime.UnixNano()
+	}
+	return p
+}
+
+func fromSnapshot(p *driverpb.Snapshot) driver.Snapshot {
+	s := driver.Snapshot{Name: p.GetName(), State: p.GetState(), Parent: p.GetParent(), IsCurrent: p.GetIsCurrent()}
+	if p.GetCreationTime() != 0 {
+		s.CreationTime = time.Unix(0, p.GetCreationTime())
+	}
+	return s
+}
+
+func toHost(h *driver.HostInfo) *driverpb.HostInfo {
+	return &driverpb.HostInfo{
+		Hostname:    h.Hostname,
+		Cpus:        int32(h.CPUs),
+		MemoryTotal: h.MemoryTotal,
+		MemoryFree:  h.MemoryFree,
+		Load1:       h.Load1,
+		Load5:       h.Load5,
+		Load15:      h.Load15,
+	}
+}
+
+func fromHost(p *driverpb.HostInfo) *driver.HostInfo {
+	return &driver.HostInfo{
+		Hostname:    p.GetHostname(),
+		CPUs:        int(p.GetCpus()),
+		MemoryTotal: p.GetMemoryTotal(),
+		MemoryFree:  p.GetMemoryFree(),
+		Load1:       p.GetLoad1(),
+		Load5:       p.GetLoad5(),
+		Load15:      p.GetLoad15(),
+	}
+}
+
+func toEvent(e driver.DomainEvent) *driverpb.DomainEvent {
+	return &driverpb.DomainEvent{
+		Id:         uint64(e.ID),
+		Uuid:       e.UUID,
+		Name:       e.Name,
+		Hypervisor: string(e.Hypervisor),
+		Flags:      int32(e.Flags),
+		Time:       int64(e.Time),
+	}
+}
+
+func fromEvent(p *driverpb.DomainEvent) driver.DomainEvent {
+	return driver.DomainEvent{
+		ID:         driver.DomainID(p.GetId()),
+		UUID:       p.GetUuid(),
+		Name:       p.GetName(),
+		Hypervisor: driver.DomainHypervisor(p.GetHypervisor()),
+		Flags:      driver.DomainFlag(p.GetFlags()),
+		Time:       driver.Timestamp(p.GetTime()),
+	}
+}
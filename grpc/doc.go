@@ -0,0 +1,17 @@
+// Package grpc Serve a driver over gRPC and collect from it remotely.
+//
+// A Server wraps any driver.Driver, a Client implements driver.Driver on top
+// of the connection so code collecting locally works unchanged against a
+// remote host:
+//
+//	s := grpc.NewServer()
+//	drivergrpc.Register(s, d)
+//	s.Serve(lis)
+//
+//	c, err := drivergrpc.Dial("hypervisor:7070", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	domains, err := c.Collect(driver.AllMetrics())
+//
+// Sentinel errors such as ErrDomainNotFound survive the round trip, the
+// DomainErrors of a collection are returned joined as locally. Watch is a
+// server stream. The wire format is defined in driverpb/driver.proto.
+package grpc
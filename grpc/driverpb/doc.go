@@ -0,0 +1,5 @@
+// Package driverpb Protobuf messages and gRPC stubs generated from
+// driver.proto, see package grpc for the server and client built on them.
+package driverpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative driver.proto
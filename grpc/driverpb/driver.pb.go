@@ -0,0 +1,3671 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.0
+// source: driver.proto
+
+// Wire form of the driver package types, mirroring them field for field.
+// Fields paired with a *Set flag in Go are optional here, presence standing
+// for the flag.
+
+package driverpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type InfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InfoRequest) Reset() {
+	*x = InfoRequest{}
+	mi := &file_driver_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoRequest) ProtoMessage() {}
+
+func (x *InfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoRequest.ProtoReflect.Descriptor instead.
+func (*InfoRequest) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{0}
+}
+
+type InfoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Capabilities  *Capabilities          `protobuf:"bytes,2,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	Detected      bool                   `protobuf:"varint,3,opt,name=detected,proto3" json:"detected,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InfoResponse) Reset() {
+	*x = InfoResponse{}
+	mi := &file_driver_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoResponse) ProtoMessage() {}
+
+func (x *InfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoResponse.ProtoReflect.Descriptor instead.
+func (*InfoResponse) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *InfoResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *InfoResponse) GetCapabilities() *Capabilities {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *InfoResponse) GetDetected() bool {
+	if x != nil {
+		return x.Detected
+	}
+	return false
+}
+
+func (x *InfoResponse) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// CollectOptions driver.CollectOptions, the filter runs on the client
+type CollectOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cpus          bool                   `protobuf:"varint,1,opt,name=cpus,proto3" json:"cpus,omitempty"`
+	Pinning       bool                   `protobuf:"varint,2,opt,name=pinning,proto3" json:"pinning,omitempty"`
+	Blocks        bool                   `protobuf:"varint,3,opt,name=blocks,proto3" json:"blocks,omitempty"`
+	BlockCapacity bool                   `protobuf:"varint,4,opt,name=block_capacity,json=blockCapacity,proto3" json:"block_capacity,omitempty"`
+	Interfaces    bool                   `protobuf:"varint,5,opt,name=interfaces,proto3" json:"interfaces,omitempty"`
+	Addresses     bool                   `protobuf:"varint,6,opt,name=addresses,proto3" json:"addresses,omitempty"`
+	Limits        bool                   `protobuf:"varint,7,opt,name=limits,proto3" json:"limits,omitempty"`
+	Memory        bool                   `protobuf:"varint,8,opt,name=memory,proto3" json:"memory,omitempty"`
+	Filesystems   bool                   `protobuf:"varint,9,opt,name=filesystems,proto3" json:"filesystems,omitempty"`
+	Graphics      bool                   `protobuf:"varint,10,opt,name=graphics,proto3" json:"graphics,omitempty"`
+	Metadata      bool                   `protobuf:"varint,11,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Concurrency   int32                  `protobuf:"varint,12,opt,name=concurrency,proto3" json:"concurrency,omitempty"`
+	HostDevices   bool                   `protobuf:"varint,13,opt,name=host_devices,json=hostDevices,proto3" json:"host_devices,omitempty"`
+	CpuTuning     bool                   `protobuf:"varint,14,opt,name=cpu_tuning,json=cpuTuning,proto3" json:"cpu_tuning,omitempty"`
+	// skip_states driver.DomainFlag
+	SkipStates []int32 `protobuf:"varint,15,rep,packed,name=skip_states,json=skipStates,proto3" json:"skip_states,omitempty"`
+	Numa       bool    `protobuf:"varint,16,opt,name=numa,proto3" json:"numa,omitempty"`
+	// enumerate_timeout and per_domain_timeout Nanoseconds
+	EnumerateTimeout int64 `protobuf:"varint,17,opt,name=enumerate_timeout,json=enumerateTimeout,proto3" json:"enumerate_timeout,omitempty"`
+	PerDomainTimeout int64 `protobuf:"varint,18,opt,name=per_domain_timeout,json=perDomainTimeout,proto3" json:"per_domain_timeout,omitempty"`
+	BackingChain     bool  `protobuf:"varint,19,opt,name=backing_chain,json=backingChain,proto3" json:"backing_chain,omitempty"`
+	Versions         bool  `protobuf:"varint,20,opt,name=versions,proto3" json:"versions,omitempty"`
+	IncludeInactive  bool  `protobuf:"varint,21,opt,name=include_inactive,json=includeInactive,proto3" json:"include_inactive,omitempty"`
+	Retries          int32 `protobuf:"varint,22,opt,name=retries,proto3" json:"retries,omitempty"`
+	// retry_delay Nanoseconds
+	RetryDelay int64 `protobuf:"varint,23,opt,name=retry_delay,json=retryDelay,proto3" json:"retry_delay,omitempty"`
+	// slow_interval Nanoseconds
+	SlowInterval int64 `protobuf:"varint,24,opt,name=slow_interval,json=slowInterval,proto3" json:"slow_interval,omitempty"`
+	// include and exclude driver.CollectOptions patterns
+	Include       []string `protobuf:"bytes,25,rep,name=include,proto3" json:"include,omitempty"`
+	Exclude       []string `protobuf:"bytes,26,rep,name=exclude,proto3" json:"exclude,omitempty"`
+	DirtyRate     bool     `protobuf:"varint,27,opt,name=dirty_rate,json=dirtyRate,proto3" json:"dirty_rate,omitempty"`
+	TopologyOnly  bool     `protobuf:"varint,28,opt,name=topology_only,json=topologyOnly,proto3" json:"topology_only,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectOptions) Reset() {
+	*x = CollectOptions{}
+	mi := &file_driver_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectOptions) ProtoMessage() {}
+
+func (x *CollectOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectOptions.ProtoReflect.Descriptor instead.
+func (*CollectOptions) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CollectOptions) GetCpus() bool {
+	if x != nil {
+		return x.Cpus
+	}
+	return false
+}
+
+func (x *CollectOptions) GetPinning() bool {
+	if x != nil {
+		return x.Pinning
+	}
+	return false
+}
+
+func (x *CollectOptions) GetBlocks() bool {
+	if x != nil {
+		return x.Blocks
+	}
+	return false
+}
+
+func (x *CollectOptions) GetBlockCapacity() bool {
+	if x != nil {
+		return x.BlockCapacity
+	}
+	return false
+}
+
+func (x *CollectOptions) GetInterfaces() bool {
+	if x != nil {
+		return x.Interfaces
+	}
+	return false
+}
+
+func (x *CollectOptions) GetAddresses() bool {
+	if x != nil {
+		return x.Addresses
+	}
+	return false
+}
+
+func (x *CollectOptions) GetLimits() bool {
+	if x != nil {
+		return x.Limits
+	}
+	return false
+}
+
+func (x *CollectOptions) GetMemory() bool {
+	if x != nil {
+		return x.Memory
+	}
+	return false
+}
+
+func (x *CollectOptions) GetFilesystems() bool {
+	if x != nil {
+		return x.Filesystems
+	}
+	return false
+}
+
+func (x *CollectOptions) GetGraphics() bool {
+	if x != nil {
+		return x.Graphics
+	}
+	return false
+}
+
+func (x *CollectOptions) GetMetadata() bool {
+	if x != nil {
+		return x.Metadata
+	}
+	return false
+}
+
+func (x *CollectOptions) GetConcurrency() int32 {
+	if x != nil {
+		return x.Concurrency
+	}
+	return 0
+}
+
+func (x *CollectOptions) GetHostDevices() bool {
+	if x != nil {
+		return x.HostDevices
+	}
+	return false
+}
+
+func (x *CollectOptions) GetCpuTuning() bool {
+	if x != nil {
+		return x.CpuTuning
+	}
+	return false
+}
+
+func (x *CollectOptions) GetSkipStates() []int32 {
+	if x != nil {
+		return x.SkipStates
+	}
+	return nil
+}
+
+func (x *CollectOptions) GetNuma() bool {
+	if x != nil {
+		return x.Numa
+	}
+	return false
+}
+
+func (x *CollectOptions) GetEnumerateTimeout() int64 {
+	if x != nil {
+		return x.EnumerateTimeout
+	}
+	return 0
+}
+
+func (x *CollectOptions) GetPerDomainTimeout() int64 {
+	if x != nil {
+		return x.PerDomainTimeout
+	}
+	return 0
+}
+
+func (x *CollectOptions) GetBackingChain() bool {
+	if x != nil {
+		return x.BackingChain
+	}
+	return false
+}
+
+func (x *CollectOptions) GetVersions() bool {
+	if x != nil {
+		return x.Versions
+	}
+	return false
+}
+
+func (x *CollectOptions) GetIncludeInactive() bool {
+	if x != nil {
+		return x.IncludeInactive
+	}
+	return false
+}
+
+func (x *CollectOptions) GetRetries() int32 {
+	if x != nil {
+		return x.Retries
+	}
+	return 0
+}
+
+func (x *CollectOptions) GetRetryDelay() int64 {
+	if x != nil {
+		return x.RetryDelay
+	}
+	return 0
+}
+
+func (x *CollectOptions) GetSlowInterval() int64 {
+	if x != nil {
+		return x.SlowInterval
+	}
+	return 0
+}
+
+func (x *CollectOptions) GetInclude() []string {
+	if x != nil {
+		return x.Include
+	}
+	return nil
+}
+
+func (x *CollectOptions) GetExclude() []string {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
+func (x *CollectOptions) GetDirtyRate() bool {
+	if x != nil {
+		return x.DirtyRate
+	}
+	return false
+}
+
+func (x *CollectOptions) GetTopologyOnly() bool {
+	if x != nil {
+		return x.TopologyOnly
+	}
+	return false
+}
+
+type CollectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Options       *CollectOptions        `protobuf:"bytes,1,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectRequest) Reset() {
+	*x = CollectRequest{}
+	mi := &file_driver_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectRequest) ProtoMessage() {}
+
+func (x *CollectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectRequest.ProtoReflect.Descriptor instead.
+func (*CollectRequest) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CollectRequest) GetOptions() *CollectOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type CollectResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Domains []*Domain              `protobuf:"bytes,1,rep,name=domains,proto3" json:"domains,omitempty"`
+	// errors Messages of the errors tied to no domain
+	Errors        []string       `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	DomainErrors  []*DomainError `protobuf:"bytes,3,rep,name=domain_errors,json=domainErrors,proto3" json:"domain_errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectResponse) Reset() {
+	*x = CollectResponse{}
+	mi := &file_driver_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectResponse) ProtoMessage() {}
+
+func (x *CollectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectResponse.ProtoReflect.Descriptor instead.
+func (*CollectResponse) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CollectResponse) GetDomains() []*Domain {
+	if x != nil {
+		return x.Domains
+	}
+	return nil
+}
+
+func (x *CollectResponse) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *CollectResponse) GetDomainErrors() []*DomainError {
+	if x != nil {
+		return x.DomainErrors
+	}
+	return nil
+}
+
+// DomainError driver.DomainError, message is the one of the error it wraps
+type DomainError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DomainError) Reset() {
+	*x = DomainError{}
+	mi := &file_driver_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DomainError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DomainError) ProtoMessage() {}
+
+func (x *DomainError) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DomainError.ProtoReflect.Descriptor instead.
+func (*DomainError) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DomainError) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DomainError) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DomainError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type CollectDomainRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Key:
+	//
+	//	*CollectDomainRequest_Id
+	//	*CollectDomainRequest_Uuid
+	//	*CollectDomainRequest_Name
+	Key           isCollectDomainRequest_Key `protobuf_oneof:"key"`
+	Options       *CollectOptions            `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectDomainRequest) Reset() {
+	*x = CollectDomainRequest{}
+	mi := &file_driver_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectDomainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectDomainRequest) ProtoMessage() {}
+
+func (x *CollectDomainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectDomainRequest.ProtoReflect.Descriptor instead.
+func (*CollectDomainRequest) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CollectDomainRequest) GetKey() isCollectDomainRequest_Key {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *CollectDomainRequest) GetId() uint64 {
+	if x != nil {
+		if x, ok := x.Key.(*CollectDomainRequest_Id); ok {
+			return x.Id
+		}
+	}
+	return 0
+}
+
+func (x *CollectDomainRequest) GetUuid() string {
+	if x != nil {
+		if x, ok := x.Key.(*CollectDomainRequest_Uuid); ok {
+			return x.Uuid
+		}
+	}
+	return ""
+}
+
+func (x *CollectDomainRequest) GetName() string {
+	if x != nil {
+		if x, ok := x.Key.(*CollectDomainRequest_Name); ok {
+			return x.Name
+		}
+	}
+	return ""
+}
+
+func (x *CollectDomainRequest) GetOptions() *CollectOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type isCollectDomainRequest_Key interface {
+	isCollectDomainRequest_Key()
+}
+
+type CollectDomainRequest_Id struct {
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3,oneof"`
+}
+
+type CollectDomainRequest_Uuid struct {
+	Uuid string `protobuf:"bytes,2,opt,name=uuid,proto3,oneof"`
+}
+
+type CollectDomainRequest_Name struct {
+	Name string `protobuf:"bytes,3,opt,name=name,proto3,oneof"`
+}
+
+func (*CollectDomainRequest_Id) isCollectDomainRequest_Key() {}
+
+func (*CollectDomainRequest_Uuid) isCollectDomainRequest_Key() {}
+
+func (*CollectDomainRequest_Name) isCollectDomainRequest_Key() {}
+
+type CollectSnapshotsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectSnapshotsRequest) Reset() {
+	*x = CollectSnapshotsRequest{}
+	mi := &file_driver_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectSnapshotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectSnapshotsRequest) ProtoMessage() {}
+
+func (x *CollectSnapshotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectSnapshotsRequest.ProtoReflect.Descriptor instead.
+func (*CollectSnapshotsRequest) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CollectSnapshotsRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CollectSnapshotsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshots     []*Snapshot            `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CollectSnapshotsResponse) Reset() {
+	*x = CollectSnapshotsResponse{}
+	mi := &file_driver_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CollectSnapshotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CollectSnapshotsResponse) ProtoMessage() {}
+
+func (x *CollectSnapshotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CollectSnapshotsResponse.ProtoReflect.Descriptor instead.
+func (*CollectSnapshotsResponse) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CollectSnapshotsResponse) GetSnapshots() []*Snapshot {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
+}
+
+type HostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostRequest) Reset() {
+	*x = HostRequest{}
+	mi := &file_driver_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostRequest) ProtoMessage() {}
+
+func (x *HostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostRequest.ProtoReflect.Descriptor instead.
+func (*HostRequest) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{9}
+}
+
+type PingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
+	mi := &file_driver_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingRequest) ProtoMessage() {}
+
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{10}
+}
+
+type PingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingResponse) Reset() {
+	*x = PingResponse{}
+	mi := &file_driver_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingResponse) ProtoMessage() {}
+
+func (x *PingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
+func (*PingResponse) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{11}
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_driver_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{12}
+}
+
+type Capabilities struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	SupportsCpus          bool                   `protobuf:"varint,1,opt,name=supports_cpus,json=supportsCpus,proto3" json:"supports_cpus,omitempty"`
+	SupportsBlocks        bool                   `protobuf:"varint,2,opt,name=supports_blocks,json=supportsBlocks,proto3" json:"supports_blocks,omitempty"`
+	SupportsInterfaces    bool                   `protobuf:"varint,3,opt,name=supports_interfaces,json=supportsInterfaces,proto3" json:"supports_interfaces,omitempty"`
+	SupportsMemory        bool                   `protobuf:"varint,4,opt,name=supports_memory,json=supportsMemory,proto3" json:"supports_memory,omitempty"`
+	SupportsFilesystems   bool                   `protobuf:"varint,5,opt,name=supports_filesystems,json=supportsFilesystems,proto3" json:"supports_filesystems,omitempty"`
+	SupportsGraphics      bool                   `protobuf:"varint,6,opt,name=supports_graphics,json=supportsGraphics,proto3" json:"supports_graphics,omitempty"`
+	SupportsMetadata      bool                   `protobuf:"varint,7,opt,name=supports_metadata,json=supportsMetadata,proto3" json:"supports_metadata,omitempty"`
+	SupportsGuestIp       bool                   `protobuf:"varint,8,opt,name=supports_guest_ip,json=supportsGuestIp,proto3" json:"supports_guest_ip,omitempty"`
+	SupportsBlockCapacity bool                   `protobuf:"varint,9,opt,name=supports_block_capacity,json=supportsBlockCapacity,proto3" json:"supports_block_capacity,omitempty"`
+	SupportsPinning       bool                   `protobuf:"varint,10,opt,name=supports_pinning,json=supportsPinning,proto3" json:"supports_pinning,omitempty"`
+	SupportsLimits        bool                   `protobuf:"varint,11,opt,name=supports_limits,json=supportsLimits,proto3" json:"supports_limits,omitempty"`
+	SupportsSnapshots     bool                   `protobuf:"varint,12,opt,name=supports_snapshots,json=supportsSnapshots,proto3" json:"supports_snapshots,omitempty"`
+	SupportsEvents        bool                   `protobuf:"varint,13,opt,name=supports_events,json=supportsEvents,proto3" json:"supports_events,omitempty"`
+	SupportsHostDevices   bool                   `protobuf:"varint,14,opt,name=supports_host_devices,json=supportsHostDevices,proto3" json:"supports_host_devices,omitempty"`
+	SupportsCpuTuning     bool                   `protobuf:"varint,15,opt,name=supports_cpu_tuning,json=supportsCpuTuning,proto3" json:"supports_cpu_tuning,omitempty"`
+	SupportsNuma          bool                   `protobuf:"varint,16,opt,name=supports_numa,json=supportsNuma,proto3" json:"supports_numa,omitempty"`
+	SupportsBackingChain  bool                   `protobuf:"varint,17,opt,name=supports_backing_chain,json=supportsBackingChain,proto3" json:"supports_backing_chain,omitempty"`
+	SupportsVersions      bool                   `protobuf:"varint,18,opt,name=supports_versions,json=supportsVersions,proto3" json:"supports_versions,omitempty"`
+	SupportsDirtyRate     bool                   `protobuf:"varint,19,opt,name=supports_dirty_rate,json=supportsDirtyRate,proto3" json:"supports_dirty_rate,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *Capabilities) Reset() {
+	*x = Capabilities{}
+	mi := &file_driver_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Capabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Capabilities) ProtoMessage() {}
+
+func (x *Capabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Capabilities.ProtoReflect.Descriptor instead.
+func (*Capabilities) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Capabilities) GetSupportsCpus() bool {
+	if x != nil {
+		return x.SupportsCpus
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsBlocks() bool {
+	if x != nil {
+		return x.SupportsBlocks
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsInterfaces() bool {
+	if x != nil {
+		return x.SupportsInterfaces
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsMemory() bool {
+	if x != nil {
+		return x.SupportsMemory
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsFilesystems() bool {
+	if x != nil {
+		return x.SupportsFilesystems
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsGraphics() bool {
+	if x != nil {
+		return x.SupportsGraphics
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsMetadata() bool {
+	if x != nil {
+		return x.SupportsMetadata
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsGuestIp() bool {
+	if x != nil {
+		return x.SupportsGuestIp
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsBlockCapacity() bool {
+	if x != nil {
+		return x.SupportsBlockCapacity
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsPinning() bool {
+	if x != nil {
+		return x.SupportsPinning
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsLimits() bool {
+	if x != nil {
+		return x.SupportsLimits
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsSnapshots() bool {
+	if x != nil {
+		return x.SupportsSnapshots
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsEvents() bool {
+	if x != nil {
+		return x.SupportsEvents
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsHostDevices() bool {
+	if x != nil {
+		return x.SupportsHostDevices
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsCpuTuning() bool {
+	if x != nil {
+		return x.SupportsCpuTuning
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsNuma() bool {
+	if x != nil {
+		return x.SupportsNuma
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsBackingChain() bool {
+	if x != nil {
+		return x.SupportsBackingChain
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsVersions() bool {
+	if x != nil {
+		return x.SupportsVersions
+	}
+	return false
+}
+
+func (x *Capabilities) GetSupportsDirtyRate() bool {
+	if x != nil {
+		return x.SupportsDirtyRate
+	}
+	return false
+}
+
+type Domain struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Name       string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Id         uint64                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	Hypervisor string                 `protobuf:"bytes,3,opt,name=hypervisor,proto3" json:"hypervisor,omitempty"`
+	Uuid       string                 `protobuf:"bytes,4,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	OsType     string                 `protobuf:"bytes,5,opt,name=os_type,json=osType,proto3" json:"os_type,omitempty"`
+	Time       int64                  `protobuf:"varint,6,opt,name=time,proto3" json:"time,omitempty"`
+	// flags driver.DomainFlag
+	Flags        int32               `protobuf:"varint,7,opt,name=flags,proto3" json:"flags,omitempty"`
+	Persistent   *bool               `protobuf:"varint,8,opt,name=persistent,proto3,oneof" json:"persistent,omitempty"`
+	Autostart    *bool               `protobuf:"varint,9,opt,name=autostart,proto3,oneof" json:"autostart,omitempty"`
+	Vcpus        int32               `protobuf:"varint,10,opt,name=vcpus,proto3" json:"vcpus,omitempty"`
+	VcpusCurrent int32               `protobuf:"varint,11,opt,name=vcpus_current,json=vcpusCurrent,proto3" json:"vcpus_current,omitempty"`
+	VcpusMaximum int32               `protobuf:"varint,12,opt,name=vcpus_maximum,json=vcpusMaximum,proto3" json:"vcpus_maximum,omitempty"`
+	NestedVirt   *bool               `protobuf:"varint,13,opt,name=nested_virt,json=nestedVirt,proto3,oneof" json:"nested_virt,omitempty"`
+	Cpus         []*CPU              `protobuf:"bytes,14,rep,name=cpus,proto3" json:"cpus,omitempty"`
+	Blocks       []*BlockDevice      `protobuf:"bytes,15,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	Interfaces   []*NetworkInterface `protobuf:"bytes,16,rep,name=interfaces,proto3" json:"interfaces,omitempty"`
+	Memory       *Memory             `protobuf:"bytes,17,opt,name=memory,proto3" json:"memory,omitempty"`
+	Filesystems  []*Filesystem       `protobuf:"bytes,18,rep,name=filesystems,proto3" json:"filesystems,omitempty"`
+	Title        string              `protobuf:"bytes,19,opt,name=title,proto3" json:"title,omitempty"`
+	Description  string              `protobuf:"bytes,20,opt,name=description,proto3" json:"description,omitempty"`
+	Labels       map[string]string   `protobuf:"bytes,21,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Graphics     []*GraphicsDevice   `protobuf:"bytes,22,rep,name=graphics,proto3" json:"graphics,omitempty"`
+	Consoles     []string            `protobuf:"bytes,23,rep,name=consoles,proto3" json:"consoles,omitempty"`
+	// collect_duration Nanoseconds
+	CollectDuration int64          `protobuf:"varint,24,opt,name=collect_duration,json=collectDuration,proto3" json:"collect_duration,omitempty"`
+	MemoryBacking   *MemoryBacking `protobuf:"bytes,25,opt,name=memory_backing,json=memoryBacking,proto3" json:"memory_backing,omitempty"`
+	StartTime       int64          `protobuf:"varint,26,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	HostDevices     []*HostDevice  `protobuf:"bytes,27,rep,name=host_devices,json=hostDevices,proto3" json:"host_devices,omitempty"`
+	CpuTuning       *CPUTuning     `protobuf:"bytes,28,opt,name=cpu_tuning,json=cpuTuning,proto3" json:"cpu_tuning,omitempty"`
+	Iothreads       []*IOThread    `protobuf:"bytes,29,rep,name=iothreads,proto3" json:"iothreads,omitempty"`
+	EmulatorTime    *float64       `protobuf:"fixed64,30,opt,name=emulator_time,json=emulatorTime,proto3,oneof" json:"emulator_time,omitempty"`
+	Numa            *NUMA          `protobuf:"bytes,31,opt,name=numa,proto3" json:"numa,omitempty"`
+	MachineType     string         `protobuf:"bytes,32,opt,name=machine_type,json=machineType,proto3" json:"machine_type,omitempty"`
+	EmulatorVersion string         `protobuf:"bytes,33,opt,name=emulator_version,json=emulatorVersion,proto3" json:"emulator_version,omitempty"`
+	// guest_agent driver.AgentState
+	GuestAgent    int32        `protobuf:"varint,34,opt,name=guest_agent,json=guestAgent,proto3" json:"guest_agent,omitempty"`
+	Topology      *CPUTopology `protobuf:"bytes,35,opt,name=topology,proto3" json:"topology,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Domain) Reset() {
+	*x = Domain{}
+	mi := &file_driver_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Domain) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Domain) ProtoMessage() {}
+
+func (x *Domain) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Domain.ProtoReflect.Descriptor instead.
+func (*Domain) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Domain) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Domain) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Domain) GetHypervisor() string {
+	if x != nil {
+		return x.Hypervisor
+	}
+	return ""
+}
+
+func (x *Domain) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *Domain) GetOsType() string {
+	if x != nil {
+		return x.OsType
+	}
+	return ""
+}
+
+func (x *Domain) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *Domain) GetFlags() int32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
+func (x *Domain) GetPersistent() bool {
+	if x != nil && x.Persistent != nil {
+		return *x.Persistent
+	}
+	return false
+}
+
+func (x *Domain) GetAutostart() bool {
+	if x != nil && x.Autostart != nil {
+		return *x.Autostart
+	}
+	return false
+}
+
+func (x *Domain) GetVcpus() int32 {
+	if x != nil {
+		return x.Vcpus
+	}
+	return 0
+}
+
+func (x *Domain) GetVcpusCurrent() int32 {
+	if x != nil {
+		return x.VcpusCurrent
+	}
+	return 0
+}
+
+func (x *Domain) GetVcpusMaximum() int32 {
+	if x != nil {
+		return x.VcpusMaximum
+	}
+	return 0
+}
+
+func (x *Domain) GetNestedVirt() bool {
+	if x != nil && x.NestedVirt != nil {
+		return *x.NestedVirt
+	}
+	return false
+}
+
+func (x *Domain) GetCpus() []*CPU {
+	if x != nil {
+		return x.Cpus
+	}
+	return nil
+}
+
+func (x *Domain) GetBlocks() []*BlockDevice {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+func (x *Domain) GetInterfaces() []*NetworkInterface {
+	if x != nil {
+		return x.Interfaces
+	}
+	return nil
+}
+
+func (x *Domain) GetMemory() *Memory {
+	if x != nil {
+		return x.Memory
+	}
+	return nil
+}
+
+func (x *Domain) GetFilesystems() []*Filesystem {
+	if x != nil {
+		return x.Filesystems
+	}
+	return nil
+}
+
+func (x *Domain) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Domain) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Domain) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Domain) GetGraphics() []*GraphicsDevice {
+	if x != nil {
+		return x.Graphics
+	}
+	return nil
+}
+
+func (x *Domain) GetConsoles() []string {
+	if x != nil {
+		return x.Consoles
+	}
+	return nil
+}
+
+func (x *Domain) GetCollectDuration() int64 {
+	if x != nil {
+		return x.CollectDuration
+	}
+	return 0
+}
+
+func (x *Domain) GetMemoryBacking() *MemoryBacking {
+	if x != nil {
+		return x.MemoryBacking
+	}
+	return nil
+}
+
+func (x *Domain) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *Domain) GetHostDevices() []*HostDevice {
+	if x != nil {
+		return x.HostDevices
+	}
+	return nil
+}
+
+func (x *Domain) GetCpuTuning() *CPUTuning {
+	if x != nil {
+		return x.CpuTuning
+	}
+	return nil
+}
+
+func (x *Domain) GetIothreads() []*IOThread {
+	if x != nil {
+		return x.Iothreads
+	}
+	return nil
+}
+
+func (x *Domain) GetEmulatorTime() float64 {
+	if x != nil && x.EmulatorTime != nil {
+		return *x.EmulatorTime
+	}
+	return 0
+}
+
+func (x *Domain) GetNuma() *NUMA {
+	if x != nil {
+		return x.Numa
+	}
+	return nil
+}
+
+func (x *Domain) GetMachineType() string {
+	if x != nil {
+		return x.MachineType
+	}
+	return ""
+}
+
+func (x *Domain) GetEmulatorVersion() string {
+	if x != nil {
+		return x.EmulatorVersion
+	}
+	return ""
+}
+
+func (x *Domain) GetGuestAgent() int32 {
+	if x != nil {
+		return x.GuestAgent
+	}
+	return 0
+}
+
+func (x *Domain) GetTopology() *CPUTopology {
+	if x != nil {
+		return x.Topology
+	}
+	return nil
+}
+
+type CPUTopology struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Sockets        int32                  `protobuf:"varint,1,opt,name=sockets,proto3" json:"sockets,omitempty"`
+	CoresPerSocket int32                  `protobuf:"varint,2,opt,name=cores_per_socket,json=coresPerSocket,proto3" json:"cores_per_socket,omitempty"`
+	ThreadsPerCore int32                  `protobuf:"varint,3,opt,name=threads_per_core,json=threadsPerCore,proto3" json:"threads_per_core,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CPUTopology) Reset() {
+	*x = CPUTopology{}
+	mi := &file_driver_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CPUTopology) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CPUTopology) ProtoMessage() {}
+
+func (x *CPUTopology) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CPUTopology.ProtoReflect.Descriptor instead.
+func (*CPUTopology) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CPUTopology) GetSockets() int32 {
+	if x != nil {
+		return x.Sockets
+	}
+	return 0
+}
+
+func (x *CPUTopology) GetCoresPerSocket() int32 {
+	if x != nil {
+		return x.CoresPerSocket
+	}
+	return 0
+}
+
+func (x *CPUTopology) GetThreadsPerCore() int32 {
+	if x != nil {
+		return x.ThreadsPerCore
+	}
+	return 0
+}
+
+type CPU struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// flags driver.CPUFlag
+	Flags       int32    `protobuf:"varint,2,opt,name=flags,proto3" json:"flags,omitempty"`
+	Time        float64  `protobuf:"fixed64,3,opt,name=time,proto3" json:"time,omitempty"`
+	Idle        *float64 `protobuf:"fixed64,4,opt,name=idle,proto3,oneof" json:"idle,omitempty"`
+	Load1       float64  `protobuf:"fixed64,5,opt,name=load1,proto3" json:"load1,omitempty"`
+	Load5       float64  `protobuf:"fixed64,6,opt,name=load5,proto3" json:"load5,omitempty"`
+	Load15      float64  `protobuf:"fixed64,7,opt,name=load15,proto3" json:"load15,omitempty"`
+	PhysicalCpu *int32   `protobuf:"varint,8,opt,name=physical_cpu,json=physicalCpu,proto3,oneof" json:"physical_cpu,omitempty"`
+	// affinity driver.CPUSet words
+	Affinity      []uint64 `protobuf:"varint,9,rep,packed,name=affinity,proto3" json:"affinity,omitempty"`
+	Steal         *float64 `protobuf:"fixed64,10,opt,name=steal,proto3,oneof" json:"steal,omitempty"`
+	Iowait        *float64 `protobuf:"fixed64,11,opt,name=iowait,proto3,oneof" json:"iowait,omitempty"`
+	NumaNode      *int32   `protobuf:"varint,12,opt,name=numa_node,json=numaNode,proto3,oneof" json:"numa_node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CPU) Reset() {
+	*x = CPU{}
+	mi := &file_driver_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CPU) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CPU) ProtoMessage() {}
+
+func (x *CPU) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CPU.ProtoReflect.Descriptor instead.
+func (*CPU) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CPU) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *CPU) GetFlags() int32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
+func (x *CPU) GetTime() float64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *CPU) GetIdle() float64 {
+	if x != nil && x.Idle != nil {
+		return *x.Idle
+	}
+	return 0
+}
+
+func (x *CPU) GetLoad1() float64 {
+	if x != nil {
+		return x.Load1
+	}
+	return 0
+}
+
+func (x *CPU) GetLoad5() float64 {
+	if x != nil {
+		return x.Load5
+	}
+	return 0
+}
+
+func (x *CPU) GetLoad15() float64 {
+	if x != nil {
+		return x.Load15
+	}
+	return 0
+}
+
+func (x *CPU) GetPhysicalCpu() int32 {
+	if x != nil && x.PhysicalCpu != nil {
+		return *x.PhysicalCpu
+	}
+	return 0
+}
+
+func (x *CPU) GetAffinity() []uint64 {
+	if x != nil {
+		return x.Affinity
+	}
+	return nil
+}
+
+func (x *CPU) GetSteal() float64 {
+	if x != nil && x.Steal != nil {
+		return *x.Steal
+	}
+	return 0
+}
+
+func (x *CPU) GetIowait() float64 {
+	if x != nil && x.Iowait != nil {
+		return *x.Iowait
+	}
+	return 0
+}
+
+func (x *CPU) GetNumaNode() int32 {
+	if x != nil && x.NumaNode != nil {
+		return *x.NumaNode
+	}
+	return 0
+}
+
+type NUMA struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Mode  string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	// nodes driver.CPUSet words
+	Nodes         []uint64    `protobuf:"varint,2,rep,packed,name=nodes,proto3" json:"nodes,omitempty"`
+	Cells         []*NUMACell `protobuf:"bytes,3,rep,name=cells,proto3" json:"cells,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NUMA) Reset() {
+	*x = NUMA{}
+	mi := &file_driver_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NUMA) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NUMA) ProtoMessage() {}
+
+func (x *NUMA) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NUMA.ProtoReflect.Descriptor instead.
+func (*NUMA) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *NUMA) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *NUMA) GetNodes() []uint64 {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *NUMA) GetCells() []*NUMACell {
+	if x != nil {
+		return x.Cells
+	}
+	return nil
+}
+
+type NUMACell struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// cpus driver.CPUSet words
+	Cpus   []uint64 `protobuf:"varint,2,rep,packed,name=cpus,proto3" json:"cpus,omitempty"`
+	Memory uint64   `protobuf:"varint,3,opt,name=memory,proto3" json:"memory,omitempty"`
+	Mode   string   `protobuf:"bytes,4,opt,name=mode,proto3" json:"mode,omitempty"`
+	// nodes driver.CPUSet words
+	Nodes         []uint64 `protobuf:"varint,5,rep,packed,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NUMACell) Reset() {
+	*x = NUMACell{}
+	mi := &file_driver_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NUMACell) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NUMACell) ProtoMessage() {}
+
+func (x *NUMACell) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NUMACell.ProtoReflect.Descriptor instead.
+func (*NUMACell) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *NUMACell) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *NUMACell) GetCpus() []uint64 {
+	if x != nil {
+		return x.Cpus
+	}
+	return nil
+}
+
+func (x *NUMACell) GetMemory() uint64 {
+	if x != nil {
+		return x.Memory
+	}
+	return 0
+}
+
+func (x *NUMACell) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *NUMACell) GetNodes() []uint64 {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type IOThread struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Time  *float64               `protobuf:"fixed64,2,opt,name=time,proto3,oneof" json:"time,omitempty"`
+	// affinity driver.CPUSet words
+	Affinity      []uint64 `protobuf:"varint,3,rep,packed,name=affinity,proto3" json:"affinity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IOThread) Reset() {
+	*x = IOThread{}
+	mi := &file_driver_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IOThread) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IOThread) ProtoMessage() {}
+
+func (x *IOThread) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IOThread.ProtoReflect.Descriptor instead.
+func (*IOThread) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *IOThread) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *IOThread) GetTime() float64 {
+	if x != nil && x.Time != nil {
+		return *x.Time
+	}
+	return 0
+}
+
+func (x *IOThread) GetAffinity() []uint64 {
+	if x != nil {
+		return x.Affinity
+	}
+	return nil
+}
+
+type CPUTuning struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Shares         uint64                 `protobuf:"varint,1,opt,name=shares,proto3" json:"shares,omitempty"`
+	Weight         uint64                 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	Period         uint64                 `protobuf:"varint,3,opt,name=period,proto3" json:"period,omitempty"`
+	Quota          int64                  `protobuf:"varint,4,opt,name=quota,proto3" json:"quota,omitempty"`
+	GlobalPeriod   uint64                 `protobuf:"varint,5,opt,name=global_period,json=globalPeriod,proto3" json:"global_period,omitempty"`
+	GlobalQuota    int64                  `protobuf:"varint,6,opt,name=global_quota,json=globalQuota,proto3" json:"global_quota,omitempty"`
+	EmulatorPeriod uint64                 `protobuf:"varint,7,opt,name=emulator_period,json=emulatorPeriod,proto3" json:"emulator_period,omitempty"`
+	EmulatorQuota  int64                  `protobuf:"varint,8,opt,name=emulator_quota,json=emulatorQuota,proto3" json:"emulator_quota,omitempty"`
+	IothreadPeriod uint64                 `protobuf:"varint,9,opt,name=iothread_period,json=iothreadPeriod,proto3" json:"iothread_period,omitempty"`
+	IothreadQuota  int64                  `protobuf:"varint,10,opt,name=iothread_quota,json=iothreadQuota,proto3" json:"iothread_quota,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CPUTuning) Reset() {
+	*x = CPUTuning{}
+	mi := &file_driver_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CPUTuning) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CPUTuning) ProtoMessage() {}
+
+func (x *CPUTuning) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CPUTuning.ProtoReflect.Descriptor instead.
+func (*CPUTuning) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *CPUTuning) GetShares() uint64 {
+	if x != nil {
+		return x.Shares
+	}
+	return 0
+}
+
+func (x *CPUTuning) GetWeight() uint64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *CPUTuning) GetPeriod() uint64 {
+	if x != nil {
+		return x.Period
+	}
+	return 0
+}
+
+func (x *CPUTuning) GetQuota() int64 {
+	if x != nil {
+		return x.Quota
+	}
+	return 0
+}
+
+func (x *CPUTuning) GetGlobalPeriod() uint64 {
+	if x != nil {
+		return x.GlobalPeriod
+	}
+	return 0
+}
+
+func (x *CPUTuning) GetGlobalQuota() int64 {
+	if x != nil {
+		return x.GlobalQuota
+	}
+	return 0
+}
+
+func (x *CPUTuning) GetEmulatorPeriod() uint64 {
+	if x != nil {
+		return x.EmulatorPeriod
+	}
+	return 0
+}
+
+func (x *CPUTuning) GetEmulatorQuota() int64 {
+	if x != nil {
+		return x.EmulatorQuota
+	}
+	return 0
+}
+
+func (x *CPUTuning) GetIothreadPeriod() uint64 {
+	if x != nil {
+		return x.IothreadPeriod
+	}
+	return 0
+}
+
+func (x *CPUTuning) GetIothreadQuota() int64 {
+	if x != nil {
+		return x.IothreadQuota
+	}
+	return 0
+}
+
+type BlockIO struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operations    uint64                 `protobuf:"varint,1,opt,name=operations,proto3" json:"operations,omitempty"`
+	Bytes         uint64                 `protobuf:"varint,2,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Sectors       uint64                 `protobuf:"varint,3,opt,name=sectors,proto3" json:"sectors,omitempty"`
+	Absolute      bool                   `protobuf:"varint,4,opt,name=absolute,proto3" json:"absolute,omitempty"`
+	TotalTime     *uint64                `protobuf:"varint,5,opt,name=total_time,json=totalTime,proto3,oneof" json:"total_time,omitempty"`
+	Errors        uint64                 `protobuf:"varint,6,opt,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockIO) Reset() {
+	*x = BlockIO{}
+	mi := &file_driver_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockIO) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockIO) ProtoMessage() {}
+
+func (x *BlockIO) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockIO.ProtoReflect.Descriptor instead.
+func (*BlockIO) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *BlockIO) GetOperations() uint64 {
+	if x != nil {
+		return x.Operations
+	}
+	return 0
+}
+
+func (x *BlockIO) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *BlockIO) GetSectors() uint64 {
+	if x != nil {
+		return x.Sectors
+	}
+	return 0
+}
+
+func (x *BlockIO) GetAbsolute() bool {
+	if x != nil {
+		return x.Absolute
+	}
+	return false
+}
+
+func (x *BlockIO) GetTotalTime() uint64 {
+	if x != nil && x.TotalTime != nil {
+		return *x.TotalTime
+	}
+	return 0
+}
+
+func (x *BlockIO) GetErrors() uint64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+type BlockLimits struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReadIops      uint64                 `protobuf:"varint,1,opt,name=read_iops,json=readIops,proto3" json:"read_iops,omitempty"`
+	WriteIops     uint64                 `protobuf:"varint,2,opt,name=write_iops,json=writeIops,proto3" json:"write_iops,omitempty"`
+	TotalIops     uint64                 `protobuf:"varint,3,opt,name=total_iops,json=totalIops,proto3" json:"total_iops,omitempty"`
+	ReadBytesSec  uint64                 `protobuf:"varint,4,opt,name=read_bytes_sec,json=readBytesSec,proto3" json:"read_bytes_sec,omitempty"`
+	WriteBytesSec uint64                 `protobuf:"varint,5,opt,name=write_bytes_sec,json=writeBytesSec,proto3" json:"write_bytes_sec,omitempty"`
+	TotalBytesSec uint64                 `protobuf:"varint,6,opt,name=total_bytes_sec,json=totalBytesSec,proto3" json:"total_bytes_sec,omitempty"`
+	Weight        uint64                 `protobuf:"varint,7,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockLimits) Reset() {
+	*x = BlockLimits{}
+	mi := &file_driver_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockLimits) ProtoMessage() {}
+
+func (x *BlockLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockLimits.ProtoReflect.Descriptor instead.
+func (*BlockLimits) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *BlockLimits) GetReadIops() uint64 {
+	if x != nil {
+		return x.ReadIops
+	}
+	return 0
+}
+
+func (x *BlockLimits) GetWriteIops() uint64 {
+	if x != nil {
+		return x.WriteIops
+	}
+	return 0
+}
+
+func (x *BlockLimits) GetTotalIops() uint64 {
+	if x != nil {
+		return x.TotalIops
+	}
+	return 0
+}
+
+func (x *BlockLimits) GetReadBytesSec() uint64 {
+	if x != nil {
+		return x.ReadBytesSec
+	}
+	return 0
+}
+
+func (x *BlockLimits) GetWriteBytesSec() uint64 {
+	if x != nil {
+		return x.WriteBytesSec
+	}
+	return 0
+}
+
+func (x *BlockLimits) GetTotalBytesSec() uint64 {
+	if x != nil {
+		return x.TotalBytesSec
+	}
+	return 0
+}
+
+func (x *BlockLimits) GetWeight() uint64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type BlockDevice struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	ReadOnly      bool                   `protobuf:"varint,2,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	IsDisk        bool                   `protobuf:"varint,3,opt,name=is_disk,json=isDisk,proto3" json:"is_disk,omitempty"`
+	IsCdrom       bool                   `protobuf:"varint,4,opt,name=is_cdrom,json=isCdrom,proto3" json:"is_cdrom,omitempty"`
+	Read          *BlockIO               `protobuf:"bytes,5,opt,name=read,proto3" json:"read,omitempty"`
+	Write         *BlockIO               `protobuf:"bytes,6,opt,name=write,proto3" json:"write,omitempty"`
+	Flush         *BlockIO               `protobuf:"bytes,7,opt,name=flush,proto3" json:"flush,omitempty"`
+	Bus           string                 `protobuf:"bytes,8,opt,name=bus,proto3" json:"bus,omitempty"`
+	Target        string                 `protobuf:"bytes,9,opt,name=target,proto3" json:"target,omitempty"`
+	Source        string                 `protobuf:"bytes,10,opt,name=source,proto3" json:"source,omitempty"`
+	Capacity      uint64                 `protobuf:"varint,11,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	Allocation    uint64                 `protobuf:"varint,12,opt,name=allocation,proto3" json:"allocation,omitempty"`
+	Physical      uint64                 `protobuf:"varint,13,opt,name=physical,proto3" json:"physical,omitempty"`
+	Limits        *BlockLimits           `protobuf:"bytes,14,opt,name=limits,proto3" json:"limits,omitempty"`
+	Stalled       bool                   `protobuf:"varint,15,opt,name=stalled,proto3" json:"stalled,omitempty"`
+	BackingChain  []string               `protobuf:"bytes,16,rep,name=backing_chain,json=backingChain,proto3" json:"backing_chain,omitempty"`
+	Throttled     bool                   `protobuf:"varint,17,opt,name=throttled,proto3" json:"throttled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BlockDevice) Reset() {
+	*x = BlockDevice{}
+	mi := &file_driver_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockDevice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockDevice) ProtoMessage() {}
+
+func (x *BlockDevice) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockDevice.ProtoReflect.Descriptor instead.
+func (*BlockDevice) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *BlockDevice) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *BlockDevice) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *BlockDevice) GetIsDisk() bool {
+	if x != nil {
+		return x.IsDisk
+	}
+	return false
+}
+
+func (x *BlockDevice) GetIsCdrom() bool {
+	if x != nil {
+		return x.IsCdrom
+	}
+	return false
+}
+
+func (x *BlockDevice) GetRead() *BlockIO {
+	if x != nil {
+		return x.Read
+	}
+	return nil
+}
+
+func (x *BlockDevice) GetWrite() *BlockIO {
+	if x != nil {
+		return x.Write
+	}
+	return nil
+}
+
+func (x *BlockDevice) GetFlush() *BlockIO {
+	if x != nil {
+		return x.Flush
+	}
+	return nil
+}
+
+func (x *BlockDevice) GetBus() string {
+	if x != nil {
+		return x.Bus
+	}
+	return ""
+}
+
+func (x *BlockDevice) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *BlockDevice) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *BlockDevice) GetCapacity() uint64 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *BlockDevice) GetAllocation() uint64 {
+	if x != nil {
+		return x.Allocation
+	}
+	return 0
+}
+
+func (x *BlockDevice) GetPhysical() uint64 {
+	if x != nil {
+		return x.Physical
+	}
+	return 0
+}
+
+func (x *BlockDevice) GetLimits() *BlockLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *BlockDevice) GetStalled() bool {
+	if x != nil {
+		return x.Stalled
+	}
+	return false
+}
+
+func (x *BlockDevice) GetBackingChain() []string {
+	if x != nil {
+		return x.BackingChain
+	}
+	return nil
+}
+
+func (x *BlockDevice) GetThrottled() bool {
+	if x != nil {
+		return x.Throttled
+	}
+	return false
+}
+
+type HostDevice struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostDevice) Reset() {
+	*x = HostDevice{}
+	mi := &file_driver_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostDevice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostDevice) ProtoMessage() {}
+
+func (x *HostDevice) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostDevice.ProtoReflect.Descriptor instead.
+func (*HostDevice) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *HostDevice) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *HostDevice) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *HostDevice) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HostDevice) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type NetworkIO struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bytes         uint64                 `protobuf:"varint,1,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Packets       uint64                 `protobuf:"varint,2,opt,name=packets,proto3" json:"packets,omitempty"`
+	Errors        uint64                 `protobuf:"varint,3,opt,name=errors,proto3" json:"errors,omitempty"`
+	Drops         uint64                 `protobuf:"varint,4,opt,name=drops,proto3" json:"drops,omitempty"`
+	Fifo          uint64                 `protobuf:"varint,5,opt,name=fifo,proto3" json:"fifo,omitempty"`
+	Frame         uint64                 `protobuf:"varint,6,opt,name=frame,proto3" json:"frame,omitempty"`
+	Collisions    uint64                 `protobuf:"varint,7,opt,name=collisions,proto3" json:"collisions,omitempty"`
+	Carrier       uint64                 `protobuf:"varint,8,opt,name=carrier,proto3" json:"carrier,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NetworkIO) Reset() {
+	*x = NetworkIO{}
+	mi := &file_driver_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NetworkIO) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkIO) ProtoMessage() {}
+
+func (x *NetworkIO) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkIO.ProtoReflect.Descriptor instead.
+func (*NetworkIO) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *NetworkIO) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *NetworkIO) GetPackets() uint64 {
+	if x != nil {
+		return x.Packets
+	}
+	return 0
+}
+
+func (x *NetworkIO) GetErrors() uint64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *NetworkIO) GetDrops() uint64 {
+	if x != nil {
+		return x.Drops
+	}
+	return 0
+}
+
+func (x *NetworkIO) GetFifo() uint64 {
+	if x != nil {
+		return x.Fifo
+	}
+	return 0
+}
+
+func (x *NetworkIO) GetFrame() uint64 {
+	if x != nil {
+		return x.Frame
+	}
+	return 0
+}
+
+func (x *NetworkIO) GetCollisions() uint64 {
+	if x != nil {
+		return x.Collisions
+	}
+	return 0
+}
+
+func (x *NetworkIO) GetCarrier() uint64 {
+	if x != nil {
+		return x.Carrier
+	}
+	return 0
+}
+
+type IPNet struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ip            []byte                 `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	Mask          []byte                 `protobuf:"bytes,2,opt,name=mask,proto3" json:"mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IPNet) Reset() {
+	*x = IPNet{}
+	mi := &file_driver_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IPNet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IPNet) ProtoMessage() {}
+
+func (x *IPNet) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IPNet.ProtoReflect.Descriptor instead.
+func (*IPNet) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *IPNet) GetIp() []byte {
+	if x != nil {
+		return x.Ip
+	}
+	return nil
+}
+
+func (x *IPNet) GetMask() []byte {
+	if x != nil {
+		return x.Mask
+	}
+	return nil
+}
+
+type NetworkInterface struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Mac           []byte                 `protobuf:"bytes,2,opt,name=mac,proto3" json:"mac,omitempty"`
+	Bridges       []string               `protobuf:"bytes,3,rep,name=bridges,proto3" json:"bridges,omitempty"`
+	Rx            *NetworkIO             `protobuf:"bytes,4,opt,name=rx,proto3" json:"rx,omitempty"`
+	Tx            *NetworkIO             `protobuf:"bytes,5,opt,name=tx,proto3" json:"tx,omitempty"`
+	Addresses     []*IPNet               `protobuf:"bytes,6,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	LinkUp        *bool                  `protobuf:"varint,7,opt,name=link_up,json=linkUp,proto3,oneof" json:"link_up,omitempty"`
+	InboundLimit  uint64                 `protobuf:"varint,8,opt,name=inbound_limit,json=inboundLimit,proto3" json:"inbound_limit,omitempty"`
+	OutboundLimit uint64                 `protobuf:"varint,9,opt,name=outbound_limit,json=outboundLimit,proto3" json:"outbound_limit,omitempty"`
+	HostDevice    string                 `protobuf:"bytes,10,opt,name=host_device,json=hostDevice,proto3" json:"host_device,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NetworkInterface) Reset() {
+	*x = NetworkInterface{}
+	mi := &file_driver_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NetworkInterface) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkInterface) ProtoMessage() {}
+
+func (x *NetworkInterface) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkInterface.ProtoReflect.Descriptor instead.
+func (*NetworkInterface) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *NetworkInterface) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *NetworkInterface) GetMac() []byte {
+	if x != nil {
+		return x.Mac
+	}
+	return nil
+}
+
+func (x *NetworkInterface) GetBridges() []string {
+	if x != nil {
+		return x.Bridges
+	}
+	return nil
+}
+
+func (x *NetworkInterface) GetRx() *NetworkIO {
+	if x != nil {
+		return x.Rx
+	}
+	return nil
+}
+
+func (x *NetworkInterface) GetTx() *NetworkIO {
+	if x != nil {
+		return x.Tx
+	}
+	return nil
+}
+
+func (x *NetworkInterface) GetAddresses() []*IPNet {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+func (x *NetworkInterface) GetLinkUp() bool {
+	if x != nil && x.LinkUp != nil {
+		return *x.LinkUp
+	}
+	return false
+}
+
+func (x *NetworkInterface) GetInboundLimit() uint64 {
+	if x != nil {
+		return x.InboundLimit
+	}
+	return 0
+}
+
+func (x *NetworkInterface) GetOutboundLimit() uint64 {
+	if x != nil {
+		return x.OutboundLimit
+	}
+	return 0
+}
+
+func (x *NetworkInterface) GetHostDevice() string {
+	if x != nil {
+		return x.HostDevice
+	}
+	return ""
+}
+
+type Memory struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Actual        *uint64                `protobuf:"varint,1,opt,name=actual,proto3,oneof" json:"actual,omitempty"`
+	Available     *uint64                `protobuf:"varint,2,opt,name=available,proto3,oneof" json:"available,omitempty"`
+	Unused        *uint64                `protobuf:"varint,3,opt,name=unused,proto3,oneof" json:"unused,omitempty"`
+	Rss           *uint64                `protobuf:"varint,4,opt,name=rss,proto3,oneof" json:"rss,omitempty"`
+	SwapIn        *uint64                `protobuf:"varint,5,opt,name=swap_in,json=swapIn,proto3,oneof" json:"swap_in,omitempty"`
+	SwapOut       *uint64                `protobuf:"varint,6,opt,name=swap_out,json=swapOut,proto3,oneof" json:"swap_out,omitempty"`
+	MajorFaults   *uint64                `protobuf:"varint,7,opt,name=major_faults,json=majorFaults,proto3,oneof" json:"major_faults,omitempty"`
+	MinorFaults   *uint64                `protobuf:"varint,8,opt,name=minor_faults,json=minorFaults,proto3,oneof" json:"minor_faults,omitempty"`
+	DirtyRate     *uint64                `protobuf:"varint,9,opt,name=dirty_rate,json=dirtyRate,proto3,oneof" json:"dirty_rate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Memory) Reset() {
+	*x = Memory{}
+	mi := &file_driver_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Memory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Memory) ProtoMessage() {}
+
+func (x *Memory) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Memory.ProtoReflect.Descriptor instead.
+func (*Memory) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *Memory) GetActual() uint64 {
+	if x != nil && x.Actual != nil {
+		return *x.Actual
+	}
+	return 0
+}
+
+func (x *Memory) GetAvailable() uint64 {
+	if x != nil && x.Available != nil {
+		return *x.Available
+	}
+	return 0
+}
+
+func (x *Memory) GetUnused() uint64 {
+	if x != nil && x.Unused != nil {
+		return *x.Unused
+	}
+	return 0
+}
+
+func (x *Memory) GetRss() uint64 {
+	if x != nil && x.Rss != nil {
+		return *x.Rss
+	}
+	return 0
+}
+
+func (x *Memory) GetSwapIn() uint64 {
+	if x != nil && x.SwapIn != nil {
+		return *x.SwapIn
+	}
+	return 0
+}
+
+func (x *Memory) GetSwapOut() uint64 {
+	if x != nil && x.SwapOut != nil {
+		return *x.SwapOut
+	}
+	return 0
+}
+
+func (x *Memory) GetMajorFaults() uint64 {
+	if x != nil && x.MajorFaults != nil {
+		return *x.MajorFaults
+	}
+	return 0
+}
+
+func (x *Memory) GetMinorFaults() uint64 {
+	if x != nil && x.MinorFaults != nil {
+		return *x.MinorFaults
+	}
+	return 0
+}
+
+func (x *Memory) GetDirtyRate() uint64 {
+	if x != nil && x.DirtyRate != nil {
+		return *x.DirtyRate
+	}
+	return 0
+}
+
+type MemoryBacking struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	BalloonCurrent uint64                 `protobuf:"varint,1,opt,name=balloon_current,json=balloonCurrent,proto3" json:"balloon_current,omitempty"`
+	BalloonMaximum uint64                 `protobuf:"varint,2,opt,name=balloon_maximum,json=balloonMaximum,proto3" json:"balloon_maximum,omitempty"`
+	Hugepages      bool                   `protobuf:"varint,3,opt,name=hugepages,proto3" json:"hugepages,omitempty"`
+	HugepageSize   uint64                 `protobuf:"varint,4,opt,name=hugepage_size,json=hugepageSize,proto3" json:"hugepage_size,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MemoryBacking) Reset() {
+	*x = MemoryBacking{}
+	mi := &file_driver_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MemoryBacking) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MemoryBacking) ProtoMessage() {}
+
+func (x *MemoryBacking) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MemoryBacking.ProtoReflect.Descriptor instead.
+func (*MemoryBacking) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *MemoryBacking) GetBalloonCurrent() uint64 {
+	if x != nil {
+		return x.BalloonCurrent
+	}
+	return 0
+}
+
+func (x *MemoryBacking) GetBalloonMaximum() uint64 {
+	if x != nil {
+		return x.BalloonMaximum
+	}
+	return 0
+}
+
+func (x *MemoryBacking) GetHugepages() bool {
+	if x != nil {
+		return x.Hugepages
+	}
+	return false
+}
+
+func (x *MemoryBacking) GetHugepageSize() uint64 {
+	if x != nil {
+		return x.HugepageSize
+	}
+	return 0
+}
+
+type Filesystem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mountpoint    string                 `protobuf:"bytes,1,opt,name=mountpoint,proto3" json:"mountpoint,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	TotalBytes    uint64                 `protobuf:"varint,4,opt,name=total_bytes,json=totalBytes,proto3" json:"total_bytes,omitempty"`
+	UsedBytes     uint64                 `protobuf:"varint,5,opt,name=used_bytes,json=usedBytes,proto3" json:"used_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Filesystem) Reset() {
+	*x = Filesystem{}
+	mi := &file_driver_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Filesystem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Filesystem) ProtoMessage() {}
+
+func (x *Filesystem) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Filesystem.ProtoReflect.Descriptor instead.
+func (*Filesystem) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *Filesystem) GetMountpoint() string {
+	if x != nil {
+		return x.Mountpoint
+	}
+	return ""
+}
+
+func (x *Filesystem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Filesystem) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Filesystem) GetTotalBytes() uint64 {
+	if x != nil {
+		return x.TotalBytes
+	}
+	return 0
+}
+
+func (x *Filesystem) GetUsedBytes() uint64 {
+	if x != nil {
+		return x.UsedBytes
+	}
+	return 0
+}
+
+type GraphicsDevice struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Listen        []byte                 `protobuf:"bytes,2,opt,name=listen,proto3" json:"listen,omitempty"`
+	Port          int32                  `protobuf:"varint,3,opt,name=port,proto3" json:"port,omitempty"`
+	TlsPort       int32                  `protobuf:"varint,4,opt,name=tls_port,json=tlsPort,proto3" json:"tls_port,omitempty"`
+	Password      bool                   `protobuf:"varint,5,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GraphicsDevice) Reset() {
+	*x = GraphicsDevice{}
+	mi := &file_driver_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GraphicsDevice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GraphicsDevice) ProtoMessage() {}
+
+func (x *GraphicsDevice) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GraphicsDevice.ProtoReflect.Descriptor instead.
+func (*GraphicsDevice) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *GraphicsDevice) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *GraphicsDevice) GetListen() []byte {
+	if x != nil {
+		return x.Listen
+	}
+	return nil
+}
+
+func (x *GraphicsDevice) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *GraphicsDevice) GetTlsPort() int32 {
+	if x != nil {
+		return x.TlsPort
+	}
+	return 0
+}
+
+func (x *GraphicsDevice) GetPassword() bool {
+	if x != nil {
+		return x.Password
+	}
+	return false
+}
+
+type Snapshot struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// creation_time Unix time in nanoseconds
+	CreationTime  int64  `protobuf:"varint,2,opt,name=creation_time,json=creationTime,proto3" json:"creation_time,omitempty"`
+	State         string `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Parent        string `protobuf:"bytes,4,opt,name=parent,proto3" json:"parent,omitempty"`
+	IsCurrent     bool   `protobuf:"varint,5,opt,name=is_current,json=isCurrent,proto3" json:"is_current,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_driver_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *Snapshot) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Snapshot) GetCreationTime() int64 {
+	if x != nil {
+		return x.CreationTime
+	}
+	return 0
+}
+
+func (x *Snapshot) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Snapshot) GetParent() string {
+	if x != nil {
+		return x.Parent
+	}
+	return ""
+}
+
+func (x *Snapshot) GetIsCurrent() bool {
+	if x != nil {
+		return x.IsCurrent
+	}
+	return false
+}
+
+type HostInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Cpus          int32                  `protobuf:"varint,2,opt,name=cpus,proto3" json:"cpus,omitempty"`
+	MemoryTotal   uint64                 `protobuf:"varint,3,opt,name=memory_total,json=memoryTotal,proto3" json:"memory_total,omitempty"`
+	MemoryFree    uint64                 `protobuf:"varint,4,opt,name=memory_free,json=memoryFree,proto3" json:"memory_free,omitempty"`
+	Load1         float64                `protobuf:"fixed64,5,opt,name=load1,proto3" json:"load1,omitempty"`
+	Load5         float64                `protobuf:"fixed64,6,opt,name=load5,proto3" json:"load5,omitempty"`
+	Load15        float64                `protobuf:"fixed64,7,opt,name=load15,proto3" json:"load15,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostInfo) Reset() {
+	*x = HostInfo{}
+	mi := &file_driver_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostInfo) ProtoMessage() {}
+
+func (x *HostInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostInfo.ProtoReflect.Descriptor instead.
+func (*HostInfo) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *HostInfo) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *HostInfo) GetCpus() int32 {
+	if x != nil {
+		return x.Cpus
+	}
+	return 0
+}
+
+func (x *HostInfo) GetMemoryTotal() uint64 {
+	if x != nil {
+		return x.MemoryTotal
+	}
+	return 0
+}
+
+func (x *HostInfo) GetMemoryFree() uint64 {
+	if x != nil {
+		return x.MemoryFree
+	}
+	return 0
+}
+
+func (x *HostInfo) GetLoad1() float64 {
+	if x != nil {
+		return x.Load1
+	}
+	return 0
+}
+
+func (x *HostInfo) GetLoad5() float64 {
+	if x != nil {
+		return x.Load5
+	}
+	return 0
+}
+
+func (x *HostInfo) GetLoad15() float64 {
+	if x != nil {
+		return x.Load15
+	}
+	return 0
+}
+
+type DomainEvent struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Uuid       string                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Name       string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Hypervisor string                 `protobuf:"bytes,4,opt,name=hypervisor,proto3" json:"hypervisor,omitempty"`
+	// flags driver.DomainFlag
+	Flags         int32 `protobuf:"varint,5,opt,name=flags,proto3" json:"flags,omitempty"`
+	Time          int64 `protobuf:"varint,6,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DomainEvent) Reset() {
+	*x = DomainEvent{}
+	mi := &file_driver_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DomainEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DomainEvent) ProtoMessage() {}
+
+func (x *DomainEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_driver_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DomainEvent.ProtoReflect.Descriptor instead.
+func (*DomainEvent) Descriptor() ([]byte, []int) {
+	return file_driver_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *DomainEvent) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DomainEvent) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *DomainEvent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DomainEvent) GetHypervisor() string {
+	if x != nil {
+		return x.Hypervisor
+	}
+	return ""
+}
+
+func (x *DomainEvent) GetFlags() int32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
+func (x *DomainEvent) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+var File_driver_proto protoreflect.FileDescriptor
+
+const file_driver_proto_rawDesc = "" +
+	"\n" +
+	"\fdriver.proto\x12\x15virtmonitor.driver.v1\"\r\n" +
+	"\vInfoRequest\"\x9f\x01\n" +
+	"\fInfoResponse\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12G\n" +
+	"\fcapabilities\x18\x02 \x01(\v2#.virtmonitor.driver.v1.CapabilitiesR\fcapabilities\x12\x1a\n" +
+	"\bdetected\x18\x03 \x01(\bR\bdetected\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\xfd\x06\n" +
+	"\x0eCollectOptions\x12\x12\n" +
+	"\x04cpus\x18\x01 \x01(\bR\x04cpus\x12\x18\n" +
+	"\apinning\x18\x02 \x01(\bR\apinning\x12\x16\n" +
+	"\x06blocks\x18\x03 \x01(\bR\x06blocks\x12%\n" +
+	"\x0eblock_capacity\x18\x04 \x01(\bR\rblockCapacity\x12\x1e\n" +
+	"\n" +
+	"interfaces\x18\x05 \x01(\bR\n" +
+	"interfaces\x12\x1c\n" +
+	"\taddresses\x18\x06 \x01(\bR\taddresses\x12\x16\n" +
+	"\x06limits\x18\a \x01(\bR\x06limits\x12\x16\n" +
+	"\x06memory\x18\b \x01(\bR\x06memory\x12 \n" +
+	"\vfilesystems\x18\t \x01(\bR\vfilesystems\x12\x1a\n" +
+	"\bgraphics\x18\n" +
+	" \x01(\bR\bgraphics\x12\x1a\n" +
+	"\bmetadata\x18\v \x01(\bR\bmetadata\x12 \n" +
+	"\vconcurrency\x18\f \x01(\x05R\vconcurrency\x12!\n" +
+	"\fhost_devices\x18\r \x01(\bR\vhostDevices\x12\x1d\n" +
+	"\n" +
+	"cpu_tuning\x18\x0e \x01(\bR\tcpuTuning\x12\x1f\n" +
+	"\vskip_states\x18\x0f \x03(\x05R\n" +
+	"skipStates\x12\x12\n" +
+	"\x04numa\x18\x10 \x01(\bR\x04numa\x12+\n" +
+	"\x11enumerate_timeout\x18\x11 \x01(\x03R\x10enumerateTimeout\x12,\n" +
+	"\x12per_domain_timeout\x18\x12 \x01(\x03R\x10perDomainTimeout\x12#\n" +
+	"\rbacking_chain\x18\x13 \x01(\bR\fbackingChain\x12\x1a\n" +
+	"\bversions\x18\x14 \x01(\bR\bversions\x12)\n" +
+	"\x10include_inactive\x18\x15 \x01(\bR\x0fincludeInactive\x12\x18\n" +
+	"\aretries\x18\x16 \x01(\x05R\aretries\x12\x1f\n" +
+	"\vretry_delay\x18\x17 \x01(\x03R\n" +
+	"retryDelay\x12#\n" +
+	"\rslow_interval\x18\x18 \x01(\x03R\fslowInterval\x12\x18\n" +
+	"\ainclude\x18\x19 \x03(\tR\ainclude\x12\x18\n" +
+	"\aexclude\x18\x1a \x03(\tR\aexclude\x12\x1d\n" +
+	"\n" +
+	"dirty_rate\x18\x1b \x01(\bR\tdirtyRate\x12#\n" +
+	"\rtopology_only\x18\x1c \x01(\bR\ftopologyOnly\"Q\n" +
+	"\x0eCollectRequest\x12?\n" +
+	"\aoptions\x18\x01 \x01(\v2%.virtmonitor.driver.v1.CollectOptionsR\aoptions\"\xab\x01\n" +
+	"\x0fCollectResponse\x127\n" +
+	"\adomains\x18\x01 \x03(\v2\x1d.virtmonitor.driver.v1.DomainR\adomains\x12\x16\n" +
+	"\x06errors\x18\x02 \x03(\tR\x06errors\x12G\n" +
+	"\rdomain_errors\x18\x03 \x03(\v2\".virtmonitor.driver.v1.DomainErrorR\fdomainErrors\"K\n" +
+	"\vDomainError\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\x9c\x01\n" +
+	"\x14CollectDomainRequest\x12\x10\n" +
+	"\x02id\x18\x01 \x01(\x04H\x00R\x02id\x12\x14\n" +
+	"\x04uuid\x18\x02 \x01(\tH\x00R\x04uuid\x12\x14\n" +
+	"\x04name\x18\x03 \x01(\tH\x00R\x04name\x12?\n" +
+	"\aoptions\x18\x04 \x01(\v2%.virtmonitor.driver.v1.CollectOptionsR\aoptionsB\x05\n" +
+	"\x03key\")\n" +
+	"\x17CollectSnapshotsRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"Y\n" +
+	"\x18CollectSnapshotsResponse\x12=\n" +
+	"\tsnapshots\x18\x01 \x03(\v2\x1f.virtmonitor.driver.v1.SnapshotR\tsnapshots\"\r\n" +
+	"\vHostRequest\"\r\n" +
+	"\vPingRequest\"\x0e\n" +
+	"\fPingResponse\"\x0e\n" +
+	"\fWatchRequest\"\xef\x06\n" +
+	"\fCapabilities\x12#\n" +
+	"\rsupports_cpus\x18\x01 \x01(\bR\fsupportsCpus\x12'\n" +
+	"\x0fsupports_blocks\x18\x02 \x01(\bR\x0esupportsBlocks\x12/\n" +
+	"\x13supports_interfaces\x18\x03 \x01(\bR\x12supportsInterfaces\x12'\n" +
+	"\x0fsupports_memory\x18\x04 \x01(\bR\x0esupportsMemory\x121\n" +
+	"\x14supports_filesystems\x18\x05 \x01(\bR\x13supportsFilesystems\x12+\n" +
+	"\x11supports_graphics\x18\x06 \x01(\bR\x10supportsGraphics\x12+\n" +
+	"\x11supports_metadata\x18\a \x01(\bR\x10supportsMetadata\x12*\n" +
+	"\x11supports_guest_ip\x18\b \x01(\bR\x0fsupportsGuestIp\x126\n" +
+	"\x17supports_block_capacity\x18\t \x01(\bR\x15supportsBlockCapacity\x12)\n" +
+	"\x10supports_pinning\x18\n" +
+	" \x01(\bR\x0fsupportsPinning\x12'\n" +
+	"\x0fsupports_limits\x18\v \x01(\bR\x0esupportsLimits\x12-\n" +
+	"\x12supports_snapshots\x18\f \x01(\bR\x11supportsSnapshots\x12'\n" +
+	"\x0fsupports_events\x18\r \x01(\bR\x0esupportsEvents\x122\n" +
+	"\x15supports_host_devices\x18\x0e \x01(\bR\x13supportsHostDevices\x12.\n" +
+	"\x13supports_cpu_tuning\x18\x0f \x01(\bR\x11supportsCpuTuning\x12#\n" +
+	"\rsupports_numa\x18\x10 \x01(\bR\fsupportsNuma\x124\n" +
+	"\x16supports_backing_chain\x18\x11 \x01(\bR\x14supportsBackingChain\x12+\n" +
+	"\x11supports_versions\x18\x12 \x01(\bR\x10supportsVersions\x12.\n" +
+	"\x13supports_dirty_rate\x18\x13 \x01(\bR\x11supportsDirtyRate\"\xdd\f\n" +
+	"\x06Domain\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x04R\x02id\x12\x1e\n" +
+	"\n" +
+	"hypervisor\x18\x03 \x01(\tR\n" +
+	"hypervisor\x12\x12\n" +
+	"\x04uuid\x18\x04 \x01(\tR\x04uuid\x12\x17\n" +
+	"\aos_type\x18\x05 \x01(\tR\x06osType\x12\x12\n" +
+	"\x04time\x18\x06 \x01(\x03R\x04time\x12\x14\n" +
+	"\x05flags\x18\a \x01(\x05R\x05flags\x12#\n" +
+	"\n" +
+	"persistent\x18\b \x01(\bH\x00R\n" +
+	"persistent\x88\x01\x01\x12!\n" +
+	"\tautostart\x18\t \x01(\bH\x01R\tautostart\x88\x01\x01\x12\x14\n" +
+	"\x05vcpus\x18\n" +
+	" \x01(\x05R\x05vcpus\x12#\n" +
+	"\rvcpus_current\x18\v \x01(\x05R\fvcpusCurrent\x12#\n" +
+	"\rvcpus_maximum\x18\f \x01(\x05R\fvcpusMaximum\x12$\n" +
+	"\vnested_virt\x18\r \x01(\bH\x02R\n" +
+	"nestedVirt\x88\x01\x01\x12.\n" +
+	"\x04cpus\x18\x0e \x03(\v2\x1a.virtmonitor.driver.v1.CPUR\x04cpus\x12:\n" +
+	"\x06blocks\x18\x0f \x03(\v2\".virtmonitor.driver.v1.BlockDeviceR\x06blocks\x12G\n" +
+	"\n" +
+	"interfaces\x18\x10 \x03(\v2'.virtmonitor.driver.v1.NetworkInterfaceR\n" +
+	"interfaces\x125\n" +
+	"\x06memory\x18\x11 \x01(\v2\x1d.virtmonitor.driver.v1.MemoryR\x06memory\x12C\n" +
+	"\vfilesystems\x18\x12 \x03(\v2!.virtmonitor.driver.v1.FilesystemR\vfilesystems\x12\x14\n" +
+	"\x05title\x18\x13 \x01(\tR\x05title\x12 \n" +
+	"\vdescription\x18\x14 \x01(\tR\vdescription\x12A\n" +
+	"\x06labels\x18\x15 \x03(\v2).virtmonitor.driver.v1.Domain.LabelsEntryR\x06labels\x12A\n" +
+	"\bgraphics\x18\x16 \x03(\v2%.virtmonitor.driver.v1.GraphicsDeviceR\bgraphics\x12\x1a\n" +
+	"\bconsoles\x18\x17 \x03(\tR\bconsoles\x12)\n" +
+	"\x10collect_duration\x18\x18 \x01(\x03R\x0fcollectDuration\x12K\n" +
+	"\x0ememory_backing\x18\x19 \x01(\v2$.virtmonitor.driver.v1.MemoryBackingR\rmemoryBacking\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x1a \x01(\x03R\tstartTime\x12D\n" +
+	"\fhost_devices\x18\x1b \x03(\v2!.virtmonitor.driver.v1.HostDeviceR\vhostDevices\x12?\n" +
+	"\n" +
+	"cpu_tuning\x18\x1c \x01(\v2 .virtmonitor.driver.v1.CPUTuningR\tcpuTuning\x12=\n" +
+	"\tiothreads\x18\x1d \x03(\v2\x1f.virtmonitor.driver.v1.IOThreadR\tiothreads\x12(\n" +
+	"\remulator_time\x18\x1e \x01(\x01H\x03R\femulatorTime\x88\x01\x01\x12/\n" +
+	"\x04numa\x18\x1f \x01(\v2\x1b.virtmonitor.driver.v1.NUMAR\x04numa\x12!\n" +
+	"\fmachine_type\x18  \x01(\tR\vmachineType\x12)\n" +
+	"\x10emulator_version\x18! \x01(\tR\x0femulatorVersion\x12\x1f\n" +
+	"\vguest_agent\x18\" \x01(\x05R\n" +
+	"guestAgent\x12>\n" +
+	"\btopology\x18# \x01(\v2\".virtmonitor.driver.v1.CPUTopologyR\btopology\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\r\n" +
+	"\v_persistentB\f\n" +
+	"\n" +
+	"_autostartB\x0e\n" +
+	"\f_nested_virtB\x10\n" +
+	"\x0e_emulator_time\"{\n" +
+	"\vCPUTopology\x12\x18\n" +
+	"\asockets\x18\x01 \x01(\x05R\asockets\x12(\n" +
+	"\x10cores_per_socket\x18\x02 \x01(\x05R\x0ecoresPerSocket\x12(\n" +
+	"\x10threads_per_core\x18\x03 \x01(\x05R\x0ethreadsPerCore\"\xf7\x02\n" +
+	"\x03CPU\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x14\n" +
+	"\x05flags\x18\x02 \x01(\x05R\x05flags\x12\x12\n" +
+	"\x04time\x18\x03 \x01(\x01R\x04time\x12\x17\n" +
+	"\x04idle\x18\x04 \x01(\x01H\x00R\x04idle\x88\x01\x01\x12\x14\n" +
+	"\x05load1\x18\x05 \x01(\x01R\x05load1\x12\x14\n" +
+	"\x05load5\x18\x06 \x01(\x01R\x05load5\x12\x16\n" +
+	"\x06load15\x18\a \x01(\x01R\x06load15\x12&\n" +
+	"\fphysical_cpu\x18\b \x01(\x05H\x01R\vphysicalCpu\x88\x01\x01\x12\x1a\n" +
+	"\baffinity\x18\t \x03(\x04R\baffinity\x12\x19\n" +
+	"\x05steal\x18\n" +
+	" \x01(\x01H\x02R\x05steal\x88\x01\x01\x12\x1b\n" +
+	"\x06iowait\x18\v \x01(\x01H\x03R\x06iowait\x88\x01\x01\x12 \n" +
+	"\tnuma_node\x18\f \x01(\x05H\x04R\bnumaNode\x88\x01\x01B\a\n" +
+	"\x05_idleB\x0f\n" +
+	"\r_physical_cpuB\b\n" +
+	"\x06_stealB\t\n" +
+	"\a_iowaitB\f\n" +
+	"\n" +
+	"_numa_node\"g\n" +
+	"\x04NUMA\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\x12\x14\n" +
+	"\x05nodes\x18\x02 \x03(\x04R\x05nodes\x125\n" +
+	"\x05cells\x18\x03 \x03(\v2\x1f.virtmonitor.driver.v1.NUMACellR\x05cells\"p\n" +
+	"\bNUMACell\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04cpus\x18\x02 \x03(\x04R\x04cpus\x12\x16\n" +
+	"\x06memory\x18\x03 \x01(\x04R\x06memory\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\tR\x04mode\x12\x14\n" +
+	"\x05nodes\x18\x05 \x03(\x04R\x05nodes\"X\n" +
+	"\bIOThread\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x17\n" +
+	"\x04time\x18\x02 \x01(\x01H\x00R\x04time\x88\x01\x01\x12\x1a\n" +
+	"\baffinity\x18\x03 \x03(\x04R\baffinityB\a\n" +
+	"\x05_time\"\xd1\x02\n" +
+	"\tCPUTuning\x12\x16\n" +
+	"\x06shares\x18\x01 \x01(\x04R\x06shares\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\x04R\x06weight\x12\x16\n" +
+	"\x06period\x18\x03 \x01(\x04R\x06period\x12\x14\n" +
+	"\x05quota\x18\x04 \x01(\x03R\x05quota\x12#\n" +
+	"\rglobal_period\x18\x05 \x01(\x04R\fglobalPeriod\x12!\n" +
+	"\fglobal_quota\x18\x06 \x01(\x03R\vglobalQuota\x12'\n" +
+	"\x0femulator_period\x18\a \x01(\x04R\x0eemulatorPeriod\x12%\n" +
+	"\x0eemulator_quota\x18\b \x01(\x03R\remulatorQuota\x12'\n" +
+	"\x0fiothread_period\x18\t \x01(\x04R\x0eiothreadPeriod\x12%\n" +
+	"\x0eiothread_quota\x18\n" +
+	" \x01(\x03R\riothreadQuota\"\xc0\x01\n" +
+	"\aBlockIO\x12\x1e\n" +
+	"\n" +
+	"operations\x18\x01 \x01(\x04R\n" +
+	"operations\x12\x14\n" +
+	"\x05bytes\x18\x02 \x01(\x04R\x05bytes\x12\x18\n" +
+	"\asectors\x18\x03 \x01(\x04R\asectors\x12\x1a\n" +
+	"\babsolute\x18\x04 \x01(\bR\babsolute\x12\"\n" +
+	"\n" +
+	"total_time\x18\x05 \x01(\x04H\x00R\ttotalTime\x88\x01\x01\x12\x16\n" +
+	"\x06errors\x18\x06 \x01(\x04R\x06errorsB\r\n" +
+	"\v_total_time\"\xf6\x01\n" +
+	"\vBlockLimits\x12\x1b\n" +
+	"\tread_iops\x18\x01 \x01(\x04R\breadIops\x12\x1d\n" +
+	"\n" +
+	"write_iops\x18\x02 \x01(\x04R\twriteIops\x12\x1d\n" +
+	"\n" +
+	"total_iops\x18\x03 \x01(\x04R\ttotalIops\x12$\n" +
+	"\x0eread_bytes_sec\x18\x04 \x01(\x04R\freadBytesSec\x12&\n" +
+	"\x0fwrite_bytes_sec\x18\x05 \x01(\x04R\rwriteBytesSec\x12&\n" +
+	"\x0ftotal_bytes_sec\x18\x06 \x01(\x04R\rtotalBytesSec\x12\x16\n" +
+	"\x06weight\x18\a \x01(\x04R\x06weight\"\xc5\x04\n" +
+	"\vBlockDevice\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tread_only\x18\x02 \x01(\bR\breadOnly\x12\x17\n" +
+	"\ais_disk\x18\x03 \x01(\bR\x06isDisk\x12\x19\n" +
+	"\bis_cdrom\x18\x04 \x01(\bR\aisCdrom\x122\n" +
+	"\x04read\x18\x05 \x01(\v2\x1e.virtmonitor.driver.v1.BlockIOR\x04read\x124\n" +
+	"\x05write\x18\x06 \x01(\v2\x1e.virtmonitor.driver.v1.BlockIOR\x05write\x124\n" +
+	"\x05flush\x18\a \x01(\v2\x1e.virtmonitor.driver.v1.BlockIOR\x05flush\x12\x10\n" +
+	"\x03bus\x18\b \x01(\tR\x03bus\x12\x16\n" +
+	"\x06target\x18\t \x01(\tR\x06target\x12\x16\n" +
+	"\x06source\x18\n" +
+	" \x01(\tR\x06source\x12\x1a\n" +
+	"\bcapacity\x18\v \x01(\x04R\bcapacity\x12\x1e\n" +
+	"\n" +
+	"allocation\x18\f \x01(\x04R\n" +
+	"allocation\x12\x1a\n" +
+	"\bphysical\x18\r \x01(\x04R\bphysical\x12:\n" +
+	"\x06limits\x18\x0e \x01(\v2\".virtmonitor.driver.v1.BlockLimitsR\x06limits\x12\x18\n" +
+	"\astalled\x18\x0f \x01(\bR\astalled\x12#\n" +
+	"\rbacking_chain\x18\x10 \x03(\tR\fbackingChain\x12\x1c\n" +
+	"\tthrottled\x18\x11 \x01(\bR\tthrottled\"p\n" +
+	"\n" +
+	"HostDevice\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\"\xcd\x01\n" +
+	"\tNetworkIO\x12\x14\n" +
+	"\x05bytes\x18\x01 \x01(\x04R\x05bytes\x12\x18\n" +
+	"\apackets\x18\x02 \x01(\x04R\apackets\x12\x16\n" +
+	"\x06errors\x18\x03 \x01(\x04R\x06errors\x12\x14\n" +
+	"\x05drops\x18\x04 \x01(\x04R\x05drops\x12\x12\n" +
+	"\x04fifo\x18\x05 \x01(\x04R\x04fifo\x12\x14\n" +
+	"\x05frame\x18\x06 \x01(\x04R\x05frame\x12\x1e\n" +
+	"\n" +
+	"collisions\x18\a \x01(\x04R\n" +
+	"collisions\x12\x18\n" +
+	"\acarrier\x18\b \x01(\x04R\acarrier\"+\n" +
+	"\x05IPNet\x12\x0e\n" +
+	"\x02ip\x18\x01 \x01(\fR\x02ip\x12\x12\n" +
+	"\x04mask\x18\x02 \x01(\fR\x04mask\"\x89\x03\n" +
+	"\x10NetworkInterface\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03mac\x18\x02 \x01(\fR\x03mac\x12\x18\n" +
+	"\abridges\x18\x03 \x03(\tR\abridges\x120\n" +
+	"\x02rx\x18\x04 \x01(\v2 .virtmonitor.driver.v1.NetworkIOR\x02rx\x120\n" +
+	"\x02tx\x18\x05 \x01(\v2 .virtmonitor.driver.v1.NetworkIOR\x02tx\x12:\n" +
+	"\taddresses\x18\x06 \x03(\v2\x1c.virtmonitor.driver.v1.IPNetR\taddresses\x12\x1c\n" +
+	"\alink_up\x18\a \x01(\bH\x00R\x06linkUp\x88\x01\x01\x12#\n" +
+	"\rinbound_limit\x18\b \x01(\x04R\finboundLimit\x12%\n" +
+	"\x0eoutbound_limit\x18\t \x01(\x04R\routboundLimit\x12\x1f\n" +
+	"\vhost_device\x18\n" +
+	" \x01(\tR\n" +
+	"hostDeviceB\n" +
+	"\n" +
+	"\b_link_up\"\xa4\x03\n" +
+	"\x06Memory\x12\x1b\n" +
+	"\x06actual\x18\x01 \x01(\x04H\x00R\x06actual\x88\x01\x01\x12!\n" +
+	"\tavailable\x18\x02 \x01(\x04H\x01R\tavailable\x88\x01\x01\x12\x1b\n" +
+	"\x06unused\x18\x03 \x01(\x04H\x02R\x06unused\x88\x01\x01\x12\x15\n" +
+	"\x03rss\x18\x04 \x01(\x04H\x03R\x03rss\x88\x01\x01\x12\x1c\n" +
+	"\aswap_in\x18\x05 \x01(\x04H\x04R\x06swapIn\x88\x01\x01\x12\x1e\n" +
+	"\bswap_out\x18\x06 \x01(\x04H\x05R\aswapOut\x88\x01\x01\x12&\n" +
+	"\fmajor_faults\x18\a \x01(\x04H\x06R\vmajorFaults\x88\x01\x01\x12&\n" +
+	"\fminor_faults\x18\b \x01(\x04H\aR\vminorFaults\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"dirty_rate\x18\t \x01(\x04H\bR\tdirtyRate\x88\x01\x01B\t\n" +
+	"\a_actualB\f\n" +
+	"\n" +
+	"_availableB\t\n" +
+	"\a_unusedB\x06\n" +
+	"\x04_rssB\n" +
+	"\n" +
+	"\b_swap_inB\v\n" +
+	"\t_swap_outB\x0f\n" +
+	"\r_major_faultsB\x0f\n" +
+	"\r_minor_faultsB\r\n" +
+	"\v_dirty_rate\"\xa4\x01\n" +
+	"\rMemoryBacking\x12'\n" +
+	"\x0fballoon_current\x18\x01 \x01(\x04R\x0eballoonCurrent\x12'\n" +
+	"\x0fballoon_maximum\x18\x02 \x01(\x04R\x0eballoonMaximum\x12\x1c\n" +
+	"\thugepages\x18\x03 \x01(\bR\thugepages\x12#\n" +
+	"\rhugepage_size\x18\x04 \x01(\x04R\fhugepageSize\"\x94\x01\n" +
+	"\n" +
+	"Filesystem\x12\x1e\n" +
+	"\n" +
+	"mountpoint\x18\x01 \x01(\tR\n" +
+	"mountpoint\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x1f\n" +
+	"\vtotal_bytes\x18\x04 \x01(\x04R\n" +
+	"totalBytes\x12\x1d\n" +
+	"\n" +
+	"used_bytes\x18\x05 \x01(\x04R\tusedBytes\"\x87\x01\n" +
+	"\x0eGraphicsDevice\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
+	"\x06listen\x18\x02 \x01(\fR\x06listen\x12\x12\n" +
+	"\x04port\x18\x03 \x01(\x05R\x04port\x12\x19\n" +
+	"\btls_port\x18\x04 \x01(\x05R\atlsPort\x12\x1a\n" +
+	"\bpassword\x18\x05 \x01(\bR\bpassword\"\x90\x01\n" +
+	"\bSnapshot\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
+	"\rcreation_time\x18\x02 \x01(\x03R\fcreationTime\x12\x14\n" +
+	"\x05state\x18\x03 \x01(\tR\x05state\x12\x16\n" +
+	"\x06parent\x18\x04 \x01(\tR\x06parent\x12\x1d\n" +
+	"\n" +
+	"is_current\x18\x05 \x01(\bR\tisCurrent\"\xc2\x01\n" +
+	"\bHostInfo\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x12\n" +
+	"\x04cpus\x18\x02 \x01(\x05R\x04cpus\x12!\n" +
+	"\fmemory_total\x18\x03 \x01(\x04R\vmemoryTotal\x12\x1f\n" +
+	"\vmemory_free\x18\x04 \x01(\x04R\n" +
+	"memoryFree\x12\x14\n" +
+	"\x05load1\x18\x05 \x01(\x01R\x05load1\x12\x14\n" +
+	"\x05load5\x18\x06 \x01(\x01R\x05load5\x12\x16\n" +
+	"\x06load15\x18\a \x01(\x01R\x06load15\"\x8f\x01\n" +
+	"\vDomainEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\tR\x04uuid\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1e\n" +
+	"\n" +
+	"hypervisor\x18\x04 \x01(\tR\n" +
+	"hypervisor\x12\x14\n" +
+	"\x05flags\x18\x05 \x01(\x05R\x05flags\x12\x12\n" +
+	"\x04time\x18\x06 \x01(\x03R\x04time2\xf7\x04\n" +
+	"\x06Driver\x12O\n" +
+	"\x04Info\x12\".virtmonitor.driver.v1.InfoRequest\x1a#.virtmonitor.driver.v1.InfoResponse\x12X\n" +
+	"\aCollect\x12%.virtmonitor.driver.v1.CollectRequest\x1a&.virtmonitor.driver.v1.CollectResponse\x12[\n" +
+	"\rCollectDomain\x12+.virtmonitor.driver.v1.CollectDomainRequest\x1a\x1d.virtmonitor.driver.v1.Domain\x12s\n" +
+	"\x10CollectSnapshots\x12..virtmonitor.driver.v1.CollectSnapshotsRequest\x1a/.virtmonitor.driver.v1.CollectSnapshotsResponse\x12K\n" +
+	"\x04Host\x12\".virtmonitor.driver.v1.HostRequest\x1a\x1f.virtmonitor.driver.v1.HostInfo\x12O\n" +
+	"\x04Ping\x12\".virtmonitor.driver.v1.PingRequest\x1a#.virtmonitor.driver.v1.PingResponse\x12R\n" +
+	"\x05Watch\x12#.virtmonitor.driver.v1.WatchRequest\x1a\".virtmonitor.driver.v1.DomainEvent0\x01B-Z+github.com/virtmonitor/driver/grpc/driverpbb\x06proto3"
+
+var (
+	file_driver_proto_rawDescOnce sync.Once
+	file_driver_proto_rawDescData []byte
+)
+
+func file_driver_proto_rawDescGZIP() []byte {
+	file_driver_proto_rawDescOnce.Do(func() {
+		file_driver_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_driver_proto_rawDesc), len(file_driver_proto_rawDesc)))
+	})
+	return file_driver_proto_rawDescData
+}
+
+var file_driver_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
+var file_driver_proto_goTypes = []any{
+	(*InfoRequest)(nil),              // 0: virtmonitor.driver.v1.InfoRequest
+	(*InfoResponse)(nil),             // 1: virtmonitor.driver.v1.InfoResponse
+	(*CollectOptions)(nil),           // 2: virtmonitor.driver.v1.CollectOptions
+	(*CollectRequest)(nil),           // 3: virtmonitor.driver.v1.CollectRequest
+	(*CollectResponse)(nil),          // 4: virtmonitor.driver.v1.CollectResponse
+	(*DomainError)(nil),              // 5: virtmonitor.driver.v1.DomainError
+	(*CollectDomainRequest)(nil),     // 6: virtmonitor.driver.v1.CollectDomainRequest
+	(*CollectSnapshotsRequest)(nil),  // 7: virtmonitor.driver.v1.CollectSnapshotsRequest
+	(*CollectSnapshotsResponse)(nil), // 8: virtmonitor.driver.v1.CollectSnapshotsResponse
+	(*HostRequest)(nil),              // 9: virtmonitor.driver.v1.HostRequest
+	(*PingRequest)(nil),              // 10: virtmonitor.driver.v1.PingRequest
+	(*PingResponse)(nil),             // 11: virtmonitor.driver.v1.PingResponse
+	(*WatchRequest)(nil),             // 12: virtmonitor.driver.v1.WatchRequest
+	(*Capabilities)(nil),             // 13: virtmonitor.driver.v1.Capabilities
+	(*Domain)(nil),                   // 14: virtmonitor.driver.v1.Domain
+	(*CPUTopology)(nil),              // 15: virtmonitor.driver.v1.CPUTopology
+	(*CPU)(nil),                      // 16: virtmonitor.driver.v1.CPU
+	(*NUMA)(nil),                     // 17: virtmonitor.driver.v1.NUMA
+	(*NUMACell)(nil),                 // 18: virtmonitor.driver.v1.NUMACell
+	(*IOThread)(nil),                 // 19: virtmonitor.driver.v1.IOThread
+	(*CPUTuning)(nil),                // 20: virtmonitor.driver.v1.CPUTuning
+	(*BlockIO)(nil),                  // 21: virtmonitor.driver.v1.BlockIO
+	(*BlockLimits)(nil),              // 22: virtmonitor.driver.v1.BlockLimits
+	(*BlockDevice)(nil),              // 23: virtmonitor.driver.v1.BlockDevice
+	(*HostDevice)(nil),               // 24: virtmonitor.driver.v1.HostDevice
+	(*NetworkIO)(nil),                // 25: virtmonitor.driver.v1.NetworkIO
+	(*IPNet)(nil),                    // 26: virtmonitor.driver.v1.IPNet
+	(*NetworkInterface)(nil),         // 27: virtmonitor.driver.v1.NetworkInterface
+	(*Memory)(nil),                   // 28: virtmonitor.driver.v1.Memory
+	(*MemoryBacking)(nil),            // 29: virtmonitor.driver.v1.MemoryBacking
+	(*Filesystem)(nil),               // 30: virtmonitor.driver.v1.Filesystem
+	(*GraphicsDevice)(nil),           // 31: virtmonitor.driver.v1.GraphicsDevice
+	(*Snapshot)(nil),                 // 32: virtmonitor.driver.v1.Snapshot
+	(*HostInfo)(nil),                 // 33: virtmonitor.driver.v1.HostInfo
+	(*DomainEvent)(nil),              // 34: virtmonitor.driver.v1.DomainEvent
+	nil,                              // 35: virtmonitor.driver.v1.Domain.LabelsEntry
+}
+var file_driver_proto_depIdxs = []int32{
+	13, // 0: virtmonitor.driver.v1.InfoResponse.capabilities:type_name -> virtmonitor.driver.v1.Capabilities
+	2,  // 1: virtmonitor.driver.v1.CollectRequest.options:type_name -> virtmonitor.driver.v1.CollectOptions
+	14, // 2: virtmonitor.driver.v1.CollectResponse.domains:type_name -> virtmonitor.driver.v1.Domain
+	5,  // 3: virtmonitor.driver.v1.CollectResponse.domain_errors:type_name -> virtmonitor.driver.v1.DomainError
+	2,  // 4: virtmonitor.driver.v1.CollectDomainRequest.options:type_name -> virtmonitor.driver.v1.CollectOptions
+	32, // 5: virtmonitor.driver.v1.CollectSnapshotsResponse.snapshots:type_name -> virtmonitor.driver.v1.Snapshot
+	16, // 6: virtmonitor.driver.v1.Domain.cpus:type_name -> virtmonitor.driver.v1.CPU
+	23, // 7: virtmonitor.driver.v1.Domain.blocks:type_name -> virtmonitor.driver.v1.BlockDevice
+	27, // 8: virtmonitor.driver.v1.Domain.interfaces:type_name -> virtmonitor.driver.v1.NetworkInterface
+	28, // 9: virtmonitor.driver.v1.Domain.memory:type_name -> virtmonitor.driver.v1.Memory
+	30, // 10: virtmonitor.driver.v1.Domain.filesystems:type_name -> virtmonitor.driver.v1.Filesystem
+	35, // 11: virtmonitor.driver.v1.Domain.labels:type_name -> virtmonitor.driver.v1.Domain.LabelsEntry
+	31, // 12: virtmonitor.driver.v1.Domain.graphics:type_name -> virtmonitor.driver.v1.GraphicsDevice
+	29, // 13: virtmonitor.driver.v1.Domain.memory_backing:type_name -> virtmonitor.driver.v1.MemoryBacking
+	24, // 14: virtmonitor.driver.v1.Domain.host_devices:type_name -> virtmonitor.driver.v1.HostDevice
+	20, // 15: virtmonitor.driver.v1.Domain.cpu_tuning:type_name -> virtmonitor.driver.v1.CPUTuning
+	19, // 16: virtmonitor.driver.v1.Domain.iothreads:type_name -> virtmonitor.driver.v1.IOThread
+	17, // 17: virtmonitor.driver.v1.Domain.numa:type_name -> virtmonitor.driver.v1.NUMA
+	15, // 18: virtmonitor.driver.v1.Domain.topology:type_name -> virtmonitor.driver.v1.CPUTopology
+	18, // 19: virtmonitor.driver.v1.NUMA.cells:type_name -> virtmonitor.driver.v1.NUMACell
+	21, // 20: virtmonitor.driver.v1.BlockDevice.read:type_name -> virtmonitor.driver.v1.BlockIO
+	21, // 21: virtmonitor.driver.v1.BlockDevice.write:type_name -> virtmonitor.driver.v1.BlockIO
+	21, // 22: virtmonitor.driver.v1.BlockDevice.flush:type_name -> virtmonitor.driver.v1.BlockIO
+	22, // 23: virtmonitor.driver.v1.BlockDevice.limits:type_name -> virtmonitor.driver.v1.BlockLimits
+	25, // 24: virtmonitor.driver.v1.NetworkInterface.rx:type_name -> virtmonitor.driver.v1.NetworkIO
+	25, // 25: virtmonitor.driver.v1.NetworkInterface.tx:type_name -> virtmonitor.driver.v1.NetworkIO
+	26, // 26: virtmonitor.driver.v1.NetworkInterface.addresses:type_name -> virtmonitor.driver.v1.IPNet
+	0,  // 27: virtmonitor.driver.v1.Driver.Info:input_type -> virtmonitor.driver.v1.InfoRequest
+	3,  // 28: virtmonitor.driver.v1.Driver.Collect:input_type -> virtmonitor.driver.v1.CollectRequest
+	6,  // 29: virtmonitor.driver.v1.Driver.CollectDomain:input_type -> virtmonitor.driver.v1.CollectDomainRequest
+	7,  // 30: virtmonitor.driver.v1.Driver.CollectSnapshots:input_type -> virtmonitor.driver.v1.CollectSnapshotsRequest
+	9,  // 31: virtmonitor.driver.v1.Driver.Host:input_type -> virtmonitor.driver.v1.HostRequest
+	10, // 32: virtmonitor.driver.v1.Driver.Ping:input_type -> virtmonitor.driver.v1.PingRequest
+	12, // 33: virtmonitor.driver.v1.Driver.Watch:input_type -> virtmonitor.driver.v1.WatchRequest
+	1,  // 34: virtmonitor.driver.v1.Driver.Info:output_type -> virtmonitor.driver.v1.InfoResponse
+	4,  // 35: virtmonitor.driver.v1.Driver.Collect:output_type -> virtmonitor.driver.v1.CollectResponse
+	14, // 36: virtmonitor.driver.v1.Driver.CollectDomain:output_type -> virtmonitor.driver.v1.Domain
+	8,  // 37: virtmonitor.driver.v1.Driver.CollectSnapshots:output_type -> virtmonitor.driver.v1.CollectSnapshotsResponse
+	33, // 38: virtmonitor.driver.v1.Driver.Host:output_type -> virtmonitor.driver.v1.HostInfo
+	11, // 39: virtmonitor.driver.v1.Driver.Ping:output_type -> virtmonitor.driver.v1.PingResponse
+	34, // 40: virtmonitor.driver.v1.Driver.Watch:output_type -> virtmonitor.driver.v1.DomainEvent
+	34, // [34:41] is the sub-list for method output_type
+	27, // [27:34] is the sub-list for method input_type
+	27, // [27:27] is the sub-list for extension type_name
+	27, // [27:27] is the sub-list for extension extendee
+	0,  // [0:27] is the sub-list for field type_name
+}
+
+func init() { file_driver_proto_init() }
+func file_driver_proto_init() {
+	if File_driver_proto != nil {
+		return
+	}
+	file_driver_proto_msgTypes[6].OneofWrappers = []any{
+		(*CollectDomainRequest_Id)(nil),
+		(*CollectDomainRequest_Uuid)(nil),
+		(*CollectDomainRequest_Name)(nil),
+	}
+	file_driver_proto_msgTypes[14].OneofWrappers = []any{}
+	file_driver_proto_msgTypes[16].OneofWrappers = []any{}
+	file_driver_proto_msgTypes[19].OneofWrappers = []any{}
+	file_driver_proto_msgTypes[21].OneofWrappers = []any{}
+	file_driver_proto_msgTypes[27].OneofWrappers = []any{}
+	file_driver_proto_msgTypes[28].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_driver_proto_rawDesc), len(file_driver_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   36,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_driver_proto_goTypes,
+		DependencyIndexes: file_driver_proto_depIdxs,
+		MessageInfos:      file_driver_proto_msgTypes,
+	}.Build()
+	File_driver_proto = out.File
+	file_driver_proto_goTypes = nil
+	file_driver_proto_depIdxs = nil
+}
@@ -0,0 +1,379 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v6.33.0
+// source: driver.proto
+
+// Wire form of the driver package types, mirroring them field for field.
+// Fields paired with a *Set flag in Go are optional here, presence standing
+// for the flag.
+
+package driverpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Driver_Info_FullMethodName             = "/virtmonitor.driver.v1.Driver/Info"
+	Driver_Collect_FullMethodName          = "/virtmonitor.driver.v1.Driver/Collect"
+	Driver_CollectDomain_FullMethodName    = "/virtmonitor.driver.v1.Driver/CollectDomain"
+	Driver_CollectSnapshots_FullMethodName = "/virtmonitor.driver.v1.Driver/CollectSnapshots"
+	Driver_Host_FullMethodName             = "/virtmonitor.driver.v1.Driver/Host"
+	Driver_Ping_FullMethodName             = "/virtmonitor.driver.v1.Driver/Ping"
+	Driver_Watch_FullMethodName            = "/virtmonitor.driver.v1.Driver/Watch"
+)
+
+// DriverClient is the client API for Driver service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Driver A driver.Driver served over the network
+type DriverClient interface {
+	// Info Name, capabilities and detection of the served driver
+	Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
+	// Collect Collect every domain, per domain errors are returned alongside
+	// the domains that did collect
+	Collect(ctx context.Context, in *CollectRequest, opts ...grpc.CallOption) (*CollectResponse, error)
+	// CollectDomain Collect a single domain by ID, UUID or name
+	CollectDomain(ctx context.Context, in *CollectDomainRequest, opts ...grpc.CallOption) (*Domain, error)
+	// CollectSnapshots Snapshots of a domain
+	CollectSnapshots(ctx context.Context, in *CollectSnapshotsRequest, opts ...grpc.CallOption) (*CollectSnapshotsResponse, error)
+	// Host Metrics of the physical host
+	Host(ctx context.Context, in *HostRequest, opts ...grpc.CallOption) (*HostInfo, error)
+	// Ping Round trip to the served driver's hypervisor
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	// Watch Domain lifecycle changes until the call is cancelled or the
+	// served driver's channel closes
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DomainEvent], error)
+}
+
+type driverClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDriverClient(cc grpc.ClientConnInterface) DriverClient {
+	return &driverClient{cc}
+}
+
+func (c *driverClient) Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, Driver_Info_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverClient) Collect(ctx context.Context, in *CollectRequest, opts ...grpc.CallOption) (*CollectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectResponse)
+	err := c.cc.Invoke(ctx, Driver_Collect_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverClient) CollectDomain(ctx context.Context, in *CollectDomainRequest, opts ...grpc.CallOption) (*Domain, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Domain)
+	err := c.cc.Invoke(ctx, Driver_CollectDomain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverClient) CollectSnapshots(ctx context.Context, in *CollectSnapshotsRequest, opts ...grpc.CallOption) (*CollectSnapshotsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CollectSnapshotsResponse)
+	err := c.cc.Invoke(ctx, Driver_CollectSnapshots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverClient) Host(ctx context.Context, in *HostRequest, opts ...grpc.CallOption) (*HostInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HostInfo)
+	err := c.cc.Invoke(ctx, Driver_Host_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, Driver_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *driverClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DomainEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Driver_ServiceDesc.Streams[0], Driver_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, DomainEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Driver_WatchClient = grpc.ServerStreamingClient[DomainEvent]
+
+// DriverServer is the server API for Driver service.
+// All implementations must embed UnimplementedDriverServer
+// for forward compatibility.
+//
+// Driver A driver.Driver served over the network
+type DriverServer interface {
+	// Info Name, capabilities and detection of the served driver
+	Info(context.Context, *InfoRequest) (*InfoResponse, error)
+	// Collect Collect every domain, per domain errors are returned alongside
+	// the domains that did collect
+	Collect(context.Context, *CollectRequest) (*CollectResponse, error)
+	// CollectDomain Collect a single domain by ID, UUID or name
+	CollectDomain(context.Context, *CollectDomainRequest) (*Domain, error)
+	// CollectSnapshots Snapshots of a domain
+	CollectSnapshots(context.Context, *CollectSnapshotsRequest) (*CollectSnapshotsResponse, error)
+	// Host Metrics of the physical host
+	Host(context.Context, *HostRequest) (*HostInfo, error)
+	// Ping Round trip to the served driver's hypervisor
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	// Watch Domain lifecycle changes until the call is cancelled or the
+	// served driver's channel closes
+	Watch(*WatchRequest, grpc.ServerStreamingServer[DomainEvent]) error
+	mustEmbedUnimplementedDriverServer()
+}
+
+// UnimplementedDriverServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDriverServer struct{}
+
+func (UnimplementedDriverServer) Info(context.Context, *InfoRequest) (*InfoResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Info not implemented")
+}
+func (UnimplementedDriverServer) Collect(context.Context, *CollectRequest) (*CollectResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Collect not implemented")
+}
+func (UnimplementedDriverServer) CollectDomain(context.Context, *CollectDomainRequest) (*Domain, error) {
+	return nil, status.Error(codes.Unimplemented, "method CollectDomain not implemented")
+}
+func (UnimplementedDriverServer) CollectSnapshots(context.Context, *CollectSnapshotsRequest) (*CollectSnapshotsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CollectSnapshots not implemented")
+}
+func (UnimplementedDriverServer) Host(context.Context, *HostRequest) (*HostInfo, error) {
+	return nil, status.Error(codes.Unimplemented, "method Host not implemented")
+}
+func (UnimplementedDriverServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedDriverServer) Watch(*WatchRequest, grpc.ServerStreamingServer[DomainEvent]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedDriverServer) mustEmbedUnimplementedDriverServer() {}
+func (UnimplementedDriverServer) testEmbeddedByValue()                {}
+
+// UnsafeDriverServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DriverServer will
+// result in compilation errors.
+type UnsafeDriverServer interface {
+	mustEmbedUnimplementedDriverServer()
+}
+
+func RegisterDriverServer(s grpc.ServiceRegistrar, srv DriverServer) {
+	// If the following call panics, it indicates UnimplementedDriverServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Driver_ServiceDesc, srv)
+}
+
+func _Driver_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Driver_Info_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServer).Info(ctx, req.(*InfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Driver_Collect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServer).Collect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Driver_Collect_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServer).Collect(ctx, req.(*CollectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Driver_CollectDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectDomainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServer).CollectDomain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Driver_CollectDomain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServer).CollectDomain(ctx, req.(*CollectDomainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Driver_CollectSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectSnapshotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServer).CollectSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Driver_CollectSnapshots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServer).CollectSnapshots(ctx, req.(*CollectSnapshotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Driver_Host_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServer).Host(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Driver_Host_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServer).Host(ctx, req.(*HostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Driver_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DriverServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Driver_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DriverServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Driver_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DriverServer).Watch(m, &grpc.GenericServerStream[WatchRequest, DomainEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Driver_WatchServer = grpc.ServerStreamingServer[DomainEvent]
+
+// Driver_ServiceDesc is the grpc.ServiceDesc for Driver service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Driver_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "virtmonitor.driver.v1.Driver",
+	HandlerType: (*DriverServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Info",
+			Handler:    _Driver_Info_Handler,
+		},
+		{
+			MethodName: "Collect",
+			Handler:    _Driver_Collect_Handler,
+		},
+		{
+			MethodName: "CollectDomain",
+			Handler:    _Driver_CollectDomain_Handler,
+		},
+		{
+			MethodName: "CollectSnapshots",
+			Handler:    _Driver_CollectSnapshots_Handler,
+		},
+		{
+			MethodName: "Host",
+			Handler:    _Driver_Host_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _Driver_Ping_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Driver_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "driver.proto",
+}
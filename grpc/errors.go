@@ -0,0 +1,98 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/virtmonitor/driver"
+)
+
+// sentinels Errors of the driver package restored by the client, in the
+// order their codes are tried
+var sentinels = []struct {
+	err  error
+	code codes.Code
+}{
+	{driver.ErrDomainNotFound, codes.NotFound},
+	{driver.ErrPermissionDenied, codes.PermissionDenied},
+	{driver.ErrHypervisorUnavailable, codes.Unavailable},
+	{driver.ErrInvalidUUID, codes.InvalidArgument},
+	{driver.ErrInvalidDomainID, codes.InvalidArgument},
+	{driver.ErrInvalidPattern, codes.InvalidArgument},
+	{driver.ErrNotSupported, codes.Unimplemented},
+}
+
+// remoteErr Error of the served driver, keeping its message and the
+// sentinel it wrapped
+type remoteErr struct {
+	msg      string
+	sentinel error
+}
+
+func (e *remoteErr) Error() string { return e.msg }
+
+func (e *remoteErr) Unwrap() error { return e.sentinel }
+
+// toStatus gRPC status of a driver error, its code derived from the
+// sentinel it wraps
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := codes.Unknown
+	switch {
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	default:
+		for _, s := range sentinels {
+			if errors.Is(err, s.err) {
+				code = s.code
+				break
+			}
+		}
+	}
+	return status.Error(code, err.Error())
+}
+
+// fromStatus Driver error behind a gRPC status, wrapping the sentinel named
+// in its message. Failures of the connection itself wrap
+// ErrHypervisorUnavailable.
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return remoteError(st.Message(), st.Code())
+}
+
+// remoteError Error of the served driver from its message, code is
+// codes.Unknown for per domain errors
+func remoteError(msg string, code codes.Code) error {
+	for _, s := range sentinels {
+		if strings.Contains(msg, s.err.Error()) {
+			return &remoteErr{msg: msg, sentinel: s.err}
+		}
+	}
+	switch code {
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	}
+	for _, s := range sentinels {
+		if s.code == code && code != codes.InvalidArgument {
+			return fmt.Errorf("grpc: %w: %s", s.err, msg)
+		}
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,157 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/grpc/driverpb"
+	"github.com/virtmonitor/driver/mock"
+)
+
+// recorder Mock driver keeping the options of its collections
+type recorder struct {
+	*mock.Mock
+	opts chan driver.CollectOptions
+}
+
+func (r *recorder) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	r.opts <- opts
+	return r.Mock.CollectContext(ctx, opts)
+}
+
+// serve Client of d served over an in-memory connection
+func serve(t *testing.T, d driver.Driver) *Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	Register(s, d)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c, err := NewClient(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// allOptions Options with every field but Filter set to a value other than
+// its zero one, fields added later included
+func allOptions() driver.CollectOptions {
+	var opts driver.CollectOptions
+	v := reflect.ValueOf(&opts).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		switch name := v.Type().Field(i).Name; {
+		case f.Kind() == reflect.Bool:
+			f.SetBool(true)
+		case f.Type() == reflect.TypeOf(time.Duration(0)):
+			f.SetInt(int64(i+1) * int64(time.Millisecond))
+		case f.Kind() == reflect.Int:
+			f.SetInt(int64(i + 1))
+		case name == "SkipStates":
+			f.Set(reflect.ValueOf([]driver.DomainFlag{driver.DomainPaused, driver.DomainMigrating}))
+		case name == "Include":
+			f.Set(reflect.ValueOf([]string{"web-*"}))
+		case name == "Exclude":
+			f.Set(reflect.ValueOf([]string{"web-test"}))
+		case name == "Filter":
+		default:
+			panic("allOptions: no value for CollectOptions." + name)
+		}
+	}
+	return opts
+}
+
+func TestCollectOptions(t *testing.T) {
+	r := &recorder{Mock: mock.New(), opts: make(chan driver.CollectOptions, 1)}
+	c := serve(t, r)
+
+	for _, opts := range []driver.CollectOptions{{}, allOptions(), {IncludeInactive: true}} {
+		if _, err := c.CollectContext(context.Background(), opts); err != nil {
+			t.Fatal(err)
+		}
+		if got := <-r.opts; !reflect.DeepEqual(got, opts) {
+			t.Errorf("options: sent %+v, served driver got %+v", opts, got)
+		}
+	}
+}
+
+func TestCollectDomainErrors(t *testing.T) {
+	failed := &driver.DomainError{ID: 2, Name: "broken", Err: driver.ErrPermissionDenied}
+	m := mock.New().Queue(
+		mock.Result{Domains: []*driver.Domain{{ID: 1, Name: "vm"}}, Err: errors.Join(failed, &driver.DomainError{ID: 3, Err: driver.ErrDomainNotFound})},
+		mock.Result{Err: failed},
+	)
+	c := serve(t, m)
+
+	domains, err := c.CollectContext(context.Background(), driver.CollectOptions{})
+	if len(domains) != 1 || domains[1] == nil {
+		t.Fatalf("partial collection: got %v, %v", domains, err)
+	}
+	errs := driver.DomainErrors(err)
+	if len(errs) != 2 || !errors.Is(errs[2], driver.ErrPermissionDenied) || !errors.Is(errs[3], driver.ErrDomainNotFound) {
+		t.Errorf("partial collection: DomainErrors %v", errs)
+	}
+	var de *driver.DomainError
+	if !errors.As(errs[2], &de) || de.Name != "broken" || de.Error() != failed.Error() {
+		t.Errorf("DomainError: got %v, want %v", errs[2], failed)
+	}
+	if errors.Is(err, driver.ErrHypervisorUnavailable) {
+		t.Errorf("partial collection: unexpected ErrHypervisorUnavailable in %v", err)
+	}
+
+	// Every domain failing is still a partial result
+	domains, err = c.CollectContext(context.Background(), driver.CollectOptions{})
+	if len(domains) != 0 || len(driver.DomainErrors(err)) != 1 {
+		t.Errorf("no domain collected: got %v, %v", domains, err)
+	}
+}
+
+func TestCollectUnavailable(t *testing.T) {
+	m := mock.New().WithCollectError(driver.ErrHypervisorUnavailable)
+	c := serve(t, m)
+
+	domains, err := c.CollectContext(context.Background(), driver.CollectOptions{})
+	if domains != nil || !errors.Is(err, driver.ErrHypervisorUnavailable) || driver.DomainErrors(err) != nil {
+		t.Errorf("collection failure: got %v, %v", domains, err)
+	}
+	if !driver.Transient(err) {
+		t.Errorf("collection failure: %v isn't transient", err)
+	}
+}
+
+func TestServerUnreachable(t *testing.T) {
+	c := serve(t, mock.New())
+	lis := bufconn.Listen(1 << 20)
+	lis.Close()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c.c = driverpb.NewDriverClient(conn)
+
+	if _, err := c.CollectContext(context.Background(), driver.CollectOptions{}); !errors.Is(err, driver.ErrHypervisorUnavailable) {
+		t.Errorf("server unreachable: got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/grpc/driverpb"
+)
+
+// Server gRPC service serving a driver
+type Server struct {
+	driverpb.UnimplementedDriverServer
+	d driver.Driver
+}
+
+// NewServer Create a service serving d. Closing d is left to the caller.
+func NewServer(d driver.Driver) *Server {
+	return &Server{d: d}
+}
+
+// Register Serve d on s
+func Register(s grpc.ServiceRegistrar, d driver.Driver) {
+	driverpb.RegisterDriverServer(s, NewServer(d))
+}
+
+// Info Name, capabilities and detection of the driver
+func (s *Server) Info(ctx context.Context, req *driverpb.InfoRequest) (*driverpb.InfoResponse, error) {
+	r := driver.Diagnose(s.d)
+	return &driverpb.InfoResponse{
+		Name:         string(s.d.Name()),
+		Capabilities: toCapabilities(s.d.Capabilities()),
+		Detected:     r.Detected,
+		Reason:       r.Reason,
+	}, nil
+}
+
+// Collect Collect every domain. A collection failing as a whole is an
+// error, per domain errors are listed in the response even when no domain
+// collected.
+func (s *Server) Collect(ctx context.Context, req *driverpb.CollectRequest) (*driverpb.CollectResponse, error) {
+	domains, err := s.d.CollectContext(ctx, fromOptions(req.GetOptions()))
+	if err != nil && len(domains) == 0 && driver.DomainErrors(err) == nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &driverpb.CollectResponse{Domains: make([]*driverpb.Domain, 0, len(domains))}
+	for _, d := range domains {
+		resp.Domains = append(resp.Domains, toDomain(d))
+	}
+	addErrors(resp, err)
+	return resp, nil
+}
+
+// addErrors List the errors joined in err in resp, DomainErrors keeping
+// their domain
+func addErrors(resp *driverpb.CollectResponse, err error) {
+	var de *driver.DomainError
+	if err == nil {
+		return
+	}
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range j.Unwrap() {
+			addErrors(resp, e)
+		}
+		return
+	}
+	if errors.As(err, &de) {
+		resp.DomainErrors = append(resp.DomainErrors, &driverpb.DomainError{Id: uint64(de.ID), Name: de.Name, Message: de.Err.Error()})
+		return
+	}
+	resp.Errors = append(resp.Errors, err.Error())
+}
+
+// CollectDomain Collect a single domain by ID, UUID or name
+func (s *Server) CollectDomain(ctx context.Context, req *driverpb.CollectDomainRequest) (*driverpb.Domain, error) {
+	opts := fromOptions(req.GetOptions())
+
+	var (
+		d   *driver.Domain
+		err error
+	)
+	switch key := req.GetKey().(type) {
+	case *driverpb.CollectDomainRequest_Id:
+		d, err = s.d.CollectDomain(driver.DomainID(key.Id), opts)
+	case *driverpb.CollectDomainRequest_Uuid:
+		d, err = s.d.CollectDomainByUUID(key.Uuid, opts)
+	case *driverpb.CollectDomainRequest_Name:
+		d, err = s.d.CollectDomainByName(key.Name, opts)
+	default:
+		err = errors.New("grpc: no domain ID, UUID or name")
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toDomain(d), nil
+}
+
+// CollectSnapshots Snapshots of a domain
+func (s *Server) CollectSnapshots(ctx context.Context, req *driverpb.CollectSnapshotsRequest) (*driverpb.CollectSnapshotsResponse, error) {
+	snaps, err := s.d.CollectSnapshots(driver.DomainID(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &driverpb.CollectSnapshotsResponse{}
+	for _, snap := range snaps {
+		resp.Snapshots = append(resp.Snapshots, toSnapshot(snap))
+	}
+	return resp, nil
+}
+
+// Host Metrics of the physical host
+func (s *Server) Host(ctx context.Context, req *driverpb.HostRequest) (*driverpb.HostInfo, error) {
+	h, err := s.d.Host()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toHost(h), nil
+}
+
+// Ping Ping the driver
+func (s *Server) Ping(ctx context.Context, req *driverpb.PingRequest) (*driverpb.PingResponse, error) {
+	if err := s.d.Ping(ctx); err != nil {
+		return nil, toStatus(err)
+	}
+	return &driverpb.PingResponse{}, nil
+}
+
+// Watch Stream the events of the driver until the client goes away or the
+// driver closes its channel
+func (s *Server) Watch(req *driverpb.WatchRequest, stream grpc.ServerStreamingServer[driverpb.DomainEvent]) error {
+	ctx := stream.Context()
+	events, err := s.d.Watch(ctx)
+	if err != nil {
+		return toStatus(err)
+	}
+	// Tell the client the subscription succeeded
+	if err := stream.SendHeader(nil); err != nil {
+		return fmt.Errorf("grpc: watch: %w", err)
+	}
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toEvent(e)); err != nil {
+				return fmt.Errorf("grpc: watch: %w", err)
+			}
+		case <-ctx.Done():
+			return toStatus(ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package driver
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CollectHealth Outcome of the collections of a driver
+type CollectHealth struct {
+	// LastSuccess Time the latest successful collection completed, zero if
+	// none has. Collections where only some domains failed succeed.
+	LastSuccess time.Time `json:"last_success"`
+	// LastFailure Time the latest failed collection completed, zero if none
+	// has
+	LastFailure time.Time `json:"last_failure"`
+	// LastError Error of the latest collection, nil if it had none. Per
+	// domain failures are reported here too, see DomainErrors.
+	LastError error `json:"-"`
+	// ConsecutiveFailures Collections failed since the latest success
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// Collections and Failures Collections run and failed in total
+	Collections uint64 `json:"collections"`
+	Failures    uint64 `json:"failures"`
+}
+
+// HealthReporter Optional interface of drivers recording the outcome of
+// their collections, such as the one returned by WithHealth
+type HealthReporter interface {
+	// LastError Error of the latest collection, nil if it had none or no
+	// collection ran
+	LastError() error
+	// LastCollect Time the latest successful collection completed, zero if
+	// none has
+	LastCollect() time.Time
+	// Health Outcome of the collections so far
+	Health() CollectHealth
+}
+
+// Health Outcome of the collections of d, false if d isn't a HealthReporter
+func Health(d Driver) (CollectHealth, bool) {
+	if h, ok := d.(HealthReporter); ok {
+		return h.Health(), true
+	}
+	return CollectHealth{}, false
+}
+
+// healthDriver Driver recording the outcome of its collections
+type healthDriver struct {
+	Driver
+
+	mu     sync.Mutex
+	health CollectHealth
+}
+
+// WithHealth Driver recording the outcome of every Collect and
+// CollectContext of d, for alerting on hypervisors that can't be scraped.
+// A collection fails when it returns an error other than per domain
+// failures; collections cancelled by their context aren't recorded. The
+// result implements HealthReporter.
+func WithHealth(d Driver) Driver {
+	return &healthDriver{Driver: d}
+}
+
+// Collect Collect domains, recording the outcome
+func (h *healthDriver) Collect(opts CollectOptions) (map[DomainID]*Domain, error) {
+	return h.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect domains, recording the outcome
+func (h *healthDriver) CollectContext(ctx context.Context, opts CollectOptions) (map[DomainID]*Domain, error) {
+	domains, err := h.Driver.CollectContext(ctx, opts)
+	if ctx.Err() != nil {
+		return domains, err
+	}
+
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.health.Collections++
+	h.health.LastError = err
+	if err == nil || onlyDomainErrors(err) {
+		h.health.LastSuccess = now
+		h.health.ConsecutiveFailures = 0
+	} else {
+		h.health.LastFailure = now
+		h.health.ConsecutiveFailures++
+		h.health.Failures++
+	}
+	return domains, err
+}
+
+// LastError Error of the latest collection
+func (h *healthDriver) LastError() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.health.LastError
+}
+
+// LastCollect Time the latest successful collection completed
+func (h *healthDriver) LastCollect() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.health.LastSuccess
+}
+
+// Health Outcome of the collections so far
+func (h *healthDriver) Health() CollectHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.health
+}
+
+// onlyDomainErrors Test if err is made of DomainErrors only, as returned by
+// a collection where some domains failed, possibly wrapped
+func onlyDomainErrors(err error) bool {
+	switch e := err.(type) {
+	case *DomainError:
+		return true
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			if !onlyDomainErrors(err) {
+				return false
+			}
+		}
+		return len(e.Unwrap()) > 0
+	case interface{ Unwrap() error }:
+		return onlyDomainErrors(e.Unwrap())
+	}
+	return false
+}
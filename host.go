@@ -0,0 +1,142 @@
+package driver
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HostInfo Physical host metrics
+type HostInfo struct {
+	Hostname string `json:"hostname"`
+	// CPUs Number of physical (logical) CPUs
+	CPUs int `json:"cpus"`
+	// MemoryTotal Total memory in bytes
+	MemoryTotal uint64 `json:"memory_total"`
+	// MemoryFree Free memory in bytes
+	MemoryFree uint64  `json:"memory_free"`
+	Load1      float64 `json:"load1"`
+	Load5      float64 `json:"load5"`
+	Load15     float64 `json:"load15"`
+}
+
+// LocalHostInfo Metrics of the host this process runs on, for drivers whose
+// hypervisor is local. Memory and load are read from /proc and left zero
+// where it isn't available.
+func LocalHostInfo() (*HostInfo, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &HostInfo{
+		Hostname: hostname,
+		CPUs:     runtime.NumCPU(),
+	}
+	h.readLoadAvg()
+	h.readMemInfo()
+	return h, nil
+}
+
+func (h *HostInfo) readLoadAvg() {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return
+	}
+	h.Load1, _ = strconv.ParseFloat(fields[0], 64)
+	h.Load5, _ = strconv.ParseFloat(fields[1], 64)
+	h.Load15, _ = strconv.ParseFloat(fields[2], 64)
+}
+
+func (h *HostInfo) readMemInfo() {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	// Values are in kB
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			h.MemoryTotal = v * 1024
+		case "MemAvailable:":
+			h.MemoryFree = v * 1024
+		}
+	}
+}
+
+// clockTicks Kernel clock ticks per second (USER_HZ), 100 on every Linux
+// architecture the drivers run on
+const clockTicks = 100
+
+// ProcessStartTime Start time of a local process, read from /proc. Drivers
+// use the start time of the process running a domain as its StartTime.
+func ProcessStartTime(pid int) (Timestamp, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+	// The command may hold spaces and parentheses, fields follow the last one
+	i := strings.LastIndexByte(string(stat), ')')
+	if i < 0 {
+		return 0, errors.New("driver: malformed /proc stat of process " + strconv.Itoa(pid))
+	}
+	// starttime is field 22, the 20th after the command
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 20 {
+		return 0, errors.New("driver: malformed /proc stat of process " + strconv.Itoa(pid))
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	boot, err := bootTime()
+	if err != nil {
+		return 0, err
+	}
+	return boot + Timestamp(ticks*uint64(time.Second)/clockTicks), nil
+}
+
+// bootTime Boot time of the host, from the btime line of /proc/stat
+func bootTime() (Timestamp, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if v, ok := strings.CutPrefix(s.Text(), "btime "); ok {
+			sec, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return Timestamp(sec * int64(time.Second)), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("driver: no btime in /proc/stat")
+}
@@ -0,0 +1,311 @@
+// Package httpapi Read only HTTP JSON interface over a driver.
+//
+// Routes:
+//
+//	GET /domains                   collect every domain
+//	GET /domains/{id}              collect a domain by ID, UUID or name
+//	GET /domains/{id}/snapshots    snapshots of a domain
+//	GET /host                      host metrics
+//	GET /capabilities              what the driver collects
+//	GET /ping                      204 when the hypervisor answers
+//
+// Collections take their CollectOptions from boolean query parameters named
+// after the options in snake case, such as ?memory=true&blocks=true, plus
+// all=true for driver.AllMetrics, concurrency=N, retries=N,
+// enumerate_timeout, per_domain_timeout, retry_delay and slow_interval as Go
+// durations such as 500ms, skip_states, a comma separated list of domain state
+// names such as migrating,saving, and include and exclude, repeated once per
+// pattern as in ?include=web-*&include=db-*. Domains
+// are encoded with their JSON field names. Errors are returned as
+// {"error": "..."} with a status matching the sentinel they wrap: 404 for
+// ErrDomainNotFound, 503 for ErrHypervisorUnavailable, 403 for
+// ErrPermissionDenied, 501 for ErrNotSupported and 400 for malformed
+// arguments and patterns. A collection with per domain errors only is a 200,
+// the errors listed in the response.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// CollectResponse Body of GET /domains
+type CollectResponse struct {
+	Domains map[driver.DomainID]*driver.Domain `json:"domains"`
+	// Errors Messages of the domains that failed to collect
+	Errors []string `json:"errors,omitempty"`
+	// DomainErrors Messages of the failed domains by ID, when the driver
+	// tells which they are
+	DomainErrors map[driver.DomainID]string `json:"domain_errors,omitempty"`
+}
+
+// errBadRequest Malformed query parameter
+var errBadRequest = errors.New("httpapi: bad request")
+
+// Handler Serve d over HTTP
+func Handler(d driver.Driver) http.Handler {
+	h := &handler{d: d}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /domains", h.domains)
+	mux.HandleFunc("GET /domains/{id}", h.domain)
+	mux.HandleFunc("GET /domains/{id}/snapshots", h.snapshots)
+	mux.HandleFunc("GET /host", h.host)
+	mux.HandleFunc("GET /capabilities", h.capabilities)
+	mux.HandleFunc("GET /ping", h.ping)
+	return mux
+}
+
+type handler struct {
+	d driver.Driver
+}
+
+func (h *handler) domains(w http.ResponseWriter, r *http.Request) {
+	opts, err := options(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	domains, err := h.d.CollectContext(r.Context(), opts)
+	// Every domain failing is still a partial result
+	if err != nil && len(domains) == 0 && driver.DomainErrors(err) == nil {
+		writeError(w, err)
+		return
+	}
+	resp := CollectResponse{Domains: domains}
+	if err != nil {
+		resp.Errors = messages(err)
+		for id, derr := range driver.DomainErrors(err) {
+			if resp.DomainErrors == nil {
+				resp.DomainErrors = make(map[driver.DomainID]string)
+			}
+			resp.DomainErrors[id] = derr.Error()
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) domain(w http.ResponseWriter, r *http.Request) {
+	opts, err := options(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	d, err := h.lookup(r.PathValue("id"), opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+func (h *handler) snapshots(w http.ResponseWriter, r *http.Request) {
+	id, err := driver.ParseDomainID(r.PathValue("id"))
+	if err != nil {
+		// Resolve a UUID or name to the domain ID
+		var d *driver.Domain
+		if d, err = h.lookup(r.PathValue("id"), driver.CollectOptions{}); err != nil {
+			writeError(w, err)
+			return
+		}
+		id = d.ID
+	}
+
+	snaps, err := h.d.CollectSnapshots(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if snaps == nil {
+		snaps = []driver.Snapshot{}
+	}
+	writeJSON(w, http.StatusOK, snaps)
+}
+
+func (h *handler) host(w http.ResponseWriter, r *http.Request) {
+	host, err := h.d.Host()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, host)
+}
+
+func (h *handler) capabilities(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.d.Capabilities())
+}
+
+func (h *handler) ping(w http.ResponseWriter, r *http.Request) {
+	if err := h.d.Ping(r.Context()); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookup Collect the domain key names, a numeric ID, a UUID or else a name
+func (h *handler) lookup(key string, opts driver.CollectOptions) (*driver.Domain, error) {
+	if id, err := driver.ParseDomainID(key); err == nil {
+		return h.d.CollectDomain(id, opts)
+	}
+	if driver.ValidUUID(key) {
+		return h.d.CollectDomainByUUID(key, opts)
+	}
+	return h.d.CollectDomainByName(key, opts)
+}
+
+// options Collect options from the query parameters of r
+func options(r *http.Request) (driver.CollectOptions, error) {
+	q := r.URL.Query()
+
+	var opts driver.CollectOptions
+	if q.Has("all") {
+		all, err := flag(q.Get("all"), "all")
+		if err != nil {
+			return opts, err
+		}
+		if all {
+			opts = driver.AllMetrics()
+		}
+	}
+
+	for name, field := range map[string]*bool{
+		"cpus":             &opts.CPUs,
+		"pinning":          &opts.Pinning,
+		"blocks":           &opts.Blocks,
+		"block_capacity":   &opts.BlockCapacity,
+		"backing_chain":    &opts.BackingChain,
+		"interfaces":       &opts.Interfaces,
+		"addresses":        &opts.Addresses,
+		"limits":           &opts.Limits,
+		"memory":           &opts.Memory,
+		"dirty_rate":       &opts.DirtyRate,
+		"filesystems":      &opts.Filesystems,
+		"graphics":         &opts.Graphics,
+		"metadata":         &opts.Metadata,
+		"host_devices":     &opts.HostDevices,
+		"cpu_tuning":       &opts.CPUTuning,
+		"numa":             &opts.NUMA,
+		"versions":         &opts.Versions,
+		"topology_only":    &opts.TopologyOnly,
+		"include_inactive": &opts.IncludeInactive,
+	} {
+		if !q.Has(name) {
+			continue
+		}
+		var err error
+		if *field, err = flag(q.Get(name), name); err != nil {
+			return opts, err
+		}
+	}
+
+	for name, field := range map[string]*int{
+		"concurrency": &opts.Concurrency,
+		"retries":     &opts.Retries,
+	} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("%w: %s %q", errBadRequest, name, v)
+		}
+		*field = n
+	}
+
+	for name, field := range map[string]*time.Duration{
+		"enumerate_timeout":  &opts.EnumerateTimeout,
+		"per_domain_timeout": &opts.PerDomainTimeout,
+		"retry_delay":        &opts.RetryDelay,
+		"slow_interval":      &opts.SlowInterval,
+	} {
+		v := q.Get(name)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return opts, fmt.Errorf("%w: %s %q", errBadRequest, name, v)
+		}
+		*field = d
+	}
+
+	if v := q.Get("skip_states"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			state, err := driver.ParseDomainFlag(name)
+			if err != nil {
+				return opts, fmt.Errorf("%w: skip_states %q", errBadRequest, name)
+			}
+			opts.SkipStates = append(opts.SkipStates, state)
+		}
+	}
+
+	// Patterns can hold commas, they aren't split
+	opts.Include, opts.Exclude = q["include"], q["exclude"]
+	return opts, nil
+}
+
+// flag Boolean query parameter, a parameter without value is true
+func flag(v, name string) (bool, error) {
+	if v == "" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s %q", errBadRequest, name, v)
+	}
+	return b, nil
+}
+
+// messages Messages of the errors joined in err
+func messages(err error) []string {
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		var msgs []string
+		for _, e := range j.Unwrap() {
+			msgs = append(msgs, messages(e)...)
+		}
+		return msgs
+	}
+	return []string{err.Error()}
+}
+
+// statusCode HTTP status of a driver error
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, driver.ErrDomainNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, driver.ErrHypervisorUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, driver.ErrPermissionDenied):
+		return http.StatusForbidden
+	case errors.Is(err, driver.ErrNotSupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, driver.ErrInvalidUUID), errors.Is(err, driver.ErrInvalidDomainID), errors.Is(err, errBadRequest),
+		errors.Is(err, driver.ErrInvalidPattern):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, statusCode(err), struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		driver.GetLogger().Debug("writing response failed", "error", err)
+	}
+}
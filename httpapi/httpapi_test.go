@@ -0,0 +1,147 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/mock"
+)
+
+func TestOptions(t *testing.T) {
+	all := driver.AllMetrics()
+	allButMemory := driver.AllMetrics()
+	allButMemory.Memory = false
+
+	for _, tc := range []struct {
+		query string
+		want  driver.CollectOptions
+	}{
+		{"", driver.CollectOptions{}},
+		{"all=true", all},
+		{"all=false&cpus", driver.CollectOptions{CPUs: true}},
+		{"all&memory=false", allButMemory},
+		{"memory=1&blocks=true&include_inactive", driver.CollectOptions{Memory: true, Blocks: true, IncludeInactive: true}},
+		{"concurrency=4&retries=2", driver.CollectOptions{Concurrency: 4, Retries: 2}},
+		{"enumerate_timeout=2s&per_domain_timeout=500ms&retry_delay=1s&slow_interval=1m", driver.CollectOptions{
+			EnumerateTimeout: 2 * time.Second,
+			PerDomainTimeout: 500 * time.Millisecond,
+			RetryDelay:       time.Second,
+			SlowInterval:     time.Minute,
+		}},
+		{"skip_states=migrating,saving", driver.CollectOptions{SkipStates: []driver.DomainFlag{driver.DomainMigrating, driver.DomainSaving}}},
+		{"include=web-*&include=db-{a,b}&exclude=web-test", driver.CollectOptions{Include: []string{"web-*", "db-{a,b}"}, Exclude: []string{"web-test"}}},
+	} {
+		got, err := options(httptest.NewRequest("GET", "/domains?"+tc.query, nil))
+		if err != nil {
+			t.Errorf("%q: %v", tc.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: got %+v, want %+v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestBadOptions(t *testing.T) {
+	h := Handler(mock.New())
+	for _, query := range []string{
+		"memory=maybe",
+		"all=2",
+		"concurrency=-1",
+		"retries=many",
+		"per_domain_timeout=soon",
+		"retry_delay=-1s",
+		"skip_states=migrating,bogus",
+		"include=[",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/domains?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: status %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestCollectStatus(t *testing.T) {
+	vm := &driver.Domain{ID: 1, Name: "vm"}
+	failed := &driver.DomainError{ID: 2, Name: "broken", Err: driver.ErrPermissionDenied}
+	m := mock.New().Queue(
+		mock.Result{Domains: []*driver.Domain{vm}},
+		mock.Result{Domains: []*driver.Domain{vm}, Err: errors.Join(failed, &driver.DomainError{ID: 3, Err: driver.ErrDomainNotFound})},
+		mock.Result{Err: failed},
+		mock.Result{Err: driver.ErrHypervisorUnavailable},
+		mock.Result{Err: driver.ErrPermissionDenied},
+	)
+	h := Handler(m)
+
+	for _, tc := range []struct {
+		name    string
+		code    int
+		domains int
+		errors  int
+	}{
+		{"complete", http.StatusOK, 1, 0},
+		{"partial", http.StatusOK, 1, 2},
+		{"every domain failed", http.StatusOK, 0, 1},
+		{"hypervisor unavailable", http.StatusServiceUnavailable, 0, 0},
+		{"permission denied", http.StatusForbidden, 0, 0},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/domains", nil))
+		if w.Code != tc.code {
+			t.Errorf("%s: status %d, want %d: %s", tc.name, w.Code, tc.code, w.Body)
+			continue
+		}
+		if tc.code != http.StatusOK {
+			var body struct{ Error string }
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body.Error == "" {
+				t.Errorf("%s: error body %q, %v", tc.name, body.Error, err)
+			}
+			continue
+		}
+
+		var resp CollectResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(resp.Domains) != tc.domains || len(resp.Errors) != tc.errors || len(resp.DomainErrors) != tc.errors {
+			t.Errorf("%s: got %d domains, errors %q, domain errors %v", tc.name, len(resp.Domains), resp.Errors, resp.DomainErrors)
+		}
+		if tc.errors > 0 && resp.DomainErrors[2] != failed.Error() {
+			t.Errorf("%s: domain 2 error %q, want %q", tc.name, resp.DomainErrors[2], failed.Error())
+		}
+	}
+}
+
+func TestUnknownDomain(t *testing.T) {
+	m := mock.New().WithDomains(&driver.Domain{ID: 1, Name: "vm", UUID: "5d7c4e0a-2a4b-4c3e-9f1d-0b6a8e2f4c11"}).
+		WithCapabilities(driver.Capabilities{SupportsSnapshots: true})
+	h := Handler(m)
+
+	for _, tc := range []struct {
+		path string
+		code int
+	}{
+		{"/domains/1", http.StatusOK},
+		{"/domains/vm", http.StatusOK},
+		{"/domains/5d7c4e0a-2a4b-4c3e-9f1d-0b6a8e2f4c11", http.StatusOK},
+		{"/domains/1/snapshots", http.StatusOK},
+		{"/domains/42", http.StatusNotFound},
+		{"/domains/nobody", http.StatusNotFound},
+		{"/domains/00000000-0000-0000-0000-000000000000", http.StatusNotFound},
+		{"/domains/42/snapshots", http.StatusNotFound},
+		{"/domains/nobody/snapshots", http.StatusNotFound},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tc.path, nil))
+		if w.Code != tc.code {
+			t.Errorf("%s: status %d, want %d: %s", tc.path, w.Code, tc.code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+// Package hyperv Driver collecting Hyper-V virtual machines on Windows hosts.
+//
+// VMs are read from the root\virtualization\v2 WMI namespace, which only
+// exists with the Hyper-V role installed: Msvm_ComputerSystem for the VMs and
+// their state, Msvm_Processor and the settings classes for their devices,
+// and the Hyper-V performance counter classes for vCPU run time, disk,
+// network and dynamic memory statistics. Queries run through PowerShell's
+// Get-CimInstance, a single process per collection covering every VM.
+// Hyper-V has no numeric IDs, domain IDs are hashed from the VM GUID, which
+// is also the UUID.
+//
+// The driver is only compiled on Windows. Importing the package registers it
+// under the name "hyperv" using DefaultPowerShell; driver.NewDriver takes the
+// powershell key.
+package hyperv
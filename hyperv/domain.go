@@ -0,0 +1,301 @@
+//go:build windows
+
+package hyperv
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/internal/hvutil"
+)
+
+// Msvm_ComputerSystem EnabledState values
+const (
+	stateEnabled      = 2
+	stateShuttingDown = 4
+	stateQuiesce      = 9
+	statePaused       = 32768
+	stateStarting     = 32770
+	stateSaving       = 32773
+	stateStopping     = 32774
+	statePausing      = 32776
+	stateResuming     = 32777
+)
+
+// cdSubType ResourceSubType of images attached to a DVD drive
+const cdSubType = "Microsoft:Hyper-V:Virtual CD/DVD Disk"
+
+const mib = 1 << 20
+
+// perfName Performance counter instance names don't allow some characters,
+// names are compared in the form Windows gives them
+var perfName = strings.NewReplacer("(", "[", ")", "]", "#", "_", "/", "_", `\`, "-")
+
+// index Classes of a report by VM, settings and devices keyed by VM GUID,
+// counters by instance name
+type index struct {
+	processors        map[string][]processor
+	processorSettings map[string]processorSettings
+	// vcpuTimes Raw run times by VM instance name and vCPU
+	vcpuTimes      map[string]map[uint64]uint64
+	disks          map[string][]storageSettings
+	storage        map[string]storageCounters
+	adapters       map[string][]portSettings
+	network        []networkCounters
+	memorySettings map[string]memorySettings
+	memory         map[string]memoryCounters
+}
+
+func newIndex(r *report) *index {
+	idx := &index{
+		processors:        make(map[string][]processor),
+		processorSettings: make(map[string]processorSettings),
+		vcpuTimes:         make(map[string]map[uint64]uint64),
+		disks:             make(map[string][]storageSettings),
+		storage:           make(map[string]storageCounters, len(r.StorageCounters)),
+		adapters:          make(map[string][]portSettings),
+		network:           r.NetworkCounters,
+		memorySettings:    make(map[string]memorySettings),
+		memory:            make(map[string]memoryCounters, len(r.MemoryCounters)),
+	}
+	for _, p := range r.Processors {
+		guid := strings.ToLower(p.SystemName)
+		idx.processors[guid] = append(idx.processors[guid], p)
+	}
+	for _, s := range r.ProcessorSettings {
+		idx.processorSettings[owner(s.InstanceID)] = s
+	}
+	for _, c := range r.VirtualProcessors {
+		vm, vcpu, ok := strings.Cut(c.Name, ":Hv VP ")
+		n, err := strconv.ParseUint(vcpu, 10, 64)
+		if !ok || err != nil {
+			continue
+		}
+		vm = strings.ToLower(vm)
+		if idx.vcpuTimes[vm] == nil {
+			idx.vcpuTimes[vm] = make(map[uint64]uint64)
+		}
+		idx.vcpuTimes[vm][n] = c.PercentTotalRunTime
+	}
+	for _, s := range r.Disks {
+		guid := owner(s.InstanceID)
+		idx.disks[guid] = append(idx.disks[guid], s)
+	}
+	for _, c := range r.StorageCounters {
+		idx.storage[strings.ToLower(c.Name)] = c
+	}
+	for _, s := range r.Adapters {
+		guid := owner(s.InstanceID)
+		idx.adapters[guid] = append(idx.adapters[guid], s)
+	}
+	for _, s := range r.MemorySettings {
+		idx.memorySettings[owner(s.InstanceID)] = s
+	}
+	for _, c := range r.MemoryCounters {
+		idx.memory[strings.ToLower(c.Name)] = c
+	}
+	return idx
+}
+
+// identity Domain of a computer system with its identity only, nil for the
+// host, which has no GUID
+func identity(sys computerSystem, now driver.Timestamp) *driver.Domain {
+	uuid, err := driver.NormalizeUUID(sys.Name)
+	if err != nil {
+		return nil
+	}
+	return &driver.Domain{
+		Name:       sys.ElementName,
+		UUID:       uuid,
+		ID:         driver.HashDomainID(uuid),
+		Hypervisor: Hypervisor,
+		Time:       now,
+		Flags:      domainFlag(sys.EnabledState),
+	}
+}
+
+// domain Collect a VM from the indexed report, nil for the host or if
+// opts.Filter excludes it
+func (idx *index) domain(sys computerSystem, opts driver.CollectOptions, now driver.Timestamp) *driver.Domain {
+	d := identity(sys, now)
+	if d == nil || !opts.Keep(d.Name, d.UUID, d.ID) {
+		return nil
+	}
+	// VMs are defined until deleted, with a single autostart action
+	d.Persistent, d.PersistentSet = true, true
+	if opts.Skips(d.Flags) {
+		return d
+	}
+
+	guid := strings.ToLower(sys.Name)
+	instance := strings.ToLower(perfName.Replace(sys.ElementName))
+	running := d.Flags == driver.DomainOnline || d.Flags == driver.DomainPaused ||
+		d.Flags.Migrating() || d.Flags == driver.DomainSaving
+	if running && sys.OnTimeInMilliseconds > 0 {
+		d.StartTime = driver.TimestampOf(now.Time().Add(-time.Duration(sys.OnTimeInMilliseconds) * time.Millisecond))
+	}
+
+	settings := idx.processorSettings[guid]
+	d.VCPUs = int(settings.VirtualQuantity)
+	if opts.CPUs {
+		// Hyper-V has no vCPU hotplug
+		d.VCPUsMaximum = d.VCPUs
+		d.VCPUsCurrent = len(idx.processors[guid])
+		d.Cpus = idx.cpus(guid, instance, d.Flags)
+	}
+	if opts.CPUTuning {
+		d.CPUTuning.Weight = settings.Weight
+	}
+	if opts.Blocks {
+		d.Blocks = idx.blocks(guid)
+	}
+	if opts.Interfaces {
+		d.Interfaces = idx.interfaces(guid)
+	}
+	if opts.Memory {
+		idx.collectMemory(guid, instance, running, d)
+	}
+
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d
+}
+
+// cpus vCPUs of a running VM, with their run time when the hypervisor
+// counters are available
+func (idx *index) cpus(guid, instance string, flags driver.DomainFlag) []driver.CPU {
+	procs := idx.processors[guid]
+	times := idx.vcpuTimes[instance]
+	cpus := make([]driver.CPU, 0, len(procs))
+	for _, p := range procs {
+		id, ok := trailingNumber(p.DeviceID)
+		if !ok {
+			continue
+		}
+		cpu := driver.CPU{ID: id, Flags: driver.CPUOnline}
+		if flags == driver.DomainPaused || flags == driver.DomainSaving {
+			cpu.Flags = driver.CPUPaused
+		}
+		// Run time is counted in 100ns units
+		if t, ok := times[id]; ok {
+			cpu.Time = float64(t) * 100
+		}
+		cpus = append(cpus, cpu)
+	}
+	return cpus
+}
+
+// blocks Attached images named after their file, pass-through disks have no
+// image and aren't reported
+func (idx *index) blocks(guid string) []driver.BlockDevice {
+	blocks := make([]driver.BlockDevice, 0, len(idx.disks[guid]))
+	for _, s := range idx.disks[guid] {
+		if len(s.HostResource) == 0 || s.HostResource[0] == "" {
+			continue
+		}
+		path := s.HostResource[0]
+		block := driver.BlockDevice{
+			Name:   filepath.Base(path),
+			Source: path,
+			IsDisk: s.ResourceSubType != cdSubType,
+		}
+		block.IsCDrom = !block.IsDisk
+		block.ReadOnly = block.IsCDrom
+
+		c := idx.storage[strings.ToLower(perfName.Replace(path))]
+		block.Read = driver.BlockIO{Operations: c.ReadOperationsPerSec, Bytes: c.ReadBytesPersec, Absolute: true}
+		block.Write = driver.BlockIO{Operations: c.WriteOperationsPerSec, Bytes: c.WriteBytesPersec, Absolute: true}
+		block.Flush = driver.BlockIO{Operations: c.FlushCount, Absolute: true}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// interfaces Network adapters named after their device ID, the adapter
+// names shown in Hyper-V Manager needn't be unique
+func (idx *index) interfaces(guid string) []driver.NetworkInterface {
+	ifaces := make([]driver.NetworkInterface, 0, len(idx.adapters[guid]))
+	for _, s := range idx.adapters[guid] {
+		_, id, ok := strings.Cut(s.InstanceID, `\`)
+		if !ok {
+			continue
+		}
+		iface := driver.NetworkInterface{Name: strings.ToLower(strings.Trim(id, "{}"))}
+		if mac, err := hvutil.ParseMAC(s.Address); err == nil {
+			iface.Mac = mac
+		}
+
+		// Counter instances end with "<VM GUID>--<adapter ID>"
+		key := strings.ToLower(guid + "--" + id)
+		for _, c := range idx.network {
+			if strings.HasSuffix(strings.ToLower(c.Name), key) {
+				iface.RX = driver.NetworkIO{Bytes: c.BytesReceivedPersec, Packets: c.PacketsReceivedPersec, Drops: c.DroppedPacketsIncomingPersec}
+				iface.TX = driver.NetworkIO{Bytes: c.BytesSentPersec, Packets: c.PacketsSentPersec, Drops: c.DroppedPacketsOutgoingPersec}
+				break
+			}
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces
+}
+
+// collectMemory Memory assigned to a VM, from the dynamic memory counters
+// when present, otherwise its startup memory while running
+func (idx *index) collectMemory(guid, instance string, running bool, d *driver.Domain) {
+	settings := idx.memorySettings[guid]
+	d.MemoryBacking.BalloonMaximum = settings.VirtualQuantity * mib
+	if settings.DynamicMemoryEnabled {
+		d.MemoryBacking.BalloonMaximum = settings.Limit * mib
+	}
+	if !running {
+		return
+	}
+
+	d.Memory.Actual, d.Memory.ActualSet = settings.VirtualQuantity*mib, true
+	if c, ok := idx.memory[instance]; ok {
+		d.Memory.Actual = c.PhysicalMemory * mib
+		d.Memory.Available, d.Memory.AvailableSet = c.GuestVisiblePhysicalMemory*mib, true
+		if c.GuestAvailableMemory >= 0 {
+			d.Memory.Unused, d.Memory.UnusedSet = uint64(c.GuestAvailableMemory)*mib, true
+		}
+	}
+	d.MemoryBacking.BalloonCurrent = d.Memory.Actual
+}
+
+// domainFlag Map a Msvm_ComputerSystem EnabledState onto a DomainFlag, saved
+// VMs are off. Live migration doesn't show in the state.
+func domainFlag(state uint16) driver.DomainFlag {
+	switch state {
+	case stateEnabled, stateStarting, statePausing:
+		return driver.DomainOnline
+	case stateSaving:
+		return driver.DomainSaving
+	case stateQuiesce, statePaused, stateResuming:
+		return driver.DomainPaused
+	case stateShuttingDown, stateStopping:
+		return driver.DomainDying
+	default:
+		return driver.DomainShutdown
+	}
+}
+
+// owner VM GUID of a settings instance ID ("Microsoft:<GUID>\...")
+func owner(instanceID string) string {
+	guid, _, _ := strings.Cut(strings.TrimPrefix(instanceID, "Microsoft:"), `\`)
+	return strings.ToLower(guid)
+}
+
+// trailingNumber Number ending a device ID
+func trailingNumber(s string) (uint64, bool) {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	n, err := strconv.ParseUint(s[i:], 10, 64)
+	return n, err == nil
+}
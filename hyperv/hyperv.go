@@ -0,0 +1,275 @@
+//go:build windows
+
+package hyperv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+const (
+	// Hypervisor Hypervisor name reported by the Hyper-V driver
+	Hypervisor driver.DomainHypervisor = "hyperv"
+	// DefaultPowerShell Default PowerShell command, looked up in PATH
+	DefaultPowerShell = "powershell.exe"
+)
+
+// Msvm_VirtualSystemSettingData VirtualSystemType values
+const (
+	systemRealized   = "Microsoft:Hyper-V:System:Realized"
+	snapshotRealized = "Microsoft:Hyper-V:Snapshot:Realized"
+)
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New(DefaultPowerShell)); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		Keys: []string{"powershell"},
+		New: func(cfg driver.Config) (driver.Driver, error) {
+			return New(cfg.String("powershell", DefaultPowerShell)), nil
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// HyperV Hyper-V driver
+type HyperV struct {
+	powershell string
+}
+
+// New Create a Hyper-V driver running its queries with the powershell
+// command
+func New(powershell string) *HyperV {
+	return &HyperV{powershell: powershell}
+}
+
+// Name Hypervisor name
+func (h *HyperV) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Capabilities Supported metrics. Counters are read from the Hyper-V
+// performance classes, which have no block latency.
+func (h *HyperV) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:       true,
+		SupportsBlocks:     true,
+		SupportsInterfaces: true,
+		SupportsMemory:     true,
+		SupportsSnapshots:  true,
+		SupportsCPUTuning:  true,
+	}
+}
+
+// Detect Test if the Hyper-V role is installed and answers
+func (h *HyperV) Detect() bool {
+	return h.Diagnose().Detected
+}
+
+// Diagnose Tell a host without PowerShell from one without the Hyper-V role,
+// counting the VMs when both are present
+func (h *HyperV) Diagnose() driver.DetectResult {
+	if _, err := exec.LookPath(h.powershell); err != nil {
+		return driver.DetectResult{Reason: h.powershell + " not found", Err: fmt.Errorf("hyperv: %w: %w", driver.ErrHypervisorUnavailable, err)}
+	}
+
+	r, err := h.query(context.Background(), driver.CollectOptions{})
+	switch {
+	case errors.Is(err, driver.ErrHypervisorUnavailable):
+		return driver.DetectResult{Reason: "Hyper-V role not installed: " + err.Error(), Err: err}
+	case err != nil:
+		return driver.DetectResult{Reason: "querying " + Namespace + " failed: " + err.Error(), Err: err}
+	}
+
+	vms := 0
+	for _, sys := range r.Systems {
+		if driver.ValidUUID(sys.Name) {
+			vms++
+		}
+	}
+	if vms == 0 {
+		return driver.DetectResult{Detected: true, Reason: "Hyper-V role installed but no VMs"}
+	}
+	return driver.DetectResult{Detected: true, Reason: fmt.Sprintf("%d VMs", vms)}
+}
+
+// Collect Collect VMs
+func (h *HyperV) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return h.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect every VM, stopped ones included, killing PowerShell
+// when ctx is done. A single query covers every VM, each reports its
+// duration as CollectDuration. The query is bounded by EnumerateTimeout,
+// there is no per VM phase, the query is retried as the listing.
+func (h *HyperV) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	start := time.Now()
+	r, err := driver.Enumerate(ctx, opts, func(ctx context.Context) (*report, error) { return h.query(ctx, opts) })
+	if err != nil {
+		return nil, err
+	}
+
+	idx := newIndex(r)
+	now := driver.TimestampNow()
+	elapsed := time.Since(start)
+	out := make(map[driver.DomainID]*driver.Domain, len(r.Systems))
+	var errs []error
+	for _, sys := range r.Systems {
+		if d := idx.domain(sys, opts, now); d != nil && opts.KeepState(d.Flags) {
+			d.CollectDuration = elapsed
+			if err := driver.AddDomain(out, d); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+// CollectDomain Collect a single VM by ID
+func (h *HyperV) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := h.find(opts, func(d *driver.Domain) bool { return d.ID == id })
+	if err == nil && d == nil {
+		err = fmt.Errorf("hyperv: domain %d: %w", id, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// CollectDomainByUUID Collect a single VM by GUID
+func (h *HyperV) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	u, err := driver.NormalizeUUID(uuid)
+	if err != nil {
+		return nil, fmt.Errorf("hyperv: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+	d, err := h.find(opts, func(d *driver.Domain) bool { return d.UUID == u })
+	if err == nil && d == nil {
+		err = fmt.Errorf("hyperv: domain %s: %w", uuid, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// CollectDomainByName Collect a single VM by name, the first one found if
+// several share it
+func (h *HyperV) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := h.find(opts, func(d *driver.Domain) bool { return d.Name == name })
+	if err == nil && d == nil {
+		err = fmt.Errorf("hyperv: domain %q: %w", name, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// find Collect the first VM accepted by match, or nil if none is. The
+// classes opts needs are queried for every VM.
+func (h *HyperV) find(opts driver.CollectOptions, match func(*driver.Domain) bool) (*driver.Domain, error) {
+	opts = opts.Unfiltered()
+	r, err := h.query(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	now := driver.TimestampNow()
+	for _, sys := range r.Systems {
+		if d := identity(sys, now); d != nil && match(d) {
+			return newIndex(r).domain(sys, opts, now), nil
+		}
+	}
+	return nil, nil
+}
+
+// CollectSnapshots List the checkpoints of a VM, recovery checkpoints taken
+// by backups are left out
+func (h *HyperV) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	d, err := h.CollectDomain(id, driver.CollectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var settings []systemSettings
+	if err := h.run(context.Background(), snapshotsScript(d.UUID), &settings); err != nil {
+		return nil, err
+	}
+
+	var current string
+	names := make(map[string]string, len(settings))
+	for _, s := range settings {
+		names[s.InstanceID] = s.ElementName
+		if s.VirtualSystemType == systemRealized {
+			current = parentID(s.Parent)
+		}
+	}
+
+	var snapshots []driver.Snapshot
+	for _, s := range settings {
+		if s.VirtualSystemType != snapshotRealized {
+			continue
+		}
+		created, _ := time.Parse(time.RFC3339Nano, s.CreationTime)
+		snapshots = append(snapshots, driver.Snapshot{
+			Name:         s.ElementName,
+			CreationTime: created,
+			Parent:       names[parentID(s.Parent)],
+			IsCurrent:    s.InstanceID == current,
+		})
+	}
+	return snapshots, nil
+}
+
+// parentID Instance ID in the object path of a parent snapshot
+// (...Msvm_VirtualSystemSettingData.InstanceID="Microsoft:<GUID>")
+func parentID(path string) string {
+	_, id, ok := strings.Cut(path, `InstanceID="`)
+	if !ok {
+		return ""
+	}
+	id, _, _ = strings.Cut(id, `"`)
+	return id
+}
+
+// Host Metrics of the local host, which runs the VMs. Windows has no load
+// average, it is left zero.
+func (h *HyperV) Host() (*driver.HostInfo, error) {
+	info, err := driver.LocalHostInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	var mem hostMemory
+	if err := h.run(context.Background(), hostScript, &mem); err != nil {
+		return nil, err
+	}
+	info.MemoryTotal = mem.TotalVisibleMemorySize * 1024
+	info.MemoryFree = mem.FreePhysicalMemory * 1024
+	return info, nil
+}
+
+// Ping Query the Hyper-V management service, killing PowerShell when ctx is
+// done first
+func (h *HyperV) Ping(ctx context.Context) error {
+	return h.run(ctx, pingScript, nil)
+}
+
+// Watch Hyper-V events need a WMI subscription, which isn't implemented
+func (h *HyperV) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	return nil, fmt.Errorf("hyperv: watch: %w", driver.ErrNotSupported)
+}
+
+// Close Nothing to release, PowerShell runs per query
+func (h *HyperV) Close() error {
+	return nil
+}
+
+// query Run the collection script for opts
+func (h *HyperV) query(ctx context.Context, opts driver.CollectOptions) (*report, error) {
+	var r report
+	if err := h.run(ctx, collectScript(opts), &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
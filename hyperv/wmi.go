@@ -0,0 +1,260 @@
+//go:build windows
+
+package hyperv
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/virtmonitor/driver"
+)
+
+// Namespace WMI namespace of the Hyper-V provider
+const Namespace = `root\virtualization\v2`
+
+// scriptHeader Settings and helpers shared by every script. Counter classes
+// are missing until their device type is used after boot, they are queried
+// without failing.
+const scriptHeader = `$ErrorActionPreference = 'Stop'
+[Console]::OutputEncoding = [Text.Encoding]::UTF8
+$ns = '` + Namespace + `'
+function Query($class) { @(Get-CimInstance -Namespace $ns -ClassName $class) }
+function Counters($class) { @(Get-CimInstance -ClassName $class -ErrorAction SilentlyContinue) }
+`
+
+// Queries of the collection script by category, keyed as in report
+const (
+	systemsQuery = `Systems = @(Query Msvm_ComputerSystem | Select-Object Name, ElementName, EnabledState, OnTimeInMilliseconds)
+`
+	cpuQueries = `Processors = @(Query Msvm_Processor | Select-Object SystemName, DeviceID, EnabledState)
+ProcessorSettings = @(Query Msvm_ProcessorSettingData | Select-Object InstanceID, VirtualQuantity, Weight)
+VirtualProcessors = @(Counters Win32_PerfRawData_HvStats_HyperVHypervisorVirtualProcessor | Select-Object Name, PercentTotalRunTime)
+`
+	blockQueries = `Disks = @(Query Msvm_StorageAllocationSettingData | Select-Object InstanceID, ResourceSubType, HostResource)
+StorageCounters = @(Counters Win32_PerfRawData_Counters_HyperVVirtualStorageDevice | Select-Object Name, ReadBytesPersec, WriteBytesPersec, ReadOperationsPerSec, WriteOperationsPerSec, FlushCount)
+`
+	interfaceQueries = `Adapters = @(@(Query Msvm_SyntheticEthernetPortSettingData) + @(Query Msvm_EmulatedEthernetPortSettingData) | Select-Object InstanceID, ElementName, Address)
+NetworkCounters = @(Counters Win32_PerfRawData_NvspNicStats_HyperVVirtualNetworkAdapter | Select-Object Name, BytesReceivedPersec, BytesSentPersec, PacketsReceivedPersec, PacketsSentPersec, DroppedPacketsIncomingPersec, DroppedPacketsOutgoingPersec)
+`
+	memoryQueries = `MemorySettings = @(Query Msvm_MemorySettingData | Select-Object InstanceID, VirtualQuantity, Limit, DynamicMemoryEnabled)
+MemoryCounters = @(Counters Win32_PerfRawData_BalancerStats_HyperVDynamicMemoryVM | Select-Object Name, PhysicalMemory, GuestVisiblePhysicalMemory, GuestAvailableMemory)
+`
+)
+
+// pingScript Minimal query answered by the Hyper-V provider
+const pingScript = scriptHeader + `$null = Query Msvm_VirtualSystemManagementService
+`
+
+// hostScript Host memory, in KiB
+const hostScript = scriptHeader + `Get-CimInstance -ClassName Win32_OperatingSystem | Select-Object TotalVisibleMemorySize, FreePhysicalMemory | ConvertTo-Json -Compress
+`
+
+// collectScript Script reporting the VMs with the classes opts needs
+func collectScript(opts driver.CollectOptions) string {
+	var b strings.Builder
+	b.WriteString(scriptHeader)
+	b.WriteString("ConvertTo-Json -Depth 4 -Compress -InputObject @{\n")
+	b.WriteString(systemsQuery)
+	if opts.CPUs || opts.CPUTuning {
+		b.WriteString(cpuQueries)
+	}
+	if opts.Blocks {
+		b.WriteString(blockQueries)
+	}
+	if opts.Interfaces {
+		b.WriteString(interfaceQueries)
+	}
+	if opts.Memory {
+		b.WriteString(memoryQueries)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// snapshotsScript Script listing the settings of the VM with the GUID, which
+// must be validated
+func snapshotsScript(guid string) string {
+	return scriptHeader + `ConvertTo-Json -Depth 2 -Compress -InputObject @(Get-CimInstance -Namespace $ns -ClassName Msvm_VirtualSystemSettingData -Filter "VirtualSystemIdentifier = '` + guid + `'" |
+	Select-Object InstanceID, ElementName, VirtualSystemType, Parent, @{n = 'CreationTime'; e = { $_.CreationTime.ToUniversalTime().ToString('o') }})
+`
+}
+
+// report Output of collectScript, categories not queried are empty
+type report struct {
+	Systems           []computerSystem
+	Processors        []processor
+	ProcessorSettings []processorSettings
+	VirtualProcessors []processorCounters
+	Disks             []storageSettings
+	StorageCounters   []storageCounters
+	Adapters          []portSettings
+	NetworkCounters   []networkCounters
+	MemorySettings    []memorySettings
+	MemoryCounters    []memoryCounters
+}
+
+// computerSystem Msvm_ComputerSystem, the host is one too
+type computerSystem struct {
+	// Name VM GUID
+	Name string
+	// ElementName Name shown in Hyper-V Manager
+	ElementName          string
+	EnabledState         uint16
+	OnTimeInMilliseconds uint64
+}
+
+// processor Msvm_Processor, one per vCPU of the running VMs
+type processor struct {
+	// SystemName VM GUID
+	SystemName   string
+	DeviceID     string
+	EnabledState uint16
+}
+
+// processorSettings Msvm_ProcessorSettingData
+type processorSettings struct {
+	InstanceID      string
+	VirtualQuantity uint64
+	// Weight Relative weight, 100 by default
+	Weight uint64
+}
+
+// processorCounters Hyper-V Hypervisor Virtual Processor counters, named
+// "<VM>:Hv VP <n>"
+type processorCounters struct {
+	Name string
+	// PercentTotalRunTime Raw run time in 100ns units
+	PercentTotalRunTime uint64
+}
+
+// storageSettings Msvm_StorageAllocationSettingData, attached images
+type storageSettings struct {
+	InstanceID      string
+	ResourceSubType string
+	HostResource    []string
+}
+
+// storageCounters Hyper-V Virtual Storage Device counters, named after the
+// image path. Raw per second counters are running totals.
+type storageCounters struct {
+	Name                  string
+	ReadBytesPersec       uint64
+	WriteBytesPersec      uint64
+	ReadOperationsPerSec  uint64
+	WriteOperationsPerSec uint64
+	FlushCount            uint64
+}
+
+// portSettings Msvm_SyntheticEthernetPortSettingData and
+// Msvm_EmulatedEthernetPortSettingData
+type portSettings struct {
+	InstanceID  string
+	ElementName string
+	// Address MAC address without separators
+	Address string
+}
+
+// networkCounters Hyper-V Virtual Network Adapter counters, named after the
+// VM and adapter. Received is traffic to the guest.
+type networkCounters struct {
+	Name                         string
+	BytesReceivedPersec          uint64
+	BytesSentPersec              uint64
+	PacketsReceivedPersec        uint64
+	PacketsSentPersec            uint64
+	DroppedPacketsIncomingPersec uint64
+	DroppedPacketsOutgoingPersec uint64
+}
+
+// memorySettings Msvm_MemorySettingData, sizes in MiB
+type memorySettings struct {
+	InstanceID string
+	// VirtualQuantity Startup memory
+	VirtualQuantity uint64
+	// Limit Maximum memory with dynamic memory
+	Limit                uint64
+	DynamicMemoryEnabled bool
+}
+
+// memoryCounters Hyper-V Dynamic Memory VM counters, named after the VM,
+// sizes in MiB
+type memoryCounters struct {
+	Name                       string
+	PhysicalMemory             uint64
+	GuestVisiblePhysicalMemory uint64
+	// GuestAvailableMemory Negative when the guest is short of memory
+	GuestAvailableMemory int64
+}
+
+// systemSettings Msvm_VirtualSystemSettingData of the VM or a snapshot
+type systemSettings struct {
+	InstanceID        string
+	ElementName       string
+	VirtualSystemType string
+	// Parent Object path of the snapshot these settings derive from
+	Parent       string
+	CreationTime string
+}
+
+// hostMemory Win32_OperatingSystem memory, in KiB
+type hostMemory struct {
+	TotalVisibleMemorySize uint64
+	FreePhysicalMemory     uint64
+}
+
+// run Run a PowerShell script, decoding its JSON output into v unless nil
+func (h *HyperV) run(ctx context.Context, script string, v any) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, h.powershell, "-NoProfile", "-NonInteractive", "-EncodedCommand", encodeCommand(script))
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case errors.Is(err, exec.ErrNotFound):
+		return fmt.Errorf("hyperv: %w: %w", driver.ErrHypervisorUnavailable, err)
+	default:
+		return scriptError(err, strings.TrimSpace(stderr.String()))
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(bytes.TrimPrefix(out, []byte("\xef\xbb\xbf")), v); err != nil {
+		return fmt.Errorf("hyperv: decoding query output: %w", err)
+	}
+	return nil
+}
+
+// scriptError Wrap a failed script with the matching sentinel, telling a
+// host without the role from a refused query
+func scriptError(err error, msg string) error {
+	switch {
+	case strings.Contains(msg, "Invalid namespace"):
+		return fmt.Errorf("hyperv: %w: %s not found, is the Hyper-V role installed?", driver.ErrHypervisorUnavailable, Namespace)
+	case strings.Contains(msg, "Access denied"), strings.Contains(msg, "Access is denied"):
+		return fmt.Errorf("hyperv: %w: %s", driver.ErrPermissionDenied, msg)
+	}
+	return fmt.Errorf("hyperv: powershell: %w: %s", err, msg)
+}
+
+// encodeCommand Encode a script for -EncodedCommand, base64 of UTF-16LE,
+// sparing it from command line quoting
+func encodeCommand(script string) string {
+	units := utf16.Encode([]rune(script))
+	buf := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(buf[2*i:], u)
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
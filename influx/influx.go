@@ -0,0 +1,254 @@
+// Package influx InfluxDB line protocol encoding of domain metrics.
+package influx
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	tagEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+	stringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+// EncodeLineProtocol Write one line per metric category to w: measurements
+// domain, cpu, block, network and memory, tagged with the domain name, uuid
+// and hypervisor plus the cpu, device or interface. Lines are timestamped
+// with the domain's Time when set, otherwise ts.
+func EncodeLineProtocol(w io.Writer, doms map[driver.DomainID]*driver.Domain, ts time.Time) error {
+	bw := bufio.NewWriter(w)
+
+	ids := make([]driver.DomainID, 0, len(doms))
+	for id := range doms {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		if err := encodeDomain(bw, doms[id], ts); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Encoder driver.Encoder writing line protocol, timestamping domains
+// without a Time with the encoding time
+type Encoder struct{}
+
+// Encode Implements driver.Encoder
+func (Encoder) Encode(w io.Writer, doms map[driver.DomainID]*driver.Domain) error {
+	return EncodeLineProtocol(w, doms, time.Now())
+}
+
+// line A single line protocol point under construction
+type line struct {
+	w      *bufio.Writer
+	fields int
+}
+
+func newLine(w *bufio.Writer, measurement string, tags ...string) *line {
+	w.WriteString(measurementEscaper.Replace(measurement))
+	for i := 0; i+1 < len(tags); i += 2 {
+		// Empty tag values are invalid, leave the tag out
+		if tags[i+1] == "" {
+			continue
+		}
+		w.WriteByte(',')
+		w.WriteString(tagEscaper.Replace(tags[i]))
+		w.WriteByte('=')
+		w.WriteString(tagEscaper.Replace(tags[i+1]))
+	}
+	return &line{w: w}
+}
+
+func (l *line) sep(key string) {
+	if l.fields == 0 {
+		l.w.WriteByte(' ')
+	} else {
+		l.w.WriteByte(',')
+	}
+	l.fields++
+	l.w.WriteString(tagEscaper.Replace(key))
+	l.w.WriteByte('=')
+}
+
+func (l *line) uint(key string, v uint64) {
+	l.sep(key)
+	l.w.WriteString(strconv.FormatUint(v, 10))
+	l.w.WriteByte('i')
+}
+
+func (l *line) float(key string, v float64) {
+	l.sep(key)
+	l.w.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+func (l *line) bool(key string, v bool) {
+	l.sep(key)
+	l.w.WriteString(strconv.FormatBool(v))
+}
+
+func (l *line) string(key, v string) {
+	l.sep(key)
+	l.w.WriteByte('"')
+	l.w.WriteString(stringEscaper.Replace(v))
+	l.w.WriteByte('"')
+}
+
+// end Terminate the line, a line without fields is invalid so callers must
+// add at least one
+func (l *line) end(ts int64) error {
+	l.w.WriteByte(' ')
+	l.w.WriteString(strconv.FormatInt(ts, 10))
+	return l.w.WriteByte('\n')
+}
+
+func encodeDomain(w *bufio.Writer, d *driver.Domain, fallback time.Time) error {
+	ts := fallback.UnixNano()
+	if d.Time != 0 {
+		ts = int64(d.Time)
+	}
+
+	tags := []string{"domain", d.Name, "uuid", d.UUID, "hypervisor", string(d.Hypervisor)}
+	with := func(extra ...string) []string {
+		return append(tags[:len(tags):len(tags)], extra...)
+	}
+
+	l := newLine(w, "domain", tags...)
+	l.uint("id", uint64(d.ID))
+	l.string("state", d.Flags.String())
+	l.string("os_type", d.OSType)
+	if d.MachineType != "" {
+		l.string("machine_type", d.MachineType)
+	}
+	if d.EmulatorVersion != "" {
+		l.string("emulator_version", d.EmulatorVersion)
+	}
+	if d.GuestAgent != driver.AgentUnknown {
+		l.string("guest_agent", d.GuestAgent.String())
+	}
+	if err := l.end(ts); err != nil {
+		return err
+	}
+
+	for _, cpu := range d.Cpus {
+		l := newLine(w, "cpu", with("cpu", strconv.FormatUint(cpu.ID, 10))...)
+		l.string("state", cpu.Flags.String())
+		l.float("time", cpu.Time)
+		if cpu.IdleSet {
+			l.float("idle", cpu.Idle)
+		}
+		if cpu.StealSet {
+			l.float("steal", cpu.Steal)
+		}
+		if cpu.IOWaitSet {
+			l.float("iowait", cpu.IOWait)
+		}
+		l.float("load1", cpu.Load1)
+		l.float("load5", cpu.Load5)
+		l.float("load15", cpu.Load15)
+		if err := l.end(ts); err != nil {
+			return err
+		}
+	}
+
+	for _, b := range d.Blocks {
+		l := newLine(w, "block", with("device", b.Name)...)
+		l.uint("read_operations", b.Read.Operations)
+		l.uint("read_bytes", b.Read.Bytes)
+		l.uint("write_operations", b.Write.Operations)
+		l.uint("write_bytes", b.Write.Bytes)
+		l.uint("flush_operations", b.Flush.Operations)
+		l.uint("read_errors", b.Read.Errors)
+		l.uint("write_errors", b.Write.Errors)
+		l.uint("flush_errors", b.Flush.Errors)
+		if b.Read.TotalTimeSet {
+			l.uint("read_time", b.Read.TotalTime)
+		}
+		if b.Write.TotalTimeSet {
+			l.uint("write_time", b.Write.TotalTime)
+		}
+		if b.Flush.TotalTimeSet {
+			l.uint("flush_time", b.Flush.TotalTime)
+		}
+		l.bool("absolute", b.Read.Absolute)
+		l.bool("stalled", b.Stalled)
+		l.bool("throttled", b.Throttled)
+		if err := l.end(ts); err != nil {
+			return err
+		}
+	}
+
+	for _, iface := range d.Interfaces {
+		l := newLine(w, "network", with("interface", iface.Name)...)
+		l.uint("rx_bytes", iface.RX.Bytes)
+		l.uint("rx_packets", iface.RX.Packets)
+		l.uint("rx_errors", iface.RX.Errors)
+		l.uint("rx_drops", iface.RX.Drops)
+		l.uint("rx_fifo_errors", iface.RX.FIFO)
+		l.uint("rx_frame_errors", iface.RX.Frame)
+		l.uint("tx_bytes", iface.TX.Bytes)
+		l.uint("tx_packets", iface.TX.Packets)
+		l.uint("tx_errors", iface.TX.Errors)
+		l.uint("tx_drops", iface.TX.Drops)
+		l.uint("tx_fifo_errors", iface.TX.FIFO)
+		l.uint("tx_collisions", iface.TX.Collisions)
+		l.uint("tx_carrier_errors", iface.TX.Carrier)
+		if err := l.end(ts); err != nil {
+			return err
+		}
+	}
+
+	for _, fs := range d.Filesystems {
+		l := newLine(w, "filesystem", with("mountpoint", fs.Mountpoint, "fstype", fs.Type)...)
+		l.string("name", fs.Name)
+		l.uint("total_bytes", fs.TotalBytes)
+		l.uint("used_bytes", fs.UsedBytes)
+		if err := l.end(ts); err != nil {
+			return err
+		}
+	}
+
+	return encodeMemory(w, d.Memory, tags, ts)
+}
+
+func encodeMemory(w *bufio.Writer, m driver.Memory, tags []string, ts int64) error {
+	fields := []struct {
+		key string
+		v   uint64
+		set bool
+	}{
+		{"actual", m.Actual, m.ActualSet},
+		{"available", m.Available, m.AvailableSet},
+		{"unused", m.Unused, m.UnusedSet},
+		{"rss", m.RSS, m.RSSSet},
+		{"swap_in", m.SwapIn, m.SwapInSet},
+		{"swap_out", m.SwapOut, m.SwapOutSet},
+		{"major_faults", m.MajorFaults, m.MajorFaultsSet},
+		{"minor_faults", m.MinorFaults, m.MinorFaultsSet},
+		{"dirty_rate", m.DirtyRate, m.DirtyRateSet},
+	}
+
+	var l *line
+	for _, f := range fields {
+		if !f.set {
+			continue
+		}
+		if l == nil {
+			l = newLine(w, "memory", tags...)
+		}
+		l.uint(f.key, f.v)
+	}
+	if l == nil {
+		return nil
+	}
+	return l.end(ts)
+}
@@ -0,0 +1,56 @@
+// Package hvutil Helpers shared by the drivers reading the same host
+// interfaces: cgroup files, API sockets and MAC addresses.
+package hvutil
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ReadKeyed Read a flat keyed file of "key value" lines, such as cpu.stat
+// and memory.stat. Lines that don't parse are skipped.
+func ReadKeyed(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values, s.Err()
+}
+
+// Stale Test if err means nothing listens on an API socket anymore
+func Stale(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, os.ErrNotExist)
+}
+
+// ParseMAC Parse a MAC address written with or without separators
+func ParseMAC(s string) (net.HardwareAddr, error) {
+	if len(s) == 12 {
+		var b strings.Builder
+		for i := 0; i < 12; i += 2 {
+			if i > 0 {
+				b.WriteByte(':')
+			}
+			b.WriteString(s[i : i+2])
+		}
+		s = b.String()
+	}
+	return net.ParseMAC(s)
+}
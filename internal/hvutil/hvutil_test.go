@@ -0,0 +1,52 @@
+package hvutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestReadKeyed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cpu.stat")
+	stat := "usage_usec 123456\nuser_usec 100000\nnr_periods 0\nmalformed\nthrottled_usec x\nextra 1 2\n"
+	if err := os.WriteFile(path, []byte(stat), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadKeyed(path)
+	want := map[string]uint64{"usage_usec": 123456, "user_usec": 100000, "nr_periods": 0}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadKeyed() = %v, %v, want %v", got, err, want)
+	}
+	if _, err := ReadKeyed(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("missing file: %v", err)
+	}
+}
+
+func TestStale(t *testing.T) {
+	for err, want := range map[error]bool{
+		fmt.Errorf("dial: %w", syscall.ECONNREFUSED): true,
+		fmt.Errorf("dial: %w", os.ErrNotExist):       true,
+		fmt.Errorf("dial: %w", os.ErrPermission):     false,
+		syscall.ETIMEDOUT:                            false,
+	} {
+		if got := Stale(err); got != want {
+			t.Errorf("Stale(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+func TestParseMAC(t *testing.T) {
+	for _, s := range []string{"525400123456", "52:54:00:12:34:56", "52-54-00-12-34-56"} {
+		if mac, err := ParseMAC(s); err != nil || mac.String() != "52:54:00:12:34:56" {
+			t.Errorf("ParseMAC(%q) = %v, %v", s, mac, err)
+		}
+	}
+	for _, s := range []string{"", "52540012345", "52540012345g"} {
+		if _, err := ParseMAC(s); err == nil {
+			t.Errorf("ParseMAC(%q) succeeded", s)
+		}
+	}
+}
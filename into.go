@@ -0,0 +1,78 @@
+package driver
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// IntoCollector Optional interface of drivers collecting into the domains of
+// a previous collection, reusing their memory rather than allocating anew.
+// Drivers collecting with CollectDomains implement it with
+// CollectDomainsInto.
+type IntoCollector interface {
+	// CollectInto Collect domains as CollectContext does, into dst
+	CollectInto(ctx context.Context, dst map[DomainID]*Domain, opts CollectOptions) error
+}
+
+// CollectInto Collect the domains of d into dst, for callers sampling at a
+// fixed interval: passing the same map every time spares reallocating the
+// map, the domains and their Cpus, IOThreads, Blocks and Interfaces when d
+// is an IntoCollector. Other drivers are collected with CollectContext, only
+// the map is reused.
+//
+// dst is replaced by the collection: domains gone are removed, the others
+// overwritten, along with their slices. Domains of dst, and any slice or
+// pointer read from them, are only valid until the next call with dst:
+// values kept across calls, such as the previous sample a Rate is computed
+// against or a domain passed to a tracker keeping it, must be a Clone. dst
+// must not be read or written during the call. Domains collected along with
+// an error are left in dst, as from CollectContext; dst is emptied when ctx
+// is done.
+func CollectInto(ctx context.Context, d Driver, dst map[DomainID]*Domain, opts CollectOptions) error {
+	if c, ok := d.(IntoCollector); ok {
+		return c.CollectInto(ctx, dst, opts)
+	}
+	domains, err := d.CollectContext(ctx, opts)
+	clear(dst)
+	maps.Copy(dst, domains)
+	return err
+}
+
+// Reset Zero d for collecting into again. Cpus, IOThreads, Blocks and
+// Interfaces keep their memory and are left empty rather than nil, for
+// drivers to append to.
+func (d *Domain) Reset() {
+	*d = Domain{Cpus: d.Cpus[:0], IOThreads: d.IOThreads[:0], Blocks: d.Blocks[:0], Interfaces: d.Interfaces[:0]}
+}
+
+// Recycler Domains of a previous collection for drivers to collect into,
+// each handed out once so that concurrent workers never share one. A nil
+// Recycler hands out new domains. Safe for concurrent use.
+type Recycler struct {
+	mu      sync.Mutex
+	domains map[DomainID]*Domain
+}
+
+// NewRecycler Recycler of domains. The map isn't retained, the domains are.
+func NewRecycler(domains map[DomainID]*Domain) *Recycler {
+	return &Recycler{domains: maps.Clone(domains)}
+}
+
+// Domain The domain previously under id, Reset, or a new domain if there was
+// none or it was already handed out
+func (r *Recycler) Domain(id DomainID) *Domain {
+	if r == nil {
+		return new(Domain)
+	}
+	r.mu.Lock()
+	d := r.domains[id]
+	delete(r.domains, id)
+	r.mu.Unlock()
+
+	if d == nil {
+		return new(Domain)
+	}
+	d.Reset()
+	return d
+}
@@ -0,0 +1,157 @@
+package driver_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/mock"
+)
+
+// benchDomains Domains collected by the benchmarks
+const benchDomains = 64
+
+// fillDomain Fill d as a driver would, with 4 vCPUs, 2 disks and 2
+// interfaces, appending to the slices it holds
+func fillDomain(d *driver.Domain, id int) *driver.Domain {
+	d.ID, d.Name, d.Hypervisor, d.Flags = driver.DomainID(id), fmt.Sprintf("vm-%d", id), mock.Hypervisor, driver.DomainOnline
+	d.VCPUs, d.VCPUsCurrent = 4, 4
+	for i := 0; i < 4; i++ {
+		d.Cpus = append(d.Cpus, driver.CPU{ID: uint64(i), Time: float64(id * i), Flags: driver.CPURunning})
+	}
+	for _, name := range []string{"vda", "vdb"} {
+		d.Blocks = append(d.Blocks, driver.BlockDevice{Name: name, IsDisk: true, Read: driver.BlockIO{Operations: uint64(id)}})
+	}
+	for _, name := range []string{"tap0", "tap1"} {
+		d.Interfaces = append(d.Interfaces, driver.NetworkInterface{Name: name, RX: driver.NetworkIO{Bytes: uint64(id)}})
+	}
+	return d
+}
+
+func benchItems() []int {
+	items := make([]int, benchDomains)
+	for i := range items {
+		items[i] = i + 1
+	}
+	return items
+}
+
+// benchMock Mock collecting benchDomains domains
+func benchMock() *mock.Mock {
+	var domains []*driver.Domain
+	for _, id := range benchItems() {
+		domains = append(domains, fillDomain(new(driver.Domain), id))
+	}
+	return mock.New().WithDomains(domains...)
+}
+
+// benchInto Mock implementing driver.IntoCollector as drivers collecting
+// with CollectDomains do
+type benchInto struct {
+	*mock.Mock
+	items []int
+}
+
+func (m benchInto) CollectInto(ctx context.Context, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	return driver.CollectDomainsInto(ctx, opts, m.items, dst, func(_ context.Context, id int, r *driver.Recycler) (*driver.Domain, error) {
+		return fillDomain(r.Domain(driver.DomainID(id)), id), nil
+	})
+}
+
+func BenchmarkCollect(b *testing.B) {
+	ctx := context.Background()
+	opts := driver.CollectOptions{CPUs: true, Blocks: true, Interfaces: true}
+
+	b.Run("mock/CollectContext", func(b *testing.B) {
+		m := benchMock()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := m.CollectContext(ctx, opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	// The mock isn't an IntoCollector, CollectInto falls back to
+	// CollectContext
+	b.Run("mock/CollectInto-fallback", func(b *testing.B) {
+		m := benchMock()
+		dst := make(map[driver.DomainID]*driver.Domain)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := driver.CollectInto(ctx, m, dst, opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("IntoCollector/CollectInto", func(b *testing.B) {
+		var d driver.Driver = benchInto{mock.New(), benchItems()}
+		if _, ok := d.(driver.IntoCollector); !ok {
+			b.Fatal("benchInto isn't an IntoCollector")
+		}
+		dst := make(map[driver.DomainID]*driver.Domain)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := driver.CollectInto(ctx, d, dst, opts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	items := benchItems()
+	b.Run("CollectDomains", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := driver.CollectDomains(ctx, opts, items, func(_ context.Context, id int) (*driver.Domain, error) {
+				return fillDomain(new(driver.Domain), id), nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("CollectDomainsInto", func(b *testing.B) {
+		dst := make(map[driver.DomainID]*driver.Domain)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			err := driver.CollectDomainsInto(ctx, opts, items, dst, func(_ context.Context, id int, r *driver.Recycler) (*driver.Domain, error) {
+				return fillDomain(r.Domain(driver.DomainID(id)), id), nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestCollectDomainsIntoReuses(t *testing.T) {
+	ctx := context.Background()
+	items := benchItems()
+	collect := func(_ context.Context, id int, r *driver.Recycler) (*driver.Domain, error) {
+		return fillDomain(r.Domain(driver.DomainID(id)), id), nil
+	}
+
+	dst := make(map[driver.DomainID]*driver.Domain)
+	if err := driver.CollectDomainsInto(ctx, driver.CollectOptions{}, items, dst, collect); err != nil {
+		t.Fatal(err)
+	}
+	first := make(map[driver.DomainID]*driver.Domain, len(dst))
+	for id, d := range dst {
+		first[id] = d
+	}
+
+	if err := driver.CollectDomainsInto(ctx, driver.CollectOptions{}, items[1:], dst, collect); err != nil {
+		t.Fatal(err)
+	}
+	if len(dst) != len(items)-1 || dst[1] != nil {
+		t.Fatalf("got %d domains, want %d without domain 1", len(dst), len(items)-1)
+	}
+	for id, d := range dst {
+		if d != first[id] {
+			t.Errorf("domain %d reallocated", id)
+		}
+		if len(d.Cpus) != 4 || len(d.Blocks) != 2 || len(d.Interfaces) != 2 {
+			t.Errorf("domain %d: %d vCPUs, %d blocks, %d interfaces, want 4, 2 and 2", id, len(d.Cpus), len(d.Blocks), len(d.Interfaces))
+		}
+	}
+}
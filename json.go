@@ -0,0 +1,63 @@
+package driver
+
+import (
+	"encoding/json"
+	"net"
+)
+
+// networkInterface NetworkInterface without its methods, to avoid recursing
+type networkInterface NetworkInterface
+
+// jsonInterface JSON form of a NetworkInterface, with the MAC as a string
+// and addresses in CIDR notation
+type jsonInterface struct {
+	networkInterface
+	Mac       string   `json:"mac"`
+	Addresses []string `json:"addresses"`
+}
+
+// MarshalJSON Encode the interface with its MAC in colon separated form
+// and its addresses in CIDR notation
+func (n NetworkInterface) MarshalJSON() ([]byte, error) {
+	j := jsonInterface{networkInterface: networkInterface(n)}
+	if n.Mac != nil {
+		j.Mac = n.Mac.String()
+	}
+	for _, a := range n.Addresses {
+		j.Addresses = append(j.Addresses, a.String())
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON Decode an interface encoded by MarshalJSON
+func (n *NetworkInterface) UnmarshalJSON(data []byte) error {
+	var j jsonInterface
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*n = NetworkInterface(j.networkInterface)
+	n.Mac = nil
+	if j.Mac != "" {
+		mac, err := net.ParseMAC(j.Mac)
+		if err != nil {
+			return err
+		}
+		n.Mac = mac
+	}
+
+	n.Addresses = nil
+	for _, a := range j.Addresses {
+		ip, ipnet, err := net.ParseCIDR(a)
+		if err != nil {
+			return err
+		}
+		// Keep the host part, in the same length form as the mask
+		if len(ipnet.Mask) == net.IPv4len {
+			ip = ip.To4()
+		}
+		ipnet.IP = ip
+		n.Addresses = append(n.Addresses, *ipnet)
+	}
+	return nil
+}
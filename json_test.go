@@ -0,0 +1,55 @@
+package driver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/virtmonitor/driver"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestDomainJSONGolden The golden file holds the JSON of fullDomain indented,
+// it's compared compacted
+func TestDomainJSONGolden(t *testing.T) {
+	got, err := json.Marshal(fullDomain())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "domain.golden")
+	if *update {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, got, "", "\t"); err != nil {
+			t.Fatal(err)
+		}
+		indented.WriteByte('\n')
+		if err := os.WriteFile(golden, indented.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, want); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, compact.Bytes()) {
+		t.Errorf("JSON of the full domain differs from %s, rerun with -update if intended:\n got %s\nwant %s", golden, got, compact.Bytes())
+	}
+
+	// The golden decodes back to the domain it was encoded from
+	var d driver.Domain
+	if err := json.Unmarshal(want, &d); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Equal(fullDomain()) {
+		t.Errorf("decoding %s: got %+v, want %+v", golden, d, fullDomain())
+	}
+}
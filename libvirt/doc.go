@@ -0,0 +1,19 @@
+// Package libvirt Driver collecting domains from a libvirt daemon.
+//
+// The driver talks to libvirtd over its RPC socket, or over TCP or TLS for
+// remote URIs (see Config), and is only compiled with the libvirt build tag,
+// keeping the dependency out of default builds:
+//
+//	go build -tags libvirt
+//
+// Collected domains hold their github.com/digitalocean/go-libvirt Domain as
+// private data (see driver.Private), the handle to act on the domain over a
+// connection of your own to the same daemon. It names the domain as it was
+// when collected, its ID changes when the domain restarts. Inactive domains,
+// collected with driver.CollectOptions.IncludeInactive and found by every
+// lookup, have no libvirt ID: theirs is hashed from their UUID.
+//
+// Importing the package registers the driver under the name "libvirt",
+// connecting to DefaultURI; driver.NewDriver takes the uri and timeout keys,
+// the fields of Config.
+package libvirt
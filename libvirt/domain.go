@@ -0,0 +1,1153 @@
+//go:build libvirt
+
+package libvirt
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/virtmonitor/driver"
+)
+
+// qemuRunDir Directory where the system libvirtd keeps the pid files of
+// running QEMU domains
+const qemuRunDir = "/run/libvirt/qemu"
+
+// vCPU states as reported by virDomainGetVcpus
+const (
+	vcpuOffline = 0
+	vcpuRunning = 1
+	vcpuBlocked = 2
+)
+
+// collect Collect every active domain, the inactive ones too with
+// opts.IncludeInactive. Domains are queried concurrently, libvirt
+// multiplexes the RPCs over conn.
+func collect(ctx context.Context, conn *golibvirt.Libvirt, runDir string, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	doms, err := listDomains(ctx, conn, opts)
+	if err != nil {
+		return nil, err
+	}
+	return driver.CollectDomains(ctx, opts, doms, collector(conn, runDir, opts, nil))
+}
+
+// collectInto Collect the domains as collect does into dst, reusing
+// its domains. dst is emptied when the listing fails.
+func collectInto(ctx context.Context, conn *golibvirt.Libvirt, runDir string, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	doms, err := listDomains(ctx, conn, opts)
+	if err != nil {
+		clear(dst)
+		return err
+	}
+	return driver.CollectDomainsInto(ctx, opts, doms, dst, func(ctx context.Context, dom golibvirt.Domain, r *driver.Recycler) (*driver.Domain, error) {
+		return collector(conn, runDir, opts, r)(ctx, dom)
+	})
+}
+
+// stream Collect the domains as collect does, emitting each as soon as it's
+// collected
+func stream(ctx context.Context, conn *golibvirt.Libvirt, runDir string, opts driver.CollectOptions, emit func(*driver.Domain) error) error {
+	doms, err := listDomains(ctx, conn, opts)
+	if err != nil {
+		return err
+	}
+	return driver.StreamDomains(ctx, opts, doms, collector(conn, runDir, opts, nil), emit)
+}
+
+// listDomains List the active domains, and the inactive ones with
+// opts.IncludeInactive, within opts.EnumerateTimeout, retried per
+// opts.Retries
+func listDomains(ctx context.Context, conn *golibvirt.Libvirt, opts driver.CollectOptions) ([]golibvirt.Domain, error) {
+	flags := golibvirt.ConnectListDomainsActive
+	if opts.IncludeInactive {
+		flags = 0
+	}
+	return driver.Enumerate(ctx, opts, func(ectx context.Context) ([]golibvirt.Domain, error) {
+		// RPCs can't be cancelled, the timeouts stop waiting for them
+		doms, err := driver.RunContext(ectx, func() ([]golibvirt.Domain, error) {
+			doms, _, err := conn.ConnectListAllDomains(1, flags)
+			return doms, err
+		})
+		if ectx.Err() != nil {
+			return nil, fmt.Errorf("libvirt: listing domains: %w", ectx.Err())
+		}
+		if err != nil {
+			return nil, rpcError(err)
+		}
+		return doms, nil
+	})
+}
+
+// collector Collect function of the domains listed by listDomains, filling
+// the domains of r
+func collector(conn *golibvirt.Libvirt, runDir string, opts driver.CollectOptions, r *driver.Recycler) func(context.Context, golibvirt.Domain) (*driver.Domain, error) {
+	return func(ctx context.Context, dom golibvirt.Domain) (*driver.Domain, error) {
+		// Listed domains carry their identity, filtering costs no RPC
+		if !opts.Keep(dom.Name, formatUUID(dom.UUID), domainID(dom)) {
+			return nil, nil
+		}
+		d, err := driver.RunContext(ctx, func() (*driver.Domain, error) {
+			return collectDomain(conn, runDir, dom, opts, r)
+		})
+		if errors.Is(err, driver.ErrDomainNotFound) {
+			// Stopped since being listed
+			driver.GetLogger().Debug("skipping domain stopped while collecting", "driver", Hypervisor, "domain", dom.Name)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, &driver.DomainError{ID: domainID(dom), Name: dom.Name, Err: err}
+		}
+		return d, nil
+	}
+}
+
+// domainID Domain ID of dom, hashed from its UUID for inactive domains,
+// which libvirt gives ID -1
+func domainID(dom golibvirt.Domain) driver.DomainID {
+	if dom.ID >= 0 {
+		return driver.DomainID(dom.ID)
+	}
+	return driver.HashDomainID(formatUUID(dom.UUID))
+}
+
+// collectDomain Collect a single domain into a domain of r, wrapping errors
+// with driver sentinels
+func collectDomain(conn *golibvirt.Libvirt, runDir string, dom golibvirt.Domain, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	d, err := domain(conn, runDir, dom, opts, r)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return d, nil
+}
+
+func domain(conn *golibvirt.Libvirt, runDir string, dom golibvirt.Domain, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	d := r.Domain(domainID(dom))
+	d.Name, d.UUID, d.ID, d.Hypervisor, d.Time = dom.Name, formatUUID(dom.UUID), domainID(dom), Hypervisor, driver.TimestampNow()
+	d.SetPrivate(dom)
+
+	state, reason, err := conn.DomainGetState(dom, 0)
+	if err != nil {
+		return nil, err
+	}
+	d.Flags = jobFlag(conn, dom, golibvirt.DomainState(state), reason)
+
+	if d.OSType, err = conn.DomainGetOsType(dom); err != nil {
+		return nil, err
+	}
+
+	persistent, err := conn.DomainIsPersistent(dom)
+	if err != nil {
+		return nil, err
+	}
+	d.Persistent, d.PersistentSet = persistent == 1, true
+	// Transient domains have no autostart setting
+	if d.Persistent {
+		autostart, err := conn.DomainGetAutostart(dom)
+		if err != nil {
+			return nil, err
+		}
+		d.Autostart = autostart == 1
+	}
+	d.AutostartSet = true
+	// Inactive domains end here, Skips covers them
+	if opts.Skips(d.Flags) {
+		return d, nil
+	}
+
+	// The XML is fetched once, for whichever categories need it
+	var x *domainXML
+	if opts.Metadata || opts.HostDevices || opts.NUMA || opts.Versions || opts.CPUs || opts.Blocks || opts.Interfaces || opts.Graphics || opts.Memory || opts.Filesystems {
+		desc, err := domainXMLDesc(conn, dom, opts.Graphics)
+		if err != nil {
+			return nil, err
+		}
+		if x, err = parseDomainXML(desc); err != nil {
+			return nil, err
+		}
+	}
+
+	// Metadata is configuration
+	if opts.Metadata {
+		d.Title, d.Description, d.Labels = x.Title, x.Description, x.labels()
+	}
+	// So are host devices
+	if opts.HostDevices {
+		d.HostDevices = collectHostDevices(conn, x)
+	}
+	// And the NUMA topology, the live placement is read below
+	if opts.NUMA {
+		d.NUMA = x.numa()
+	}
+	// And the machine type
+	if opts.Versions {
+		d.MachineType = x.OS.Type.Machine
+	}
+
+	d.StartTime = startTime(runDir, dom.Name)
+
+	if opts.Versions {
+		if d.EmulatorVersion, err = emulatorVersion(conn); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.CPUs {
+		if err = collectCPUs(conn, dom, d, opts.Pinning, opts.NUMA, !opts.TopologyOnly); err != nil {
+			return nil, err
+		}
+		d.NestedVirt, d.NestedVirtSet = x.nestedVirt()
+		d.Topology = x.topology()
+		if err = collectThreads(conn, runDir, dom, d, opts.Pinning); err != nil {
+			return nil, err
+		}
+	}
+
+	if x != nil {
+		// The options the guest agent backs, reporting whether it answers
+		if opts.CPUs || opts.Addresses || opts.Filesystems {
+			d.GuestAgent = x.guestAgent()
+		}
+
+		if opts.Graphics {
+			d.Graphics, d.Consoles = collectGraphics(x)
+		}
+
+		if opts.Blocks {
+			if d.Blocks, err = collectBlocks(conn, dom, x, d.Blocks, opts.BlockCapacity, opts.BackingChain, opts.Limits, !opts.TopologyOnly); err != nil {
+				return nil, err
+			}
+		}
+		if opts.Interfaces {
+			if d.Interfaces, err = collectInterfaces(conn, dom, x, d.Interfaces, opts.Limits, runDir != "", !opts.TopologyOnly); err != nil {
+				return nil, err
+			}
+			if opts.Addresses {
+				collectAddresses(conn, dom, d.Interfaces)
+			}
+		}
+	}
+
+	if opts.Memory {
+		if d.Memory, err = collectMemory(conn, dom); err != nil {
+			return nil, err
+		}
+		if d.MemoryBacking, err = collectMemoryBacking(conn, dom, x); err != nil {
+			return nil, err
+		}
+		if opts.DirtyRate {
+			collectDirtyRate(conn, dom, &d.Memory)
+		}
+	}
+
+	if opts.Filesystems {
+		d.Filesystems = collectFilesystems(conn, dom)
+	}
+
+	if opts.CPUTuning {
+		if d.CPUTuning, err = collectCPUTuning(conn, dom); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.NUMA {
+		if err = collectNUMAPlacement(conn, dom, &d.NUMA); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d, nil
+}
+
+// startTime Start time of the QEMU process running a domain, found through
+// its pid file. 0 for other hypervisors, remote hosts or when the file can't
+// be read.
+func startTime(runDir, name string) driver.Timestamp {
+	pid, ok := qemuPID(runDir, name)
+	if !ok {
+		return 0
+	}
+	t, _ := driver.ProcessStartTime(pid)
+	return t
+}
+
+// domainXMLDesc Live domain XML. The secure XML, which needs a read-write
+// connection, is the only one telling whether graphics have a password,
+// without it they are reported without one.
+func domainXMLDesc(conn *golibvirt.Libvirt, dom golibvirt.Domain, secure bool) (string, error) {
+	if secure {
+		desc, err := conn.DomainGetXMLDesc(dom, golibvirt.DomainXMLSecure)
+		if err == nil {
+			return desc, nil
+		}
+		driver.GetLogger().Debug("secure domain XML unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+	}
+	return conn.DomainGetXMLDesc(dom, 0)
+}
+
+// collectGraphics Graphical consoles and serial console paths from the
+// domain XML. Ports are -1 until allocated.
+func collectGraphics(x *domainXML) ([]driver.GraphicsDevice, []string) {
+	var graphics []driver.GraphicsDevice
+	for _, g := range x.Devices.Graphics {
+		if g.Type != "vnc" && g.Type != "spice" {
+			continue
+		}
+		graphics = append(graphics, driver.GraphicsDevice{
+			Type:     g.Type,
+			Listen:   g.listen(),
+			Port:     g.Port,
+			TLSPort:  g.TLSPort,
+			Password: g.Passwd != nil && *g.Passwd != "",
+		})
+	}
+
+	var consoles []string
+	for _, c := range x.Devices.Consoles {
+		if path := c.path(); path != "" {
+			consoles = append(consoles, path)
+		}
+	}
+	return graphics, consoles
+}
+
+// collectHostDevices Devices passed through to the domain, PCI devices
+// described from their node device when it can be read
+func collectHostDevices(conn *golibvirt.Libvirt, x *domainXML) []driver.HostDevice {
+	var devs []driver.HostDevice
+	for _, h := range x.Devices.HostDevs {
+		dev, ok := h.device()
+		if !ok {
+			continue
+		}
+		if dev.Type == "pci" {
+			// Node devices are named after the address, pci_0000_06_02_0
+			name := "pci_" + strings.NewReplacer(":", "_", ".", "_").Replace(dev.Address)
+			if desc, err := conn.NodeDeviceGetXMLDesc(name, 0); err != nil {
+				driver.GetLogger().Debug("node device unavailable", "driver", Hypervisor, "device", name, "error", err)
+			} else if n, err := parseNodeDeviceXML(desc); err == nil {
+				n.describe(&dev)
+			}
+		}
+		devs = append(devs, dev)
+	}
+	return devs
+}
+
+// collectFilesystems Guest file systems from guest-get-fsinfo, through
+// libvirt's guest info. Without a reachable agent there are none, that isn't
+// an error.
+func collectFilesystems(conn *golibvirt.Libvirt, dom golibvirt.Domain) []driver.Filesystem {
+	params, err := conn.DomainGetGuestInfo(dom, uint32(golibvirt.DomainGuestInfoFilesystem), 0)
+	if err != nil {
+		driver.GetLogger().Debug("guest file systems unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+		return nil
+	}
+
+	values, strs := typedParams(params), stringParams(params)
+	count := int(values["fs.count"])
+	fss := make([]driver.Filesystem, 0, count)
+	for i := 0; i < count; i++ {
+		prefix := "fs." + strconv.Itoa(i) + "."
+		fss = append(fss, driver.Filesystem{
+			Mountpoint: strs[prefix+"mountpoint"],
+			Name:       strs[prefix+"name"],
+			Type:       strs[prefix+"fstype"],
+			TotalBytes: values[prefix+"total-bytes"],
+			UsedBytes:  values[prefix+"used-bytes"],
+		})
+	}
+	return fss
+}
+
+// collectVCPUCounts Hotplug maximum and online vCPUs. The guest agent knows
+// which vCPUs the guest took offline, without it the vCPUs plugged in count
+// as online.
+func collectVCPUCounts(conn *golibvirt.Libvirt, dom golibvirt.Domain, d *driver.Domain) error {
+	maximum, err := conn.DomainGetVcpusFlags(dom, uint32(golibvirt.DomainVCPULive|golibvirt.DomainVCPUMaximum))
+	if err != nil {
+		return err
+	}
+	d.VCPUsMaximum = int(maximum)
+
+	current, err := conn.DomainGetVcpusFlags(dom, uint32(golibvirt.DomainVCPUGuest))
+	if err != nil {
+		driver.GetLogger().Debug("guest vCPU count unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+		if current, err = conn.DomainGetVcpusFlags(dom, uint32(golibvirt.DomainVCPULive)); err != nil {
+			return err
+		}
+	}
+	d.VCPUsCurrent = int(current)
+	return nil
+}
+
+// collectCPUs vCPUs of a running domain. Their affinity is read for pinning,
+// and for numa to find the host NUMA node each is pinned to. The wait times
+// are only queried with stats.
+func collectCPUs(conn *golibvirt.Libvirt, dom golibvirt.Domain, d *driver.Domain, pinning, numa, stats bool) error {
+	_, _, _, nrVirtCPU, _, err := conn.DomainGetInfo(dom)
+	if err != nil {
+		return err
+	}
+	d.VCPUs = int(nrVirtCPU)
+	if err = collectVCPUCounts(conn, dom, d); err != nil {
+		return err
+	}
+
+	// Affinity maps hold one bit per host CPU for every vCPU
+	var maplen int32
+	if pinning || numa {
+		_, _, hostCPUs, _, _, _, _, _, err := conn.NodeGetInfo()
+		if err != nil {
+			return err
+		}
+		maplen = (hostCPUs + 7) / 8
+	}
+	var nodes map[int]driver.CPUSet
+	if numa {
+		if nodes, err = hostNodes(conn); err != nil {
+			return err
+		}
+	}
+
+	vcpus, cpumaps, err := conn.DomainGetVcpus(dom, int32(nrVirtCPU), maplen)
+	if err != nil {
+		return err
+	}
+
+	d.Cpus = slices.Grow(d.Cpus[:0], len(vcpus))
+	for i, vcpu := range vcpus {
+		cpu := driver.CPU{
+			ID:   uint64(vcpu.Number),
+			Time: float64(vcpu.CPUTime),
+		}
+
+		switch {
+		case d.Flags == driver.DomainPaused:
+			cpu.Flags = driver.CPUPaused
+		case vcpu.State == vcpuRunning:
+			cpu.Flags = driver.CPURunning
+		case vcpu.State == vcpuBlocked:
+			cpu.Flags = driver.CPUHalted
+		case vcpu.State == vcpuOffline:
+			cpu.Flags = driver.CPUPaused
+		default:
+			cpu.Flags = driver.CPUOnline
+		}
+
+		if vcpu.CPU >= 0 {
+			cpu.PhysicalCPU, cpu.PhysicalCPUSet = int(vcpu.CPU), true
+		}
+
+		if m := int(maplen); m > 0 && len(cpumaps) >= (i+1)*m {
+			affinity := cpuSet(cpumaps[i*m : (i+1)*m])
+			if pinning {
+				cpu.Affinity = affinity
+			}
+			cpu.NUMANode, cpu.NUMANodeSet = driver.PinnedNode(affinity, nodes)
+		}
+
+		d.Cpus = append(d.Cpus, cpu)
+	}
+	if stats {
+		collectCPUWaits(conn, dom, d.Cpus)
+	}
+	return nil
+}
+
+// agentCPUStats Reply of guest-get-cpustats, times in milliseconds
+type agentCPUStats struct {
+	Return []struct {
+		CPU    uint64  `json:"cpu"`
+		Idle   *uint64 `json:"idle"`
+		IOWait *uint64 `json:"iowait"`
+		Steal  *uint64 `json:"steal"`
+	} `json:"return"`
+}
+
+// collectCPUWaits Fill in steal time from the host scheduler, which libvirt
+// reports as the run queue delay of each vCPU thread, and idle and IO wait
+// times from the guest agent. Steal time seen by the guest is only used when
+// the host has none. Neither being available isn't an error.
+func collectCPUWaits(conn *golibvirt.Libvirt, dom golibvirt.Domain, cpus []driver.CPU) {
+	index := make(map[uint64]int, len(cpus))
+	for i := range cpus {
+		index[cpus[i].ID] = i
+	}
+
+	records, err := conn.ConnectGetAllDomainStats([]golibvirt.Domain{dom}, uint32(golibvirt.DomainStatsVCPU), 0)
+	if err != nil {
+		driver.GetLogger().Debug("vCPU delay unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+	}
+	for _, record := range records {
+		values := typedParams(record.Params)
+		for id, i := range index {
+			if delay, ok := values["vcpu."+strconv.FormatUint(id, 10)+".delay"]; ok {
+				cpus[i].Steal, cpus[i].StealSet = float64(delay), true
+			}
+		}
+	}
+
+	reply, err := conn.QEMUDomainAgentCommand(dom, `{"execute":"guest-get-cpustats"}`, int32(golibvirt.DomainAgentResponseTimeoutDefault), 0)
+	if err != nil || len(reply) == 0 {
+		driver.GetLogger().Debug("guest CPU statistics unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+		return
+	}
+	var stats agentCPUStats
+	if err := json.Unmarshal([]byte(reply[0]), &stats); err != nil {
+		driver.GetLogger().Debug("guest CPU statistics unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+		return
+	}
+
+	ms := float64(time.Millisecond)
+	for _, s := range stats.Return {
+		i, ok := index[s.CPU]
+		if !ok {
+			continue
+		}
+		if s.Idle != nil {
+			cpus[i].Idle, cpus[i].IdleSet = float64(*s.Idle)*ms, true
+		}
+		if s.IOWait != nil {
+			cpus[i].IOWait, cpus[i].IOWaitSet = float64(*s.IOWait)*ms, true
+		}
+		if s.Steal != nil && !cpus[i].StealSet {
+			cpus[i].Steal, cpus[i].StealSet = float64(*s.Steal)*ms, true
+		}
+	}
+}
+
+// cpuSet Convert a libvirt cpumap, one bit per host CPU, to a CPUSet
+func cpuSet(cpumap []byte) driver.CPUSet {
+	var set driver.CPUSet
+	for i, b := range cpumap {
+		for bit := 0; bit < 8; bit++ {
+			if b&(1<<uint(bit)) != 0 {
+				set.Set(i*8 + bit)
+			}
+		}
+	}
+	return set
+}
+
+// collectBlocks Disks of the live XML, appended to blocks emptied so that its
+// memory is reused
+func collectBlocks(conn *golibvirt.Libvirt, dom golibvirt.Domain, x *domainXML, blocks []driver.BlockDevice, capacity, chain, limits, stats bool) ([]driver.BlockDevice, error) {
+	var (
+		stalled map[string]bool
+		err     error
+	)
+	if stats {
+		if stalled, err = diskErrors(conn, dom, len(x.Devices.Disks)); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		weight  uint64
+		weights map[string]uint64
+	)
+	if limits {
+		// Without a blkio or io cgroup controller there is no weight
+		if weight, weights, err = blkioWeights(conn, dom); err != nil {
+			driver.GetLogger().Debug("IO weights unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+		}
+	}
+
+	blocks = slices.Grow(blocks[:0], len(x.Devices.Disks))
+	for _, disk := range x.Devices.Disks {
+		if disk.Target.Dev == "" {
+			continue
+		}
+
+		block := driver.BlockDevice{
+			Name:     disk.Target.Dev,
+			ReadOnly: disk.ReadOnly != nil,
+			IsDisk:   disk.Device == "" || disk.Device == "disk",
+			IsCDrom:  disk.Device == "cdrom",
+			Bus:      disk.Target.Bus,
+			Target:   disk.Target.Dev,
+			Source:   disk.source(),
+			Stalled:  stalled[disk.Target.Dev],
+		}
+		if chain {
+			block.BackingChain = disk.backingChain()
+		}
+
+		if stats {
+			params, err := blockStats(conn, dom, disk.Target.Dev)
+			if err != nil {
+				return nil, err
+			}
+
+			block.Read = blockIO(params["rd_operations"], params["rd_bytes"])
+			block.Write = blockIO(params["wr_operations"], params["wr_bytes"])
+			block.Flush = blockIO(params["flush_operations"], 0)
+			// Timings are only reported by some hypervisors, QEMU among them
+			for field, io := range map[string]*driver.BlockIO{
+				"rd_total_times":    &block.Read,
+				"wr_total_times":    &block.Write,
+				"flush_total_times": &block.Flush,
+			} {
+				io.TotalTime, io.TotalTimeSet = params[field]
+			}
+		}
+
+		if capacity {
+			block.Allocation, block.Capacity, block.Physical, err = conn.DomainGetBlockInfo(dom, disk.Target.Dev, 0)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if limits {
+			if block.Limits, err = blockLimits(conn, dom, disk.Target.Dev); err != nil {
+				return nil, err
+			}
+			block.Limits.Weight = weight
+			if w, ok := weights[block.Source]; ok {
+				block.Limits.Weight = w
+			}
+		}
+
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// diskErrors Targets of the disks in an IO error state, such as a full
+// backing store. Hypervisors that don't track disk errors report none.
+func diskErrors(conn *golibvirt.Libvirt, dom golibvirt.Domain, disks int) (map[string]bool, error) {
+	if disks == 0 {
+		return nil, nil
+	}
+	// Only disks with an error are listed
+	errs, _, err := conn.DomainGetDiskErrors(dom, uint32(disks), 0)
+	if err != nil {
+		var lerr golibvirt.Error
+		if errors.As(err, &lerr) && golibvirt.ErrorNumber(lerr.Code) == golibvirt.ErrNoSupport {
+			return nil, nil
+		}
+		return nil, err
+	}
+	stalled := make(map[string]bool, len(errs))
+	for _, e := range errs {
+		stalled[e.Disk] = true
+	}
+	return stalled, nil
+}
+
+// blockStats Fetch the typed block statistics for a device
+func blockStats(conn *golibvirt.Libvirt, dom golibvirt.Domain, dev string) (map[string]uint64, error) {
+	_, nparams, err := conn.DomainBlockStatsFlags(dom, dev, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	params, _, err := conn.DomainBlockStatsFlags(dom, dev, nparams, 0)
+	if err != nil {
+		return nil, err
+	}
+	return typedParams(params), nil
+}
+
+// blockLimits Fetch the IO tuning of a device
+func blockLimits(conn *golibvirt.Libvirt, dom golibvirt.Domain, dev string) (driver.BlockLimits, error) {
+	_, nparams, err := conn.DomainGetBlockIOTune(dom, golibvirt.OptString{dev}, 0, 0)
+	if err != nil {
+		return driver.BlockLimits{}, err
+	}
+	params, _, err := conn.DomainGetBlockIOTune(dom, golibvirt.OptString{dev}, nparams, 0)
+	if err != nil {
+		return driver.BlockLimits{}, err
+	}
+
+	values := typedParams(params)
+	return driver.BlockLimits{
+		ReadIOPS:      values["read_iops_sec"],
+		WriteIOPS:     values["write_iops_sec"],
+		TotalIOPS:     values["total_iops_sec"],
+		ReadBytesSec:  values["read_bytes_sec"],
+		WriteBytesSec: values["write_bytes_sec"],
+		TotalBytesSec: values["total_bytes_sec"],
+	}, nil
+}
+
+// blkioWeights Fetch the IO weight of the domain and the weights overriding
+// it per host device path, device_weight listing them as
+// "path,weight,path,weight"
+func blkioWeights(conn *golibvirt.Libvirt, dom golibvirt.Domain) (uint64, map[string]uint64, error) {
+	_, nparams, err := conn.DomainGetBlkioParameters(dom, 0, 0)
+	if err != nil {
+		return 0, nil, err
+	}
+	params, _, err := conn.DomainGetBlkioParameters(dom, nparams, 0)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	weights := make(map[string]uint64)
+	fields := strings.Split(stringParams(params)["device_weight"], ",")
+	for i := 0; i+1 < len(fields); i += 2 {
+		if w, err := strconv.ParseUint(fields[i+1], 10, 64); err == nil && w > 0 {
+			weights[fields[i]] = w
+		}
+	}
+	return typedParams(params)["weight"], weights, nil
+}
+
+func blockIO(ops, bytes uint64) driver.BlockIO {
+	return driver.BlockIO{
+		Operations: ops,
+		Bytes:      bytes,
+		Sectors:    bytes / 512,
+		Absolute:   true,
+	}
+}
+
+// collectInterfaces Interfaces named after their host device. Bridges of
+// local domains are looked up on the host, those of remote ones taken from
+// the live XML, which carries the bridge of libvirt networks too. They're
+// appended to ifaces emptied, as collectBlocks does.
+func collectInterfaces(conn *golibvirt.Libvirt, dom golibvirt.Domain, x *domainXML, ifaces []driver.NetworkInterface, limits, local, stats bool) ([]driver.NetworkInterface, error) {
+	ifaces = slices.Grow(ifaces[:0], len(x.Devices.Interfaces))
+	for _, ifx := range x.Devices.Interfaces {
+		if ifx.Target.Dev == "" {
+			continue
+		}
+
+		iface := driver.NetworkInterface{
+			Name:       ifx.Target.Dev,
+			HostDevice: ifx.Target.Dev,
+		}
+		if mac, err := net.ParseMAC(ifx.MAC.Address); err == nil {
+			iface.Mac = mac
+		}
+		if local {
+			iface.Bridges = driver.HostBridges(ifx.Target.Dev)
+		}
+		if len(iface.Bridges) == 0 && ifx.Source.Bridge != "" {
+			iface.Bridges = []string{ifx.Source.Bridge}
+		}
+		// Links are up unless explicitly configured down
+		iface.LinkUp = ifx.Link == nil || ifx.Link.State != "down"
+		iface.LinkUpSet = true
+		// Bandwidth averages are in kilobytes per second
+		if limits && ifx.Bandwidth != nil {
+			if ifx.Bandwidth.Inbound != nil {
+				iface.InboundLimit = ifx.Bandwidth.Inbound.Average * 1000
+			}
+			if ifx.Bandwidth.Outbound != nil {
+				iface.OutboundLimit = ifx.Bandwidth.Outbound.Average * 1000
+			}
+		}
+
+		if stats {
+			rxBytes, rxPackets, rxErrs, rxDrop, txBytes, txPackets, txErrs, txDrop, err := conn.DomainInterfaceStats(dom, ifx.Target.Dev)
+			if err != nil {
+				return nil, err
+			}
+			iface.RX = networkIO(rxBytes, rxPackets, rxErrs, rxDrop)
+			iface.TX = networkIO(txBytes, txPackets, txErrs, txDrop)
+		}
+
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+// collectAddresses Fill in guest addresses from the guest agent, falling
+// back to DHCP leases. Interfaces are left without addresses when neither
+// source is available, that isn't an error.
+func collectAddresses(conn *golibvirt.Libvirt, dom golibvirt.Domain, ifaces []driver.NetworkInterface) {
+	guest, err := conn.DomainInterfaceAddresses(dom, uint32(golibvirt.DomainInterfaceAddressesSrcAgent), 0)
+	if err != nil {
+		driver.GetLogger().Debug("guest agent addresses unavailable, trying leases", "driver", Hypervisor, "domain", dom.Name, "error", err)
+		if guest, err = conn.DomainInterfaceAddresses(dom, uint32(golibvirt.DomainInterfaceAddressesSrcLease), 0); err != nil {
+			driver.GetLogger().Debug("lease addresses unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+			return
+		}
+	}
+
+	byMAC := make(map[string][]net.IPNet, len(guest))
+	for _, g := range guest {
+		if len(g.Hwaddr) == 0 {
+			continue
+		}
+		mac, err := net.ParseMAC(g.Hwaddr[0])
+		if err != nil {
+			continue
+		}
+		for _, a := range g.Addrs {
+			ip := net.ParseIP(a.Addr)
+			if ip == nil {
+				continue
+			}
+			bits := 128
+			if golibvirt.IPAddrType(a.Type) == golibvirt.IPAddrTypeIpv4 {
+				ip, bits = ip.To4(), 32
+			}
+			byMAC[mac.String()] = append(byMAC[mac.String()], net.IPNet{IP: ip, Mask: net.CIDRMask(int(a.Prefix), bits)})
+		}
+	}
+
+	for i := range ifaces {
+		ifaces[i].Addresses = byMAC[ifaces[i].Mac.String()]
+	}
+}
+
+// emulatorVersion Version of the hypervisor of the connection, such as QEMU
+// 8.2.0, which libvirt encodes as major * 1,000,000 + minor * 1,000 + micro
+func emulatorVersion(conn *golibvirt.Libvirt) (string, error) {
+	v, err := conn.ConnectGetVersion()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d.%d.%d", v/1000000, v/1000%1000, v%1000), nil
+}
+
+// networkIO Build a NetworkIO, libvirt reports -1 for unsupported counters
+func networkIO(bytes, packets, errs, drops int64) driver.NetworkIO {
+	return driver.NetworkIO{
+		Bytes:   counter(bytes),
+		Packets: counter(packets),
+		Errors:  counter(errs),
+		Drops:   counter(drops),
+	}
+}
+
+func counter(v int64) uint64 {
+	if v < 0 {
+		return 0
+	}
+	return uint64(v)
+}
+
+func collectMemory(conn *golibvirt.Libvirt, dom golibvirt.Domain) (mem driver.Memory, err error) {
+	stats, err := conn.DomainMemoryStats(dom, uint32(golibvirt.DomainMemoryStatNr), 0)
+	if err != nil {
+		return
+	}
+
+	// Sizes are reported in KiB
+	for _, stat := range stats {
+		switch golibvirt.DomainMemoryStatTags(stat.Tag) {
+		case golibvirt.DomainMemoryStatActualBalloon:
+			mem.Actual, mem.ActualSet = stat.Val*1024, true
+		case golibvirt.DomainMemoryStatAvailable:
+			mem.Available, mem.AvailableSet = stat.Val*1024, true
+		case golibvirt.DomainMemoryStatUnused:
+			mem.Unused, mem.UnusedSet = stat.Val*1024, true
+		case golibvirt.DomainMemoryStatRss:
+			mem.RSS, mem.RSSSet = stat.Val*1024, true
+		case golibvirt.DomainMemoryStatSwapIn:
+			mem.SwapIn, mem.SwapInSet = stat.Val*1024, true
+		case golibvirt.DomainMemoryStatSwapOut:
+			mem.SwapOut, mem.SwapOutSet = stat.Val*1024, true
+		case golibvirt.DomainMemoryStatMajorFault:
+			mem.MajorFaults, mem.MajorFaultsSet = stat.Val, true
+		case golibvirt.DomainMemoryStatMinorFault:
+			mem.MinorFaults, mem.MinorFaultsSet = stat.Val, true
+		}
+	}
+	return
+}
+
+// dirtyRatePeriod Seconds a dirty rate measurement lasts, libvirt's minimum
+const dirtyRatePeriod = 1
+
+// Values of dirtyrate.calc_status
+const (
+	dirtyRateMeasuring = 1
+	dirtyRateMeasured  = 2
+)
+
+// collectDirtyRate Measure the rate the guest dirties its memory: start a
+// measurement and poll its status until QEMU reports the rate, in MiB per
+// second. Hypervisors without the measurement, which needs libvirt 7.2 and
+// QEMU 5.2, leave it unset; that isn't an error.
+func collectDirtyRate(conn *golibvirt.Libvirt, dom golibvirt.Domain, mem *driver.Memory) {
+	if err := conn.DomainStartDirtyRateCalc(dom, dirtyRatePeriod, 0); err != nil {
+		driver.GetLogger().Debug("dirty rate unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+		return
+	}
+
+	time.Sleep(dirtyRatePeriod * time.Second)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		records, err := conn.ConnectGetAllDomainStats([]golibvirt.Domain{dom}, uint32(golibvirt.DomainStatsDirtyrate), 0)
+		if err != nil {
+			driver.GetLogger().Debug("dirty rate unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+			return
+		}
+		status := uint64(dirtyRateMeasuring)
+		for _, record := range records {
+			values := typedParams(record.Params)
+			status = values["dirtyrate.calc_status"]
+			if status == dirtyRateMeasured {
+				// MiB to 4 KiB pages
+				mem.DirtyRate, mem.DirtyRateSet = values["dirtyrate.megabytes_per_second"]*256, true
+				return
+			}
+		}
+		if status != dirtyRateMeasuring || time.Now().After(deadline) {
+			driver.GetLogger().Debug("dirty rate not measured", "driver", Hypervisor, "domain", dom.Name, "status", status)
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// collectMemoryBacking Balloon sizes of dom and its hugepage backing
+func collectMemoryBacking(conn *golibvirt.Libvirt, dom golibvirt.Domain, x *domainXML) (b driver.MemoryBacking, err error) {
+	_, maxMem, memory, _, _, err := conn.DomainGetInfo(dom)
+	if err != nil {
+		return
+	}
+
+	// Sizes are reported in KiB
+	b.BalloonCurrent, b.BalloonMaximum = memory*1024, maxMem*1024
+	b.Hugepages, b.HugepageSize = x.hugepages()
+	return
+}
+
+// infiniteQuota Quota libvirt reports for unlimited bandwidth, next to -1
+const infiniteQuota = 17592186044415
+
+// collectCPUTuning Scheduler parameters of a running domain. Hypervisors
+// without scheduler parameters leave the tuning unset.
+func collectCPUTuning(conn *golibvirt.Libvirt, dom golibvirt.Domain) (t driver.CPUTuning, err error) {
+	_, nparams, err := conn.DomainGetSchedulerType(dom)
+	if err != nil {
+		var lerr golibvirt.Error
+		if errors.As(err, &lerr) && golibvirt.ErrorNumber(lerr.Code) == golibvirt.ErrNoSupport {
+			return t, nil
+		}
+		return t, err
+	}
+	params, err := conn.DomainGetSchedulerParametersFlags(dom, nparams, 0)
+	if err != nil {
+		return t, err
+	}
+
+	values := typedParams(params)
+	t.Shares, t.Weight = values["cpu_shares"], values["weight"]
+	t.Period, t.GlobalPeriod = values["vcpu_period"], values["global_period"]
+	t.EmulatorPeriod, t.IOThreadPeriod = values["emulator_period"], values["iothread_period"]
+
+	// Quotas are signed, typedParams clamps them
+	for _, p := range params {
+		v, ok := p.Value.I.(int64)
+		if !ok {
+			continue
+		}
+		if v < 0 || v >= infiniteQuota {
+			v = -1
+		}
+		switch p.Field {
+		case "vcpu_quota":
+			t.Quota = v
+		case "global_quota":
+			t.GlobalQuota = v
+		case "emulator_quota":
+			t.EmulatorQuota = v
+		case "iothread_quota":
+			t.IOThreadQuota = v
+		}
+	}
+	return t, nil
+}
+
+// hostNodes CPUs of every NUMA node of the host libvirtd runs on, from its
+// capabilities
+func hostNodes(conn *golibvirt.Libvirt) (map[int]driver.CPUSet, error) {
+	desc, err := conn.ConnectGetCapabilities()
+	if err != nil {
+		return nil, err
+	}
+	caps, err := parseCapabilitiesXML(desc)
+	if err != nil {
+		return nil, err
+	}
+	return caps.nodes(), nil
+}
+
+// numaModes Names of the numa_mode values
+var numaModes = map[golibvirt.DomainNumatuneMemMode]string{
+	golibvirt.DomainNumatuneMemStrict:      "strict",
+	golibvirt.DomainNumatuneMemPreferred:   "preferred",
+	golibvirt.DomainNumatuneMemInterleave:  "interleave",
+	golibvirt.DomainNumatuneMemRestrictive: "restrictive",
+}
+
+// collectNUMAPlacement Memory placement of a running domain, which differs
+// from the XML when it was changed live or placed automatically by numad.
+// Hypervisors without NUMA parameters leave the placement of the XML.
+func collectNUMAPlacement(conn *golibvirt.Libvirt, dom golibvirt.Domain, n *driver.NUMA) error {
+	_, nparams, err := conn.DomainGetNumaParameters(dom, 0, 0)
+	if err == nil && nparams > 0 {
+		var params []golibvirt.TypedParam
+		if params, _, err = conn.DomainGetNumaParameters(dom, nparams, 0); err == nil {
+			numaParams(params, n)
+		}
+	}
+	var lerr golibvirt.Error
+	if errors.As(err, &lerr) && golibvirt.ErrorNumber(lerr.Code) == golibvirt.ErrNoSupport {
+		return nil
+	}
+	return err
+}
+
+// numaParams Apply the numa_mode and numa_nodeset parameters, an empty
+// nodeset leaves memory unbound
+func numaParams(params []golibvirt.TypedParam, n *driver.NUMA) {
+	var mode string
+	var nodes driver.CPUSet
+	for _, p := range params {
+		switch p.Field {
+		case "numa_mode":
+			if v, ok := p.Value.I.(int32); ok {
+				mode = numaModes[golibvirt.DomainNumatuneMemMode(v)]
+			}
+		case "numa_nodeset":
+			if v, ok := p.Value.I.(string); ok {
+				nodes, _ = driver.ParseCPUSet(v)
+			}
+		}
+	}
+	if nodes.Count() == 0 {
+		return
+	}
+	n.Mode, n.Nodes = mode, nodes
+}
+
+// typedParams Flatten numeric typed parameters into a map
+func typedParams(params []golibvirt.TypedParam) map[string]uint64 {
+	values := make(map[string]uint64, len(params))
+	for _, p := range params {
+		switch v := p.Value.I.(type) {
+		case int32:
+			values[p.Field] = counter(int64(v))
+		case uint32:
+			values[p.Field] = uint64(v)
+		case int64:
+			values[p.Field] = counter(v)
+		case uint64:
+			values[p.Field] = v
+		}
+	}
+	return values
+}
+
+// stringParams String valued typed parameters
+func stringParams(params []golibvirt.TypedParam) map[string]string {
+	values := make(map[string]string)
+	for _, p := range params {
+		if v, ok := p.Value.I.(string); ok {
+			values[p.Field] = v
+		}
+	}
+	return values
+}
+
+// domainFlag Map a libvirt domain state onto a DomainFlag
+func domainFlag(state golibvirt.DomainState) driver.DomainFlag {
+	switch state {
+	case golibvirt.DomainRunning, golibvirt.DomainBlocked:
+		return driver.DomainOnline
+	case golibvirt.DomainPaused, golibvirt.DomainPmsuspended:
+		return driver.DomainPaused
+	case golibvirt.DomainShutdown:
+		return driver.DomainDying
+	case golibvirt.DomainCrashed:
+		return driver.DomainCrashed
+	default:
+		return driver.DomainShutdown
+	}
+}
+
+// jobFlag DomainFlag of a domain, telling migrating, saving and restoring
+// domains, and the direction of migrations, from the state reason of paused
+// ones and the job of running ones, the job of paused migrating ones. Job
+// statistics don't wait for the job to finish, a failure to get them
+// leaves the state as it is.
+func jobFlag(conn *golibvirt.Libvirt, dom golibvirt.Domain, state golibvirt.DomainState, reason int32) driver.DomainFlag {
+	flag := domainFlag(state)
+	switch {
+	case state == golibvirt.DomainPaused:
+		switch golibvirt.DomainPausedReason(reason) {
+		case golibvirt.DomainPausedMigration, golibvirt.DomainPausedPostcopy:
+			// The job tells the direction, and restores, which libvirt
+			// runs as incoming migrations from the saved file
+			if job := jobOperation(conn, dom); job != driver.DomainOnline {
+				return job
+			}
+			return driver.DomainMigrating
+		case golibvirt.DomainPausedSave:
+			return driver.DomainSaving
+		}
+		return flag
+	case flag != driver.DomainOnline:
+		return flag
+	}
+
+	if job := jobOperation(conn, dom); job != driver.DomainOnline {
+		return job
+	}
+	return flag
+}
+
+// jobOperation State of the migration, save or restore job running on dom,
+// DomainOnline when none of them is or the job can't be read
+func jobOperation(conn *golibvirt.Libvirt, dom golibvirt.Domain) driver.DomainFlag {
+	typ, params, err := conn.DomainGetJobStats(dom, 0)
+	if err != nil {
+		driver.GetLogger().Debug("job statistics unavailable", "driver", Hypervisor, "domain", dom.Name, "error", err)
+		return driver.DomainOnline
+	}
+	if golibvirt.DomainJobType(typ) == golibvirt.DomainJobNone {
+		return driver.DomainOnline
+	}
+	operation, ok := typedParams(params)[golibvirt.DomainJobOperationStr]
+	if !ok {
+		return driver.DomainOnline
+	}
+	switch golibvirt.DomainJobOperation(operation) {
+	case golibvirt.DomainJobOperationStrMigrationIn:
+		return driver.DomainMigratingIn
+	case golibvirt.DomainJobOperationStrMigrationOut:
+		return driver.DomainMigratingOut
+	case golibvirt.DomainJobOperationStrSave:
+		return driver.DomainSaving
+	case golibvirt.DomainJobOperationStrRestore:
+		return driver.DomainRestoring
+	}
+	return driver.DomainOnline
+}
+
+func parseUUID(s string) (u golibvirt.UUID, err error) {
+	n, err := driver.NormalizeUUID(s)
+	if err != nil {
+		return u, fmt.Errorf("libvirt: %q: %w", s, driver.ErrInvalidUUID)
+	}
+	b, err := hex.DecodeString(strings.ReplaceAll(n, "-", ""))
+	if err != nil {
+		return u, fmt.Errorf("libvirt: %q: %w", s, driver.ErrInvalidUUID)
+	}
+	copy(u[:], b)
+	return u, nil
+}
+
+func formatUUID(u golibvirt.UUID) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,54 @@
+//go:build libvirt
+
+package libvirt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/virtmonitor/driver"
+)
+
+// connectError Wrap a failure to reach libvirtd with a driver sentinel
+func connectError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("libvirt: %w: %w", driver.ErrPermissionDenied, err)
+	}
+	return fmt.Errorf("libvirt: %w: %w", driver.ErrHypervisorUnavailable, err)
+}
+
+// rpcError Wrap an RPC failure with the matching driver sentinel
+func rpcError(err error) error {
+	var lerr golibvirt.Error
+	if !errors.As(err, &lerr) {
+		if disconnected(err) {
+			return fmt.Errorf("libvirt: %w: %w", driver.ErrHypervisorUnavailable, err)
+		}
+		return fmt.Errorf("libvirt: %w", err)
+	}
+
+	switch golibvirt.ErrorNumber(lerr.Code) {
+	case golibvirt.ErrNoDomain:
+		return fmt.Errorf("libvirt: %w: %w", driver.ErrDomainNotFound, err)
+	case golibvirt.ErrOperationDenied, golibvirt.ErrAuthFailed:
+		return fmt.Errorf("libvirt: %w: %w", driver.ErrPermissionDenied, err)
+	case golibvirt.ErrNoSupport:
+		return fmt.Errorf("libvirt: %w: %w", driver.ErrNotSupported, err)
+	}
+	return fmt.Errorf("libvirt: %w", err)
+}
+
+// disconnected Test if err is a transport failure of the libvirtd connection
+func disconnected(err error) bool {
+	var nerr net.Error
+	return errors.As(err, &nerr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, syscall.EINVAL) ||
+		errors.Is(err, golibvirt.ErrInterrupted)
+}
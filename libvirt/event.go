@@ -0,0 +1,111 @@
+//go:build libvirt
+
+package libvirt
+
+import (
+	"context"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/virtmonitor/driver"
+)
+
+// Watch Push lifecycle events registered with virConnectDomainEventRegisterAny.
+// The watch shares the driver connection, Close or a collection aborted by
+// its context end it along with the connection.
+func (l *Libvirt) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.connect(); err != nil {
+		return nil, err
+	}
+
+	conn := l.conn
+	events, err := conn.LifecycleEvents(ctx)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	out := make(chan driver.DomainEvent)
+	go func() {
+		defer close(out)
+		// Unblock the library goroutine still sending on events
+		defer func() {
+			go func() {
+				for range events {
+				}
+			}()
+		}()
+
+		for {
+			select {
+			case msg, ok := <-events:
+				if !ok {
+					return
+				}
+				ev, ok := domainEvent(msg)
+				if !ok {
+					continue
+				}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return
+				}
+			case <-conn.Disconnected():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// domainEvent Translate a lifecycle message, definition changes carry no
+// state and are skipped
+func domainEvent(msg golibvirt.DomainEventLifecycleMsg) (driver.DomainEvent, bool) {
+	flag, ok := eventFlag(golibvirt.DomainEventType(msg.Event), msg.Detail)
+	if !ok {
+		return driver.DomainEvent{}, false
+	}
+
+	// Stopped domains get the ID they are collected under with
+	// IncludeInactive
+	ev := driver.DomainEvent{
+		ID:         domainID(msg.Dom),
+		UUID:       formatUUID(msg.Dom.UUID),
+		Name:       msg.Dom.Name,
+		Hypervisor: Hypervisor,
+		Flags:      flag,
+		Time:       driver.TimestampNow(),
+	}
+	return ev, true
+}
+
+// eventFlag Domain state entered with a lifecycle event
+func eventFlag(event golibvirt.DomainEventType, detail int32) (driver.DomainFlag, bool) {
+	switch event {
+	case golibvirt.DomainEventStarted, golibvirt.DomainEventResumed:
+		return driver.DomainOnline, true
+	case golibvirt.DomainEventSuspended:
+		// Migrations out pause the domain for their final switchover
+		switch golibvirt.DomainEventSuspendedDetailType(detail) {
+		case golibvirt.DomainEventSuspendedMigrated, golibvirt.DomainEventSuspendedPostcopy:
+			return driver.DomainMigratingOut, true
+		}
+		return driver.DomainPaused, true
+	case golibvirt.DomainEventPmsuspended:
+		return driver.DomainPaused, true
+	case golibvirt.DomainEventShutdown:
+		return driver.DomainDying, true
+	case golibvirt.DomainEventStopped:
+		if golibvirt.DomainEventStoppedDetailType(detail) == golibvirt.DomainEventStoppedCrashed {
+			return driver.DomainCrashed, true
+		}
+		return driver.DomainShutdown, true
+	case golibvirt.DomainEventCrashed:
+		return driver.DomainCrashed, true
+	}
+	return 0, false
+}
@@ -0,0 +1,531 @@
+//go:build libvirt
+
+package libvirt
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/digitalocean/go-libvirt/socket"
+	"github.com/digitalocean/go-libvirt/socket/dialers"
+	"github.com/virtmonitor/driver"
+)
+
+const (
+	// Hypervisor Hypervisor name reported by the libvirt driver
+	Hypervisor driver.DomainHypervisor = "libvirt"
+	// DefaultURI Default connection URI, the system QEMU daemon
+	DefaultURI = "qemu:///system"
+	// DefaultSocket Default path of the libvirtd RPC socket
+	DefaultSocket = "/var/run/libvirt/libvirt-sock"
+	// DefaultTimeout Default dial timeout
+	DefaultTimeout = 15 * time.Second
+)
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New()); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		Keys: []string{"uri", "timeout"},
+		New:  fromConfig,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// fromConfig Create a libvirt driver from the uri and timeout keys of a
+// driver.Config
+func fromConfig(cfg driver.Config) (driver.Driver, error) {
+	timeout, err := cfg.Duration("timeout", 0)
+	if err != nil {
+		return nil, err
+	}
+	l, err := NewWithConfig(Config{URI: cfg["uri"], Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", driver.ErrInvalidConfig, err)
+	}
+	return l, nil
+}
+
+// Config Connection settings of a libvirt driver
+type Config struct {
+	// URI libvirt connection URI, DefaultURI if empty. Local URIs connect to
+	// the daemon socket, qemu:///session to the one of the current user,
+	// unless the socket parameter sets its path. Remote URIs use the tcp or
+	// tls transport (qemu+tcp://host/system, qemu+tls://host/system), tls
+	// being the default when a host is given; tls takes the pkipath and
+	// no_verify parameters as libvirt does.
+	URI string
+	// Timeout Dial timeout, DefaultTimeout if zero. TLS connections keep the
+	// go-libvirt timeout.
+	Timeout time.Duration
+}
+
+// Libvirt Libvirt driver
+type Libvirt struct {
+	mu  sync.Mutex
+	uri string
+	// socket Path of the local socket, empty for remote connections
+	socket string
+	// runDir Directory of the QEMU pid files, empty when the processes
+	// aren't local or not QEMU
+	runDir string
+	dialer socket.Dialer
+	remote golibvirt.ConnectURI
+	conn   *golibvirt.Libvirt
+}
+
+// New Create a libvirt driver connecting to DefaultURI
+func New() *Libvirt {
+	// The default URI always parses
+	l, _ := NewWithConfig(Config{})
+	return l
+}
+
+// NewWithConfig Create a libvirt driver connecting as cfg says. Nothing is
+// dialed until the driver is used, an invalid or unsupported URI is an error.
+func NewWithConfig(cfg Config) (*Libvirt, error) {
+	if cfg.URI == "" {
+		cfg.URI = DefaultURI
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	u, err := url.Parse(cfg.URI)
+	if err != nil {
+		return nil, fmt.Errorf("libvirt: URI %q: %w", cfg.URI, err)
+	}
+	if u.Scheme == "" {
+		return nil, fmt.Errorf("libvirt: URI %q: no driver scheme", cfg.URI)
+	}
+
+	l := &Libvirt{uri: cfg.URI, remote: golibvirt.RemoteURI(u)}
+	name, transport, _ := strings.Cut(u.Scheme, "+")
+	if transport == "" && u.Host != "" {
+		transport = "tls"
+	}
+
+	query := u.Query()
+	switch transport {
+	case "", "unix":
+		l.socket = query.Get("socket")
+		if l.socket == "" {
+			l.socket = localSocket(u.Path)
+		}
+		if name == "qemu" {
+			l.runDir = localRunDir(u.Path)
+		}
+		l.dialer = dialers.NewLocal(dialers.WithSocket(l.socket), dialers.WithLocalTimeout(cfg.Timeout))
+	case "tcp":
+		opts := []dialers.RemoteOption{dialers.WithRemoteTimeout(cfg.Timeout)}
+		if port := u.Port(); port != "" {
+			opts = append(opts, dialers.UsePort(port))
+		}
+		l.dialer = dialers.NewRemote(u.Hostname(), opts...)
+	case "tls":
+		var opts []dialers.TLSOption
+		if port := u.Port(); port != "" {
+			opts = append(opts, dialers.UseTLSPort(port))
+		}
+		if path := query.Get("pkipath"); path != "" {
+			opts = append(opts, dialers.UsePKIPath(path))
+		}
+		if query.Get("no_verify") == "1" {
+			opts = append(opts, dialers.WithInsecureNoVerify())
+		}
+		l.dialer = dialers.NewTLS(u.Hostname(), opts...)
+	default:
+		return nil, fmt.Errorf("libvirt: URI %q: unsupported transport %q", cfg.URI, transport)
+	}
+	return l, nil
+}
+
+// localSocket Daemon socket of a local URI path, per user for sessions
+func localSocket(path string) string {
+	if path != "/session" {
+		return DefaultSocket
+	}
+	return filepath.Join(userRuntimeDir(), "libvirt-sock")
+}
+
+// localRunDir QEMU pid file directory of a local URI path
+func localRunDir(path string) string {
+	if path != "/session" {
+		return qemuRunDir
+	}
+	return filepath.Join(userRuntimeDir(), "qemu", "run")
+}
+
+// userRuntimeDir Runtime directory of the session daemon, as libvirt picks it
+func userRuntimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "libvirt")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".cache", "libvirt")
+}
+
+// Name Hypervisor name
+func (l *Libvirt) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Capabilities Supported metrics
+func (l *Libvirt) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:          true,
+		SupportsBlocks:        true,
+		SupportsInterfaces:    true,
+		SupportsMemory:        true,
+		SupportsFilesystems:   true,
+		SupportsGraphics:      true,
+		SupportsMetadata:      true,
+		SupportsHostDevices:   true,
+		SupportsCPUTuning:     true,
+		SupportsNUMA:          true,
+		SupportsGuestIP:       true,
+		SupportsBlockCapacity: true,
+		SupportsBackingChain:  true,
+		SupportsPinning:       true,
+		SupportsLimits:        true,
+		SupportsSnapshots:     true,
+		SupportsEvents:        true,
+		SupportsVersions:      true,
+		SupportsDirtyRate:     true,
+	}
+}
+
+// Detect Test if libvirtd is reachable at the configured URI, local sockets
+// are checked for first
+func (l *Libvirt) Detect() bool {
+	if l.socket != "" {
+		if _, err := os.Stat(l.socket); err != nil {
+			return false
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.connect() == nil
+}
+
+// Diagnose Tell a missing socket from a refused connection, counting the
+// domains once connected
+func (l *Libvirt) Diagnose() driver.DetectResult {
+	if l.socket != "" {
+		if _, err := os.Stat(l.socket); err != nil {
+			return driver.DetectResult{
+				Reason: "no socket found at " + l.socket,
+				Err:    connectError(err),
+			}
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.connect(); err != nil {
+		reason := "connecting to " + l.uri + " failed: "
+		if l.socket != "" {
+			reason = "socket " + l.socket + " present but connection refused: "
+		}
+		return driver.DetectResult{Reason: reason + err.Error(), Err: err}
+	}
+
+	doms, _, err := l.conn.ConnectListAllDomains(1, 0)
+	switch {
+	case err != nil:
+		return driver.DetectResult{Detected: true, Reason: "connected, listing domains failed: " + err.Error()}
+	case len(doms) == 0:
+		return driver.DetectResult{Detected: true, Reason: "connected but no domains"}
+	}
+	return driver.DetectResult{Detected: true, Reason: fmt.Sprintf("connected, %d domains", len(doms))}
+}
+
+// Collect Collect domains
+func (l *Libvirt) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return l.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect domains, dropping the connection to abort the
+// in-flight RPC when ctx is done
+func (l *Libvirt) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := l.connect(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		domains map[driver.DomainID]*driver.Domain
+		err     error
+	}
+
+	conn := l.conn
+	done := make(chan result, 1)
+	go func() {
+		domains, err := collect(ctx, conn, l.runDir, opts)
+		done <- result{domains, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.domains, r.err
+	case <-ctx.Done():
+		l.disconnect()
+		<-done
+		return nil, ctx.Err()
+	}
+}
+
+// CollectInto Collect the domains as CollectContext does into dst,
+// reusing its domains, see driver.CollectInto
+func (l *Libvirt) CollectInto(ctx context.Context, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		clear(dst)
+		return err
+	}
+	if err := l.connect(); err != nil {
+		clear(dst)
+		return err
+	}
+
+	conn := l.conn
+	done := make(chan error, 1)
+	go func() {
+		done <- collectInto(ctx, conn, l.runDir, dst, opts)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		l.disconnect()
+		<-done
+		clear(dst)
+		return ctx.Err()
+	}
+}
+
+// CollectStream Collect the domains as CollectContext does, emitting
+// each as soon as it's collected
+func (l *Libvirt) CollectStream(ctx context.Context, opts driver.CollectOptions, emit func(*driver.Domain) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := l.connect(); err != nil {
+		return err
+	}
+
+	conn := l.conn
+	done := make(chan error, 1)
+	go func() {
+		done <- stream(ctx, conn, l.runDir, opts, emit)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		l.disconnect()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// CollectDomain Collect a single domain by ID. IDs past the range of libvirt
+// IDs are those hashed for inactive domains, found among them.
+func (l *Libvirt) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.connect(); err != nil {
+		return nil, err
+	}
+
+	if id > math.MaxInt32 {
+		return l.collectInactive(id, opts)
+	}
+	dom, err := l.conn.DomainLookupByID(int32(id))
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return collectDomain(l.conn, l.runDir, dom, opts, nil)
+}
+
+// collectInactive Collect the inactive domain whose hashed ID is id
+func (l *Libvirt) collectInactive(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	doms, _, err := l.conn.ConnectListAllDomains(1, golibvirt.ConnectListDomainsInactive)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	for _, dom := range doms {
+		if domainID(dom) == id {
+			return collectDomain(l.conn, l.runDir, dom, opts, nil)
+		}
+	}
+	return nil, fmt.Errorf("libvirt: domain %d: %w", id, driver.ErrDomainNotFound)
+}
+
+// CollectDomainByUUID Collect a single domain by UUID
+func (l *Libvirt) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	u, err := parseUUID(uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.connect(); err != nil {
+		return nil, err
+	}
+
+	dom, err := l.conn.DomainLookupByUUID(u)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return collectDomain(l.conn, l.runDir, dom, opts, nil)
+}
+
+// CollectDomainByName Collect a single domain by name
+func (l *Libvirt) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.connect(); err != nil {
+		return nil, err
+	}
+
+	dom, err := l.conn.DomainLookupByName(name)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return collectDomain(l.conn, l.runDir, dom, opts, nil)
+}
+
+// Host Host metrics from libvirtd. libvirt has no load average, it is read
+// locally when libvirtd is reached over a local socket and left unset for
+// remote hosts.
+func (l *Libvirt) Host() (*driver.HostInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.connect(); err != nil {
+		return nil, err
+	}
+
+	h := &driver.HostInfo{}
+	var err error
+	if l.socket != "" {
+		if h, err = driver.LocalHostInfo(); err != nil {
+			return nil, err
+		}
+	}
+	if h.Hostname, err = l.conn.ConnectGetHostname(); err != nil {
+		return nil, rpcError(err)
+	}
+
+	_, memory, cpus, _, _, _, _, _, err := l.conn.NodeGetInfo()
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	h.CPUs = int(cpus)
+	h.MemoryTotal = memory * 1024
+
+	if h.MemoryFree, err = l.conn.NodeGetFreeMemory(); err != nil {
+		return nil, rpcError(err)
+	}
+	return h, nil
+}
+
+// Ping Query the libvirt version, dropping the connection when ctx is done
+// first
+func (l *Libvirt) Ping(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := l.connect(); err != nil {
+		return err
+	}
+
+	conn := l.conn
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.ConnectGetLibVersion()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return rpcError(err)
+		}
+		return nil
+	case <-ctx.Done():
+		l.disconnect()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Close Close the libvirt connection
+func (l *Libvirt) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.disconnect()
+}
+
+// connect Connect to libvirtd unless a live connection exists, l.mu must be held
+func (l *Libvirt) connect() error {
+	if l.conn != nil && l.conn.IsConnected() {
+		return nil
+	}
+
+	conn := golibvirt.NewWithDialer(l.dialer)
+	if err := conn.ConnectToURI(l.remote); err != nil {
+		return connectError(err)
+	}
+	l.conn = conn
+	return nil
+}
+
+// disconnect Tear down the connection, l.mu must be held
+func (l *Libvirt) disconnect() error {
+	if l.conn == nil {
+		return nil
+	}
+	conn := l.conn
+	l.conn = nil
+	if err := conn.Disconnect(); err != nil {
+		return fmt.Errorf("libvirt: disconnect: %w", err)
+	}
+	return nil
+}
+
+// Priority Prefer libvirt over drivers bypassing it, such as qmp
+func (l *Libvirt) Priority() int {
+	return 100
+}
@@ -0,0 +1,86 @@
+//go:build libvirt
+
+package libvirt
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/virtmonitor/driver"
+)
+
+// snapshotXML The parts of the libvirt snapshot XML used by the driver
+type snapshotXML struct {
+	Name         string `xml:"name"`
+	State        string `xml:"state"`
+	CreationTime int64  `xml:"creationTime"`
+	Parent       struct {
+		Name string `xml:"name"`
+	} `xml:"parent"`
+}
+
+// CollectSnapshots List the snapshots of a running domain
+func (l *Libvirt) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.connect(); err != nil {
+		return nil, err
+	}
+
+	if id > math.MaxInt32 {
+		return nil, fmt.Errorf("libvirt: domain %d: %w", id, driver.ErrDomainNotFound)
+	}
+	dom, err := l.conn.DomainLookupByID(int32(id))
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	snaps, _, err := l.conn.DomainListAllSnapshots(dom, 1, 0)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	out := make([]driver.Snapshot, 0, len(snaps))
+	for _, snap := range snaps {
+		s, err := collectSnapshot(l.conn, snap)
+		var lerr golibvirt.Error
+		if errors.As(err, &lerr) && golibvirt.ErrorNumber(lerr.Code) == golibvirt.ErrNoDomainSnapshot {
+			// Deleted since it was listed
+			continue
+		}
+		if err != nil {
+			return nil, rpcError(err)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func collectSnapshot(conn *golibvirt.Libvirt, snap golibvirt.DomainSnapshot) (driver.Snapshot, error) {
+	desc, err := conn.DomainSnapshotGetXMLDesc(snap, 0)
+	if err != nil {
+		return driver.Snapshot{}, err
+	}
+	var x snapshotXML
+	if err := xml.Unmarshal([]byte(desc), &x); err != nil {
+		return driver.Snapshot{}, err
+	}
+
+	current, err := conn.DomainSnapshotIsCurrent(snap, 0)
+	if err != nil {
+		return driver.Snapshot{}, err
+	}
+
+	return driver.Snapshot{
+		Name:         x.Name,
+		CreationTime: time.Unix(x.CreationTime, 0),
+		State:        x.State,
+		Parent:       x.Parent.Name,
+		IsCurrent:    current == 1,
+	}, nil
+}
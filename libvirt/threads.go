@@ -0,0 +1,140 @@
+//go:build libvirt
+
+package libvirt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	golibvirt "github.com/digitalocean/go-libvirt"
+	"github.com/virtmonitor/driver"
+)
+
+// threadTimes CPU time spent by the threads of a QEMU process, in
+// nanoseconds
+type threadTimes struct {
+	// total Every thread of the process, exited ones included
+	total float64
+	vcpus float64
+	// iothreads IO thread times by QEMU object ID (e.g. iothread1)
+	iothreads map[string]float64
+}
+
+// collectThreads IO threads and emulator time of a running domain. IO
+// thread affinity comes from libvirt, times from the procfs entries of the
+// QEMU process, which libvirt names after their role. Times are left unset
+// when the process can't be read, as for other hypervisors.
+func collectThreads(conn *golibvirt.Libvirt, runDir string, dom golibvirt.Domain, d *driver.Domain, pinning bool) error {
+	infos, _, err := conn.DomainGetIothreadInfo(dom, 0)
+	if err != nil {
+		var lerr golibvirt.Error
+		if !errors.As(err, &lerr) || golibvirt.ErrorNumber(lerr.Code) != golibvirt.ErrNoSupport {
+			return err
+		}
+	}
+
+	var times threadTimes
+	pid, ok := qemuPID(runDir, dom.Name)
+	if ok {
+		times, ok = procThreads(pid)
+	}
+
+	iothreads := 0.0
+	for _, info := range infos {
+		t := driver.IOThread{ID: uint64(info.IothreadID)}
+		if pinning {
+			t.Affinity = cpuSet(info.Cpumap)
+		}
+		if v, found := times.iothreads["iothread"+strconv.FormatUint(t.ID, 10)]; ok && found {
+			t.Time, t.TimeSet = v, true
+			iothreads += v
+		}
+		d.IOThreads = append(d.IOThreads, t)
+	}
+
+	if ok {
+		d.EmulatorTime, d.EmulatorTimeSet = max(times.total-times.vcpus-iothreads, 0), true
+	}
+	return nil
+}
+
+// qemuPID Process ID of the QEMU running a domain, from its pid file in
+// runDir. There is none to read when runDir is empty.
+func qemuPID(runDir, name string) (int, bool) {
+	if runDir == "" {
+		return 0, false
+	}
+	data, err := os.ReadFile(filepath.Join(runDir, name+".pid"))
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid, err == nil
+}
+
+// procThreads Thread times of a process. QEMU names vCPU threads "CPU
+// <n>/KVM" and IO threads "IO <id>".
+func procThreads(pid int) (threadTimes, bool) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	total, ok := procTime(filepath.Join(dir, "stat"))
+	if !ok {
+		return threadTimes{}, false
+	}
+
+	tasks, err := os.ReadDir(filepath.Join(dir, "task"))
+	if err != nil {
+		return threadTimes{}, false
+	}
+
+	times := threadTimes{total: total, iothreads: make(map[string]float64)}
+	for _, task := range tasks {
+		comm, err := os.ReadFile(filepath.Join(dir, "task", task.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSpace(string(comm))
+		t, ok := procTime(filepath.Join(dir, "task", task.Name(), "stat"))
+		if !ok {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(name, "CPU ") && strings.HasSuffix(name, "/KVM"):
+			times.vcpus += t
+		case strings.HasPrefix(name, "IO "):
+			times.iothreads[strings.TrimPrefix(name, "IO ")] = t
+		}
+	}
+	return times, true
+}
+
+// procTime User and system time of a procfs stat file in nanoseconds
+func procTime(path string) (float64, bool) {
+	stat, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	// Skip past the command name, which may contain spaces. utime and stime
+	// are fields 14 and 15 in proc(5), at index 11 and 12 after it.
+	s := string(stat)
+	if i := strings.LastIndexByte(s, ')'); i >= 0 {
+		s = s[i+1:]
+	}
+	fields := strings.Fields(s)
+	if len(fields) < 13 {
+		return 0, false
+	}
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+
+	// USER_HZ is 100 on all supported platforms
+	return float64(utime+stime) * float64(time.Second/100), true
+}
@@ -0,0 +1,518 @@
+//go:build libvirt
+
+package libvirt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/virtmonitor/driver"
+)
+
+// LabelsNamespace XML namespace of the domain metadata element holding
+// labels, one child element per label named after its key:
+//
+//	<metadata>
+//	  <labels xmlns="https://github.com/virtmonitor/driver/labels/1">
+//	    <team>storage</team>
+//	  </labels>
+//	</metadata>
+const LabelsNamespace = "https://github.com/virtmonitor/driver/labels/1"
+
+// domainXML The parts of the libvirt domain XML used by the driver
+type domainXML struct {
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	Metadata    struct {
+		Elements []metadataXML `xml:",any"`
+	} `xml:"metadata"`
+	OS struct {
+		Type struct {
+			Machine string `xml:"machine,attr"`
+		} `xml:"type"`
+	} `xml:"os"`
+	CPU struct {
+		Mode     string `xml:"mode,attr"`
+		Features []struct {
+			Policy string `xml:"policy,attr"`
+			Name   string `xml:"name,attr"`
+		} `xml:"feature"`
+		Topology *struct {
+			Sockets  int `xml:"sockets,attr"`
+			Dies     int `xml:"dies,attr"`
+			Clusters int `xml:"clusters,attr"`
+			Cores    int `xml:"cores,attr"`
+			Threads  int `xml:"threads,attr"`
+		} `xml:"topology"`
+		NUMA struct {
+			Cells []struct {
+				ID     *uint64 `xml:"id,attr"`
+				CPUs   string  `xml:"cpus,attr"`
+				Memory uint64  `xml:"memory,attr"`
+				Unit   string  `xml:"unit,attr"`
+			} `xml:"cell"`
+		} `xml:"numa"`
+	} `xml:"cpu"`
+	NUMATune struct {
+		Memory *struct {
+			Mode    string `xml:"mode,attr"`
+			Nodeset string `xml:"nodeset,attr"`
+		} `xml:"memory"`
+		MemNodes []struct {
+			CellID  uint64 `xml:"cellid,attr"`
+			Mode    string `xml:"mode,attr"`
+			Nodeset string `xml:"nodeset,attr"`
+		} `xml:"memnode"`
+	} `xml:"numatune"`
+	MemoryBacking struct {
+		Hugepages *struct {
+			Pages []struct {
+				Size uint64 `xml:"size,attr"`
+				Unit string `xml:"unit,attr"`
+			} `xml:"page"`
+		} `xml:"hugepages"`
+	} `xml:"memoryBacking"`
+	Devices struct {
+		Disks      []diskXML      `xml:"disk"`
+		Interfaces []interfaceXML `xml:"interface"`
+		Graphics   []graphicsXML  `xml:"graphics"`
+		Consoles   []consoleXML   `xml:"console"`
+		HostDevs   []hostdevXML   `xml:"hostdev"`
+		Channels   []channelXML   `xml:"channel"`
+	} `xml:"devices"`
+}
+
+// guestAgentChannel Target name of the QEMU guest agent channel
+const guestAgentChannel = "org.qemu.guest_agent.0"
+
+type channelXML struct {
+	Target struct {
+		Name string `xml:"name,attr"`
+		// State connected or disconnected, tracked by libvirt from the agent's
+		// lifecycle events
+		State string `xml:"state,attr"`
+	} `xml:"target"`
+}
+
+// guestAgent State of the guest agent channel. Domains without one have no
+// agent to connect, libvirt too old to track the state leaves it unknown.
+func (x *domainXML) guestAgent() driver.AgentState {
+	for _, c := range x.Devices.Channels {
+		if c.Target.Name != guestAgentChannel {
+			continue
+		}
+		switch c.Target.State {
+		case "connected":
+			return driver.AgentConnected
+		case "disconnected":
+			return driver.AgentDisconnected
+		}
+		return driver.AgentUnknown
+	}
+	return driver.AgentDisconnected
+}
+
+// hugepages Whether memory is backed by hugepages and their size in bytes,
+// 0 for the host default. Only the first page size is reported when NUMA
+// nodes use several.
+func (x *domainXML) hugepages() (bool, uint64) {
+	h := x.MemoryBacking.Hugepages
+	if h == nil {
+		return false, 0
+	}
+	if len(h.Pages) == 0 {
+		return true, 0
+	}
+	return true, h.Pages[0].Size * unitSize(h.Pages[0].Unit)
+}
+
+// unitSize Bytes in a libvirt scaled integer unit, KiB by default
+func unitSize(unit string) uint64 {
+	switch unit {
+	case "b", "bytes":
+		return 1
+	case "KB":
+		return 1000
+	case "MB":
+		return 1000 * 1000
+	case "M", "MiB":
+		return 1 << 20
+	case "GB":
+		return 1000 * 1000 * 1000
+	case "G", "GiB":
+		return 1 << 30
+	}
+	return 1 << 10
+}
+
+// nestedVirt Whether the vCPUs expose vmx or svm. Explicit features are
+// decisive. Passthrough and maximum CPUs inherit the host's, which depends on
+// the nested parameter of the KVM module; ok is false when it can't be read.
+func (x *domainXML) nestedVirt() (nested, ok bool) {
+	for _, f := range x.CPU.Features {
+		if f.Name != "vmx" && f.Name != "svm" {
+			continue
+		}
+		switch f.Policy {
+		case "", "require", "force":
+			return true, true
+		case "disable", "forbid":
+			return false, true
+		}
+	}
+
+	if x.CPU.Mode == "host-passthrough" || x.CPU.Mode == "maximum" {
+		return hostNested()
+	}
+	// Live XML lists the features of host-model CPUs, so none means none
+	return false, true
+}
+
+// topology Configured CPU topology, cores multiplied by the dies and
+// clusters of each socket, which default to 1
+func (x *domainXML) topology() driver.CPUTopology {
+	t := x.CPU.Topology
+	if t == nil {
+		return driver.CPUTopology{}
+	}
+	return driver.CPUTopology{
+		Sockets:        t.Sockets,
+		CoresPerSocket: t.Cores * max(t.Dies, 1) * max(t.Clusters, 1),
+		ThreadsPerCore: t.Threads,
+	}
+}
+
+// kvmNestedParams Module parameters enabling nested virtualization
+var kvmNestedParams = []string{
+	"/sys/module/kvm_intel/parameters/nested",
+	"/sys/module/kvm_amd/parameters/nested",
+}
+
+// hostNested Whether KVM on the local host allows nested virtualization
+func hostNested() (nested, ok bool) {
+	for _, path := range kvmNestedParams {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		v := strings.TrimSpace(string(data))
+		return v == "Y" || v == "1", true
+	}
+	return false, false
+}
+
+// metadataXML A custom metadata element, in its application's namespace
+type metadataXML struct {
+	XMLName  xml.Name
+	Children []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+// numa Guest NUMA cells and the memory placement of <numatune>. Cells
+// without an ID are numbered in order, the mode defaults to strict.
+func (x *domainXML) numa() driver.NUMA {
+	var n driver.NUMA
+	if m := x.NUMATune.Memory; m != nil {
+		n.Mode = m.Mode
+		n.Nodes, _ = driver.ParseCPUSet(m.Nodeset)
+		if n.Mode == "" {
+			n.Mode = "strict"
+		}
+	}
+
+	for i, c := range x.CPU.NUMA.Cells {
+		cell := driver.NUMACell{ID: uint64(i), Memory: c.Memory * unitSize(c.Unit)}
+		if c.ID != nil {
+			cell.ID = *c.ID
+		}
+		cell.CPUs, _ = driver.ParseCPUSet(c.CPUs)
+		for _, m := range x.NUMATune.MemNodes {
+			if m.CellID == cell.ID {
+				cell.Mode = m.Mode
+				cell.Nodes, _ = driver.ParseCPUSet(m.Nodeset)
+				if cell.Mode == "" {
+					cell.Mode = "strict"
+				}
+			}
+		}
+		n.Cells = append(n.Cells, cell)
+	}
+	return n
+}
+
+// labels Labels from the metadata element in LabelsNamespace, nil if there
+// is none
+func (x *domainXML) labels() map[string]string {
+	var labels map[string]string
+	for _, el := range x.Metadata.Elements {
+		if el.XMLName.Space != LabelsNamespace {
+			continue
+		}
+		for _, child := range el.Children {
+			if labels == nil {
+				labels = make(map[string]string, len(el.Children))
+			}
+			labels[child.XMLName.Local] = strings.TrimSpace(child.Value)
+		}
+	}
+	return labels
+}
+
+type diskXML struct {
+	Device       string           `xml:"device,attr"`
+	ReadOnly     *struct{}        `xml:"readonly"`
+	Source       sourceXML        `xml:"source"`
+	BackingStore *backingStoreXML `xml:"backingStore"`
+	Target       struct {
+		Dev string `xml:"dev,attr"`
+		Bus string `xml:"bus,attr"`
+	} `xml:"target"`
+}
+
+type sourceXML struct {
+	File     string `xml:"file,attr"`
+	Dev      string `xml:"dev,attr"`
+	Dir      string `xml:"dir,attr"`
+	Protocol string `xml:"protocol,attr"`
+	Name     string `xml:"name,attr"`
+	Pool     string `xml:"pool,attr"`
+	Volume   string `xml:"volume,attr"`
+}
+
+// backingStoreXML An image below a disk source, holding the next one down.
+// The chain ends with an empty element, or none when libvirt didn't probe
+// it.
+type backingStoreXML struct {
+	Source       sourceXML        `xml:"source"`
+	BackingStore *backingStoreXML `xml:"backingStore"`
+}
+
+// source Backing path of a disk, see sourceXML.path
+func (d *diskXML) source() string {
+	return d.Source.path()
+}
+
+// backingChain Paths of the backing images of a disk, nearest first
+func (d *diskXML) backingChain() []string {
+	var chain []string
+	for b := d.BackingStore; b != nil; b = b.BackingStore {
+		path := b.Source.path()
+		if path == "" {
+			break
+		}
+		chain = append(chain, path)
+	}
+	return chain
+}
+
+// path A file, block device or directory, the protocol and name of a
+// network disk, or the pool and volume of a storage volume
+func (s sourceXML) path() string {
+	switch {
+	case s.File != "":
+		return s.File
+	case s.Dev != "":
+		return s.Dev
+	case s.Dir != "":
+		return s.Dir
+	case s.Protocol != "" && s.Name != "":
+		return s.Protocol + "://" + s.Name
+	case s.Pool != "" && s.Volume != "":
+		return s.Pool + "/" + s.Volume
+	}
+	return ""
+}
+
+type interfaceXML struct {
+	MAC struct {
+		Address string `xml:"address,attr"`
+	} `xml:"mac"`
+	Source struct {
+		Bridge string `xml:"bridge,attr"`
+	} `xml:"source"`
+	Target struct {
+		Dev string `xml:"dev,attr"`
+	} `xml:"target"`
+	Link *struct {
+		State string `xml:"state,attr"`
+	} `xml:"link"`
+	Bandwidth *struct {
+		Inbound  *bandwidthXML `xml:"inbound"`
+		Outbound *bandwidthXML `xml:"outbound"`
+	} `xml:"bandwidth"`
+}
+
+type bandwidthXML struct {
+	Average uint64 `xml:"average,attr"`
+}
+
+type hostdevXML struct {
+	Type   string `xml:"type,attr"`
+	Model  string `xml:"model,attr"`
+	Source struct {
+		Vendor struct {
+			ID string `xml:"id,attr"`
+		} `xml:"vendor"`
+		Product struct {
+			ID string `xml:"id,attr"`
+		} `xml:"product"`
+		Address struct {
+			Domain   string `xml:"domain,attr"`
+			Bus      string `xml:"bus,attr"`
+			Slot     string `xml:"slot,attr"`
+			Function string `xml:"function,attr"`
+			Device   string `xml:"device,attr"`
+			UUID     string `xml:"uuid,attr"`
+		} `xml:"address"`
+	} `xml:"source"`
+}
+
+// device Host device without its description, false for types other than
+// pci, usb and mdev such as SCSI hostdevs
+func (h *hostdevXML) device() (driver.HostDevice, bool) {
+	a := h.Source.Address
+	dev := driver.HostDevice{Type: h.Type}
+	switch h.Type {
+	case "pci":
+		dev.Address = fmt.Sprintf("%04x:%02x:%02x.%x", attrUint(a.Domain), attrUint(a.Bus), attrUint(a.Slot), attrUint(a.Function))
+	case "usb":
+		// Devices selected by vendor and product only get an address once attached
+		if a.Bus != "" {
+			dev.Address = fmt.Sprintf("%03d:%03d", attrUint(a.Bus), attrUint(a.Device))
+		}
+		if h.Source.Vendor.ID != "" {
+			dev.Name = fmt.Sprintf("%04x:%04x", attrUint(h.Source.Vendor.ID), attrUint(h.Source.Product.ID))
+		}
+	case "mdev":
+		dev.Address, dev.Name = a.UUID, h.Model
+	default:
+		return dev, false
+	}
+	return dev, true
+}
+
+// attrUint Numeric attribute in decimal or 0x prefixed hexadecimal, 0 when
+// malformed
+func attrUint(s string) uint64 {
+	v, _ := strconv.ParseUint(s, 0, 64)
+	return v
+}
+
+// nodeDeviceXML The PCI capability of a node device
+type nodeDeviceXML struct {
+	Capability struct {
+		Product struct {
+			ID   string `xml:"id,attr"`
+			Name string `xml:",chardata"`
+		} `xml:"product"`
+		Vendor struct {
+			ID   string `xml:"id,attr"`
+			Name string `xml:",chardata"`
+		} `xml:"vendor"`
+	} `xml:"capability"`
+}
+
+// describe Fill in the IDs and names of a PCI device from the XML of its
+// node device
+func (n *nodeDeviceXML) describe(dev *driver.HostDevice) {
+	c := n.Capability
+	dev.Name = fmt.Sprintf("%04x:%04x", attrUint(c.Vendor.ID), attrUint(c.Product.ID))
+	dev.Description = strings.TrimSpace(strings.TrimSpace(c.Vendor.Name) + " " + strings.TrimSpace(c.Product.Name))
+}
+
+type graphicsXML struct {
+	Type    string `xml:"type,attr"`
+	Port    int    `xml:"port,attr"`
+	TLSPort int    `xml:"tlsPort,attr"`
+	Listen  string `xml:"listen,attr"`
+	// Passwd Only present in the secure XML
+	Passwd  *string `xml:"passwd,attr"`
+	Listens []struct {
+		Type    string `xml:"type,attr"`
+		Address string `xml:"address,attr"`
+	} `xml:"listen"`
+}
+
+// listen Address listened on, the listen attribute being the legacy form
+// of the first listen element
+func (g *graphicsXML) listen() net.IP {
+	if g.Listen != "" {
+		return net.ParseIP(g.Listen)
+	}
+	for _, l := range g.Listens {
+		if l.Type == "address" && l.Address != "" {
+			return net.ParseIP(l.Address)
+		}
+	}
+	return nil
+}
+
+type consoleXML struct {
+	Type   string `xml:"type,attr"`
+	TTY    string `xml:"tty,attr"`
+	Source struct {
+		Path string `xml:"path,attr"`
+	} `xml:"source"`
+}
+
+// path Host path of the console, empty for consoles without one such as
+// TCP consoles
+func (c *consoleXML) path() string {
+	if c.Source.Path != "" {
+		return c.Source.Path
+	}
+	return c.TTY
+}
+
+func parseDomainXML(desc string) (*domainXML, error) {
+	var x domainXML
+	if err := xml.Unmarshal([]byte(desc), &x); err != nil {
+		return nil, err
+	}
+	return &x, nil
+}
+
+// capabilitiesXML The NUMA topology of the host capabilities XML
+type capabilitiesXML struct {
+	Cells []struct {
+		ID   int `xml:"id,attr"`
+		CPUs []struct {
+			ID int `xml:"id,attr"`
+		} `xml:"cpus>cpu"`
+	} `xml:"host>topology>cells>cell"`
+}
+
+// nodes CPUs of every host NUMA node by node number
+func (c *capabilitiesXML) nodes() map[int]driver.CPUSet {
+	nodes := make(map[int]driver.CPUSet, len(c.Cells))
+	for _, cell := range c.Cells {
+		var cpus driver.CPUSet
+		for _, cpu := range cell.CPUs {
+			cpus.Set(cpu.ID)
+		}
+		nodes[cell.ID] = cpus
+	}
+	return nodes
+}
+
+func parseCapabilitiesXML(desc string) (*capabilitiesXML, error) {
+	var c capabilitiesXML
+	if err := xml.Unmarshal([]byte(desc), &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func parseNodeDeviceXML(desc string) (*nodeDeviceXML, error) {
+	var n nodeDeviceXML
+	if err := xml.Unmarshal([]byte(desc), &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
@@ -0,0 +1,126 @@
+package driver
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Load average windows, as for the host load average
+var loadWindows = [3]time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// vcpuLoad Load averages of a vCPU and the sample they were last updated
+// with
+type vcpuLoad struct {
+	time  float64
+	at    Timestamp
+	loads [3]float64
+	// seeded Whether loads hold an average, set from the second sample
+	seeded bool
+}
+
+// domainLoad Load state of a domain, reset when it restarts
+type domainLoad struct {
+	start Timestamp
+	vcpus map[uint64]*vcpuLoad
+}
+
+// LoadTracker Computes the Load1, Load5 and Load15 of vCPUs from successive
+// samples of their run time, for hypervisors that don't report them. The
+// load of a vCPU is its utilization, 1 for a vCPU always running, averaged
+// exponentially over 1, 5 and 15 minutes whatever the sampling interval.
+// The first sample of a vCPU only records its time, the averages start
+// from the utilization over the second. The zero value is ready to use and
+// safe for concurrent use.
+type LoadTracker struct {
+	mu      sync.Mutex
+	domains map[DomainID]*domainLoad
+}
+
+// Observe Update the averages of the vCPUs of d from its sample and set
+// their Load fields, overwriting what the driver reported. A domain that
+// restarted, a vCPU whose time went backwards or appeared starts over,
+// vCPUs gone are forgotten. Samples not newer than the previous one only
+// set the current averages.
+func (t *LoadTracker) Observe(d *Domain) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.observe(d)
+}
+
+// ObserveAll Observe every domain of a collection and forget those not in
+// it, so that state of domains gone doesn't accumulate
+func (t *LoadTracker) ObserveAll(domains map[DomainID]*Domain) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id := range t.domains {
+		if _, ok := domains[id]; !ok {
+			delete(t.domains, id)
+		}
+	}
+	for _, d := range domains {
+		t.observe(d)
+	}
+}
+
+// Forget Drop the state of a domain, its next sample starts over
+func (t *LoadTracker) Forget(id DomainID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.domains, id)
+}
+
+func (t *LoadTracker) observe(d *Domain) {
+	if t.domains == nil {
+		t.domains = make(map[DomainID]*domainLoad)
+	}
+	now := d.Time
+	if now == 0 {
+		now = TimestampNow()
+	}
+
+	state := t.domains[d.ID]
+	if state == nil || state.start != d.StartTime {
+		state = &domainLoad{start: d.StartTime, vcpus: make(map[uint64]*vcpuLoad, len(d.Cpus))}
+		t.domains[d.ID] = state
+	}
+
+	seen := make(map[uint64]bool, len(d.Cpus))
+	for i := range d.Cpus {
+		cpu := &d.Cpus[i]
+		seen[cpu.ID] = true
+		v := state.vcpus[cpu.ID]
+		if v == nil || cpu.Time < v.time {
+			state.vcpus[cpu.ID] = &vcpuLoad{time: cpu.Time, at: now}
+			continue
+		}
+		if now > v.at {
+			v.update(cpu.Time, now)
+		}
+		if v.seeded {
+			cpu.Load1, cpu.Load5, cpu.Load15 = v.loads[0], v.loads[1], v.loads[2]
+		}
+	}
+	for id := range state.vcpus {
+		if !seen[id] {
+			delete(state.vcpus, id)
+		}
+	}
+}
+
+// update Fold the utilization since the previous sample into the averages,
+// decayed by how long ago that sample was
+func (v *vcpuLoad) update(cpuTime float64, now Timestamp) {
+	interval := now.Sub(v.at)
+	utilization := (cpuTime - v.time) / float64(interval)
+	for i, window := range loadWindows {
+		if !v.seeded {
+			v.loads[i] = utilization
+			continue
+		}
+		decay := math.Exp(-float64(interval) / float64(window))
+		v.loads[i] = v.loads[i]*decay + utilization*(1-decay)
+	}
+	v.seeded = true
+	v.time, v.at = cpuTime, now
+}
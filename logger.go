@@ -0,0 +1,44 @@
+package driver
+
+import "sync/atomic"
+
+// Logger Diagnostic output of the drivers, with slog style key-value
+// arguments. *slog.Logger satisfies it as is.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// loggerBox Fixed type wrapper, atomic.Value needs a consistent type
+type loggerBox struct {
+	Logger
+}
+
+var logger atomic.Value
+
+func init() {
+	logger.Store(loggerBox{nopLogger{}})
+}
+
+// SetLogger Route driver diagnostics to l, nil discards them (the default)
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	logger.Store(loggerBox{l})
+}
+
+// GetLogger Logger drivers report diagnostics to
+func GetLogger() Logger {
+	return logger.Load().(loggerBox).Logger
+}
+
+// nopLogger Logger discarding everything
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
@@ -0,0 +1,158 @@
+package lxc
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/virtmonitor/driver/internal/hvutil"
+)
+
+// v1Controllers cgroup v1 controllers read by the driver
+var v1Controllers = []string{"cpu", "cpuacct", "cpuset", "memory", "blkio", "freezer"}
+
+// cgroup Cgroup directories of a running container
+type cgroup struct {
+	// unified cgroup v2, dir is the container's directory in the hierarchy
+	unified bool
+	dir     string
+	// dirs cgroup v1 directory of the container per controller
+	dirs map[string]string
+}
+
+// findCgroup Locate the cgroup of a container, false if it isn't running.
+// LXC 4 places containers under lxc.payload.<name>, older releases under
+// lxc/<name>.
+func findCgroup(root, name string) (*cgroup, bool) {
+	rels := []string{"lxc.payload." + name, filepath.Join("lxc.payload", name), filepath.Join("lxc", name)}
+
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		for _, rel := range rels {
+			dir := filepath.Join(root, rel)
+			if isDir(dir) {
+				return &cgroup{unified: true, dir: dir}, true
+			}
+		}
+		return nil, false
+	}
+
+	for _, rel := range rels {
+		cg := &cgroup{dirs: make(map[string]string)}
+		for _, controller := range v1Controllers {
+			if dir := filepath.Join(root, controller, rel); isDir(dir) {
+				cg.dirs[controller] = dir
+			}
+		}
+		if len(cg.dirs) > 0 {
+			return cg, true
+		}
+	}
+	return nil, false
+}
+
+// path File of a controller, the controller is ignored on cgroup v2
+func (c *cgroup) path(controller, file string) string {
+	if c.unified {
+		return filepath.Join(c.dir, file)
+	}
+	dir, ok := c.dirs[controller]
+	if !ok {
+		// Missing controller, opening the empty path fails with fs.ErrNotExist
+		return ""
+	}
+	return filepath.Join(dir, file)
+}
+
+// pid First process found in the container's cgroup tree, walking into
+// child cgroups as systemd inside the container moves init there
+func (c *cgroup) pid() (int, bool) {
+	dir := c.dir
+	if !c.unified {
+		if dir = c.dirs["cpuacct"]; dir == "" {
+			dir = c.dirs["memory"]
+		}
+	}
+	if dir == "" {
+		return 0, false
+	}
+
+	var pid int
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || entry.Name() != "cgroup.procs" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		if fields := strings.Fields(string(data)); len(fields) > 0 {
+			pid, _ = strconv.Atoi(fields[0])
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return pid, pid > 0
+}
+
+// frozen Test if the container's cgroup is frozen
+func (c *cgroup) frozen() bool {
+	if c.unified {
+		events, err := hvutil.ReadKeyed(c.path("", "cgroup.events"))
+		return err == nil && events["frozen"] == 1
+	}
+	state, err := os.ReadFile(c.path("freezer", "freezer.state"))
+	return err == nil && strings.TrimSpace(string(state)) == "FROZEN"
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// readString Read a single value file
+func readString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readUint Read a single integer value file
+func readUint(path string) (uint64, error) {
+	s, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// configValue Value of the last assignment to key in an LXC config file,
+// empty if there is none
+func configValue(path, key string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var value string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		k, v, ok := strings.Cut(s.Text(), "=")
+		if ok && strings.TrimSpace(k) == key {
+			value = strings.TrimSpace(v)
+		}
+	}
+	return value, s.Err()
+}
+
+// missing Test if an error only means the file isn't there, because the
+// controller isn't enabled or the container stopped while being read
+func missing(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,720 @@
+package lxc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/internal/hvutil"
+)
+
+// unlimited cgroup v1 memory limits from here on mean no limit, the kernel
+// reports the page aligned maximum
+const unlimited = math.MaxInt64 / 2
+
+// collectContainer Collect a container into a domain of r, stopped
+// containers only carry their identity. nil if opts.Filter excludes it.
+func (l *LXC) collectContainer(name string, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	id := containerID(name)
+	if !opts.Keep(name, "", id) {
+		return nil, nil
+	}
+	d := r.Domain(id)
+	d.Name, d.ID, d.Hypervisor, d.Time = name, id, Hypervisor, driver.TimestampNow()
+	d.Flags = driver.DomainShutdown
+	// Containers are defined by their config file
+	d.Persistent, d.PersistentSet = true, true
+	if auto, err := configValue(filepath.Join(l.path, name, "config"), "lxc.start.auto"); err == nil {
+		d.Autostart, d.AutostartSet = auto == "1", true
+	}
+
+	cg, ok := findCgroup(l.cgroupRoot, name)
+	if !ok {
+		return d, nil
+	}
+	pid, ok := cg.pid()
+	if !ok {
+		return d, nil
+	}
+	d.Flags = driver.DomainOnline
+	d.StartTime, _ = driver.ProcessStartTime(pid)
+	if cg.frozen() {
+		d.Flags = driver.DomainPaused
+	}
+	if opts.Skips(d.Flags) {
+		return d, nil
+	}
+
+	if opts.CPUs {
+		if err := check(name, "cpu", collectCPU(cg, d, opts.Pinning, opts.NUMA)); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Blocks {
+		blocks, err := collectBlocks(cg, d.Blocks, opts.BlockCapacity, opts.Limits)
+		if err := check(name, "blocks", err); err != nil {
+			return nil, err
+		}
+		d.Blocks = blocks
+	}
+	if opts.Interfaces {
+		ifaces, err := collectInterfaces(pid, d.Interfaces)
+		if err := check(name, "interfaces", err); err != nil {
+			return nil, err
+		}
+		d.Interfaces = ifaces
+	}
+	if opts.Memory {
+		if err := check(name, "memory", collectMemory(cg, &d.Memory)); err != nil {
+			return nil, err
+		}
+	}
+	if opts.CPUTuning {
+		if err := check(name, "cpu tuning", collectCPUTuning(cg, &d.CPUTuning)); err != nil {
+			return nil, err
+		}
+	}
+	if opts.NUMA {
+		collectNUMA(cg, &d.NUMA)
+	}
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d, nil
+}
+
+// check Classify the failure reading a metric category. Missing files leave
+// the category unset and are only logged.
+func check(name, category string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if missing(err) {
+		driver.GetLogger().Debug("container statistics unavailable", "driver", Hypervisor, "domain", name, "category", category, "error", err)
+		return nil
+	}
+	return containerError(name, err)
+}
+
+// collectCPU A single CPU carrying the total usage of the container, cgroups
+// don't account time per vCPU. VCPUs is the number of CPUs the container may
+// run on.
+func collectCPU(cg *cgroup, d *driver.Domain, pinning, numa bool) error {
+	cpu := driver.CPU{Flags: driver.CPURunning}
+	if d.Flags == driver.DomainPaused {
+		cpu.Flags = driver.CPUPaused
+	}
+
+	if cg.unified {
+		stat, err := hvutil.ReadKeyed(cg.path("", "cpu.stat"))
+		if err != nil {
+			return err
+		}
+		cpu.Time = float64(stat["usage_usec"] * uint64(time.Microsecond))
+	} else {
+		usage, err := readUint(cg.path("cpuacct", "cpuacct.usage"))
+		if err != nil {
+			return err
+		}
+		cpu.Time = float64(usage)
+	}
+
+	// The cpuset can be widened up to every host CPU
+	d.VCPUs = runtime.NumCPU()
+	d.VCPUsMaximum = d.VCPUs
+	if set, ok := cpuset(cg); ok {
+		d.VCPUs = set.Count()
+		if pinning {
+			cpu.Affinity = set
+		}
+		if numa {
+			cpu.NUMANode, cpu.NUMANodeSet = driver.PinnedNode(set, driver.HostNUMANodes())
+		}
+	}
+	d.VCPUsCurrent = d.VCPUs
+	d.Cpus = append(d.Cpus[:0], cpu)
+	return nil
+}
+
+// cpuset Effective CPUs of the container
+func cpuset(cg *cgroup) (driver.CPUSet, bool) {
+	if !cg.unified {
+		return readSet(cg, "cpuset.effective_cpus", "cpuset.cpus")
+	}
+	return readSet(cg, "cpuset.cpus.effective")
+}
+
+// mems Effective memory nodes of the container
+func mems(cg *cgroup) (driver.CPUSet, bool) {
+	if !cg.unified {
+		return readSet(cg, "cpuset.effective_mems", "cpuset.mems")
+	}
+	return readSet(cg, "cpuset.mems.effective")
+}
+
+// readSet Set in the first non empty one of the cpuset files
+func readSet(cg *cgroup, files ...string) (driver.CPUSet, bool) {
+	for _, file := range files {
+		list, err := readString(cg.path("cpuset", file))
+		if err != nil || list == "" {
+			continue
+		}
+		if set, err := driver.ParseCPUSet(list); err == nil {
+			return set, true
+		}
+	}
+	return nil, false
+}
+
+// blockStat IO counters of one host block device
+type blockStat struct {
+	major, minor          uint64
+	readBytes, writeBytes uint64
+	readOps, writeOps     uint64
+}
+
+// collectBlocks Host block devices the container did IO on, appended to
+// blocks emptied. cgroups don't account flushes.
+func collectBlocks(cg *cgroup, blocks []driver.BlockDevice, capacity, limits bool) ([]driver.BlockDevice, error) {
+	var (
+		stats map[string]*blockStat
+		err   error
+	)
+	if cg.unified {
+		stats, err = ioStat(cg.path("", "io.stat"))
+	} else {
+		stats, err = blkioStat(cg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		throttle map[string]driver.BlockLimits
+		weight   uint64
+		weights  map[string]uint64
+	)
+	if limits {
+		if cg.unified {
+			throttle, err = ioMax(cg.path("", "io.max"))
+		} else {
+			throttle, err = blkioLimits(cg)
+		}
+		// Without the files throttling isn't available, like unlimited
+		if err != nil && !missing(err) {
+			return nil, err
+		}
+		if cg.unified {
+			weight, weights, err = ioWeight(cg.path("", "io.weight"))
+		} else {
+			weight, weights, err = blkioWeight(cg)
+		}
+		if err != nil && !missing(err) {
+			return nil, err
+		}
+	}
+
+	blocks = slices.Grow(blocks[:0], len(stats))
+	for dev, s := range stats {
+		sys := fmt.Sprintf("/sys/dev/block/%d:%d", s.major, s.minor)
+		block := driver.BlockDevice{
+			Name:   fmt.Sprintf("%d:%d", s.major, s.minor),
+			IsDisk: true,
+			Read:   blockIO(s.readOps, s.readBytes),
+			Write:  blockIO(s.writeOps, s.writeBytes),
+			Flush:  blockIO(0, 0),
+			Limits: throttle[dev],
+		}
+		if limits {
+			block.Limits.Weight = weight
+			if w, ok := weights[dev]; ok {
+				block.Limits.Weight = w
+			}
+		}
+		if target, err := os.Readlink(sys); err == nil {
+			block.Name = filepath.Base(target)
+			block.Source = "/dev/" + block.Name
+		}
+		// SCSI CD-ROM major
+		if s.major == 11 {
+			block.IsDisk, block.IsCDrom = false, true
+		}
+		if ro, err := readString(filepath.Join(sys, "ro")); err == nil {
+			block.ReadOnly = ro == "1"
+		}
+		// The size file counts 512 byte sectors regardless of the block size
+		if capacity {
+			if sectors, err := readUint(filepath.Join(sys, "size")); err == nil {
+				block.Capacity = sectors * 512
+				block.Physical = block.Capacity
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// ioStat Parse cgroup v2 io.stat, "MAJ:MIN rbytes=N wbytes=N rios=N ..."
+func ioStat(path string) (map[string]*blockStat, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := make(map[string]*blockStat)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		stat, ok := deviceStat(stats, fields[0])
+		if !ok {
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "rbytes":
+				stat.readBytes = v
+			case "wbytes":
+				stat.writeBytes = v
+			case "rios":
+				stat.readOps = v
+			case "wios":
+				stat.writeOps = v
+			}
+		}
+	}
+	return stats, s.Err()
+}
+
+// blkioStat Parse the cgroup v1 blkio throttle counters, "MAJ:MIN Read N"
+func blkioStat(cg *cgroup) (map[string]*blockStat, error) {
+	stats := make(map[string]*blockStat)
+	files := []struct {
+		name        string
+		read, write func(*blockStat, uint64)
+	}{
+		{"blkio.throttle.io_service_bytes", func(s *blockStat, v uint64) { s.readBytes = v }, func(s *blockStat, v uint64) { s.writeBytes = v }},
+		{"blkio.throttle.io_serviced", func(s *blockStat, v uint64) { s.readOps = v }, func(s *blockStat, v uint64) { s.writeOps = v }},
+	}
+
+	for _, file := range files {
+		// Prefer the counters including child cgroups, not every kernel has them
+		f, err := os.Open(cg.path("blkio", file.name+"_recursive"))
+		if missing(err) {
+			f, err = os.Open(cg.path("blkio", file.name))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			fields := strings.Fields(s.Text())
+			if len(fields) != 3 {
+				continue
+			}
+			stat, ok := deviceStat(stats, fields[0])
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseUint(fields[2], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch fields[1] {
+			case "Read":
+				file.read(stat, v)
+			case "Write":
+				file.write(stat, v)
+			}
+		}
+		err = s.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// ioMax Parse cgroup v2 io.max, "MAJ:MIN rbps=N wbps=max riops=N wiops=max"
+func ioMax(path string) (map[string]driver.BlockLimits, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	limits := make(map[string]driver.BlockLimits)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		var l driver.BlockLimits
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			// "max" is unlimited, left zero
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "rbps":
+				l.ReadBytesSec = v
+			case "wbps":
+				l.WriteBytesSec = v
+			case "riops":
+				l.ReadIOPS = v
+			case "wiops":
+				l.WriteIOPS = v
+			}
+		}
+		limits[fields[0]] = l
+	}
+	return limits, s.Err()
+}
+
+// blkioLimits Parse the cgroup v1 blkio throttle limits, "MAJ:MIN N"
+func blkioLimits(cg *cgroup) (map[string]driver.BlockLimits, error) {
+	limits := make(map[string]driver.BlockLimits)
+	files := []struct {
+		name string
+		set  func(*driver.BlockLimits, uint64)
+	}{
+		{"blkio.throttle.read_bps_device", func(l *driver.BlockLimits, v uint64) { l.ReadBytesSec = v }},
+		{"blkio.throttle.write_bps_device", func(l *driver.BlockLimits, v uint64) { l.WriteBytesSec = v }},
+		{"blkio.throttle.read_iops_device", func(l *driver.BlockLimits, v uint64) { l.ReadIOPS = v }},
+		{"blkio.throttle.write_iops_device", func(l *driver.BlockLimits, v uint64) { l.WriteIOPS = v }},
+	}
+
+	for _, file := range files {
+		f, err := os.Open(cg.path("blkio", file.name))
+		if err != nil {
+			return nil, err
+		}
+
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			fields := strings.Fields(s.Text())
+			if len(fields) != 2 {
+				continue
+			}
+			v, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			l := limits[fields[0]]
+			file.set(&l, v)
+			limits[fields[0]] = l
+		}
+		err = s.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return limits, nil
+}
+
+// ioWeight Parse cgroup v2 io.weight, the "default N" line and "MAJ:MIN N"
+// lines overriding it per device
+func ioWeight(path string) (uint64, map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	var def uint64
+	weights := make(map[string]uint64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if fields[0] == "default" {
+			def = v
+		} else {
+			weights[fields[0]] = v
+		}
+	}
+	return def, weights, s.Err()
+}
+
+// blkioWeight Parse the cgroup v1 blkio weights, blkio.weight and the
+// "MAJ:MIN N" lines of blkio.weight_device. Kernels without CFQ or BFQ have
+// neither.
+func blkioWeight(cg *cgroup) (uint64, map[string]uint64, error) {
+	def, err := readUint(cg.path("blkio", "blkio.weight"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	weights := make(map[string]uint64)
+	f, err := os.Open(cg.path("blkio", "blkio.weight_device"))
+	if missing(err) {
+		return def, weights, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			weights[fields[0]] = v
+		}
+	}
+	return def, weights, s.Err()
+}
+
+// deviceStat Counters of the device named "MAJ:MIN", created on first use
+func deviceStat(stats map[string]*blockStat, dev string) (*blockStat, bool) {
+	if stat, ok := stats[dev]; ok {
+		return stat, true
+	}
+
+	major, minor, ok := strings.Cut(dev, ":")
+	if !ok {
+		return nil, false
+	}
+	stat := &blockStat{}
+	var err error
+	if stat.major, err = strconv.ParseUint(major, 10, 64); err != nil {
+		return nil, false
+	}
+	if stat.minor, err = strconv.ParseUint(minor, 10, 64); err != nil {
+		return nil, false
+	}
+	stats[dev] = stat
+	return stat, true
+}
+
+func blockIO(ops, bytes uint64) driver.BlockIO {
+	return driver.BlockIO{
+		Operations: ops,
+		Bytes:      bytes,
+		Sectors:    bytes / 512,
+		Absolute:   true,
+	}
+}
+
+// collectInterfaces Interfaces of the container's network namespace, read
+// through its init process, appended to ifaces emptied. Loopback is skipped.
+func collectInterfaces(pid int, ifaces []driver.NetworkInterface) ([]driver.NetworkInterface, error) {
+	proc := "/proc/" + strconv.Itoa(pid)
+	f, err := os.Open(proc + "/net/dev")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ifaces = ifaces[:0]
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		name, counters, ok := strings.Cut(s.Text(), ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(counters)
+		if name == "lo" || len(fields) < 16 {
+			continue
+		}
+
+		values := make([]uint64, 16)
+		for i := range values {
+			values[i], _ = strconv.ParseUint(fields[i], 10, 64)
+		}
+		iface := driver.NetworkInterface{
+			Name: name,
+			RX: driver.NetworkIO{
+				Bytes: values[0], Packets: values[1], Errors: values[2], Drops: values[3],
+				FIFO: values[4], Frame: values[5],
+			},
+			TX: driver.NetworkIO{
+				Bytes: values[8], Packets: values[9], Errors: values[10], Drops: values[11],
+				FIFO: values[12], Collisions: values[13], Carrier: values[14],
+			},
+		}
+
+		// sysfs mounted in the container reflects its network namespace
+		sys := filepath.Join(proc, "root/sys/class/net", name)
+		if addr, err := readString(filepath.Join(sys, "address")); err == nil {
+			if mac, err := net.ParseMAC(addr); err == nil {
+				iface.Mac = mac
+			}
+		}
+		if state, err := readString(filepath.Join(sys, "operstate")); err == nil && state != "unknown" {
+			iface.LinkUp, iface.LinkUpSet = state == "up", true
+		}
+		if peer, ok := vethPeer(sys); ok {
+			iface.HostDevice = peer
+			iface.Bridges = driver.HostBridges(peer)
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, s.Err()
+}
+
+// vethPeer Host end of a veth pair whose container end has the sysfs
+// directory sys. The iflink of a veth is the ifindex of its peer, other
+// devices link to themselves.
+func vethPeer(sys string) (string, bool) {
+	index, err1 := readString(filepath.Join(sys, "ifindex"))
+	link, err2 := readString(filepath.Join(sys, "iflink"))
+	if err1 != nil || err2 != nil || index == link {
+		return "", false
+	}
+
+	devs, err := os.ReadDir("/sys/class/net")
+	if err != nil {
+		return "", false
+	}
+	for _, dev := range devs {
+		if i, err := readString(filepath.Join("/sys/class/net", dev.Name(), "ifindex")); err == nil && i == link {
+			return dev.Name(), true
+		}
+	}
+	return "", false
+}
+
+// collectMemory Memory usage and limit of the container's memory cgroup.
+// Usage is reported as RSS and the limit, when there is one, as Available.
+func collectMemory(cg *cgroup, m *driver.Memory) error {
+	usageFile, limitFile := "memory.usage_in_bytes", "memory.limit_in_bytes"
+	faults, majorFaults := "total_pgfault", "total_pgmajfault"
+	if cg.unified {
+		usageFile, limitFile = "memory.current", "memory.max"
+		faults, majorFaults = "pgfault", "pgmajfault"
+	}
+
+	usage, err := readUint(cg.path("memory", usageFile))
+	if err != nil {
+		return err
+	}
+	m.RSS, m.RSSSet = usage, true
+
+	// cgroup v2 writes "max" for no limit, which doesn't parse
+	if limit, err := readUint(cg.path("memory", limitFile)); err == nil && limit < unlimited {
+		m.Available, m.AvailableSet = limit, true
+		if limit > usage {
+			m.Unused, m.UnusedSet = limit-usage, true
+		}
+	}
+
+	stat, err := hvutil.ReadKeyed(cg.path("memory", "memory.stat"))
+	if err != nil {
+		return err
+	}
+	if major, ok := stat[majorFaults]; ok {
+		m.MajorFaults, m.MajorFaultsSet = major, true
+		if all, ok := stat[faults]; ok && all >= major {
+			m.MinorFaults, m.MinorFaultsSet = all-major, true
+		}
+	}
+	return nil
+}
+
+// collectCPUTuning Weight and bandwidth limit of the container's cpu
+// cgroup. The limit applies to the container as a whole, it is reported as
+// the global period and quota.
+func collectCPUTuning(cg *cgroup, t *driver.CPUTuning) error {
+	if cg.unified {
+		weight, err := readUint(cg.path("", "cpu.weight"))
+		if err != nil {
+			return err
+		}
+		t.Weight = weight
+
+		// "$MAX $PERIOD", $MAX being "max" when unlimited
+		limit, err := readString(cg.path("", "cpu.max"))
+		if err != nil {
+			return err
+		}
+		quota, period, _ := strings.Cut(limit, " ")
+		t.GlobalPeriod, _ = strconv.ParseUint(period, 10, 64)
+		t.GlobalQuota = -1
+		if quota != "max" {
+			t.GlobalQuota, _ = strconv.ParseInt(quota, 10, 64)
+		}
+		return nil
+	}
+
+	shares, err := readUint(cg.path("cpu", "cpu.shares"))
+	if err != nil {
+		return err
+	}
+	t.Shares = shares
+	if t.GlobalPeriod, err = readUint(cg.path("cpu", "cpu.cfs_period_us")); err != nil {
+		return err
+	}
+	// -1 when unlimited
+	quota, err := readString(cg.path("cpu", "cpu.cfs_quota_us"))
+	if err != nil {
+		return err
+	}
+	if t.GlobalQuota, err = strconv.ParseInt(quota, 10, 64); err != nil || t.GlobalQuota < 0 {
+		t.GlobalQuota = -1
+	}
+	return nil
+}
+
+// collectNUMA Memory nodes of the container's cpuset, which the kernel
+// enforces strictly. Containers allowed on every host node are unbound, and
+// share the host's NUMA topology rather than having cells of their own.
+func collectNUMA(cg *cgroup, n *driver.NUMA) {
+	nodes, ok := mems(cg)
+	if !ok || nodes.Count() >= len(driver.HostNUMANodes()) {
+		return
+	}
+	n.Mode, n.Nodes = "strict", nodes
+}
+
+// containerError Wrap a read failure, permission errors wrap
+// ErrPermissionDenied
+func containerError(name string, err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("lxc: %s: %w: %w", name, driver.ErrPermissionDenied, err)
+	}
+	return fmt.Errorf("lxc: %s: %w", name, err)
+}
+
+// fsError Wrap a failure listing containers
+func fsError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("lxc: %w: %w", driver.ErrPermissionDenied, err)
+	}
+	return fmt.Errorf("lxc: %w: %w", driver.ErrHypervisorUnavailable, err)
+}
@@ -0,0 +1,265 @@
+// Package lxc Driver collecting LXC containers from cgroups and procfs.
+//
+// Every directory holding a config file under the configured LXC path is a
+// container. Running containers are read from their cgroup, v1 or v2
+// depending on what is mounted, and from the network namespace of their
+// init process. Importing the package registers the driver under the name
+// "lxc" using DefaultPath and DefaultCgroupRoot; driver.NewDriver takes the
+// path and cgroup_root keys.
+package lxc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/virtmonitor/driver"
+)
+
+const (
+	// Hypervisor Hypervisor name reported by the LXC driver
+	Hypervisor driver.DomainHypervisor = "lxc"
+	// DefaultPath Default LXC path holding one directory per container
+	DefaultPath = "/var/lib/lxc"
+	// DefaultCgroupRoot Default cgroup file system mount point
+	DefaultCgroupRoot = "/sys/fs/cgroup"
+)
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New(DefaultPath, DefaultCgroupRoot)); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		Keys: []string{"path", "cgroup_root"},
+		New: func(cfg driver.Config) (driver.Driver, error) {
+			return New(cfg.String("path", DefaultPath), cfg.String("cgroup_root", DefaultCgroupRoot)), nil
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// LXC LXC driver
+type LXC struct {
+	path       string
+	cgroupRoot string
+}
+
+// New Create an LXC driver for the containers under path, with cgroups
+// mounted at cgroupRoot
+func New(path, cgroupRoot string) *LXC {
+	return &LXC{path: path, cgroupRoot: cgroupRoot}
+}
+
+// Name Hypervisor name
+func (l *LXC) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Capabilities Supported metrics. Containers share the host kernel, only
+// total CPU usage is accounted and block devices are the host's.
+func (l *LXC) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:          true,
+		SupportsBlocks:        true,
+		SupportsInterfaces:    true,
+		SupportsMemory:        true,
+		SupportsBlockCapacity: true,
+		SupportsPinning:       true,
+		SupportsLimits:        true,
+		SupportsCPUTuning:     true,
+		SupportsNUMA:          true,
+	}
+}
+
+// Detect Test if the LXC path and the cgroup file system are present
+func (l *LXC) Detect() bool {
+	return l.Diagnose().Detected
+}
+
+// Diagnose Tell a missing LXC path from a missing cgroup file system,
+// counting the containers when both are present
+func (l *LXC) Diagnose() driver.DetectResult {
+	info, err := os.Stat(l.path)
+	switch {
+	case err != nil:
+		return driver.DetectResult{Reason: "no LXC path at " + l.path, Err: fsError(err)}
+	case !info.IsDir():
+		return driver.DetectResult{Reason: l.path + " isn't a directory"}
+	}
+	if _, err := os.Stat(l.cgroupRoot); err != nil {
+		return driver.DetectResult{Reason: "no cgroup file system at " + l.cgroupRoot, Err: fsError(err)}
+	}
+
+	names, err := l.containers()
+	switch {
+	case err != nil:
+		return driver.DetectResult{Detected: true, Reason: "listing containers failed: " + err.Error()}
+	case len(names) == 0:
+		return driver.DetectResult{Detected: true, Reason: "no containers defined under " + l.path}
+	}
+	return driver.DetectResult{Detected: true, Reason: fmt.Sprintf("%d containers", len(names))}
+}
+
+// Collect Collect containers
+func (l *LXC) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return l.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect every container, stopped ones with
+// CollectOptions.IncludeInactive
+func (l *LXC) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	names, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) {
+		names, err := l.containers()
+		if err != nil {
+			return nil, fsError(err)
+		}
+		return names, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.CollectDomains(ctx, opts, names, l.collector(opts, nil))
+}
+
+// CollectInto Collect every container as CollectContext does into dst,
+// reusing its domains, see driver.CollectInto
+func (l *LXC) CollectInto(ctx context.Context, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	names, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) {
+		names, err := l.containers()
+		if err != nil {
+			return nil, fsError(err)
+		}
+		return names, nil
+	})
+	if err != nil {
+		clear(dst)
+		return err
+	}
+
+	return driver.CollectDomainsInto(ctx, opts, names, dst, func(ctx context.Context, name string, r *driver.Recycler) (*driver.Domain, error) {
+		return l.collector(opts, r)(ctx, name)
+	})
+}
+
+// CollectStream Collect every container as CollectContext does, emitting
+// each as soon as it's collected
+func (l *LXC) CollectStream(ctx context.Context, opts driver.CollectOptions, emit func(*driver.Domain) error) error {
+	names, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) {
+		names, err := l.containers()
+		if err != nil {
+			return nil, fsError(err)
+		}
+		return names, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return driver.StreamDomains(ctx, opts, names, l.collector(opts, nil), emit)
+}
+
+// collector Collect function of the containers listed by CollectContext,
+// CollectInto and CollectStream, filling the domains of r
+func (l *LXC) collector(opts driver.CollectOptions, r *driver.Recycler) func(context.Context, string) (*driver.Domain, error) {
+	return func(ctx context.Context, name string) (*driver.Domain, error) {
+		d, err := l.collectContainer(name, opts, r)
+		if err != nil {
+			return nil, &driver.DomainError{ID: containerID(name), Name: name, Err: err}
+		}
+		return d, nil
+	}
+}
+
+// CollectDomain Collect a single container by ID
+func (l *LXC) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	opts = opts.Unfiltered()
+	names, err := l.containers()
+	if err != nil {
+		return nil, fsError(err)
+	}
+
+	for _, name := range names {
+		if containerID(name) == id {
+			return l.collectContainer(name, opts, nil)
+		}
+	}
+	return nil, fmt.Errorf("lxc: domain %d: %w", id, driver.ErrDomainNotFound)
+}
+
+// CollectDomainByUUID Containers have no UUID, any valid UUID is a miss
+func (l *LXC) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	if !driver.ValidUUID(uuid) {
+		return nil, fmt.Errorf("lxc: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+	return nil, fmt.Errorf("lxc: domain %s: %w", uuid, driver.ErrDomainNotFound)
+}
+
+// CollectDomainByName Collect a single container by name
+func (l *LXC) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	if filepath.Base(name) != name || !l.isContainer(name) {
+		return nil, fmt.Errorf("lxc: domain %q: %w", name, driver.ErrDomainNotFound)
+	}
+	opts = opts.Unfiltered()
+	return l.collectContainer(name, opts, nil)
+}
+
+// CollectSnapshots LXC snapshots are managed by liblxc and aren't read
+func (l *LXC) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	return nil, fmt.Errorf("lxc: snapshots: %w", driver.ErrNotSupported)
+}
+
+// Host Metrics of the local host, which the containers share
+func (l *LXC) Host() (*driver.HostInfo, error) {
+	return driver.LocalHostInfo()
+}
+
+// Ping Test if the LXC path and the cgroup file system are still readable,
+// there is no connection to check
+func (l *LXC) Ping(ctx context.Context) error {
+	for _, path := range []string{l.path, l.cgroupRoot} {
+		if _, err := os.Stat(path); err != nil {
+			return fsError(err)
+		}
+	}
+	return nil
+}
+
+// Watch LXC has no event source readable without liblxc
+func (l *LXC) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	return nil, fmt.Errorf("lxc: watch: %w", driver.ErrNotSupported)
+}
+
+// Close Nothing to release, files are opened per read
+func (l *LXC) Close() error {
+	return nil
+}
+
+// containers Names of the containers defined under the LXC path
+func (l *LXC) containers() ([]string, error) {
+	entries, err := os.ReadDir(l.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && l.isContainer(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (l *LXC) isContainer(name string) bool {
+	_, err := os.Stat(filepath.Join(l.path, name, "config"))
+	return err == nil
+}
+
+// containerID Stable domain ID hashed from the container name, containers
+// have no numeric ID and their init PID changes across restarts
+func containerID(name string) driver.DomainID {
+	return driver.HashDomainID(name)
+}
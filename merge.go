@@ -0,0 +1,236 @@
+package driver
+
+// MergePolicy Which collection's value Merge keeps when both hold one
+type MergePolicy int
+
+const (
+	// MergeBaseWins Values of base are kept, overlay only fills the fields
+	// base leaves zero or unset
+	MergeBaseWins MergePolicy = iota
+	// MergeOverlayWins Values of overlay replace those of base, the fields
+	// overlay leaves zero or unset keep the value of base
+	MergeOverlayWins
+)
+
+// Merge Enrich the domains of base with those of overlay, base winning, see
+// MergeWith
+func Merge(base, overlay map[DomainID]*Domain) map[DomainID]*Domain {
+	return MergeWith(base, overlay, MergeBaseWins)
+}
+
+// MergeWith Enrich the domains of base with those of overlay, such as the
+// cheap identity and state of one driver with the statistics of another.
+// Domains are matched by UUID, or by name when neither has one. The result
+// holds the domains of base, under their IDs, merged with the matching one
+// of overlay; domains only in overlay are left out. The inputs aren't
+// modified and share nothing with the result.
+//
+// A field is merged when the overlay domain holds a value for it: one that
+// isn't zero or, for fields with a Set flag, that is set. Under
+// MergeBaseWins the value fills base only where it holds none, under
+// MergeOverlayWins it replaces the value of base. ID, Hypervisor and the
+// driver-private data stay those of base. Flags, whose zero value is
+// DomainOnline, and Time follow the policy whatever their value.
+//
+// Devices are matched like Diff matches them, vCPUs and IO threads by ID and
+// the others by name (file systems by mountpoint, graphics by type, host
+// devices by address). Matched devices are merged field by field, IO
+// counters as a whole so that the Absolute flag stays consistent. Devices
+// only in overlay are appended, other slices and labels are merged as a
+// whole and by key.
+func MergeWith(base, overlay map[DomainID]*Domain, policy MergePolicy) map[DomainID]*Domain {
+	byUUID := make(map[string]*Domain, len(overlay))
+	byName := make(map[string]*Domain)
+	for _, d := range overlay {
+		if d.UUID != "" {
+			byUUID[d.UUID] = d
+		} else {
+			byName[d.Name] = d
+		}
+	}
+
+	out := make(map[DomainID]*Domain, len(base))
+	for id, d := range base {
+		o := byUUID[d.UUID]
+		if d.UUID == "" {
+			o = byName[d.Name]
+		}
+		out[id] = MergeDomain(d, o, policy)
+	}
+	return out
+}
+
+// MergeDomain Copy of base enriched with overlay as MergeWith does, a plain
+// copy of base if overlay is nil
+func MergeDomain(base, overlay *Domain, policy MergePolicy) *Domain {
+	d := base.Clone()
+	if d == nil || overlay == nil {
+		return d
+	}
+	o := overlay.Clone()
+	over := policy == MergeOverlayWins
+
+	if over {
+		d.Flags, d.Time = o.Flags, o.Time
+	}
+	mergeValue(&d.Name, o.Name, over)
+	mergeValue(&d.UUID, o.UUID, over)
+	mergeValue(&d.OSType, o.OSType, over)
+	mergeValue(&d.MachineType, o.MachineType, over)
+	mergeValue(&d.EmulatorVersion, o.EmulatorVersion, over)
+	mergeValue(&d.StartTime, o.StartTime, over)
+	mergeValue(&d.GuestAgent, o.GuestAgent, over)
+	mergeSet(&d.Persistent, &d.PersistentSet, o.Persistent, o.PersistentSet, over)
+	mergeSet(&d.Autostart, &d.AutostartSet, o.Autostart, o.AutostartSet, over)
+
+	mergeValue(&d.VCPUs, o.VCPUs, over)
+	mergeValue(&d.VCPUsCurrent, o.VCPUsCurrent, over)
+	mergeValue(&d.VCPUsMaximum, o.VCPUsMaximum, over)
+	mergeSet(&d.NestedVirt, &d.NestedVirtSet, o.NestedVirt, o.NestedVirtSet, over)
+	mergeValue(&d.Topology, o.Topology, over)
+	mergeValue(&d.CPUTuning, o.CPUTuning, over)
+	mergeValue(&d.NUMA.Mode, o.NUMA.Mode, over)
+	mergeSlice(&d.NUMA.Nodes, o.NUMA.Nodes, over)
+	d.NUMA.Cells = mergeDevices(d.NUMA.Cells, o.NUMA.Cells, func(c NUMACell) uint64 { return c.ID },
+		func(dst *NUMACell, src NUMACell) {
+			mergeSlice(&dst.CPUs, src.CPUs, over)
+			mergeValue(&dst.Memory, src.Memory, over)
+			mergeValue(&dst.Mode, src.Mode, over)
+			mergeSlice(&dst.Nodes, src.Nodes, over)
+		})
+	d.IOThreads = mergeDevices(d.IOThreads, o.IOThreads, func(t IOThread) uint64 { return t.ID },
+		func(dst *IOThread, src IOThread) {
+			mergeSet(&dst.Time, &dst.TimeSet, src.Time, src.TimeSet, over)
+			mergeSlice(&dst.Affinity, src.Affinity, over)
+		})
+	mergeSet(&d.EmulatorTime, &d.EmulatorTimeSet, o.EmulatorTime, o.EmulatorTimeSet, over)
+
+	d.Cpus = mergeDevices(d.Cpus, o.Cpus, func(c CPU) uint64 { return c.ID }, func(dst *CPU, src CPU) {
+		if over {
+			dst.Flags = src.Flags
+		}
+		mergeValue(&dst.Time, src.Time, over)
+		mergeSet(&dst.Idle, &dst.IdleSet, src.Idle, src.IdleSet, over)
+		mergeValue(&dst.Load1, src.Load1, over)
+		mergeValue(&dst.Load5, src.Load5, over)
+		mergeValue(&dst.Load15, src.Load15, over)
+		mergeSet(&dst.Steal, &dst.StealSet, src.Steal, src.StealSet, over)
+		mergeSet(&dst.IOWait, &dst.IOWaitSet, src.IOWait, src.IOWaitSet, over)
+		mergeSet(&dst.PhysicalCPU, &dst.PhysicalCPUSet, src.PhysicalCPU, src.PhysicalCPUSet, over)
+		mergeSlice(&dst.Affinity, src.Affinity, over)
+		mergeSet(&dst.NUMANode, &dst.NUMANodeSet, src.NUMANode, src.NUMANodeSet, over)
+	})
+	d.Blocks = mergeDevices(d.Blocks, o.Blocks, func(b BlockDevice) string { return b.Name },
+		func(dst *BlockDevice, src BlockDevice) {
+			mergeValue(&dst.ReadOnly, src.ReadOnly, over)
+			mergeValue(&dst.IsDisk, src.IsDisk, over)
+			mergeValue(&dst.IsCDrom, src.IsCDrom, over)
+			mergeValue(&dst.Read, src.Read, over)
+			mergeValue(&dst.Write, src.Write, over)
+			mergeValue(&dst.Flush, src.Flush, over)
+			mergeValue(&dst.Stalled, src.Stalled, over)
+			mergeValue(&dst.Bus, src.Bus, over)
+			mergeValue(&dst.Target, src.Target, over)
+			mergeValue(&dst.Source, src.Source, over)
+			mergeSlice(&dst.BackingChain, src.BackingChain, over)
+			mergeValue(&dst.Capacity, src.Capacity, over)
+			mergeValue(&dst.Allocation, src.Allocation, over)
+			mergeValue(&dst.Physical, src.Physical, over)
+			mergeValue(&dst.Limits, src.Limits, over)
+			mergeValue(&dst.Throttled, src.Throttled, over)
+		})
+	d.Interfaces = mergeDevices(d.Interfaces, o.Interfaces, func(n NetworkInterface) string { return n.Name },
+		func(dst *NetworkInterface, src NetworkInterface) {
+			mergeSlice(&dst.Mac, src.Mac, over)
+			mergeSlice(&dst.Bridges, src.Bridges, over)
+			mergeValue(&dst.RX, src.RX, over)
+			mergeValue(&dst.TX, src.TX, over)
+			mergeValue(&dst.HostDevice, src.HostDevice, over)
+			mergeSlice(&dst.Addresses, src.Addresses, over)
+			mergeSet(&dst.LinkUp, &dst.LinkUpSet, src.LinkUp, src.LinkUpSet, over)
+			mergeValue(&dst.InboundLimit, src.InboundLimit, over)
+			mergeValue(&dst.OutboundLimit, src.OutboundLimit, over)
+		})
+
+	m, om := &d.Memory, &o.Memory
+	mergeSet(&m.Actual, &m.ActualSet, om.Actual, om.ActualSet, over)
+	mergeSet(&m.Available, &m.AvailableSet, om.Available, om.AvailableSet, over)
+	mergeSet(&m.Unused, &m.UnusedSet, om.Unused, om.UnusedSet, over)
+	mergeSet(&m.RSS, &m.RSSSet, om.RSS, om.RSSSet, over)
+	mergeSet(&m.SwapIn, &m.SwapInSet, om.SwapIn, om.SwapInSet, over)
+	mergeSet(&m.SwapOut, &m.SwapOutSet, om.SwapOut, om.SwapOutSet, over)
+	mergeSet(&m.MajorFaults, &m.MajorFaultsSet, om.MajorFaults, om.MajorFaultsSet, over)
+	mergeSet(&m.MinorFaults, &m.MinorFaultsSet, om.MinorFaults, om.MinorFaultsSet, over)
+	mergeSet(&m.DirtyRate, &m.DirtyRateSet, om.DirtyRate, om.DirtyRateSet, over)
+	mergeValue(&d.MemoryBacking.BalloonCurrent, o.MemoryBacking.BalloonCurrent, over)
+	mergeValue(&d.MemoryBacking.BalloonMaximum, o.MemoryBacking.BalloonMaximum, over)
+	mergeValue(&d.MemoryBacking.Hugepages, o.MemoryBacking.Hugepages, over)
+	mergeValue(&d.MemoryBacking.HugepageSize, o.MemoryBacking.HugepageSize, over)
+
+	d.Filesystems = mergeDevices(d.Filesystems, o.Filesystems, func(f Filesystem) string { return f.Mountpoint },
+		func(dst *Filesystem, src Filesystem) { mergeValue(dst, src, over) })
+	mergeValue(&d.Title, o.Title, over)
+	mergeValue(&d.Description, o.Description, over)
+	for k, v := range o.Labels {
+		if _, ok := d.Labels[k]; ok && !over {
+			continue
+		}
+		if d.Labels == nil {
+			d.Labels = make(map[string]string, len(o.Labels))
+		}
+		d.Labels[k] = v
+	}
+	d.Graphics = mergeDevices(d.Graphics, o.Graphics, func(g GraphicsDevice) string { return g.Type },
+		func(dst *GraphicsDevice, src GraphicsDevice) {
+			mergeSlice(&dst.Listen, src.Listen, over)
+			mergeValue(&dst.Port, src.Port, over)
+			mergeValue(&dst.TLSPort, src.TLSPort, over)
+			mergeValue(&dst.Password, src.Password, over)
+		})
+	mergeSlice(&d.Consoles, o.Consoles, over)
+	d.HostDevices = mergeDevices(d.HostDevices, o.HostDevices, func(h HostDevice) string { return h.Address },
+		func(dst *HostDevice, src HostDevice) { mergeValue(dst, src, over) })
+	mergeValue(&d.CollectDuration, o.CollectDuration, over)
+
+	d.SortDevices()
+	return d
+}
+
+// mergeValue Merge src into dst unless src is zero
+func mergeValue[T comparable](dst *T, src T, over bool) {
+	var zero T
+	if src != zero && (over || *dst == zero) {
+		*dst = src
+	}
+}
+
+// mergeSet Merge a value valid when its Set flag is
+func mergeSet[T any](dst *T, dstSet *bool, src T, srcSet, over bool) {
+	if srcSet && (over || !*dstSet) {
+		*dst, *dstSet = src, true
+	}
+}
+
+// mergeSlice Merge a slice as a whole unless src is empty
+func mergeSlice[S ~[]E, E any](dst *S, src S, over bool) {
+	if len(src) > 0 && (over || len(*dst) == 0) {
+		*dst = src
+	}
+}
+
+// mergeDevices Merge the devices of src into those of dst matched by key,
+// appending those dst lacks
+func mergeDevices[T any, K comparable](dst, src []T, key func(T) K, merge func(*T, T)) []T {
+	index := make(map[K]int, len(dst))
+	for i, dev := range dst {
+		index[key(dev)] = i
+	}
+	for _, dev := range src {
+		if i, ok := index[key(dev)]; ok {
+			merge(&dst[i], dev)
+		} else {
+			dst = append(dst, dev)
+		}
+	}
+	return dst
+}
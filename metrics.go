@@ -0,0 +1,170 @@
+package driver
+
+import "strconv"
+
+// MetricKind How a numeric field evolves between collections
+type MetricKind int
+
+const (
+	// Counter Monotonically increasing value, exported as a rate. Block IO
+	// counters are per interval deltas when BlockIO.Absolute is unset.
+	Counter MetricKind = iota
+	// Gauge Instantaneous value
+	Gauge
+)
+
+// String Human readable metric kind
+func (k MetricKind) String() string {
+	switch k {
+	case Counter:
+		return "counter"
+	case Gauge:
+		return "gauge"
+	}
+	return "MetricKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// MetricUnit Unit of a numeric field
+type MetricUnit string
+
+// Units of the numeric fields
+const (
+	UnitBytes          MetricUnit = "bytes"
+	UnitBytesPerSecond MetricUnit = "bytes_per_second"
+	UnitPackets        MetricUnit = "packets"
+	UnitOperations     MetricUnit = "operations"
+	UnitOpsPerSecond   MetricUnit = "operations_per_second"
+	UnitSectors        MetricUnit = "sectors"
+	UnitNanoseconds    MetricUnit = "nanoseconds"
+	UnitMicroseconds   MetricUnit = "microseconds"
+	UnitFaults         MetricUnit = "faults"
+	UnitCount          MetricUnit = "count"
+	// UnitLoad Run queue length averaged over an interval
+	UnitLoad MetricUnit = "load"
+	// UnitWeight Relative share, in the hypervisor's range
+	UnitWeight MetricUnit = "weight"
+	// UnitPagesPerSecond 4 KiB memory pages per second
+	UnitPagesPerSecond MetricUnit = "pages_per_second"
+)
+
+// MetricDescriptor Classification of a numeric Domain field
+type MetricDescriptor struct {
+	// Path Go field path from Domain, [] marking the elements of a slice
+	// (e.g. Blocks[].Read.Bytes)
+	Path string
+	Kind MetricKind
+	Unit MetricUnit
+	// Set Path of the flag telling whether the field is valid, empty when it
+	// always is
+	Set string
+	// Option CollectOptions field the metric is collected with, empty when
+	// it always is
+	Option string
+	// Help One line description
+	Help string
+}
+
+var metricDescriptors = []MetricDescriptor{
+	{Path: "VCPUs", Kind: Gauge, Unit: UnitCount, Help: "vCPUs configured"},
+	{Path: "VCPUsCurrent", Kind: Gauge, Unit: UnitCount, Option: "CPUs", Help: "vCPUs online"},
+	{Path: "VCPUsMaximum", Kind: Gauge, Unit: UnitCount, Option: "CPUs", Help: "vCPUs the domain can be hotplugged up to"},
+	{Path: "Topology.Sockets", Kind: Gauge, Unit: UnitCount, Option: "CPUs", Help: "CPU sockets presented to the guest"},
+	{Path: "Topology.CoresPerSocket", Kind: Gauge, Unit: UnitCount, Option: "CPUs", Help: "Cores of each guest CPU socket"},
+	{Path: "Topology.ThreadsPerCore", Kind: Gauge, Unit: UnitCount, Option: "CPUs", Help: "Threads of each guest CPU core"},
+	{Path: "CollectDuration", Kind: Gauge, Unit: UnitNanoseconds, Help: "Time spent collecting the domain"},
+
+	{Path: "CPUTuning.Shares", Kind: Gauge, Unit: UnitCount, Option: "CPUTuning", Help: "Relative CPU weight as cgroup v1 shares"},
+	{Path: "CPUTuning.Weight", Kind: Gauge, Unit: UnitCount, Option: "CPUTuning", Help: "Relative CPU weight as cgroup v2 weight"},
+	{Path: "CPUTuning.Period", Kind: Gauge, Unit: UnitMicroseconds, Option: "CPUTuning", Help: "Bandwidth control period of each vCPU"},
+	{Path: "CPUTuning.Quota", Kind: Gauge, Unit: UnitMicroseconds, Option: "CPUTuning", Help: "CPU time each vCPU may use per period, -1 when unlimited"},
+	{Path: "CPUTuning.GlobalPeriod", Kind: Gauge, Unit: UnitMicroseconds, Option: "CPUTuning", Help: "Bandwidth control period of the domain"},
+	{Path: "CPUTuning.GlobalQuota", Kind: Gauge, Unit: UnitMicroseconds, Option: "CPUTuning", Help: "CPU time the domain may use per period, -1 when unlimited"},
+	{Path: "CPUTuning.EmulatorPeriod", Kind: Gauge, Unit: UnitMicroseconds, Option: "CPUTuning", Help: "Bandwidth control period of the emulator threads"},
+	{Path: "CPUTuning.EmulatorQuota", Kind: Gauge, Unit: UnitMicroseconds, Option: "CPUTuning", Help: "CPU time the emulator threads may use per period, -1 when unlimited"},
+	{Path: "CPUTuning.IOThreadPeriod", Kind: Gauge, Unit: UnitMicroseconds, Option: "CPUTuning", Help: "Bandwidth control period of each IO thread"},
+	{Path: "CPUTuning.IOThreadQuota", Kind: Gauge, Unit: UnitMicroseconds, Option: "CPUTuning", Help: "CPU time each IO thread may use per period, -1 when unlimited"},
+
+	{Path: "Cpus[].Time", Kind: Counter, Unit: UnitNanoseconds, Option: "CPUs", Help: "Time the vCPU has run"},
+	{Path: "Cpus[].Idle", Kind: Counter, Unit: UnitNanoseconds, Set: "Cpus[].IdleSet", Option: "CPUs", Help: "Time the vCPU has idled"},
+	{Path: "Cpus[].Steal", Kind: Counter, Unit: UnitNanoseconds, Set: "Cpus[].StealSet", Option: "CPUs", Help: "Time the vCPU waited for a physical CPU"},
+	{Path: "Cpus[].IOWait", Kind: Counter, Unit: UnitNanoseconds, Set: "Cpus[].IOWaitSet", Option: "CPUs", Help: "Time the guest waited for IO on the vCPU"},
+	{Path: "Cpus[].Load1", Kind: Gauge, Unit: UnitLoad, Option: "CPUs", Help: "vCPU load over 1 minute"},
+	{Path: "Cpus[].Load5", Kind: Gauge, Unit: UnitLoad, Option: "CPUs", Help: "vCPU load over 5 minutes"},
+	{Path: "Cpus[].Load15", Kind: Gauge, Unit: UnitLoad, Option: "CPUs", Help: "vCPU load over 15 minutes"},
+
+	{Path: "IOThreads[].Time", Kind: Counter, Unit: UnitNanoseconds, Set: "IOThreads[].TimeSet", Option: "CPUs", Help: "Time the IO thread has run"},
+	{Path: "EmulatorTime", Kind: Counter, Unit: UnitNanoseconds, Set: "EmulatorTimeSet", Option: "CPUs", Help: "Time the hypervisor threads other than vCPUs and IO threads have run"},
+
+	{Path: "Blocks[].Read.Operations", Kind: Counter, Unit: UnitOperations, Option: "Blocks", Help: "Read operations"},
+	{Path: "Blocks[].Read.Bytes", Kind: Counter, Unit: UnitBytes, Option: "Blocks", Help: "Bytes read"},
+	{Path: "Blocks[].Read.Sectors", Kind: Counter, Unit: UnitSectors, Option: "Blocks", Help: "Sectors read"},
+	{Path: "Blocks[].Read.TotalTime", Kind: Counter, Unit: UnitNanoseconds, Set: "Blocks[].Read.TotalTimeSet", Option: "Blocks", Help: "Time spent completing reads"},
+	{Path: "Blocks[].Read.Errors", Kind: Counter, Unit: UnitOperations, Option: "Blocks", Help: "Failed reads"},
+	{Path: "Blocks[].Write.Operations", Kind: Counter, Unit: UnitOperations, Option: "Blocks", Help: "Write operations"},
+	{Path: "Blocks[].Write.Bytes", Kind: Counter, Unit: UnitBytes, Option: "Blocks", Help: "Bytes written"},
+	{Path: "Blocks[].Write.Sectors", Kind: Counter, Unit: UnitSectors, Option: "Blocks", Help: "Sectors written"},
+	{Path: "Blocks[].Write.TotalTime", Kind: Counter, Unit: UnitNanoseconds, Set: "Blocks[].Write.TotalTimeSet", Option: "Blocks", Help: "Time spent completing writes"},
+	{Path: "Blocks[].Write.Errors", Kind: Counter, Unit: UnitOperations, Option: "Blocks", Help: "Failed writes"},
+	{Path: "Blocks[].Flush.Operations", Kind: Counter, Unit: UnitOperations, Option: "Blocks", Help: "Flush operations"},
+	{Path: "Blocks[].Flush.TotalTime", Kind: Counter, Unit: UnitNanoseconds, Set: "Blocks[].Flush.TotalTimeSet", Option: "Blocks", Help: "Time spent completing flushes"},
+	{Path: "Blocks[].Flush.Errors", Kind: Counter, Unit: UnitOperations, Option: "Blocks", Help: "Failed flushes"},
+	{Path: "Blocks[].Capacity", Kind: Gauge, Unit: UnitBytes, Option: "BlockCapacity", Help: "Logical size seen by the guest"},
+	{Path: "Blocks[].Allocation", Kind: Gauge, Unit: UnitBytes, Option: "BlockCapacity", Help: "Bytes allocated in the backing store"},
+	{Path: "Blocks[].Physical", Kind: Gauge, Unit: UnitBytes, Option: "BlockCapacity", Help: "Size of the backing store"},
+	{Path: "Blocks[].Limits.ReadIOPS", Kind: Gauge, Unit: UnitOpsPerSecond, Option: "Limits", Help: "Read operations limit"},
+	{Path: "Blocks[].Limits.WriteIOPS", Kind: Gauge, Unit: UnitOpsPerSecond, Option: "Limits", Help: "Write operations limit"},
+	{Path: "Blocks[].Limits.TotalIOPS", Kind: Gauge, Unit: UnitOpsPerSecond, Option: "Limits", Help: "Combined operations limit"},
+	{Path: "Blocks[].Limits.ReadBytesSec", Kind: Gauge, Unit: UnitBytesPerSecond, Option: "Limits", Help: "Read bandwidth limit"},
+	{Path: "Blocks[].Limits.WriteBytesSec", Kind: Gauge, Unit: UnitBytesPerSecond, Option: "Limits", Help: "Write bandwidth limit"},
+	{Path: "Blocks[].Limits.TotalBytesSec", Kind: Gauge, Unit: UnitBytesPerSecond, Option: "Limits", Help: "Combined bandwidth limit"},
+	{Path: "Blocks[].Limits.Weight", Kind: Gauge, Unit: UnitWeight, Option: "Limits", Help: "Proportional IO share"},
+
+	{Path: "Interfaces[].RX.Bytes", Kind: Counter, Unit: UnitBytes, Option: "Interfaces", Help: "Bytes received"},
+	{Path: "Interfaces[].RX.Packets", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Packets received"},
+	{Path: "Interfaces[].RX.Errors", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Receive errors"},
+	{Path: "Interfaces[].RX.Drops", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Received packets dropped"},
+	{Path: "Interfaces[].RX.FIFO", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Receive buffer overruns"},
+	{Path: "Interfaces[].RX.Frame", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Received frames with a bad length or checksum"},
+	{Path: "Interfaces[].TX.Bytes", Kind: Counter, Unit: UnitBytes, Option: "Interfaces", Help: "Bytes transmitted"},
+	{Path: "Interfaces[].TX.Packets", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Packets transmitted"},
+	{Path: "Interfaces[].TX.Errors", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Transmit errors"},
+	{Path: "Interfaces[].TX.Drops", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Transmitted packets dropped"},
+	{Path: "Interfaces[].TX.FIFO", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Transmit buffer overruns"},
+	{Path: "Interfaces[].TX.Collisions", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Transmit collisions"},
+	{Path: "Interfaces[].TX.Carrier", Kind: Counter, Unit: UnitPackets, Option: "Interfaces", Help: "Transmit carrier losses"},
+	{Path: "Interfaces[].InboundLimit", Kind: Gauge, Unit: UnitBytesPerSecond, Option: "Limits", Help: "Bandwidth limit of traffic to the guest"},
+	{Path: "Interfaces[].OutboundLimit", Kind: Gauge, Unit: UnitBytesPerSecond, Option: "Limits", Help: "Bandwidth limit of traffic from the guest"},
+
+	{Path: "Memory.Actual", Kind: Gauge, Unit: UnitBytes, Set: "Memory.ActualSet", Option: "Memory", Help: "Current balloon size"},
+	{Path: "Memory.Available", Kind: Gauge, Unit: UnitBytes, Set: "Memory.AvailableSet", Option: "Memory", Help: "Memory visible to the guest"},
+	{Path: "Memory.Unused", Kind: Gauge, Unit: UnitBytes, Set: "Memory.UnusedSet", Option: "Memory", Help: "Memory unused by the guest"},
+	{Path: "Memory.RSS", Kind: Gauge, Unit: UnitBytes, Set: "Memory.RSSSet", Option: "Memory", Help: "Resident set size of the hypervisor process"},
+	{Path: "Memory.SwapIn", Kind: Counter, Unit: UnitBytes, Set: "Memory.SwapInSet", Option: "Memory", Help: "Bytes swapped in by the guest"},
+	{Path: "Memory.SwapOut", Kind: Counter, Unit: UnitBytes, Set: "Memory.SwapOutSet", Option: "Memory", Help: "Bytes swapped out by the guest"},
+	{Path: "Memory.MajorFaults", Kind: Counter, Unit: UnitFaults, Set: "Memory.MajorFaultsSet", Option: "Memory", Help: "Major page faults in the guest"},
+	{Path: "Memory.MinorFaults", Kind: Counter, Unit: UnitFaults, Set: "Memory.MinorFaultsSet", Option: "Memory", Help: "Minor page faults in the guest"},
+	{Path: "Memory.DirtyRate", Kind: Gauge, Unit: UnitPagesPerSecond, Set: "Memory.DirtyRateSet", Option: "DirtyRate", Help: "Guest memory pages written per second"},
+	{Path: "MemoryBacking.BalloonCurrent", Kind: Gauge, Unit: UnitBytes, Option: "Memory", Help: "Memory the balloon targets"},
+	{Path: "MemoryBacking.BalloonMaximum", Kind: Gauge, Unit: UnitBytes, Option: "Memory", Help: "Memory the balloon can grow up to"},
+	{Path: "MemoryBacking.HugepageSize", Kind: Gauge, Unit: UnitBytes, Option: "Memory", Help: "Size of the hugepages backing guest memory"},
+
+	{Path: "Filesystems[].TotalBytes", Kind: Gauge, Unit: UnitBytes, Option: "Filesystems", Help: "Size of the guest file system"},
+	{Path: "Filesystems[].UsedBytes", Kind: Gauge, Unit: UnitBytes, Option: "Filesystems", Help: "Bytes used in the guest file system"},
+}
+
+// MetricDescriptors Kind and unit of every numeric Domain field, for
+// exporters generated from metadata. The slice is a copy.
+func MetricDescriptors() []MetricDescriptor {
+	return append([]MetricDescriptor(nil), metricDescriptors...)
+}
+
+// MetricDescriptorByPath Descriptor of the field at path, false if the
+// field isn't a metric
+func MetricDescriptorByPath(path string) (MetricDescriptor, bool) {
+	for _, m := range metricDescriptors {
+		if m.Path == path {
+			return m, true
+		}
+	}
+	return MetricDescriptor{}, false
+}
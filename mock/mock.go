@@ -0,0 +1,485 @@
+// Package mock Programmable driver for testing code built on the driver package.
+//
+// Importing the package registers Default under the name "mock" so it can be
+// found through driver.AvailableDrivers and driver.GetDriver; driver.NewDriver
+// creates new mocks, it takes no key.
+package mock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// Hypervisor Hypervisor name reported by the mock driver
+const Hypervisor driver.DomainHypervisor = "mock"
+
+// Default Mock instance registered with the driver package
+var Default = New()
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), Default); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		New: func(driver.Config) (driver.Driver, error) { return New(), nil },
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// Result A single queued collection result. Domains are returned along with
+// an Err made of driver.DomainErrors only, as from a collection where some
+// domains failed; any other Err fails the collection as a whole.
+type Result struct {
+	Domains []*driver.Domain
+	Err     error
+}
+
+// Mock Mock driver, safe for concurrent use
+type Mock struct {
+	mu       sync.Mutex
+	detect   bool
+	caps     driver.Capabilities
+	latency  time.Duration
+	domains  []*driver.Domain
+	host     *driver.HostInfo
+	snaps    map[driver.DomainID][]driver.Snapshot
+	err      error
+	closeErr error
+	pingErr  error
+	queue    []Result
+	calls    int
+	closed   bool
+
+	watchers map[*watcher]struct{}
+}
+
+// watcher An active Watch, fed by Emit
+type watcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	in     chan driver.DomainEvent
+}
+
+// New Create a mock that detects successfully, collects nothing and claims
+// support for every metric category, snapshots and events
+func New() *Mock {
+	return &Mock{
+		detect: true,
+		caps: driver.Capabilities{
+			SupportsCPUs:          true,
+			SupportsBlocks:        true,
+			SupportsInterfaces:    true,
+			SupportsMemory:        true,
+			SupportsFilesystems:   true,
+			SupportsGraphics:      true,
+			SupportsMetadata:      true,
+			SupportsHostDevices:   true,
+			SupportsCPUTuning:     true,
+			SupportsNUMA:          true,
+			SupportsGuestIP:       true,
+			SupportsBlockCapacity: true,
+			SupportsBackingChain:  true,
+			SupportsPinning:       true,
+			SupportsLimits:        true,
+			SupportsSnapshots:     true,
+			SupportsEvents:        true,
+			SupportsVersions:      true,
+			SupportsDirtyRate:     true,
+		},
+		snaps:    make(map[driver.DomainID][]driver.Snapshot),
+		watchers: make(map[*watcher]struct{}),
+	}
+}
+
+// WithDomains Domains returned by Collect once the queue is empty
+func (m *Mock) WithDomains(domains ...*driver.Domain) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.domains = domains
+	return m
+}
+
+// WithHost Host info returned by Host
+func (m *Mock) WithHost(h *driver.HostInfo) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.host = h
+	return m
+}
+
+// WithSnapshots Snapshots returned by CollectSnapshots for domain id
+func (m *Mock) WithSnapshots(id driver.DomainID, snaps ...driver.Snapshot) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snaps[id] = snaps
+	return m
+}
+
+// WithCollectError Error returned by Collect once the queue is empty
+func (m *Mock) WithCollectError(err error) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+	return m
+}
+
+// WithCloseError Error returned by Close
+func (m *Mock) WithCloseError(err error) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closeErr = err
+	return m
+}
+
+// WithPingError Error returned by Ping
+func (m *Mock) WithPingError(err error) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pingErr = err
+	return m
+}
+
+// WithLatency Delay every Collect by d, cut short if the context is done
+func (m *Mock) WithLatency(d time.Duration) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latency = d
+	return m
+}
+
+// WithDetect Value returned by Detect
+func (m *Mock) WithDetect(detect bool) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.detect = detect
+	return m
+}
+
+// WithCapabilities Value returned by Capabilities
+func (m *Mock) WithCapabilities(caps driver.Capabilities) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.caps = caps
+	return m
+}
+
+// Queue Append results returned by successive Collect calls, in order,
+// before falling back to WithDomains/WithCollectError
+func (m *Mock) Queue(results ...Result) *Mock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queue = append(m.queue, results...)
+	return m
+}
+
+// Calls Number of collection calls made so far
+func (m *Mock) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// Closed Test if Close has been called
+func (m *Mock) Closed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closed
+}
+
+// Name Hypervisor name
+func (m *Mock) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Priority Rank below every real driver
+func (m *Mock) Priority() int {
+	return -100
+}
+
+// Capabilities Return the configured capabilities
+func (m *Mock) Capabilities() driver.Capabilities {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.caps
+}
+
+// Detect Return the configured detection result
+func (m *Mock) Detect() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.detect
+}
+
+// Diagnose Report the programmed detection
+func (m *Mock) Diagnose() driver.DetectResult {
+	if m.Detect() {
+		return driver.DetectResult{Detected: true, Reason: "mock detection enabled"}
+	}
+	return driver.DetectResult{Reason: "mock detection disabled"}
+}
+
+// Collect Return the next programmed result
+func (m *Mock) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return m.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Return clones of the domains of the next programmed result
+// after the configured latency. Domains sharing an ID are added as
+// driver.AddDomain does.
+func (m *Mock) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	result, err := driver.Enumerate(ctx, opts, func(ctx context.Context) (Result, error) { return m.next(ctx, true) })
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make(map[driver.DomainID]*driver.Domain, len(result.Domains))
+	errs := []error{result.Err}
+	for _, d := range result.Domains {
+		if opts.Keep(d.Name, d.UUID, d.ID) && opts.KeepState(d.Flags) {
+			if err := driver.AddDomain(domains, d.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return domains, errors.Join(errs...)
+}
+
+// CollectSlice Return clones of the domains of the next programmed result
+// as a slice, see driver.SliceCollector
+func (m *Mock) CollectSlice(ctx context.Context, opts driver.CollectOptions) ([]*driver.Domain, error) {
+	result, err := driver.Enumerate(ctx, opts, func(ctx context.Context) (Result, error) { return m.next(ctx, true) })
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]*driver.Domain, 0, len(result.Domains))
+	for _, d := range result.Domains {
+		if opts.Keep(d.Name, d.UUID, d.ID) && opts.KeepState(d.Flags) {
+			domains = append(domains, d.Clone())
+		}
+	}
+	return domains, result.Err
+}
+
+// CollectDomain Find a domain in the current programmed result without
+// advancing the queue
+func (m *Mock) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := m.find(func(d *driver.Domain) bool { return d.ID == id })
+	if err == nil && d == nil {
+		err = fmt.Errorf("mock: domain %d: %w", id, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// CollectDomainByUUID Find a domain by UUID in the current programmed result
+func (m *Mock) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	uuid, err := driver.NormalizeUUID(uuid)
+	if err != nil {
+		return nil, fmt.Errorf("mock: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+
+	d, err := m.find(func(d *driver.Domain) bool {
+		u, _ := driver.NormalizeUUID(d.UUID)
+		return u == uuid
+	})
+	if err == nil && d == nil {
+		err = fmt.Errorf("mock: domain %s: %w", uuid, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// CollectDomainByName Find a domain by name in the current programmed result
+func (m *Mock) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := m.find(func(d *driver.Domain) bool { return d.Name == name })
+	if err == nil && d == nil {
+		err = fmt.Errorf("mock: domain %q: %w", name, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// find Clone of the first domain in the current programmed result accepted
+// by match
+func (m *Mock) find(match func(*driver.Domain) bool) (*driver.Domain, error) {
+	result, err := m.next(context.Background(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range result.Domains {
+		if match(d) {
+			return d.Clone(), nil
+		}
+	}
+	return nil, nil
+}
+
+// CollectSnapshots Return the programmed snapshots of a domain in the
+// current programmed result, ErrNotSupported unless the capabilities include
+// snapshots
+func (m *Mock) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	m.mu.Lock()
+	supported := m.caps.SupportsSnapshots
+	m.mu.Unlock()
+	if !supported {
+		return nil, fmt.Errorf("mock: snapshots: %w", driver.ErrNotSupported)
+	}
+
+	d, err := m.CollectDomain(id, driver.CollectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]driver.Snapshot(nil), m.snaps[d.ID]...), nil
+}
+
+// Host Return the programmed host info, ErrNotSupported if there is none
+func (m *Mock) Host() (*driver.HostInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.host == nil {
+		return nil, fmt.Errorf("mock: host: %w", driver.ErrNotSupported)
+	}
+	h := *m.host
+	return &h, nil
+}
+
+// Ping Return the programmed ping error
+func (m *Mock) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pingErr
+}
+
+// Watch Return a channel fed by Emit, ErrNotSupported unless the
+// capabilities include events. Closed when ctx is done or on Close.
+func (m *Mock) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.caps.SupportsEvents {
+		return nil, fmt.Errorf("mock: watch: %w", driver.ErrNotSupported)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &watcher{ctx: ctx, cancel: cancel, in: make(chan driver.DomainEvent)}
+	m.watchers[w] = struct{}{}
+
+	out := make(chan driver.DomainEvent)
+	go func() {
+		defer close(out)
+		defer func() {
+			m.mu.Lock()
+			delete(m.watchers, w)
+			m.mu.Unlock()
+		}()
+
+		for {
+			select {
+			case ev := <-w.in:
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Emit Deliver events to every active Watch, blocking until each watcher
+// has received them or has ended
+func (m *Mock) Emit(events ...driver.DomainEvent) {
+	m.mu.Lock()
+	watchers := make([]*watcher, 0, len(m.watchers))
+	for w := range m.watchers {
+		watchers = append(watchers, w)
+	}
+	m.mu.Unlock()
+
+	for _, w := range watchers {
+		for _, ev := range events {
+			select {
+			case w.in <- ev:
+			case <-w.ctx.Done():
+			}
+		}
+	}
+}
+
+// next Current programmed result, popped off the queue if pop is set,
+// returned after the configured latency. The error is that of a collection
+// failing as a whole: an Err made of DomainErrors only is left in the result.
+func (m *Mock) next(ctx context.Context, pop bool) (Result, error) {
+	m.mu.Lock()
+	m.calls++
+	latency := m.latency
+	result := Result{Domains: m.domains, Err: m.err}
+	if len(m.queue) > 0 {
+		result = m.queue[0]
+		if pop {
+			m.queue = m.queue[1:]
+		}
+	}
+	m.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return Result{}, ctx.Err()
+		}
+	} else if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	if partial(result.Err) {
+		return result, nil
+	}
+	return result, result.Err
+}
+
+// partial Test if err is made of driver.DomainErrors only
+func partial(err error) bool {
+	switch e := err.(type) {
+	case *driver.DomainError:
+		return true
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			if !partial(err) {
+				return false
+			}
+		}
+		return len(e.Unwrap()) > 0
+	}
+	return false
+}
+
+// Close Mark the mock closed, end active watches and return the
+// configured close error
+func (m *Mock) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for w := range m.watchers {
+		w.cancel()
+	}
+	return m.closeErr
+}
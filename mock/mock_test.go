@@ -0,0 +1,79 @@
+package mock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/virtmonitor/driver"
+)
+
+func TestCollectClones(t *testing.T) {
+	d := &driver.Domain{ID: 1, Name: "vm", Cpus: []driver.CPU{{ID: 0}}}
+	m := New().WithDomains(d)
+
+	domains, err := m.CollectContext(context.Background(), driver.CollectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	domains[1].Name = "changed"
+	domains[1].Cpus[0].Time = 1
+
+	slice, err := m.CollectSlice(context.Background(), driver.CollectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slice[0].Cpus[0].Time = 2
+
+	found, err := m.CollectDomain(1, driver.CollectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found.Cpus[0].Time = 3
+
+	if d.Name != "vm" || d.Cpus[0].Time != 0 {
+		t.Errorf("programmed domain modified through the collected ones: %+v", d)
+	}
+}
+
+func TestCollectPartialResult(t *testing.T) {
+	failed := &driver.DomainError{ID: 2, Name: "broken", Err: driver.ErrDomainNotFound}
+	m := New().Queue(
+		Result{Domains: []*driver.Domain{{ID: 1}}, Err: failed},
+		Result{Domains: []*driver.Domain{{ID: 1}}, Err: errors.Join(failed, &driver.DomainError{ID: 3, Err: driver.ErrPermissionDenied})},
+		Result{Domains: []*driver.Domain{{ID: 1}}, Err: driver.ErrHypervisorUnavailable},
+	)
+
+	domains, err := m.CollectContext(context.Background(), driver.CollectOptions{})
+	if len(domains) != 1 || !errors.Is(err, failed) {
+		t.Errorf("single DomainError: got %d domains, %v", len(domains), err)
+	}
+	slice, err := m.CollectSlice(context.Background(), driver.CollectOptions{})
+	if len(slice) != 1 || len(driver.DomainErrors(err)) != 2 {
+		t.Errorf("joined DomainErrors: got %d domains, %v", len(slice), err)
+	}
+	domains, err = m.CollectContext(context.Background(), driver.CollectOptions{})
+	if domains != nil || !errors.Is(err, driver.ErrHypervisorUnavailable) {
+		t.Errorf("collection failure: got %v, %v", domains, err)
+	}
+}
+
+func TestCollectDomainNotFound(t *testing.T) {
+	m := New().WithDomains(&driver.Domain{ID: 1, Name: "vm", UUID: "6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f"})
+
+	var opts driver.CollectOptions
+	const uuid = "00000000-0000-0000-0000-000000000002"
+	lookups := map[string]func() (*driver.Domain, error){
+		"ID":   func() (*driver.Domain, error) { return m.CollectDomain(2, opts) },
+		"UUID": func() (*driver.Domain, error) { return m.CollectDomainByUUID(uuid, opts) },
+		"Name": func() (*driver.Domain, error) { return m.CollectDomainByName("other", opts) },
+	}
+	for name, lookup := range lookups {
+		if d, err := lookup(); d != nil || !errors.Is(err, driver.ErrDomainNotFound) {
+			t.Errorf("by %s: got %v, %v, want ErrDomainNotFound", name, d, err)
+		}
+	}
+	if _, err := m.CollectDomain(1, opts); err != nil {
+		t.Errorf("existing domain: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// sysClassNet sysfs directory of the host network devices
+const sysClassNet = "/sys/class/net"
+
+// maxMasters Bound on the master chain followed by HostBridges
+const maxMasters = 8
+
+// HostBridges Bridges the host network device dev is attached to, read from
+// sysfs following its masters upwards (a tap in a bond in a bridge),
+// nearest first. Open vSwitch ports have no bridge there, their master is
+// ovs-system. Nil when dev has no bridge or sysfs isn't available.
+func HostBridges(dev string) []string {
+	var bridges []string
+	for i := 0; i < maxMasters && dev != ""; i++ {
+		master, err := os.Readlink(filepath.Join(sysClassNet, dev, "master"))
+		if err != nil {
+			break
+		}
+		dev = filepath.Base(master)
+		if _, err := os.Stat(filepath.Join(sysClassNet, dev, "bridge")); err == nil {
+			bridges = append(bridges, dev)
+		}
+	}
+	return bridges
+}
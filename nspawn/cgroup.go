@@ -0,0 +1,235 @@
+package nspawn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/internal/hvutil"
+)
+
+// unlimited Memory limits from here on mean no limit
+const unlimited = math.MaxInt64 / 2
+
+// findCgroup Directory of the cgroup of a machine's unit. The leader runs
+// in a child of it, nspawn moves the payload to a payload subgroup and
+// systemd inside the container to init.scope below it, so the leader's
+// cgroup is walked up to the unit's. When the leader's cgroup doesn't name
+// the unit, the leader's own cgroup is read.
+func findCgroup(root string, leader int, unit string) (string, error) {
+	if leader <= 0 {
+		return "", fmt.Errorf("no leader process: %w", fs.ErrNotExist)
+	}
+	f, err := os.Open("/proc/" + strconv.Itoa(leader) + "/cgroup")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// The unified hierarchy is the "0::" line
+	var rel string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if path, ok := strings.CutPrefix(s.Text(), "0::"); ok {
+			rel = path
+			break
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	if rel == "" {
+		return "", fmt.Errorf("process %d isn't in a cgroup v2 hierarchy: %w", leader, fs.ErrNotExist)
+	}
+
+	elems := strings.Split(strings.Trim(rel, "/"), "/")
+	for i := len(elems) - 1; i >= 0; i-- {
+		if unit != "" && elems[i] == unit {
+			elems = elems[:i+1]
+			break
+		}
+	}
+	return filepath.Join(append([]string{root}, elems...)...), nil
+}
+
+// frozen Test if the cgroup is frozen, by machinectl freeze or systemctl
+// freeze of the unit
+func frozen(dir string) bool {
+	events, err := hvutil.ReadKeyed(filepath.Join(dir, "cgroup.events"))
+	return err == nil && events["frozen"] == 1
+}
+
+// collectCPU A single CPU carrying the total usage of the machine, cgroups
+// don't account time per vCPU. VCPUs is the number of CPUs the machine may
+// run on.
+func collectCPU(dir string, d *driver.Domain, pinning bool) error {
+	cpu := driver.CPU{Flags: driver.CPURunning}
+	if d.Flags == driver.DomainPaused {
+		cpu.Flags = driver.CPUPaused
+	}
+
+	stat, err := hvutil.ReadKeyed(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return err
+	}
+	cpu.Time = float64(stat["usage_usec"] * uint64(time.Microsecond))
+
+	// The cpuset can be widened up to every host CPU
+	d.VCPUs = runtime.NumCPU()
+	d.VCPUsMaximum = d.VCPUs
+	if list, err := readString(filepath.Join(dir, "cpuset.cpus.effective")); err == nil && list != "" {
+		if set, err := driver.ParseCPUSet(list); err == nil {
+			d.VCPUs = set.Count()
+			if pinning {
+				cpu.Affinity = set
+			}
+		}
+	}
+	d.VCPUsCurrent = d.VCPUs
+	d.Cpus = append(d.Cpus[:0], cpu)
+	return nil
+}
+
+// collectBlocks Host block devices the machine did IO on, from io.stat
+// lines such as "MAJ:MIN rbytes=N wbytes=N rios=N wios=N ...", appended
+// to blocks emptied. cgroups don't account flushes.
+func collectBlocks(dir string, blocks []driver.BlockDevice, capacity bool) ([]driver.BlockDevice, error) {
+	f, err := os.Open(filepath.Join(dir, "io.stat"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	blocks = blocks[:0]
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		var major, minor uint64
+		if _, err := fmt.Sscanf(fields[0], "%d:%d", &major, &minor); err != nil {
+			continue
+		}
+
+		var readBytes, writeBytes, readOps, writeOps uint64
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "rbytes":
+				readBytes = v
+			case "wbytes":
+				writeBytes = v
+			case "rios":
+				readOps = v
+			case "wios":
+				writeOps = v
+			}
+		}
+
+		sys := fmt.Sprintf("/sys/dev/block/%d:%d", major, minor)
+		block := driver.BlockDevice{
+			Name:   fields[0],
+			IsDisk: true,
+			Read:   blockIO(readOps, readBytes),
+			Write:  blockIO(writeOps, writeBytes),
+			Flush:  blockIO(0, 0),
+		}
+		if target, err := os.Readlink(sys); err == nil {
+			block.Name = filepath.Base(target)
+			block.Source = "/dev/" + block.Name
+		}
+		// SCSI CD-ROM major
+		if major == 11 {
+			block.IsDisk, block.IsCDrom = false, true
+		}
+		if ro, err := readString(filepath.Join(sys, "ro")); err == nil {
+			block.ReadOnly = ro == "1"
+		}
+		// The size file counts 512 byte sectors regardless of the block size
+		if capacity {
+			if sectors, err := readUint(filepath.Join(sys, "size")); err == nil {
+				block.Capacity = sectors * 512
+				block.Physical = block.Capacity
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, s.Err()
+}
+
+func blockIO(ops, bytes uint64) driver.BlockIO {
+	return driver.BlockIO{
+		Operations: ops,
+		Bytes:      bytes,
+		Sectors:    bytes / 512,
+		Absolute:   true,
+	}
+}
+
+// collectMemory Memory usage and limit of the machine's cgroup. Usage is
+// reported as RSS and the limit, when there is one, as Available.
+func collectMemory(dir string, m *driver.Memory) error {
+	usage, err := readUint(filepath.Join(dir, "memory.current"))
+	if err != nil {
+		return err
+	}
+	m.RSS, m.RSSSet = usage, true
+
+	// "max" for no limit doesn't parse
+	if limit, err := readUint(filepath.Join(dir, "memory.max")); err == nil && limit < unlimited {
+		m.Available, m.AvailableSet = limit, true
+		if limit > usage {
+			m.Unused, m.UnusedSet = limit-usage, true
+		}
+	}
+
+	stat, err := hvutil.ReadKeyed(filepath.Join(dir, "memory.stat"))
+	if err != nil {
+		return err
+	}
+	if major, ok := stat["pgmajfault"]; ok {
+		m.MajorFaults, m.MajorFaultsSet = major, true
+		if all, ok := stat["pgfault"]; ok && all >= major {
+			m.MinorFaults, m.MinorFaultsSet = all-major, true
+		}
+	}
+	return nil
+}
+
+// readString Read a single value file
+func readString(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readUint Read a single integer value file
+func readUint(path string) (uint64, error) {
+	s, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// missing Test if an error only means the file isn't there, because the
+// controller isn't enabled or the machine stopped while being read
+func missing(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,544 @@
+package nspawn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// D-Bus message types and header fields, from the D-Bus specification
+const (
+	msgMethodCall   = 1
+	msgMethodReturn = 2
+	msgError        = 3
+
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSignature   = 8
+)
+
+// maxMessage Largest message accepted, the specification's limit
+const maxMessage = 128 << 20
+
+// dbusErr Error reply, the connection remains usable
+type dbusErr struct {
+	Name    string
+	Message string
+}
+
+func (e *dbusErr) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// dbusErrorName Name of the D-Bus error err holds, empty if it holds none
+func dbusErrorName(err error) string {
+	var derr *dbusErr
+	if errors.As(err, &derr) {
+		return derr.Name
+	}
+	return ""
+}
+
+// dbus Minimal D-Bus client making method calls with string arguments,
+// calls are serialized. Signals the bus sends unrequested are discarded.
+type dbus struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	r      *bufio.Reader
+	serial uint32
+}
+
+// openBus Connect to the bus listening on the unix socket at path,
+// authenticating as the user running the process
+func openBus(ctx context.Context, path string) (*dbus, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, err
+	}
+	b := &dbus{conn: conn, r: bufio.NewReader(conn)}
+	if err := b.auth(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("dbus auth: %w", err)
+	}
+	if _, err := b.call(ctx, "org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// auth Authenticate with the EXTERNAL mechanism, the bus checks the uid
+// against the socket's peer credentials
+func (b *dbus) auth(ctx context.Context) error {
+	b.deadline(ctx)
+	uid := hexString(strconv.Itoa(os.Getuid()))
+	if _, err := io.WriteString(b.conn, "\x00AUTH EXTERNAL "+uid+"\r\n"); err != nil {
+		return err
+	}
+	line, err := b.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("rejected: %s", strings.TrimSpace(line))
+	}
+	_, err = io.WriteString(b.conn, "BEGIN\r\n")
+	return err
+}
+
+func hexString(s string) string {
+	const digits = "0123456789abcdef"
+	h := make([]byte, 0, 2*len(s))
+	for i := 0; i < len(s); i++ {
+		h = append(h, digits[s[i]>>4], digits[s[i]&0x0f])
+	}
+	return string(h)
+}
+
+// deadline Bound the next reads and writes by the deadline of ctx
+func (b *dbus) deadline(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	b.conn.SetDeadline(deadline)
+}
+
+// call Call member of iface on the object at path of dest, every argument
+// is a string. The reply's body is returned decoded, see decoder.value.
+func (b *dbus) call(ctx context.Context, dest, path, iface, member string, args ...string) ([]any, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.deadline(ctx)
+	// A call cut short by ctx leaves the connection unusable, unblock it
+	stop := context.AfterFunc(ctx, func() { b.conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	b.serial++
+	if _, err := b.conn.Write(methodCall(b.serial, dest, path, iface, member, args)); err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+
+	for {
+		typ, fields, body, err := b.readMessage()
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		if reply, _ := fields[fieldReplySerial].(uint32); reply != b.serial || (typ != msgMethodReturn && typ != msgError) {
+			continue
+		}
+
+		sig, _ := fields[fieldSignature].(string)
+		values, err := body.values(sig)
+		if err != nil {
+			return nil, fmt.Errorf("dbus %s.%s: %w", iface, member, err)
+		}
+		if typ == msgError {
+			derr := &dbusErr{}
+			derr.Name, _ = fields[fieldErrorName].(string)
+			if len(values) > 0 {
+				derr.Message, _ = values[0].(string)
+			}
+			return nil, derr
+		}
+		return values, nil
+	}
+}
+
+// ctxErr The context's error when it ended a call, err otherwise
+func ctxErr(ctx context.Context, err error) error {
+	if cerr := ctx.Err(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// methodCall Marshal a method call with string arguments
+func methodCall(serial uint32, dest, path, iface, member string, args []string) []byte {
+	var body encoder
+	for _, arg := range args {
+		body.string(arg)
+	}
+
+	var e encoder
+	e.buf.WriteString("l")
+	e.buf.WriteByte(msgMethodCall)
+	e.buf.WriteByte(0)
+	e.buf.WriteByte(1)
+	e.uint32(uint32(body.buf.Len()))
+	e.uint32(serial)
+
+	var fields encoder
+	// The fields array starts at offset 16, aligned, offsets inside it are
+	// relative to the array as they would be to the message
+	header := func(code byte, sig byte, value string) {
+		fields.align(8)
+		fields.buf.WriteByte(code)
+		fields.signature(string(sig))
+		if sig == 'g' {
+			fields.signature(value)
+		} else {
+			fields.string(value)
+		}
+	}
+	header(fieldPath, 'o', path)
+	header(fieldInterface, 's', iface)
+	header(fieldMember, 's', member)
+	header(fieldDestination, 's', dest)
+	if len(args) > 0 {
+		header(fieldSignature, 'g', strings.Repeat("s", len(args)))
+	}
+	e.uint32(uint32(fields.buf.Len()))
+	e.buf.Write(fields.buf.Bytes())
+	e.align(8)
+	e.buf.Write(body.buf.Bytes())
+	return e.buf.Bytes()
+}
+
+// readMessage Read a message, its header fields by code and its body
+func (b *dbus) readMessage() (byte, map[byte]any, *decoder, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(b.r, fixed); err != nil {
+		return 0, nil, nil, err
+	}
+
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return 0, nil, nil, fmt.Errorf("dbus: invalid endianness %q", fixed[0])
+	}
+	bodyLen, fieldsLen := order.Uint32(fixed[4:8]), order.Uint32(fixed[12:16])
+	// The body is aligned on 8 bytes after the header fields
+	headerLen := (16 + uint64(fieldsLen) + 7) &^ 7
+	if headerLen+uint64(bodyLen) > maxMessage {
+		return 0, nil, nil, fmt.Errorf("dbus: message of %d bytes too large", headerLen+uint64(bodyLen))
+	}
+
+	msg := make([]byte, headerLen+uint64(bodyLen))
+	copy(msg, fixed)
+	if _, err := io.ReadFull(b.r, msg[16:]); err != nil {
+		return 0, nil, nil, err
+	}
+
+	header := &decoder{order: order, buf: msg[:16+fieldsLen], pos: 12}
+	values, err := header.values("a(yv)")
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("dbus: header: %w", err)
+	}
+	fields := make(map[byte]any)
+	for _, field := range values[0].([]any) {
+		f := field.([]any)
+		fields[f[0].(byte)] = f[1]
+	}
+	return fixed[1], fields, &decoder{order: order, buf: msg[headerLen:]}, nil
+}
+
+func (b *dbus) close() error {
+	return b.conn.Close()
+}
+
+// encoder Marshal little endian values, offsets are those of buf
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *encoder) align(n int) {
+	for e.buf.Len()%n != 0 {
+		e.buf.WriteByte(0)
+	}
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.align(4)
+	binary.Write(&e.buf, binary.LittleEndian, v)
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.buf.WriteString(s)
+	e.buf.WriteByte(0)
+}
+
+func (e *encoder) signature(s string) {
+	e.buf.WriteByte(byte(len(s)))
+	e.buf.WriteString(s)
+	e.buf.WriteByte(0)
+}
+
+// decoder Unmarshal values from buf, offsets are those of buf which must
+// start on an 8 byte boundary of the message
+type decoder struct {
+	order binary.ByteOrder
+	buf   []byte
+	pos   int
+}
+
+var errTruncated = errors.New("truncated message")
+
+// values Decode the values of the complete types of sig
+func (d *decoder) values(sig string) ([]any, error) {
+	var values []any
+	for sig != "" {
+		n, err := typeLen(sig)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(sig[:n], 0)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		sig = sig[n:]
+	}
+	return values, nil
+}
+
+// value Decode a value of the single complete type sig. Integers decode to
+// the Go type of their size, strings, object paths and signatures to
+// string, arrays of bytes to []byte, arrays of dict entries keyed by string
+// to map[string]any, other arrays and structs to []any and variants to the
+// value they hold.
+func (d *decoder) value(sig string, depth int) (any, error) {
+	if depth > 64 {
+		return nil, errors.New("signature nested too deep")
+	}
+	d.align(alignment(sig[0]))
+
+	switch sig[0] {
+	case 'y':
+		b, err := d.next(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'b':
+		v, err := d.uint32()
+		return v != 0, err
+	case 'n':
+		b, err := d.next(2)
+		if err != nil {
+			return nil, err
+		}
+		return int16(d.order.Uint16(b)), nil
+	case 'q':
+		b, err := d.next(2)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint16(b), nil
+	case 'i':
+		v, err := d.uint32()
+		return int32(v), err
+	case 'u', 'h':
+		return d.uint32()
+	case 'x':
+		v, err := d.uint64()
+		return int64(v), err
+	case 't':
+		return d.uint64()
+	case 'd':
+		v, err := d.uint64()
+		return math.Float64frombits(v), err
+	case 's', 'o':
+		n, err := d.uint32()
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(int(n) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(b[:n]), nil
+	case 'g':
+		return d.signature()
+	case 'v':
+		vsig, err := d.signature()
+		if err != nil {
+			return nil, err
+		}
+		if n, err := typeLen(vsig); err != nil || n != len(vsig) {
+			return nil, fmt.Errorf("invalid variant signature %q", vsig)
+		}
+		return d.value(vsig, depth+1)
+	case '(', '{':
+		var fields []any
+		inner := sig[1 : len(sig)-1]
+		for inner != "" {
+			n, err := typeLen(inner)
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.value(inner[:n], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, v)
+			inner = inner[n:]
+		}
+		return fields, nil
+	case 'a':
+		return d.array(sig[1:], depth)
+	}
+	return nil, fmt.Errorf("unsupported type %q", sig[0])
+}
+
+// array Decode an array of elements of type elem
+func (d *decoder) array(elem string, depth int) (any, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return nil, err
+	}
+	// Padding to the first element isn't counted in the length
+	d.align(alignment(elem[0]))
+	end := d.pos + int(n)
+	if n > maxMessage || end > len(d.buf) {
+		return nil, errTruncated
+	}
+
+	if elem == "y" {
+		b, _ := d.next(int(n))
+		return append([]byte(nil), b...), nil
+	}
+
+	dict := elem[0] == '{' && elem[1] == 's'
+	var (
+		items []any
+		m     = make(map[string]any)
+	)
+	for d.pos < end {
+		if dict {
+			d.align(8)
+			key, err := d.value("s", depth+1)
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.value(elem[2:len(elem)-1], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			m[key.(string)] = value
+			continue
+		}
+		v, err := d.value(elem, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	if dict {
+		return m, nil
+	}
+	return items, nil
+}
+
+func (d *decoder) align(n int) {
+	d.pos = (d.pos + n - 1) / n * n
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return nil, errTruncated
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	d.align(4)
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return d.order.Uint32(b), nil
+}
+
+func (d *decoder) uint64() (uint64, error) {
+	d.align(8)
+	b, err := d.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return d.order.Uint64(b), nil
+}
+
+func (d *decoder) signature() (string, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return "", err
+	}
+	s, err := d.next(int(b[0]) + 1)
+	if err != nil {
+		return "", err
+	}
+	return string(s[:b[0]]), nil
+}
+
+// alignment Alignment of the type starting with c
+func alignment(c byte) int {
+	switch c {
+	case 'n', 'q':
+		return 2
+	case 'b', 'i', 'u', 'h', 's', 'o', 'a':
+		return 4
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	}
+	return 1
+}
+
+// typeLen Length of the first complete type of sig
+func typeLen(sig string) (int, error) {
+	if sig == "" {
+		return 0, errors.New("empty signature")
+	}
+	switch sig[0] {
+	case 'a':
+		n, err := typeLen(sig[1:])
+		return n + 1, err
+	case '(', '{':
+		closing := byte(')')
+		if sig[0] == '{' {
+			closing = '}'
+		}
+		i := 1
+		for i < len(sig) && sig[i] != closing {
+			n, err := typeLen(sig[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+		if i >= len(sig) || i == 1 {
+			return 0, fmt.Errorf("invalid signature %q", sig)
+		}
+		return i + 1, nil
+	case 'y', 'b', 'n', 'q', 'i', 'u', 'h', 'x', 't', 'd', 's', 'o', 'g', 'v':
+		return 1, nil
+	}
+	return 0, fmt.Errorf("invalid signature %q", sig)
+}
@@ -0,0 +1,176 @@
+package nspawn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// machine Container listed by machined
+type machine struct {
+	name string
+	// path D-Bus object of the machine
+	path string
+}
+
+// listMachines Containers registered with machined. Virtual machines and
+// the host, which newer releases list as ".host", are left out.
+func listMachines(ctx context.Context, bus *dbus) ([]machine, error) {
+	values, err := bus.call(ctx, machined, machinedPath, managerIface, "ListMachines")
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 1 {
+		return nil, errors.New("ListMachines: unexpected reply")
+	}
+	// a(ssso): name, class, service, object path
+	list, _ := values[0].([]any)
+
+	var machines []machine
+	for _, item := range list {
+		fields, ok := item.([]any)
+		if !ok || len(fields) != 4 {
+			continue
+		}
+		name, _ := fields[0].(string)
+		class, _ := fields[1].(string)
+		path, _ := fields[3].(string)
+		if class == "container" && name != "" {
+			machines = append(machines, machine{name: name, path: path})
+		}
+	}
+	return machines, nil
+}
+
+// machineProperties Properties of the machine object, such as Name, Id,
+// Leader, Unit, State and Timestamp
+func machineProperties(ctx context.Context, bus *dbus, m machine) (map[string]any, error) {
+	values, err := bus.call(ctx, machined, m.path, propertiesIface, "GetAll", machineIface)
+	if err != nil {
+		return nil, err
+	}
+	props, ok := values[0].(map[string]any)
+	if len(values) != 1 || !ok {
+		return nil, errors.New("GetAll: unexpected reply")
+	}
+	return props, nil
+}
+
+// machineUUID Machine ID in UUID form, empty when unset; machined reports
+// 16 zero bytes for containers without one
+func machineUUID(props map[string]any) string {
+	id, _ := props["Id"].([]byte)
+	if len(id) != 16 {
+		return ""
+	}
+	for _, b := range id {
+		if b != 0 {
+			return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+		}
+	}
+	return ""
+}
+
+// collectMachine Collect a container into a domain of r, nil if opts.Filter
+// excludes it or it stopped since it was listed
+func (n *Nspawn) collectMachine(ctx context.Context, bus *dbus, m machine, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	props, err := machineProperties(ctx, bus, m)
+	switch {
+	case dbusErrorName(err) == "org.freedesktop.machine1.NoSuchMachine" || dbusErrorName(err) == "org.freedesktop.DBus.Error.UnknownObject":
+		return nil, nil
+	case err != nil:
+		return nil, busError(err)
+	}
+
+	id, uuid := machineID(m.name), machineUUID(props)
+	if !opts.Keep(m.name, uuid, id) {
+		return nil, nil
+	}
+	d := r.Domain(id)
+	d.Name, d.ID, d.UUID = m.name, id, uuid
+	d.Hypervisor, d.Time, d.Flags = Hypervisor, driver.TimestampNow(), driver.DomainOnline
+	// Microseconds since the epoch the machine was registered at
+	if usec, _ := props["Timestamp"].(uint64); usec > 0 {
+		d.StartTime = driver.TimestampOf(time.UnixMicro(int64(usec)))
+	}
+
+	// opening and running machines are up, closing ones are being
+	// terminated
+	if state, _ := props["State"].(string); state == "closing" {
+		d.Flags = driver.DomainDying
+	}
+
+	leader, _ := props["Leader"].(uint32)
+	unit, _ := props["Unit"].(string)
+	dir, err := findCgroup(n.cgroupRoot, int(leader), unit)
+	if err != nil {
+		// The leader exited, the machine is going away
+		if missing(err) {
+			return d, nil
+		}
+		return nil, containerError(m.name, err)
+	}
+	if frozen(dir) {
+		d.Flags = driver.DomainPaused
+	}
+	if opts.Skips(d.Flags) {
+		return d, nil
+	}
+
+	if opts.CPUs {
+		if err := check(m.name, "cpu", collectCPU(dir, d, opts.Pinning)); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Blocks {
+		blocks, err := collectBlocks(dir, d.Blocks, opts.BlockCapacity)
+		if err := check(m.name, "blocks", err); err != nil {
+			return nil, err
+		}
+		d.Blocks = blocks
+	}
+	if opts.Memory {
+		if err := check(m.name, "memory", collectMemory(dir, &d.Memory)); err != nil {
+			return nil, err
+		}
+	}
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d, nil
+}
+
+// check Classify the failure reading a metric category. Missing files leave
+// the category unset and are only logged.
+func check(name, category string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if missing(err) {
+		driver.GetLogger().Debug("container statistics unavailable", "driver", Hypervisor, "domain", name, "category", category, "error", err)
+		return nil
+	}
+	return containerError(name, err)
+}
+
+// containerError Wrap a read failure, permission errors wrap
+// ErrPermissionDenied
+func containerError(name string, err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("nspawn: %s: %w: %w", name, driver.ErrPermissionDenied, err)
+	}
+	return fmt.Errorf("nspawn: %s: %w", name, err)
+}
+
+// fsError Wrap a failure reading the cgroup file system
+func fsError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("nspawn: %w: %w", driver.ErrPermissionDenied, err)
+	}
+	return fmt.Errorf("nspawn: %w: %w", driver.ErrHypervisorUnavailable, err)
+}
@@ -0,0 +1,314 @@
+// Package nspawn Driver collecting systemd-nspawn containers registered
+// with systemd-machined.
+//
+// Machines are listed from org.freedesktop.machine1 over the system D-Bus,
+// which is spoken to directly over the bus socket. machined only tracks
+// running machines; those of the container class are collected, virtual
+// machines it also registers are left to the driver of their hypervisor.
+// Statistics come from the cgroup of the machine's unit, located through
+// the cgroup of its leader process. Only the unified cgroup v2 hierarchy is
+// read, systemd dropped cgroup v1 support in release 256.
+//
+// Machines have no numeric ID, domain IDs are hashed from the machine name
+// and the UUID is the machine ID. Importing the package registers the driver
+// under the name "nspawn" using DefaultSocket and DefaultCgroupRoot;
+// driver.NewDriver takes the socket and cgroup_root keys.
+package nspawn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/virtmonitor/driver"
+)
+
+const (
+	// Hypervisor Hypervisor name reported by the nspawn driver
+	Hypervisor driver.DomainHypervisor = "nspawn"
+	// DefaultSocket Default system D-Bus socket
+	DefaultSocket = "/run/dbus/system_bus_socket"
+	// DefaultCgroupRoot Default cgroup file system mount point
+	DefaultCgroupRoot = "/sys/fs/cgroup"
+)
+
+// machined D-Bus name and interfaces of systemd-machined
+const (
+	machined        = "org.freedesktop.machine1"
+	machinedPath    = "/org/freedesktop/machine1"
+	managerIface    = "org.freedesktop.machine1.Manager"
+	machineIface    = "org.freedesktop.machine1.Machine"
+	propertiesIface = "org.freedesktop.DBus.Properties"
+)
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New(DefaultSocket, DefaultCgroupRoot)); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		Keys: []string{"socket", "cgroup_root"},
+		New: func(cfg driver.Config) (driver.Driver, error) {
+			return New(cfg.String("socket", DefaultSocket), cfg.String("cgroup_root", DefaultCgroupRoot)), nil
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// Nspawn systemd-nspawn driver
+type Nspawn struct {
+	socket     string
+	cgroupRoot string
+}
+
+// New Create an nspawn driver talking to machined over the D-Bus socket,
+// with cgroups mounted at cgroupRoot
+func New(socket, cgroupRoot string) *Nspawn {
+	return &Nspawn{socket: socket, cgroupRoot: cgroupRoot}
+}
+
+// Name Hypervisor name
+func (n *Nspawn) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Capabilities Supported metrics. Containers share the host kernel, only
+// total CPU usage is accounted and block devices are the host's.
+func (n *Nspawn) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:          true,
+		SupportsBlocks:        true,
+		SupportsMemory:        true,
+		SupportsBlockCapacity: true,
+		SupportsPinning:       true,
+	}
+}
+
+// Detect Test if systemd-machined answers over D-Bus
+func (n *Nspawn) Detect() bool {
+	return n.Diagnose().Detected
+}
+
+// Diagnose Tell a missing bus from a bus without machined, counting the
+// containers when it answers
+func (n *Nspawn) Diagnose() driver.DetectResult {
+	if _, err := os.Stat(n.socket); err != nil {
+		return driver.DetectResult{Reason: "no D-Bus socket at " + n.socket, Err: busError(err)}
+	}
+	if _, err := os.Stat(filepath.Join(n.cgroupRoot, "cgroup.controllers")); err != nil {
+		return driver.DetectResult{Reason: "no cgroup v2 file system at " + n.cgroupRoot, Err: fsError(err)}
+	}
+
+	ctx := context.Background()
+	bus, err := openBus(ctx, n.socket)
+	if err != nil {
+		return driver.DetectResult{Reason: "connecting to D-Bus failed", Err: busError(err)}
+	}
+	defer bus.close()
+
+	machines, err := listMachines(ctx, bus)
+	if err != nil {
+		return driver.DetectResult{Reason: "systemd-machined unavailable", Err: busError(err)}
+	}
+	if len(machines) == 0 {
+		return driver.DetectResult{Detected: true, Reason: "systemd-machined running, no containers"}
+	}
+	return driver.DetectResult{Detected: true, Reason: fmt.Sprintf("%d containers", len(machines))}
+}
+
+// Collect Collect containers
+func (n *Nspawn) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return n.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect every running container
+func (n *Nspawn) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	bus, machines, err := n.list(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer bus.close()
+
+	return driver.CollectDomains(ctx, opts, machines, n.collector(bus, opts, nil))
+}
+
+// CollectInto Collect every running container as CollectContext does into
+// dst, reusing its domains, see driver.CollectInto
+func (n *Nspawn) CollectInto(ctx context.Context, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	bus, machines, err := n.list(ctx, opts)
+	if err != nil {
+		clear(dst)
+		return err
+	}
+	defer bus.close()
+
+	return driver.CollectDomainsInto(ctx, opts, machines, dst, func(ctx context.Context, m machine, r *driver.Recycler) (*driver.Domain, error) {
+		return n.collector(bus, opts, r)(ctx, m)
+	})
+}
+
+// CollectStream Collect every running container as CollectContext does,
+// emitting each as soon as it's collected
+func (n *Nspawn) CollectStream(ctx context.Context, opts driver.CollectOptions, emit func(*driver.Domain) error) error {
+	bus, machines, err := n.list(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer bus.close()
+
+	return driver.StreamDomains(ctx, opts, machines, n.collector(bus, opts, nil), emit)
+}
+
+// list Connect to the bus and list the containers within
+// opts.EnumerateTimeout, retried per opts.Retries. The connection is the
+// caller's to close.
+func (n *Nspawn) list(ctx context.Context, opts driver.CollectOptions) (*dbus, []machine, error) {
+	var bus *dbus
+	machines, err := driver.Enumerate(ctx, opts, func(ectx context.Context) ([]machine, error) {
+		b, err := openBus(ectx, n.socket)
+		if err != nil {
+			return nil, busError(err)
+		}
+		machines, err := listMachines(ectx, b)
+		if err != nil {
+			b.close()
+			return nil, busError(err)
+		}
+		bus = b
+		return machines, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return bus, machines, nil
+}
+
+// collector Collect function of the containers listed by CollectContext,
+// CollectInto and CollectStream, querying machined over bus and filling the
+// domains of r
+func (n *Nspawn) collector(bus *dbus, opts driver.CollectOptions, r *driver.Recycler) func(context.Context, machine) (*driver.Domain, error) {
+	return func(ctx context.Context, m machine) (*driver.Domain, error) {
+		d, err := n.collectMachine(ctx, bus, m, opts, r)
+		if err != nil {
+			return nil, &driver.DomainError{ID: machineID(m.name), Name: m.name, Err: err}
+		}
+		return d, nil
+	}
+}
+
+// CollectDomain Collect a single container by ID
+func (n *Nspawn) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	return n.lookup(opts, fmt.Sprintf("%d", id), func(m machine) bool { return machineID(m.name) == id })
+}
+
+// CollectDomainByUUID Collect a single container by machine ID
+func (n *Nspawn) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	norm, err := driver.NormalizeUUID(uuid)
+	if err != nil {
+		return nil, fmt.Errorf("nspawn: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+
+	ctx := context.Background()
+	bus, machines, err := n.list(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer bus.close()
+
+	opts = opts.Unfiltered()
+	for _, m := range machines {
+		props, err := machineProperties(ctx, bus, m)
+		if err != nil || machineUUID(props) != norm {
+			continue
+		}
+		if d, err := n.collectMachine(ctx, bus, m, opts, nil); d != nil || err != nil {
+			return d, err
+		}
+		break
+	}
+	return nil, fmt.Errorf("nspawn: domain %s: %w", norm, driver.ErrDomainNotFound)
+}
+
+// CollectDomainByName Collect a single container by machine name
+func (n *Nspawn) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	return n.lookup(opts, fmt.Sprintf("%q", name), func(m machine) bool { return m.name == name })
+}
+
+// lookup Collect the first container match is true for, what naming it in
+// errors
+func (n *Nspawn) lookup(opts driver.CollectOptions, what string, match func(machine) bool) (*driver.Domain, error) {
+	ctx := context.Background()
+	bus, machines, err := n.list(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer bus.close()
+
+	opts = opts.Unfiltered()
+	for _, m := range machines {
+		if !match(m) {
+			continue
+		}
+		// nil when it stopped since it was listed
+		if d, err := n.collectMachine(ctx, bus, m, opts, nil); d != nil || err != nil {
+			return d, err
+		}
+		break
+	}
+	return nil, fmt.Errorf("nspawn: domain %s: %w", what, driver.ErrDomainNotFound)
+}
+
+// CollectSnapshots Container images are managed by machined, not
+// snapshotted
+func (n *Nspawn) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	return nil, fmt.Errorf("nspawn: snapshots: %w", driver.ErrNotSupported)
+}
+
+// Host Metrics of the local host, which the containers share
+func (n *Nspawn) Host() (*driver.HostInfo, error) {
+	return driver.LocalHostInfo()
+}
+
+// Ping Test if machined still answers
+func (n *Nspawn) Ping(ctx context.Context) error {
+	bus, err := openBus(ctx, n.socket)
+	if err != nil {
+		return busError(err)
+	}
+	defer bus.close()
+
+	if _, err := listMachines(ctx, bus); err != nil {
+		return busError(err)
+	}
+	return nil
+}
+
+// Watch machined signals aren't subscribed to
+func (n *Nspawn) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	return nil, fmt.Errorf("nspawn: watch: %w", driver.ErrNotSupported)
+}
+
+// Close Nothing to release, the bus is connected per collection
+func (n *Nspawn) Close() error {
+	return nil
+}
+
+// machineID Stable domain ID hashed from the machine name, the machine ID
+// may be unset and the leader PID changes across restarts
+func machineID(name string) driver.DomainID {
+	return driver.HashDomainID(name)
+}
+
+// busError Wrap a failure reaching machined, permission errors wrap
+// ErrPermissionDenied
+func busError(err error) error {
+	if errors.Is(err, os.ErrPermission) || dbusErrorName(err) == "org.freedesktop.DBus.Error.AccessDenied" {
+		return fmt.Errorf("nspawn: %w: %w", driver.ErrPermissionDenied, err)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("nspawn: %w", err)
+	}
+	return fmt.Errorf("nspawn: %w: %w", driver.ErrHypervisorUnavailable, err)
+}
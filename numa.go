@@ -0,0 +1,60 @@
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sysNodes sysfs directory of the host NUMA nodes
+const sysNodes = "/sys/devices/system/node"
+
+// HostNUMANodes CPUs of every NUMA node of the local host by node number,
+// read from sysfs. Nil when sysfs isn't available, hosts without NUMA have
+// a single node 0.
+func HostNUMANodes() map[int]CPUSet {
+	paths, _ := filepath.Glob(filepath.Join(sysNodes, "node[0-9]*"))
+	var nodes map[int]CPUSet
+	for _, path := range paths {
+		n, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(path), "node"))
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(path, "cpulist"))
+		if err != nil {
+			continue
+		}
+		cpus, err := ParseCPUSet(string(data))
+		if err != nil {
+			continue
+		}
+		if nodes == nil {
+			nodes = make(map[int]CPUSet, len(paths))
+		}
+		nodes[n] = cpus
+	}
+	return nodes
+}
+
+// PinnedNode Node of nodes (CPUs by node number, see HostNUMANodes) holding
+// every CPU of affinity, false when affinity is empty, spans several nodes
+// or has CPUs no node holds
+func PinnedNode(affinity CPUSet, nodes map[int]CPUSet) (int, bool) {
+	if affinity.Count() == 0 {
+		return 0, false
+	}
+	for n, cpus := range nodes {
+		inside := true
+		for _, cpu := range affinity.CPUs() {
+			if !cpus.Has(cpu) {
+				inside = false
+				break
+			}
+		}
+		if inside {
+			return n, true
+		}
+	}
+	return 0, false
+}
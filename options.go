@@ -0,0 +1,246 @@
+package driver
+
+import (
+	"context"
+	"time"
+)
+
+// CollectOptions Selects which metric categories a collection populates.
+// The zero value collects only the cheap basics: domain identity and state.
+type CollectOptions struct {
+	// CPUs Collect per vCPU statistics
+	CPUs bool
+	// Pinning Also collect vCPU affinity. Requires CPUs.
+	Pinning bool
+	// Blocks Collect block device statistics
+	Blocks bool
+	// BlockCapacity Also collect block device sizes, a separate and more
+	// expensive query for some hypervisors. Requires Blocks.
+	BlockCapacity bool
+	// BackingChain Also collect the backing images of block devices, from
+	// the device configuration. Requires Blocks.
+	BackingChain bool
+	// Interfaces Collect network interface statistics
+	Interfaces bool
+	// Addresses Also collect guest IP addresses, usually through the guest
+	// agent. Requires Interfaces.
+	Addresses bool
+	// Limits Also collect IO and bandwidth throttling limits. Applies to the
+	// devices collected with Blocks and Interfaces.
+	Limits bool
+	// Memory Collect memory statistics (may query the balloon driver)
+	Memory bool
+	// DirtyRate Also measure the rate the guest writes to its memory, for
+	// estimating how long a live migration takes. Expensive: the hypervisor
+	// tracks the pages written for a second before the rate is known, every
+	// domain taking that long to collect, and AllMetrics and
+	// Capabilities.Options leave it out. Requires Memory.
+	DirtyRate bool
+	// Filesystems Collect guest file system usage through the guest agent
+	Filesystems bool
+	// Graphics Collect graphical and serial console connection details
+	Graphics bool
+	// Metadata Collect the title, description and labels of domains
+	Metadata bool
+	// HostDevices Collect the PCI, USB and mediated host devices passed
+	// through to domains
+	HostDevices bool
+	// CPUTuning Collect the CPU scheduler configuration: shares, periods
+	// and quotas
+	CPUTuning bool
+	// NUMA Collect the guest NUMA cells and their host node placement, and
+	// the host node each pinned vCPU is on. vCPU nodes require CPUs.
+	NUMA bool
+	// Versions Collect the machine type of domains and the version of the
+	// emulator running them, for tracking upgrades
+	Versions bool
+
+	// TopologyOnly Collect the vCPUs, block devices and interfaces selected
+	// by CPUs, Blocks and Interfaces without their statistics, for
+	// inventories. Only their configuration is filled in: the ID and Flags
+	// of vCPUs, with Affinity under Pinning and NUMANode under NUMA; the
+	// Name, ReadOnly, IsDisk, IsCDrom, Bus, Target and Source of block
+	// devices, with BackingChain, the sizes and Limits under BackingChain,
+	// BlockCapacity and Limits; the Name, Mac, Bridges, HostDevice and
+	// LinkUp of interfaces, with Addresses and the bandwidth limits under
+	// Addresses and Limits. Every statistic is zero: vCPU times, Steal,
+	// IOWait and PhysicalCPU, block IO, Stalled and Throttled, interface RX
+	// and TX, IO thread times and EmulatorTime, see Domain.ClearCounters.
+	// The domain VCPUs counts and the other categories are unaffected.
+	// libvirt skips the statistics queries, drivers reading statistics
+	// along with the configuration clear them.
+	TopologyOnly bool
+
+	// SkipStates Domains in these states only carry their identity and
+	// state, none of the statistics queries run for them. Meant for the
+	// migration states, DomainSaving and DomainRestoring, during which
+	// statistics can block or be meaningless. DomainMigrating skips either
+	// direction, DomainMigratingIn and DomainMigratingOut only theirs.
+	// Drivers tell the state apart before any statistics query.
+	SkipStates []DomainFlag
+	// IncludeInactive Also collect the domains that aren't running,
+	// DomainShutdown and DomainCrashed, left out of collections otherwise.
+	// Like the domains in SkipStates they only carry their identity (Name,
+	// ID, UUID, Hypervisor), Time and Flags, plus the configuration drivers
+	// read along with the state: Persistent (libvirt, lxc, virtualbox,
+	// hyperv), Autostart (libvirt, lxc), OSType and VCPUs (virtualbox, xen).
+	// Every statistic is zero. Lookups of a single domain ignore it,
+	// inactive domains are always found.
+	IncludeInactive bool
+
+	// Concurrency Maximum number of domains collected concurrently,
+	// 0 for GOMAXPROCS
+	Concurrency int
+
+	// EnumerateTimeout Time allowed listing the domains, before any of them
+	// is collected, 0 for no limit beyond the context. Listings read from
+	// the local file system (sockets, cgroups, procfs) aren't bounded.
+	EnumerateTimeout time.Duration
+	// PerDomainTimeout Time allowed collecting each domain, 0 for no limit
+	// beyond the context. A domain exceeding it fails with a DomainError
+	// wrapping context.DeadlineExceeded while the others proceed. Drivers
+	// collecting every domain in a single query (hyperv, xen) bound it with
+	// EnumerateTimeout instead, xen not at all.
+	PerDomainTimeout time.Duration
+
+	// Retries Times the listing of the domains is retried when it fails
+	// with a transient error, see Transient, 0 for none. Domains failing
+	// once listed aren't retried. Attempts, and the delays between them,
+	// share EnumerateTimeout and the context's deadline: no attempt starts
+	// once the delay before it would outlast them.
+	Retries int
+	// RetryDelay Delay before every retry, 0 retries at once
+	RetryDelay time.Duration
+
+	// SlowInterval Interval at which the expensive categories,
+	// BlockCapacity, BackingChain, Addresses and Filesystems, are queried
+	// by drivers wrapped with WithSlowInterval, which fill them from the
+	// values last queried in between. 0 queries them on every collection.
+	// Drivers ignore it.
+	SlowInterval time.Duration
+
+	// Include Patterns of the domains collected, empty for every domain.
+	// A domain is included when a pattern matches its name or its UUID, in
+	// the lower case hyphenated form drivers report, as a whole. Patterns
+	// are globs as for path.Match: * matches any run of characters but /,
+	// ? any single one but /, [...] a class and \ escapes the next
+	// character. Patterns delimited by slashes, such as /web-[0-9]+/, are
+	// RE2 regular expressions between the slashes, also anchored at both
+	// ends: /.*web.*/ matches anywhere. Malformed patterns fail collections
+	// with ErrInvalidPattern before any domain is listed, see CheckPatterns.
+	Include []string
+	// Exclude Patterns of the domains left out, as for Include, taking
+	// precedence over it
+	Exclude []string
+	// Filter Domains it returns false for are left out of Collect, nil keeps
+	// every domain. It runs on the identity the driver looks up first, before
+	// any CPU, block, network or memory query, so excluded domains cost next
+	// to nothing. The UUID is empty for drivers without one. Lookups of a
+	// single domain ignore it, as they ignore Include and Exclude. It runs
+	// after them, on the domains they keep.
+	Filter func(name, uuid string, id DomainID) bool
+}
+
+// Keep Test if Include, Exclude and Filter keep a domain
+func (o CollectOptions) Keep(name, uuid string, id DomainID) bool {
+	if len(o.Include) > 0 && !matchAny(o.Include, name, uuid) {
+		return false
+	}
+	if matchAny(o.Exclude, name, uuid) {
+		return false
+	}
+	return o.Filter == nil || o.Filter(name, uuid, id)
+}
+
+// Unfiltered Options without Include, Exclude and Filter, for lookups of a
+// single domain
+func (o CollectOptions) Unfiltered() CollectOptions {
+	o.Include, o.Exclude, o.Filter = nil, nil, nil
+	return o
+}
+
+// CheckPatterns Test if the Include and Exclude patterns are well formed,
+// the error wrapping ErrInvalidPattern otherwise. Enumerate runs it before
+// listing domains.
+func (o CollectOptions) CheckPatterns() error {
+	for _, p := range append(append([]string(nil), o.Include...), o.Exclude...) {
+		if err := checkPattern(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Inactive Test if a domain state is one of a domain that isn't running,
+// DomainShutdown or DomainCrashed
+func Inactive(state DomainFlag) bool {
+	return state == DomainShutdown || state == DomainCrashed
+}
+
+// KeepState Test if a domain in state is collected, inactive domains only
+// are with IncludeInactive
+func (o CollectOptions) KeepState(state DomainFlag) bool {
+	return o.IncludeInactive || !Inactive(state)
+}
+
+// Skips Test if the statistics queries of a domain in state are skipped:
+// the domain is inactive, having none to query, or SkipStates holds state
+func (o CollectOptions) Skips(state DomainFlag) bool {
+	if Inactive(state) {
+		return true
+	}
+	for _, s := range o.SkipStates {
+		if s == state || s == DomainMigrating && state.Migrating() {
+			return true
+		}
+	}
+	return false
+}
+
+// EnumerateContext Context bounding the listing of domains by
+// EnumerateTimeout, for drivers
+func (o CollectOptions) EnumerateContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, o.EnumerateTimeout)
+}
+
+// DomainContext Context bounding the collection of a domain by
+// PerDomainTimeout, CollectDomains derives it for every item
+func (o CollectOptions) DomainContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, o.PerDomainTimeout)
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// AllMetrics Options with every metric category enabled
+func AllMetrics() CollectOptions {
+	return CollectOptions{
+		CPUs:          true,
+		Pinning:       true,
+		Blocks:        true,
+		BlockCapacity: true,
+		BackingChain:  true,
+		Interfaces:    true,
+		Addresses:     true,
+		Limits:        true,
+		Memory:        true,
+		Filesystems:   true,
+		Graphics:      true,
+		Metadata:      true,
+		HostDevices:   true,
+		CPUTuning:     true,
+		NUMA:          true,
+		Versions:      true,
+	}
+}
+
+// Collect Collect from d using the original positional arguments
+//
+// Deprecated: use Driver.Collect with CollectOptions.
+func Collect(d Driver, cpus, blocks, ifaces bool) (map[DomainID]*Domain, error) {
+	return d.Collect(CollectOptions{CPUs: cpus, Blocks: blocks, Interfaces: ifaces})
+}
@@ -0,0 +1,70 @@
+package driver
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// patterns Compiled regular expression patterns, shared by every collection
+var patterns sync.Map
+
+// compilePattern Regular expression of a "/expr/" pattern, nil for a glob
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) < 2 || !strings.HasPrefix(pattern, "/") || !strings.HasSuffix(pattern, "/") {
+		return nil, nil
+	}
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	// Compiled alone first so that errors quote the expression as written
+	expr := pattern[1 : len(pattern)-1]
+	if _, err := regexp.Compile(expr); err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// checkPattern Test if a pattern is well formed
+func checkPattern(pattern string) error {
+	re, err := compilePattern(pattern)
+	if err == nil && re == nil {
+		_, err = path.Match(pattern, "")
+	}
+	if err != nil {
+		return fmt.Errorf("driver: pattern %q: %w: %w", pattern, ErrInvalidPattern, err)
+	}
+	return nil
+}
+
+// matchPattern Test if pattern matches s as a whole. Malformed patterns,
+// rejected by CheckPatterns, match nothing.
+func matchPattern(pattern, s string) bool {
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return false
+	}
+	if re != nil {
+		return re.MatchString(s)
+	}
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
+
+// matchAny Test if any of the patterns matches the name or, when set, the
+// UUID
+func matchAny(patterns []string, name, uuid string) bool {
+	for _, p := range patterns {
+		if matchPattern(p, name) || (uuid != "" && matchPattern(p, uuid)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package driver
+
+import "sort"
+
+// UnknownPlacement Key of VCPUPlacement gathering the vCPUs without a
+// PhysicalCPU
+const UnknownPlacement = -1
+
+// VCPURef vCPU of a domain
+type VCPURef struct {
+	Domain DomainID `json:"domain"`
+	Name   string   `json:"name"`
+	// VCPU ID of the vCPU within the domain
+	VCPU  uint64  `json:"vcpu"`
+	Flags CPUFlag `json:"flags"`
+}
+
+// VCPUPlacement vCPUs of every domain of a collection by the physical CPU
+// they last ran on, showing the domains contending for each host CPU. vCPUs
+// without PhysicalCPUSet, as reported by drivers that don't know it or
+// domains skipped for their state, are under UnknownPlacement. The
+// placement is a snapshot as of each domain's collection: halted and paused
+// vCPUs keep the CPU they last ran on, check Flags for those running. The
+// vCPUs of each CPU are ordered by domain ID then vCPU ID. Requires
+// CollectOptions.CPUs.
+func VCPUPlacement(domains map[DomainID]*Domain) map[int][]VCPURef {
+	placement := make(map[int][]VCPURef)
+	for _, d := range domains {
+		for _, cpu := range d.Cpus {
+			pcpu := UnknownPlacement
+			if cpu.PhysicalCPUSet {
+				pcpu = cpu.PhysicalCPU
+			}
+			placement[pcpu] = append(placement[pcpu], VCPURef{Domain: d.ID, Name: d.Name, VCPU: cpu.ID, Flags: cpu.Flags})
+		}
+	}
+
+	for _, refs := range placement {
+		sort.Slice(refs, func(i, j int) bool {
+			if refs[i].Domain != refs[j].Domain {
+				return refs[i].Domain < refs[j].Domain
+			}
+			return refs[i].VCPU < refs[j].VCPU
+		})
+	}
+	return placement
+}
@@ -0,0 +1,56 @@
+//go:build (linux || darwin || freebsd) && cgo
+
+package driver
+
+import (
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"sync"
+)
+
+var (
+	pluginsMu sync.Mutex
+	// plugins Paths of the plugins loaded, Go plugins can't be unloaded
+	plugins = make(map[string]bool)
+)
+
+// LoadPlugin Open the Go plugin at path and call its Register function,
+// which registers its drivers as a driver's init() would. The plugin must be
+// built with -buildmode=plugin against the same version of this package, and
+// export
+//
+//	func Register() error
+//
+// under the name PluginSymbol. Loading a plugin already loaded does nothing.
+func LoadPlugin(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("driver: LoadPlugin %q: %w", path, err)
+	}
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	if plugins[abs] {
+		return nil
+	}
+	p, err := plugin.Open(abs)
+	if err != nil {
+		return fmt.Errorf("driver: LoadPlugin %q: %w", path, err)
+	}
+	sym, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return fmt.Errorf("driver: LoadPlugin %q: %w", path, err)
+	}
+	register, ok := sym.(func() error)
+	if !ok {
+		return fmt.Errorf("driver: LoadPlugin %q: %s is %T, not func() error", path, PluginSymbol, sym)
+	}
+	// Once opened the plugin stays loaded, failing Register isn't retried
+	plugins[abs] = true
+	if err := register(); err != nil {
+		return fmt.Errorf("driver: LoadPlugin %q: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !((linux || darwin || freebsd) && cgo)
+
+package driver
+
+import "fmt"
+
+// LoadPlugin Go plugins need cgo on Linux, macOS or FreeBSD. Drivers can
+// still be linked in and register from init().
+func LoadPlugin(path string) error {
+	return fmt.Errorf("driver: LoadPlugin %q: %w", path, ErrNotSupported)
+}
@@ -0,0 +1,226 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Workers Effective collection concurrency: Concurrency, or GOMAXPROCS if unset
+func (o CollectOptions) Workers() int {
+	if o.Concurrency > 0 {
+		return o.Concurrency
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// RunContext Run fn, returning ctx.Err() as soon as ctx is done, for drivers
+// whose queries can't be cancelled to honour the deadlines of
+// EnumerateContext and DomainContext. When ctx is done first fn keeps
+// running in the background and its result is dropped, fn must not touch
+// state the caller may have released by then.
+func RunContext[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	if ctx.Done() == nil {
+		return fn()
+	}
+
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		done <- result{v, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Enumerate Run list, the listing of the domains of a driver, under
+// opts.EnumerateContext, retrying it up to opts.Retries times while it fails
+// with a Transient error. Retries wait opts.RetryDelay and share the
+// EnumerateTimeout of the first attempt: when the delay would outlast it, or
+// the deadline of ctx, the last error is returned without retrying. Other
+// errors are returned at once, as are malformed Include and Exclude patterns
+// before list runs.
+func Enumerate[T any](ctx context.Context, opts CollectOptions, list func(context.Context) (T, error)) (T, error) {
+	if err := opts.CheckPatterns(); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	ectx, cancel := opts.EnumerateContext(ctx)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		v, err := list(ectx)
+		if err == nil || !Transient(err) || attempt >= opts.Retries {
+			return v, err
+		}
+		if deadline, ok := ectx.Deadline(); ok && time.Until(deadline) <= opts.RetryDelay {
+			return v, err
+		}
+
+		GetLogger().Debug("listing domains failed, retrying", "attempt", attempt+1, "delay", opts.RetryDelay, "error", err)
+		t := time.NewTimer(opts.RetryDelay)
+		select {
+		case <-t.C:
+		case <-ectx.Done():
+			t.Stop()
+			if cerr := ctx.Err(); cerr != nil {
+				return v, cerr
+			}
+			return v, err
+		}
+	}
+}
+
+// CollectDomains Helper for drivers running collect for every item on a pool
+// of opts.Workers() goroutines, timing each into Domain.CollectDuration.
+// Each item is collected under opts.DomainContext, see PerDomainTimeout.
+// Items collect returns a nil domain for are skipped, as are inactive
+// domains unless opts.IncludeInactive, see KeepState. Failing items don't
+// abort the others: the collected domains are returned along with the item
+// errors joined, which collect wraps in DomainError for callers to tell the
+// failed domains apart. Domains are added with AddDomain, a domain reporting
+// the ID of one collected before it is left out with an error. If ctx is
+// done, nothing but ctx.Err() is returned.
+func CollectDomains[T any](ctx context.Context, opts CollectOptions, items []T, collect func(context.Context, T) (*Domain, error)) (map[DomainID]*Domain, error) {
+	domains := make(map[DomainID]*Domain, len(items))
+	err := CollectDomainsInto(ctx, opts, items, domains, func(ctx context.Context, item T, _ *Recycler) (*Domain, error) {
+		return collect(ctx, item)
+	})
+	if ctx.Err() != nil {
+		return nil, err
+	}
+	return domains, err
+}
+
+// CollectDomainsInto Helper for drivers implementing IntoCollector,
+// collecting items as CollectDomains does into dst. collect gets the
+// domains dst held, through a Recycler, to fill the domain of its item
+// from. dst is emptied once its domains are handed to the Recycler and
+// filled as items are collected, it's emptied as well if ctx is done.
+func CollectDomainsInto[T any](ctx context.Context, opts CollectOptions, items []T, dst map[DomainID]*Domain, collect func(context.Context, T, *Recycler) (*Domain, error)) error {
+	r := NewRecycler(dst)
+	clear(dst)
+	var dups []error
+	err := StreamDomains(ctx, opts, items, func(ctx context.Context, item T) (*Domain, error) {
+		return collect(ctx, item, r)
+	}, func(d *Domain) error {
+		if err := AddDomain(dst, d); err != nil {
+			dups = append(dups, err)
+		}
+		return nil
+	})
+	if err := ctx.Err(); err != nil {
+		clear(dst)
+		return err
+	}
+	return errors.Join(append([]error{err}, dups...)...)
+}
+
+// AddDomain Add d to a collection by ID, for drivers assembling one. A
+// domain already holding the ID is kept and d left out: hypervisors can
+// briefly list two domains under one ID, one destroyed and another created
+// during the listing, or report the same domain twice. The same domain,
+// with the same UUID or, without one, the same name, is dropped silently.
+// A different one is logged as a warning and returned as a DomainError
+// wrapping ErrDuplicateDomainID, naming d and the domain kept, for the
+// driver to join with the errors of the collection.
+func AddDomain(domains map[DomainID]*Domain, d *Domain) error {
+	held, ok := domains[d.ID]
+	if !ok {
+		domains[d.ID] = d
+		return nil
+	}
+	if held.UUID == d.UUID && (held.UUID != "" || held.Name == d.Name) {
+		GetLogger().Debug("domain listed twice", "id", d.ID, "name", d.Name)
+		return nil
+	}
+	GetLogger().Warn("duplicate domain ID, domain left out", "id", d.ID, "kept", held.Name, "kept_uuid", held.UUID, "dropped", d.Name, "dropped_uuid", d.UUID)
+	return &DomainError{ID: d.ID, Name: d.Name, Err: fmt.Errorf("%w %d, held by %q", ErrDuplicateDomainID, d.ID, held.Name)}
+}
+
+// StreamDomains Helper for drivers implementing Streamer, collecting items
+// as CollectDomains does but passing every domain to emit as soon as it's
+// collected rather than gathering them. emit is called by one worker at a
+// time. An error from emit stops the collection, cancelling the items being
+// collected, and is returned as is. If ctx is done ctx.Err() is returned,
+// otherwise the item errors joined.
+func StreamDomains[T any](ctx context.Context, opts CollectOptions, items []T, collect func(context.Context, T) (*Domain, error), emit func(*Domain) error) error {
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := opts.Workers()
+	if workers > len(items) {
+		workers = len(items)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		errs    []error
+		emitErr error
+		queue   = make(chan T)
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				start := time.Now()
+				dctx, dcancel := opts.DomainContext(sctx)
+				d, err := collect(dctx, item)
+				dcancel()
+				if d != nil {
+					d.CollectDuration = time.Since(start)
+				}
+
+				mu.Lock()
+				switch {
+				case emitErr != nil:
+					// Stopping, items finishing meanwhile are dropped
+				case err != nil:
+					GetLogger().Debug("domain collection failed", "error", err)
+					errs = append(errs, err)
+				case d != nil && opts.KeepState(d.Flags):
+					if emitErr = emit(d); emitErr != nil {
+						cancel()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, item := range items {
+		select {
+		case queue <- item:
+		case <-sctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if emitErr != nil {
+		return emitErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,106 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// BenchmarkConcurrency Collect the domains of a mock taking 100µs per
+// domain, one at a time and on the default GOMAXPROCS workers
+func BenchmarkConcurrency(b *testing.B) {
+	const latency = 100 * time.Microsecond
+	m := benchMock()
+	ids := make([]driver.DomainID, 0, benchDomains)
+	for _, id := range benchItems() {
+		ids = append(ids, driver.DomainID(id))
+	}
+
+	for _, bench := range []struct {
+		name    string
+		workers int
+	}{
+		{"Concurrency=1", 1},
+		{fmt.Sprintf("Concurrency=GOMAXPROCS(%d)", runtime.GOMAXPROCS(0)), 0},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			opts := driver.CollectOptions{CPUs: true, Blocks: true, Interfaces: true, Concurrency: bench.workers}
+			for i := 0; i < b.N; i++ {
+				domains, err := driver.CollectDomains(context.Background(), opts, ids, func(ctx context.Context, id driver.DomainID) (*driver.Domain, error) {
+					time.Sleep(latency)
+					return m.CollectDomain(id, opts)
+				})
+				if err != nil || len(domains) != benchDomains {
+					b.Fatalf("got %d domains, %v", len(domains), err)
+				}
+			}
+		})
+	}
+}
+
+func TestAddDomain(t *testing.T) {
+	const uuid = "6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f"
+	for _, tt := range []struct {
+		name      string
+		held, add *driver.Domain
+		dup       bool
+	}{
+		{"same UUID", &driver.Domain{ID: 1, Name: "a", UUID: uuid}, &driver.Domain{ID: 1, Name: "renamed", UUID: uuid}, false},
+		{"same name without UUID", &driver.Domain{ID: 1, Name: "a"}, &driver.Domain{ID: 1, Name: "a"}, false},
+		{"other UUID", &driver.Domain{ID: 1, Name: "a", UUID: uuid}, &driver.Domain{ID: 1, Name: "a"}, true},
+		{"other name without UUID", &driver.Domain{ID: 1, Name: "a"}, &driver.Domain{ID: 1, Name: "b"}, true},
+	} {
+		domains := map[driver.DomainID]*driver.Domain{}
+		if err := driver.AddDomain(domains, tt.held); err != nil {
+			t.Fatalf("%s: adding the first domain: %v", tt.name, err)
+		}
+		err := driver.AddDomain(domains, tt.add)
+		if domains[1] != tt.held || len(domains) != 1 {
+			t.Errorf("%s: the domain added first isn't kept alone", tt.name)
+		}
+		if !tt.dup {
+			if err != nil {
+				t.Errorf("%s: got %v, want the domain dropped silently", tt.name, err)
+			}
+			continue
+		}
+		var derr *driver.DomainError
+		if !errors.As(err, &derr) || !errors.Is(err, driver.ErrDuplicateDomainID) {
+			t.Fatalf("%s: got %v, want a DomainError wrapping ErrDuplicateDomainID", tt.name, err)
+		}
+		if derr.ID != 1 || derr.Name != tt.add.Name {
+			t.Errorf("%s: error names domain %d %q, want the one left out", tt.name, derr.ID, derr.Name)
+		}
+	}
+}
+
+func TestCollectDomainsDuplicateIDs(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	domains, err := driver.CollectDomains(context.Background(), driver.CollectOptions{Concurrency: 1}, names, func(_ context.Context, name string) (*driver.Domain, error) {
+		// b and c collide with a
+		return &driver.Domain{ID: 7, Name: name}, nil
+	})
+	if len(domains) != 1 || domains[7] == nil {
+		t.Fatalf("got %d domains, want the one domain 7 kept", len(domains))
+	}
+	if !errors.Is(err, driver.ErrDuplicateDomainID) {
+		t.Fatalf("got %v, want ErrDuplicateDomainID", err)
+	}
+	var dropped []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var derr *driver.DomainError
+		if errors.As(e, &derr) {
+			dropped = append(dropped, derr.Name)
+		}
+	}
+	kept := domains[7].Name
+	if len(dropped) != 2 || slices.Contains(dropped, kept) {
+		t.Errorf("kept %q, dropped %v, want the other two dropped", kept, dropped)
+	}
+}
@@ -0,0 +1,22 @@
+package driver
+
+// Private Driver-private data of the domain, nil when the driver stores
+// none. What it holds is specific to the driver and documented by its
+// package; it is not part of the stable interface and may change between
+// releases. Private data isn't encoded, domains decoded from JSON, gob or
+// the gRPC API have none.
+func (d *Domain) Private() interface{} {
+	return d.prv
+}
+
+// SetPrivate Store driver-private data in the domain, for drivers only
+func (d *Domain) SetPrivate(v interface{}) {
+	d.prv = v
+}
+
+// Private Driver-private data of the domain as a T, false if the domain
+// holds none or data of another type. See Domain.Private.
+func Private[T any](d *Domain) (T, bool) {
+	v, ok := d.prv.(T)
+	return v, ok
+}
@@ -0,0 +1,188 @@
+package procfs
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tap A tap network backend of the command line, with the MAC address of
+// the NIC using it
+type tap struct {
+	id     string
+	ifname string
+	mac    net.HardwareAddr
+}
+
+// cmdline What a QEMU command line tells about its VM
+type cmdline struct {
+	name     string
+	uuid     string
+	id       string
+	vcpus    int
+	maxVCPUs int
+	// machine Machine type, empty for the default
+	machine string
+	// memory Boot and maximum memory in bytes
+	memory, maxMemory uint64
+	taps              []tap
+}
+
+// qemuBinary Test if argv[0] runs QEMU system emulation
+func qemuBinary(arg0 string) bool {
+	base := filepath.Base(arg0)
+	return strings.HasPrefix(base, "qemu-system-") || base == "qemu-kvm"
+}
+
+// cmdlineOptions Options parsed from the command line, all taking a value
+var cmdlineOptions = map[string]bool{
+	"name": true, "uuid": true, "id": true, "smp": true, "m": true,
+	"netdev": true, "device": true, "machine": true, "M": true,
+}
+
+// parseCmdline Parse the NUL separated arguments of /proc/<pid>/cmdline,
+// false if they don't run QEMU. Options are taken in both their -opt and
+// --opt forms. Other arguments are skipped one at a time, which also skips
+// the values of options not listed in cmdlineOptions.
+func parseCmdline(raw []byte) (cmdline, bool) {
+	args := strings.Split(strings.TrimRight(string(raw), "\x00"), "\x00")
+	if len(args) == 0 || !qemuBinary(args[0]) {
+		return cmdline{}, false
+	}
+
+	c := cmdline{vcpus: 1}
+	macs := make(map[string]net.HardwareAddr)
+	for i := 1; i+1 < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		opt := strings.TrimPrefix(strings.TrimPrefix(args[i], "-"), "-")
+		if !cmdlineOptions[opt] {
+			continue
+		}
+		value := args[i+1]
+		i++
+
+		first, props := options(value)
+		switch opt {
+		case "name":
+			// -name guest=<name>,debug-threads=on or -name <name>
+			c.name = props["guest"]
+			if c.name == "" {
+				c.name = first
+			}
+		case "uuid":
+			c.uuid = value
+		case "id":
+			// Proxmox VE passes its VM ID
+			c.id = value
+		case "smp":
+			if n, err := strconv.Atoi(first); err == nil {
+				c.vcpus = n
+			}
+			if n, err := strconv.Atoi(props["cpus"]); err == nil {
+				c.vcpus = n
+			}
+			c.maxVCPUs, _ = strconv.Atoi(props["maxcpus"])
+		case "m":
+			size := props["size"]
+			if size == "" {
+				size = first
+			}
+			c.memory = parseSize(size)
+			c.maxMemory = parseSize(props["maxmem"])
+		case "machine", "M":
+			// -machine type=<type>,... or -machine <type>,...
+			c.machine = props["type"]
+			if c.machine == "" {
+				c.machine = first
+			}
+		case "netdev":
+			if first == "tap" || props["type"] == "tap" {
+				c.taps = append(c.taps, tap{id: props["id"], ifname: props["ifname"]})
+			}
+		case "device":
+			if mac, err := net.ParseMAC(props["mac"]); err == nil && props["netdev"] != "" {
+				macs[props["netdev"]] = mac
+			}
+		}
+	}
+
+	for i := range c.taps {
+		c.taps[i].mac = macs[c.taps[i].id]
+	}
+	if c.maxVCPUs < c.vcpus {
+		c.maxVCPUs = c.vcpus
+	}
+	if c.maxMemory < c.memory {
+		c.maxMemory = c.memory
+	}
+	return c, true
+}
+
+// options Split a QEMU option value into its leading bare value, if any,
+// and its key=value properties. Commas are escaped by doubling them.
+func options(value string) (string, map[string]string) {
+	var first string
+	props := make(map[string]string)
+	for i, part := range splitOptions(value) {
+		key, v, ok := strings.Cut(part, "=")
+		switch {
+		case ok:
+			props[key] = v
+		case i == 0:
+			first = part
+		default:
+			props[key] = "on"
+		}
+	}
+	return first, props
+}
+
+// splitOptions Split on single commas, ",," standing for a literal comma
+func splitOptions(value string) []string {
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == ',' && i+1 < len(value) && value[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case value[i] == ',':
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return append(parts, b.String())
+}
+
+// parseSize Parse a QEMU memory size into bytes, in MiB without a suffix.
+// 0 if it doesn't parse.
+func parseSize(s string) uint64 {
+	if s == "" {
+		return 0
+	}
+	shift := 20
+	switch s[len(s)-1] {
+	case 'k', 'K':
+		shift = 10
+	case 'm', 'M':
+		shift = 20
+	case 'g', 'G':
+		shift = 30
+	case 't', 'T':
+		shift = 40
+	case 'b', 'B':
+		shift = 0
+	default:
+		s += "M"
+	}
+	n, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n << shift
+}
@@ -0,0 +1,318 @@
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// clockTicks Kernel clock ticks per second (USER_HZ) the stat times count in
+const clockTicks = 100
+
+// stat Fields of /proc/<pid>/stat and /proc/<pid>/task/<tid>/stat
+type stat struct {
+	comm  string
+	state byte
+	// time User and system time in nanoseconds
+	time      float64
+	processor int
+}
+
+// collect Collect the domain of a QEMU process into a domain of r, nil if it
+// exited meanwhile
+func (p *Procfs) collect(proc process, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	dir := filepath.Join(p.proc, strconv.Itoa(proc.pid))
+	st, err := readStat(filepath.Join(dir, "stat"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, procError(err)
+	}
+
+	d := r.Domain(proc.id())
+	d.Name, d.ID, d.Hypervisor = proc.name(), proc.id(), Hypervisor
+	d.Time, d.Flags = driver.TimestampNow(), driver.DomainOnline
+	if proc.cmd.uuid != "" {
+		d.UUID, _ = driver.NormalizeUUID(proc.cmd.uuid)
+	}
+	// Zombies have exited and wait to be reaped
+	if st.state == 'Z' || st.state == 'X' {
+		d.Flags = driver.DomainDying
+	}
+	if opts.Skips(d.Flags) {
+		return d, nil
+	}
+	// ProcessStartTime reads the procfs of the host
+	if p.proc == DefaultProc {
+		if start, err := driver.ProcessStartTime(proc.pid); err == nil {
+			d.StartTime = start
+		}
+	}
+
+	if opts.Versions {
+		d.MachineType = proc.cmd.machine
+	}
+	d.VCPUs = proc.cmd.vcpus
+	d.VCPUsMaximum = proc.cmd.maxVCPUs
+	if opts.CPUs {
+		if err := collectCPUs(dir, st, d); err != nil {
+			return nil, vanished(err)
+		}
+	}
+	if opts.Blocks {
+		if err := collectIO(dir, d); err != nil {
+			return nil, vanished(err)
+		}
+	}
+	if opts.Interfaces {
+		if err := collectInterfaces(dir, proc.cmd.taps, d); err != nil {
+			return nil, vanished(err)
+		}
+	}
+	if opts.Memory {
+		if err := collectMemory(dir, proc.cmd, d); err != nil {
+			return nil, vanished(err)
+		}
+	}
+
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d, nil
+}
+
+// vanished Wrap a failure reading the entries of a process. Those of a
+// process exiting mid collection disappear, which fails the domain like a
+// hypervisor losing it would.
+func vanished(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("procfs: process exited: %w: %w", driver.ErrDomainNotFound, err)
+	}
+	return procError(err)
+}
+
+// collectCPUs vCPUs from the threads QEMU names "CPU <n>/KVM" (or "/TCG"),
+// the time of the other threads is the emulator time. QEMU names its threads
+// only with -name debug-threads=on, without it a single CPU carries the time
+// of the whole process.
+func collectCPUs(dir string, total stat, d *driver.Domain) error {
+	tasks, err := os.ReadDir(filepath.Join(dir, "task"))
+	if err != nil {
+		return err
+	}
+
+	var vcpus float64
+	for _, task := range tasks {
+		st, err := readStat(filepath.Join(dir, "task", task.Name(), "stat"))
+		if errors.Is(err, os.ErrNotExist) {
+			// Thread exited since the listing
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		id, ok := vcpuThread(st.comm)
+		if !ok {
+			continue
+		}
+		// vCPU threads sleep while the guest CPU halts
+		cpu := driver.CPU{ID: id, Time: st.time, Flags: driver.CPUHalted}
+		if st.state == 'R' {
+			cpu.Flags = driver.CPURunning
+		}
+		cpu.PhysicalCPU, cpu.PhysicalCPUSet = st.processor, true
+		d.Cpus = append(d.Cpus, cpu)
+		vcpus += st.time
+	}
+
+	if len(d.Cpus) == 0 {
+		d.Cpus = append(d.Cpus, driver.CPU{Time: total.time, Flags: driver.CPUOnline})
+		d.VCPUsCurrent = d.VCPUs
+		return nil
+	}
+	d.VCPUsCurrent = len(d.Cpus)
+	// Threads exiting between the stats can make the sum exceed the total
+	if total.time > vcpus {
+		d.EmulatorTime, d.EmulatorTimeSet = total.time-vcpus, true
+	}
+	return nil
+}
+
+// vcpuThread ID of the vCPU a thread named comm runs
+func vcpuThread(comm string) (uint64, bool) {
+	n, ok := strings.CutPrefix(comm, "CPU ")
+	if !ok {
+		return 0, false
+	}
+	n, _, ok = strings.Cut(n, "/")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(n, 10, 64)
+	return id, err == nil
+}
+
+// collectIO Storage IO of the process as a single device, named "io". The
+// counters include every file the process reads and writes, the images as
+// much as its logs, but no operation counts.
+func collectIO(dir string, d *driver.Domain) error {
+	io, err := readKeyValues(filepath.Join(dir, "io"), ":")
+	if err != nil {
+		return err
+	}
+	d.Blocks = append(d.Blocks[:0], driver.BlockDevice{
+		Name:   "io",
+		IsDisk: true,
+		Read:   driver.BlockIO{Bytes: io["read_bytes"], Absolute: true},
+		Write:  driver.BlockIO{Bytes: io["write_bytes"], Absolute: true},
+	})
+	return nil
+}
+
+// collectInterfaces Tap backends of the command line, counted in the network
+// namespace of the process. The host device receives what the guest sends,
+// the counters are swapped to the view of the guest. Taps of the command line
+// without an ifname are named by the kernel and skipped.
+func collectInterfaces(dir string, taps []tap, d *driver.Domain) error {
+	if len(taps) == 0 {
+		return nil
+	}
+	devs, err := readNetDev(filepath.Join(dir, "net", "dev"))
+	if err != nil {
+		return err
+	}
+
+	d.Interfaces = slices.Grow(d.Interfaces[:0], len(taps))
+	for _, t := range taps {
+		if t.ifname == "" {
+			continue
+		}
+		iface := driver.NetworkInterface{
+			Name:       t.ifname,
+			Mac:        t.mac,
+			HostDevice: t.ifname,
+			Bridges:    driver.HostBridges(t.ifname),
+		}
+		if dev, ok := devs[t.ifname]; ok {
+			iface.RX, iface.TX = dev.tx, dev.rx
+		}
+		d.Interfaces = append(d.Interfaces, iface)
+	}
+	return nil
+}
+
+// collectMemory Configured memory and the resident set of the process,
+// which grows as the guest touches its memory and includes that of QEMU
+func collectMemory(dir string, cmd cmdline, d *driver.Domain) error {
+	status, err := readKeyValues(filepath.Join(dir, "status"), ":")
+	if err != nil {
+		return err
+	}
+	d.MemoryBacking.BalloonMaximum = cmd.maxMemory
+	d.MemoryBacking.BalloonCurrent = cmd.memory
+	d.Memory.Actual, d.Memory.ActualSet = cmd.memory, true
+	if rss, ok := status["VmRSS"]; ok {
+		d.Memory.RSS, d.Memory.RSSSet = rss*1024, true
+	}
+	return nil
+}
+
+// readStat Parse a stat file of a process or thread
+func readStat(path string) (stat, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return stat{}, err
+	}
+	// The command may hold spaces and parentheses, fields follow the last one
+	open, end := bytes.IndexByte(raw, '('), bytes.LastIndexByte(raw, ')')
+	if open < 0 || end < open {
+		return stat{}, errors.New("procfs: malformed " + path)
+	}
+	// state is field 3, utime and stime 14 and 15, processor 39
+	fields := strings.Fields(string(raw[end+1:]))
+	if len(fields) < 37 {
+		return stat{}, errors.New("procfs: malformed " + path)
+	}
+	st := stat{comm: string(raw[open+1 : end]), state: fields[0][0]}
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+	processor, err3 := strconv.Atoi(fields[36])
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return stat{}, fmt.Errorf("procfs: malformed %s: %w", path, err)
+	}
+	st.time = float64((utime + stime) * uint64(time.Second) / clockTicks)
+	st.processor = processor
+	return st, nil
+}
+
+// readKeyValues Parse a file of "key<sep> value" lines with numeric values
+// such as /proc/<pid>/io, a trailing "kB" unit is dropped. Other lines are
+// skipped.
+func readKeyValues(path, sep string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		key, value, ok := strings.Cut(s.Text(), sep)
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "kB"))
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			values[strings.TrimSpace(key)] = n
+		}
+	}
+	return values, s.Err()
+}
+
+// netDev Counters of a network device as the host sees them
+type netDev struct {
+	rx, tx driver.NetworkIO
+}
+
+// readNetDev Parse /proc/<pid>/net/dev, two header lines then a line of 16
+// counters per device, received first
+func readNetDev(path string) (map[string]netDev, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	devs := make(map[string]netDev)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		name, counters, ok := strings.Cut(s.Text(), ":")
+		fields := strings.Fields(counters)
+		if !ok || len(fields) < 16 {
+			continue
+		}
+		var v [16]uint64
+		for i := range v {
+			v[i], _ = strconv.ParseUint(fields[i], 10, 64)
+		}
+		devs[strings.TrimSpace(name)] = netDev{
+			// Frame, collision and carrier errors are those of the host end of
+			// the link, only the buffer overruns carry over to the guest side
+			rx: driver.NetworkIO{Bytes: v[0], Packets: v[1], Errors: v[2], Drops: v[3], FIFO: v[4]},
+			tx: driver.NetworkIO{Bytes: v[8], Packets: v[9], Errors: v[10], Drops: v[11], FIFO: v[12]},
+		}
+	}
+	return devs, s.Err()
+}
@@ -0,0 +1,287 @@
+// Package procfs Driver collecting QEMU processes from procfs, for hosts
+// where neither libvirt nor the QMP sockets can be reached.
+//
+// Every process running a qemu-system-* (or qemu-kvm) binary is a domain,
+// named, identified and sized from its command line (-name, -uuid, -smp,
+// -m). Only what the process exposes is reported:
+//
+//   - CPUs: the run time of the vCPU threads, told apart when QEMU names
+//     them (-name debug-threads=on), otherwise a single CPU carrying the
+//     time of the whole process
+//   - Blocks: the storage IO of the whole process from /proc/<pid>/io, as a
+//     single device named "io" without operation counts
+//   - Interfaces: the tap backends of the command line (-netdev
+//     tap,ifname=...), counted in the network namespace of the process
+//   - Memory: the configured size and the resident set of the process
+//
+// Live processes are DomainOnline, paused guests can't be told apart.
+// Processes of other users need the privileges to read their procfs
+// entries. Importing the package registers the driver under the name
+// "procfs" using DefaultProc; driver.NewDriver takes the proc key.
+package procfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/virtmonitor/driver"
+)
+
+const (
+	// Hypervisor Hypervisor name reported by the procfs driver
+	Hypervisor driver.DomainHypervisor = "procfs"
+	// DefaultProc Default procfs mount point
+	DefaultProc = "/proc"
+)
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New(DefaultProc)); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		Keys: []string{"proc"},
+		New: func(cfg driver.Config) (driver.Driver, error) {
+			return New(cfg.String("proc", DefaultProc)), nil
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// Procfs procfs driver
+type Procfs struct {
+	proc string
+}
+
+// New Create a procfs driver reading the procfs mounted at proc
+func New(proc string) *Procfs {
+	return &Procfs{proc: proc}
+}
+
+// Name Hypervisor name
+func (p *Procfs) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Capabilities Supported metrics, see the package documentation for how
+// little each covers
+func (p *Procfs) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:       true,
+		SupportsBlocks:     true,
+		SupportsInterfaces: true,
+		SupportsMemory:     true,
+		SupportsVersions:   true,
+	}
+}
+
+// Detect Test if any QEMU process is running
+func (p *Procfs) Detect() bool {
+	return p.Diagnose().Detected
+}
+
+// Diagnose Count the QEMU processes found
+func (p *Procfs) Diagnose() driver.DetectResult {
+	procs, err := p.processes()
+	switch {
+	case err != nil:
+		return driver.DetectResult{Reason: "listing " + p.proc + " failed: " + err.Error(), Err: err}
+	case len(procs) == 0:
+		return driver.DetectResult{
+			Reason: "no QEMU process found in " + p.proc,
+			Err:    fmt.Errorf("procfs: no QEMU processes: %w", driver.ErrHypervisorUnavailable),
+		}
+	}
+	return driver.DetectResult{Detected: true, Reason: fmt.Sprintf("%d QEMU processes", len(procs))}
+}
+
+// Collect Collect QEMU processes
+func (p *Procfs) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return p.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect QEMU processes concurrently
+func (p *Procfs) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	procs, err := driver.Enumerate(ctx, opts, func(context.Context) ([]process, error) { return p.processes() })
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.CollectDomains(ctx, opts, procs, p.collector(opts, nil))
+}
+
+// CollectInto Collect QEMU processes as CollectContext does into dst,
+// reusing its domains, see driver.CollectInto
+func (p *Procfs) CollectInto(ctx context.Context, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	procs, err := driver.Enumerate(ctx, opts, func(context.Context) ([]process, error) { return p.processes() })
+	if err != nil {
+		clear(dst)
+		return err
+	}
+
+	return driver.CollectDomainsInto(ctx, opts, procs, dst, func(ctx context.Context, proc process, r *driver.Recycler) (*driver.Domain, error) {
+		return p.collector(opts, r)(ctx, proc)
+	})
+}
+
+// CollectStream Collect QEMU processes as CollectContext does, emitting each
+// as soon as it's collected
+func (p *Procfs) CollectStream(ctx context.Context, opts driver.CollectOptions, emit func(*driver.Domain) error) error {
+	procs, err := driver.Enumerate(ctx, opts, func(context.Context) ([]process, error) { return p.processes() })
+	if err != nil {
+		return err
+	}
+
+	return driver.StreamDomains(ctx, opts, procs, p.collector(opts, nil), emit)
+}
+
+// collector Collect function of the processes listed by CollectContext,
+// CollectInto and CollectStream, filling the domains of r
+func (p *Procfs) collector(opts driver.CollectOptions, r *driver.Recycler) func(context.Context, process) (*driver.Domain, error) {
+	return func(ctx context.Context, proc process) (*driver.Domain, error) {
+		if !opts.Keep(proc.name(), proc.cmd.uuid, proc.id()) {
+			return nil, nil
+		}
+		d, err := p.collect(proc, opts, r)
+		if err != nil {
+			return nil, &driver.DomainError{ID: proc.id(), Name: proc.name(), Err: err}
+		}
+		return d, nil
+	}
+}
+
+// CollectDomain Collect a single QEMU process by domain ID
+func (p *Procfs) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	return p.find(opts, func(proc process) bool { return proc.id() == id }, fmt.Sprintf("%d", id))
+}
+
+// CollectDomainByUUID Collect a single QEMU process by its -uuid
+func (p *Procfs) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	u, err := driver.NormalizeUUID(uuid)
+	if err != nil {
+		return nil, fmt.Errorf("procfs: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+	return p.find(opts, func(proc process) bool { return proc.cmd.uuid == u }, uuid)
+}
+
+// CollectDomainByName Collect a single QEMU process by name, the one with
+// the lowest PID if several share it
+func (p *Procfs) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	return p.find(opts, func(proc process) bool { return proc.name() == name }, fmt.Sprintf("%q", name))
+}
+
+// find Collect the first process accepted by match, what names it in errors
+func (p *Procfs) find(opts driver.CollectOptions, match func(process) bool, what string) (*driver.Domain, error) {
+	procs, err := p.processes()
+	if err != nil {
+		return nil, err
+	}
+
+	opts = opts.Unfiltered()
+	for _, proc := range procs {
+		if !match(proc) {
+			continue
+		}
+		d, err := p.collect(proc, opts, nil)
+		if d != nil || err != nil {
+			return d, err
+		}
+		// Exited since it was listed
+		break
+	}
+	return nil, fmt.Errorf("procfs: domain %s: %w", what, driver.ErrDomainNotFound)
+}
+
+// CollectSnapshots Snapshots live in the disk images, which the process
+// doesn't tell about
+func (p *Procfs) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	return nil, fmt.Errorf("procfs: snapshots: %w", driver.ErrNotSupported)
+}
+
+// Host Metrics of the local host, which runs the processes
+func (p *Procfs) Host() (*driver.HostInfo, error) {
+	return driver.LocalHostInfo()
+}
+
+// Ping Test that procfs can be listed
+func (p *Procfs) Ping(ctx context.Context) error {
+	_, err := p.processes()
+	return err
+}
+
+// Watch Processes have no event stream
+func (p *Procfs) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	return nil, fmt.Errorf("procfs: watch: %w", driver.ErrNotSupported)
+}
+
+// Close Nothing to release, procfs is read per collection
+func (p *Procfs) Close() error {
+	return nil
+}
+
+// process A QEMU process and its parsed command line
+type process struct {
+	pid int
+	cmd cmdline
+}
+
+// name Domain name, from -name or the PID when there is none
+func (proc process) name() string {
+	if proc.cmd.name != "" {
+		return proc.cmd.name
+	}
+	return "qemu-" + strconv.Itoa(proc.pid)
+}
+
+// id Domain ID: the Proxmox VE VM ID when given, otherwise hashed from the
+// UUID or failing that from the name
+func (proc process) id() driver.DomainID {
+	if id, err := driver.ParseDomainID(proc.cmd.id); err == nil {
+		return id
+	}
+	if proc.cmd.uuid != "" {
+		return driver.HashDomainID(proc.cmd.uuid)
+	}
+	return driver.HashDomainID(proc.name())
+}
+
+// processes QEMU processes currently running, by ascending PID. Processes
+// whose command line can't be read, kernel threads and those of other users
+// without the privileges, are skipped.
+func (p *Procfs) processes() ([]process, error) {
+	entries, err := os.ReadDir(p.proc)
+	if err != nil {
+		return nil, procError(err)
+	}
+
+	var procs []process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(p.proc, e.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		if cmd, ok := parseCmdline(raw); ok {
+			procs = append(procs, process{pid: pid, cmd: cmd})
+		}
+	}
+	return procs, nil
+}
+
+// procError Wrap a failure reading procfs
+func procError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("procfs: %w: %w", driver.ErrPermissionDenied, err)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("procfs: %w: %w", driver.ErrHypervisorUnavailable, err)
+	}
+	return fmt.Errorf("procfs: %w", err)
+}
@@ -0,0 +1,35 @@
+package procfs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/virtmonitor/driver"
+)
+
+func TestCollectDomainNotFound(t *testing.T) {
+	proc := t.TempDir()
+	// A process that isn't QEMU
+	if err := os.Mkdir(filepath.Join(proc, "1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(proc, "1", "cmdline"), []byte("/sbin/init\x00splash\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := New(proc)
+
+	var opts driver.CollectOptions
+	const uuid = "6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f"
+	lookups := map[string]func() (*driver.Domain, error){
+		"ID":   func() (*driver.Domain, error) { return p.CollectDomain(1, opts) },
+		"UUID": func() (*driver.Domain, error) { return p.CollectDomainByUUID(uuid, opts) },
+		"Name": func() (*driver.Domain, error) { return p.CollectDomainByName("init", opts) },
+	}
+	for name, lookup := range lookups {
+		if d, err := lookup(); d != nil || !errors.Is(err, driver.ErrDomainNotFound) {
+			t.Errorf("by %s: got %v, %v, want ErrDomainNotFound", name, d, err)
+		}
+	}
+}
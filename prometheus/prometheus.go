@@ -0,0 +1,276 @@
+// Package prometheus Prometheus collector exporting domain metrics.
+//
+// Domains are collected on every scrape. Cumulative counters are exported as
+// Prometheus counters and instantaneous values as gauges. Block IO from
+// drivers reporting per interval deltas (BlockIO.Absolute unset) is exported
+// as *_delta gauges instead of *_total counters, as it must not be rated again.
+package prometheus
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/virtmonitor/driver"
+)
+
+const namespace = "virtmonitor"
+
+var (
+	domainLabels = []string{"domain", "uuid", "hypervisor"}
+	cpuLabels    = append(domainLabels[:3:3], "cpu")
+	blockLabels  = append(domainLabels[:3:3], "device", "op")
+	deviceLabels = append(domainLabels[:3:3], "device")
+	ifaceLabels  = append(domainLabels[:3:3], "interface", "direction")
+	fsLabels     = append(domainLabels[:3:3], "mountpoint", "fstype")
+)
+
+// Source Returns the domains to export on each scrape
+type Source func() (map[driver.DomainID]*driver.Domain, error)
+
+// Collector Prometheus collector for a driver or other domain source
+type Collector struct {
+	source Source
+
+	up       *prometheus.Desc
+	duration *prometheus.Desc
+	info     *prometheus.Desc
+
+	domainDuration *prometheus.Desc
+	guestAgent     *prometheus.Desc
+
+	cpuTime   *prometheus.Desc
+	cpuSteal  *prometheus.Desc
+	cpuIOWait *prometheus.Desc
+	cpuLoad   map[string]*prometheus.Desc
+
+	blockOps         *prometheus.Desc
+	blockBytes       *prometheus.Desc
+	blockOpsDelta    *prometheus.Desc
+	blockBytesDelta  *prometheus.Desc
+	blockTime        *prometheus.Desc
+	blockTimeDelta   *prometheus.Desc
+	blockErrors      *prometheus.Desc
+	blockErrorsDelta *prometheus.Desc
+	blockStalled     *prometheus.Desc
+	blockThrottled   *prometheus.Desc
+
+	netBytes   *prometheus.Desc
+	netPackets *prometheus.Desc
+	netErrors  *prometheus.Desc
+	netDrops   *prometheus.Desc
+	netFIFO    *prometheus.Desc
+	netFrame   *prometheus.Desc
+	netColls   *prometheus.Desc
+	netCarrier *prometheus.Desc
+
+	memGauges   map[string]*prometheus.Desc
+	memCounters map[string]*prometheus.Desc
+
+	fsSize *prometheus.Desc
+	fsUsed *prometheus.Desc
+}
+
+// New Collector collecting from d with opts on every scrape
+func New(d driver.Driver, opts driver.CollectOptions) *Collector {
+	return NewFromSource(func() (map[driver.DomainID]*driver.Domain, error) {
+		return d.Collect(opts)
+	})
+}
+
+// NewFromSource Collector exporting whatever source returns, e.g. the latest
+// result of a collection loop owned by the caller
+func NewFromSource(source Source) *Collector {
+	desc := func(name, help string, labels []string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+	}
+
+	return &Collector{
+		source: source,
+
+		up:       desc("up", "Whether the last collection succeeded.", nil),
+		duration: desc("collect_duration_seconds", "Time taken by the last collection.", nil),
+		info:     desc("domain_info", "Domain identity and state.", append(domainLabels[:3:3], "os_type", "state", "machine_type", "emulator_version")),
+
+		domainDuration: desc("domain_collect_duration_seconds", "Time taken collecting the domain.", domainLabels),
+		guestAgent:     desc("domain_guest_agent_connected", "Whether the guest agent is connected, absent when unknown.", domainLabels),
+
+		cpuTime:   desc("cpu_time_seconds_total", "vCPU time consumed.", cpuLabels),
+		cpuSteal:  desc("cpu_steal_seconds_total", "Time the vCPU waited for a physical CPU.", cpuLabels),
+		cpuIOWait: desc("cpu_iowait_seconds_total", "Time the guest waited for IO on the vCPU.", cpuLabels),
+		cpuLoad: map[string]*prometheus.Desc{
+			"1":  desc("cpu_load1", "vCPU 1 minute load average.", cpuLabels),
+			"5":  desc("cpu_load5", "vCPU 5 minute load average.", cpuLabels),
+			"15": desc("cpu_load15", "vCPU 15 minute load average.", cpuLabels),
+		},
+
+		blockOps:         desc("block_operations_total", "Block device operations.", blockLabels),
+		blockBytes:       desc("block_bytes_total", "Block device bytes transferred.", blockLabels),
+		blockOpsDelta:    desc("block_operations_delta", "Block device operations during the last interval.", blockLabels),
+		blockBytesDelta:  desc("block_bytes_delta", "Block device bytes transferred during the last interval.", blockLabels),
+		blockTime:        desc("block_time_seconds_total", "Time spent completing block device operations.", blockLabels),
+		blockTimeDelta:   desc("block_time_seconds_delta", "Time spent completing block device operations during the last interval.", blockLabels),
+		blockErrors:      desc("block_errors_total", "Block device operations failed.", blockLabels),
+		blockErrorsDelta: desc("block_errors_delta", "Block device operations failed during the last interval.", blockLabels),
+		blockStalled:     desc("block_stalled", "Whether the block device is in an error state.", deviceLabels),
+		blockThrottled:   desc("block_throttled", "Whether IO to the block device ran at its limits, set by a ThrottleTracker.", deviceLabels),
+
+		netBytes:   desc("network_bytes_total", "Network interface bytes.", ifaceLabels),
+		netPackets: desc("network_packets_total", "Network interface packets.", ifaceLabels),
+		netErrors:  desc("network_errors_total", "Network interface errors.", ifaceLabels),
+		netDrops:   desc("network_drops_total", "Network interface dropped packets.", ifaceLabels),
+		netFIFO:    desc("network_fifo_errors_total", "Network interface buffer overruns.", ifaceLabels),
+		netFrame:   desc("network_frame_errors_total", "Network interface received frames with a bad length or checksum.", ifaceLabels),
+		netColls:   desc("network_collisions_total", "Network interface transmit collisions.", ifaceLabels),
+		netCarrier: desc("network_carrier_errors_total", "Network interface transmit carrier losses.", ifaceLabels),
+
+		memGauges: map[string]*prometheus.Desc{
+			"actual":     desc("memory_actual_bytes", "Current balloon size.", domainLabels),
+			"available":  desc("memory_available_bytes", "Memory visible to the guest.", domainLabels),
+			"unused":     desc("memory_unused_bytes", "Memory unused by the guest.", domainLabels),
+			"rss":        desc("memory_rss_bytes", "Resident set size of the hypervisor process.", domainLabels),
+			"dirty_rate": desc("memory_dirty_rate_pages_per_second", "Guest memory pages of 4 KiB written per second.", domainLabels),
+		},
+		memCounters: map[string]*prometheus.Desc{
+			"swap_in":      desc("memory_swap_in_bytes_total", "Bytes swapped in by the guest.", domainLabels),
+			"swap_out":     desc("memory_swap_out_bytes_total", "Bytes swapped out by the guest.", domainLabels),
+			"major_faults": desc("memory_major_faults_total", "Major page faults in the guest.", domainLabels),
+			"minor_faults": desc("memory_minor_faults_total", "Minor page faults in the guest.", domainLabels),
+		},
+
+		fsSize: desc("filesystem_size_bytes", "Guest file system size.", fsLabels),
+		fsUsed: desc("filesystem_used_bytes", "Guest file system bytes used.", fsLabels),
+	}
+}
+
+// Describe Implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.up, c.duration, c.info, c.domainDuration, c.guestAgent, c.cpuTime, c.cpuSteal, c.cpuIOWait,
+		c.blockOps, c.blockBytes, c.blockOpsDelta, c.blockBytesDelta, c.blockTime, c.blockTimeDelta,
+		c.blockErrors, c.blockErrorsDelta, c.blockStalled, c.blockThrottled,
+		c.netBytes, c.netPackets, c.netErrors, c.netDrops, c.netFIFO, c.netFrame, c.netColls, c.netCarrier,
+		c.fsSize, c.fsUsed,
+	} {
+		ch <- d
+	}
+	for _, m := range []map[string]*prometheus.Desc{c.cpuLoad, c.memGauges, c.memCounters} {
+		for _, d := range m {
+			ch <- d
+		}
+	}
+}
+
+// Collect Implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
+	domains, err := c.source()
+	ch <- prometheus.MustNewConstMetric(c.duration, prometheus.GaugeValue, time.Since(start).Seconds())
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		ch <- prometheus.NewInvalidMetric(c.up, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+
+	for _, d := range domains {
+		c.collectDomain(ch, d)
+	}
+}
+
+func (c *Collector) collectDomain(ch chan<- prometheus.Metric, d *driver.Domain) {
+	labels := []string{d.Name, d.UUID, string(d.Hypervisor)}
+	with := func(extra ...string) []string {
+		return append(labels[:len(labels):len(labels)], extra...)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, with(d.OSType, d.Flags.String(), d.MachineType, d.EmulatorVersion)...)
+	if d.CollectDuration > 0 {
+		ch <- prometheus.MustNewConstMetric(c.domainDuration, prometheus.GaugeValue, d.CollectDuration.Seconds(), labels...)
+	}
+	if d.GuestAgent != driver.AgentUnknown {
+		connected := 0.0
+		if d.GuestAgent == driver.AgentConnected {
+			connected = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.guestAgent, prometheus.GaugeValue, connected, labels...)
+	}
+
+	for _, cpu := range d.Cpus {
+		id := strconv.FormatUint(cpu.ID, 10)
+		ch <- prometheus.MustNewConstMetric(c.cpuTime, prometheus.CounterValue, cpu.Time/float64(time.Second), with(id)...)
+		if cpu.StealSet {
+			ch <- prometheus.MustNewConstMetric(c.cpuSteal, prometheus.CounterValue, cpu.Steal/float64(time.Second), with(id)...)
+		}
+		if cpu.IOWaitSet {
+			ch <- prometheus.MustNewConstMetric(c.cpuIOWait, prometheus.CounterValue, cpu.IOWait/float64(time.Second), with(id)...)
+		}
+		ch <- prometheus.MustNewConstMetric(c.cpuLoad["1"], prometheus.GaugeValue, cpu.Load1, with(id)...)
+		ch <- prometheus.MustNewConstMetric(c.cpuLoad["5"], prometheus.GaugeValue, cpu.Load5, with(id)...)
+		ch <- prometheus.MustNewConstMetric(c.cpuLoad["15"], prometheus.GaugeValue, cpu.Load15, with(id)...)
+	}
+
+	for _, b := range d.Blocks {
+		for op, io := range map[string]driver.BlockIO{"read": b.Read, "write": b.Write, "flush": b.Flush} {
+			ops, bytes, spent, errs, typ := c.blockOps, c.blockBytes, c.blockTime, c.blockErrors, prometheus.CounterValue
+			if !io.Absolute {
+				ops, bytes, spent, errs, typ = c.blockOpsDelta, c.blockBytesDelta, c.blockTimeDelta, c.blockErrorsDelta, prometheus.GaugeValue
+			}
+			ch <- prometheus.MustNewConstMetric(ops, typ, float64(io.Operations), with(b.Name, op)...)
+			ch <- prometheus.MustNewConstMetric(bytes, typ, float64(io.Bytes), with(b.Name, op)...)
+			ch <- prometheus.MustNewConstMetric(errs, typ, float64(io.Errors), with(b.Name, op)...)
+			if io.TotalTimeSet {
+				ch <- prometheus.MustNewConstMetric(spent, typ, float64(io.TotalTime)/float64(time.Second), with(b.Name, op)...)
+			}
+		}
+		stalled := 0.0
+		if b.Stalled {
+			stalled = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.blockStalled, prometheus.GaugeValue, stalled, with(b.Name)...)
+		throttled := 0.0
+		if b.Throttled {
+			throttled = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.blockThrottled, prometheus.GaugeValue, throttled, with(b.Name)...)
+	}
+
+	for _, iface := range d.Interfaces {
+		for dir, io := range map[string]driver.NetworkIO{"rx": iface.RX, "tx": iface.TX} {
+			ch <- prometheus.MustNewConstMetric(c.netBytes, prometheus.CounterValue, float64(io.Bytes), with(iface.Name, dir)...)
+			ch <- prometheus.MustNewConstMetric(c.netPackets, prometheus.CounterValue, float64(io.Packets), with(iface.Name, dir)...)
+			ch <- prometheus.MustNewConstMetric(c.netErrors, prometheus.CounterValue, float64(io.Errors), with(iface.Name, dir)...)
+			ch <- prometheus.MustNewConstMetric(c.netDrops, prometheus.CounterValue, float64(io.Drops), with(iface.Name, dir)...)
+			ch <- prometheus.MustNewConstMetric(c.netFIFO, prometheus.CounterValue, float64(io.FIFO), with(iface.Name, dir)...)
+		}
+		ch <- prometheus.MustNewConstMetric(c.netFrame, prometheus.CounterValue, float64(iface.RX.Frame), with(iface.Name, "rx")...)
+		ch <- prometheus.MustNewConstMetric(c.netColls, prometheus.CounterValue, float64(iface.TX.Collisions), with(iface.Name, "tx")...)
+		ch <- prometheus.MustNewConstMetric(c.netCarrier, prometheus.CounterValue, float64(iface.TX.Carrier), with(iface.Name, "tx")...)
+	}
+
+	for _, fs := range d.Filesystems {
+		ch <- prometheus.MustNewConstMetric(c.fsSize, prometheus.GaugeValue, float64(fs.TotalBytes), with(fs.Mountpoint, fs.Type)...)
+		ch <- prometheus.MustNewConstMetric(c.fsUsed, prometheus.GaugeValue, float64(fs.UsedBytes), with(fs.Mountpoint, fs.Type)...)
+	}
+
+	m := d.Memory
+	gauge := func(name string, v uint64, set bool) {
+		if set {
+			ch <- prometheus.MustNewConstMetric(c.memGauges[name], prometheus.GaugeValue, float64(v), labels...)
+		}
+	}
+	counter := func(name string, v uint64, set bool) {
+		if set {
+			ch <- prometheus.MustNewConstMetric(c.memCounters[name], prometheus.CounterValue, float64(v), labels...)
+		}
+	}
+	gauge("actual", m.Actual, m.ActualSet)
+	gauge("available", m.Available, m.AvailableSet)
+	gauge("unused", m.Unused, m.UnusedSet)
+	gauge("rss", m.RSS, m.RSSSet)
+	gauge("dirty_rate", m.DirtyRate, m.DirtyRateSet)
+	counter("swap_in", m.SwapIn, m.SwapInSet)
+	counter("swap_out", m.SwapOut, m.SwapOutSet)
+	counter("major_faults", m.MajorFaults, m.MajorFaultsSet)
+	counter("minor_faults", m.MinorFaults, m.MinorFaultsSet)
+}
@@ -0,0 +1,514 @@
+package qmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+type statusInfo struct {
+	Running bool   `json:"running"`
+	Status  string `json:"status"`
+}
+
+type cpuInfo struct {
+	CPUIndex int    `json:"cpu-index"`
+	ThreadID int    `json:"thread-id"`
+	QOMPath  string `json:"qom-path"`
+}
+
+// hotpluggableCPU A vCPU slot, plugged in if it has a QOM path
+type hotpluggableCPU struct {
+	VCPUsCount int    `json:"vcpus-count"`
+	QOMPath    string `json:"qom-path"`
+}
+
+type blockStats struct {
+	Device   string `json:"device"`
+	NodeName string `json:"node-name"`
+	Qdev     string `json:"qdev"`
+	Stats    struct {
+		RdBytes          uint64 `json:"rd_bytes"`
+		WrBytes          uint64 `json:"wr_bytes"`
+		RdOperations     uint64 `json:"rd_operations"`
+		WrOperations     uint64 `json:"wr_operations"`
+		FlushOperations  uint64 `json:"flush_operations"`
+		RdTotalTimeNs    uint64 `json:"rd_total_time_ns"`
+		WrTotalTimeNs    uint64 `json:"wr_total_time_ns"`
+		FlushTotalTimeNs uint64 `json:"flush_total_time_ns"`
+		FailedRd         uint64 `json:"failed_rd_operations"`
+		FailedWr         uint64 `json:"failed_wr_operations"`
+		FailedFlush      uint64 `json:"failed_flush_operations"`
+	} `json:"stats"`
+}
+
+// blockDevice A device of query-block, IOStatus is only reported for
+// devices that stop on errors (werror=stop or enospc)
+type blockDevice struct {
+	Device   string `json:"device"`
+	Qdev     string `json:"qdev"`
+	IOStatus string `json:"io-status"`
+}
+
+type blockNode struct {
+	NodeName  string     `json:"node-name"`
+	ReadOnly  bool       `json:"ro"`
+	File      string     `json:"file"`
+	BPS       uint64     `json:"bps"`
+	BPSRead   uint64     `json:"bps_rd"`
+	BPSWrite  uint64     `json:"bps_wr"`
+	IOPS      uint64     `json:"iops"`
+	IOPSRead  uint64     `json:"iops_rd"`
+	IOPSWrite uint64     `json:"iops_wr"`
+	Image     blockImage `json:"image"`
+}
+
+// blockImage Image of a block node, holding the image it is backed by
+type blockImage struct {
+	Filename     string      `json:"filename"`
+	VirtualSize  uint64      `json:"virtual-size"`
+	ActualSize   uint64      `json:"actual-size"`
+	BackingImage *blockImage `json:"backing-image"`
+}
+
+// backingChain Files of the images backing the node, nearest first
+func (n blockNode) backingChain() []string {
+	var chain []string
+	for img := n.Image.BackingImage; img != nil; img = img.BackingImage {
+		chain = append(chain, img.Filename)
+	}
+	return chain
+}
+
+type rxFilter struct {
+	Name    string `json:"name"`
+	MainMAC string `json:"main-mac"`
+}
+
+// collectDomain Collect the domain behind the monitor of socket path into a
+// domain of r, nil if opts.Filter excludes it
+func collectDomain(ctx context.Context, m *monitor, path string, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	now := driver.TimestampNow()
+	var status statusInfo
+	if err := m.execute(ctx, "query-status", nil, &status); err != nil {
+		return nil, err
+	}
+	flags := domainFlag(status.Status)
+	if flags == driver.DomainOnline {
+		migrating, err := liveMigrating(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		if migrating {
+			flags = driver.DomainMigratingOut
+		}
+	}
+
+	var name struct {
+		Name string `json:"name"`
+	}
+	if err := m.execute(ctx, "query-name", nil, &name); err != nil {
+		return nil, err
+	}
+
+	var uuid struct {
+		UUID string `json:"UUID"`
+	}
+	if err := m.execute(ctx, "query-uuid", nil, &uuid); err != nil {
+		return nil, err
+	}
+	// Invalid UUIDs are kept as reported
+	u := uuid.UUID
+	if n, err := driver.NormalizeUUID(u); err == nil {
+		u = n
+	} else if u != "" {
+		driver.GetLogger().Warn("domain reports an invalid UUID", "driver", Hypervisor, "domain", name.Name, "error", err)
+	}
+	id := socketID(path)
+	if !opts.Keep(name.Name, u, id) {
+		return nil, nil
+	}
+
+	d := r.Domain(id)
+	d.Name, d.UUID, d.ID = name.Name, u, id
+	d.Hypervisor, d.Time, d.Flags = Hypervisor, now, flags
+	d.SetPrivate(path)
+	if opts.Skips(d.Flags) {
+		return d, nil
+	}
+
+	if opts.Versions {
+		var err error
+		if d.MachineType, d.EmulatorVersion, err = versions(ctx, m); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.CPUs {
+		var cpus []cpuInfo
+		if err := m.execute(ctx, "query-cpus-fast", nil, &cpus); err != nil {
+			return nil, err
+		}
+		d.VCPUs = len(cpus)
+		d.VCPUsCurrent = len(cpus)
+		maximum, err := maxVCPUs(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		d.VCPUsMaximum = max(maximum, len(cpus))
+		d.Cpus = collectCPUs(d.Cpus, cpus, d.Flags, opts.Pinning)
+		if len(cpus) > 0 {
+			if d.NestedVirt, d.NestedVirtSet, err = nestedVirt(ctx, m, cpus[0].QOMPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if opts.Blocks {
+		blocks, err := collectBlocks(ctx, m, d.Blocks, opts.BlockCapacity, opts.BackingChain, opts.Limits)
+		if err != nil {
+			return nil, err
+		}
+		d.Blocks = blocks
+	}
+
+	if opts.Interfaces {
+		var filters []rxFilter
+		if err := m.execute(ctx, "query-rx-filter", nil, &filters); err != nil {
+			return nil, err
+		}
+		d.Interfaces = collectInterfaces(d.Interfaces, filters)
+	}
+
+	if opts.Memory {
+		var balloon struct {
+			Actual uint64 `json:"actual"`
+		}
+		// Fails when the guest has no balloon device, leave memory unset
+		if err := m.execute(ctx, "query-balloon", nil, &balloon); err == nil {
+			d.Memory.Actual, d.Memory.ActualSet = balloon.Actual, true
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		} else {
+			driver.GetLogger().Debug("balloon unavailable", "driver", Hypervisor, "domain", d.Name, "error", err)
+		}
+		d.MemoryBacking.BalloonCurrent = d.Memory.Actual
+
+		var summary struct {
+			BaseMemory    uint64 `json:"base-memory"`
+			PluggedMemory uint64 `json:"plugged-memory"`
+		}
+		if err := m.execute(ctx, "query-memory-size-summary", nil, &summary); err == nil {
+			d.MemoryBacking.BalloonMaximum = summary.BaseMemory + summary.PluggedMemory
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d, nil
+}
+
+func collectCPUs(cpus []driver.CPU, infos []cpuInfo, flags driver.DomainFlag, pinning bool) []driver.CPU {
+	cpus = slices.Grow(cpus[:0], len(infos))
+	for _, info := range infos {
+		cpu := driver.CPU{
+			ID:    uint64(info.CPUIndex),
+			Flags: driver.CPURunning,
+		}
+		if flags != driver.DomainOnline {
+			cpu.Flags = driver.CPUPaused
+		}
+
+		threadStat(info.ThreadID, &cpu)
+		threadSteal(info.ThreadID, &cpu)
+		if pinning {
+			cpu.Affinity = threadAffinity(info.ThreadID)
+		}
+
+		cpus = append(cpus, cpu)
+	}
+	return cpus
+}
+
+// threadStat Fill in CPU time in nanoseconds and the last physical CPU of a
+// vCPU thread from procfs. Monitor sockets are local so the thread is
+// visible to us; the fields are left unset if not.
+func threadStat(tid int, cpu *driver.CPU) {
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(tid) + "/stat")
+	if err != nil {
+		return
+	}
+
+	// Skip past the command name, which may contain spaces
+	s := string(stat)
+	if i := strings.LastIndexByte(s, ')'); i >= 0 {
+		s = s[i+1:]
+	}
+
+	// Fields are numbered from 1 in proc(5), the name is field 2 so field n
+	// is at index n-3: utime 14, stime 15, processor 39
+	fields := strings.Fields(s)
+	if len(fields) < 37 {
+		return
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+
+	// USER_HZ is 100 on all supported platforms
+	cpu.Time = float64(utime+stime) * float64(time.Second/100)
+
+	if processor, err := strconv.Atoi(fields[36]); err == nil {
+		cpu.PhysicalCPU, cpu.PhysicalCPUSet = processor, true
+	}
+}
+
+// threadSteal Fill in steal time from the scheduler statistics of a vCPU
+// thread: the time it waited on a run queue, which KVM reports to the guest
+// as steal time. Left unset without schedstats.
+func threadSteal(tid int, cpu *driver.CPU) {
+	sched, err := os.ReadFile("/proc/" + strconv.Itoa(tid) + "/schedstat")
+	if err != nil {
+		return
+	}
+
+	// run time, run queue delay and time slices, times in nanoseconds
+	fields := strings.Fields(string(sched))
+	if len(fields) < 2 {
+		return
+	}
+	if delay, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+		cpu.Steal, cpu.StealSet = float64(delay), true
+	}
+}
+
+// threadAffinity CPUs a vCPU thread may run on, from procfs
+func threadAffinity(tid int) driver.CPUSet {
+	status, err := os.ReadFile("/proc/" + strconv.Itoa(tid) + "/status")
+	if err != nil {
+		return nil
+	}
+
+	for _, line := range strings.Split(string(status), "\n") {
+		if list, ok := strings.CutPrefix(line, "Cpus_allowed_list:"); ok {
+			set, err := driver.ParseCPUSet(list)
+			if err != nil {
+				return nil
+			}
+			return set
+		}
+	}
+	return nil
+}
+
+// versions Machine type of the VM, from the QOM type of /machine such as
+// pc-q35-8.2-machine, and version of the QEMU process
+func versions(ctx context.Context, m *monitor) (machine, version string, err error) {
+	var typ string
+	if err := m.execute(ctx, "qom-get", map[string]string{"path": "/machine", "property": "type"}, &typ); err != nil {
+		return "", "", err
+	}
+
+	var v struct {
+		QEMU struct {
+			Major int `json:"major"`
+			Minor int `json:"minor"`
+			Micro int `json:"micro"`
+		} `json:"qemu"`
+	}
+	if err := m.execute(ctx, "query-version", nil, &v); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSuffix(typ, "-machine"), fmt.Sprintf("%d.%d.%d", v.QEMU.Major, v.QEMU.Minor, v.QEMU.Micro), nil
+}
+
+// nestedVirt Whether a vCPU exposes vmx or svm, read from the feature
+// properties of x86 CPU objects. ok is false for other architectures.
+func nestedVirt(ctx context.Context, m *monitor, qomPath string) (nested, ok bool, err error) {
+	for _, feature := range []string{"vmx", "svm"} {
+		var enabled bool
+		err := m.execute(ctx, "qom-get", map[string]string{"path": qomPath, "property": feature}, &enabled)
+		var cerr *commandError
+		if errors.As(err, &cerr) {
+			// No such property, not an x86 CPU or not this vendor
+			continue
+		}
+		if err != nil {
+			return false, false, err
+		}
+		ok = true
+		if enabled {
+			return true, true, nil
+		}
+	}
+	return false, ok, nil
+}
+
+// maxVCPUs vCPUs of every hotpluggable slot, 0 for machine types without
+// CPU hotplug
+func maxVCPUs(ctx context.Context, m *monitor) (int, error) {
+	var slots []hotpluggableCPU
+	if err := m.execute(ctx, "query-hotpluggable-cpus", nil, &slots); err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, nil
+	}
+
+	n := 0
+	for _, slot := range slots {
+		n += slot.VCPUsCount
+	}
+	return n, nil
+}
+
+func collectBlocks(ctx context.Context, m *monitor, blocks []driver.BlockDevice, capacity, chain, limits bool) ([]driver.BlockDevice, error) {
+	var stats []blockStats
+	if err := m.execute(ctx, "query-blockstats", nil, &stats); err != nil {
+		return nil, err
+	}
+
+	var nodes []blockNode
+	if err := m.execute(ctx, "query-named-block-nodes", nil, &nodes); err != nil {
+		return nil, err
+	}
+	byName := make(map[string]blockNode, len(nodes))
+	for _, node := range nodes {
+		byName[node.NodeName] = node
+	}
+
+	var devices []blockDevice
+	if err := m.execute(ctx, "query-block", nil, &devices); err != nil {
+		return nil, err
+	}
+	// Devices are named after their drive, or their qdev without one
+	stalled := make(map[string]bool)
+	for _, dev := range devices {
+		if dev.IOStatus == "failed" || dev.IOStatus == "nospace" {
+			stalled[dev.Device], stalled[dev.Qdev] = true, true
+		}
+	}
+
+	blocks = slices.Grow(blocks[:0], len(stats))
+	for _, s := range stats {
+		name := s.Device
+		if name == "" {
+			name = s.Qdev
+		}
+		if name == "" {
+			name = s.NodeName
+		}
+
+		// QMP doesn't expose the guest bus or device name, only the backing file
+		node := byName[s.NodeName]
+		block := driver.BlockDevice{
+			Name:     name,
+			ReadOnly: node.ReadOnly,
+			IsDisk:   true,
+			Read:     timedBlockIO(s.Stats.RdOperations, s.Stats.RdBytes, s.Stats.RdTotalTimeNs, s.Stats.FailedRd),
+			Write:    timedBlockIO(s.Stats.WrOperations, s.Stats.WrBytes, s.Stats.WrTotalTimeNs, s.Stats.FailedWr),
+			Flush:    timedBlockIO(s.Stats.FlushOperations, 0, s.Stats.FlushTotalTimeNs, s.Stats.FailedFlush),
+			Stalled:  stalled[name],
+			Source:   node.File,
+		}
+		// The image sizes come with the node listing, QMP has no physical size
+		if capacity {
+			block.Capacity = node.Image.VirtualSize
+			block.Allocation = node.Image.ActualSize
+		}
+		if chain {
+			block.BackingChain = node.backingChain()
+		}
+		// Throttling is part of the node listing as well
+		if limits {
+			block.Limits = driver.BlockLimits{
+				ReadIOPS:      node.IOPSRead,
+				WriteIOPS:     node.IOPSWrite,
+				TotalIOPS:     node.IOPS,
+				ReadBytesSec:  node.BPSRead,
+				WriteBytesSec: node.BPSWrite,
+				TotalBytesSec: node.BPS,
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// timedBlockIO Block IO with the total time and failures, which QEMU always
+// accounts
+func timedBlockIO(ops, bytes, totalTime, failed uint64) driver.BlockIO {
+	return driver.BlockIO{
+		Operations:   ops,
+		Bytes:        bytes,
+		Sectors:      bytes / 512,
+		Absolute:     true,
+		TotalTime:    totalTime,
+		TotalTimeSet: true,
+		Errors:       failed,
+	}
+}
+
+// collectInterfaces Interfaces from the NIC receive filters, QMP exposes no
+// per interface counters so only identity is populated. Appended to ifaces
+// emptied.
+func collectInterfaces(ifaces []driver.NetworkInterface, filters []rxFilter) []driver.NetworkInterface {
+	ifaces = slices.Grow(ifaces[:0], len(filters))
+	for _, f := range filters {
+		iface := driver.NetworkInterface{Name: f.Name}
+		if mac, err := net.ParseMAC(f.MainMAC); err == nil {
+			iface.Mac = mac
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces
+}
+
+// liveMigrating Whether a running domain is being migrated out, which
+// query-status doesn't tell until the final stop
+func liveMigrating(ctx context.Context, m *monitor) (bool, error) {
+	var info struct {
+		Status string `json:"status"`
+	}
+	if err := m.execute(ctx, "query-migrate", nil, &info); err != nil {
+		return false, err
+	}
+	switch info.Status {
+	case "setup", "active", "pre-switchover", "device", "postcopy-active", "postcopy-paused", "postcopy-recover", "wait-unplug":
+		return true, nil
+	}
+	return false, nil
+}
+
+// domainFlag Map a QMP run state onto a DomainFlag
+func domainFlag(status string) driver.DomainFlag {
+	switch status {
+	case "running":
+		return driver.DomainOnline
+	case "inmigrate":
+		return driver.DomainMigratingIn
+	case "finish-migrate":
+		return driver.DomainMigratingOut
+	case "save-vm":
+		return driver.DomainSaving
+	case "restore-vm":
+		return driver.DomainRestoring
+	case "shutdown":
+		return driver.DomainShutdown
+	case "guest-panicked", "internal-error", "io-error", "watchdog":
+		return driver.DomainCrashed
+	default:
+		return driver.DomainPaused
+	}
+}
@@ -0,0 +1,36 @@
+package qmp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/virtmonitor/driver"
+)
+
+// commandError A QMP command returned an error object
+type commandError struct {
+	Command string
+	Class   string
+	Desc    string
+}
+
+func (e *commandError) Error() string {
+	return fmt.Sprintf("qmp: %s: %s: %s", e.Command, e.Class, e.Desc)
+}
+
+// Unwrap Map the QMP error class onto a driver sentinel
+func (e *commandError) Unwrap() error {
+	if e.Class == "CommandNotFound" {
+		return driver.ErrNotSupported
+	}
+	return nil
+}
+
+// connError Wrap a failure to connect to or talk over a monitor socket
+func connError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("qmp: %w: %w", driver.ErrPermissionDenied, err)
+	}
+	return fmt.Errorf("qmp: %w: %w", driver.ErrHypervisorUnavailable, err)
+}
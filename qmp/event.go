@@ -0,0 +1,117 @@
+package qmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// Watch Push run state events from every socket present when called.
+// Sockets created afterwards aren't watched, call Watch again to pick them
+// up. The channel is closed once ctx is done or every watched monitor
+// connection has dropped, QEMU exiting included.
+func (q *QMP) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	sockets, err := q.sockets()
+	if err != nil {
+		return nil, err
+	}
+
+	type watched struct {
+		domain *driver.Domain
+		events <-chan response
+		cancel func()
+	}
+
+	var (
+		watches []watched
+		errs    []error
+	)
+	for _, path := range sockets {
+		d, err := q.collectSocket(ctx, path, driver.CollectOptions{}, nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if d == nil {
+			continue
+		}
+		m, err := q.monitor(ctx, path)
+		if err != nil {
+			errs = append(errs, connError(err))
+			continue
+		}
+		events, cancel := m.subscribe()
+		watches = append(watches, watched{d, events, cancel})
+	}
+	if len(watches) == 0 {
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		return nil, fmt.Errorf("qmp: no monitor sockets: %w", driver.ErrHypervisorUnavailable)
+	}
+
+	out := make(chan driver.DomainEvent)
+	var wg sync.WaitGroup
+	for _, w := range watches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer w.cancel()
+
+			for {
+				select {
+				case resp, ok := <-w.events:
+					if !ok {
+						return
+					}
+					flag, ok := eventFlag(resp.Event)
+					if !ok {
+						continue
+					}
+					ev := driver.DomainEvent{
+						ID:         w.domain.ID,
+						UUID:       w.domain.UUID,
+						Name:       w.domain.Name,
+						Hypervisor: Hypervisor,
+						Flags:      flag,
+						Time:       driver.TimestampOf(time.Unix(resp.Timestamp.Seconds, resp.Timestamp.Microseconds*1e3)),
+					}
+					select {
+					case out <- ev:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
+
+// eventFlag Domain state entered with a QMP event, events not changing the
+// run state are skipped
+func eventFlag(event string) (driver.DomainFlag, bool) {
+	switch event {
+	case "RESUME", "WAKEUP":
+		return driver.DomainOnline, true
+	case "STOP", "SUSPEND", "SUSPEND_DISK":
+		return driver.DomainPaused, true
+	case "POWERDOWN":
+		return driver.DomainDying, true
+	case "SHUTDOWN":
+		return driver.DomainShutdown, true
+	case "GUEST_PANICKED":
+		return driver.DomainCrashed, true
+	}
+	return 0, false
+}
@@ -0,0 +1,215 @@
+package qmp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// eventBuffer Events buffered per subscriber, further events are dropped
+// until the subscriber catches up
+const eventBuffer = 16
+
+// monitor A negotiated connection to a single QMP socket. A read loop
+// hands replies to the command in flight and events to subscribers.
+type monitor struct {
+	mu      sync.Mutex // serializes commands
+	conn    net.Conn
+	enc     *json.Encoder
+	replies chan response
+	done    chan struct{}
+	err     error // read error ending the loop, set before done is closed
+
+	subMu sync.Mutex
+	subs  map[chan response]struct{}
+}
+
+// request A QMP command
+type request struct {
+	Execute   string      `json:"execute"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+// response A QMP reply, asynchronous events have Event set
+type response struct {
+	Return json.RawMessage `json:"return"`
+	Error  *struct {
+		Class string `json:"class"`
+		Desc  string `json:"desc"`
+	} `json:"error"`
+	Event     string `json:"event"`
+	Timestamp struct {
+		Seconds      int64 `json:"seconds"`
+		Microseconds int64 `json:"microseconds"`
+	} `json:"timestamp"`
+}
+
+// dial Connect to a QMP socket and leave capabilities negotiation mode
+func dial(ctx context.Context, path string) (*monitor, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &monitor{
+		conn:    conn,
+		enc:     json.NewEncoder(conn),
+		replies: make(chan response, 1),
+		done:    make(chan struct{}),
+		subs:    make(map[chan response]struct{}),
+	}
+
+	dec := json.NewDecoder(conn)
+	if err := m.greeting(ctx, dec); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go m.read(dec)
+
+	if err := m.execute(ctx, "qmp_capabilities", nil, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// greeting Read the server greeting, before the read loop is started
+func (m *monitor) greeting(ctx context.Context, dec *json.Decoder) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		m.conn.SetReadDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		m.conn.SetReadDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+	defer m.conn.SetReadDeadline(time.Time{})
+
+	var greeting struct {
+		QMP *json.RawMessage `json:"QMP"`
+	}
+	if err := dec.Decode(&greeting); err != nil {
+		return ctxErr(ctx, err)
+	}
+	if greeting.QMP == nil {
+		return fmt.Errorf("qmp: unexpected greeting")
+	}
+	return nil
+}
+
+// read Dispatch messages until the connection fails. A reply nobody waits
+// for, left by a command aborted through its context, is discarded.
+func (m *monitor) read(dec *json.Decoder) {
+	defer func() {
+		m.subMu.Lock()
+		for sub := range m.subs {
+			close(sub)
+			delete(m.subs, sub)
+		}
+		m.subMu.Unlock()
+	}()
+	defer close(m.done)
+
+	for {
+		var resp response
+		if err := dec.Decode(&resp); err != nil {
+			m.err = err
+			return
+		}
+
+		if resp.Event != "" {
+			m.publish(resp)
+			continue
+		}
+		select {
+		case m.replies <- resp:
+		default:
+		}
+	}
+}
+
+// publish Hand an event to every subscriber with room for it
+func (m *monitor) publish(ev response) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	for sub := range m.subs {
+		select {
+		case sub <- ev:
+		default:
+		}
+	}
+}
+
+// subscribe Receive events until cancel is called or the connection fails,
+// either closes the channel
+func (m *monitor) subscribe() (events <-chan response, cancel func()) {
+	sub := make(chan response, eventBuffer)
+
+	m.subMu.Lock()
+	select {
+	case <-m.done:
+		close(sub)
+	default:
+		m.subs[sub] = struct{}{}
+	}
+	m.subMu.Unlock()
+
+	return sub, func() {
+		m.subMu.Lock()
+		defer m.subMu.Unlock()
+		if _, ok := m.subs[sub]; ok {
+			close(sub)
+			delete(m.subs, sub)
+		}
+	}
+}
+
+// execute Run a command and decode its return value into out. A command
+// aborted through ctx leaves its reply in flight, the caller must drop the
+// monitor.
+func (m *monitor) execute(ctx context.Context, cmd string, args, out interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		m.conn.SetWriteDeadline(deadline)
+	} else {
+		m.conn.SetWriteDeadline(time.Time{})
+	}
+	if err := m.enc.Encode(request{Execute: cmd, Arguments: args}); err != nil {
+		return ctxErr(ctx, err)
+	}
+
+	var resp response
+	select {
+	case resp = <-m.replies:
+	case <-m.done:
+		return m.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if resp.Error != nil {
+		return &commandError{Command: cmd, Class: resp.Error.Class, Desc: resp.Error.Desc}
+	}
+	if out == nil || len(resp.Return) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Return, out)
+}
+
+// ctxErr Prefer the context error over the resulting I/O error
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (m *monitor) close() error {
+	return m.conn.Close()
+}
@@ -0,0 +1,447 @@
+// Package qmp Driver collecting domains directly from QEMU monitor (QMP) sockets.
+//
+// Every socket matching the configured pattern in the configured directory is
+// treated as one domain. Connections are negotiated once and kept open
+// across collections. The private data of collected domains (see
+// driver.Private) is the path of their monitor socket, a string. Importing
+// the package registers the driver under the name "qmp" using
+// DefaultDirectory and DefaultPattern; driver.NewDriver takes the directory,
+// pattern and timeout keys, the fields of Config.
+package qmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/internal/hvutil"
+)
+
+const (
+	// Hypervisor Hypervisor name reported by the QMP driver
+	Hypervisor driver.DomainHypervisor = "qmp"
+	// DefaultDirectory Default directory scanned for monitor sockets
+	DefaultDirectory = "/var/run/qemu-server"
+	// DefaultPattern Default glob matching monitor sockets in the directory
+	DefaultPattern = "*.qmp"
+)
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New(DefaultDirectory, DefaultPattern)); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		Keys: []string{"directory", "pattern", "timeout"},
+		New:  fromConfig,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// fromConfig Create a QMP driver from the directory, pattern and timeout
+// keys of a driver.Config
+func fromConfig(cfg driver.Config) (driver.Driver, error) {
+	timeout, err := cfg.Duration("timeout", 0)
+	if err != nil {
+		return nil, err
+	}
+	q, err := NewWithConfig(Config{Directory: cfg["directory"], Pattern: cfg["pattern"], Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", driver.ErrInvalidConfig, err)
+	}
+	return q, nil
+}
+
+// Config Settings of a QMP driver
+type Config struct {
+	// Directory Directory scanned for monitor sockets, DefaultDirectory if
+	// empty
+	Directory string
+	// Pattern Glob matching monitor sockets in Directory, DefaultPattern if
+	// empty. A plain file name watches a single socket.
+	Pattern string
+	// Timeout Time allowed to connect to a monitor and negotiate
+	// capabilities, only bounded by the collection context if zero
+	Timeout time.Duration
+}
+
+// QMP QMP driver
+type QMP struct {
+	dir     string
+	pattern string
+	timeout time.Duration
+
+	mu       sync.Mutex
+	monitors map[string]*monitor
+}
+
+// New Create a QMP driver scanning dir for sockets matching the glob pattern
+func New(dir, pattern string) *QMP {
+	return &QMP{
+		dir:      dir,
+		pattern:  pattern,
+		monitors: make(map[string]*monitor),
+	}
+}
+
+// NewWithConfig Create a QMP driver as cfg says, a malformed pattern is an
+// error
+func NewWithConfig(cfg Config) (*QMP, error) {
+	if cfg.Directory == "" {
+		cfg.Directory = DefaultDirectory
+	}
+	if cfg.Pattern == "" {
+		cfg.Pattern = DefaultPattern
+	}
+	if _, err := filepath.Match(cfg.Pattern, ""); err != nil {
+		return nil, fmt.Errorf("qmp: pattern %q: %w", cfg.Pattern, err)
+	}
+
+	q := New(cfg.Directory, cfg.Pattern)
+	q.timeout = cfg.Timeout
+	return q, nil
+}
+
+// Name Hypervisor name
+func (q *QMP) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Priority Rank below libvirt, which manages the same QEMU processes
+func (q *QMP) Priority() int {
+	return 50
+}
+
+// Capabilities Supported metrics. QMP has no interface counters, interfaces
+// only carry their name and MAC, and memory is limited to the balloon size.
+func (q *QMP) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:          true,
+		SupportsBlocks:        true,
+		SupportsMemory:        true,
+		SupportsBlockCapacity: true,
+		SupportsBackingChain:  true,
+		SupportsPinning:       true,
+		SupportsLimits:        true,
+		SupportsEvents:        true,
+		SupportsVersions:      true,
+	}
+}
+
+// Detect Test if any monitor sockets are present
+func (q *QMP) Detect() bool {
+	return q.Diagnose().Detected
+}
+
+// Diagnose Report the monitor sockets found. Sockets aren't connected to, a
+// stale one left by a crashed QEMU still counts.
+func (q *QMP) Diagnose() driver.DetectResult {
+	where := filepath.Join(q.dir, q.pattern)
+	sockets, err := q.sockets()
+	switch {
+	case err != nil:
+		return driver.DetectResult{Reason: "listing " + where + " failed: " + err.Error(), Err: err}
+	case len(sockets) == 0:
+		return driver.DetectResult{
+			Reason: "no socket found matching " + where,
+			Err:    fmt.Errorf("qmp: no monitor sockets: %w", driver.ErrHypervisorUnavailable),
+		}
+	}
+	return driver.DetectResult{Detected: true, Reason: fmt.Sprintf("%d monitor sockets", len(sockets))}
+}
+
+// Collect Collect domains
+func (q *QMP) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return q.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect domains from every socket concurrently
+func (q *QMP) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	sockets, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) { return q.sockets() })
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.CollectDomains(ctx, opts, sockets, q.collector(opts, nil))
+}
+
+// CollectInto Collect domains as CollectContext does into dst, reusing its
+// domains, see driver.CollectInto
+func (q *QMP) CollectInto(ctx context.Context, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	sockets, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) { return q.sockets() })
+	if err != nil {
+		clear(dst)
+		return err
+	}
+
+	return driver.CollectDomainsInto(ctx, opts, sockets, dst, func(ctx context.Context, path string, r *driver.Recycler) (*driver.Domain, error) {
+		return q.collector(opts, r)(ctx, path)
+	})
+}
+
+// CollectStream Collect domains as CollectContext does, emitting each as
+// soon as it's collected
+func (q *QMP) CollectStream(ctx context.Context, opts driver.CollectOptions, emit func(*driver.Domain) error) error {
+	sockets, err := driver.Enumerate(ctx, opts, func(context.Context) ([]string, error) { return q.sockets() })
+	if err != nil {
+		return err
+	}
+
+	return driver.StreamDomains(ctx, opts, sockets, q.collector(opts, nil), emit)
+}
+
+// collector Collect function of the sockets listed by CollectContext,
+// CollectInto and CollectStream, filling the domains of r
+func (q *QMP) collector(opts driver.CollectOptions, r *driver.Recycler) func(context.Context, string) (*driver.Domain, error) {
+	return func(ctx context.Context, path string) (*driver.Domain, error) {
+		d, err := q.collectSocket(ctx, path, opts, r)
+		if err != nil {
+			return nil, &driver.DomainError{ID: socketID(path), Name: socketName(path), Err: err}
+		}
+		return d, nil
+	}
+}
+
+// CollectDomain Collect a single domain by ID. IDs derive from the socket
+// names, sockets are matched without connecting.
+func (q *QMP) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := q.find(context.Background(), opts,
+		func(path string) bool { return socketID(path) == id },
+		func(d *driver.Domain) bool { return d.ID == id },
+	)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, fmt.Errorf("qmp: domain %d: %w", id, driver.ErrDomainNotFound)
+	}
+	return d, nil
+}
+
+// CollectDomainByUUID Collect a single domain by UUID, scanning every socket
+func (q *QMP) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	uuid, err := driver.NormalizeUUID(uuid)
+	if err != nil {
+		return nil, fmt.Errorf("qmp: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+
+	d, err := q.find(context.Background(), opts, nil,
+		func(d *driver.Domain) bool { return strings.EqualFold(d.UUID, uuid) },
+	)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, fmt.Errorf("qmp: domain %s: %w", uuid, driver.ErrDomainNotFound)
+	}
+	return d, nil
+}
+
+// CollectDomainByName Collect a single domain by name, scanning every socket
+func (q *QMP) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := q.find(context.Background(), opts, nil,
+		func(d *driver.Domain) bool { return d.Name == name },
+	)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, fmt.Errorf("qmp: domain %q: %w", name, driver.ErrDomainNotFound)
+	}
+	return d, nil
+}
+
+// find Collect the first domain accepted by match, or nil if none is.
+// Sockets rejected by name through pre aren't connected to, the rest are
+// queried for their identity before the matching one is collected in full.
+func (q *QMP) find(ctx context.Context, opts driver.CollectOptions, pre func(path string) bool, match func(*driver.Domain) bool) (*driver.Domain, error) {
+	opts = opts.Unfiltered()
+	sockets, err := q.sockets()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range sockets {
+		if pre != nil && !pre(path) {
+			continue
+		}
+
+		d, err := q.collectSocket(ctx, path, driver.CollectOptions{}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if d != nil && match(d) {
+			return q.collectSocket(ctx, path, opts, nil)
+		}
+	}
+	return nil, nil
+}
+
+// CollectSnapshots QMP only exposes internal snapshots per disk image, not
+// domain snapshots
+func (q *QMP) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	return nil, fmt.Errorf("qmp: snapshots: %w", driver.ErrNotSupported)
+}
+
+// Host Metrics of the local host, where the monitor sockets live
+func (q *QMP) Host() (*driver.HostInfo, error) {
+	return driver.LocalHostInfo()
+}
+
+// Ping Query the version of every QEMU behind a monitor socket, joining the
+// failures. Stale sockets are skipped as in collections.
+func (q *QMP) Ping(ctx context.Context) error {
+	sockets, err := q.sockets()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, path := range sockets {
+		m, err := q.monitor(ctx, path)
+		if err != nil {
+			if hvutil.Stale(err) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, connError(err))
+			continue
+		}
+
+		if err := m.execute(ctx, "query-version", nil, nil); err != nil {
+			// A failed command leaves the connection usable, anything else doesn't
+			var cerr *commandError
+			if !errors.As(err, &cerr) {
+				q.drop(path, m)
+				err = connError(err)
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close Close all monitor connections, joining the failures
+func (q *QMP) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var errs []error
+	for path, m := range q.monitors {
+		if err := m.close(); err != nil {
+			errs = append(errs, fmt.Errorf("qmp: close %s: %w", path, err))
+		}
+		delete(q.monitors, path)
+	}
+	return errors.Join(errs...)
+}
+
+// sockets List the monitor sockets currently present
+func (q *QMP) sockets() ([]string, error) {
+	return filepath.Glob(filepath.Join(q.dir, q.pattern))
+}
+
+// monitor Get the open monitor for path, connecting if needed
+func (q *QMP) monitor(ctx context.Context, path string) (*monitor, error) {
+	q.mu.Lock()
+	m, ok := q.monitors[path]
+	q.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+
+	dialCtx := ctx
+	if q.timeout > 0 {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithTimeout(ctx, q.timeout)
+		defer cancel()
+	}
+	m, err := dial(dialCtx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if existing, ok := q.monitors[path]; ok {
+		m.close()
+		return existing, nil
+	}
+	q.monitors[path] = m
+	return m, nil
+}
+
+// drop Close and forget the monitor for path so the next collection reconnects
+func (q *QMP) drop(path string, m *monitor) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.monitors[path] == m {
+		delete(q.monitors, path)
+	}
+	m.close()
+}
+
+// collectSocket Collect the domain behind a socket into a domain of r, a
+// nil domain means the socket is stale (its VM has exited) or the domain is
+// filtered out
+func (q *QMP) collectSocket(ctx context.Context, path string, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	m, err := q.monitor(ctx, path)
+	if err != nil {
+		if hvutil.Stale(err) {
+			driver.GetLogger().Debug("skipping stale monitor socket", "driver", Hypervisor, "socket", path, "error", err)
+			return nil, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, connError(err)
+	}
+
+	d, err := collectDomain(ctx, m, path, opts, r)
+	if err != nil {
+		// A failed command leaves the connection usable, anything else doesn't
+		var cerr *commandError
+		if errors.As(err, &cerr) {
+			return nil, err
+		}
+		q.drop(path, m)
+		driver.GetLogger().Debug("dropped monitor connection", "driver", Hypervisor, "socket", path, "error", err)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, connError(err)
+	}
+	return d, nil
+}
+
+// socketID Domain ID from a numeric socket name (e.g. 101.qmp), otherwise a
+// stable hash of the socket name. Derived from the path alone, sockets
+// failing to answer are reported under the ID they collect with.
+func socketID(path string) driver.DomainID {
+	if id, ok := numericID(path); ok {
+		return id
+	}
+	return driver.HashDomainID(socketName(path))
+}
+
+// numericID Domain ID from a numeric socket name
+func numericID(path string) (driver.DomainID, bool) {
+	id, err := driver.ParseDomainID(socketName(path))
+	return id, err == nil
+}
+
+// socketName Socket file name without its extension
+func socketName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
@@ -0,0 +1,136 @@
+package driver
+
+import (
+	"math"
+	"time"
+)
+
+// BlockRate Per second block IO rates between two samples
+type BlockRate struct {
+	Operations float64
+	Bytes      float64
+	Sectors    float64
+	Errors     float64
+	// Reset A counter went backwards, deltas were taken from zero
+	Reset bool
+}
+
+// NetworkRate Per second network IO rates between two samples
+type NetworkRate struct {
+	Bytes   float64
+	Packets float64
+	Errors  float64
+	Drops   float64
+	// FIFO, Frame, Collisions and Carrier Rates of the error breakdown, see
+	// NetworkIO
+	FIFO       float64
+	Frame      float64
+	Collisions float64
+	Carrier    float64
+	// Reset A counter went backwards, deltas were taken from zero
+	Reset bool
+}
+
+// counterDelta Difference between two samples of a monotonic counter.
+// A counter that went backwards from the upper half of the uint64 range is
+// assumed to have wrapped, anything else is treated as a reset (guest restart)
+// and the delta is the current value.
+func counterDelta(cur, prev uint64) (delta uint64, reset bool) {
+	if cur >= prev {
+		return cur - prev, false
+	}
+	if prev > math.MaxUint64/2 {
+		return cur - prev, false
+	}
+	return cur, true
+}
+
+// perSecond Scale a delta to a per second rate
+func perSecond(delta uint64, interval time.Duration) float64 {
+	if interval <= 0 {
+		return 0
+	}
+	return float64(delta) / interval.Seconds()
+}
+
+// Rate Per second rates from prev to cur over interval.
+// When cur is not Absolute its values are already deltas and prev is ignored.
+func (cur BlockIO) Rate(prev BlockIO, interval time.Duration) (rate BlockRate) {
+	ops, bytes, sectors, errs := cur.Operations, cur.Bytes, cur.Sectors, cur.Errors
+
+	if cur.Absolute {
+		var r1, r2, r3, r4 bool
+		ops, r1 = counterDelta(cur.Operations, prev.Operations)
+		bytes, r2 = counterDelta(cur.Bytes, prev.Bytes)
+		sectors, r3 = counterDelta(cur.Sectors, prev.Sectors)
+		errs, r4 = counterDelta(cur.Errors, prev.Errors)
+		rate.Reset = r1 || r2 || r3 || r4
+	}
+
+	rate.Operations = perSecond(ops, interval)
+	rate.Bytes = perSecond(bytes, interval)
+	rate.Sectors = perSecond(sectors, interval)
+	rate.Errors = perSecond(errs, interval)
+	return
+}
+
+// timeDeltas Operations and time spent between the samples, with the same
+// handling of non Absolute samples as Rate
+func (cur BlockIO) timeDeltas(prev BlockIO) (ops, spent uint64, ok bool) {
+	if !cur.TotalTimeSet {
+		return 0, 0, false
+	}
+	if !cur.Absolute {
+		return cur.Operations, cur.TotalTime, true
+	}
+	if !prev.TotalTimeSet {
+		return 0, 0, false
+	}
+	ops, _ = counterDelta(cur.Operations, prev.Operations)
+	spent, _ = counterDelta(cur.TotalTime, prev.TotalTime)
+	return ops, spent, true
+}
+
+// Latency Average time an operation took from prev to cur, false without
+// timing or when no operation completed
+func (cur BlockIO) Latency(prev BlockIO) (time.Duration, bool) {
+	ops, spent, ok := cur.timeDeltas(prev)
+	if !ok || ops == 0 {
+		return 0, false
+	}
+	return time.Duration(spent / ops), true
+}
+
+// QueueDepth Average number of operations in flight over interval, the time
+// spent on operations divided by the interval (Little's law). False without
+// timing.
+func (cur BlockIO) QueueDepth(prev BlockIO, interval time.Duration) (float64, bool) {
+	_, spent, ok := cur.timeDeltas(prev)
+	if !ok || interval <= 0 {
+		return 0, false
+	}
+	return float64(spent) / float64(interval), true
+}
+
+// Rate Per second rates from prev to cur over interval
+func (cur NetworkIO) Rate(prev NetworkIO, interval time.Duration) (rate NetworkRate) {
+	bytes, r1 := counterDelta(cur.Bytes, prev.Bytes)
+	packets, r2 := counterDelta(cur.Packets, prev.Packets)
+	errors, r3 := counterDelta(cur.Errors, prev.Errors)
+	drops, r4 := counterDelta(cur.Drops, prev.Drops)
+	fifo, r5 := counterDelta(cur.FIFO, prev.FIFO)
+	frame, r6 := counterDelta(cur.Frame, prev.Frame)
+	collisions, r7 := counterDelta(cur.Collisions, prev.Collisions)
+	carrier, r8 := counterDelta(cur.Carrier, prev.Carrier)
+
+	rate.Bytes = perSecond(bytes, interval)
+	rate.Packets = perSecond(packets, interval)
+	rate.Errors = perSecond(errors, interval)
+	rate.Drops = perSecond(drops, interval)
+	rate.FIFO = perSecond(fifo, interval)
+	rate.Frame = perSecond(frame, interval)
+	rate.Collisions = perSecond(collisions, interval)
+	rate.Carrier = perSecond(carrier, interval)
+	rate.Reset = r1 || r2 || r3 || r4 || r5 || r6 || r7 || r8
+	return
+}
@@ -0,0 +1,63 @@
+package driver
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		name      string
+		cur, prev uint64
+		delta     uint64
+		reset     bool
+	}{
+		{"unchanged", 100, 100, 0, false},
+		{"increase", 1500, 1000, 500, false},
+		{"at MaxUint64", math.MaxUint64, math.MaxUint64 - 10, 10, false},
+		{"wrap to zero", 0, math.MaxUint64, 1, false},
+		{"wrap", 5, math.MaxUint64 - 9, 15, false},
+		{"wrap from upper half", 100, math.MaxUint64/2 + 1, math.MaxUint64/2 + 101, false},
+		{"reset", 10, 1000, 10, true},
+		{"reset to zero", 0, 1000, 0, true},
+		{"reset below upper half", 7, math.MaxUint64 / 2, 7, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, reset := counterDelta(tt.cur, tt.prev)
+			if delta != tt.delta || reset != tt.reset {
+				t.Errorf("counterDelta(%d, %d) = %d, %v, want %d, %v", tt.cur, tt.prev, delta, reset, tt.delta, tt.reset)
+			}
+		})
+	}
+}
+
+func TestNetworkIORate(t *testing.T) {
+	prev := NetworkIO{Bytes: math.MaxUint64 - 999, Packets: 5000}
+	cur := NetworkIO{Bytes: 1000, Packets: 5100}
+	if rate := cur.Rate(prev, 2*time.Second); rate.Bytes != 1000 || rate.Packets != 50 || rate.Reset {
+		t.Errorf("wrapped bytes: got %+v, want 1000 B/s, 50 packets/s and no reset", rate)
+	}
+
+	// Guest restarted, counters taken from zero
+	prev = NetworkIO{Bytes: 1 << 30, Packets: 1 << 20}
+	cur = NetworkIO{Bytes: 4000, Packets: 40}
+	if rate := cur.Rate(prev, 2*time.Second); rate.Bytes != 2000 || rate.Packets != 20 || !rate.Reset {
+		t.Errorf("reset: got %+v, want 2000 B/s, 20 packets/s and a reset", rate)
+	}
+}
+
+func TestBlockIORate(t *testing.T) {
+	prev := BlockIO{Operations: 100, Bytes: math.MaxUint64 - 4095, Absolute: true}
+	cur := BlockIO{Operations: 200, Bytes: 4096, Absolute: true}
+	if rate := cur.Rate(prev, time.Second); rate.Operations != 100 || rate.Bytes != 8192 || rate.Reset {
+		t.Errorf("wrapped bytes: got %+v, want 100 ops/s, 8192 B/s and no reset", rate)
+	}
+
+	prev = BlockIO{Operations: 1e6, Bytes: 1 << 32, Absolute: true}
+	cur = BlockIO{Operations: 10, Bytes: 40960, Absolute: true}
+	if rate := cur.Rate(prev, time.Second); rate.Operations != 10 || rate.Bytes != 40960 || !rate.Reset {
+		t.Errorf("reset: got %+v, want 10 ops/s, 40960 B/s and a reset", rate)
+	}
+}
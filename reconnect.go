@@ -0,0 +1,273 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// errReconnectClosed Returned by a reconnecting driver after Close
+var errReconnectClosed = errors.New("driver: reconnect: driver closed")
+
+// BackoffPolicy Delays between attempts to recreate a driver. Zero fields
+// take their value from DefaultBackoffPolicy.
+type BackoffPolicy struct {
+	// Initial Delay before the first retry
+	Initial time.Duration
+	// Max Upper bound of the delay between retries
+	Max time.Duration
+	// Multiplier Growth of the delay after every retry
+	Multiplier float64
+	// MaxRetries Retries before a call gives up and returns the last error
+	MaxRetries int
+}
+
+// DefaultBackoffPolicy Policy filling in the zero fields of a BackoffPolicy
+var DefaultBackoffPolicy = BackoffPolicy{
+	Initial:    500 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	MaxRetries: 5,
+}
+
+func (p BackoffPolicy) withDefaults() BackoffPolicy {
+	if p.Initial <= 0 {
+		p.Initial = DefaultBackoffPolicy.Initial
+	}
+	if p.Max <= 0 {
+		p.Max = DefaultBackoffPolicy.Max
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultBackoffPolicy.Multiplier
+	}
+	if p.MaxRetries <= 0 {
+		p.MaxRetries = DefaultBackoffPolicy.MaxRetries
+	}
+	return p
+}
+
+// Delay Delay before retry number attempt, counting from 0
+func (p BackoffPolicy) Delay(attempt int) time.Duration {
+	p = p.withDefaults()
+	d := float64(p.Initial)
+	for i := 0; i < attempt && d < float64(p.Max); i++ {
+		d *= p.Multiplier
+	}
+	if d > float64(p.Max) {
+		return p.Max
+	}
+	return time.Duration(d)
+}
+
+// reconnectDriver Driver recreated from its factory whenever a call fails
+// with ErrHypervisorUnavailable
+type reconnectDriver struct {
+	factory func() (Driver, error)
+	policy  BackoffPolicy
+
+	mu     sync.Mutex
+	d      Driver
+	gen    uint64
+	name   DomainHypervisor
+	closed bool
+}
+
+// WithReconnect Driver created by factory and transparently recreated when
+// a call fails with an error wrapping ErrHypervisorUnavailable, factory
+// errors wrapping it included. Calls are retried with exponential backoff
+// following policy and return the last error once it is exhausted, other
+// errors are returned at once. Safe for concurrent use, concurrent failures
+// recreate the driver only once.
+func WithReconnect(factory func() (Driver, error), policy BackoffPolicy) Driver {
+	r := &reconnectDriver{factory: factory, policy: policy.withDefaults()}
+	// Failing here is fine, the first call retries
+	r.current()
+	return r
+}
+
+// current Underlying driver and its generation, created if there is none
+func (r *reconnectDriver) current() (Driver, uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, 0, errReconnectClosed
+	}
+	if r.d == nil {
+		d, err := r.factory()
+		if err != nil {
+			return nil, 0, err
+		}
+		r.d, r.name = d, d.Name()
+		r.gen++
+	}
+	return r.d, r.gen, nil
+}
+
+// drop Discard the driver of generation gen, unless another call already
+// replaced it
+func (r *reconnectDriver) drop(gen uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.d == nil || r.gen != gen {
+		return
+	}
+	if err := r.d.Close(); err != nil {
+		GetLogger().Debug("closing stale driver failed", "driver", r.name, "error", err)
+	}
+	r.d = nil
+}
+
+// do Run fn against the current driver, recreating it and retrying while it
+// fails with ErrHypervisorUnavailable. Partial results, failing with
+// DomainErrors only, are returned as they are even when some wrap it.
+func (r *reconnectDriver) do(ctx context.Context, fn func(Driver) error) error {
+	for attempt := 0; ; attempt++ {
+		d, gen, err := r.current()
+		if err == nil {
+			if err = fn(d); unavailable(err) {
+				r.drop(gen)
+			}
+		}
+		if !unavailable(err) {
+			return err
+		}
+		if attempt >= r.policy.MaxRetries {
+			return fmt.Errorf("driver: reconnect: giving up after %d retries: %w", attempt, err)
+		}
+
+		delay := r.policy.Delay(attempt)
+		GetLogger().Debug("hypervisor unavailable, reconnecting", "driver", r.name, "attempt", attempt+1, "delay", delay, "error", err)
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// unavailable Test if err means the hypervisor is unreachable as a whole,
+// rather than for some domains of a partial result
+func unavailable(err error) bool {
+	return err != nil && !onlyDomainErrors(err) && errors.Is(err, ErrHypervisorUnavailable)
+}
+
+// Name Name of the underlying driver, empty until it was created once
+func (r *reconnectDriver) Name() DomainHypervisor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.name
+}
+
+// Detect Detect of the underlying driver, false if it can't be created
+func (r *reconnectDriver) Detect() bool {
+	d, _, err := r.current()
+	return err == nil && d.Detect()
+}
+
+// Capabilities Capabilities of the underlying driver, none if it can't be
+// created
+func (r *reconnectDriver) Capabilities() Capabilities {
+	d, _, err := r.current()
+	if err != nil {
+		return Capabilities{}
+	}
+	return d.Capabilities()
+}
+
+// Collect Collect domains, reconnecting as needed
+func (r *reconnectDriver) Collect(opts CollectOptions) (map[DomainID]*Domain, error) {
+	return r.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect domains, reconnecting as needed until ctx is done
+func (r *reconnectDriver) CollectContext(ctx context.Context, opts CollectOptions) (domains map[DomainID]*Domain, err error) {
+	err = r.do(ctx, func(d Driver) error {
+		domains, err = d.CollectContext(ctx, opts)
+		return err
+	})
+	return domains, err
+}
+
+// CollectDomain Collect a single domain by ID, reconnecting as needed
+func (r *reconnectDriver) CollectDomain(id DomainID, opts CollectOptions) (dom *Domain, err error) {
+	err = r.do(context.Background(), func(d Driver) error {
+		dom, err = d.CollectDomain(id, opts)
+		return err
+	})
+	return dom, err
+}
+
+// CollectDomainByUUID Collect a single domain by UUID, reconnecting as needed
+func (r *reconnectDriver) CollectDomainByUUID(uuid string, opts CollectOptions) (dom *Domain, err error) {
+	err = r.do(context.Background(), func(d Driver) error {
+		dom, err = d.CollectDomainByUUID(uuid, opts)
+		return err
+	})
+	return dom, err
+}
+
+// CollectDomainByName Collect a single domain by name, reconnecting as needed
+func (r *reconnectDriver) CollectDomainByName(name string, opts CollectOptions) (dom *Domain, err error) {
+	err = r.do(context.Background(), func(d Driver) error {
+		dom, err = d.CollectDomainByName(name, opts)
+		return err
+	})
+	return dom, err
+}
+
+// CollectSnapshots Snapshots of a domain, reconnecting as needed
+func (r *reconnectDriver) CollectSnapshots(id DomainID) (snaps []Snapshot, err error) {
+	err = r.do(context.Background(), func(d Driver) error {
+		snaps, err = d.CollectSnapshots(id)
+		return err
+	})
+	return snaps, err
+}
+
+// Host Host metrics, reconnecting as needed
+func (r *reconnectDriver) Host() (host *HostInfo, err error) {
+	err = r.do(context.Background(), func(d Driver) error {
+		host, err = d.Host()
+		return err
+	})
+	return host, err
+}
+
+// Ping Ping the underlying driver, recreating it as needed. Success means
+// the driver is usable again, not that the first connection survived.
+func (r *reconnectDriver) Ping(ctx context.Context) error {
+	return r.do(ctx, func(d Driver) error {
+		return d.Ping(ctx)
+	})
+}
+
+// Watch Watch the underlying driver, only the initial subscription is
+// retried. The channel is closed when the connection drops, as with any
+// driver, watch again to resubscribe.
+func (r *reconnectDriver) Watch(ctx context.Context) (events <-chan DomainEvent, err error) {
+	err = r.do(ctx, func(d Driver) error {
+		events, err = d.Watch(ctx)
+		return err
+	})
+	return events, err
+}
+
+// Close Close the underlying driver, later calls fail
+func (r *reconnectDriver) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	if r.d == nil {
+		return nil
+	}
+	err := r.d.Close()
+	r.d = nil
+	return err
+}
@@ -0,0 +1,57 @@
+package driver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/mock"
+)
+
+func TestReconnectPartialResult(t *testing.T) {
+	// A guest socket failing as qmp reports it, the others collected
+	failed := &driver.DomainError{ID: 2, Name: "vm-2", Err: fmt.Errorf("qmp: %w: connection refused", driver.ErrHypervisorUnavailable)}
+	m := mock.New().Queue(
+		mock.Result{Domains: []*driver.Domain{{ID: 1, Name: "vm-1"}}, Err: failed},
+		mock.Result{Domains: []*driver.Domain{{ID: 1, Name: "retried"}}},
+	)
+	created := 0
+	r := driver.WithReconnect(func() (driver.Driver, error) {
+		created++
+		return m, nil
+	}, driver.BackoffPolicy{Initial: time.Millisecond})
+
+	domains, err := r.CollectContext(context.Background(), driver.CollectOptions{})
+	if len(domains) != 1 || domains[1].Name != "vm-1" || !errors.Is(err, failed) {
+		t.Errorf("got %v, %v, want the partial result", domains, err)
+	}
+	if created != 1 || m.Closed() {
+		t.Errorf("driver created %d times, closed %v: dropped on a partial result", created, m.Closed())
+	}
+	if m.Calls() != 1 {
+		t.Errorf("collected %d times, want no retry", m.Calls())
+	}
+}
+
+func TestReconnectUnavailable(t *testing.T) {
+	var drivers []*mock.Mock
+	r := driver.WithReconnect(func() (driver.Driver, error) {
+		m := mock.New().WithDomains(&driver.Domain{ID: 1})
+		if len(drivers) == 0 {
+			m.WithCollectError(fmt.Errorf("mock: %w", driver.ErrHypervisorUnavailable))
+		}
+		drivers = append(drivers, m)
+		return m, nil
+	}, driver.BackoffPolicy{Initial: time.Millisecond})
+
+	domains, err := r.CollectContext(context.Background(), driver.CollectOptions{})
+	if err != nil || len(domains) != 1 {
+		t.Fatalf("got %v, %v, want the domains of the recreated driver", domains, err)
+	}
+	if len(drivers) != 2 || !drivers[0].Closed() {
+		t.Errorf("created %d drivers, want the failed one closed and recreated", len(drivers))
+	}
+}
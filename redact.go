@@ -0,0 +1,122 @@
+package driver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+)
+
+// RedactMask Categories of sensitive fields to redact, see Redact
+type RedactMask uint
+
+const (
+	// RedactIdentity Name and UUID
+	RedactIdentity RedactMask = 1 << iota
+	// RedactNetwork Interface MAC and IP addresses, host devices and
+	// bridges, and graphics listen addresses
+	RedactNetwork
+	// RedactMetadata Title, description and label values
+	RedactMetadata
+	// RedactStorage Block device sources and backing chains, which may name
+	// hosts and shares, and the host paths of consoles
+	RedactStorage
+
+	// RedactAll Every category
+	RedactAll = RedactIdentity | RedactNetwork | RedactMetadata | RedactStorage
+)
+
+// Redact Copy of the domain with the fields of the categories in fields
+// zeroed, for exporting across trust boundaries. The ID is kept, it keys
+// collections, as is the original. The copy holds no driver-private data.
+// See RedactHashed to keep the redacted values correlatable.
+func Redact(d *Domain, fields RedactMask) *Domain {
+	return redact(d, fields, nil)
+}
+
+// RedactHashed Copy of the domain with the fields of the categories in
+// fields replaced by an HMAC-SHA256 keyed with salt, so that values can be
+// correlated across domains and collections without being exposed. The same
+// value and salt always hash alike. UUIDs hash to UUIDs and MAC addresses
+// to locally administered unicast addresses; IP addresses, which hash to
+// nothing meaningful, are dropped. Empty values stay empty.
+func RedactHashed(d *Domain, fields RedactMask, salt []byte) *Domain {
+	return redact(d, fields, func(s string) []byte {
+		mac := hmac.New(sha256.New, salt)
+		mac.Write([]byte(s))
+		return mac.Sum(nil)
+	})
+}
+
+// redact Redact the fields of a copy of d, hashing them with hash or zeroing
+// them when nil
+func redact(d *Domain, fields RedactMask, hash func(string) []byte) *Domain {
+	c := d.Clone()
+	if c == nil {
+		return nil
+	}
+	c.prv = nil
+
+	str := func(s string) string {
+		if s == "" || hash == nil {
+			return ""
+		}
+		return hex.EncodeToString(hash(s)[:8])
+	}
+	strs := func(s []string) []string {
+		if hash == nil {
+			return nil
+		}
+		for i := range s {
+			s[i] = str(s[i])
+		}
+		return s
+	}
+
+	if fields&RedactIdentity != 0 {
+		c.Name = str(c.Name)
+		if c.UUID != "" && hash != nil {
+			h := hash(c.UUID)
+			c.UUID = fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+		} else {
+			c.UUID = ""
+		}
+	}
+
+	if fields&RedactNetwork != 0 {
+		for i := range c.Interfaces {
+			n := &c.Interfaces[i]
+			if len(n.Mac) > 0 && hash != nil {
+				h := hash(n.Mac.String())
+				// Locally administered, unicast
+				n.Mac = net.HardwareAddr{h[0]&^0x01 | 0x02, h[1], h[2], h[3], h[4], h[5]}
+			} else {
+				n.Mac = nil
+			}
+			n.Addresses = nil
+			n.HostDevice = str(n.HostDevice)
+			n.Bridges = strs(n.Bridges)
+		}
+		for i := range c.Graphics {
+			c.Graphics[i].Listen = nil
+		}
+	}
+
+	if fields&RedactMetadata != 0 {
+		c.Title = str(c.Title)
+		c.Description = str(c.Description)
+		for k, v := range c.Labels {
+			c.Labels[k] = str(v)
+		}
+	}
+
+	if fields&RedactStorage != 0 {
+		for i := range c.Blocks {
+			c.Blocks[i].Source = str(c.Blocks[i].Source)
+			c.Blocks[i].BackingChain = strs(c.Blocks[i].BackingChain)
+		}
+		c.Consoles = strs(c.Consoles)
+	}
+	return c
+}
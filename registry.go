@@ -0,0 +1,114 @@
+package driver
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	driversMu sync.RWMutex
+	// drivers Registered drivers, only accessed through the functions below
+	drivers = make(map[string]Driver)
+	// factories Registered driver factories, keyed like drivers
+	factories = make(map[string]Factory)
+)
+
+// PluginSymbol Name of the function a plugin opened by LoadPlugin exports to
+// register its drivers, of type func() error
+const PluginSymbol = "Register"
+
+// RegisterDriver Register a driver under name, typically from the driver's init()
+//
+// Drivers outside this module register the same way: a package whose init()
+// calls RegisterDriver, and RegisterFactory when it can be configured, is
+// linked in with a blank import, behind a build tag if it should be
+// optional. The name must not collide with another driver's; registering
+// fails on duplicates rather than replacing, see ReplaceDriver. Drivers
+// built as Go plugins register from their Register function instead, see
+// LoadPlugin. Registration is safe for concurrent use. d is shared by every
+// caller of GetDriver, it must be safe for concurrent use, see Serialize.
+func RegisterDriver(name string, d Driver) error {
+	if d == nil {
+		return fmt.Errorf("driver: RegisterDriver %q: driver is nil", name)
+	}
+
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if _, dup := drivers[name]; dup {
+		return fmt.Errorf("driver: RegisterDriver %q: already registered", name)
+	}
+	drivers[name] = d
+	return nil
+}
+
+// ReplaceDriver Register a driver under name, replacing the driver already
+// registered under it if any. The factory registered under name is kept.
+func ReplaceDriver(name string, d Driver) error {
+	if d == nil {
+		return fmt.Errorf("driver: ReplaceDriver %q: driver is nil", name)
+	}
+
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	drivers[name] = d
+	return nil
+}
+
+// UnregisterDriver Remove the driver and factory registered under name, if
+// any, so that it can be registered again. The driver isn't closed.
+func UnregisterDriver(name string) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	delete(drivers, name)
+	delete(factories, name)
+}
+
+// GetDriver Lookup a registered driver by name
+func GetDriver(name string) (Driver, bool) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	d, ok := drivers[name]
+	return d, ok
+}
+
+// RegisterFactory Register the factory creating configured instances of the
+// driver registered under name, see NewDriver. Typically called from the
+// driver's init() along with RegisterDriver.
+func RegisterFactory(name string, f Factory) error {
+	if f.New == nil {
+		return fmt.Errorf("driver: RegisterFactory %q: factory is nil", name)
+	}
+
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if _, dup := factories[name]; dup {
+		return fmt.Errorf("driver: RegisterFactory %q: already registered", name)
+	}
+	factories[name] = f
+	return nil
+}
+
+// GetFactory Lookup a registered driver factory by name
+func GetFactory(name string) (Factory, bool) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	f, ok := factories[name]
+	return f, ok
+}
+
+//AvailableDrivers List of registered driver names
+func AvailableDrivers() (names []string) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	for name := range drivers {
+		names = append(names, name)
+	}
+	return
+}
@@ -0,0 +1,55 @@
+package driver_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/mock"
+)
+
+func TestRegistry(t *testing.T) {
+	const name = "registry-test"
+	first, second := mock.New(), mock.New()
+	t.Cleanup(func() { driver.UnregisterDriver(name) })
+
+	if err := driver.RegisterDriver(name, first); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(driver.AvailableDrivers(), name) {
+		t.Fatalf("AvailableDrivers() = %v, want %q registered", driver.AvailableDrivers(), name)
+	}
+	if err := driver.RegisterDriver(name, second); err == nil {
+		t.Error("registering a name twice succeeded")
+	}
+
+	if err := driver.ReplaceDriver(name, second); err != nil {
+		t.Fatal(err)
+	}
+	if d, ok := driver.GetDriver(name); !ok || d != second {
+		t.Errorf("GetDriver(%q) = %v, %v, want the replacing driver", name, d, ok)
+	}
+	if n := len(slices.DeleteFunc(driver.AvailableDrivers(), func(s string) bool { return s != name })); n != 1 {
+		t.Errorf("%q listed %d times after ReplaceDriver, want once", name, n)
+	}
+
+	driver.UnregisterDriver(name)
+	if slices.Contains(driver.AvailableDrivers(), name) {
+		t.Errorf("AvailableDrivers() = %v, want %q unregistered", driver.AvailableDrivers(), name)
+	}
+	if _, ok := driver.GetDriver(name); ok {
+		t.Errorf("GetDriver(%q) found an unregistered driver", name)
+	}
+	if first.Closed() {
+		t.Error("UnregisterDriver closed the driver")
+	}
+
+	// Unregistered names can be registered again, ReplaceDriver registers
+	// names not registered yet
+	if err := driver.ReplaceDriver(name, first); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(driver.AvailableDrivers(), name) {
+		t.Errorf("AvailableDrivers() = %v, want %q registered again", driver.AvailableDrivers(), name)
+	}
+}
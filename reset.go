@@ -0,0 +1,246 @@
+package driver
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultResetRetention Time a ResetDetector remembers a domain missing from
+// ObserveAll, so that it can tell whether its counters reset when it comes
+// back
+const DefaultResetRetention = time.Hour
+
+// Resets Counters of a domain that went backwards since its previous
+// sample. Rate code zeroes the delta of the counters flagged, or of every
+// counter when the domain restarted.
+type Resets struct {
+	// Restarted The domain restarted since the previous sample, its start
+	// time or ID changed, every counter started over
+	Restarted bool
+	// CPUs IDs of the vCPUs with a time that went backwards
+	CPUs []uint64
+	// IOThreads IDs of the IO threads with a time that went backwards
+	IOThreads []uint64
+	// EmulatorTime The emulator time went backwards
+	EmulatorTime bool
+	// Blocks Names of the block devices with a counter that went backwards
+	Blocks []string
+	// Interfaces Names of the interfaces with a counter that went backwards
+	Interfaces []string
+	// Memory A swap or page fault counter went backwards
+	Memory bool
+}
+
+// Any Test if any counter reset
+func (r Resets) Any() bool {
+	return r.Restarted || len(r.CPUs) > 0 || len(r.IOThreads) > 0 || r.EmulatorTime ||
+		len(r.Blocks) > 0 || len(r.Interfaces) > 0 || r.Memory
+}
+
+// CPU Test if the counters of a vCPU reset
+func (r Resets) CPU(id uint64) bool {
+	return r.Restarted || contains(r.CPUs, id)
+}
+
+// IOThread Test if the time of an IO thread reset
+func (r Resets) IOThread(id uint64) bool {
+	return r.Restarted || contains(r.IOThreads, id)
+}
+
+// Block Test if the counters of a block device reset
+func (r Resets) Block(name string) bool {
+	return r.Restarted || contains(r.Blocks, name)
+}
+
+// Interface Test if the counters of an interface reset
+func (r Resets) Interface(name string) bool {
+	return r.Restarted || contains(r.Interfaces, name)
+}
+
+func contains[T comparable](s []T, v T) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// resetState Previous sample of a domain and when it was last observed
+type resetState struct {
+	prev *Domain
+	seen time.Time
+}
+
+// ResetDetector Tells which counters of domains reset between successive
+// samples, when guests restart or devices are replaced. Domains are keyed by
+// UUID, their ID changes when they restart, or by hypervisor and name for
+// drivers without UUIDs. A domain missing from ObserveAll is remembered for
+// Retention, a domain coming back within it is compared to its last sample.
+// The zero value is ready to use and safe for concurrent use.
+type ResetDetector struct {
+	// Retention Time domains missing from ObserveAll are remembered,
+	// DefaultResetRetention when 0
+	Retention time.Duration
+
+	mu      sync.Mutex
+	domains map[string]*resetState
+}
+
+// Observe Record the sample d and report the counters that reset since the
+// previous sample of the domain. The first sample of a domain, and samples
+// not newer than the previous one, report none; the latter aren't recorded.
+func (r *ResetDetector) Observe(d *Domain) Resets {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.observe(d, time.Now())
+}
+
+// ObserveAll Observe every domain of a collection, returning the resets of
+// those with any. Domains not in it for longer than Retention are
+// forgotten.
+func (r *ResetDetector) ObserveAll(domains map[DomainID]*Domain) map[DomainID]Resets {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	out := make(map[DomainID]Resets)
+	for id, d := range domains {
+		if resets := r.observe(d, now); resets.Any() {
+			out[id] = resets
+		}
+	}
+
+	retention := r.Retention
+	if retention == 0 {
+		retention = DefaultResetRetention
+	}
+	for key, state := range r.domains {
+		if now.Sub(state.seen) > retention {
+			delete(r.domains, key)
+		}
+	}
+	return out
+}
+
+// Forget Drop the previous sample of a domain, its next sample reports no
+// reset
+func (r *ResetDetector) Forget(d *Domain) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.domains, resetKey(d))
+}
+
+func (r *ResetDetector) observe(d *Domain, now time.Time) Resets {
+	if r.domains == nil {
+		r.domains = make(map[string]*resetState)
+	}
+
+	key := resetKey(d)
+	state := r.domains[key]
+	if state == nil {
+		r.domains[key] = &resetState{prev: d.Clone(), seen: now}
+		return Resets{}
+	}
+	state.seen = now
+	if d.Time != 0 && d.Time <= state.prev.Time {
+		return Resets{}
+	}
+	resets := counterResets(state.prev, d)
+	state.prev = d.Clone()
+	return resets
+}
+
+// resetKey Key identifying a domain across restarts
+func resetKey(d *Domain) string {
+	if d.UUID != "" {
+		return d.UUID
+	}
+	return string(d.Hypervisor) + "/" + d.Name
+}
+
+// counterResets Compare the counters of cur to those of prev, the same
+// domain sampled earlier
+func counterResets(prev, cur *Domain) (r Resets) {
+	if prev.StartTime != 0 && cur.StartTime != 0 && prev.StartTime != cur.StartTime ||
+		prev.UUID != "" && prev.ID != cur.ID {
+		r.Restarted = true
+		return
+	}
+
+	before := make(map[uint64]CPU, len(prev.Cpus))
+	for _, c := range prev.Cpus {
+		before[c.ID] = c
+	}
+	for _, c := range cur.Cpus {
+		p, ok := before[c.ID]
+		if ok && (c.Time < p.Time || c.IdleSet && p.IdleSet && c.Idle < p.Idle ||
+			c.StealSet && p.StealSet && c.Steal < p.Steal || c.IOWaitSet && p.IOWaitSet && c.IOWait < p.IOWait) {
+			r.CPUs = append(r.CPUs, c.ID)
+		}
+	}
+
+	threads := make(map[uint64]IOThread, len(prev.IOThreads))
+	for _, t := range prev.IOThreads {
+		threads[t.ID] = t
+	}
+	for _, t := range cur.IOThreads {
+		if p, ok := threads[t.ID]; ok && t.TimeSet && p.TimeSet && t.Time < p.Time {
+			r.IOThreads = append(r.IOThreads, t.ID)
+		}
+	}
+	r.EmulatorTime = cur.EmulatorTimeSet && prev.EmulatorTimeSet && cur.EmulatorTime < prev.EmulatorTime
+
+	blocks := make(map[string]BlockDevice, len(prev.Blocks))
+	for _, b := range prev.Blocks {
+		blocks[b.Name] = b
+	}
+	for _, b := range cur.Blocks {
+		if p, ok := blocks[b.Name]; ok && (b.Read.reset(p.Read) || b.Write.reset(p.Write) || b.Flush.reset(p.Flush)) {
+			r.Blocks = append(r.Blocks, b.Name)
+		}
+	}
+
+	ifaces := make(map[string]NetworkInterface, len(prev.Interfaces))
+	for _, n := range prev.Interfaces {
+		ifaces[n.Name] = n
+	}
+	for _, n := range cur.Interfaces {
+		if p, ok := ifaces[n.Name]; ok && (n.RX.reset(p.RX) || n.TX.reset(p.TX)) {
+			r.Interfaces = append(r.Interfaces, n.Name)
+		}
+	}
+
+	m, p := &cur.Memory, &prev.Memory
+	r.Memory = m.SwapInSet && p.SwapInSet && m.SwapIn < p.SwapIn ||
+		m.SwapOutSet && p.SwapOutSet && m.SwapOut < p.SwapOut ||
+		m.MajorFaultsSet && p.MajorFaultsSet && m.MajorFaults < p.MajorFaults ||
+		m.MinorFaultsSet && p.MinorFaultsSet && m.MinorFaults < p.MinorFaults
+	return
+}
+
+// reset Test if a counter went backwards from prev, as counted by Rate.
+// Samples that aren't Absolute hold deltas and never reset.
+func (cur BlockIO) reset(prev BlockIO) bool {
+	if !cur.Absolute || !prev.Absolute {
+		return false
+	}
+	_, r1 := counterDelta(cur.Operations, prev.Operations)
+	_, r2 := counterDelta(cur.Bytes, prev.Bytes)
+	_, r3 := counterDelta(cur.Sectors, prev.Sectors)
+	_, r4 := counterDelta(cur.Errors, prev.Errors)
+	return r1 || r2 || r3 || r4
+}
+
+// reset Test if a counter went backwards from prev, as counted by Rate
+func (cur NetworkIO) reset(prev NetworkIO) bool {
+	_, r1 := counterDelta(cur.Bytes, prev.Bytes)
+	_, r2 := counterDelta(cur.Packets, prev.Packets)
+	_, r3 := counterDelta(cur.Errors, prev.Errors)
+	_, r4 := counterDelta(cur.Drops, prev.Drops)
+	_, r5 := counterDelta(cur.FIFO, prev.FIFO)
+	_, r6 := counterDelta(cur.Frame, prev.Frame)
+	_, r7 := counterDelta(cur.Collisions, prev.Collisions)
+	_, r8 := counterDelta(cur.Carrier, prev.Carrier)
+	return r1 || r2 || r3 || r4 || r5 || r6 || r7 || r8
+}
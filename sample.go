@@ -0,0 +1,98 @@
+package driver
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// SampleResult Outcome of a single collection of a sampling loop
+type SampleResult struct {
+	// Time When the collection started
+	Time    time.Time
+	Domains map[DomainID]*Domain
+	// Err Collection error, per domain errors joined as with CollectContext
+	Err error
+	// Duration Time the collection took
+	Duration time.Duration
+	// Changes State changes since the previous collection, see
+	// StateTracker. Every domain appears in the first result.
+	Changes []DomainStateChange
+}
+
+// Sampler Periodic collection from a driver
+type Sampler struct {
+	// Interval Time between the start of two collections, must be positive
+	Interval time.Duration
+	// Jitter Upper bound of a random delay added before every collection so
+	// that hosts started together don't collect in lockstep, 0 for none
+	Jitter  time.Duration
+	Options CollectOptions
+}
+
+// Sample Equivalent to Sampler{Interval: interval, Options: opts}.Run(ctx, d)
+func Sample(ctx context.Context, d Driver, opts CollectOptions, interval time.Duration) <-chan SampleResult {
+	return Sampler{Interval: interval, Options: opts}.Run(ctx, d)
+}
+
+// Run Collect from d right away then every Interval, delivering results on
+// the returned channel until ctx is done, when it is closed. Collections
+// never overlap: ticks passing while a collection runs or its result waits
+// to be received are skipped and the loop waits a full Interval after it.
+// Collections still running when ctx is done are cancelled and not
+// delivered.
+func (s Sampler) Run(ctx context.Context, d Driver) <-chan SampleResult {
+	out := make(chan SampleResult)
+	go func() {
+		defer close(out)
+
+		var states StateTracker
+		t := time.NewTicker(s.Interval)
+		defer t.Stop()
+		for {
+			if s.Jitter > 0 && !sleep(ctx, time.Duration(rand.Int63n(int64(s.Jitter)))) {
+				return
+			}
+
+			start := time.Now()
+			domains, err := d.CollectContext(ctx, s.Options)
+			if ctx.Err() != nil {
+				return
+			}
+			res := SampleResult{Time: start, Domains: domains, Err: err, Duration: time.Since(start)}
+			res.Changes = states.Observe(domains, err, start)
+			select {
+			case out <- res:
+			case <-ctx.Done():
+				return
+			}
+
+			if elapsed := time.Since(start); elapsed >= s.Interval {
+				GetLogger().Debug("collection overran the sampling interval, skipping ticks", "driver", d.Name(), "elapsed", elapsed, "skipped", int(elapsed/s.Interval))
+				select {
+				case <-t.C:
+				default:
+				}
+				t.Reset(s.Interval)
+			}
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// sleep Wait for d, false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
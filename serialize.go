@@ -0,0 +1,163 @@
+package driver
+
+import "context"
+
+// serialDriver Driver running one call of the underlying driver at a time
+type serialDriver struct {
+	d Driver
+	// sem Held while a call runs, a channel so that waiting for it stops
+	// when a context is done
+	sem chan struct{}
+}
+
+// Serialize Driver running the calls of d one at a time, for drivers that
+// aren't safe for concurrent use. Every method of Driver, Diagnose,
+// CollectStream and CollectInto wait for the call in progress to return;
+// those taking a context stop waiting when it's done, returning its error.
+// Watch only holds d while subscribing, the events are delivered
+// concurrently with other calls. The emit function of CollectStream runs
+// with d held and must not call the result.
+func Serialize(d Driver) Driver {
+	return &serialDriver{d: d, sem: make(chan struct{}, 1)}
+}
+
+func (s *serialDriver) lock() {
+	s.sem <- struct{}{}
+}
+
+// lockContext Wait for the driver until ctx is done
+func (s *serialDriver) lockContext(ctx context.Context) error {
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *serialDriver) unlock() {
+	<-s.sem
+}
+
+// Name Hypervisor name
+func (s *serialDriver) Name() DomainHypervisor {
+	s.lock()
+	defer s.unlock()
+	return s.d.Name()
+}
+
+// Detect Detect the hypervisor
+func (s *serialDriver) Detect() bool {
+	s.lock()
+	defer s.unlock()
+	return s.d.Detect()
+}
+
+// Diagnose Detect the hypervisor, with the reason
+func (s *serialDriver) Diagnose() DetectResult {
+	s.lock()
+	defer s.unlock()
+	return Diagnose(s.d)
+}
+
+// Capabilities Supported metrics
+func (s *serialDriver) Capabilities() Capabilities {
+	s.lock()
+	defer s.unlock()
+	return s.d.Capabilities()
+}
+
+// Collect Collect domains
+func (s *serialDriver) Collect(opts CollectOptions) (map[DomainID]*Domain, error) {
+	return s.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect domains once the call in progress returns
+func (s *serialDriver) CollectContext(ctx context.Context, opts CollectOptions) (map[DomainID]*Domain, error) {
+	if err := s.lockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer s.unlock()
+	return s.d.CollectContext(ctx, opts)
+}
+
+// CollectStream Collect domains as CollectContext does, emitting each as
+// the underlying driver collects it
+func (s *serialDriver) CollectStream(ctx context.Context, opts CollectOptions, emit func(*Domain) error) error {
+	if err := s.lockContext(ctx); err != nil {
+		return err
+	}
+	defer s.unlock()
+	return CollectStream(ctx, s.d, opts, emit)
+}
+
+// CollectInto Collect domains into dst as CollectContext does, reusing
+// them when the underlying driver can
+func (s *serialDriver) CollectInto(ctx context.Context, dst map[DomainID]*Domain, opts CollectOptions) error {
+	if err := s.lockContext(ctx); err != nil {
+		clear(dst)
+		return err
+	}
+	defer s.unlock()
+	return CollectInto(ctx, s.d, dst, opts)
+}
+
+// CollectDomain Collect a single domain by ID
+func (s *serialDriver) CollectDomain(id DomainID, opts CollectOptions) (*Domain, error) {
+	s.lock()
+	defer s.unlock()
+	return s.d.CollectDomain(id, opts)
+}
+
+// CollectDomainByUUID Collect a single domain by UUID
+func (s *serialDriver) CollectDomainByUUID(uuid string, opts CollectOptions) (*Domain, error) {
+	s.lock()
+	defer s.unlock()
+	return s.d.CollectDomainByUUID(uuid, opts)
+}
+
+// CollectDomainByName Collect a single domain by name
+func (s *serialDriver) CollectDomainByName(name string, opts CollectOptions) (*Domain, error) {
+	s.lock()
+	defer s.unlock()
+	return s.d.CollectDomainByName(name, opts)
+}
+
+// CollectSnapshots List the snapshots of a domain
+func (s *serialDriver) CollectSnapshots(id DomainID) ([]Snapshot, error) {
+	s.lock()
+	defer s.unlock()
+	return s.d.CollectSnapshots(id)
+}
+
+// Host Metrics of the physical host
+func (s *serialDriver) Host() (*HostInfo, error) {
+	s.lock()
+	defer s.unlock()
+	return s.d.Host()
+}
+
+// Ping Test if the hypervisor answers once the call in progress returns
+func (s *serialDriver) Ping(ctx context.Context) error {
+	if err := s.lockContext(ctx); err != nil {
+		return err
+	}
+	defer s.unlock()
+	return s.d.Ping(ctx)
+}
+
+// Watch Subscribe to the lifecycle events of the underlying driver
+func (s *serialDriver) Watch(ctx context.Context) (<-chan DomainEvent, error) {
+	if err := s.lockContext(ctx); err != nil {
+		return nil, err
+	}
+	defer s.unlock()
+	return s.d.Watch(ctx)
+}
+
+// Close Close the underlying driver once the call in progress returns
+func (s *serialDriver) Close() error {
+	s.lock()
+	defer s.unlock()
+	return s.d.Close()
+}
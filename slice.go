@@ -0,0 +1,38 @@
+package driver
+
+import "context"
+
+// SliceCollector Optional interface of drivers building a collection as a
+// slice directly, sparing the map when the caller only iterates. The slice
+// needn't be ordered, CollectSlice orders it.
+type SliceCollector interface {
+	CollectSlice(ctx context.Context, opts CollectOptions) ([]*Domain, error)
+}
+
+// ToSlice Domains of a collection ordered by UUID, then ID, so that
+// collections of the same domains always list them in the same order
+func ToSlice(domains map[DomainID]*Domain) []*Domain {
+	return SortedDomains(domains, SortByUUID)
+}
+
+// CollectSlice Equivalent to CollectSliceContext(context.Background(), d, opts)
+func CollectSlice(d Driver, opts CollectOptions) ([]*Domain, error) {
+	return CollectSliceContext(context.Background(), d, opts)
+}
+
+// CollectSliceContext Collect from d as a slice ordered as ToSlice does,
+// through CollectSlice when d is a SliceCollector. Domains collected along
+// with an error are returned with it, as from CollectContext.
+func CollectSliceContext(ctx context.Context, d Driver, opts CollectOptions) ([]*Domain, error) {
+	if sc, ok := d.(SliceCollector); ok {
+		doms, err := sc.CollectSlice(ctx, opts)
+		SortDomains(doms, SortByUUID)
+		return doms, err
+	}
+
+	domains, err := d.CollectContext(ctx, opts)
+	if domains == nil {
+		return nil, err
+	}
+	return ToSlice(domains), err
+}
@@ -0,0 +1,179 @@
+package driver
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// slowDomain Domain as its slow categories were last queried, reset when
+// it restarts
+type slowDomain struct {
+	dom     *Domain
+	opts    CollectOptions
+	fetched time.Time
+}
+
+// slowDriver Driver querying the slow categories at most every
+// CollectOptions.SlowInterval
+type slowDriver struct {
+	Driver
+
+	mu      sync.Mutex
+	domains map[DomainID]*slowDomain
+}
+
+// WithSlowInterval Driver collecting the expensive categories of d,
+// BlockCapacity, BackingChain, Addresses and Filesystems, only every
+// CollectOptions.SlowInterval per domain and filling them from the values
+// last queried in between. Every other category is collected on every call.
+//
+// A collection queries the slow categories of every domain once any domain
+// is due, collections in between are made without them. Domains appearing
+// or restarting in between are looked up alone with CollectDomain to query
+// them, their lookup failing leaves them without. Values filled in can be as
+// old as SlowInterval: a device added since has none until the next query.
+// Options without SlowInterval, or without any slow category, are passed
+// through. Lookups of a single domain, Host and Watch go straight to d.
+func WithSlowInterval(d Driver) Driver {
+	return &slowDriver{Driver: d, domains: make(map[DomainID]*slowDomain)}
+}
+
+// slow Test if opts holds any of the slow categories
+func slow(opts CollectOptions) bool {
+	return opts.BlockCapacity || opts.BackingChain || opts.Addresses || opts.Filesystems
+}
+
+// withoutSlow opts without the slow categories
+func withoutSlow(opts CollectOptions) CollectOptions {
+	opts.BlockCapacity, opts.BackingChain, opts.Addresses, opts.Filesystems = false, false, false, false
+	return opts
+}
+
+// Collect Collect domains, querying the slow categories when due
+func (s *slowDriver) Collect(opts CollectOptions) (map[DomainID]*Domain, error) {
+	return s.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect domains, querying the slow categories when due
+func (s *slowDriver) CollectContext(ctx context.Context, opts CollectOptions) (map[DomainID]*Domain, error) {
+	if opts.SlowInterval <= 0 || !slow(opts) {
+		return s.Driver.CollectContext(ctx, opts)
+	}
+
+	now := time.Now()
+	if s.due(now, opts.SlowInterval) {
+		domains, err := s.Driver.CollectContext(ctx, opts)
+		s.mu.Lock()
+		s.domains = make(map[DomainID]*slowDomain, len(domains))
+		for _, d := range domains {
+			s.store(d, opts, now)
+		}
+		s.mu.Unlock()
+		return domains, err
+	}
+
+	domains, err := s.Driver.CollectContext(ctx, withoutSlow(opts))
+	var missed []DomainID
+	s.mu.Lock()
+	for id, d := range domains {
+		if state := s.domains[id]; state != nil && state.dom.StartTime == d.StartTime && state.covers(opts) {
+			state.fill(d, opts)
+		} else {
+			missed = append(missed, id)
+		}
+	}
+	s.mu.Unlock()
+
+	// Domains without values yet, queried alone
+	lookup := opts.Unfiltered()
+	for _, id := range missed {
+		if ctx.Err() != nil {
+			break
+		}
+		d, lerr := s.Driver.CollectDomain(id, lookup)
+		if lerr != nil || d == nil {
+			GetLogger().Debug("slow categories unavailable", "driver", s.Name(), "domain", id, "error", lerr)
+			continue
+		}
+		domains[id] = d
+		s.mu.Lock()
+		s.store(d, opts, time.Now())
+		s.mu.Unlock()
+	}
+	return domains, err
+}
+
+// due Test if no domain was queried yet or one was queried interval ago
+func (s *slowDriver) due(now time.Time, interval time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.domains) == 0 {
+		return true
+	}
+	for _, state := range s.domains {
+		if now.Sub(state.fetched) >= interval {
+			return true
+		}
+	}
+	return false
+}
+
+// store Record the slow values of d, collected with opts at fetched
+func (s *slowDriver) store(d *Domain, opts CollectOptions, fetched time.Time) {
+	s.domains[d.ID] = &slowDomain{dom: d.Clone(), opts: opts, fetched: fetched}
+}
+
+// covers Test if the state was queried with every slow category of opts
+func (state *slowDomain) covers(opts CollectOptions) bool {
+	return (!opts.BlockCapacity || state.opts.BlockCapacity) &&
+		(!opts.BackingChain || state.opts.BackingChain) &&
+		(!opts.Addresses || state.opts.Addresses) &&
+		(!opts.Filesystems || state.opts.Filesystems)
+}
+
+// fill Set the slow categories of d, collected without them, from the
+// values last queried. Devices are matched by name.
+func (state *slowDomain) fill(d *Domain, opts CollectOptions) {
+	last := state.dom.Clone()
+	blocks := make(map[string]BlockDevice, len(last.Blocks))
+	for _, b := range last.Blocks {
+		blocks[b.Name] = b
+	}
+	for i := range d.Blocks {
+		b := &d.Blocks[i]
+		v, ok := blocks[b.Name]
+		if !ok {
+			continue
+		}
+		if opts.BlockCapacity {
+			b.Capacity, b.Allocation, b.Physical = v.Capacity, v.Allocation, v.Physical
+		}
+		if opts.BackingChain {
+			b.BackingChain = v.BackingChain
+		}
+	}
+	if opts.Addresses {
+		ifaces := make(map[string]NetworkInterface, len(last.Interfaces))
+		for _, n := range last.Interfaces {
+			ifaces[n.Name] = n
+		}
+		for i := range d.Interfaces {
+			if v, ok := ifaces[d.Interfaces[i].Name]; ok {
+				d.Interfaces[i].Addresses = v.Addresses
+			}
+		}
+	}
+	if opts.Filesystems {
+		d.Filesystems = last.Filesystems
+	}
+}
+
+// Close Drop the slow values and close the underlying driver
+func (s *slowDriver) Close() error {
+	s.mu.Lock()
+	s.domains = make(map[DomainID]*slowDomain)
+	s.mu.Unlock()
+	return s.Driver.Close()
+}
@@ -0,0 +1,15 @@
+package driver
+
+import "time"
+
+// Snapshot Snapshot of a domain
+type Snapshot struct {
+	Name         string    `json:"name"`
+	CreationTime time.Time `json:"creation_time"`
+	// State Domain state captured by the snapshot as named by the
+	// hypervisor (e.g. running, shutoff, disk-snapshot)
+	State string `json:"state"`
+	// Parent Name of the snapshot this one was taken from, empty for a root
+	Parent    string `json:"parent"`
+	IsCurrent bool   `json:"is_current"`
+}
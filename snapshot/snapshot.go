@@ -0,0 +1,230 @@
+// Package snapshot Versioned binary container persisting collections to
+// disk, each written with its collection time and source hypervisor.
+//
+// A file holds snapshots back to back, Read returning them in turn until
+// io.EOF. A snapshot is a sequence of frames, each a uvarint kind, a uvarint
+// payload length and the payload:
+//
+//	magic "VMSN", uvarint Version
+//	frame header   varint Unix nanoseconds, uvarint length and hypervisor
+//	frame domain   driver.Domain.MarshalBinary, one per domain by ID
+//	frame end      empty
+//
+// The framing is what keeps the format forward compatible: readers skip
+// frames of kinds they don't know and header bytes past the fields they
+// read, so later versions add either without breaking older readers. Domain
+// frames are gob encoded, which leaves out the fields a reader doesn't have
+// and zeroes those the writer didn't. Version only changes when older readers
+// can't skip what changed, Read rejects snapshots of a newer version.
+package snapshot
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// Version Schema version written in every snapshot
+const Version = 1
+
+// magic Leading bytes of every snapshot
+const magic = "VMSN"
+
+// Frame kinds
+const (
+	frameHeader = 1
+	frameDomain = 2
+	frameEnd    = 3
+)
+
+// maxFrame Largest frame read, so that a corrupt length doesn't allocate
+// without bound
+const maxFrame = 64 << 20
+
+var (
+	// ErrFormat The data isn't a well formed snapshot
+	ErrFormat = errors.New("snapshot: malformed snapshot")
+	// ErrVersion The snapshot was written by a newer, incompatible version
+	ErrVersion = errors.New("snapshot: unsupported version")
+)
+
+// Header Description of a snapshot
+type Header struct {
+	// Version Schema version the snapshot was written with
+	Version int
+	// Time When the collection was made
+	Time time.Time
+	// Hypervisor Hypervisor of every domain, empty when the collection holds
+	// none or several hypervisors
+	Hypervisor driver.DomainHypervisor
+}
+
+// Write Append a snapshot of doms collected at ts to w. Driver private
+// state isn't written, ts is kept to the nanosecond without its location.
+func Write(w io.Writer, ts time.Time, doms map[driver.DomainID]*driver.Domain) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(magic)
+	bw.Write(binary.AppendUvarint(nil, Version))
+
+	var hypervisor driver.DomainHypervisor
+	for _, d := range doms {
+		if hypervisor == "" {
+			hypervisor = d.Hypervisor
+		} else if d.Hypervisor != hypervisor {
+			hypervisor = ""
+			break
+		}
+	}
+	header := binary.AppendVarint(nil, ts.UnixNano())
+	header = binary.AppendUvarint(header, uint64(len(hypervisor)))
+	header = append(header, hypervisor...)
+	writeFrame(bw, frameHeader, header)
+
+	for _, d := range driver.SortedDomains(doms, driver.SortByID) {
+		data, err := d.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("snapshot: %w", err)
+		}
+		writeFrame(bw, frameDomain, data)
+	}
+	writeFrame(bw, frameEnd, nil)
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	return nil
+}
+
+func writeFrame(w *bufio.Writer, kind uint64, payload []byte) {
+	w.Write(binary.AppendUvarint(nil, kind))
+	w.Write(binary.AppendUvarint(nil, uint64(len(payload))))
+	w.Write(payload)
+}
+
+// Read Read the next snapshot of r, returning its collection time and
+// domains. io.EOF is returned as is when r holds no more snapshots. Nothing
+// past the snapshot is read, r can be a file of several.
+func Read(r io.Reader) (time.Time, map[driver.DomainID]*driver.Domain, error) {
+	h, doms, err := ReadHeader(r)
+	return h.Time, doms, err
+}
+
+// ReadHeader Read the next snapshot of r as Read does, along with its header
+func ReadHeader(r io.Reader) (Header, map[driver.DomainID]*driver.Domain, error) {
+	var h Header
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = byteReader{r}
+	}
+
+	var m [len(magic)]byte
+	if n, err := io.ReadFull(r, m[:]); err != nil {
+		if n == 0 && errors.Is(err, io.EOF) {
+			return h, nil, io.EOF
+		}
+		return h, nil, fmt.Errorf("%w: %w", ErrFormat, err)
+	}
+	if string(m[:]) != magic {
+		return h, nil, fmt.Errorf("%w: bad magic", ErrFormat)
+	}
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return h, nil, fmt.Errorf("%w: %w", ErrFormat, eof(err))
+	}
+	if version > Version {
+		return h, nil, fmt.Errorf("%w: %d", ErrVersion, version)
+	}
+	h.Version = int(version)
+
+	var doms map[driver.DomainID]*driver.Domain
+	header := false
+	for {
+		kind, payload, err := readFrame(r, br)
+		if err != nil {
+			return h, nil, err
+		}
+		switch kind {
+		case frameHeader:
+			if err := h.decode(payload); err != nil {
+				return h, nil, err
+			}
+			header = true
+			doms = make(map[driver.DomainID]*driver.Domain)
+		case frameDomain:
+			if !header {
+				return h, nil, fmt.Errorf("%w: domain before header", ErrFormat)
+			}
+			d := new(driver.Domain)
+			if err := d.UnmarshalBinary(payload); err != nil {
+				return h, nil, fmt.Errorf("%w: %w", ErrFormat, err)
+			}
+			doms[d.ID] = d
+		case frameEnd:
+			if !header {
+				return h, nil, fmt.Errorf("%w: no header", ErrFormat)
+			}
+			return h, doms, nil
+		}
+		// Frames of later versions are skipped
+	}
+}
+
+// decode Read the header fields this version knows, ignoring any after them
+func (h *Header) decode(payload []byte) error {
+	ns, n := binary.Varint(payload)
+	if n <= 0 {
+		return fmt.Errorf("%w: bad header", ErrFormat)
+	}
+	payload = payload[n:]
+	l, n := binary.Uvarint(payload)
+	if n <= 0 || l > uint64(len(payload)-n) {
+		return fmt.Errorf("%w: bad header", ErrFormat)
+	}
+	h.Time = time.Unix(0, ns)
+	h.Hypervisor = driver.DomainHypervisor(payload[n : n+int(l)])
+	return nil
+}
+
+// readFrame Read the kind and payload of the next frame
+func readFrame(r io.Reader, br io.ByteReader) (uint64, []byte, error) {
+	kind, err := binary.ReadUvarint(br)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrFormat, eof(err))
+	}
+	l, err := binary.ReadUvarint(br)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrFormat, eof(err))
+	}
+	if l > maxFrame {
+		return 0, nil, fmt.Errorf("%w: frame of %d bytes", ErrFormat, l)
+	}
+	payload := make([]byte, l)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", ErrFormat, eof(err))
+	}
+	return kind, payload, nil
+}
+
+// eof A truncated snapshot, io.EOF only meaning the end of a whole one
+func eof(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// byteReader Reads single bytes of a reader without buffering past them
+type byteReader struct {
+	r io.Reader
+}
+
+func (b byteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(b.r, buf[:])
+	return buf[0], err
+}
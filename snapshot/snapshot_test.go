@@ -0,0 +1,137 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/snapshot"
+)
+
+// collection Domains stored in the snapshots, v1.snap included
+func collection() map[driver.DomainID]*driver.Domain {
+	return map[driver.DomainID]*driver.Domain{
+		1: {
+			ID: 1, Name: "web-1", Hypervisor: "mock", UUID: "6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f",
+			Flags: driver.DomainOnline, VCPUs: 2,
+			Cpus:       []driver.CPU{{ID: 0, Time: 1.5e9}, {ID: 1, Time: 2.5e9}},
+			Blocks:     []driver.BlockDevice{{Name: "vda", IsDisk: true, Read: driver.BlockIO{Operations: 10, Bytes: 40960, Absolute: true}}},
+			Interfaces: []driver.NetworkInterface{{Name: "vnet0", RX: driver.NetworkIO{Bytes: 1500, Packets: 1}}},
+			Memory:     driver.Memory{RSS: 1 << 30, RSSSet: true},
+		},
+		7: {ID: 7, Name: "db-1", Hypervisor: "mock", Flags: driver.DomainPaused, Labels: map[string]string{"env": "prod"}},
+	}
+}
+
+// collected Collection time of the snapshots
+var collected = time.Unix(1700000000, 123456789)
+
+func checkSnapshot(t *testing.T, h snapshot.Header, doms map[driver.DomainID]*driver.Domain) {
+	t.Helper()
+	if !h.Time.Equal(collected) || h.Hypervisor != "mock" {
+		t.Errorf("header = %+v, want collected at %v from mock", h, collected)
+	}
+	want := collection()
+	if len(doms) != len(want) {
+		t.Fatalf("got %d domains, want %d", len(doms), len(want))
+	}
+	for id, d := range want {
+		if !doms[id].EqualCounters(d) {
+			t.Errorf("domain %d = %+v, want %+v", id, doms[id], d)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	for i := 0; i < 2; i++ {
+		if err := snapshot.Write(&buf, collected, collection()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		h, doms, err := snapshot.ReadHeader(&buf)
+		if err != nil {
+			t.Fatalf("snapshot %d: %v", i, err)
+		}
+		if h.Version != snapshot.Version {
+			t.Errorf("snapshot %d: version %d, want %d", i, h.Version, snapshot.Version)
+		}
+		checkSnapshot(t, h, doms)
+	}
+	if _, _, err := snapshot.Read(&buf); err != io.EOF {
+		t.Errorf("past the last snapshot: %v, want io.EOF", err)
+	}
+}
+
+func TestUnknownVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := snapshot.Write(&buf, collected, collection()); err != nil {
+		t.Fatal(err)
+	}
+	// The version follows the magic, a single byte uvarint while small
+	data := buf.Bytes()
+	data[4] = byte(binary.AppendUvarint(nil, snapshot.Version+1)[0])
+
+	if _, _, err := snapshot.Read(bytes.NewReader(data)); !errors.Is(err, snapshot.ErrVersion) {
+		t.Errorf("Read() = %v, want ErrVersion", err)
+	}
+}
+
+// TestVersion1 Snapshots written by version 1 keep reading as the format
+// evolves. testdata/v1.snap must never be regenerated.
+func TestVersion1(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "v1.snap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	h, doms, err := snapshot.ReadHeader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Version != 1 {
+		t.Errorf("version %d, want 1", h.Version)
+	}
+	checkSnapshot(t, h, doms)
+}
+
+// TestSkipped Frames of unknown kinds and header fields past those known are
+// skipped, as written by later versions
+func TestSkipped(t *testing.T) {
+	frame := func(b []byte, kind uint64, payload []byte) []byte {
+		b = binary.AppendUvarint(b, kind)
+		b = binary.AppendUvarint(b, uint64(len(payload)))
+		return append(b, payload...)
+	}
+	header := binary.AppendVarint(nil, collected.UnixNano())
+	header = binary.AppendUvarint(header, uint64(len("mock")))
+	header = append(header, "mock"...)
+	header = append(header, 0xde, 0xad)
+
+	data := binary.AppendUvarint([]byte("VMSN"), snapshot.Version)
+	data = frame(data, 1, header)
+	data = frame(data, 42, []byte("from the future"))
+	for _, id := range []driver.DomainID{1, 7} {
+		d, err := collection()[id].MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = frame(data, 2, d)
+	}
+	data = frame(data, 3, nil)
+
+	h, doms, err := snapshot.ReadHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSnapshot(t, h, doms)
+}
@@ -0,0 +1,61 @@
+package driver
+
+import "sort"
+
+// SortKey Domain field to sort by
+type SortKey int
+
+const (
+	// SortByName Sort by name, then ID
+	SortByName SortKey = iota
+	// SortByID Sort by ID
+	SortByID
+	// SortByUUID Sort by UUID, then ID
+	SortByUUID
+)
+
+// SortDomains Sort doms in place by the given key
+func SortDomains(doms []*Domain, by SortKey) {
+	less := func(a, b *Domain) bool { return a.ID < b.ID }
+	switch by {
+	case SortByName:
+		less = func(a, b *Domain) bool {
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.ID < b.ID
+		}
+	case SortByUUID:
+		less = func(a, b *Domain) bool {
+			if a.UUID != b.UUID {
+				return a.UUID < b.UUID
+			}
+			return a.ID < b.ID
+		}
+	}
+	sort.Slice(doms, func(i, j int) bool { return less(doms[i], doms[j]) })
+}
+
+// SortedDomains Domains of a collection as a slice sorted by the given key
+func SortedDomains(domains map[DomainID]*Domain, by SortKey) []*Domain {
+	doms := make([]*Domain, 0, len(domains))
+	for _, d := range domains {
+		doms = append(doms, d)
+	}
+	SortDomains(doms, by)
+	return doms
+}
+
+// SortDevices Order vCPUs, IO threads and NUMA cells by ID, block devices and
+// interfaces by name, file systems by mount point and host devices by
+// address, drivers call it so every collection lists devices in the same
+// order
+func (d *Domain) SortDevices() {
+	sort.SliceStable(d.Cpus, func(i, j int) bool { return d.Cpus[i].ID < d.Cpus[j].ID })
+	sort.SliceStable(d.IOThreads, func(i, j int) bool { return d.IOThreads[i].ID < d.IOThreads[j].ID })
+	sort.SliceStable(d.Blocks, func(i, j int) bool { return d.Blocks[i].Name < d.Blocks[j].Name })
+	sort.SliceStable(d.Interfaces, func(i, j int) bool { return d.Interfaces[i].Name < d.Interfaces[j].Name })
+	sort.SliceStable(d.Filesystems, func(i, j int) bool { return d.Filesystems[i].Mountpoint < d.Filesystems[j].Mountpoint })
+	sort.SliceStable(d.HostDevices, func(i, j int) bool { return d.HostDevices[i].Address < d.HostDevices[j].Address })
+	sort.SliceStable(d.NUMA.Cells, func(i, j int) bool { return d.NUMA.Cells[i].ID < d.NUMA.Cells[j].ID })
+}
@@ -0,0 +1,100 @@
+package driver_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/virtmonitor/driver"
+)
+
+// sortDomains Domains sharing names and UUIDs, for the ID tie-break
+func sortDomains() []*driver.Domain {
+	var doms []*driver.Domain
+	for i := 1; i <= 24; i++ {
+		doms = append(doms, &driver.Domain{
+			ID:   driver.DomainID(i * 7 % 25),
+			Name: fmt.Sprintf("vm-%d", i%5),
+			UUID: fmt.Sprintf("00000000-0000-0000-0000-%012d", i%3),
+		})
+	}
+	return doms
+}
+
+func domainIDs(doms []*driver.Domain) []driver.DomainID {
+	ids := make([]driver.DomainID, len(doms))
+	for i, d := range doms {
+		ids[i] = d.ID
+	}
+	return ids
+}
+
+func TestSortDomainsShuffled(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, by := range []driver.SortKey{driver.SortByName, driver.SortByID, driver.SortByUUID} {
+		want := sortDomains()
+		driver.SortDomains(want, by)
+
+		for i := 0; i < 50; i++ {
+			doms := sortDomains()
+			rng.Shuffle(len(doms), func(i, j int) { doms[i], doms[j] = doms[j], doms[i] })
+			driver.SortDomains(doms, by)
+			if got := domainIDs(doms); !slices.Equal(got, domainIDs(want)) {
+				t.Fatalf("key %d: shuffle %d sorted to %v, want %v", by, i, got, domainIDs(want))
+			}
+
+			collection := make(map[driver.DomainID]*driver.Domain)
+			for _, d := range doms {
+				collection[d.ID] = d
+			}
+			if got := domainIDs(driver.SortedDomains(collection, by)); !slices.Equal(got, domainIDs(want)) {
+				t.Fatalf("key %d: SortedDomains = %v, want %v", by, got, domainIDs(want))
+			}
+		}
+	}
+
+	// Ties on the name fall back to the ID
+	doms := sortDomains()
+	driver.SortDomains(doms, driver.SortByName)
+	for i := 1; i < len(doms); i++ {
+		if a, b := doms[i-1], doms[i]; a.Name > b.Name || a.Name == b.Name && a.ID > b.ID {
+			t.Errorf("%q %d sorted before %q %d", a.Name, a.ID, b.Name, b.ID)
+		}
+	}
+}
+
+func TestSortDevicesShuffled(t *testing.T) {
+	devices := func() *driver.Domain {
+		d := &driver.Domain{}
+		for i := 0; i < 8; i++ {
+			d.Cpus = append(d.Cpus, driver.CPU{ID: uint64(i)})
+			d.IOThreads = append(d.IOThreads, driver.IOThread{ID: uint64(i + 1)})
+			d.Blocks = append(d.Blocks, driver.BlockDevice{Name: fmt.Sprintf("vd%c", 'a'+i)})
+			d.Interfaces = append(d.Interfaces, driver.NetworkInterface{Name: fmt.Sprintf("vnet%d", i)})
+			d.Filesystems = append(d.Filesystems, driver.Filesystem{Mountpoint: fmt.Sprintf("/mnt/%d", i)})
+			d.HostDevices = append(d.HostDevices, driver.HostDevice{Address: fmt.Sprintf("0000:0%d:00.0", i)})
+			d.NUMA.Cells = append(d.NUMA.Cells, driver.NUMACell{ID: uint64(i)})
+		}
+		return d
+	}
+	want := devices()
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		d := devices()
+		rng.Shuffle(len(d.Cpus), func(i, j int) { d.Cpus[i], d.Cpus[j] = d.Cpus[j], d.Cpus[i] })
+		rng.Shuffle(len(d.IOThreads), func(i, j int) { d.IOThreads[i], d.IOThreads[j] = d.IOThreads[j], d.IOThreads[i] })
+		rng.Shuffle(len(d.Blocks), func(i, j int) { d.Blocks[i], d.Blocks[j] = d.Blocks[j], d.Blocks[i] })
+		rng.Shuffle(len(d.Interfaces), func(i, j int) { d.Interfaces[i], d.Interfaces[j] = d.Interfaces[j], d.Interfaces[i] })
+		rng.Shuffle(len(d.Filesystems), func(i, j int) { d.Filesystems[i], d.Filesystems[j] = d.Filesystems[j], d.Filesystems[i] })
+		rng.Shuffle(len(d.HostDevices), func(i, j int) { d.HostDevices[i], d.HostDevices[j] = d.HostDevices[j], d.HostDevices[i] })
+		rng.Shuffle(len(d.NUMA.Cells), func(i, j int) { d.NUMA.Cells[i], d.NUMA.Cells[j] = d.NUMA.Cells[j], d.NUMA.Cells[i] })
+
+		d.SortDevices()
+		if !reflect.DeepEqual(d, want) {
+			t.Fatalf("shuffle %d sorted to %+v, want %+v", i, d, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package driver
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StateChangeKind What a DomainStateChange reports
+type StateChangeKind int
+
+const (
+	// StateChanged The domain went from Old to New
+	StateChanged StateChangeKind = iota
+	// StateAppeared The domain wasn't in the previous poll, New is its state
+	StateAppeared
+	// StateVanished The domain is no longer collected, Old was its last state
+	StateVanished
+)
+
+// String Human readable state change kind
+func (k StateChangeKind) String() string {
+	switch k {
+	case StateChanged:
+		return "changed"
+	case StateAppeared:
+		return "appeared"
+	case StateVanished:
+		return "vanished"
+	}
+	return "StateChangeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// DomainStateChange A change of state between two polls, the polling
+// counterpart of the DomainEvent of Watch for drivers without events
+type DomainStateChange struct {
+	Kind       StateChangeKind  `json:"kind"`
+	ID         DomainID         `json:"id"`
+	UUID       string           `json:"uuid"`
+	Name       string           `json:"name"`
+	Hypervisor DomainHypervisor `json:"hypervisor"`
+	// Old State in the previous poll, unset for StateAppeared
+	Old DomainFlag `json:"old"`
+	// New State in this poll, unset for StateVanished
+	New DomainFlag `json:"new"`
+	// At Time of the poll the change was seen in
+	At time.Time `json:"at"`
+}
+
+// StateTracker Tells the state changes of domains between consecutive
+// polls. Domains are keyed by UUID, so that restarts changing their ID are
+// seen as state changes (online, dying, online) rather than as a domain
+// vanishing and another appearing, or by hypervisor and name for drivers
+// without UUIDs. The zero value is ready to use and safe for concurrent use.
+type StateTracker struct {
+	mu     sync.Mutex
+	states map[string]DomainStateChange
+}
+
+// Observe Compare a poll to the previous one, changes are ordered by domain
+// ID. Every domain of the first poll appears. Domains that failed with a
+// DomainError in err keep their previous state rather than vanish; polls
+// failed as a whole (err made of more than DomainErrors) report nothing and
+// aren't recorded.
+func (t *StateTracker) Observe(domains map[DomainID]*Domain, err error, at time.Time) []DomainStateChange {
+	if err != nil && !onlyDomainErrors(err) {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev := t.states
+	t.states = make(map[string]DomainStateChange, len(domains))
+
+	var changes []DomainStateChange
+	for _, d := range domains {
+		cur := DomainStateChange{ID: d.ID, UUID: d.UUID, Name: d.Name, Hypervisor: d.Hypervisor, New: d.Flags, At: at}
+		key := resetKey(d)
+		t.states[key] = cur
+		old, ok := prev[key]
+		delete(prev, key)
+		switch {
+		case !ok:
+			cur.Kind = StateAppeared
+		case old.New != cur.New:
+			cur.Kind, cur.Old = StateChanged, old.New
+		default:
+			continue
+		}
+		changes = append(changes, cur)
+	}
+
+	failed := failedDomains(err)
+	for key, old := range prev {
+		if failed[old.ID] {
+			t.states[key] = old
+			continue
+		}
+		changes = append(changes, DomainStateChange{
+			Kind: StateVanished, ID: old.ID, UUID: old.UUID, Name: old.Name, Hypervisor: old.Hypervisor,
+			Old: old.New, At: at,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+	return changes
+}
+
+// failedDomains IDs of the domains err holds DomainErrors for
+func failedDomains(err error) map[DomainID]bool {
+	failed := make(map[DomainID]bool)
+	walkDomainErrors(err, func(e *DomainError) { failed[e.ID] = true })
+	return failed
+}
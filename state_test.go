@@ -0,0 +1,41 @@
+package driver_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+func TestStateTrackerPartialPoll(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	vm := func(id driver.DomainID, flags driver.DomainFlag) *driver.Domain {
+		return &driver.Domain{ID: id, UUID: fmt.Sprintf("00000000-0000-0000-0000-%012d", id), Name: fmt.Sprint("vm-", id), Flags: flags}
+	}
+
+	var st driver.StateTracker
+	st.Observe(map[driver.DomainID]*driver.Domain{1: vm(1, driver.DomainOnline), 2: vm(2, driver.DomainOnline), 3: vm(3, driver.DomainOnline)}, nil, at)
+
+	// Domains 2 and 3 failed, the partial error wrapped by the caller
+	failed := errors.Join(&driver.DomainError{ID: 2, Err: driver.ErrPermissionDenied}, &driver.DomainError{ID: 3, Err: driver.ErrDomainNotFound})
+	err := fmt.Errorf("poll: %w", failed)
+	changes := st.Observe(map[driver.DomainID]*driver.Domain{1: vm(1, driver.DomainPaused)}, err, at.Add(time.Second))
+	if len(changes) != 1 || changes[0].ID != 1 || changes[0].Kind != driver.StateChanged {
+		t.Errorf("wrapped partial poll: got %+v, want domain 1 changed only", changes)
+	}
+
+	// The failed domains kept their state, domain 3 vanishes once collected
+	// without it
+	changes = st.Observe(map[driver.DomainID]*driver.Domain{1: vm(1, driver.DomainPaused), 2: vm(2, driver.DomainPaused)}, nil, at.Add(2*time.Second))
+	if len(changes) != 2 || changes[0].ID != 2 || changes[0].Kind != driver.StateChanged ||
+		changes[1].ID != 3 || changes[1].Kind != driver.StateVanished {
+		t.Errorf("next poll: got %+v, want domain 2 changed and 3 vanished", changes)
+	}
+
+	// A poll failed as a whole reports nothing
+	if changes := st.Observe(nil, fmt.Errorf("poll: %w", driver.ErrHypervisorUnavailable), at.Add(3*time.Second)); changes != nil {
+		t.Errorf("failed poll: got %+v", changes)
+	}
+}
@@ -0,0 +1,52 @@
+package driver
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// CollectStats Timing of a collection
+type CollectStats struct {
+	// Total Wall clock duration of the whole collection
+	Total time.Duration `json:"total"`
+	// Domains Per domain durations, slowest first
+	Domains []DomainTiming `json:"domains"`
+}
+
+// DomainTiming Time spent collecting a single domain
+type DomainTiming struct {
+	ID       DomainID      `json:"id"`
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Slowest The n slowest domains, all of them if there are fewer
+func (s CollectStats) Slowest(n int) []DomainTiming {
+	if n > len(s.Domains) {
+		n = len(s.Domains)
+	}
+	return s.Domains[:n]
+}
+
+// CollectWithStats Collect from d, timing the whole collection. Per domain
+// durations are those drivers record in Domain.CollectDuration, drivers
+// gathering every domain at once leave them zero.
+func CollectWithStats(ctx context.Context, d Driver, opts CollectOptions) (map[DomainID]*Domain, CollectStats, error) {
+	start := time.Now()
+	domains, err := d.CollectContext(ctx, opts)
+	stats := CollectStats{Total: time.Since(start)}
+
+	stats.Domains = make([]DomainTiming, 0, len(domains))
+	for id, dom := range domains {
+		stats.Domains = append(stats.Domains, DomainTiming{ID: id, Name: dom.Name, Duration: dom.CollectDuration})
+	}
+	sort.Slice(stats.Domains, func(i, j int) bool {
+		a, b := stats.Domains[i], stats.Domains[j]
+		if a.Duration != b.Duration {
+			return a.Duration > b.Duration
+		}
+		return a.ID < b.ID
+	})
+	return domains, stats, err
+}
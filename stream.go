@@ -0,0 +1,39 @@
+package driver
+
+import "context"
+
+// Streamer Optional interface of drivers passing domains to a callback as
+// they're collected, never holding the collection as a whole. Drivers
+// collecting with CollectDomains implement it with StreamDomains.
+type Streamer interface {
+	// CollectStream Collect domains as CollectContext does, calling emit
+	// with each as soon as it's collected. emit is never called
+	// concurrently. An error from emit stops the collection and is
+	// returned as is, per domain failures are returned joined once every
+	// other domain is emitted.
+	CollectStream(ctx context.Context, opts CollectOptions, emit func(*Domain) error) error
+}
+
+// CollectStream Collect from d calling emit with every domain, through
+// CollectStream when d is a Streamer, for hosts whose collection is too
+// large to hold in memory at once. Other drivers are collected with
+// CollectContext and their domains emitted ordered by ID, which spares
+// nothing but keeps callers uniform. Wrappers such as WithCache aren't
+// Streamers. emit is never called concurrently; an error from it stops the
+// collection and is returned as is.
+func CollectStream(ctx context.Context, d Driver, opts CollectOptions, emit func(*Domain) error) error {
+	if s, ok := d.(Streamer); ok {
+		return s.CollectStream(ctx, opts, emit)
+	}
+
+	domains, err := d.CollectContext(ctx, opts)
+	for _, dom := range SortedDomains(domains, SortByID) {
+		if cerr := ctx.Err(); cerr != nil {
+			return cerr
+		}
+		if eerr := emit(dom); eerr != nil {
+			return eerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package driver
+
+import (
+	"strconv"
+	"strings"
+)
+
+// String Single line summary of the domain for logs: name, ID, UUID,
+// hypervisor, state and device counts, such as
+// domain "web" id=3 uuid=... hypervisor=libvirt state=online cpus=2 blocks=1 interfaces=1
+func (d *Domain) String() string {
+	if d == nil {
+		return "<nil>"
+	}
+
+	var b strings.Builder
+	b.WriteString("domain ")
+	b.WriteString(strconv.Quote(d.Name))
+	b.WriteString(" id=")
+	b.WriteString(strconv.FormatUint(uint64(d.ID), 10))
+	attr(&b, "uuid", d.UUID)
+	attr(&b, "hypervisor", string(d.Hypervisor))
+	attr(&b, "state", d.Flags.String())
+	attr(&b, "cpus", strconv.Itoa(len(d.Cpus)))
+	attr(&b, "blocks", strconv.Itoa(len(d.Blocks)))
+	attr(&b, "interfaces", strconv.Itoa(len(d.Interfaces)))
+	return b.String()
+}
+
+// String Single line summary of the block device for logs, such as
+// block "vda" bus=virtio source="/var/lib/images/web.qcow2" readonly
+func (bd BlockDevice) String() string {
+	var b strings.Builder
+	b.WriteString("block ")
+	b.WriteString(strconv.Quote(bd.Name))
+	attr(&b, "bus", bd.Bus)
+	if bd.Source != "" {
+		attr(&b, "source", strconv.Quote(bd.Source))
+	}
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{{"cdrom", bd.IsCDrom}, {"readonly", bd.ReadOnly}, {"stalled", bd.Stalled}, {"throttled", bd.Throttled}} {
+		if f.set {
+			b.WriteByte(' ')
+			b.WriteString(f.name)
+		}
+	}
+	return b.String()
+}
+
+// String Single line summary of the interface for logs, the MAC address in
+// colon separated hex, such as
+// interface "vnet0" mac=52:54:00:12:34:56 host_device=vnet0 bridges=br0 link=up
+func (n NetworkInterface) String() string {
+	var b strings.Builder
+	b.WriteString("interface ")
+	b.WriteString(strconv.Quote(n.Name))
+	if len(n.Mac) > 0 {
+		attr(&b, "mac", n.Mac.String())
+	}
+	attr(&b, "host_device", n.HostDevice)
+	attr(&b, "bridges", strings.Join(n.Bridges, ","))
+	if n.LinkUpSet {
+		link := "down"
+		if n.LinkUp {
+			link = "up"
+		}
+		attr(&b, "link", link)
+	}
+	return b.String()
+}
+
+// attr Append key=value to b, nothing if value is empty
+func attr(b *strings.Builder, key, value string) {
+	if value == "" {
+		return
+	}
+	b.WriteByte(' ')
+	b.WriteString(key)
+	b.WriteByte('=')
+	b.WriteString(value)
+}
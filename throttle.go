@@ -0,0 +1,144 @@
+package driver
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultThrottleThreshold Fraction of a limit IO must reach for a
+// ThrottleTracker to report the device throttled
+const DefaultThrottleThreshold = 0.95
+
+// blockSample Counters of a block device and when they were sampled
+type blockSample struct {
+	read, write BlockIO
+	at          Timestamp
+}
+
+// domainThrottle Throttle state of a domain, reset when it restarts
+type domainThrottle struct {
+	start  Timestamp
+	blocks map[string]blockSample
+}
+
+// ThrottleTracker Sets BlockDevice.Throttled, telling whether the limits
+// configured on a device are biting. No hypervisor reports requests waiting
+// on a limit, only the limits and cumulative counters, so the tracker takes
+// the read and write rates of a device between two successive samples and
+// compares them against its Limits: the device is throttled when a rate
+// reaches Threshold of the matching limit, total limits comparing the rates
+// of reads and writes combined.
+//
+// Samples must be collected with CollectOptions.Blocks and Limits. The first
+// sample of a device only records its counters, it is never throttled. The
+// rates are averages over the sampling interval: a guest held at its limit
+// for part of a long interval and idle the rest doesn't register, intervals
+// of a few seconds to a minute suit most limits. The zero value is ready to
+// use and safe for concurrent use.
+type ThrottleTracker struct {
+	// Threshold Fraction of a limit a rate must reach, 0 for
+	// DefaultThrottleThreshold. Throttled IO hovers just below the limit.
+	Threshold float64
+
+	mu      sync.Mutex
+	domains map[DomainID]*domainThrottle
+}
+
+// Observe Compare the rates of the block devices of d since its previous
+// sample against their limits and set their Throttled field, overwriting
+// what it held. A domain that restarted or a device that appeared starts
+// over, devices gone are forgotten.
+func (t *ThrottleTracker) Observe(d *Domain) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.observe(d)
+}
+
+// ObserveAll Observe every domain of a collection and forget those not in
+// it, so that state of domains gone doesn't accumulate
+func (t *ThrottleTracker) ObserveAll(domains map[DomainID]*Domain) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id := range t.domains {
+		if _, ok := domains[id]; !ok {
+			delete(t.domains, id)
+		}
+	}
+	for _, d := range domains {
+		t.observe(d)
+	}
+}
+
+// Forget Drop the state of a domain, its next sample starts over
+func (t *ThrottleTracker) Forget(id DomainID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.domains, id)
+}
+
+func (t *ThrottleTracker) observe(d *Domain) {
+	if t.domains == nil {
+		t.domains = make(map[DomainID]*domainThrottle)
+	}
+	now := d.Time
+	if now == 0 {
+		now = TimestampNow()
+	}
+
+	state := t.domains[d.ID]
+	if state == nil || state.start != d.StartTime {
+		state = &domainThrottle{start: d.StartTime, blocks: make(map[string]blockSample, len(d.Blocks))}
+		t.domains[d.ID] = state
+	}
+
+	seen := make(map[string]bool, len(d.Blocks))
+	for i := range d.Blocks {
+		b := &d.Blocks[i]
+		seen[b.Name] = true
+		prev, ok := state.blocks[b.Name]
+		b.Throttled = false
+		if ok && now > prev.at {
+			b.Throttled = t.throttled(b, prev, now.Sub(prev.at))
+		}
+		if !ok || now > prev.at {
+			state.blocks[b.Name] = blockSample{read: b.Read, write: b.Write, at: now}
+		}
+	}
+	for name := range state.blocks {
+		if !seen[name] {
+			delete(state.blocks, name)
+		}
+	}
+}
+
+// throttled Test if a rate of b since prev reached its limit. Counters that
+// were reset give no rate.
+func (t *ThrottleTracker) throttled(b *BlockDevice, prev blockSample, interval time.Duration) bool {
+	read := b.Read.Rate(prev.read, interval)
+	write := b.Write.Rate(prev.write, interval)
+	if read.Reset || write.Reset {
+		return false
+	}
+
+	threshold := t.Threshold
+	if threshold <= 0 {
+		threshold = DefaultThrottleThreshold
+	}
+	l := b.Limits
+	for _, c := range []struct {
+		rate  float64
+		limit uint64
+	}{
+		{read.Operations, l.ReadIOPS},
+		{write.Operations, l.WriteIOPS},
+		{read.Operations + write.Operations, l.TotalIOPS},
+		{read.Bytes, l.ReadBytesSec},
+		{write.Bytes, l.WriteBytesSec},
+		{read.Bytes + write.Bytes, l.TotalBytesSec},
+	} {
+		if c.limit > 0 && c.rate >= threshold*float64(c.limit) {
+			return true
+		}
+	}
+	return false
+}
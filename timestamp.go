@@ -0,0 +1,30 @@
+package driver
+
+import "time"
+
+// TimestampNow Current time as a Timestamp, which drivers use for
+// Domain.Time
+func TimestampNow() Timestamp {
+	return TimestampOf(time.Now())
+}
+
+// TimestampOf Timestamp of t, 0 for the zero time
+func TimestampOf(t time.Time) Timestamp {
+	if t.IsZero() {
+		return 0
+	}
+	return Timestamp(t.UnixNano())
+}
+
+// Time Timestamp as a time.Time, the zero time for 0
+func (t Timestamp) Time() time.Time {
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(t))
+}
+
+// Sub Duration t-o, such as the interval between two collections
+func (t Timestamp) Sub(o Timestamp) time.Duration {
+	return time.Duration(t - o)
+}
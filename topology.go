@@ -0,0 +1,24 @@
+package driver
+
+// ClearCounters Zero the statistics of the vCPUs, IO threads, block devices
+// and interfaces of d, keeping their configuration as
+// CollectOptions.TopologyOnly describes. Drivers run it on the domains they
+// collect with TopologyOnly.
+func (d *Domain) ClearCounters() {
+	d.EmulatorTime, d.EmulatorTimeSet = 0, false
+	for i := range d.IOThreads {
+		d.IOThreads[i].Time, d.IOThreads[i].TimeSet = 0, false
+	}
+	for i := range d.Cpus {
+		c := &d.Cpus[i]
+		*c = CPU{ID: c.ID, Flags: c.Flags, Affinity: c.Affinity, NUMANode: c.NUMANode, NUMANodeSet: c.NUMANodeSet}
+	}
+	for i := range d.Blocks {
+		b := &d.Blocks[i]
+		b.Read, b.Write, b.Flush = BlockIO{}, BlockIO{}, BlockIO{}
+		b.Stalled, b.Throttled = false, false
+	}
+	for i := range d.Interfaces {
+		d.Interfaces[i].RX, d.Interfaces[i].TX = NetworkIO{}, NetworkIO{}
+	}
+}
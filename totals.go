@@ -0,0 +1,80 @@
+package driver
+
+// Totals Sums across every domain of a collection
+type Totals struct {
+	// Domains Number of domains
+	Domains int `json:"domains"`
+	// States Number of domains in each state
+	States map[DomainFlag]int `json:"states"`
+	// VCPUs Number of configured vCPUs
+	VCPUs int `json:"vcpus"`
+	// CPUTime vCPU time of every domain, in nanoseconds
+	CPUTime float64 `json:"cpu_time"`
+
+	// Blocks Absolute block IO counters, summed
+	Blocks BlockTotals `json:"blocks"`
+	// BlockDeltas Block IO reported as per interval deltas, summed apart so
+	// they don't mix with the absolute counters
+	BlockDeltas BlockTotals `json:"block_deltas"`
+
+	RX NetworkIO `json:"rx"`
+	TX NetworkIO `json:"tx"`
+}
+
+// BlockTotals Summed block IO, split by operation
+type BlockTotals struct {
+	Read  BlockIO `json:"read"`
+	Write BlockIO `json:"write"`
+	Flush BlockIO `json:"flush"`
+}
+
+// TotalsOf Sum the domains of a collection
+func TotalsOf(domains map[DomainID]*Domain) Totals {
+	t := Totals{
+		States: make(map[DomainFlag]int),
+		Blocks: BlockTotals{Read: BlockIO{Absolute: true}, Write: BlockIO{Absolute: true}, Flush: BlockIO{Absolute: true}},
+	}
+
+	for _, d := range domains {
+		t.Domains++
+		t.States[d.Flags]++
+		t.VCPUs += d.VCPUs
+
+		for _, cpu := range d.Cpus {
+			t.CPUTime += cpu.Time
+		}
+		for _, b := range d.Blocks {
+			addBlockIO(&t.Blocks.Read, &t.BlockDeltas.Read, b.Read)
+			addBlockIO(&t.Blocks.Write, &t.BlockDeltas.Write, b.Write)
+			addBlockIO(&t.Blocks.Flush, &t.BlockDeltas.Flush, b.Flush)
+		}
+		for _, iface := range d.Interfaces {
+			t.RX.add(iface.RX)
+			t.TX.add(iface.TX)
+		}
+	}
+	return t
+}
+
+// addBlockIO Add io to the absolute or the delta sum depending on its kind
+func addBlockIO(absolute, delta *BlockIO, io BlockIO) {
+	sum := delta
+	if io.Absolute {
+		sum = absolute
+	}
+	sum.Operations += io.Operations
+	sum.Bytes += io.Bytes
+	sum.Sectors += io.Sectors
+	sum.Errors += io.Errors
+}
+
+func (n *NetworkIO) add(io NetworkIO) {
+	n.Bytes += io.Bytes
+	n.Packets += io.Packets
+	n.Errors += io.Errors
+	n.Drops += io.Drops
+	n.FIFO += io.FIFO
+	n.Frame += io.Frame
+	n.Collisions += io.Collisions
+	n.Carrier += io.Carrier
+}
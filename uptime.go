@@ -0,0 +1,12 @@
+package driver
+
+import "time"
+
+// Uptime Time the domain had been running for when collected, 0 when its
+// StartTime is unknown
+func (d *Domain) Uptime() time.Duration {
+	if d.StartTime == 0 || d.Time < d.StartTime {
+		return 0
+	}
+	return d.Time.Sub(d.StartTime)
+}
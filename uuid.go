@@ -0,0 +1,74 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidUUID Test if s is a UUID NormalizeUUID accepts
+func ValidUUID(s string) bool {
+	_, err := NormalizeUUID(s)
+	return err == nil
+}
+
+// NormalizeUUID Canonical lowercase dashed 8-4-4-4-12 form of a UUID given
+// in either case, dashed or as 32 bare hex digits, optionally in braces or
+// prefixed with urn:uuid:. Anything else returns an error wrapping
+// ErrInvalidUUID.
+func NormalizeUUID(s string) (string, error) {
+	u := s
+	if len(u) >= 9 && strings.EqualFold(u[:9], "urn:uuid:") {
+		u = u[9:]
+	} else if len(u) >= 2 && u[0] == '{' && u[len(u)-1] == '}' {
+		u = u[1 : len(u)-1]
+	}
+
+	var hex []byte
+	switch len(u) {
+	case 32:
+		hex = []byte(u)
+	case 36:
+		hex = make([]byte, 0, 32)
+		for i := 0; i < len(u); i++ {
+			switch i {
+			case 8, 13, 18, 23:
+				if u[i] != '-' {
+					return s, fmt.Errorf("driver: %q: %w", s, ErrInvalidUUID)
+				}
+			default:
+				hex = append(hex, u[i])
+			}
+		}
+	default:
+		return s, fmt.Errorf("driver: %q: %w", s, ErrInvalidUUID)
+	}
+
+	for i, c := range hex {
+		if !isHex(c) {
+			return s, fmt.Errorf("driver: %q: %w", s, ErrInvalidUUID)
+		}
+		if 'A' <= c && c <= 'F' {
+			hex[i] = c + 'a' - 'A'
+		}
+	}
+	return string(hex[0:8]) + "-" + string(hex[8:12]) + "-" + string(hex[12:16]) + "-" + string(hex[16:20]) + "-" + string(hex[20:32]), nil
+}
+
+// NormalizeUUID Rewrite d.UUID in canonical form for drivers populating it.
+// An invalid UUID is left as is and reported by an error wrapping
+// ErrInvalidUUID, an empty one is left alone.
+func (d *Domain) NormalizeUUID() error {
+	if d.UUID == "" {
+		return nil
+	}
+	u, err := NormalizeUUID(d.UUID)
+	if err != nil {
+		return err
+	}
+	d.UUID = u
+	return nil
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
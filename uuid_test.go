@@ -0,0 +1,74 @@
+package driver_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/virtmonitor/driver"
+)
+
+func TestNormalizeUUID(t *testing.T) {
+	const canonical = "6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f"
+	valid := []string{
+		canonical,
+		"6F2D4C9E-8A1B-4C3D-9E5F-0A1B2C3D4E5F",
+		"6f2D4c9E-8a1B-4c3D-9e5F-0a1B2c3D4e5F",
+		"6f2d4c9e8a1b4c3d9e5f0a1b2c3d4e5f",
+		"6F2D4C9E8A1B4C3D9E5F0A1B2C3D4E5F",
+		"{6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f}",
+		"{6F2D4C9E-8A1B-4C3D-9E5F-0A1B2C3D4E5F}",
+		"{6f2d4c9e8a1b4c3d9e5f0a1b2c3d4e5f}",
+		"urn:uuid:6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f",
+		"URN:UUID:6F2D4C9E-8A1B-4C3D-9E5F-0A1B2C3D4E5F",
+		"urn:uuid:6f2d4c9e8a1b4c3d9e5f0a1b2c3d4e5f",
+	}
+	for _, s := range valid {
+		if got, err := driver.NormalizeUUID(s); got != canonical || err != nil {
+			t.Errorf("NormalizeUUID(%q) = %q, %v, want %q", s, got, err, canonical)
+		}
+		if !driver.ValidUUID(s) {
+			t.Errorf("ValidUUID(%q) = false", s)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"{}",
+		"6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5",
+		"6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f0",
+		"6f2d4c9e8a1b-4c3d-9e5f-0a1b2c3d4e5f",
+		"6f2d4c9e-8a1b4c3d-9e5f-0a1b2c3d4e5f-",
+		"6f2d4c9e_8a1b_4c3d_9e5f_0a1b2c3d4e5f",
+		"6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5g",
+		"6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f}",
+		"{6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f",
+		"(6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f)",
+		"urn:uuid:{6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f}",
+		"{urn:uuid:6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f}",
+		"uuid:6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f",
+		" 6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f",
+	}
+	for _, s := range invalid {
+		if got, err := driver.NormalizeUUID(s); got != s || !errors.Is(err, driver.ErrInvalidUUID) {
+			t.Errorf("NormalizeUUID(%q) = %q, %v, want it unchanged and ErrInvalidUUID", s, got, err)
+		}
+		if driver.ValidUUID(s) {
+			t.Errorf("ValidUUID(%q) = true", s)
+		}
+	}
+}
+
+func TestDomainNormalizeUUID(t *testing.T) {
+	d := &driver.Domain{UUID: "{6F2D4C9E-8A1B-4C3D-9E5F-0A1B2C3D4E5F}"}
+	if err := d.NormalizeUUID(); err != nil || d.UUID != "6f2d4c9e-8a1b-4c3d-9e5f-0a1b2c3d4e5f" {
+		t.Errorf("got %q, %v", d.UUID, err)
+	}
+	d = &driver.Domain{UUID: "not-a-uuid"}
+	if err := d.NormalizeUUID(); !errors.Is(err, driver.ErrInvalidUUID) || d.UUID != "not-a-uuid" {
+		t.Errorf("invalid UUID: got %q, %v", d.UUID, err)
+	}
+	d = &driver.Domain{}
+	if err := d.NormalizeUUID(); err != nil || d.UUID != "" {
+		t.Errorf("empty UUID: got %q, %v", d.UUID, err)
+	}
+}
@@ -0,0 +1,359 @@
+package virtualbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/internal/hvutil"
+)
+
+// statsPattern VMM statistics read from running VMs: vCPU time accounting
+// and the public storage and network counters
+const statsPattern = "/TM/CPU/*|/Public/Storage/*|/Public/NetAdapter/*"
+
+// Guest memory metrics, reported by the guest additions
+const (
+	metricRAMTotal   = "Guest/RAM/Usage/Total"
+	metricRAMFree    = "Guest/RAM/Usage/Free"
+	metricRAMBalloon = "Guest/RAM/Usage/Balloon"
+)
+
+const mib = 1 << 20
+
+// maxAdapters Network adapters a VM has, nic1 to nic8
+const maxAdapters = 8
+
+// controllerDevices Device names storage controllers have in the public
+// statistics by showvminfo storagecontrollertype. IDE controllers name
+// their units by LUN, two per port.
+var controllerDevices = map[string]string{
+	"IntelAhci":   "AHCI",
+	"PIIX3":       "IDE",
+	"PIIX4":       "IDE",
+	"ICH6":        "IDE",
+	"LsiLogic":    "LSILOGICSCSI",
+	"LsiLogicSas": "LSILOGICSAS",
+	"BusLogic":    "BUSLOGIC",
+	"NVMe":        "NVME",
+	"VirtioSCSI":  "VIRTIO-SCSI",
+}
+
+// controllerBuses Guest bus of the storage controller types
+var controllerBuses = map[string]string{
+	"IntelAhci":   "sata",
+	"PIIX3":       "ide",
+	"PIIX4":       "ide",
+	"ICH6":        "ide",
+	"LsiLogic":    "scsi",
+	"LsiLogicSas": "sas",
+	"BusLogic":    "scsi",
+	"NVMe":        "nvme",
+	"VirtioSCSI":  "virtio",
+	"I82078":      "fdc",
+	"USB":         "usb",
+}
+
+// collectVM Collect a VM into a domain of r, nil if opts.Filter excludes it
+// or it was unregistered meanwhile
+func (v *VirtualBox) collectVM(ctx context.Context, uuid string, opts driver.CollectOptions, r *driver.Recycler) (*driver.Domain, error) {
+	info, err := v.showVMInfo(ctx, uuid)
+	if errors.Is(err, errVMGone) {
+		driver.GetLogger().Debug("skipping unregistered VM", "driver", Hypervisor, "uuid", uuid)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := identity(info, r)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.Keep(d.Name, d.UUID, d.ID) {
+		return nil, nil
+	}
+	// VMs are registered until deleted
+	d.Persistent, d.PersistentSet = true, true
+	d.OSType = info["ostype"]
+	d.VCPUs, _ = strconv.Atoi(info["cpus"])
+	d.VCPUsMaximum = d.VCPUs
+	if opts.Skips(d.Flags) {
+		return d, nil
+	}
+	running := d.Flags == driver.DomainOnline || d.Flags == driver.DomainPaused ||
+		d.Flags.Migrating() || d.Flags == driver.DomainSaving || d.Flags == driver.DomainRestoring
+
+	var stats map[string]uint64
+	if running && (opts.CPUs || opts.Blocks || opts.Interfaces) {
+		stats, err = v.statistics(ctx, uuid, statsPattern)
+		switch {
+		case errors.Is(err, errNotRunning):
+			driver.GetLogger().Debug("VM stopped while collecting", "driver", Hypervisor, "domain", d.Name)
+			running = false
+		case err != nil:
+			return nil, err
+		}
+	}
+
+	if opts.CPUs && running {
+		d.VCPUsCurrent = d.VCPUs
+		d.Cpus = cpus(d.Cpus, d.VCPUs, d.Flags, stats)
+	}
+	if opts.Blocks {
+		d.Blocks = blocks(d.Blocks, info, stats)
+	}
+	if opts.Interfaces {
+		d.Interfaces = interfaces(d.Interfaces, info, stats)
+	}
+	if opts.Memory && running {
+		if err := v.collectMemory(ctx, uuid, info, d); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d, nil
+}
+
+// identity Domain of r of a VM with its identity and state only
+func identity(info map[string]string, r *driver.Recycler) (*driver.Domain, error) {
+	uuid, err := driver.NormalizeUUID(info["UUID"])
+	if err != nil {
+		return nil, fmt.Errorf("virtualbox: VM %q: %w", info["name"], driver.ErrInvalidUUID)
+	}
+	d := r.Domain(driver.HashDomainID(uuid))
+	d.Name, d.UUID, d.ID = info["name"], uuid, driver.HashDomainID(uuid)
+	d.Hypervisor, d.Time, d.Flags = Hypervisor, driver.TimestampNow(), domainFlag(info["VMState"])
+	return d, nil
+}
+
+// cpus vCPUs of a running VM appended to cpus emptied, with their execution
+// and halted time when the VMM accounts for them
+func cpus(cpus []driver.CPU, n int, flags driver.DomainFlag, stats map[string]uint64) []driver.CPU {
+	cpus = slices.Grow(cpus[:0], n)
+	for id := 0; id < n; id++ {
+		cpu := driver.CPU{ID: uint64(id), Flags: driver.CPUOnline}
+		if flags == driver.DomainPaused || flags == driver.DomainSaving || flags == driver.DomainRestoring {
+			cpu.Flags = driver.CPUPaused
+		}
+
+		prefix := fmt.Sprintf("/TM/CPU/%02d/", id)
+		if t, ok := stats[prefix+"cNsExecuting"]; ok {
+			cpu.Time = float64(t)
+		}
+		if t, ok := stats[prefix+"cNsHalted"]; ok {
+			cpu.Idle, cpu.IdleSet = float64(t), true
+		}
+		cpus = append(cpus, cpu)
+	}
+	return cpus
+}
+
+// blocks Attached images named after their controller and position
+// ("SATA-0-0"), empty drives aren't reported. Images with an .iso extension
+// are CD-ROMs. Counters are left zero for controller types without public
+// statistics. Appended to blocks emptied.
+func blocks(blocks []driver.BlockDevice, info map[string]string, stats map[string]uint64) []driver.BlockDevice {
+	blocks = blocks[:0]
+	for n := 0; ; n++ {
+		suffix := strconv.Itoa(n)
+		controller, ok := info["storagecontrollername"+suffix]
+		if !ok {
+			break
+		}
+		typ := info["storagecontrollertype"+suffix]
+		device, hasStats := controllerDevices[typ]
+		instance := info["storagecontrollerinstance"+suffix]
+
+		for key, path := range info {
+			port, unit, ok := attachment(key, controller)
+			if !ok || path == "none" || path == "emptydrive" || path == "" {
+				continue
+			}
+			block := driver.BlockDevice{
+				Name:   key,
+				Source: path,
+				Bus:    controllerBuses[typ],
+				IsDisk: !strings.EqualFold(filepath.Ext(path), ".iso"),
+			}
+			block.IsCDrom = !block.IsDisk
+			block.ReadOnly = block.IsCDrom
+
+			if hasStats {
+				lun := port
+				if device == "IDE" {
+					lun = port*2 + unit
+				}
+				prefix := "/Public/Storage/" + device + instance + "/Port" + strconv.Itoa(lun) + "/"
+				block.Read = driver.BlockIO{Bytes: stats[prefix+"BytesRead"], Absolute: true}
+				block.Write = driver.BlockIO{Bytes: stats[prefix+"BytesWritten"], Absolute: true}
+			}
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// attachment Port and device of a "<controller>-<port>-<device>" attachment
+// key of controller
+func attachment(key, controller string) (port, device int, ok bool) {
+	rest, found := strings.CutPrefix(key, controller+"-")
+	if !found {
+		return 0, 0, false
+	}
+	p, d, found := strings.Cut(rest, "-")
+	if !found {
+		return 0, 0, false
+	}
+	port, err1 := strconv.Atoi(p)
+	device, err2 := strconv.Atoi(d)
+	return port, device, err1 == nil && err2 == nil
+}
+
+// interfaces Network adapters in use, named after their slot ("nic1"),
+// appended to ifaces emptied
+func interfaces(ifaces []driver.NetworkInterface, info map[string]string, stats map[string]uint64) []driver.NetworkInterface {
+	ifaces = ifaces[:0]
+	for n := 1; n <= maxAdapters; n++ {
+		slot := strconv.Itoa(n)
+		attached, ok := info["nic"+slot]
+		if !ok || attached == "none" {
+			continue
+		}
+
+		iface := driver.NetworkInterface{Name: "nic" + slot}
+		if mac, err := hvutil.ParseMAC(info["macaddress"+slot]); err == nil {
+			iface.Mac = mac
+		}
+		if attached == "bridged" && info["bridgeadapter"+slot] != "" {
+			iface.Bridges = []string{info["bridgeadapter"+slot]}
+		}
+		if cable, ok := info["cableconnected"+slot]; ok {
+			iface.LinkUp, iface.LinkUpSet = cable == "on", true
+		}
+
+		// Adapters are numbered from 0 in the statistics, received is
+		// traffic to the guest
+		prefix := "/Public/NetAdapter/" + strconv.Itoa(n-1) + "/"
+		iface.RX.Bytes = stats[prefix+"BytesReceived"]
+		iface.TX.Bytes = stats[prefix+"BytesTransmitted"]
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces
+}
+
+// collectMemory Memory of a running VM, its configured size and, with the
+// guest additions reporting, what the guest sees of it
+func (v *VirtualBox) collectMemory(ctx context.Context, uuid string, info map[string]string, d *driver.Domain) error {
+	if size, err := strconv.ParseUint(info["memory"], 10, 64); err == nil {
+		d.Memory.Actual, d.Memory.ActualSet = size*mib, true
+	}
+
+	metrics, err := v.metrics(ctx, uuid, metricRAMTotal, metricRAMFree, metricRAMBalloon)
+	if err != nil {
+		driver.GetLogger().Debug("guest memory metrics unavailable", "driver", Hypervisor, "domain", d.Name, "error", err)
+		return nil
+	}
+	if total, ok := metrics[metricRAMTotal]; ok {
+		d.Memory.Available, d.Memory.AvailableSet = total, true
+	}
+	if free, ok := metrics[metricRAMFree]; ok {
+		d.Memory.Unused, d.Memory.UnusedSet = free, true
+	}
+	if balloon, ok := metrics[metricRAMBalloon]; ok {
+		d.MemoryBacking.BalloonCurrent = d.Memory.Actual - min(balloon, d.Memory.Actual)
+	}
+	return nil
+}
+
+// snapshots Snapshot tree of showvminfo. Snapshots are keyed by their path
+// from the root, "SnapshotName" for the root and "SnapshotName-1-2" for the
+// second child of its first child.
+func snapshots(info map[string]string) []driver.Snapshot {
+	var paths []string
+	for key := range info {
+		if path, ok := strings.CutPrefix(key, "SnapshotName"); ok && snapshotPath(path) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return lessPath(paths[i], paths[j]) })
+
+	current := info["CurrentSnapshotUUID"]
+	out := make([]driver.Snapshot, 0, len(paths))
+	for _, path := range paths {
+		s := driver.Snapshot{
+			Name:      info["SnapshotName"+path],
+			IsCurrent: current != "" && info["SnapshotUUID"+path] == current,
+		}
+		if i := strings.LastIndexByte(path, '-'); i >= 0 {
+			s.Parent = info["SnapshotName"+path[:i]]
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// snapshotPath Whether path is a snapshot tree path, "" or "-<n>" repeated
+func snapshotPath(path string) bool {
+	if path == "" {
+		return true
+	}
+	parts := strings.Split(path, "-")
+	if parts[0] != "" {
+		return false
+	}
+	for _, n := range parts[1:] {
+		if _, err := strconv.Atoi(n); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// lessPath Order snapshot tree paths depth first, parents before children
+func lessPath(a, b string) bool {
+	as, bs := strings.Split(a, "-"), strings.Split(b, "-")
+	for i := 1; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			return x < y
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// domainFlag Map a showvminfo VMState onto a DomainFlag. Teleporting is
+// VirtualBox live migration, a saved VM is off.
+func domainFlag(state string) driver.DomainFlag {
+	switch state {
+	case "running", "starting", "livesnapshotting", "onlinesnapshotting", "deletingsnapshotlive":
+		return driver.DomainOnline
+	case "teleporting", "teleportingpausedvm":
+		return driver.DomainMigratingOut
+	case "teleportingin":
+		return driver.DomainMigratingIn
+	case "saving":
+		return driver.DomainSaving
+	case "restoring":
+		return driver.DomainRestoring
+	case "paused", "deletingsnapshotlivepaused":
+		return driver.DomainPaused
+	case "stopping":
+		return driver.DomainDying
+	case "aborted", "aborted-saved", "gurumeditation", "stuck":
+		return driver.DomainCrashed
+	default:
+		return driver.DomainShutdown
+	}
+}
@@ -0,0 +1,275 @@
+package virtualbox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/virtmonitor/driver"
+)
+
+var (
+	// errVMGone The VM was unregistered while being collected
+	errVMGone = errors.New("virtualbox: VM gone")
+	// errNotRunning The VM stopped before its statistics were read
+	errNotRunning = errors.New("virtualbox: VM not running")
+)
+
+// vmEntry A VM of list vms
+type vmEntry struct {
+	name string
+	uuid string
+}
+
+// id Domain ID of the VM, hashed from its UUID
+func (vm vmEntry) id() driver.DomainID {
+	return driver.HashDomainID(vm.uuid)
+}
+
+// vms Registered VMs, inaccessible ones included
+func (v *VirtualBox) vms(ctx context.Context) ([]vmEntry, error) {
+	out, err := v.run(ctx, "list", "vms")
+	if err != nil {
+		return nil, err
+	}
+	return parseList(out), nil
+}
+
+// showVMInfo Settings and state of a VM
+func (v *VirtualBox) showVMInfo(ctx context.Context, uuid string) (map[string]string, error) {
+	out, err := v.run(ctx, "showvminfo", uuid, "--machinereadable")
+	if err != nil {
+		return nil, err
+	}
+	return parseMachineReadable(out), nil
+}
+
+// statistics VMM statistics of a running VM matching pattern, a "|"
+// separated list of "*" globs
+func (v *VirtualBox) statistics(ctx context.Context, uuid, pattern string) (map[string]uint64, error) {
+	out, err := v.run(ctx, "debugvm", uuid, "statistics", "--pattern", pattern)
+	if err != nil {
+		return nil, err
+	}
+	return parseStatistics(out)
+}
+
+// metrics Latest value of the metrics of a VM, sizes in bytes. Metrics VBoxSVC
+// doesn't collect are missing.
+func (v *VirtualBox) metrics(ctx context.Context, uuid string, names ...string) (map[string]uint64, error) {
+	out, err := v.run(ctx, "metrics", "query", uuid, strings.Join(names, ","))
+	if err != nil {
+		return nil, err
+	}
+	return parseMetrics(out, names), nil
+}
+
+// run Run VBoxManage
+func (v *VirtualBox) run(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, v.vboxmanage, append([]string{"-q"}, args...)...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	switch {
+	case err == nil:
+		return out, nil
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	case errors.Is(err, exec.ErrNotFound):
+		return nil, fmt.Errorf("virtualbox: %w: %w", driver.ErrHypervisorUnavailable, err)
+	}
+
+	msg := strings.TrimSpace(stderr.String())
+	switch {
+	case strings.Contains(msg, "VBOX_E_OBJECT_NOT_FOUND"), strings.Contains(msg, "Could not find a registered machine"):
+		return nil, errVMGone
+	case strings.Contains(msg, "is not currently running"), strings.Contains(msg, "VBOX_E_INVALID_VM_STATE"):
+		return nil, errNotRunning
+	case strings.Contains(msg, "E_ACCESSDENIED"), strings.Contains(msg, "Permission denied"):
+		return nil, fmt.Errorf("virtualbox: %w: %s", driver.ErrPermissionDenied, msg)
+	case strings.Contains(msg, "Failed to create the VirtualBox object"), strings.Contains(msg, "VBoxSVC"):
+		return nil, fmt.Errorf("virtualbox: %w: %s", driver.ErrHypervisorUnavailable, msg)
+	}
+	return nil, fmt.Errorf("virtualbox: VBoxManage %s: %w: %s", strings.Join(args, " "), err, msg)
+}
+
+// parseList Parse list vms, one `"<name>" {<uuid>}` line per VM. Names may
+// contain quotes and braces, the UUID is taken from the end of the line.
+// Lines without a valid UUID are skipped.
+func parseList(out []byte) []vmEntry {
+	var vms []vmEntry
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		i := strings.LastIndex(line, "{")
+		if i < 0 || !strings.HasSuffix(line, "}") {
+			continue
+		}
+		uuid, err := driver.NormalizeUUID(line[i+1 : len(line)-1])
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSpace(line[:i])
+		if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+			name = name[1 : len(name)-1]
+		}
+		vms = append(vms, vmEntry{name: name, uuid: uuid})
+	}
+	return vms
+}
+
+// parseMachineReadable Parse --machinereadable output, one key=value pair
+// per line. Keys and values are either bare or double quoted, quoted ones
+// may span lines and, from VirtualBox 6.1, escape quotes and backslashes.
+// Lines that aren't pairs are skipped.
+func parseMachineReadable(out []byte) map[string]string {
+	info := make(map[string]string)
+	s := strings.ReplaceAll(string(out), "\r\n", "\n")
+	for s != "" {
+		var key, value string
+		key, s = token(s, "=\n")
+		if s == "" || s[0] != '=' {
+			s = skipLine(s)
+			continue
+		}
+		value, s = token(s[1:], "\n")
+		if key != "" {
+			info[key] = value
+		}
+		s = skipLine(s)
+	}
+	return info
+}
+
+// token Read a quoted string or the text up to one of the stop bytes,
+// returning it along with the rest of s
+func token(s, stop string) (string, string) {
+	if s == "" || s[0] != '"' {
+		i := strings.IndexAny(s, stop)
+		if i < 0 {
+			return strings.TrimSpace(s), ""
+		}
+		return strings.TrimSpace(s[:i]), s[i:]
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\'):
+			i++
+			b.WriteByte(s[i])
+		case c == '"':
+			return b.String(), s[i+1:]
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), ""
+}
+
+// skipLine Rest of s after the end of its first line
+func skipLine(s string) string {
+	i := strings.IndexByte(s, '\n')
+	if i < 0 {
+		return ""
+	}
+	return s[i+1:]
+}
+
+// parseStatistics Parse the XML of debugvm statistics, one element per
+// statistic named by its name attribute. Counters hold their value in c,
+// other types in val. Statistics without a single integer value, such as
+// profiles, are skipped.
+func parseStatistics(out []byte) (map[string]uint64, error) {
+	stats := make(map[string]uint64)
+	dec := xml.NewDecoder(bytes.NewReader(out))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return stats, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("virtualbox: parsing statistics: %w", err)
+		}
+
+		elem, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		var name, value string
+		for _, attr := range elem.Attr {
+			switch attr.Name.Local {
+			case "name":
+				name = attr.Value
+			case "c", "val":
+				value = attr.Value
+			}
+		}
+		if name == "" || value == "" {
+			continue
+		}
+		if n, err := strconv.ParseUint(strings.TrimSpace(value), 0, 64); err == nil {
+			stats[name] = n
+		}
+	}
+}
+
+// parseMetrics Parse metrics query, a table of object, metric and values
+// with one line per metric. Objects may contain spaces, lines are matched
+// on the metric names asked for, which may carry an aggregate suffix
+// (":avg"). Values are comma separated samples, the last one is kept.
+// Aggregates are only used for metrics without a line of samples.
+func parseMetrics(out []byte, names []string) map[string]uint64 {
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		want[name] = true
+	}
+
+	metrics := make(map[string]uint64)
+	sampled := make(map[string]bool)
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		for i, f := range fields {
+			name, _, aggregate := strings.Cut(f, ":")
+			if !want[name] {
+				continue
+			}
+			if aggregate && sampled[name] {
+				break
+			}
+			values := strings.Split(strings.Join(fields[i+1:], " "), ",")
+			if v, ok := parseSize(values[len(values)-1]); ok {
+				metrics[name] = v
+				sampled[name] = !aggregate
+			}
+			break
+		}
+	}
+	return metrics
+}
+
+// parseSize Parse a metric value such as "1024 kB" into bytes, values
+// without a unit as they are
+func parseSize(s string) (uint64, bool) {
+	number, unit, _ := strings.Cut(strings.TrimSpace(s), " ")
+	n, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch unit {
+	case "kB":
+		n *= 1024
+	case "MB":
+		n *= 1024 * 1024
+	}
+	return n, true
+}
@@ -0,0 +1,183 @@
+package virtualbox
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/virtmonitor/driver"
+	"github.com/virtmonitor/driver/internal/hvutil"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	out, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestParseMachineReadable(t *testing.T) {
+	tests := []struct {
+		version string
+		// info Values expected of the keys listed
+		info map[string]string
+		// stats VMM statistics the devices are collected with
+		stats     map[string]uint64
+		blocks    []driver.BlockDevice
+		ifaces    []driver.NetworkInterface
+		snapshots []driver.Snapshot
+	}{{
+		version: "5.2",
+		info: map[string]string{
+			"name":                   "ci-runner",
+			"UUID":                   "0b6a1c8e-2f4d-4a7b-9c1e-5d3f2a1b0c9d",
+			"VMState":                "poweroff",
+			"cpus":                   "1",
+			"memory":                 "1024",
+			"ostype":                 "Debian (64-bit)",
+			"description":            "Runs the nightly builds",
+			"storagecontrollertype1": "IntelAhci",
+		},
+		blocks: []driver.BlockDevice{
+			{Name: "SATA-0-0", Source: "/srv/vbox/ci-runner/ci-runner.vdi", Bus: "sata", IsDisk: true, Read: bytesIO(0), Write: bytesIO(0)},
+		},
+		ifaces: []driver.NetworkInterface{
+			{Name: "nic1", Mac: mustMAC("08:00:27:1a:2b:3c"), LinkUp: true, LinkUpSet: true},
+		},
+	}, {
+		version: "6.1",
+		info: map[string]string{
+			"name":        `web "blue"`,
+			"UUID":        "3f1e6c2a-9b7d-4e21-8c55-1a2b3c4d5e6f",
+			"VMState":     "running",
+			"cpus":        "2",
+			"memory":      "2048",
+			"description": "Public web server\nserves \"example.org\" from C:\\www",
+			"VideoMode":   "800,600,32",
+			"GuestAdditionsFacility_VirtualBox Base Driver": "50,1683191563355",
+		},
+		// IDE units are numbered by LUN, two per port, adapters from 0
+		stats: map[string]uint64{
+			"/Public/Storage/IDE0/Port2/BytesRead":     1 << 20,
+			"/Public/Storage/AHCI0/Port1/BytesWritten": 4096,
+			"/Public/NetAdapter/1/BytesReceived":       1500,
+		},
+		blocks: []driver.BlockDevice{
+			{Name: "IDE-1-0", Source: "/usr/share/virtualbox/VBoxGuestAdditions.iso", Bus: "ide", IsCDrom: true, ReadOnly: true, Read: bytesIO(1 << 20), Write: bytesIO(0)},
+			{Name: "SATA-0-0", Source: `/home/ops/VirtualBox VMs/web "blue"/web "blue".vdi`, Bus: "sata", IsDisk: true, Read: bytesIO(0), Write: bytesIO(0)},
+			{Name: "SATA-1-0", Source: `/home/ops/VirtualBox VMs/web "blue"/data.vdi`, Bus: "sata", IsDisk: true, Read: bytesIO(0), Write: bytesIO(4096)},
+		},
+		ifaces: []driver.NetworkInterface{
+			{Name: "nic1", Mac: mustMAC("08:00:27:a1:b2:c3"), LinkUp: true, LinkUpSet: true},
+			{Name: "nic2", Mac: mustMAC("08:00:27:d4:e5:f6"), Bridges: []string{"enp3s0"}, LinkUpSet: true, RX: driver.NetworkIO{Bytes: 1500}},
+		},
+		snapshots: []driver.Snapshot{
+			{Name: "base"},
+			{Name: "after update", Parent: "base", IsCurrent: true},
+		},
+	}, {
+		// Windows host: CRLF line endings and escaped backslashes
+		version: "7.0",
+		info: map[string]string{
+			"name":               "build",
+			"UUID":               "a1b2c3d4-e5f6-4708-9a1b-2c3d4e5f6a7b",
+			"VMState":            "paused",
+			"cpus":               "4",
+			"memory":             "8192",
+			"CfgFile":            `C:\Users\ops\VirtualBox VMs\build\build.vbox`,
+			"BIOS NVRAM File":    `C:\Users\ops\VirtualBox VMs\build\build.nvram`,
+			"GuestMemoryBalloon": "1024",
+		},
+		blocks: []driver.BlockDevice{
+			{Name: "NVMe-0-0", Source: `C:\Users\ops\VirtualBox VMs\build\build.vdi`, Bus: "nvme", IsDisk: true, Read: bytesIO(0), Write: bytesIO(0)},
+			{Name: "SATA-0-0", Source: `C:\ISO\Win10_22H2.ISO`, Bus: "sata", IsCDrom: true, ReadOnly: true, Read: bytesIO(0), Write: bytesIO(0)},
+		},
+		ifaces: []driver.NetworkInterface{
+			{Name: "nic1", Mac: mustMAC("08:00:27:9f:8e:7d"), LinkUp: true, LinkUpSet: true},
+			{Name: "nic2", Mac: mustMAC("08:00:27:6c:5b:4a"), LinkUp: true, LinkUpSet: true},
+		},
+		snapshots: []driver.Snapshot{
+			{Name: "clean install"},
+			{Name: "toolchain", Parent: "clean install"},
+			{Name: "msvc 17.8", Parent: "toolchain", IsCurrent: true},
+			{Name: "drivers", Parent: "clean install"},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			info := parseMachineReadable(readFixture(t, "showvminfo-"+tt.version+".txt"))
+			for key, want := range tt.info {
+				if got, ok := info[key]; got != want || !ok {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+			for key, value := range info {
+				if strings.ContainsAny(key, "\r\n") || strings.Contains(value, "\r") {
+					t.Errorf("line ending left in %q=%q", key, value)
+				}
+			}
+
+			blocks := blocks(nil, info, tt.stats)
+			slices.SortFunc(blocks, func(a, b driver.BlockDevice) int { return strings.Compare(a.Name, b.Name) })
+			if !reflect.DeepEqual(blocks, tt.blocks) {
+				t.Errorf("blocks = %+v, want %+v", blocks, tt.blocks)
+			}
+			if ifaces := interfaces(nil, info, tt.stats); !reflect.DeepEqual(ifaces, tt.ifaces) {
+				t.Errorf("interfaces = %+v, want %+v", ifaces, tt.ifaces)
+			}
+			if snapshots := snapshots(info); !reflect.DeepEqual(snapshots, append([]driver.Snapshot{}, tt.snapshots...)) {
+				t.Errorf("snapshots = %+v, want %+v", snapshots, tt.snapshots)
+			}
+
+			d, err := identity(info, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Name != tt.info["name"] || d.UUID != tt.info["UUID"] || d.ID != driver.HashDomainID(d.UUID) {
+				t.Errorf("identity = %q %q %d", d.Name, d.UUID, d.ID)
+			}
+		})
+	}
+}
+
+func TestParseMetrics(t *testing.T) {
+	names := []string{metricRAMTotal, metricRAMFree, metricRAMBalloon}
+	tests := []struct {
+		version string
+		want    map[string]uint64
+	}{
+		// Several samples per metric, the last one is kept
+		{"5.2", map[string]uint64{metricRAMTotal: 1016284 << 10, metricRAMFree: 801556 << 10, metricRAMBalloon: 0}},
+		// Object names with spaces and quotes, aggregates along with the
+		// samples; the balloon isn't reported
+		{"6.1", map[string]uint64{metricRAMTotal: 2035856 << 10, metricRAMFree: 1287064 << 10}},
+		// CRLF line endings, sizes in MB
+		{"7.0", map[string]uint64{metricRAMTotal: 7168 << 20, metricRAMFree: 3120 << 20, metricRAMBalloon: 1024 << 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := parseMetrics(readFixture(t, "metrics-"+tt.version+".txt"), names); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMetrics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// bytesIO Block IO of the public statistics, which only count bytes
+func bytesIO(n uint64) driver.BlockIO {
+	return driver.BlockIO{Bytes: n, Absolute: true}
+}
+
+func mustMAC(s string) net.HardwareAddr {
+	mac, err := hvutil.ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return mac
+}
@@ -0,0 +1,245 @@
+// Package virtualbox Driver collecting VirtualBox virtual machines through
+// VBoxManage.
+//
+// Every registered VM is collected from showvminfo --machinereadable, which
+// covers its state, vCPUs, memory, storage attachments and network adapters.
+// Counters of running VMs come from the statistics of their VMM, read with
+// debugvm statistics: vCPU execution and halted time, and the bytes moved by
+// every disk and adapter. Guest memory usage comes from metrics query, which
+// needs the guest additions and VBoxSVC collecting metrics, enabled with
+// "VBoxManage metrics setup". The web service isn't used.
+//
+// VirtualBox has no numeric IDs, domain IDs are hashed from the VM UUID.
+// VMs are those of the user running VBoxManage. Importing the package
+// registers the driver under the name "virtualbox" using DefaultVBoxManage;
+// driver.NewDriver takes the vboxmanage key.
+package virtualbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"github.com/virtmonitor/driver"
+)
+
+const (
+	// Hypervisor Hypervisor name reported by the VirtualBox driver
+	Hypervisor driver.DomainHypervisor = "virtualbox"
+	// DefaultVBoxManage Default VBoxManage command, looked up in PATH
+	DefaultVBoxManage = "VBoxManage"
+)
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New(DefaultVBoxManage)); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		Keys: []string{"vboxmanage"},
+		New: func(cfg driver.Config) (driver.Driver, error) {
+			return New(cfg.String("vboxmanage", DefaultVBoxManage)), nil
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// VirtualBox VirtualBox driver
+type VirtualBox struct {
+	vboxmanage string
+}
+
+// New Create a VirtualBox driver running the vboxmanage command
+func New(vboxmanage string) *VirtualBox {
+	return &VirtualBox{vboxmanage: vboxmanage}
+}
+
+// Name Hypervisor name
+func (v *VirtualBox) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Capabilities Supported metrics. VMM statistics count bytes only, there
+// are no operation counts or latency.
+func (v *VirtualBox) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:       true,
+		SupportsBlocks:     true,
+		SupportsInterfaces: true,
+		SupportsMemory:     true,
+		SupportsSnapshots:  true,
+	}
+}
+
+// Detect Test if VBoxManage is present and VBoxSVC answers
+func (v *VirtualBox) Detect() bool {
+	return v.Diagnose().Detected
+}
+
+// Diagnose Tell a host without VBoxManage from one where VBoxSVC doesn't
+// start, counting the VMs when both work
+func (v *VirtualBox) Diagnose() driver.DetectResult {
+	if _, err := exec.LookPath(v.vboxmanage); err != nil {
+		return driver.DetectResult{Reason: v.vboxmanage + " not found", Err: fmt.Errorf("virtualbox: %w: %w", driver.ErrHypervisorUnavailable, err)}
+	}
+
+	vms, err := v.vms(context.Background())
+	switch {
+	case err != nil:
+		return driver.DetectResult{Reason: "listing VMs failed: " + err.Error(), Err: err}
+	case len(vms) == 0:
+		return driver.DetectResult{Detected: true, Reason: "VirtualBox installed but no VMs"}
+	}
+	return driver.DetectResult{Detected: true, Reason: fmt.Sprintf("%d VMs", len(vms))}
+}
+
+// Collect Collect VMs
+func (v *VirtualBox) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return v.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect every registered VM, stopped ones with
+// CollectOptions.IncludeInactive, killing the running VBoxManage commands
+// when ctx is done
+func (v *VirtualBox) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	vms, err := driver.Enumerate(ctx, opts, v.vms)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.CollectDomains(ctx, opts, vms, v.collector(opts, nil))
+}
+
+// CollectInto Collect every registered VM as CollectContext does into dst,
+// reusing its domains, see driver.CollectInto
+func (v *VirtualBox) CollectInto(ctx context.Context, dst map[driver.DomainID]*driver.Domain, opts driver.CollectOptions) error {
+	vms, err := driver.Enumerate(ctx, opts, v.vms)
+	if err != nil {
+		clear(dst)
+		return err
+	}
+
+	return driver.CollectDomainsInto(ctx, opts, vms, dst, func(ctx context.Context, vm vmEntry, r *driver.Recycler) (*driver.Domain, error) {
+		return v.collector(opts, r)(ctx, vm)
+	})
+}
+
+// CollectStream Collect every VM as CollectContext does, emitting each as
+// soon as it's collected
+func (v *VirtualBox) CollectStream(ctx context.Context, opts driver.CollectOptions, emit func(*driver.Domain) error) error {
+	vms, err := driver.Enumerate(ctx, opts, v.vms)
+	if err != nil {
+		return err
+	}
+
+	return driver.StreamDomains(ctx, opts, vms, v.collector(opts, nil), emit)
+}
+
+// collector Collect function of the VMs listed by CollectContext,
+// CollectInto and CollectStream, filling the domains of r
+func (v *VirtualBox) collector(opts driver.CollectOptions, r *driver.Recycler) func(context.Context, vmEntry) (*driver.Domain, error) {
+	return func(ctx context.Context, vm vmEntry) (*driver.Domain, error) {
+		if !opts.Keep(vm.name, vm.uuid, vm.id()) {
+			return nil, nil
+		}
+		d, err := v.collectVM(ctx, vm.uuid, opts, r)
+		if err != nil {
+			return nil, &driver.DomainError{ID: vm.id(), Name: vm.name, Err: err}
+		}
+		return d, nil
+	}
+}
+
+// CollectDomain Collect a single VM by ID
+func (v *VirtualBox) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	return v.find(opts, func(vm vmEntry) bool { return vm.id() == id }, fmt.Sprintf("%d", id))
+}
+
+// CollectDomainByUUID Collect a single VM by UUID, which VBoxManage looks up
+func (v *VirtualBox) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	u, err := driver.NormalizeUUID(uuid)
+	if err != nil {
+		return nil, fmt.Errorf("virtualbox: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+	opts = opts.Unfiltered()
+	return v.collect(u, opts, uuid)
+}
+
+// CollectDomainByName Collect a single VM by name, the first one listed if
+// several share it
+func (v *VirtualBox) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	return v.find(opts, func(vm vmEntry) bool { return vm.name == name }, fmt.Sprintf("%q", name))
+}
+
+// find Collect the first listed VM accepted by match, what names it in
+// errors
+func (v *VirtualBox) find(opts driver.CollectOptions, match func(vmEntry) bool, what string) (*driver.Domain, error) {
+	vms, err := v.vms(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	opts = opts.Unfiltered()
+	for _, vm := range vms {
+		if match(vm) {
+			return v.collect(vm.uuid, opts, what)
+		}
+	}
+	return nil, fmt.Errorf("virtualbox: domain %s: %w", what, driver.ErrDomainNotFound)
+}
+
+// collect Collect a single VM, a VM unregistered since it was found isn't
+// found
+func (v *VirtualBox) collect(uuid string, opts driver.CollectOptions, what string) (*driver.Domain, error) {
+	d, err := v.collectVM(context.Background(), uuid, opts, nil)
+	if errors.Is(err, errVMGone) {
+		err = fmt.Errorf("virtualbox: domain %s: %w", what, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// CollectSnapshots List the snapshots of a VM from its snapshot tree. The
+// tree carries no creation times, they are left zero.
+func (v *VirtualBox) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	vms, err := v.vms(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vm := range vms {
+		if vm.id() != id {
+			continue
+		}
+		info, err := v.showVMInfo(context.Background(), vm.uuid)
+		if errors.Is(err, errVMGone) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		return snapshots(info), nil
+	}
+	return nil, fmt.Errorf("virtualbox: domain %d: %w", id, driver.ErrDomainNotFound)
+}
+
+// Host Metrics of the local host, which runs the VMs
+func (v *VirtualBox) Host() (*driver.HostInfo, error) {
+	return driver.LocalHostInfo()
+}
+
+// Ping List the VMs, the cheapest call answered by VBoxSVC
+func (v *VirtualBox) Ping(ctx context.Context) error {
+	_, err := v.vms(ctx)
+	return err
+}
+
+// Watch VirtualBox events need the API, which isn't used
+func (v *VirtualBox) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	return nil, fmt.Errorf("virtualbox: watch: %w", driver.ErrNotSupported)
+}
+
+// Close Nothing to release, VBoxManage runs per query
+func (v *VirtualBox) Close() error {
+	return nil
+}
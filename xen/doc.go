@@ -0,0 +1,12 @@
+// Package xen Driver collecting Xen domains through libxenstat and xenstore.
+//
+// Statistics come from libxenstat, which needs cgo and the Xen tools
+// libraries, so the driver is only compiled with the xen build tag:
+//
+//	go build -tags xen
+//
+// Domain UUIDs and device details are read from xenstore, which is spoken
+// to directly over the xenstored socket or the xenbus device. Dom0 is
+// reported with ID 0. Importing the package registers the driver under the
+// name "xen"; driver.NewDriver takes no key.
+package xen
@@ -0,0 +1,245 @@
+//go:build xen
+
+package xen
+
+/*
+#include <xenstat.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/virtmonitor/driver"
+)
+
+// vbdBackends xenstore backend directories of block devices, blkback
+// first then the QEMU and tapdisk3 backends
+var vbdBackends = []string{"vbd", "qdisk", "vbd3"}
+
+// collectDomain Build a domain from its xenstat entry, which is only valid
+// until its node is freed. xenstore supplies what libxenstat lacks. nil if
+// opts.Filter excludes it.
+func (x *Xen) collectDomain(dom *C.xenstat_domain, opts driver.CollectOptions) *driver.Domain {
+	id := uint(C.xenstat_domain_id(dom))
+	d := &driver.Domain{
+		Name:       C.GoString(C.xenstat_domain_name(dom)),
+		ID:         driver.DomainID(id),
+		Hypervisor: Hypervisor,
+		Time:       driver.TimestampNow(),
+		Flags:      domainFlag(dom),
+		VCPUs:      int(C.xenstat_domain_num_vcpus(dom)),
+	}
+
+	// The vm path ends in the UUID, /vm/<uuid>
+	if vm := x.read(fmt.Sprintf("/local/domain/%d/vm", id)); vm != "" {
+		d.UUID = path.Base(vm)
+		if err := d.NormalizeUUID(); err != nil {
+			driver.GetLogger().Warn("domain reports an invalid UUID", "driver", Hypervisor, "domain", d.Name, "error", err)
+		}
+	}
+	d.OSType = x.read(fmt.Sprintf("/libxl/%d/type", id))
+
+	if !opts.Keep(d.Name, d.UUID, d.ID) {
+		return nil
+	}
+	if opts.Skips(d.Flags) {
+		return d
+	}
+
+	if opts.CPUs {
+		d.Cpus = collectCPUs(dom, d.Flags)
+		d.VCPUsCurrent, d.VCPUsMaximum = onlineVCPUs(dom), d.VCPUs
+	}
+	if opts.Blocks {
+		d.Blocks = x.collectBlocks(id, vbds(dom), opts.BlockCapacity)
+	}
+	if opts.Interfaces {
+		d.Interfaces = x.collectInterfaces(id, vifs(dom), opts.Limits)
+	}
+	if opts.HostDevices {
+		d.HostDevices = x.collectHostDevices(id)
+	}
+	if opts.Memory {
+		d.Memory.Actual, d.Memory.ActualSet = uint64(C.xenstat_domain_cur_mem(dom)), true
+		d.MemoryBacking.BalloonCurrent = d.Memory.Actual
+		d.MemoryBacking.BalloonMaximum = uint64(C.xenstat_domain_max_mem(dom))
+	}
+	if opts.TopologyOnly {
+		d.ClearCounters()
+	}
+	d.SortDevices()
+	return d
+}
+
+// domainFlag Map the xenstat domain state bits onto a DomainFlag, a domain
+// neither running nor blocked is merely between scheduling
+func domainFlag(dom *C.xenstat_domain) driver.DomainFlag {
+	switch {
+	case C.xenstat_domain_crashed(dom) != 0:
+		return driver.DomainCrashed
+	case C.xenstat_domain_dying(dom) != 0:
+		return driver.DomainDying
+	case C.xenstat_domain_shutdown(dom) != 0:
+		return driver.DomainShutdown
+	case C.xenstat_domain_paused(dom) != 0:
+		return driver.DomainPaused
+	default:
+		return driver.DomainOnline
+	}
+}
+
+func collectCPUs(dom *C.xenstat_domain, flags driver.DomainFlag) []driver.CPU {
+	n := C.xenstat_domain_num_vcpus(dom)
+	cpus := make([]driver.CPU, 0, int(n))
+	for i := C.uint(0); i < n; i++ {
+		vcpu := C.xenstat_domain_vcpu(dom, i)
+		if vcpu == nil {
+			continue
+		}
+
+		// xenstat only reports whether a vCPU is online
+		cpu := driver.CPU{
+			ID:    uint64(i),
+			Flags: driver.CPUOnline,
+			Time:  float64(C.xenstat_vcpu_ns(vcpu)),
+		}
+		if flags == driver.DomainPaused || C.xenstat_vcpu_online(vcpu) == 0 {
+			cpu.Flags = driver.CPUPaused
+		}
+		cpus = append(cpus, cpu)
+	}
+	return cpus
+}
+
+// onlineVCPUs Number of vCPUs online, out of the maximum xenstat reports as
+// the domain's vCPUs
+func onlineVCPUs(dom *C.xenstat_domain) int {
+	online := 0
+	n := C.xenstat_domain_num_vcpus(dom)
+	for i := C.uint(0); i < n; i++ {
+		if vcpu := C.xenstat_domain_vcpu(dom, i); vcpu != nil && C.xenstat_vcpu_online(vcpu) != 0 {
+			online++
+		}
+	}
+	return online
+}
+
+// collectBlocks Block devices named after their frontend device as
+// recorded by the backend
+func (x *Xen) collectBlocks(id uint, vbds []vbd, capacity bool) []driver.BlockDevice {
+	blocks := make([]driver.BlockDevice, 0, len(vbds))
+	for _, v := range vbds {
+		block := driver.BlockDevice{
+			Name:   strconv.FormatUint(uint64(v.dev), 10),
+			IsDisk: true,
+			Read:   blockIO(v.readReqs, v.readSectors),
+			Write:  blockIO(v.writeReqs, v.writeSectors),
+			Flush:  blockIO(0, 0),
+		}
+
+		for _, backend := range vbdBackends {
+			dir := fmt.Sprintf("/local/domain/0/backend/%s/%d/%d", backend, id, v.dev)
+			dev := x.read(dir + "/dev")
+			if dev == "" {
+				continue
+			}
+			block.Name, block.Target, block.Bus = dev, dev, "xen"
+			block.Source = x.read(dir + "/params")
+			block.ReadOnly = x.read(dir+"/mode") == "r"
+			if x.read(dir+"/device-type") == "cdrom" {
+				block.IsDisk, block.IsCDrom = false, true
+			}
+			if capacity {
+				sectors, _ := strconv.ParseUint(x.read(dir+"/sectors"), 10, 64)
+				size, _ := strconv.ParseUint(x.read(dir+"/sector-size"), 10, 64)
+				block.Capacity = sectors * size
+			}
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// vifRate Bytes per second of a netback rate, "BYTES,USECS" granting BYTES
+// of guest transmit credit every USECS. Netback only limits transmit.
+func vifRate(rate string) uint64 {
+	b, us, ok := strings.Cut(rate, ",")
+	if !ok {
+		return 0
+	}
+	bytes, err1 := strconv.ParseUint(b, 10, 64)
+	usecs, err2 := strconv.ParseUint(us, 10, 64)
+	if err1 != nil || err2 != nil || usecs == 0 {
+		return 0
+	}
+	return bytes * 1000000 / usecs
+}
+
+func blockIO(ops, sectors uint64) driver.BlockIO {
+	return driver.BlockIO{
+		Operations: ops,
+		Bytes:      sectors * 512,
+		Sectors:    sectors,
+		Absolute:   true,
+	}
+}
+
+// collectHostDevices PCI devices assigned through pciback, listed in its
+// xenstore backend directory. Their IDs are read from sysfs, dom0 owns them.
+func (x *Xen) collectHostDevices(id uint) []driver.HostDevice {
+	dir := fmt.Sprintf("/local/domain/0/backend/pci/%d/0", id)
+	n, _ := strconv.Atoi(x.read(dir + "/num_devs"))
+
+	var devs []driver.HostDevice
+	for i := 0; i < n; i++ {
+		addr := x.read(fmt.Sprintf("%s/dev-%d", dir, i))
+		if addr == "" {
+			continue
+		}
+		dev := driver.HostDevice{Type: "pci", Address: addr}
+		vendor, verr := os.ReadFile("/sys/bus/pci/devices/" + addr + "/vendor")
+		product, perr := os.ReadFile("/sys/bus/pci/devices/" + addr + "/device")
+		if verr == nil && perr == nil {
+			dev.Name = strings.TrimPrefix(strings.TrimSpace(string(vendor)), "0x") + ":" + strings.TrimPrefix(strings.TrimSpace(string(product)), "0x")
+		}
+		devs = append(devs, dev)
+	}
+	return devs
+}
+
+// collectInterfaces Interfaces named after their vif in dom0, which is
+// their host device unless renamed with vifname
+func (x *Xen) collectInterfaces(id uint, vifs []vif, limits bool) []driver.NetworkInterface {
+	ifaces := make([]driver.NetworkInterface, 0, len(vifs))
+	for _, v := range vifs {
+		dir := fmt.Sprintf("/local/domain/0/backend/vif/%d/%d", id, v.id)
+		iface := driver.NetworkInterface{
+			Name: fmt.Sprintf("vif%d.%d", id, v.id),
+			RX:   v.rx,
+			TX:   v.tx,
+		}
+		iface.HostDevice = iface.Name
+		if name := x.read(dir + "/vifname"); name != "" {
+			iface.HostDevice = name
+		}
+		if mac, err := net.ParseMAC(x.read(dir + "/mac")); err == nil {
+			iface.Mac = mac
+		}
+		iface.Bridges = driver.HostBridges(iface.HostDevice)
+		if bridge := x.read(dir + "/bridge"); len(iface.Bridges) == 0 && bridge != "" {
+			iface.Bridges = []string{bridge}
+		}
+		if limits {
+			iface.OutboundLimit = vifRate(x.read(dir + "/rate"))
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces
+}
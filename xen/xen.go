@@ -0,0 +1,327 @@
+//go:build xen
+
+package xen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/virtmonitor/driver"
+)
+
+// Hypervisor Hypervisor name reported by the Xen driver
+const Hypervisor driver.DomainHypervisor = "xen"
+
+func init() {
+	if err := driver.RegisterDriver(string(Hypervisor), New()); err != nil {
+		panic(err)
+	}
+	if err := driver.RegisterFactory(string(Hypervisor), driver.Factory{
+		New: func(driver.Config) (driver.Driver, error) { return New(), nil },
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// Xen Xen driver
+type Xen struct {
+	mu   sync.Mutex
+	stat *xenstat
+	xs   *xenstore
+}
+
+// New Create a Xen driver, libxenstat and xenstore are opened on first use
+func New() *Xen {
+	return &Xen{}
+}
+
+// Name Hypervisor name
+func (x *Xen) Name() driver.DomainHypervisor {
+	return Hypervisor
+}
+
+// Capabilities Supported metrics. libxenstat has no vCPU placement or guest
+// memory statistics, memory is limited to the current allocation.
+func (x *Xen) Capabilities() driver.Capabilities {
+	return driver.Capabilities{
+		SupportsCPUs:          true,
+		SupportsBlocks:        true,
+		SupportsInterfaces:    true,
+		SupportsMemory:        true,
+		SupportsBlockCapacity: true,
+		SupportsLimits:        true,
+		SupportsHostDevices:   true,
+	}
+}
+
+// Detect Test for the Xen proc interface or a xenstored socket, then for a
+// working libxenstat
+func (x *Xen) Detect() bool {
+	return x.Diagnose().Detected
+}
+
+// Diagnose Tell a host without Xen from one where libxenstat can't be
+// initialized
+func (x *Xen) Diagnose() driver.DetectResult {
+	present := false
+	for _, path := range append([]string{"/proc/xen"}, xenstoreSockets...) {
+		if _, err := os.Stat(path); err == nil {
+			present = true
+			break
+		}
+	}
+	if !present {
+		return driver.DetectResult{Reason: "no /proc/xen or xenstored socket found"}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if err := x.open(); err != nil {
+		return driver.DetectResult{Reason: "Xen present but libxenstat unavailable: " + err.Error(), Err: err}
+	}
+	if x.xs == nil {
+		return driver.DetectResult{Detected: true, Reason: "libxenstat initialized, xenstore unavailable"}
+	}
+	return driver.DetectResult{Detected: true, Reason: "libxenstat and xenstore connected"}
+}
+
+// Collect Collect domains
+func (x *Xen) Collect(opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	return x.CollectContext(context.Background(), opts)
+}
+
+// CollectContext Collect domains. libxenstat gathers every domain in a
+// single call which can't be interrupted, ctx is checked around it.
+func (x *Xen) CollectContext(ctx context.Context, opts driver.CollectOptions) (map[driver.DomainID]*driver.Domain, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := x.open(); err != nil {
+		return nil, err
+	}
+
+	node, err := driver.Enumerate(ctx, opts, x.stat.snapshot(opts))
+	if err != nil {
+		return nil, err
+	}
+	defer freeNode(node)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	doms := domains(node)
+	out := make(map[driver.DomainID]*driver.Domain, len(doms))
+	var errs []error
+	for _, dom := range doms {
+		start := time.Now()
+		d := x.collectDomain(dom, opts)
+		if d == nil || !opts.KeepState(d.Flags) {
+			continue
+		}
+		d.CollectDuration = time.Since(start)
+		if err := driver.AddDomain(out, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+// CollectDomain Collect a single domain by domid
+func (x *Xen) CollectDomain(id driver.DomainID, opts driver.CollectOptions) (*driver.Domain, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if err := x.open(); err != nil {
+		return nil, err
+	}
+	if id > math.MaxUint32 {
+		return nil, fmt.Errorf("xen: domain %d: %w", id, driver.ErrDomainNotFound)
+	}
+	opts = opts.Unfiltered()
+
+	node, err := x.stat.node(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer freeNode(node)
+
+	dom := domainByID(node, uint32(id))
+	if dom == nil {
+		return nil, fmt.Errorf("xen: domain %d: %w", id, driver.ErrDomainNotFound)
+	}
+	return x.collectDomain(dom, opts), nil
+}
+
+// CollectDomainByUUID Collect a single domain by UUID, scanning every domain
+func (x *Xen) CollectDomainByUUID(uuid string, opts driver.CollectOptions) (*driver.Domain, error) {
+	uuid, err := driver.NormalizeUUID(uuid)
+	if err != nil {
+		return nil, fmt.Errorf("xen: %q: %w", uuid, driver.ErrInvalidUUID)
+	}
+
+	d, err := x.find(opts, func(d *driver.Domain) bool { return strings.EqualFold(d.UUID, uuid) })
+	if err == nil && d == nil {
+		err = fmt.Errorf("xen: domain %s: %w", uuid, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// CollectDomainByName Collect a single domain by name, scanning every domain
+func (x *Xen) CollectDomainByName(name string, opts driver.CollectOptions) (*driver.Domain, error) {
+	d, err := x.find(opts, func(d *driver.Domain) bool { return d.Name == name })
+	if err == nil && d == nil {
+		err = fmt.Errorf("xen: domain %q: %w", name, driver.ErrDomainNotFound)
+	}
+	return d, err
+}
+
+// find First domain accepted by match, or nil if none is. Identity is
+// checked before the matching domain is collected in full.
+func (x *Xen) find(opts driver.CollectOptions, match func(*driver.Domain) bool) (*driver.Domain, error) {
+	opts = opts.Unfiltered()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if err := x.open(); err != nil {
+		return nil, err
+	}
+
+	node, err := x.stat.node(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer freeNode(node)
+
+	for _, dom := range domains(node) {
+		if match(x.collectDomain(dom, driver.CollectOptions{})) {
+			return x.collectDomain(dom, opts), nil
+		}
+	}
+	return nil, nil
+}
+
+// Host Physical host metrics from the hypervisor, the load average is
+// dom0's as Xen keeps none
+func (x *Xen) Host() (*driver.HostInfo, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if err := x.open(); err != nil {
+		return nil, err
+	}
+
+	h, err := driver.LocalHostInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := x.stat.node(driver.CollectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer freeNode(node)
+
+	nodeInfo(node, h)
+	return h, nil
+}
+
+// Ping Query the domain list from the hypervisor, the cheapest libxenstat
+// round trip
+func (x *Xen) Ping(ctx context.Context) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := x.open(); err != nil {
+		return err
+	}
+
+	node, err := x.stat.node(driver.CollectOptions{})
+	if err != nil {
+		return err
+	}
+	freeNode(node)
+	return nil
+}
+
+// CollectSnapshots Xen has no domain snapshots
+func (x *Xen) CollectSnapshots(id driver.DomainID) ([]driver.Snapshot, error) {
+	return nil, fmt.Errorf("xen: snapshots: %w", driver.ErrNotSupported)
+}
+
+// Watch Not supported, xenstore watches don't cover pause and crash
+func (x *Xen) Watch(ctx context.Context) (<-chan driver.DomainEvent, error) {
+	return nil, fmt.Errorf("xen: watch: %w", driver.ErrNotSupported)
+}
+
+// Close Release libxenstat and the xenstore connection
+func (x *Xen) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.stat != nil {
+		x.stat.close()
+		x.stat = nil
+	}
+	if x.xs != nil {
+		err := x.xs.close()
+		x.xs = nil
+		if err != nil {
+			return fmt.Errorf("xen: close xenstore: %w", err)
+		}
+	}
+	return nil
+}
+
+// open Open libxenstat and xenstore unless already open, x.mu must be held.
+// Without xenstore domains lack their UUID and device details.
+func (x *Xen) open() error {
+	if x.stat == nil {
+		stat, err := openXenstat()
+		if err != nil {
+			return err
+		}
+		x.stat = stat
+	}
+	if x.xs == nil {
+		xs, err := openXenstore()
+		if err != nil {
+			driver.GetLogger().Debug("xenstore unavailable, domains lack UUIDs and device details", "driver", Hypervisor, "error", err)
+		}
+		x.xs = xs
+	}
+	return nil
+}
+
+// read Read a xenstore value, empty when missing, refused or xenstore is
+// unavailable. A failed connection is dropped to be reopened by the next
+// collection, x.mu must be held.
+func (x *Xen) read(path string) string {
+	if x.xs == nil {
+		return ""
+	}
+
+	value, err := x.xs.read(path)
+	if err != nil {
+		var reply *xsErr
+		if !errors.As(err, &reply) {
+			driver.GetLogger().Debug("dropped xenstore connection", "driver", Hypervisor, "error", err)
+			x.xs.close()
+			x.xs = nil
+		}
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+//go:build xen
+
+package xen
+
+/*
+#cgo LDFLAGS: -lxenstat
+#include <xenstat.h>
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/virtmonitor/driver"
+)
+
+// xenstat Handle to libxenstat, not safe for concurrent use
+type xenstat struct {
+	h *C.xenstat_handle
+}
+
+func openXenstat() (*xenstat, error) {
+	h, err := C.xenstat_init()
+	if h == nil {
+		if errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EPERM) {
+			return nil, fmt.Errorf("xen: xenstat_init: %w: %w", driver.ErrPermissionDenied, err)
+		}
+		return nil, fmt.Errorf("xen: xenstat_init: %w", driver.ErrHypervisorUnavailable)
+	}
+	return &xenstat{h: h}, nil
+}
+
+// node Snapshot of the host and its domains, collecting only the requested
+// statistics. Must be freed.
+func (x *xenstat) node(opts driver.CollectOptions) (*C.xenstat_node, error) {
+	var flags C.uint
+	if opts.CPUs {
+		flags |= C.XENSTAT_VCPU
+	}
+	if opts.Blocks {
+		flags |= C.XENSTAT_VBD
+	}
+	if opts.Interfaces {
+		flags |= C.XENSTAT_NETWORK
+	}
+
+	node := C.xenstat_get_node(x.h, flags)
+	if node == nil {
+		return nil, fmt.Errorf("xen: xenstat_get_node: %w", driver.ErrHypervisorUnavailable)
+	}
+	return node, nil
+}
+
+// snapshot node of opts for driver.Enumerate, libxenstat can't be
+// cancelled
+func (x *xenstat) snapshot(opts driver.CollectOptions) func(context.Context) (*C.xenstat_node, error) {
+	return func(context.Context) (*C.xenstat_node, error) {
+		return x.node(opts)
+	}
+}
+
+func freeNode(node *C.xenstat_node) {
+	C.xenstat_free_node(node)
+}
+
+func (x *xenstat) close() {
+	C.xenstat_uninit(x.h)
+}
+
+// domains Every domain of a node
+func domains(node *C.xenstat_node) []*C.xenstat_domain {
+	n := C.xenstat_node_num_domains(node)
+	doms := make([]*C.xenstat_domain, 0, int(n))
+	for i := C.uint(0); i < n; i++ {
+		if dom := C.xenstat_node_domain_by_index(node, i); dom != nil {
+			doms = append(doms, dom)
+		}
+	}
+	return doms
+}
+
+// vbd Block device counters, in 512 byte sectors
+type vbd struct {
+	dev                       uint
+	readReqs, writeReqs       uint64
+	readSectors, writeSectors uint64
+}
+
+func vbds(dom *C.xenstat_domain) []vbd {
+	n := C.xenstat_domain_num_vbds(dom)
+	out := make([]vbd, 0, int(n))
+	for i := C.uint(0); i < n; i++ {
+		v := C.xenstat_domain_vbd(dom, i)
+		if v == nil {
+			continue
+		}
+		out = append(out, vbd{
+			dev:          uint(C.xenstat_vbd_dev(v)),
+			readReqs:     uint64(C.xenstat_vbd_rd_reqs(v)),
+			writeReqs:    uint64(C.xenstat_vbd_wr_reqs(v)),
+			readSectors:  uint64(C.xenstat_vbd_rd_sects(v)),
+			writeSectors: uint64(C.xenstat_vbd_wr_sects(v)),
+		})
+	}
+	return out
+}
+
+// vif Network interface counters, from the domain's point of view
+type vif struct {
+	id     uint
+	rx, tx driver.NetworkIO
+}
+
+func vifs(dom *C.xenstat_domain) []vif {
+	n := C.xenstat_domain_num_networks(dom)
+	out := make([]vif, 0, int(n))
+	for i := C.uint(0); i < n; i++ {
+		net := C.xenstat_domain_network(dom, i)
+		if net == nil {
+			continue
+		}
+		out = append(out, vif{
+			id: uint(C.xenstat_network_id(net)),
+			rx: driver.NetworkIO{
+				Bytes:   uint64(C.xenstat_network_rbytes(net)),
+				Packets: uint64(C.xenstat_network_rpackets(net)),
+				Errors:  uint64(C.xenstat_network_rerrs(net)),
+				Drops:   uint64(C.xenstat_network_rdrop(net)),
+			},
+			tx: driver.NetworkIO{
+				Bytes:   uint64(C.xenstat_network_tbytes(net)),
+				Packets: uint64(C.xenstat_network_tpackets(net)),
+				Errors:  uint64(C.xenstat_network_terrs(net)),
+				Drops:   uint64(C.xenstat_network_tdrop(net)),
+			},
+		})
+	}
+	return out
+}
+
+// domainByID Domain of a node by domid, nil if there is none
+func domainByID(node *C.xenstat_node, id uint32) *C.xenstat_domain {
+	return C.xenstat_node_domain(node, C.uint(id))
+}
+
+// nodeInfo Fill in the physical CPUs and memory of the host
+func nodeInfo(node *C.xenstat_node, h *driver.HostInfo) {
+	h.CPUs = int(C.xenstat_node_num_cpus(node))
+	h.MemoryTotal = uint64(C.xenstat_node_tot_mem(node))
+	h.MemoryFree = uint64(C.xenstat_node_free_mem(node))
+}
@@ -0,0 +1,106 @@
+//go:build xen
+
+package xen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// xenstore wire message types, from xen/io/xs_wire.h
+const (
+	xsRead  = 2
+	xsError = 16
+)
+
+// xenstoreSockets Sockets of xenstored, tried before the xenbus device
+var xenstoreSockets = []string{"/var/run/xenstored/socket", "/run/xenstored/socket"}
+
+// xenbusDevice Kernel device tunnelling xenstore requests
+const xenbusDevice = "/dev/xen/xenbus"
+
+// xsErr Error reply from xenstored such as ENOENT, the connection remains
+// usable
+type xsErr struct {
+	Path  string
+	Errno string
+}
+
+func (e *xsErr) Error() string {
+	return "xen: xenstore read " + e.Path + ": " + e.Errno
+}
+
+// xenstore Minimal xenstore client, requests are serialized
+type xenstore struct {
+	mu    sync.Mutex
+	rw    io.ReadWriteCloser
+	reqID uint32
+}
+
+// xsHeader Header preceding every xenstore message
+type xsHeader struct {
+	Type  uint32
+	ReqID uint32
+	TxID  uint32
+	Len   uint32
+}
+
+// openXenstore Connect to xenstored, or through xenbus when it isn't local
+func openXenstore() (*xenstore, error) {
+	for _, path := range xenstoreSockets {
+		if conn, err := net.Dial("unix", path); err == nil {
+			return &xenstore{rw: conn}, nil
+		}
+	}
+
+	f, err := os.OpenFile(xenbusDevice, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &xenstore{rw: f}, nil
+}
+
+// read Read the value at path
+func (x *xenstore) read(path string) (string, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.reqID++
+	payload := append([]byte(path), 0)
+	req := xsHeader{Type: xsRead, ReqID: x.reqID, Len: uint32(len(payload))}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, req)
+	buf.Write(payload)
+	if _, err := x.rw.Write(buf.Bytes()); err != nil {
+		return "", err
+	}
+
+	var resp xsHeader
+	if err := binary.Read(x.rw, binary.LittleEndian, &resp); err != nil {
+		return "", err
+	}
+	body := make([]byte, resp.Len)
+	if _, err := io.ReadFull(x.rw, body); err != nil {
+		return "", err
+	}
+	value := strings.TrimRight(string(body), "\x00")
+
+	switch {
+	case resp.ReqID != req.ReqID:
+		return "", fmt.Errorf("xen: xenstore read %s: reply out of order", path)
+	case resp.Type == xsError:
+		return "", &xsErr{Path: path, Errno: value}
+	}
+	return value, nil
+}
+
+func (x *xenstore) close() error {
+	return x.rw.Close()
+}